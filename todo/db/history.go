@@ -0,0 +1,177 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// MaxItemHistory is how many changes are kept for each item; the oldest
+// are dropped first.
+const MaxItemHistory = 50
+
+// The Field of the changes that add or delete a whole item.
+const HistoryItemField = "item"
+
+// Change is a change of a field of an item.  Adding an item is a change of
+// HistoryItemField from nothing to the item, and deleting it a change from
+// the item to nothing.
+type Change struct {
+	At       time.Time   `json:"at"`
+	Field    string      `json:"field"`
+	OldValue interface{} `json:"oldValue"`
+	NewValue interface{} `json:"newValue"`
+}
+
+// GetItemHistory returns the changes of an item, oldest first.  The
+// history of an item is kept after it is deleted, so its changes can still
+// be seen.
+// Preconditions:
+// (1) The database file must exist and be a valid
+// (2) The item must exist in the DB or have a history
+//
+// Postconditions:
+// (1) The changes of the item will be returned, if it has any
+// (2) If there is an error, it will be returned
+// (3) The database file will not be modified
+func (t *ToDo) GetItemHistory(id int) ([]Change, error) {
+	history, err := t.loadHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	if changes, ok := history[id]; ok {
+		return changes, nil
+	}
+
+	// An item added before the history existed has none yet.
+	if _, err := t.GetItem(id); err != nil {
+		return nil, err
+	}
+
+	return []Change{}, nil
+}
+
+// PrintItemHistory prints the changes of an item to the console, one per
+// line.
+func (t *ToDo) PrintItemHistory(changes []Change) {
+	for _, change := range changes {
+		switch {
+		case change.Field == HistoryItemField && change.OldValue == nil:
+			fmt.Printf("%s added\n", change.At.Local().Format(time.RFC3339))
+		case change.Field == HistoryItemField && change.NewValue == nil:
+			fmt.Printf("%s deleted\n", change.At.Local().Format(time.RFC3339))
+		default:
+			fmt.Printf("%s %s: %s -> %s\n", change.At.Local().Format(time.RFC3339), change.Field, historyValue(change.OldValue), historyValue(change.NewValue))
+		}
+	}
+}
+
+// historyValue formats a value of a change as JSON, like the items are.
+func historyValue(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// diffItems returns the changes from old to new, which are nil when the
+// item is added or deleted.  UpdatedAt is the time of the changes, not a
+// change itself.
+func diffItems(old, new *ToDoItem, at time.Time) []Change {
+	switch {
+	case old == nil && new == nil:
+		return nil
+	case old == nil:
+		return []Change{{At: at, Field: HistoryItemField, NewValue: *new}}
+	case new == nil:
+		return []Change{{At: at, Field: HistoryItemField, OldValue: *old}}
+	}
+
+	var changes []Change
+	if old.Title != new.Title {
+		changes = append(changes, Change{At: at, Field: "title", OldValue: old.Title, NewValue: new.Title})
+	}
+	if old.IsDone != new.IsDone {
+		changes = append(changes, Change{At: at, Field: "done", OldValue: old.IsDone, NewValue: new.IsDone})
+	}
+	if !sameTime(old.DueDate, new.DueDate) {
+		changes = append(changes, Change{At: at, Field: "dueDate", OldValue: old.DueDate, NewValue: new.DueDate})
+	}
+
+	return changes
+}
+
+// recordHistory adds the changes of items to their history.  The items are
+// already saved, so a history that cannot be saved is reported on stderr
+// instead of failing the change.
+func (t *ToDo) recordHistory(changes map[int][]Change) {
+	empty := true
+	for _, itemChanges := range changes {
+		if len(itemChanges) > 0 {
+			empty = false
+		}
+	}
+	if empty {
+		return
+	}
+
+	if err := t.addHistory(changes); err != nil {
+		fmt.Fprintln(os.Stderr, "Error saving the history of the items: ", err)
+	}
+}
+
+func (t *ToDo) addHistory(changes map[int][]Change) error {
+	history, err := t.loadHistory()
+	if err != nil {
+		return err
+	}
+
+	for id, itemChanges := range changes {
+		itemHistory := append(history[id], itemChanges...)
+		if len(itemHistory) > MaxItemHistory {
+			itemHistory = itemHistory[len(itemHistory)-MaxItemHistory:]
+		}
+		if len(itemHistory) > 0 {
+			history[id] = itemHistory
+		}
+	}
+
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(t.historyFileName(), data, 0644)
+}
+
+// historyFileName returns the file that keeps the history of the items,
+// todo.history.json for todo.json.
+func (t *ToDo) historyFileName() string {
+	return strings.TrimSuffix(t.dbFileName, filepath.Ext(t.dbFileName)) + ".history.json"
+}
+
+// loadHistory reads the history of every item, which is empty before the
+// first change.
+func (t *ToDo) loadHistory() (map[int][]Change, error) {
+	history := make(map[int][]Change)
+
+	data, err := os.ReadFile(t.historyFileName())
+	if errors.Is(err, os.ErrNotExist) {
+		return history, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", t.historyFileName(), err)
+	}
+
+	return history, nil
+}
@@ -0,0 +1,434 @@
+package db
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Remote is a todo list that Sync keeps in step with the local DB, such
+// as a todo REST API or the DB file of another machine.
+type Remote interface {
+	// Name identifies the remote in the sync state, such as its URL.
+	Name() string
+	GetAllItems() ([]ToDoItem, error)
+	AddItem(item ToDoItem) error
+	UpdateItem(item ToDoItem) error
+	DeleteItem(id int) error
+}
+
+// NewRemote returns the remote of a -sync option: the URL of a todo REST
+// API, or the name of another DB file.
+func NewRemote(spec string) (Remote, error) {
+	switch {
+	case spec == "":
+		return nil, errors.New("sync needs a URL or a DB file")
+	case strings.HasPrefix(spec, "http://"), strings.HasPrefix(spec, "https://"):
+		return &RESTRemote{URL: strings.TrimSuffix(spec, "/")}, nil
+	}
+
+	return NewFileRemote(spec)
+}
+
+// RESTRemote is a todo REST API, which lists the items with GET /todo,
+// adds one with POST /todo, updates one with PUT /todo and deletes one
+// with DELETE /todo/:id, all in the JSON of ToDoItem.
+type RESTRemote struct {
+	URL    string
+	Client *http.Client
+}
+
+func (r *RESTRemote) Name() string {
+	return r.URL
+}
+
+func (r *RESTRemote) GetAllItems() ([]ToDoItem, error) {
+	var items []ToDoItem
+	err := r.do(http.MethodGet, "/todo", nil, &items)
+	return items, err
+}
+
+func (r *RESTRemote) AddItem(item ToDoItem) error {
+	return r.do(http.MethodPost, "/todo", item, nil)
+}
+
+func (r *RESTRemote) UpdateItem(item ToDoItem) error {
+	return r.do(http.MethodPut, "/todo", item, nil)
+}
+
+func (r *RESTRemote) DeleteItem(id int) error {
+	return r.do(http.MethodDelete, fmt.Sprintf("/todo/%d", id), nil, nil)
+}
+
+// do sends a request with the JSON of in, if any, and reads the JSON of
+// the response into out, if any.  Responses other than 2xx are errors.
+func (r *RESTRemote) do(method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, r.URL+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	client := r.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s answered %s: %s", method, r.URL+path, resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// FileRemote is another DB file, such as one in a folder that is shared
+// between machines.  Unlike the ToDo methods it stores the items as they
+// are, keeping their UpdatedAt.
+type FileRemote struct {
+	todo *ToDo
+}
+
+// NewFileRemote returns the remote of a DB file, which is created if it
+// doesn't exist.
+func NewFileRemote(dbFile string) (*FileRemote, error) {
+	todo, err := New(dbFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileRemote{todo: todo}, nil
+}
+
+func (r *FileRemote) Name() string {
+	return r.todo.dbFileName
+}
+
+func (r *FileRemote) GetAllItems() ([]ToDoItem, error) {
+	return r.todo.GetAllItems()
+}
+
+func (r *FileRemote) AddItem(item ToDoItem) error {
+	return r.todo.storeItems([]ToDoItem{item}, nil)
+}
+
+func (r *FileRemote) UpdateItem(item ToDoItem) error {
+	return r.todo.storeItems([]ToDoItem{item}, nil)
+}
+
+func (r *FileRemote) DeleteItem(id int) error {
+	return r.todo.storeItems(nil, []int{id})
+}
+
+// SyncReport says what a sync did, or would do when it is a dry run.
+// The lists hold the ids of the items.
+type SyncReport struct {
+	Remote string    `json:"remote"`
+	At     time.Time `json:"at"`
+	// When the DB was last synced with the remote, nil for the first sync.
+	LastSync *time.Time `json:"lastSync"`
+	DryRun   bool       `json:"dryRun"`
+	// Copied from the DB to the remote.
+	Pushed []int `json:"pushed"`
+	// Copied from the remote to the DB.
+	Pulled []int `json:"pulled"`
+	// Deleted from the DB because they were deleted from the remote.
+	DeletedLocal []int `json:"deletedLocal"`
+	// Deleted from the remote because they were deleted from the DB.
+	DeletedRemote []int `json:"deletedRemote"`
+	// Changed in both since the last sync; the copy updated last won.
+	Conflicts []int `json:"conflicts"`
+	Unchanged int   `json:"unchanged"`
+}
+
+// syncState is what the DB knew of a remote after the last sync.
+type syncState struct {
+	LastSync time.Time `json:"lastSync"`
+	// The items that were in both when the sync ended.
+	IDs []int `json:"ids"`
+}
+
+// Sync makes the DB and the remote hold the same items, and returns a
+// report of what it changed.  Where both hold an item the copy with the
+// latest UpdatedAt wins, and an item that only one of them holds is
+// copied to the other, unless it was deleted from the other since the
+// last sync and not updated since, in which case it is deleted.  What
+// each remote held at the last sync is kept next to the DB file, in
+// todo.sync.json for todo.json.  A dry run only reports what a sync would
+// do.
+func (t *ToDo) Sync(remote Remote, dryRun bool) (SyncReport, error) {
+	report := SyncReport{
+		Remote:        remote.Name(),
+		At:            time.Now().UTC(),
+		DryRun:        dryRun,
+		Pushed:        []int{},
+		Pulled:        []int{},
+		DeletedLocal:  []int{},
+		DeletedRemote: []int{},
+		Conflicts:     []int{},
+	}
+
+	states, err := t.loadSyncState()
+	if err != nil {
+		return report, err
+	}
+	state, synced := states[remote.Name()]
+	if synced {
+		lastSync := state.LastSync
+		report.LastSync = &lastSync
+	}
+	known := make(map[int]bool, len(state.IDs))
+	for _, id := range state.IDs {
+		known[id] = true
+	}
+
+	localItems, err := t.GetAllItems()
+	if err != nil {
+		return report, err
+	}
+	remoteItems, err := remote.GetAllItems()
+	if err != nil {
+		return report, err
+	}
+
+	local := make(DbMap, len(localItems))
+	for _, item := range localItems {
+		local[item.Id] = item
+	}
+	remoteMap := make(DbMap, len(remoteItems))
+	for _, item := range remoteItems {
+		remoteMap[item.Id] = item
+	}
+
+	ids := make([]int, 0, len(local)+len(remoteMap))
+	for id := range local {
+		ids = append(ids, id)
+	}
+	for id := range remoteMap {
+		if _, ok := local[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+
+	// changedSince is whether an item was updated after the last sync.
+	changedSince := func(item ToDoItem) bool {
+		return !synced || updatedAt(item).After(state.LastSync)
+	}
+
+	var toAdd, toUpdate, toPull []ToDoItem
+	var toDeleteLocal []int
+	for _, id := range ids {
+		localItem, inLocal := local[id]
+		remoteItem, inRemote := remoteMap[id]
+
+		switch {
+		case inLocal && inRemote:
+			if sameItem(localItem, remoteItem) {
+				report.Unchanged++
+				continue
+			}
+			if changedSince(localItem) && changedSince(remoteItem) {
+				report.Conflicts = append(report.Conflicts, id)
+			}
+			// On a tie, such as two items that were never
+			// updated, the DB wins.
+			if updatedAt(remoteItem).After(updatedAt(localItem)) {
+				toPull = append(toPull, remoteItem)
+				report.Pulled = append(report.Pulled, id)
+			} else {
+				toUpdate = append(toUpdate, localItem)
+				report.Pushed = append(report.Pushed, id)
+			}
+		case inLocal:
+			if known[id] && !changedSince(localItem) {
+				toDeleteLocal = append(toDeleteLocal, id)
+				report.DeletedLocal = append(report.DeletedLocal, id)
+			} else {
+				toAdd = append(toAdd, localItem)
+				report.Pushed = append(report.Pushed, id)
+			}
+		default:
+			if known[id] && !changedSince(remoteItem) {
+				report.DeletedRemote = append(report.DeletedRemote, id)
+			} else {
+				toPull = append(toPull, remoteItem)
+				report.Pulled = append(report.Pulled, id)
+			}
+		}
+	}
+
+	if dryRun {
+		return report, nil
+	}
+
+	// The remote is changed first, so that a sync that fails part way
+	// leaves the DB as it was and can be run again.
+	for _, item := range toAdd {
+		if err := remote.AddItem(item); err != nil {
+			return report, fmt.Errorf("pushing item %d: %w", item.Id, err)
+		}
+	}
+	for _, item := range toUpdate {
+		if err := remote.UpdateItem(item); err != nil {
+			return report, fmt.Errorf("pushing item %d: %w", item.Id, err)
+		}
+	}
+	for _, id := range report.DeletedRemote {
+		if err := remote.DeleteItem(id); err != nil {
+			return report, fmt.Errorf("deleting item %d from the remote: %w", id, err)
+		}
+	}
+
+	if err := t.storeItems(toPull, toDeleteLocal); err != nil {
+		return report, err
+	}
+
+	deleted := make(map[int]bool, len(toDeleteLocal)+len(report.DeletedRemote))
+	for _, id := range toDeleteLocal {
+		deleted[id] = true
+	}
+	for _, id := range report.DeletedRemote {
+		deleted[id] = true
+	}
+	state = syncState{LastSync: report.At, IDs: []int{}}
+	for _, id := range ids {
+		if !deleted[id] {
+			state.IDs = append(state.IDs, id)
+		}
+	}
+	states[remote.Name()] = state
+
+	return report, t.saveSyncState(states)
+}
+
+// PrintSyncReport prints a sync report to the console in a JSON pretty
+// format.
+func (t *ToDo) PrintSyncReport(report SyncReport) {
+	jsonBytes, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Println(string(jsonBytes))
+}
+
+// updatedAt returns the UpdatedAt of an item, the zero time if it was
+// never updated.
+func updatedAt(item ToDoItem) time.Time {
+	if item.UpdatedAt == nil {
+		return time.Time{}
+	}
+	return *item.UpdatedAt
+}
+
+// sameItem is whether two copies of an item are the same.
+func sameItem(a, b ToDoItem) bool {
+	return a.Id == b.Id && a.Title == b.Title && a.IsDone == b.IsDone &&
+		sameTime(a.DueDate, b.DueDate) && sameTime(a.UpdatedAt, b.UpdatedAt)
+}
+
+// sameTime is whether two optional times are the same.
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// storeItems writes and deletes items as they are, without the checks
+// and the UpdatedAt of AddItem, UpdateItem and DeleteItem.
+func (t *ToDo) storeItems(put []ToDoItem, deleteIDs []int) error {
+	if len(put) == 0 && len(deleteIDs) == 0 {
+		return nil
+	}
+
+	if err := t.loadDB(); err != nil {
+		return err
+	}
+
+	at := time.Now().UTC()
+	changes := make(map[int][]Change, len(put)+len(deleteIDs))
+	for _, item := range put {
+		item := item
+		var old *ToDoItem
+		if stored, exists := t.toDoMap[item.Id]; exists {
+			old = &stored
+		}
+		changes[item.Id] = append(changes[item.Id], diffItems(old, &item, at)...)
+		t.toDoMap[item.Id] = item
+	}
+	for _, id := range deleteIDs {
+		if stored, exists := t.toDoMap[id]; exists {
+			changes[id] = append(changes[id], diffItems(&stored, nil, at)...)
+			delete(t.toDoMap, id)
+		}
+	}
+
+	if err := t.saveDB(); err != nil {
+		return err
+	}
+
+	t.recordHistory(changes)
+
+	return nil
+}
+
+// syncStateFileName returns the file that keeps the sync state of the DB.
+func (t *ToDo) syncStateFileName() string {
+	return strings.TrimSuffix(t.dbFileName, filepath.Ext(t.dbFileName)) + ".sync.json"
+}
+
+// loadSyncState reads the sync state of every remote, which is empty
+// before the first sync.
+func (t *ToDo) loadSyncState() (map[string]syncState, error) {
+	states := make(map[string]syncState)
+
+	data, err := os.ReadFile(t.syncStateFileName())
+	if errors.Is(err, os.ErrNotExist) {
+		return states, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &states); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", t.syncStateFileName(), err)
+	}
+
+	return states, nil
+}
+
+func (t *ToDo) saveSyncState(states map[string]syncState) error {
+	data, err := json.MarshalIndent(states, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(t.syncStateFileName(), data, 0644)
+}
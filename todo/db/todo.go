@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"time"
 )
 
 // ToDoItem is the struct that represents a single ToDo item
@@ -12,6 +13,13 @@ type ToDoItem struct {
 	Id     int    `json:"id"`
 	Title  string `json:"title"`
 	IsDone bool   `json:"done"`
+	// DueDate is when the item should be done, in RFC 3339 format such
+	// as "2023-08-01T17:00:00Z".  Items without one are never due.
+	DueDate *time.Time `json:"dueDate,omitempty"`
+	// UpdatedAt is when the item was last added or updated, which Sync
+	// uses to pick the newest copy of an item.  AddItem and UpdateItem
+	// set it, so items only have one once they are changed.
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
 }
 
 // DbMap is a type alias for a map of ToDoItems.  The key
@@ -103,6 +111,7 @@ func (t *ToDo) AddItem(item ToDoItem) error {
 	}
 
 	// Add item to the map.
+	item.UpdatedAt = now()
 	t.toDoMap[item.Id] = item
 
 	// Save the item in DB.
@@ -111,6 +120,9 @@ func (t *ToDo) AddItem(item ToDoItem) error {
 		return err
 	}
 
+	// Record the new item in its history.
+	t.recordHistory(map[int][]Change{item.Id: diffItems(nil, &item, *item.UpdatedAt)})
+
 	return nil
 }
 
@@ -134,7 +146,8 @@ func (t *ToDo) DeleteItem(id int) error {
 	}
 
 	// Check if the item exists.
-	if _, exists := t.toDoMap[id]; !exists {
+	old, exists := t.toDoMap[id]
+	if !exists {
 		return errors.New("item does not exist in the database")
 	}
 
@@ -147,6 +160,9 @@ func (t *ToDo) DeleteItem(id int) error {
 		return err
 	}
 
+	// Record the deletion in the history of the item.
+	t.recordHistory(map[int][]Change{id: diffItems(&old, nil, time.Now().UTC())})
+
 	return nil
 }
 
@@ -170,11 +186,13 @@ func (t *ToDo) UpdateItem(item ToDoItem) error {
 	}
 
 	// Check if the item exists.
-	if _, exists := t.toDoMap[item.Id]; !exists {
+	old, exists := t.toDoMap[item.Id]
+	if !exists {
 		return errors.New("item does not exist in the database")
 	}
 
 	// Update item in the map.
+	item.UpdatedAt = now()
 	t.toDoMap[item.Id] = item
 
 	// Save the updated item in DB.
@@ -183,6 +201,9 @@ func (t *ToDo) UpdateItem(item ToDoItem) error {
 		return err
 	}
 
+	// Record the changed fields in the history of the item.
+	t.recordHistory(map[int][]Change{item.Id: diffItems(&old, &item, *item.UpdatedAt)})
+
 	return nil
 }
 
@@ -340,6 +361,12 @@ func initDB(dbFileName string) error {
 	return nil
 }
 
+// now returns the current time as the UpdatedAt of an item.
+func now() *time.Time {
+	updatedAt := time.Now().UTC()
+	return &updatedAt
+}
+
 func (t *ToDo) saveDB() error {
 	//1. Convert our map into a slice
 	//2. Marshal the slice into json
@@ -380,7 +407,10 @@ func (t *ToDo) loadDB() error {
 		return err
 	}
 
-	//Now let's iterate over our slice and add each item to our map
+	//Now let's iterate over our slice and add each item to our map,
+	//starting from an empty map so that items deleted from the file
+	//since the last load are gone
+	t.toDoMap = make(DbMap, len(toDoList))
 	for _, item := range toDoList {
 		t.toDoMap[item.Id] = item
 	}
@@ -0,0 +1,230 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// The states of an item that has a due date.  An item is due from Lead
+// before its due date, and overdue once the due date has passed.
+const (
+	StateDue     = "due"
+	StateOverdue = "overdue"
+)
+
+// Defaults of WatchOptions.
+const (
+	DefaultWatchInterval = time.Minute
+	DefaultWatchLead     = time.Hour
+)
+
+// DueEvent is what a Notifier is told when an item becomes due or
+// overdue.
+type DueEvent struct {
+	Item  ToDoItem  `json:"item"`
+	State string    `json:"state"`
+	At    time.Time `json:"at"`
+}
+
+// Title returns a one line summary of the event, such as
+// "ToDo 3 is overdue".
+func (e DueEvent) Title() string {
+	return fmt.Sprintf("ToDo %d is %s", e.Item.Id, e.State)
+}
+
+// Message returns the title of the item and when it is due.
+func (e DueEvent) Message() string {
+	return fmt.Sprintf("%s (due %s)", e.Item.Title, e.Item.DueDate.Local().Format(time.RFC1123))
+}
+
+// Notifier is told about the items that become due or overdue.  Watch
+// calls it again on the next check when it returns an error.
+type Notifier interface {
+	Notify(event DueEvent) error
+}
+
+// StdoutNotifier prints the events to the console.
+type StdoutNotifier struct{}
+
+func (StdoutNotifier) Notify(event DueEvent) error {
+	_, err := fmt.Printf("%s %s: %s\n", event.At.Format(time.RFC3339), event.Title(), event.Message())
+	return err
+}
+
+// CommandNotifier runs a command for every event, with the title and the
+// message of the event as its last two arguments.  With notify-send or
+// terminal-notifier it shows a desktop notification.
+type CommandNotifier struct {
+	Command string
+	Args    []string
+}
+
+func (n CommandNotifier) Notify(event DueEvent) error {
+	args := append(append([]string{}, n.Args...), event.Title(), event.Message())
+	cmd := exec.Command(n.Command, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
+
+// WebhookNotifier posts every event as JSON to a URL.
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+func (n WebhookNotifier) Notify(event DueEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	client := n.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	resp, err := client.Post(n.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook %s answered %s", n.URL, resp.Status)
+	}
+
+	return nil
+}
+
+// NewNotifier returns the notifier of a -notify option: "stdout", the
+// URL of a webhook, or "cmd:" followed by a command and its arguments,
+// such as "cmd:notify-send -u critical".
+func NewNotifier(spec string) (Notifier, error) {
+	switch {
+	case spec == "" || spec == "stdout":
+		return StdoutNotifier{}, nil
+	case strings.HasPrefix(spec, "http://"), strings.HasPrefix(spec, "https://"):
+		return WebhookNotifier{URL: spec}, nil
+	case strings.HasPrefix(spec, "cmd:"):
+		fields := strings.Fields(strings.TrimPrefix(spec, "cmd:"))
+		if len(fields) == 0 {
+			return nil, errors.New("the cmd notifier needs a command")
+		}
+		return CommandNotifier{Command: fields[0], Args: fields[1:]}, nil
+	}
+
+	return nil, fmt.Errorf("unknown notifier %q, use stdout, cmd:<command> or a webhook URL", spec)
+}
+
+// DueState returns the state of the item at now: StateOverdue once its
+// due date has passed, StateDue from lead before it, and an empty string
+// otherwise or when the item is done or has no due date.
+func (item ToDoItem) DueState(now time.Time, lead time.Duration) string {
+	switch {
+	case item.IsDone || item.DueDate == nil:
+		return ""
+	case now.After(*item.DueDate):
+		return StateOverdue
+	case !now.Before(item.DueDate.Add(-lead)):
+		return StateDue
+	}
+
+	return ""
+}
+
+// WatchOptions sets how Watch checks the items.  Zero values take the
+// defaults.
+type WatchOptions struct {
+	// How often the items are checked.
+	Interval time.Duration
+	// How long before its due date an item is due.
+	Lead time.Duration
+}
+
+// notice is the last state an item was notified in, for its due date.
+type notice struct {
+	state   string
+	dueDate time.Time
+}
+
+// Watch checks the items every Interval until ctx is done, and tells the
+// notifier about each item that became due or overdue.  Every item is
+// notified once in each state, and again when its due date changes; done
+// items are skipped.  The DB file is read again on every check, so items
+// changed by other todo commands are seen.  The first check runs at once.
+func (t *ToDo) Watch(ctx context.Context, options WatchOptions, notifier Notifier) error {
+	if options.Interval <= 0 {
+		options.Interval = DefaultWatchInterval
+	}
+	if options.Lead <= 0 {
+		options.Lead = DefaultWatchLead
+	}
+
+	notified := make(map[int]notice)
+
+	ticker := time.NewTicker(options.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := t.checkDue(time.Now(), options.Lead, notified, notifier); err != nil {
+			fmt.Fprintln(os.Stderr, "Error checking due items: ", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkDue notifies the items whose state changed since they were last
+// notified.  A failed notification is tried again on the next check.
+func (t *ToDo) checkDue(now time.Time, lead time.Duration, notified map[int]notice, notifier Notifier) error {
+	items, err := t.GetAllItems()
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[int]bool, len(items))
+	var errs []error
+	for _, item := range items {
+		seen[item.Id] = true
+
+		state := item.DueState(now, lead)
+		if state == "" {
+			delete(notified, item.Id)
+			continue
+		}
+
+		last, ok := notified[item.Id]
+		if ok && last.state == state && last.dueDate.Equal(*item.DueDate) {
+			continue
+		}
+
+		if err := notifier.Notify(DueEvent{Item: item, State: state, At: now}); err != nil {
+			errs = append(errs, fmt.Errorf("item %d: %w", item.Id, err))
+			continue
+		}
+		notified[item.Id] = notice{state: state, dueDate: *item.DueDate}
+	}
+
+	// Forget the items that were deleted.
+	for id := range notified {
+		if !seen[id] {
+			delete(notified, id)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -1,10 +1,13 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"time"
 
 	"drexel.edu/todo/db"
 )
@@ -19,6 +22,13 @@ var (
 	addFlag        string
 	updateFlag     string
 	deleteFlag     int
+	watchFlag      bool
+	intervalFlag   time.Duration
+	leadFlag       time.Duration
+	notifyFlag     string
+	syncFlag       string
+	dryRunFlag     bool
+	historyFlag    int
 )
 
 type AppOptType int
@@ -34,6 +44,9 @@ const (
 	UPDATE_DB_ITEM
 	DELETE_DB_ITEM
 	CHANGE_ITEM_STATUS
+	WATCH_DB_ITEMS
+	SYNC_DB_ITEMS
+	ITEM_HISTORY
 	NOT_IMPLEMENTED
 	INVALID_APP_OPT
 )
@@ -77,6 +90,13 @@ func processCmdLineFlags() (AppOptType, error) {
 	flag.StringVar(&updateFlag, "u", "", "Update an item in the database")
 	flag.IntVar(&deleteFlag, "d", 0, "Delete an item from the database")
 	flag.BoolVar(&itemStatusFlag, "s", false, "Change item 'done' status to true or false")
+	flag.BoolVar(&watchFlag, "w", false, "Watch the items and notify when they become due or overdue")
+	flag.DurationVar(&intervalFlag, "interval", db.DefaultWatchInterval, "How often -w checks the items")
+	flag.DurationVar(&leadFlag, "lead", db.DefaultWatchLead, "How long before its due date -w notifies that an item is due")
+	flag.StringVar(&notifyFlag, "notify", "stdout", "How -w notifies: stdout, cmd:<command> or a webhook URL")
+	flag.StringVar(&syncFlag, "sync", "", "Sync the database with a todo REST API URL or another database file")
+	flag.BoolVar(&dryRunFlag, "dry-run", false, "Report what -sync would change without changing anything")
+	flag.IntVar(&historyFlag, "history", 0, "Show the changes of an item in the database")
 
 	flag.Parse()
 
@@ -118,6 +138,15 @@ func processCmdLineFlags() (AppOptType, error) {
 			if queryFlag > 0 {
 				appOpt = CHANGE_ITEM_STATUS
 			}
+		case "w":
+			appOpt = WATCH_DB_ITEMS
+		case "sync":
+			appOpt = SYNC_DB_ITEMS
+		case "history":
+			appOpt = ITEM_HISTORY
+		// The database file and the options of -w and -sync do not
+		// choose an operation
+		case "db", "interval", "lead", "notify", "dry-run":
 		default:
 			appOpt = INVALID_APP_OPT
 		}
@@ -218,6 +247,45 @@ func main() {
 			break
 		}
 		fmt.Println("Ok")
+	case WATCH_DB_ITEMS:
+		fmt.Println("Running WATCH_DB_ITEMS...")
+		notifier, err := db.NewNotifier(notifyFlag)
+		if err != nil {
+			fmt.Println("Error: ", err)
+			break
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		options := db.WatchOptions{Interval: intervalFlag, Lead: leadFlag}
+		if err := todo.Watch(ctx, options, notifier); err != nil {
+			fmt.Println("Error: ", err)
+			break
+		}
+		fmt.Println("Ok")
+	case SYNC_DB_ITEMS:
+		fmt.Println("Running SYNC_DB_ITEMS...")
+		remote, err := db.NewRemote(syncFlag)
+		if err != nil {
+			fmt.Println("Error: ", err)
+			break
+		}
+		report, err := todo.Sync(remote, dryRunFlag)
+		todo.PrintSyncReport(report)
+		if err != nil {
+			fmt.Println("Error: ", err)
+			break
+		}
+		fmt.Println("Ok")
+	case ITEM_HISTORY:
+		fmt.Println("Running ITEM_HISTORY...")
+		changes, err := todo.GetItemHistory(historyFlag)
+		if err != nil {
+			fmt.Println("Error: ", err)
+			break
+		}
+		todo.PrintItemHistory(changes)
+		fmt.Println("THERE ARE", len(changes), "CHANGES OF THE ITEM")
+		fmt.Println("Ok")
 	default:
 		fmt.Println("INVALID_APP_OPT")
 	}
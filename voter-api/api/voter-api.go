@@ -1,9 +1,13 @@
 package api
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
 
 	"voter-api/voter"
@@ -11,46 +15,69 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// The bounds of the vote dates the voter poll requests accept, the same as
+// those of the Redis variant.
+const (
+	// The earliest vote date.
+	minVoteDate = "2000-01-01T00:00:00Z"
+	// How far in the future a vote date may be, for clock skew.
+	maxVoteDateSkew = 5 * time.Minute
+)
+
 // The API handler that handles incoming requests.
 type VoterAPI struct {
 	voterList        *voter.VoterList
+	metricsLock      sync.Mutex
 	totalCalls       uint64
 	errorCalls       uint64
 	bootTime         time.Time
 	totalRequestTime time.Duration
 }
 
-// Create a new instance of VoterAPI with an initialized voter list.
-func NewVoterHandler() *VoterAPI {
+// Create a new instance of VoterAPI with an initialized voter list. The
+// voters are kept in dbFile, or only in memory when it is empty.
+func NewVoterHandler(dbFile string) (*VoterAPI, error) {
+	voterList := voter.NewVoterList()
+	if dbFile != "" {
+		var err error
+		if voterList, err = voter.NewVoterListFromFile(dbFile); err != nil {
+			return nil, err
+		}
+	}
+
 	return &VoterAPI{
-		voterList:        voter.NewVoterList(),
+		voterList:        voterList,
 		totalCalls:       0,
 		errorCalls:       0,
 		bootTime:         time.Now(),
 		totalRequestTime: 0,
-	}
+	}, nil
 }
 
 // The custom middleware to handle health metadata.
 func HealthMiddleware(va *VoterAPI) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Update total API calls count.
-		va.totalCalls++
-
 		// Record the start time of the request.
 		start := time.Now()
 
 		// Process the request.
 		c.Next()
 
+		// Calculate the request duration.
+		duration := time.Since(start)
+
+		// Handlers run concurrently, so the metrics are updated under a lock.
+		va.metricsLock.Lock()
+		defer va.metricsLock.Unlock()
+
+		// Update total API calls count.
+		va.totalCalls++
+
 		// Update error API calls count if there's an error.
 		if c.Writer.Status() >= 400 {
 			va.errorCalls++
 		}
 
-		// Calculate the request duration.
-		duration := time.Since(start)
-
 		// Update the total request time.
 		va.totalRequestTime += duration
 	}
@@ -63,16 +90,101 @@ func (va *VoterAPI) WelcomeToVoterAPI(c *gin.Context) {
 	})
 }
 
+// Return the response of a voter, with the links of the Redis variant.
+func voterResponse(voter voter.Voter) map[string]interface{} {
+	return map[string]interface{}{
+		"voterId":     voter.VoterID,
+		"firstName":   voter.FirstName,
+		"lastName":    voter.LastName,
+		"voteHistory": voter.VoteHistory,
+		"links": map[string]interface{}{
+			"get": map[string]interface{}{
+				"method": "GET",
+				"url":    fmt.Sprintf("/voters/%d", voter.VoterID),
+			},
+			"update": map[string]interface{}{
+				"method": "PUT",
+				"url":    fmt.Sprintf("/voters/%d", voter.VoterID),
+			},
+			"delete": map[string]interface{}{
+				"method": "DELETE",
+				"url":    fmt.Sprintf("/voters/%d", voter.VoterID),
+			},
+		},
+	}
+}
+
+// Return the response of a poll in the history of a voter, with the links
+// of the Redis variant.
+func voterPollResponse(voterID uint, pollID uint, voteDate time.Time) map[string]interface{} {
+	link := fmt.Sprintf("/voters/%d/polls/%d", voterID, pollID)
+
+	return map[string]interface{}{
+		"pollId":   pollID,
+		"voteDate": voteDate,
+		"links": map[string]interface{}{
+			"get": map[string]interface{}{
+				"method": "GET",
+				"url":    link,
+			},
+			"update": map[string]interface{}{
+				"method": "PUT",
+				"url":    link,
+			},
+			"delete": map[string]interface{}{
+				"method": "DELETE",
+				"url":    link,
+			},
+		},
+	}
+}
+
+// Read the optional {"voteDate": "<RFC3339>"} body of the voter poll
+// requests. The vote date is now when the body or its voteDate is left
+// out, and must be between minVoteDate and maxVoteDateSkew from now
+// otherwise.
+func bindVoteDate(c *gin.Context) (time.Time, error) {
+	var requestBody struct {
+		VoteDate string `json:"voteDate"`
+	}
+
+	if err := c.ShouldBindJSON(&requestBody); err != nil && !errors.Is(err, io.EOF) {
+		return time.Time{}, err
+	}
+
+	if requestBody.VoteDate == "" {
+		return time.Now(), nil
+	}
+
+	voteDate, err := time.Parse(time.RFC3339, requestBody.VoteDate)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("voteDate must be an RFC3339 time: %w", err)
+	}
+
+	earliest, _ := time.Parse(time.RFC3339, minVoteDate)
+	if voteDate.Before(earliest) || voteDate.After(time.Now().Add(maxVoteDateSkew)) {
+		return time.Time{}, fmt.Errorf("voteDate must be between %s and now", minVoteDate)
+	}
+
+	return voteDate, nil
+}
+
 // Implementation of GET /voters.
-// Returns all voters with all voter history.
+// Returns all voters with all voter history, in voter ID order.
 func (va *VoterAPI) ListAllVoters(c *gin.Context) {
-	voters := va.voterList.GetAllVoters()
+	voters, err := va.voterList.GetAllVoters()
+	if err != nil {
+		log.Println("Error getting voters: ", err)
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
 
-	if voters == nil {
-		voters = make([]voter.Voter, 0)
+	voterResponses := make([]map[string]interface{}, len(voters))
+	for i, voter := range voters {
+		voterResponses[i] = voterResponse(voter)
 	}
 
-	c.JSON(http.StatusOK, voters)
+	c.JSON(http.StatusOK, voterResponses)
 }
 
 // Implementation of GET /voters/:id.
@@ -93,7 +205,7 @@ func (va *VoterAPI) GetVoter(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, voter)
+	c.JSON(http.StatusOK, voterResponse(voter))
 }
 
 // Implementation of POST /voters/:id.
@@ -126,7 +238,8 @@ func (va *VoterAPI) AddVoter(c *gin.Context) {
 }
 
 // Implementation of PUT /voters/:id.
-// Update an existing voter with :id.
+// Update an existing voter with :id and return it as it was saved. A voter
+// that does not exist is a 404, a voter that could not be saved a 500.
 func (va *VoterAPI) UpdateVoter(c *gin.Context) {
 	voterID := c.Param("id")
 	voterIDUint, err := strconv.ParseUint(voterID, 10, 32)
@@ -136,21 +249,27 @@ func (va *VoterAPI) UpdateVoter(c *gin.Context) {
 		return
 	}
 
-	var voter voter.Voter
-	if err := c.ShouldBindJSON(&voter); err != nil {
+	var requestVoter voter.Voter
+	if err := c.ShouldBindJSON(&requestVoter); err != nil {
 		log.Println("Error binding JSON: ", err)
 		c.AbortWithStatus(http.StatusBadRequest)
 		return
 	}
 
-	voter.VoterID = uint(voterIDUint)
-	if err := va.voterList.UpdateVoter(voter); err != nil {
+	requestVoter.VoterID = uint(voterIDUint)
+	updatedVoter, err := va.voterList.UpdateVoter(requestVoter)
+	if errors.Is(err, voter.ErrVoterNotFound) {
+		log.Println("Error updating voter: ", err)
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	if err != nil {
 		log.Println("Error updating voter: ", err)
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
 
-	c.JSON(http.StatusOK, va.voterList.Voters[voter.VoterID])
+	c.JSON(http.StatusOK, updatedVoter)
 }
 
 // Implementation of DELETE /voters.
@@ -158,7 +277,7 @@ func (va *VoterAPI) UpdateVoter(c *gin.Context) {
 func (va *VoterAPI) DeleteAllVoters(c *gin.Context) {
 	if err := va.voterList.DeleteAllVoters(); err != nil {
 		log.Println("Error deleting voters: ", err)
-		c.AbortWithStatus(http.StatusNotFound)
+		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
 
@@ -178,11 +297,17 @@ func (va *VoterAPI) DeleteVoter(c *gin.Context) {
 		return
 	}
 
-	if err := va.voterList.DeleteVoter(uint(voterIDUint)); err != nil {
+	err = va.voterList.DeleteVoter(uint(voterIDUint))
+	if errors.Is(err, voter.ErrVoterNotFound) {
 		log.Println("Error deleting voter: ", err)
 		c.AbortWithStatus(http.StatusNotFound)
 		return
 	}
+	if err != nil {
+		log.Println("Error deleting voter: ", err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Voter deleted successfully.",
@@ -207,11 +332,16 @@ func (va *VoterAPI) GetVoterHistory(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, voterHistory)
+	voterHistoryResponses := make([]map[string]interface{}, len(voterHistory))
+	for i, voterPoll := range voterHistory {
+		voterHistoryResponses[i] = voterPollResponse(uint(voterIDUint), voterPoll.PollID, voterPoll.VoteDate)
+	}
+
+	c.JSON(http.StatusOK, voterHistoryResponses)
 }
 
-// Implementation of GET /voters/:id/polls/:pollid.
-// Get a specific poll from a voter's voting history with :id & :pollid.
+// Implementation of GET /voters/:id/polls/:pollId.
+// Get a specific poll from a voter's voting history with :id & :pollId.
 func (va *VoterAPI) GetVoterPoll(c *gin.Context) {
 	voterID := c.Param("id")
 	voterIDUint, err := strconv.ParseUint(voterID, 10, 32)
@@ -221,7 +351,7 @@ func (va *VoterAPI) GetVoterPoll(c *gin.Context) {
 		return
 	}
 
-	pollID := c.Param("pollid")
+	pollID := c.Param("pollId")
 	pollIDUint, err := strconv.ParseUint(pollID, 10, 32)
 	if err != nil {
 		log.Println("Error converting poll ID to uint: ", err)
@@ -236,11 +366,12 @@ func (va *VoterAPI) GetVoterPoll(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, voterPoll)
+	c.JSON(http.StatusOK, voterPollResponse(uint(voterIDUint), voterPoll.PollID, voterPoll.VoteDate))
 }
 
-// Implementation of POST /voters/:id/polls/:pollid.
-// Add a new poll to a voter's voting history with :id & :pollid.
+// Implementation of POST /voters/:id/polls/:pollId.
+// Add a new poll to a voter's voting history with :id & :pollId, voted at
+// the voteDate of the optional body or now.
 func (va *VoterAPI) AddVoterPoll(c *gin.Context) {
 	voterID := c.Param("id")
 	voterIDUint, err := strconv.ParseUint(voterID, 10, 32)
@@ -250,7 +381,7 @@ func (va *VoterAPI) AddVoterPoll(c *gin.Context) {
 		return
 	}
 
-	pollID := c.Param("pollid")
+	pollID := c.Param("pollId")
 	pollIDUint, err := strconv.ParseUint(pollID, 10, 32)
 	if err != nil {
 		log.Println("Error converting poll ID to uint: ", err)
@@ -258,7 +389,14 @@ func (va *VoterAPI) AddVoterPoll(c *gin.Context) {
 		return
 	}
 
-	newVoterPoll, err := va.voterList.AddVoterPoll(uint(voterIDUint), uint(pollIDUint))
+	voteDate, err := bindVoteDate(c)
+	if err != nil {
+		log.Println("Error parsing JSON request body: ", err)
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	newVoterPoll, err := va.voterList.AddVoterPoll(uint(voterIDUint), uint(pollIDUint), voteDate)
 	if err != nil {
 		log.Println("Error adding voter poll: ", err)
 		c.AbortWithStatus(http.StatusBadRequest)
@@ -268,8 +406,9 @@ func (va *VoterAPI) AddVoterPoll(c *gin.Context) {
 	c.JSON(http.StatusOK, newVoterPoll)
 }
 
-// Implementation of PUT /voters/:id/polls/:pollid.
-// Update an existing poll in a voter's voting history with :id & :pollid.
+// Implementation of PUT /voters/:id/polls/:pollId.
+// Update the vote date of an existing poll in a voter's voting history
+// with :id & :pollId, to the voteDate of the optional body or now.
 func (va *VoterAPI) UpdateVoterPoll(c *gin.Context) {
 	voterID := c.Param("id")
 	voterIDUint, err := strconv.ParseUint(voterID, 10, 32)
@@ -279,7 +418,7 @@ func (va *VoterAPI) UpdateVoterPoll(c *gin.Context) {
 		return
 	}
 
-	pollID := c.Param("pollid")
+	pollID := c.Param("pollId")
 	pollIDUint, err := strconv.ParseUint(pollID, 10, 32)
 	if err != nil {
 		log.Println("Error converting poll ID to uint: ", err)
@@ -287,7 +426,14 @@ func (va *VoterAPI) UpdateVoterPoll(c *gin.Context) {
 		return
 	}
 
-	updatedVoterPoll, err := va.voterList.UpdateVoterPoll(uint(voterIDUint), uint(pollIDUint))
+	voteDate, err := bindVoteDate(c)
+	if err != nil {
+		log.Println("Error parsing JSON request body: ", err)
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	updatedVoterPoll, err := va.voterList.UpdateVoterPoll(uint(voterIDUint), uint(pollIDUint), voteDate)
 	if err != nil {
 		log.Println("Error updating voter poll: ", err)
 		c.AbortWithStatus(http.StatusBadRequest)
@@ -297,8 +443,8 @@ func (va *VoterAPI) UpdateVoterPoll(c *gin.Context) {
 	c.JSON(http.StatusOK, updatedVoterPoll)
 }
 
-// Implementation of DELETE /voters/:id/polls/:pollid.
-// Delete a specific poll from a voter's voting history with :id & :pollid.
+// Implementation of DELETE /voters/:id/polls/:pollId.
+// Delete a specific poll from a voter's voting history with :id & :pollId.
 func (va *VoterAPI) DeleteVoterPoll(c *gin.Context) {
 	voterID := c.Param("id")
 	voterIDUint, err := strconv.ParseUint(voterID, 10, 32)
@@ -308,7 +454,7 @@ func (va *VoterAPI) DeleteVoterPoll(c *gin.Context) {
 		return
 	}
 
-	pollID := c.Param("pollid")
+	pollID := c.Param("pollId")
 	pollIDUint, err := strconv.ParseUint(pollID, 10, 32)
 	if err != nil {
 		log.Println("Error converting poll ID to uint: ", err)
@@ -330,6 +476,9 @@ func (va *VoterAPI) DeleteVoterPoll(c *gin.Context) {
 // Implementation of GET voters/health.
 // Get the health status of the voter API.
 func (va *VoterAPI) HealthCheck(c *gin.Context) {
+	va.metricsLock.Lock()
+	defer va.metricsLock.Unlock()
+
 	uptime := time.Since(va.bootTime).String()
 	averageRequestTime := time.Duration(0)
 	if va.totalCalls > 0 {
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"voter-api/voter"
+
+	"github.com/gin-gonic/gin"
+)
+
+func send(r *gin.Engine, method, path string) int {
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(method, path, nil))
+	return recorder.Code
+}
+
+// A voter that does not exist is 404, a voter list that cannot be saved
+// 500, and the voters stay as they were.
+func TestDeleteVoterStatuses(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	dir := filepath.Join(t.TempDir(), "db")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	va, err := NewVoterHandler(filepath.Join(dir, "voters.json"))
+	if err != nil {
+		t.Fatalf("NewVoterHandler: %v", err)
+	}
+	if err := va.voterList.AddVoter(voter.NewVoter(1, "Ada", "Lovelace")); err != nil {
+		t.Fatalf("AddVoter: %v", err)
+	}
+
+	r := gin.New()
+	r.GET("/voters/:id", va.GetVoter)
+	r.DELETE("/voters", va.DeleteAllVoters)
+	r.DELETE("/voters/:id", va.DeleteVoter)
+
+	if status := send(r, http.MethodDelete, "/voters/2"); status != http.StatusNotFound {
+		t.Fatalf("DELETE /voters/2 = %d, want %d", status, http.StatusNotFound)
+	}
+
+	// Without its directory the voter list cannot be saved.
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if status := send(r, http.MethodDelete, "/voters/1"); status != http.StatusInternalServerError {
+		t.Fatalf("DELETE /voters/1 = %d, want %d", status, http.StatusInternalServerError)
+	}
+	if status := send(r, http.MethodDelete, "/voters"); status != http.StatusInternalServerError {
+		t.Fatalf("DELETE /voters = %d, want %d", status, http.StatusInternalServerError)
+	}
+	if status := send(r, http.MethodDelete, "/voters/2"); status != http.StatusNotFound {
+		t.Fatalf("DELETE /voters/2 = %d after a failed save, want %d", status, http.StatusNotFound)
+	}
+
+	if status := send(r, http.MethodGet, "/voters/1"); status != http.StatusOK {
+		t.Fatalf("GET /voters/1 = %d after the failed deletes, want %d", status, http.StatusOK)
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"flag"
 	"fmt"
+	"log"
 
 	"voter-api/api"
 
@@ -11,13 +12,15 @@ import (
 )
 
 var (
-	hostFlag string
-	portFlag uint
+	hostFlag   string
+	portFlag   uint
+	dbFileFlag string
 )
 
 func processCmdLineFlags() {
 	flag.StringVar(&hostFlag, "h", "0.0.0.0", "Listen on all interfaces")
 	flag.UintVar(&portFlag, "p", 1080, "Default Port")
+	flag.StringVar(&dbFileFlag, "db-file", "", "JSON file the voters are saved to, in memory only when empty")
 
 	flag.Parse()
 }
@@ -28,7 +31,10 @@ func main() {
 	r.Use(cors.Default())
 
 	// Create a new instance of the VoterAPI handler.
-	voterHandler := api.NewVoterHandler()
+	voterHandler, err := api.NewVoterHandler(dbFileFlag)
+	if err != nil {
+		log.Fatalln("Error loading voters: ", err)
+	}
 
 	// Register the HealthMiddleware, it will be called for every request.
 	r.Use(api.HealthMiddleware(voterHandler))
@@ -42,10 +48,10 @@ func main() {
 	r.DELETE("/voters", voterHandler.DeleteAllVoters)
 	r.DELETE("/voters/:id", voterHandler.DeleteVoter)
 	r.GET("/voters/:id/polls", voterHandler.GetVoterHistory)
-	r.GET("/voters/:id/polls/:pollid", voterHandler.GetVoterPoll)
-	r.POST("/voters/:id/polls/:pollid", voterHandler.AddVoterPoll)
-	r.PUT("/voters/:id/polls/:pollid", voterHandler.UpdateVoterPoll)
-	r.DELETE("/voters/:id/polls/:pollid", voterHandler.DeleteVoterPoll)
+	r.GET("/voters/:id/polls/:pollId", voterHandler.GetVoterPoll)
+	r.POST("/voters/:id/polls/:pollId", voterHandler.AddVoterPoll)
+	r.PUT("/voters/:id/polls/:pollId", voterHandler.UpdateVoterPoll)
+	r.DELETE("/voters/:id/polls/:pollId", voterHandler.DeleteVoterPoll)
 	r.GET("/voters/health", voterHandler.HealthCheck)
 
 	// Start the server.
@@ -0,0 +1,133 @@
+package voter
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Create a VoterList kept in dbFile as a JSON array of voters. The file is
+// created with an empty array when it does not exist, and the voters it
+// holds are loaded. Every change is written back to the file before it is
+// answered, so the voters survive a restart.
+func NewVoterListFromFile(dbFile string) (*VoterList, error) {
+	if _, err := os.Stat(dbFile); err != nil {
+		if err := initDB(dbFile); err != nil {
+			return nil, err
+		}
+	}
+
+	voterList := NewVoterList()
+	voterList.dbFileName = dbFile
+
+	if err := voterList.loadDB(); err != nil {
+		return nil, err
+	}
+
+	return voterList, nil
+}
+
+// Create dbFile with an empty JSON array, so that it can be loaded.
+func initDB(dbFile string) error {
+	return writeFileAtomic(dbFile, []byte("[]"))
+}
+
+// Write data to a temporary file next to path and rename it over path.
+// The rename replaces the file in one step, so a crash while writing
+// leaves the previous file whole instead of a truncated one.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+
+	// Removing the temporary file fails once it was renamed, which is fine.
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Write every voter to the file of the VoterList, in voter ID order. A
+// VoterList without a file is only kept in memory. The caller holds mu,
+// and undoes its change when the write fails, so memory never holds a
+// change the file does not.
+func (vl *VoterList) saveDB() error {
+	if vl.dbFileName == "" {
+		return nil
+	}
+
+	voters := make([]Voter, 0, len(vl.voters))
+	for _, voter := range vl.voters {
+		voters = append(voters, voter)
+	}
+
+	sort.Slice(voters, func(i, j int) bool {
+		return voters[i].VoterID < voters[j].VoterID
+	})
+
+	data, err := json.MarshalIndent(voters, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(vl.dbFileName, data)
+}
+
+// Write the voters to the file after a change of the voter with voterID,
+// who was previous before it, or did not exist. When the write fails the
+// voter is restored. The caller holds mu.
+func (vl *VoterList) saveVoter(voterID uint, previous Voter, existed bool) error {
+	err := vl.saveDB()
+	if err == nil {
+		return nil
+	}
+
+	if existed {
+		vl.voters[voterID] = previous
+	} else {
+		delete(vl.voters, voterID)
+	}
+
+	return err
+}
+
+// Replace the voters of the VoterList with those of its file.
+func (vl *VoterList) loadDB() error {
+	data, err := os.ReadFile(vl.dbFileName)
+	if err != nil {
+		return err
+	}
+
+	var voters []Voter
+	if err := json.Unmarshal(data, &voters); err != nil {
+		return err
+	}
+
+	vl.mu.Lock()
+	defer vl.mu.Unlock()
+
+	vl.voters = make(map[uint]Voter, len(voters))
+	for _, voter := range voters {
+		if voter.VoteHistory == nil {
+			voter.VoteHistory = make([]voterPoll, 0)
+		}
+		vl.voters[voter.VoterID] = voter
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package voter
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Return a VoterList kept in a file with voters 1 and 2, who voted in poll
+// 1, and a function that makes every later save fail.
+func newFailingVoterList(t *testing.T) (*VoterList, func()) {
+	t.Helper()
+
+	dir := filepath.Join(t.TempDir(), "db")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	vl, err := NewVoterListFromFile(filepath.Join(dir, "voters.json"))
+	if err != nil {
+		t.Fatalf("NewVoterListFromFile: %v", err)
+	}
+
+	for _, id := range []uint{1, 2} {
+		if err := vl.AddVoter(NewVoter(id, "First", "Last")); err != nil {
+			t.Fatalf("AddVoter(%d): %v", id, err)
+		}
+		if _, err := vl.AddVoterPoll(id, 1, time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)); err != nil {
+			t.Fatalf("AddVoterPoll(%d, 1): %v", id, err)
+		}
+	}
+
+	// Without its directory the file cannot be written.
+	return vl, func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestVoterListFileRoundTrip(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "voters.json")
+
+	vl, err := NewVoterListFromFile(dbFile)
+	if err != nil {
+		t.Fatalf("NewVoterListFromFile: %v", err)
+	}
+	if err := vl.AddVoter(NewVoter(1, "Ada", "Lovelace")); err != nil {
+		t.Fatalf("AddVoter: %v", err)
+	}
+	if _, err := vl.AddVoterPoll(1, 2, time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("AddVoterPoll: %v", err)
+	}
+
+	reloaded, err := NewVoterListFromFile(dbFile)
+	if err != nil {
+		t.Fatalf("reloading %s: %v", dbFile, err)
+	}
+
+	want, _ := vl.GetAllVoters()
+	got, _ := reloaded.GetAllVoters()
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("reloaded voters = %+v, want %+v", got, want)
+	}
+}
+
+// Every change whose save fails is undone, so the voters in memory stay
+// those of the file.
+func TestVoterListRestoresFailedSaves(t *testing.T) {
+	changes := map[string]func(vl *VoterList) error{
+		"AddVoter": func(vl *VoterList) error {
+			return vl.AddVoter(NewVoter(3, "First", "Last"))
+		},
+		"UpdateVoter": func(vl *VoterList) error {
+			_, err := vl.UpdateVoter(Voter{VoterID: 1, FirstName: "Changed", LastName: "Changed"})
+			return err
+		},
+		"DeleteAllVoters": func(vl *VoterList) error {
+			return vl.DeleteAllVoters()
+		},
+		"DeleteVoter": func(vl *VoterList) error {
+			return vl.DeleteVoter(1)
+		},
+		"AddVoterPoll": func(vl *VoterList) error {
+			_, err := vl.AddVoterPoll(1, 2, time.Now())
+			return err
+		},
+		"UpdateVoterPoll": func(vl *VoterList) error {
+			_, err := vl.UpdateVoterPoll(1, 1, time.Now())
+			return err
+		},
+		"DeleteVoterPoll": func(vl *VoterList) error {
+			return vl.DeleteVoterPoll(1, 1)
+		},
+	}
+
+	for name, change := range changes {
+		t.Run(name, func(t *testing.T) {
+			vl, failSaves := newFailingVoterList(t)
+			before, _ := vl.GetAllVoters()
+			failSaves()
+
+			if err := change(vl); err == nil {
+				t.Fatal("the change succeeded without its file")
+			}
+
+			if after, _ := vl.GetAllVoters(); !reflect.DeepEqual(after, before) {
+				t.Fatalf("voters after a failed save = %+v, want %+v", after, before)
+			}
+		})
+	}
+}
@@ -2,9 +2,15 @@ package voter
 
 import (
 	"errors"
+	"sort"
+	"sync"
 	"time"
 )
 
+// ErrVoterNotFound is returned when a voter does not exist in the
+// VoterList.
+var ErrVoterNotFound = errors.New("voter does not exist")
+
 // voterPoll represents the voting information for a specific poll.
 type voterPoll struct {
 	PollID   uint      `json:"pollId"`
@@ -19,15 +25,22 @@ type Voter struct {
 	VoteHistory []voterPoll `json:"voteHistory"`
 }
 
-// VoterList is a collection of voters.
+// VoterList is a collection of voters. It is safe for concurrent use,
+// every method holds mu while it touches voters. Its methods have the
+// signatures of the VoterCache of the Redis variant, so the handlers are
+// the same for both.
 type VoterList struct {
-	Voters map[uint]Voter
+	mu     sync.RWMutex
+	voters map[uint]Voter
+	// The file the voters are saved to on every change, none when they
+	// are only kept in memory.
+	dbFileName string
 }
 
-// Create a new VoterList instance and initializes the Voters map.
+// Create a new VoterList instance and initializes the voters map.
 func NewVoterList() *VoterList {
 	voterList := &VoterList{
-		Voters: make(map[uint]Voter),
+		voters: make(map[uint]Voter),
 	}
 
 	return voterList
@@ -45,87 +58,137 @@ func NewVoter(id uint, firstName string, lastName string) Voter {
 	return voter
 }
 
-// Return a slice of all voters in the VoterList.
-func (vl *VoterList) GetAllVoters() []Voter {
+// Return a copy of the voter that shares no vote history with the
+// VoterList, so callers can read it after the lock is released.
+func copyVoter(voter Voter) Voter {
+	history := make([]voterPoll, len(voter.VoteHistory))
+	copy(history, voter.VoteHistory)
+	voter.VoteHistory = history
+
+	return voter
+}
+
+// Return a slice of all voters in the VoterList, in voter ID order.
+func (vl *VoterList) GetAllVoters() ([]Voter, error) {
+	vl.mu.RLock()
+	defer vl.mu.RUnlock()
+
 	var voters []Voter
 
-	for _, voter := range vl.Voters {
-		voters = append(voters, voter)
+	for _, voter := range vl.voters {
+		voters = append(voters, copyVoter(voter))
 	}
 
-	return voters
+	sort.Slice(voters, func(i, j int) bool {
+		return voters[i].VoterID < voters[j].VoterID
+	})
+
+	return voters, nil
 }
 
 // Retrieve a single voter from the VoterList by voterID.
 func (vl *VoterList) GetVoter(voterID uint) (Voter, error) {
-	voter, exists := vl.Voters[voterID]
+	vl.mu.RLock()
+	defer vl.mu.RUnlock()
+
+	voter, exists := vl.voters[voterID]
 	if !exists {
-		return Voter{}, errors.New("voter does not exist")
+		return Voter{}, ErrVoterNotFound
 	}
 
-	return voter, nil
+	return copyVoter(voter), nil
 }
 
 // Add a new voter to the VoterList.
 func (vl *VoterList) AddVoter(voter Voter) error {
-	if _, exists := vl.Voters[voter.VoterID]; exists {
+	vl.mu.Lock()
+	defer vl.mu.Unlock()
+
+	if _, exists := vl.voters[voter.VoterID]; exists {
 		return errors.New("voter already exists")
 	}
 
-	vl.Voters[voter.VoterID] = voter
+	vl.voters[voter.VoterID] = voter
 
-	return nil
+	return vl.saveVoter(voter.VoterID, Voter{}, false)
 }
 
-// Update an existing voter in the VoterList.
-func (vl *VoterList) UpdateVoter(voter Voter) error {
-	existingVoter, exists := vl.Voters[voter.VoterID]
+// Update an existing voter in the VoterList and return the updated voter.
+func (vl *VoterList) UpdateVoter(voter Voter) (Voter, error) {
+	vl.mu.Lock()
+	defer vl.mu.Unlock()
+
+	existingVoter, exists := vl.voters[voter.VoterID]
 
 	if !exists {
-		return errors.New("voter does not exist")
+		return Voter{}, ErrVoterNotFound
 	}
 
+	previous := existingVoter
 	existingVoter.FirstName = voter.FirstName
 	existingVoter.LastName = voter.LastName
 
-	vl.Voters[voter.VoterID] = existingVoter
+	vl.voters[voter.VoterID] = existingVoter
 
-	return nil
+	if err := vl.saveVoter(voter.VoterID, previous, true); err != nil {
+		return Voter{}, err
+	}
+
+	return copyVoter(existingVoter), nil
 }
 
 // Delete all voters from the VoterList.
 func (vl *VoterList) DeleteAllVoters() error {
-	vl.Voters = make(map[uint]Voter)
+	vl.mu.Lock()
+	defer vl.mu.Unlock()
+
+	previous := vl.voters
+	vl.voters = make(map[uint]Voter)
+
+	if err := vl.saveDB(); err != nil {
+		vl.voters = previous
+		return err
+	}
 
 	return nil
 }
 
 // Delete a single voter from the VoterList by voterID.
 func (vl *VoterList) DeleteVoter(voterID uint) error {
-	if _, exists := vl.Voters[voterID]; !exists {
-		return errors.New("voter does not exist")
+	vl.mu.Lock()
+	defer vl.mu.Unlock()
+
+	previous, exists := vl.voters[voterID]
+	if !exists {
+		return ErrVoterNotFound
 	}
 
-	delete(vl.Voters, voterID)
+	delete(vl.voters, voterID)
 
-	return nil
+	return vl.saveVoter(voterID, previous, true)
 }
 
 // Retrieve the vote history of a voter by voterID.
 func (vl *VoterList) GetVoterHistory(voterID uint) ([]voterPoll, error) {
-	voter, exists := vl.Voters[voterID]
+	vl.mu.RLock()
+	defer vl.mu.RUnlock()
+
+	voter, exists := vl.voters[voterID]
 	if !exists {
-		return nil, errors.New("voter does not exist")
+		return nil, ErrVoterNotFound
 	}
 
-	return voter.VoteHistory, nil
+	return copyVoter(voter).VoteHistory, nil
 }
 
 // Retrieve a specific voter poll by voterID and pollID.
 func (vl *VoterList) GetVoterPoll(voterID, pollID uint) (voterPoll, error) {
-	voter, exists := vl.Voters[voterID]
+	vl.mu.RLock()
+	defer vl.mu.RUnlock()
+
+	voter, exists := vl.voters[voterID]
 	if !exists {
-		return voterPoll{}, errors.New("voter does not exist")
+		return voterPoll{}, ErrVoterNotFound
 	}
 
 	for _, poll := range voter.VoteHistory {
@@ -137,11 +200,14 @@ func (vl *VoterList) GetVoterPoll(voterID, pollID uint) (voterPoll, error) {
 	return voterPoll{}, errors.New("voter poll not found")
 }
 
-// Add a new voter poll to the vote history of a voter.
-func (vl *VoterList) AddVoterPoll(voterID, pollID uint) (voterPoll, error) {
-	voter, exists := vl.Voters[voterID]
+// Add a new voter poll, voted at voteDate, to the vote history of a voter.
+func (vl *VoterList) AddVoterPoll(voterID, pollID uint, voteDate time.Time) (voterPoll, error) {
+	vl.mu.Lock()
+	defer vl.mu.Unlock()
+
+	voter, exists := vl.voters[voterID]
 	if !exists {
-		return voterPoll{}, errors.New("voter does not exist")
+		return voterPoll{}, ErrVoterNotFound
 	}
 
 	for _, poll := range voter.VoteHistory {
@@ -152,28 +218,40 @@ func (vl *VoterList) AddVoterPoll(voterID, pollID uint) (voterPoll, error) {
 
 	newVoterPoll := voterPoll{
 		PollID:   pollID,
-		VoteDate: time.Now(),
+		VoteDate: voteDate,
 	}
 
+	previous := voter
 	voter.VoteHistory = append(voter.VoteHistory, newVoterPoll)
-	vl.Voters[voter.VoterID] = voter
+	vl.voters[voter.VoterID] = voter
+
+	if err := vl.saveVoter(voterID, previous, true); err != nil {
+		return voterPoll{}, err
+	}
 
 	return newVoterPoll, nil
 }
 
-// Update an existing voter poll in the vote history of a voter.
-func (vl *VoterList) UpdateVoterPoll(voterID, pollID uint) (voterPoll, error) {
-	voter, exists := vl.Voters[voterID]
+// Update the vote date of an existing voter poll in the vote history of a
+// voter.
+func (vl *VoterList) UpdateVoterPoll(voterID, pollID uint, voteDate time.Time) (voterPoll, error) {
+	vl.mu.Lock()
+	defer vl.mu.Unlock()
+
+	previous, exists := vl.voters[voterID]
 	if !exists {
-		return voterPoll{}, errors.New("voter does not exist")
+		return voterPoll{}, ErrVoterNotFound
 	}
 
+	// The history is changed in a copy, so the previous voter keeps theirs.
+	voter := copyVoter(previous)
+
 	var updatedVoterPoll voterPoll
 	for i, poll := range voter.VoteHistory {
 		if poll.PollID == pollID {
 			updatedVoterPoll = voterPoll{
 				PollID:   pollID,
-				VoteDate: time.Now(),
+				VoteDate: voteDate,
 			}
 			voter.VoteHistory[i] = updatedVoterPoll
 			break
@@ -184,16 +262,23 @@ func (vl *VoterList) UpdateVoterPoll(voterID, pollID uint) (voterPoll, error) {
 		return voterPoll{}, errors.New("voter poll not found")
 	}
 
-	vl.Voters[voter.VoterID] = voter
+	vl.voters[voter.VoterID] = voter
+
+	if err := vl.saveVoter(voterID, previous, true); err != nil {
+		return voterPoll{}, err
+	}
 
 	return updatedVoterPoll, nil
 }
 
 // Remove a specific voter poll from the vote history of a voter.
 func (vl *VoterList) DeleteVoterPoll(voterID, pollID uint) error {
-	voter, exists := vl.Voters[voterID]
+	vl.mu.Lock()
+	defer vl.mu.Unlock()
+
+	voter, exists := vl.voters[voterID]
 	if !exists {
-		return errors.New("voter does not exist")
+		return ErrVoterNotFound
 	}
 
 	var updatedVoteHistory []voterPoll
@@ -207,8 +292,9 @@ func (vl *VoterList) DeleteVoterPoll(voterID, pollID uint) error {
 		return errors.New("voter poll not found")
 	}
 
+	previous := voter
 	voter.VoteHistory = updatedVoteHistory
-	vl.Voters[voter.VoterID] = voter
+	vl.voters[voter.VoterID] = voter
 
-	return nil
+	return vl.saveVoter(voterID, previous, true)
 }
@@ -0,0 +1,178 @@
+package voter
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// The voters each goroutine of the tests works on.
+const votersPerWorker = 50
+
+// Run fn from workers goroutines at once and wait for them.
+func runWorkers(workers int, fn func(worker int)) {
+	var wait sync.WaitGroup
+	for worker := 0; worker < workers; worker++ {
+		wait.Add(1)
+		go func(worker int) {
+			defer wait.Done()
+			fn(worker)
+		}(worker)
+	}
+	wait.Wait()
+}
+
+// Add, update, read and delete voters from many goroutines at once. Run
+// with -race, any unguarded access of the voters map is reported.
+func TestVoterListConcurrentChanges(t *testing.T) {
+	vl := NewVoterList()
+
+	const workers = 8
+	runWorkers(workers, func(worker int) {
+		for i := 0; i < votersPerWorker; i++ {
+			id := uint(worker*votersPerWorker + i + 1)
+
+			if err := vl.AddVoter(NewVoter(id, "First", "Last")); err != nil {
+				t.Errorf("AddVoter(%d): %v", id, err)
+				return
+			}
+
+			name := fmt.Sprintf("Updated%d", id)
+			if _, err := vl.UpdateVoter(Voter{VoterID: id, FirstName: name, LastName: "Last"}); err != nil {
+				t.Errorf("UpdateVoter(%d): %v", id, err)
+				return
+			}
+
+			voter, err := vl.GetVoter(id)
+			if err != nil {
+				t.Errorf("GetVoter(%d): %v", id, err)
+				return
+			}
+			if voter.FirstName != name {
+				t.Errorf("GetVoter(%d).FirstName = %q, want %q", id, voter.FirstName, name)
+			}
+
+			if _, err := vl.GetAllVoters(); err != nil {
+				t.Errorf("GetAllVoters: %v", err)
+				return
+			}
+
+			// Every other voter is deleted again.
+			if i%2 == 0 {
+				if err := vl.DeleteVoter(id); err != nil {
+					t.Errorf("DeleteVoter(%d): %v", id, err)
+					return
+				}
+				if _, err := vl.GetVoter(id); !errors.Is(err, ErrVoterNotFound) {
+					t.Errorf("GetVoter(%d) after DeleteVoter: %v, want ErrVoterNotFound", id, err)
+				}
+			}
+		}
+	})
+
+	voters, err := vl.GetAllVoters()
+	if err != nil {
+		t.Fatalf("GetAllVoters: %v", err)
+	}
+	if want := workers * votersPerWorker / 2; len(voters) != want {
+		t.Fatalf("len(GetAllVoters()) = %d, want %d", len(voters), want)
+	}
+	for i := 1; i < len(voters); i++ {
+		if voters[i-1].VoterID >= voters[i].VoterID {
+			t.Fatalf("GetAllVoters is not in voter ID order: %d before %d", voters[i-1].VoterID, voters[i].VoterID)
+		}
+	}
+}
+
+// Change the vote history of one voter from many goroutines while others
+// read it. The voters returned must not share their history with the
+// VoterList.
+func TestVoterListConcurrentHistory(t *testing.T) {
+	vl := NewVoterList()
+	if err := vl.AddVoter(NewVoter(1, "First", "Last")); err != nil {
+		t.Fatalf("AddVoter: %v", err)
+	}
+
+	const workers = 8
+	voteDate := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	runWorkers(workers, func(worker int) {
+		for i := 0; i < votersPerWorker; i++ {
+			pollID := uint(worker*votersPerWorker + i + 1)
+
+			if _, err := vl.AddVoterPoll(1, pollID, voteDate); err != nil {
+				t.Errorf("AddVoterPoll(1, %d): %v", pollID, err)
+				return
+			}
+			if _, err := vl.UpdateVoterPoll(1, pollID, voteDate.Add(time.Minute)); err != nil {
+				t.Errorf("UpdateVoterPoll(1, %d): %v", pollID, err)
+				return
+			}
+
+			voter, err := vl.GetVoter(1)
+			if err != nil {
+				t.Errorf("GetVoter(1): %v", err)
+				return
+			}
+			// Writing to the copy must not race with the VoterList.
+			for j := range voter.VoteHistory {
+				voter.VoteHistory[j].VoteDate = time.Time{}
+			}
+
+			if i%2 == 0 {
+				if err := vl.DeleteVoterPoll(1, pollID); err != nil {
+					t.Errorf("DeleteVoterPoll(1, %d): %v", pollID, err)
+					return
+				}
+			}
+		}
+	})
+
+	history, err := vl.GetVoterHistory(1)
+	if err != nil {
+		t.Fatalf("GetVoterHistory: %v", err)
+	}
+	if want := workers * votersPerWorker / 2; len(history) != want {
+		t.Fatalf("len(GetVoterHistory()) = %d, want %d", len(history), want)
+	}
+	for _, entry := range history {
+		if !entry.VoteDate.Equal(voteDate.Add(time.Minute)) {
+			t.Fatalf("poll %d has vote date %v, want %v", entry.PollID, entry.VoteDate, voteDate.Add(time.Minute))
+		}
+	}
+}
+
+// Delete every voter while others are added and read.
+func TestVoterListConcurrentDeleteAll(t *testing.T) {
+	vl := NewVoterList()
+
+	runWorkers(4, func(worker int) {
+		for i := 0; i < votersPerWorker; i++ {
+			id := uint(worker*votersPerWorker + i + 1)
+			if worker == 0 {
+				if err := vl.DeleteAllVoters(); err != nil {
+					t.Errorf("DeleteAllVoters: %v", err)
+					return
+				}
+				continue
+			}
+
+			if err := vl.AddVoter(NewVoter(id, "First", "Last")); err != nil {
+				t.Errorf("AddVoter(%d): %v", id, err)
+				return
+			}
+			if _, err := vl.GetAllVoters(); err != nil {
+				t.Errorf("GetAllVoters: %v", err)
+				return
+			}
+		}
+	})
+
+	if err := vl.DeleteAllVoters(); err != nil {
+		t.Fatalf("DeleteAllVoters: %v", err)
+	}
+	if voters, _ := vl.GetAllVoters(); len(voters) != 0 {
+		t.Fatalf("len(GetAllVoters()) = %d after DeleteAllVoters, want 0", len(voters))
+	}
+}
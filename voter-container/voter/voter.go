@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sort"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -119,7 +120,7 @@ func (vc *VoterCache) getItemFromRedis(key string, item *Voter) error {
 	return nil
 }
 
-// Return a slice of all voters from the VoterCache.
+// Return a slice of all voters from the VoterCache, in voter ID order.
 func (vc *VoterCache) GetAllVoters() ([]Voter, error) {
 	var voters []Voter
 
@@ -141,6 +142,10 @@ func (vc *VoterCache) GetAllVoters() ([]Voter, error) {
 		voters = append(voters, voter)
 	}
 
+	sort.Slice(voters, func(i, j int) bool {
+		return voters[i].VoterID < voters[j].VoterID
+	})
+
 	return voters, nil
 }
 
@@ -0,0 +1,201 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"gateway-api/schema"
+
+	"shared/endpoints"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const (
+	AdminTokenHeader   = "X-Admin-Token"
+	EmbargoTokenHeader = "X-Embargo-Token"
+)
+
+// The headers of a GraphQL request that are passed on to the votes API, so
+// results keep their release rules.
+var forwardedHeaders = []string{AdminTokenHeader, EmbargoTokenHeader}
+
+// Fetch the voters with the given IDs. A single voter is fetched by ID,
+// several at once with the voter list.
+func (ga *GatewayAPI) fetchVoters(ids []uint) (map[uint]schema.Voter, error) {
+	voters := make(map[uint]schema.Voter, len(ids))
+
+	if len(ids) == 1 {
+		var voter schema.Voter
+		resp, err := ga.apiClient.R().SetResult(&voter).Get(fmt.Sprintf("%s/voters/%d", ga.voterAPIURL, ids[0]))
+		if err := downstreamError(endpoints.VoterAPI, ga.voterAPIURL, resp, err); err != nil {
+			return nil, err
+		}
+
+		if !resp.IsError() {
+			voters[voter.VoterID] = voter
+		}
+
+		return voters, nil
+	}
+
+	allVoters, err := ga.listVoters()
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := idSet(ids)
+	for _, voter := range allVoters {
+		if wanted[voter.VoterID] {
+			voters[voter.VoterID] = voter
+		}
+	}
+
+	return voters, nil
+}
+
+// Fetch every voter.
+func (ga *GatewayAPI) listVoters() ([]schema.Voter, error) {
+	var voters = []schema.Voter{}
+
+	resp, err := ga.apiClient.R().SetResult(&voters).Get(ga.voterAPIURL + "/voters")
+	if err := downstreamError(endpoints.VoterAPI, ga.voterAPIURL, resp, err); err != nil {
+		return nil, err
+	}
+
+	return voters, nil
+}
+
+// Fetch the polls with the given IDs. A single poll is fetched by ID,
+// several at once with the poll list.
+func (ga *GatewayAPI) fetchPolls(ids []uint) (map[uint]schema.Poll, error) {
+	polls := make(map[uint]schema.Poll, len(ids))
+
+	if len(ids) == 1 {
+		var poll schema.Poll
+		resp, err := ga.apiClient.R().SetResult(&poll).Get(fmt.Sprintf("%s/polls/%d", ga.pollAPIURL, ids[0]))
+		if err := downstreamError(endpoints.PollAPI, ga.pollAPIURL, resp, err); err != nil {
+			return nil, err
+		}
+
+		if !resp.IsError() {
+			polls[poll.PollID] = poll
+		}
+
+		return polls, nil
+	}
+
+	allPolls, err := ga.listPolls()
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := idSet(ids)
+	for _, poll := range allPolls {
+		if wanted[poll.PollID] {
+			polls[poll.PollID] = poll
+		}
+	}
+
+	return polls, nil
+}
+
+// Fetch every poll.
+func (ga *GatewayAPI) listPolls() ([]schema.Poll, error) {
+	var polls = []schema.Poll{}
+
+	resp, err := ga.apiClient.R().SetResult(&polls).Get(ga.pollAPIURL + "/polls")
+	if err := downstreamError(endpoints.PollAPI, ga.pollAPIURL, resp, err); err != nil {
+		return nil, err
+	}
+
+	return polls, nil
+}
+
+// Fetch every vote.
+func (ga *GatewayAPI) listVotes() ([]schema.Vote, error) {
+	var allVotes = []schema.Vote{}
+
+	resp, err := ga.apiClient.R().SetResult(&allVotes).Get(ga.votesAPIURL + "/votes")
+	if err := downstreamError(endpoints.VotesAPI, ga.votesAPIURL, resp, err); err != nil {
+		return nil, err
+	}
+
+	return allVotes, nil
+}
+
+// Fetch the results of the polls with the given IDs from the votes API,
+// one request per poll in parallel. Polls whose results are not released
+// to the caller are left out.
+func (ga *GatewayAPI) fetchResults(headers http.Header, ids []uint) (map[uint]schema.PollResults, error) {
+	pollResults := make(map[uint]schema.PollResults, len(ids))
+
+	var lock sync.Mutex
+	var wait sync.WaitGroup
+	var firstErr error
+
+	for _, id := range ids {
+		wait.Add(1)
+		go func(pollID uint) {
+			defer wait.Done()
+
+			request := ga.apiClient.R()
+			for _, header := range forwardedHeaders {
+				if value := headers.Get(header); value != "" {
+					request.SetHeader(header, value)
+				}
+			}
+
+			var results schema.PollResults
+			resp, err := request.SetResult(&results).Get(fmt.Sprintf("%s/votes/results/%d", ga.votesAPIURL, pollID))
+
+			lock.Lock()
+			defer lock.Unlock()
+
+			if err := downstreamError(endpoints.VotesAPI, ga.votesAPIURL, resp, err); err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+
+			if !resp.IsError() {
+				pollResults[pollID] = results
+			}
+		}(id)
+	}
+
+	wait.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	return pollResults, nil
+}
+
+// Turn a failed call to the endpoint at url into an error. Client errors
+// are answers, the caller decides what they mean.
+func downstreamError(endpoint endpoints.Endpoint, url string, resp *resty.Response, err error) error {
+	if err != nil {
+		return endpoint.Unreachable(url, err)
+	}
+
+	if resp.StatusCode() >= http.StatusInternalServerError {
+		return errors.New(endpoint.Name + " returned " + resp.Status())
+	}
+
+	return nil
+}
+
+// Return the IDs as a set.
+func idSet(ids []uint) map[uint]bool {
+	set := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+
+	return set
+}
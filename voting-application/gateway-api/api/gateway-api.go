@@ -0,0 +1,172 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"shared/apierror"
+	"shared/healthkit"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-resty/resty/v2"
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+const (
+	DependencyCheckTimeout = 2 * time.Second
+	// The most resolvers run at the same time for one request.
+	MaxResolverParallelism = 20
+)
+
+// The API handler that handles incoming requests.
+type GatewayAPI struct {
+	schema      *graphql.Schema
+	voterAPIURL string
+	pollAPIURL  string
+	votesAPIURL string
+	apiClient   *resty.Client
+	metrics     *healthkit.Metrics
+}
+
+// Create a new instance of GatewayAPI that calls the REST APIs at the
+// given locations.
+func NewGatewayHandler(voterAPIURL, pollAPIURL, votesAPIURL string) *GatewayAPI {
+	ga := &GatewayAPI{
+		voterAPIURL: voterAPIURL,
+		pollAPIURL:  pollAPIURL,
+		votesAPIURL: votesAPIURL,
+		apiClient:   resty.New(),
+		metrics:     healthkit.New(),
+	}
+
+	ga.schema = graphql.MustParseSchema(graphQLSchema, &rootResolver{ga: ga},
+		graphql.MaxParallelism(MaxResolverParallelism))
+
+	return ga
+}
+
+// The custom middleware to handle health metadata.
+func HealthMiddleware(ga *GatewayAPI) gin.HandlerFunc {
+	return ga.metrics.Middleware()
+}
+
+// graphQLRequest is the body of a GraphQL request.
+type graphQLRequest struct {
+	Query         string                 `json:"query" form:"query"`
+	OperationName string                 `json:"operationName" form:"operationName"`
+	Variables     map[string]interface{} `json:"variables" form:"-"`
+}
+
+// Implementation of POST /graphql.
+// Execute a GraphQL query. Voters, polls and results are fetched from the
+// REST APIs in batches; the number of batches is reported in the
+// extensions of the response.
+func (ga *GatewayAPI) ExecuteQuery(c *gin.Context) {
+	var request graphQLRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Println("Error binding JSON: ", err)
+		apierror.AbortInvalidBody(c, err)
+		return
+	}
+
+	ga.execute(c, request)
+}
+
+// Implementation of GET /graphql?query=.
+// Execute a GraphQL query given in the query string.
+func (ga *GatewayAPI) ExecuteQueryString(c *gin.Context) {
+	var request graphQLRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		log.Println("Error binding query: ", err)
+		apierror.AbortWithDetails(c, http.StatusBadRequest, apierror.CodeBadRequest, "Query string is not valid", err)
+		return
+	}
+
+	ga.execute(c, request)
+}
+
+// Execute a GraphQL request with fresh loaders and write the response.
+func (ga *GatewayAPI) execute(c *gin.Context, request graphQLRequest) {
+	if request.Query == "" {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeValidationFailed, "query is required")
+		return
+	}
+
+	loaders := ga.newRequestLoaders(c.Request.Header)
+	ctx := context.WithValue(c.Request.Context(), loadersKey{}, loaders)
+
+	response := ga.schema.Exec(ctx, request.Query, request.OperationName, request.Variables)
+	response.Extensions = map[string]interface{}{
+		"batches": gin.H{
+			"voters":  loaders.voters.batchCount(),
+			"polls":   loaders.polls.batchCount(),
+			"results": loaders.results.batchCount(),
+		},
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Implementation of GET /gateway/health.
+// Get the health status of the gateway API.
+func (ga *GatewayAPI) HealthCheck(c *gin.Context) {
+	c.JSON(http.StatusOK, ga.metrics.Report())
+}
+
+// Implementation of GET /healthz.
+// Liveness probe, reports that the process is up and serving requests.
+func (ga *GatewayAPI) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+	})
+}
+
+// Implementation of GET /readyz.
+// Readiness probe, checks the three REST APIs the gateway stitches together
+// and returns 503 with detail when any of them is down.
+func (ga *GatewayAPI) Readiness(c *gin.Context) {
+	dependencies := map[string]interface{}{}
+	ready := true
+
+	for name, url := range map[string]string{
+		"voter-api": ga.voterAPIURL,
+		"poll-api":  ga.pollAPIURL,
+		"votes-api": ga.votesAPIURL,
+	} {
+		dependencyStatus := ga.checkDependency(url + "/healthz")
+		dependencies[name] = dependencyStatus
+		ready = ready && dependencyStatus["status"] == "up"
+	}
+
+	if !ready {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status":       "not ready",
+			"dependencies": dependencies,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":       "ready",
+		"dependencies": dependencies,
+	})
+}
+
+// Probe a downstream service and describe its status.
+func (ga *GatewayAPI) checkDependency(url string) gin.H {
+	ctx, cancel := context.WithTimeout(context.Background(), DependencyCheckTimeout)
+	defer cancel()
+
+	resp, err := ga.apiClient.R().SetContext(ctx).Get(url)
+	if err != nil {
+		return gin.H{"status": "down", "error": err.Error()}
+	}
+
+	if resp.IsError() {
+		return gin.H{"status": "down", "error": "unexpected status " + resp.Status()}
+	}
+
+	return gin.H{"status": "up"}
+}
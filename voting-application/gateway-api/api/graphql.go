@@ -0,0 +1,350 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"gateway-api/schema"
+)
+
+// The GraphQL schema of the gateway. IDs are the numeric IDs of the REST
+// APIs.
+const graphQLSchema = `
+schema {
+	query: Query
+}
+
+type Query {
+	voters: [Voter!]!
+	voter(id: Int!): Voter
+	polls: [Poll!]!
+	poll(id: Int!): Poll
+	votes(pollId: Int): [Vote!]!
+	vote(id: Int!): Vote
+}
+
+type Voter {
+	id: Int!
+	firstName: String!
+	lastName: String!
+	name: String!
+	# pending, active or suspended; only active voters can vote.
+	status: String!
+	votes: [Vote!]!
+}
+
+type Poll {
+	id: Int!
+	title: String!
+	question: String!
+	status: String!
+	options: [PollOption!]!
+	# Null when the results of the poll are not released to the caller.
+	totalVotes: Int
+	votes: [Vote!]!
+}
+
+type PollOption {
+	id: Int!
+	text: String!
+	# Null when the results of the poll are not released to the caller.
+	votes: Int
+}
+
+type Vote {
+	id: Int!
+	voter: Voter
+	poll: Poll
+	option: PollOption
+}
+`
+
+// requestLoaders hold what a single GraphQL request fetched from the REST
+// APIs, so every voter, poll and result is fetched at most once per
+// request.
+type requestLoaders struct {
+	voters  *loader[schema.Voter]
+	polls   *loader[schema.Poll]
+	results *loader[schema.PollResults]
+
+	votesOnce sync.Once
+	votes     []schema.Vote
+	votesErr  error
+}
+
+type loadersKey struct{}
+
+// Create the loaders of a request. Results are fetched with the headers of
+// the request, so they follow the release rules of the votes API.
+func (ga *GatewayAPI) newRequestLoaders(headers http.Header) *requestLoaders {
+	return &requestLoaders{
+		voters: newLoader(ga.fetchVoters),
+		polls:  newLoader(ga.fetchPolls),
+		results: newLoader(func(ids []uint) (map[uint]schema.PollResults, error) {
+			return ga.fetchResults(headers, ids)
+		}),
+	}
+}
+
+// Return the loaders of the request of the context.
+func loadersFrom(ctx context.Context) *requestLoaders {
+	return ctx.Value(loadersKey{}).(*requestLoaders)
+}
+
+// Return every vote, fetched once per request.
+func (ga *GatewayAPI) allVotes(ctx context.Context) ([]schema.Vote, error) {
+	loaders := loadersFrom(ctx)
+	loaders.votesOnce.Do(func() {
+		loaders.votes, loaders.votesErr = ga.listVotes()
+	})
+
+	return loaders.votes, loaders.votesErr
+}
+
+// Return the resolvers of the votes that match.
+func (ga *GatewayAPI) votesWhere(ctx context.Context, match func(vote schema.Vote) bool) ([]*voteResolver, error) {
+	allVotes, err := ga.allVotes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resolvers := []*voteResolver{}
+	for _, vote := range allVotes {
+		if match(vote) {
+			resolvers = append(resolvers, &voteResolver{ga: ga, vote: vote})
+		}
+	}
+
+	return resolvers, nil
+}
+
+// rootResolver resolves the Query type.
+type rootResolver struct {
+	ga *GatewayAPI
+}
+
+func (r *rootResolver) Voters(ctx context.Context) ([]*voterResolver, error) {
+	voters, err := r.ga.listVoters()
+	if err != nil {
+		return nil, err
+	}
+
+	resolvers := make([]*voterResolver, len(voters))
+	for i, voter := range voters {
+		resolvers[i] = &voterResolver{ga: r.ga, voter: voter}
+	}
+
+	return resolvers, nil
+}
+
+func (r *rootResolver) Voter(ctx context.Context, args struct{ ID int32 }) (*voterResolver, error) {
+	return r.ga.loadVoter(ctx, uint(args.ID))
+}
+
+func (r *rootResolver) Polls(ctx context.Context) ([]*pollResolver, error) {
+	polls, err := r.ga.listPolls()
+	if err != nil {
+		return nil, err
+	}
+
+	resolvers := make([]*pollResolver, len(polls))
+	for i, poll := range polls {
+		resolvers[i] = &pollResolver{ga: r.ga, poll: poll}
+	}
+
+	return resolvers, nil
+}
+
+func (r *rootResolver) Poll(ctx context.Context, args struct{ ID int32 }) (*pollResolver, error) {
+	return r.ga.loadPoll(ctx, uint(args.ID))
+}
+
+func (r *rootResolver) Votes(ctx context.Context, args struct{ PollID *int32 }) ([]*voteResolver, error) {
+	return r.ga.votesWhere(ctx, func(vote schema.Vote) bool {
+		return args.PollID == nil || vote.PollID == uint(*args.PollID)
+	})
+}
+
+func (r *rootResolver) Vote(ctx context.Context, args struct{ ID int32 }) (*voteResolver, error) {
+	resolvers, err := r.ga.votesWhere(ctx, func(vote schema.Vote) bool {
+		return vote.VoteID == uint(args.ID)
+	})
+	if err != nil || len(resolvers) == 0 {
+		return nil, err
+	}
+
+	return resolvers[0], nil
+}
+
+// Load a voter through the loader of the request, nil when it does not exist.
+func (ga *GatewayAPI) loadVoter(ctx context.Context, voterID uint) (*voterResolver, error) {
+	voter, found, err := loadersFrom(ctx).voters.load(voterID)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return &voterResolver{ga: ga, voter: voter}, nil
+}
+
+// Load a poll through the loader of the request, nil when it does not exist.
+func (ga *GatewayAPI) loadPoll(ctx context.Context, pollID uint) (*pollResolver, error) {
+	poll, found, err := loadersFrom(ctx).polls.load(pollID)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return &pollResolver{ga: ga, poll: poll}, nil
+}
+
+// voterResolver resolves the Voter type.
+type voterResolver struct {
+	ga    *GatewayAPI
+	voter schema.Voter
+}
+
+func (r *voterResolver) ID() int32 {
+	return int32(r.voter.VoterID)
+}
+
+func (r *voterResolver) FirstName() string {
+	return r.voter.FirstName
+}
+
+func (r *voterResolver) LastName() string {
+	return r.voter.LastName
+}
+
+func (r *voterResolver) Name() string {
+	return r.voter.FirstName + " " + r.voter.LastName
+}
+
+func (r *voterResolver) Status() string {
+	return r.voter.Status
+}
+
+func (r *voterResolver) Votes(ctx context.Context) ([]*voteResolver, error) {
+	return r.ga.votesWhere(ctx, func(vote schema.Vote) bool {
+		return vote.VoterID == r.voter.VoterID
+	})
+}
+
+// pollResolver resolves the Poll type.
+type pollResolver struct {
+	ga   *GatewayAPI
+	poll schema.Poll
+}
+
+func (r *pollResolver) ID() int32 {
+	return int32(r.poll.PollID)
+}
+
+func (r *pollResolver) Title() string {
+	return r.poll.PollTitle
+}
+
+func (r *pollResolver) Question() string {
+	return r.poll.PollQuestion
+}
+
+func (r *pollResolver) Status() string {
+	return r.poll.PollStatus
+}
+
+func (r *pollResolver) Options() []*optionResolver {
+	resolvers := make([]*optionResolver, len(r.poll.PollOptions))
+	for i, option := range r.poll.PollOptions {
+		resolvers[i] = &optionResolver{poll: r, option: option}
+	}
+
+	return resolvers
+}
+
+// Load the results of the poll, nil when they are not released to the caller.
+func (r *pollResolver) results(ctx context.Context) (*schema.PollResults, error) {
+	results, found, err := loadersFrom(ctx).results.load(r.poll.PollID)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return &results, nil
+}
+
+func (r *pollResolver) TotalVotes(ctx context.Context) (*int32, error) {
+	results, err := r.results(ctx)
+	if err != nil || results == nil {
+		return nil, err
+	}
+
+	totalVotes := int32(results.TotalVotes)
+	return &totalVotes, nil
+}
+
+func (r *pollResolver) Votes(ctx context.Context) ([]*voteResolver, error) {
+	return r.ga.votesWhere(ctx, func(vote schema.Vote) bool {
+		return vote.PollID == r.poll.PollID
+	})
+}
+
+// optionResolver resolves the PollOption type.
+type optionResolver struct {
+	poll   *pollResolver
+	option schema.PollOption
+}
+
+func (r *optionResolver) ID() int32 {
+	return int32(r.option.PollOptionID)
+}
+
+func (r *optionResolver) Text() string {
+	return r.option.PollOptionText
+}
+
+func (r *optionResolver) Votes(ctx context.Context) (*int32, error) {
+	results, err := r.poll.results(ctx)
+	if err != nil || results == nil {
+		return nil, err
+	}
+
+	var votes int32
+	for _, result := range results.Results {
+		if result.OptionID == r.option.PollOptionID {
+			votes = int32(result.Votes)
+		}
+	}
+
+	return &votes, nil
+}
+
+// voteResolver resolves the Vote type.
+type voteResolver struct {
+	ga   *GatewayAPI
+	vote schema.Vote
+}
+
+func (r *voteResolver) ID() int32 {
+	return int32(r.vote.VoteID)
+}
+
+func (r *voteResolver) Voter(ctx context.Context) (*voterResolver, error) {
+	return r.ga.loadVoter(ctx, r.vote.VoterID)
+}
+
+func (r *voteResolver) Poll(ctx context.Context) (*pollResolver, error) {
+	return r.ga.loadPoll(ctx, r.vote.PollID)
+}
+
+func (r *voteResolver) Option(ctx context.Context) (*optionResolver, error) {
+	poll, err := r.ga.loadPoll(ctx, r.vote.PollID)
+	if err != nil || poll == nil {
+		return nil, err
+	}
+
+	for _, option := range poll.poll.PollOptions {
+		if option.PollOptionID == r.vote.VoteValue {
+			return &optionResolver{poll: poll, option: option}, nil
+		}
+	}
+
+	return nil, nil
+}
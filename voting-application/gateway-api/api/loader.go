@@ -0,0 +1,115 @@
+package api
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// How long a loader collects keys before fetching them together.
+	LoaderWait = 2 * time.Millisecond
+	// The most keys fetched in one batch.
+	LoaderMaxBatch = 100
+)
+
+// loaderEntry is the result of a single key, it is ready once done is closed.
+type loaderEntry[V any] struct {
+	value V
+	found bool
+	err   error
+	done  chan struct{}
+}
+
+// loader collects the keys resolvers ask for during LoaderWait and fetches
+// them with a single call, so a query that resolves the voter of a hundred
+// votes does not make a hundred requests. Every key is fetched at most
+// once, a loader lives for a single GraphQL request.
+type loader[V any] struct {
+	fetch   func(keys []uint) (map[uint]V, error)
+	lock    sync.Mutex
+	entries map[uint]*loaderEntry[V]
+	batch   []uint
+	batches uint
+}
+
+// Create a loader that fetches batches of keys with fetch. Keys missing
+// from the map fetch returns are not found.
+func newLoader[V any](fetch func(keys []uint) (map[uint]V, error)) *loader[V] {
+	return &loader[V]{
+		fetch:   fetch,
+		entries: make(map[uint]*loaderEntry[V]),
+	}
+}
+
+// Load the value of a key, waiting for the batch it is part of. It reports
+// whether the key was found.
+func (l *loader[V]) load(key uint) (V, bool, error) {
+	l.lock.Lock()
+
+	entry, ok := l.entries[key]
+	if !ok {
+		entry = &loaderEntry[V]{done: make(chan struct{})}
+		l.entries[key] = entry
+		l.batch = append(l.batch, key)
+
+		if len(l.batch) == 1 {
+			time.AfterFunc(LoaderWait, l.dispatch)
+		}
+
+		if len(l.batch) >= LoaderMaxBatch {
+			keys := l.batch
+			l.batch = nil
+			l.lock.Unlock()
+			l.run(keys)
+			<-entry.done
+			return entry.value, entry.found, entry.err
+		}
+	}
+
+	l.lock.Unlock()
+
+	<-entry.done
+	return entry.value, entry.found, entry.err
+}
+
+// Fetch the keys collected so far.
+func (l *loader[V]) dispatch() {
+	l.lock.Lock()
+	keys := l.batch
+	l.batch = nil
+	l.lock.Unlock()
+
+	if len(keys) > 0 {
+		l.run(keys)
+	}
+}
+
+// Fetch a batch of keys and hand the results to the waiting resolvers.
+func (l *loader[V]) run(keys []uint) {
+	values, err := l.fetch(keys)
+
+	l.lock.Lock()
+	l.batches++
+	entries := make([]*loaderEntry[V], len(keys))
+	for i, key := range keys {
+		entries[i] = l.entries[key]
+	}
+	l.lock.Unlock()
+
+	for i, key := range keys {
+		entry := entries[i]
+		entry.err = err
+		if err == nil {
+			entry.value, entry.found = values[key]
+		}
+		close(entry.done)
+	}
+}
+
+// Return the number of batches the loader fetched.
+func (l *loader[V]) batchCount() uint {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return l.batches
+}
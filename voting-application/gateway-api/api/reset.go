@@ -0,0 +1,116 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"shared/apierror"
+	"shared/endpoints"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-resty/resty/v2"
+)
+
+// resetReport is what POST /admin/reset removed.
+type resetReport struct {
+	VotesDeleted          int  `json:"votesDeleted"`
+	VotersCleared         int  `json:"votersCleared"`
+	HistoryEntriesCleared int  `json:"historyEntriesCleared"`
+	PollsReset            bool `json:"pollsReset"`
+	PollsDeleted          int  `json:"pollsDeleted"`
+}
+
+// Return a request to a downstream API with the admin token of the
+// gateway request, if any.
+func (ga *GatewayAPI) adminRequest(headers http.Header) *resty.Request {
+	request := ga.apiClient.R()
+	if value := headers.Get(AdminTokenHeader); value != "" {
+		request.SetHeader(AdminTokenHeader, value)
+	}
+
+	return request
+}
+
+// Answer the reset request and return false when a step of it failed: 502
+// or 503 when the endpoint failed, and its own status when it refused the
+// step, such as 401 without the admin token.
+func checkResetStep(c *gin.Context, endpoint endpoints.Endpoint, url string, resp *resty.Response, err error, message string) bool {
+	if err := downstreamError(endpoint, url, resp, err); err != nil {
+		log.Println("Error resetting: ", err)
+		apierror.AbortWithError(c, http.StatusBadGateway, message, err)
+		return false
+	}
+
+	if resp.IsError() {
+		log.Println("Error resetting: ", endpoint.Name+" returned "+resp.Status())
+		apierror.AbortWithDetails(c, resp.StatusCode(), apierror.CodeForStatus(resp.StatusCode()), message, endpoint.Name+" returned "+resp.Status())
+		return false
+	}
+
+	return true
+}
+
+// Implementation of POST /admin/reset?polls=true.
+// Wipe the test data of the services: every vote, then the vote history
+// of every voter, and with ?polls=true every poll. The voters themselves
+// are kept. The votes API checks the admin token of the request first, so
+// a reset without it changes nothing. The steps stop at the first that
+// fails; as each of them can be repeated, running the reset again
+// finishes it.
+func (ga *GatewayAPI) Reset(c *gin.Context) {
+	report := resetReport{PollsReset: c.Query("polls") == "true"}
+
+	var deleted struct {
+		Deleted int `json:"deleted"`
+	}
+	resp, err := ga.adminRequest(c.Request.Header).SetResult(&deleted).Delete(ga.votesAPIURL + "/votes")
+	if !checkResetStep(c, endpoints.VotesAPI, ga.votesAPIURL, resp, err, "Could not delete the votes") {
+		return
+	}
+	report.VotesDeleted = deleted.Deleted
+
+	voters, err := ga.listVoters()
+	if err != nil {
+		log.Println("Error getting voters: ", err)
+		apierror.AbortWithError(c, http.StatusBadGateway, "Could not get the vote histories of the voters", err)
+		return
+	}
+
+	for _, voter := range voters {
+		for _, entry := range voter.VoteHistory {
+			url := fmt.Sprintf("%s/voters/%d/polls/%d", ga.voterAPIURL, voter.VoterID, entry.PollID)
+			resp, err := ga.adminRequest(c.Request.Header).Delete(url)
+
+			// An entry removed since the voters were listed is cleared already.
+			if err == nil && resp.StatusCode() == http.StatusNotFound {
+				continue
+			}
+			if !checkResetStep(c, endpoints.VoterAPI, ga.voterAPIURL, resp, err, "Could not clear the vote history of a voter") {
+				return
+			}
+			report.HistoryEntriesCleared++
+		}
+
+		if len(voter.VoteHistory) > 0 {
+			report.VotersCleared++
+		}
+	}
+
+	if report.PollsReset {
+		polls, err := ga.listPolls()
+		if err != nil {
+			log.Println("Error getting polls: ", err)
+			apierror.AbortWithError(c, http.StatusBadGateway, "Could not get the polls", err)
+			return
+		}
+
+		resp, err := ga.adminRequest(c.Request.Header).Delete(ga.pollAPIURL + "/polls")
+		if !checkResetStep(c, endpoints.PollAPI, ga.pollAPIURL, resp, err, "Could not delete the polls") {
+			return
+		}
+		report.PollsDeleted = len(polls)
+	}
+
+	c.JSON(http.StatusOK, report)
+}
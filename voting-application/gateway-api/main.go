@@ -0,0 +1,23 @@
+package main
+
+import (
+	"gateway-api/api"
+
+	"shared/bootstrap"
+	"shared/endpoints"
+)
+
+func main() {
+	server := bootstrap.New("gateway-api", bootstrap.Config{
+		Port:      1084,
+		Endpoints: []endpoints.Endpoint{endpoints.VoterAPI, endpoints.PollAPI, endpoints.VotesAPI},
+	})
+
+	// Create a new instance of the GatewayAPI handler.
+	gatewayHandler := api.NewGatewayHandler(server.URL(endpoints.VoterAPI), server.URL(endpoints.PollAPI), server.URL(endpoints.VotesAPI))
+
+	server.Run(
+		bootstrap.WithMiddleware(api.HealthMiddleware(gatewayHandler)),
+		bootstrap.WithRoutes(routeTable(gatewayHandler).Register),
+	)
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"net/http"
+
+	"gateway-api/api"
+
+	"shared/routes"
+)
+
+var queryLimit = routes.PerMinute(300)
+
+// Define the API endpoints and map them to the corresponding handler. The
+// gateway forwards the admin and embargo tokens, the downstream APIs check
+// them.
+func routeTable(gatewayHandler *api.GatewayAPI) *routes.Table {
+	return &routes.Table{
+		Service: "gateway-api",
+		Welcome: "Welcome to gateway API.",
+		Routes: []routes.Route{
+			{Method: http.MethodPost, Path: "/graphql", Handler: gatewayHandler.ExecuteQuery, Summary: "Run a GraphQL query", Limit: queryLimit},
+			{Method: http.MethodGet, Path: "/graphql", Handler: gatewayHandler.ExecuteQueryString, Summary: "Run the GraphQL query of the query string", Limit: queryLimit},
+			{Method: http.MethodPost, Path: "/admin/reset", Handler: gatewayHandler.Reset, Summary: "Delete every vote and vote history, and every poll with ?polls=true, for cleaning up test environments"},
+			{Method: http.MethodGet, Path: "/gateway/health", Handler: gatewayHandler.HealthCheck, Summary: "Request metrics of the API", Access: routes.Internal},
+			{Method: http.MethodGet, Path: "/healthz", Handler: gatewayHandler.Liveness, Summary: "Liveness probe", Access: routes.Internal},
+			{Method: http.MethodGet, Path: "/readyz", Handler: gatewayHandler.Readiness, Summary: "Readiness probe", Access: routes.Internal},
+		},
+	}
+}
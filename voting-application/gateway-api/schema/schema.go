@@ -0,0 +1,48 @@
+package schema
+
+import "time"
+
+type VoterPoll struct {
+	PollID   uint
+	VoteDate time.Time
+}
+
+type Voter struct {
+	VoterID     uint
+	FirstName   string
+	LastName    string
+	VoteHistory []VoterPoll
+	Status      string
+}
+
+type PollOption struct {
+	PollOptionID   uint
+	PollOptionText string
+	MaxVotes       uint
+}
+
+type Poll struct {
+	PollID       uint
+	PollTitle    string
+	PollQuestion string
+	PollOptions  []PollOption
+	PollStatus   string
+}
+
+type Vote struct {
+	VoteID    uint
+	VoterID   uint
+	PollID    uint
+	VoteValue uint
+}
+
+type OptionResult struct {
+	OptionID uint
+	Votes    uint
+}
+
+type PollResults struct {
+	PollID     uint
+	TotalVotes uint
+	Results    []OptionResult
+}
@@ -0,0 +1,13 @@
+// Package integration tests the voter, poll and votes APIs end to end,
+// against the services of the Compose stack:
+//
+//	make integration-up
+//	make integration-test
+//	make integration-down
+//
+// The tests are behind the integration build tag, so go test ./... in the
+// other modules never needs the stack. They call the APIs at
+// VOTER_API_URL, POLL_API_URL and VOTES_API_URL, on localhost by default,
+// with the admin token of ADMIN_TOKEN, integration by default. Every test
+// uses IDs of its own, so they run against a stack that already has data.
+package integration
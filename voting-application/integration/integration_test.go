@@ -0,0 +1,267 @@
+//go:build integration
+
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"shared/pollclient"
+	"shared/restclient"
+	"shared/voterclient"
+	"shared/votesclient"
+)
+
+// Add the voters with the IDs, deleting them with their votes when the
+// test ends.
+func addVoters(t *testing.T, voterIDs ...uint) {
+	t.Helper()
+	ctx := testContext(t)
+
+	for _, voterID := range voterIDs {
+		voter := voterclient.Voter{VoterID: voterID, FirstName: "Voter", LastName: fmt.Sprint(voterID)}
+		if _, err := voters.AddVoter(ctx, voter); err != nil {
+			t.Fatalf("adding voter %d: %v", voterID, err)
+		}
+
+		voterID := voterID
+		t.Cleanup(func() { deleteVoterWithVotes(t, voterID) })
+	}
+}
+
+// Delete a voter and their votes, with DELETE /voters/:id?cascade=true.
+func deleteVoterWithVotes(t *testing.T, voterID uint) error {
+	_, err := voterREST.Do(testContext(t), restclient.Request{
+		Method: http.MethodDelete,
+		Path:   fmt.Sprintf("/voters/%d", voterID),
+		Query:  map[string]string{"cascade": "true"},
+	})
+	return err
+}
+
+// Add an open poll with an option for each text, numbered from 1, deleting
+// it when the test ends.
+func addPoll(t *testing.T, pollID uint, texts ...string) {
+	t.Helper()
+	ctx := testContext(t)
+
+	poll := pollclient.Poll{PollID: pollID, PollTitle: fmt.Sprintf("Poll %d", pollID), PollQuestion: "Which one?"}
+	if _, err := polls.AddPoll(ctx, poll); err != nil {
+		t.Fatalf("adding poll %d: %v", pollID, err)
+	}
+	t.Cleanup(func() { polls.DeletePoll(testContext(t), pollID) })
+
+	for i, text := range texts {
+		if _, err := polls.AddPollOption(ctx, pollID, uint(i+1), text, 0); err != nil {
+			t.Fatalf("adding option %d to poll %d: %v", i+1, pollID, err)
+		}
+	}
+}
+
+// Cast the vote of voterID for optionID in pollID.
+func castVote(t *testing.T, voteID, voterID, pollID, optionID uint) votesclient.Vote {
+	t.Helper()
+
+	vote := votesclient.Vote{VoteID: voteID, VoterID: voterID, PollID: pollID, VoteValue: optionID}
+	cast, err := votes.AddVote(testContext(t), vote, "")
+	if err != nil {
+		t.Fatalf("casting vote %d of voter %d in poll %d: %v", voteID, voterID, pollID, err)
+	}
+
+	return cast
+}
+
+// Check that err is an error response with status.
+func expectStatus(t *testing.T, what string, err error, status int) {
+	t.Helper()
+
+	if !restclient.IsStatus(err, status) {
+		t.Fatalf("%s: err = %v, want status %d", what, err, status)
+	}
+}
+
+// Check the total votes and the votes of each option of the results of a
+// poll.
+func expectResults(t *testing.T, pollID uint, total uint, optionVotes map[uint]uint) {
+	t.Helper()
+
+	results, err := votes.GetPollResults(testContext(t), pollID, "")
+	if err != nil {
+		t.Fatalf("getting the results of poll %d: %v", pollID, err)
+	}
+	if results.Meta["access"] != "admin" {
+		t.Fatalf("results of poll %d have access %v, want admin", pollID, results.Meta["access"])
+	}
+
+	if results.TotalVotes == nil || *results.TotalVotes != total {
+		t.Fatalf("poll %d has %v total votes, want %d", pollID, results.TotalVotes, total)
+	}
+
+	got := make(map[uint]uint, len(results.Results))
+	for _, result := range results.Results {
+		if result.Votes == nil {
+			t.Fatalf("option %d of poll %d has no count", result.OptionID, pollID)
+		}
+		got[result.OptionID] = *result.Votes
+	}
+	for optionID, want := range optionVotes {
+		if got[optionID] != want {
+			t.Fatalf("option %d of poll %d has %d votes, want %d", optionID, pollID, got[optionID], want)
+		}
+	}
+}
+
+// Return the IDs of the polls in the vote history of a voter.
+func votedIn(t *testing.T, voterID uint) map[uint]bool {
+	t.Helper()
+
+	history, err := voters.GetVoterHistory(testContext(t), voterID)
+	if err != nil {
+		t.Fatalf("getting the vote history of voter %d: %v", voterID, err)
+	}
+
+	pollIDs := make(map[uint]bool, len(history))
+	for _, entry := range history {
+		pollIDs[entry.PollID] = true
+	}
+
+	return pollIDs
+}
+
+func TestVotesAreTalliedAndKeptInTheHistory(t *testing.T) {
+	ctx := testContext(t)
+	base := newIDs()
+	pollID := base
+
+	addVoters(t, base+1, base+2, base+3)
+	addPoll(t, pollID, "Tea", "Coffee")
+
+	cast := castVote(t, base+1, base+1, pollID, 1)
+	if cast.Receipt == "" {
+		t.Fatalf("vote %d has no receipt", cast.VoteID)
+	}
+	castVote(t, base+2, base+2, pollID, 2)
+	castVote(t, base+3, base+3, pollID, 1)
+
+	vote, err := votes.GetVote(ctx, base+1)
+	if err != nil {
+		t.Fatalf("getting vote %d: %v", base+1, err)
+	}
+	if vote.VoterID != base+1 || vote.PollID != pollID || vote.VoteValue != 1 || vote.VoteDate == nil {
+		t.Fatalf("vote %d = %+v", base+1, vote)
+	}
+
+	pollVotes, err := votes.ListPollVotes(ctx, pollID)
+	if err != nil {
+		t.Fatalf("listing the votes of poll %d: %v", pollID, err)
+	}
+	if len(pollVotes) != 3 {
+		t.Fatalf("poll %d has %d votes, want 3", pollID, len(pollVotes))
+	}
+
+	for _, voterID := range []uint{base + 1, base + 2, base + 3} {
+		if !votedIn(t, voterID)[pollID] {
+			t.Fatalf("the vote history of voter %d does not have poll %d", voterID, pollID)
+		}
+	}
+
+	expectResults(t, pollID, 3, map[uint]uint{1: 2, 2: 1})
+
+	// A voter votes once per poll, and only in polls that exist.
+	_, err = votes.AddVote(ctx, votesclient.Vote{VoteID: base + 4, VoterID: base + 1, PollID: pollID, VoteValue: 2}, "")
+	expectStatus(t, "a second vote of a voter", err, http.StatusConflict)
+
+	_, err = votes.AddVote(ctx, votesclient.Vote{VoteID: base + 5, VoterID: base + 2, PollID: base + 99, VoteValue: 1}, "")
+	expectStatus(t, "a vote in an unknown poll", err, http.StatusNotFound)
+
+	_, err = votes.GetVote(ctx, base+4)
+	expectStatus(t, "getting the refused vote", err, http.StatusNotFound)
+
+	expectResults(t, pollID, 3, map[uint]uint{1: 2, 2: 1})
+}
+
+func TestDeletingAPollDeletesItsVotesAndHistory(t *testing.T) {
+	ctx := testContext(t)
+	base := newIDs()
+	deletedPollID, keptPollID := base, base+1
+
+	addVoters(t, base+1, base+2)
+	addPoll(t, deletedPollID, "Cricket", "Football")
+	addPoll(t, keptPollID, "Tea", "Coffee")
+
+	castVote(t, base+1, base+1, deletedPollID, 1)
+	castVote(t, base+2, base+2, deletedPollID, 2)
+	castVote(t, base+3, base+1, keptPollID, 2)
+
+	if err := polls.DeletePoll(ctx, deletedPollID); err != nil {
+		t.Fatalf("deleting poll %d: %v", deletedPollID, err)
+	}
+
+	_, err := polls.GetPoll(ctx, deletedPollID)
+	expectStatus(t, "getting the deleted poll", err, http.StatusNotFound)
+
+	pollVotes, err := votes.ListPollVotes(ctx, deletedPollID)
+	if err != nil {
+		t.Fatalf("listing the votes of poll %d: %v", deletedPollID, err)
+	}
+	if len(pollVotes) != 0 {
+		t.Fatalf("the deleted poll %d still has votes: %+v", deletedPollID, pollVotes)
+	}
+	for _, voteID := range []uint{base + 1, base + 2} {
+		_, err := votes.GetVote(ctx, voteID)
+		expectStatus(t, fmt.Sprintf("getting vote %d of the deleted poll", voteID), err, http.StatusNotFound)
+	}
+
+	for _, voterID := range []uint{base + 1, base + 2} {
+		_, err := voters.GetVoterPoll(ctx, voterID, deletedPollID)
+		expectStatus(t, fmt.Sprintf("getting the deleted poll in the history of voter %d", voterID), err, http.StatusNotFound)
+	}
+
+	// The votes of the other poll are kept.
+	if !votedIn(t, base+1)[keptPollID] {
+		t.Fatalf("the vote history of voter %d lost poll %d", base+1, keptPollID)
+	}
+	if _, err := votes.GetVote(ctx, base+3); err != nil {
+		t.Fatalf("getting vote %d of the kept poll: %v", base+3, err)
+	}
+	expectResults(t, keptPollID, 1, map[uint]uint{2: 1})
+}
+
+func TestDeletingAVoterWithVotes(t *testing.T) {
+	ctx := testContext(t)
+	base := newIDs()
+	pollID := base
+
+	addVoters(t, base+1, base+2)
+	addPoll(t, pollID, "Tea", "Coffee")
+
+	castVote(t, base+1, base+1, pollID, 1)
+	castVote(t, base+2, base+2, pollID, 2)
+
+	// A voter with votes is only deleted with ?cascade=true.
+	err := voters.DeleteVoter(ctx, base+1)
+	expectStatus(t, "deleting a voter with votes", err, http.StatusConflict)
+	if _, err := voters.GetVoter(ctx, base+1); err != nil {
+		t.Fatalf("getting voter %d after a refused delete: %v", base+1, err)
+	}
+
+	if err := deleteVoterWithVotes(t, base+1); err != nil {
+		t.Fatalf("deleting voter %d with their votes: %v", base+1, err)
+	}
+
+	_, err = voters.GetVoter(ctx, base+1)
+	expectStatus(t, "getting the deleted voter", err, http.StatusNotFound)
+	_, err = votes.GetVote(ctx, base+1)
+	expectStatus(t, "getting the vote of the deleted voter", err, http.StatusNotFound)
+
+	voterVotes, err := votes.ListVoterVotes(ctx, base+1)
+	if err != nil {
+		t.Fatalf("listing the votes of voter %d: %v", base+1, err)
+	}
+	if len(voterVotes) != 0 {
+		t.Fatalf("the deleted voter %d still has votes: %+v", base+1, voterVotes)
+	}
+
+	expectResults(t, pollID, 1, map[uint]uint{1: 0, 2: 1})
+}
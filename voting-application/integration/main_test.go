@@ -0,0 +1,132 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"shared/endpoints"
+	"shared/pollclient"
+	"shared/restclient"
+	"shared/voterclient"
+	"shared/votesclient"
+)
+
+// How long TestMain waits for the services to be ready.
+const readyTimeout = time.Minute
+
+var (
+	voterURL   = envOr("VOTER_API_URL", "http://localhost:1080")
+	pollURL    = envOr("POLL_API_URL", "http://localhost:1081")
+	votesURL   = envOr("VOTES_API_URL", "http://localhost:1082")
+	adminToken = envOr("ADMIN_TOKEN", "integration")
+
+	// The clients send the admin token, so the tests read the results of
+	// polls that are not released yet.
+	voters *voterclient.Client
+	polls  *pollclient.Client
+	votes  *votesclient.Client
+	// A raw client of the voter API, for the calls voterclient has no
+	// method for.
+	voterREST *restclient.Client
+)
+
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func TestMain(m *testing.M) {
+	if err := setup(); err != nil {
+		fmt.Fprintln(os.Stderr, "integration:", err)
+		fmt.Fprintln(os.Stderr, "integration: start the services with make integration-up")
+		os.Exit(1)
+	}
+
+	os.Exit(m.Run())
+}
+
+func setup() error {
+	for _, url := range []string{voterURL, pollURL, votesURL} {
+		if err := waitReady(url); err != nil {
+			return err
+		}
+	}
+
+	config := restclient.Config{
+		Timeout: 10 * time.Second,
+		Headers: map[string]string{votesclient.AdminTokenHeader: adminToken},
+	}
+
+	var err error
+	config.BaseURL = voterURL
+	if voters, err = voterclient.New(config); err != nil {
+		return err
+	}
+	if voterREST, err = restclient.New(endpoints.VoterAPI, config); err != nil {
+		return err
+	}
+
+	config.BaseURL = pollURL
+	if polls, err = pollclient.New(config); err != nil {
+		return err
+	}
+
+	config.BaseURL = votesURL
+	if votes, err = votesclient.New(config); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Wait until the API at url answers its readiness probe with 200.
+func waitReady(url string) error {
+	deadline := time.Now().Add(readyTimeout)
+	for {
+		response, err := http.Get(url + "/readyz")
+		if err == nil {
+			response.Body.Close()
+			if response.StatusCode == http.StatusOK {
+				return nil
+			}
+			err = fmt.Errorf("readiness probe answered %s", response.Status)
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%s is not ready after %s: %v", url, readyTimeout, err)
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+var lastID atomic.Uint32
+
+// Return the first of a block of 100 IDs no other test or earlier run
+// uses, for the voters, polls and votes of a test.
+func newIDs() uint {
+	if lastID.Load() == 0 {
+		// Runs a second apart start a million IDs apart. The base wraps
+		// after about an hour, inside the uint32 IDs of the APIs.
+		lastID.CompareAndSwap(0, uint32(time.Now().Unix()%4000)*1000000)
+	}
+	return uint(lastID.Add(100))
+}
+
+// Return the context of the calls of a test, canceled when it ends.
+func testContext(t *testing.T) context.Context {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	t.Cleanup(cancel)
+
+	return ctx
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+
+	"shared/endpoints"
+)
+
+// pollCascade is the votes-api report of the votes and vote history
+// entries of a poll it removed, or would remove in a dry run.
+type pollCascade struct {
+	PollID          uint   `json:"pollId"`
+	DryRun          bool   `json:"dryRun"`
+	VoteIDs         []uint `json:"voteIds"`
+	OrphanedHistory []uint `json:"orphanedHistory"`
+}
+
+// Ask the votes API to delete the votes of a poll and strip the poll from
+// the vote histories, or only to report what it would remove.
+func (pa *PollAPI) cascadePollDelete(pollID uint, dryRun bool) (pollCascade, error) {
+	var cascade pollCascade
+	cascadePath := fmt.Sprintf("%s/votes/by-poll/%d", pa.votesAPIURL, pollID)
+
+	request := pa.apiClient.R().SetResult(&cascade)
+	if dryRun {
+		request.SetQueryParam("dryRun", "true")
+	}
+
+	resp, err := request.Delete(cascadePath)
+	if err != nil {
+		return pollCascade{}, endpoints.VotesAPI.Unreachable(pa.votesAPIURL, err)
+	}
+
+	if resp.IsError() {
+		return pollCascade{}, errors.New("votes API returned " + resp.Status())
+	}
+
+	return cascade, nil
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Closing a poll ends its voting and freezes its results, so like
+// certifying it requires the admin token.
+func TestClosePollRequiresTheAdminToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("ADMIN_TOKEN", "secret")
+
+	r := gin.New()
+	r.POST("/polls/:id/close", (&PollAPI{}).ClosePoll)
+
+	for name, token := range map[string]string{"no token": "", "wrong token": "guess"} {
+		request := httptest.NewRequest(http.MethodPost, "/polls/1/close", nil)
+		if token != "" {
+			request.Header.Set(AdminTokenHeader, token)
+		}
+
+		recorder := httptest.NewRecorder()
+		r.ServeHTTP(recorder, request)
+
+		if recorder.Code != http.StatusUnauthorized {
+			t.Fatalf("POST /polls/1/close with %s = %d, want %d", name, recorder.Code, http.StatusUnauthorized)
+		}
+	}
+}
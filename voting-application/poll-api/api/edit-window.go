@@ -0,0 +1,86 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"poll-api/poll"
+
+	"shared/apierror"
+	"shared/endpoints"
+
+	"github.com/gin-gonic/gin"
+)
+
+// vote is the subset of a votes-api vote needed to enforce the edit window.
+type vote struct {
+	VoteID uint `json:"voteId"`
+	PollID uint `json:"pollId"`
+}
+
+// Count the votes recorded for a poll by asking the votes API for the
+// votes of the poll alone, with ?pollId=.
+func (pa *PollAPI) countPollVotes(pollID uint) (int, error) {
+	var votes []vote
+	votesPath := pa.votesAPIURL + "/votes"
+
+	resp, err := pa.apiClient.R().
+		SetQueryParam("pollId", strconv.FormatUint(uint64(pollID), 10)).
+		SetResult(&votes).
+		Get(votesPath)
+	if err != nil {
+		return 0, endpoints.VotesAPI.Unreachable(pa.votesAPIURL, err)
+	}
+
+	if resp.IsError() {
+		return 0, errors.New("votes API returned " + resp.Status())
+	}
+
+	return len(votes), nil
+}
+
+// Enforce the edit window of a poll. Question and option mutations are
+// blocked once the first vote is recorded, unless the request is sent by
+// an admin with ?override=true, in which case the mutation is audited.
+// Returns true when the caller may proceed; otherwise the response has
+// already been written.
+func (pa *PollAPI) checkEditWindow(c *gin.Context, pollID uint, action string) bool {
+	voteCount, err := pa.countPollVotes(pollID)
+	if err != nil {
+		log.Println("Error checking votes for poll: ", err)
+	}
+
+	if err == nil && voteCount == 0 {
+		return true
+	}
+
+	if c.Query("override") == "true" && isAdminRequest(c) {
+		entry := poll.AuditEntry{
+			Action:    action,
+			PollID:    pollID,
+			Detail:    fmt.Sprintf("edit window override with %d recorded votes", voteCount),
+			Timestamp: time.Now(),
+		}
+
+		if err := pa.pollList.AddAuditEntry(entry); err != nil {
+			log.Println("Error writing audit entry: ", err)
+			apierror.AbortWithError(c, http.StatusInternalServerError, "Could not write audit entry", err)
+			return false
+		}
+
+		log.Printf("AUDIT: %s on poll %d overrode the edit window (%d votes)", action, pollID, voteCount)
+		return true
+	}
+
+	if err != nil {
+		apierror.AbortWithDetails(c, http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, "Could not verify votes for poll", err)
+		return false
+	}
+
+	apierror.Abort(c, http.StatusConflict, apierror.CodeConflict, "Poll can not be edited after voting has started")
+	return false
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+)
+
+func TestCountPollVotesAsksForThePollOnly(t *testing.T) {
+	var queries []string
+	votesAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+
+		votes := []vote{}
+		if r.URL.Path == "/votes" && r.URL.Query().Get("pollId") == "7" {
+			votes = []vote{{VoteID: 1, PollID: 7}, {VoteID: 2, PollID: 7}}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(votes)
+	}))
+	defer votesAPI.Close()
+
+	pa := &PollAPI{apiClient: resty.New(), votesAPIURL: votesAPI.URL}
+
+	count, err := pa.countPollVotes(7)
+	if err != nil {
+		t.Fatalf("countPollVotes(7): %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("countPollVotes(7) = %d, want 2", count)
+	}
+
+	if count, err := pa.countPollVotes(8); err != nil || count != 0 {
+		t.Fatalf("countPollVotes(8) = %d, %v, want 0", count, err)
+	}
+
+	if len(queries) != 2 || queries[0] != "pollId=7" || queries[1] != "pollId=8" {
+		t.Fatalf("the votes API was asked for %q, want pollId=7 and pollId=8", queries)
+	}
+}
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"poll-api/poll"
+
+	"shared/apierror"
+	"shared/events"
+	"shared/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Implementation of PUT /polls/:id/eligibility.
+// Set the eligibility rules of the poll with :id. A body without rules
+// lets anyone vote again; the allowlist is kept either way. Like the
+// question, the rules are locked once the poll has votes.
+func (pa *PollAPI) SetPollEligibility(c *gin.Context) {
+	pollID := c.Param("id")
+	pollIDUint, err := strconv.ParseUint(pollID, 10, 32)
+	if err != nil {
+		log.Println("Error converting poll ID to uint: ", err)
+		apierror.AbortInvalidID(c, "Poll ID", err)
+		return
+	}
+
+	var eligibility poll.Eligibility
+	if err := validation.Bind(c, &eligibility); err != nil {
+		log.Println("Error binding JSON: ", err)
+		return
+	}
+
+	if !pa.checkEditWindow(c, uint(pollIDUint), "set-poll-eligibility") {
+		return
+	}
+
+	updatedPoll, err := pa.pollList.SetPollEligibility(uint(pollIDUint), poll.NormalizeEligibility(&eligibility))
+	if err != nil {
+		log.Println("Error setting poll eligibility: ", err)
+		apierror.AbortWithError(c, http.StatusNotFound, "Could not set poll eligibility", err)
+		return
+	}
+
+	pa.recordPollChange(events.EventTypePollUpdated, pollChanged(updatedPoll))
+
+	c.JSON(http.StatusOK, updatedPoll)
+}
+
+// Implementation of GET /polls/:id/eligibility/voters.
+// Returns the voter IDs on the allowlist of the poll with :id, in order.
+func (pa *PollAPI) GetEligibleVoters(c *gin.Context) {
+	pollID := c.Param("id")
+	pollIDUint, err := strconv.ParseUint(pollID, 10, 32)
+	if err != nil {
+		log.Println("Error converting poll ID to uint: ", err)
+		apierror.AbortInvalidID(c, "Poll ID", err)
+		return
+	}
+
+	voterIDs, err := pa.pollList.GetEligibleVoters(uint(pollIDUint))
+	if err != nil {
+		log.Println("Error getting eligible voters: ", err)
+		apierror.AbortWithError(c, http.StatusNotFound, "Could not get eligible voters", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pollId":   pollIDUint,
+		"voterIds": voterIDs,
+	})
+}
+
+// Implementation of POST /polls/:id/eligibility/voters.
+// Add the voterIds of the body to the allowlist of the poll with :id.
+// Voters already on it are left as they are.
+func (pa *PollAPI) AddEligibleVoters(c *gin.Context) {
+	pollID := c.Param("id")
+	pollIDUint, err := strconv.ParseUint(pollID, 10, 32)
+	if err != nil {
+		log.Println("Error converting poll ID to uint: ", err)
+		apierror.AbortInvalidID(c, "Poll ID", err)
+		return
+	}
+
+	var requestBody struct {
+		VoterIDs []uint `json:"voterIds" binding:"required,min=1,max=1000,dive,required"`
+	}
+
+	if err := validation.Bind(c, &requestBody); err != nil {
+		log.Println("Error parsing JSON request body: ", err)
+		return
+	}
+
+	added, err := pa.pollList.AddEligibleVoters(uint(pollIDUint), requestBody.VoterIDs)
+	if err != nil {
+		log.Println("Error adding eligible voters: ", err)
+		apierror.AbortWithError(c, http.StatusNotFound, "Could not add eligible voters", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pollId": pollIDUint,
+		"added":  added,
+	})
+}
+
+// Implementation of GET /polls/:id/eligibility/voters/:voterId.
+// Returns 200 when the voter with :voterId is on the allowlist of the poll
+// with :id, 404 when it is not.
+func (pa *PollAPI) GetEligibleVoter(c *gin.Context) {
+	pollIDUint, voterIDUint, ok := eligibleVoterParams(c)
+	if !ok {
+		return
+	}
+
+	eligible, err := pa.pollList.IsEligibleVoter(pollIDUint, voterIDUint)
+	if err != nil {
+		log.Println("Error checking eligible voter: ", err)
+		apierror.AbortWithError(c, http.StatusNotFound, "Could not check eligible voter", err)
+		return
+	}
+
+	if !eligible {
+		apierror.Abort(c, http.StatusNotFound, apierror.CodeNotFound, poll.ErrNotOnAllowlist.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pollId":  pollIDUint,
+		"voterId": voterIDUint,
+	})
+}
+
+// Implementation of DELETE /polls/:id/eligibility/voters/:voterId.
+// Remove the voter with :voterId from the allowlist of the poll with :id.
+func (pa *PollAPI) RemoveEligibleVoter(c *gin.Context) {
+	pollIDUint, voterIDUint, ok := eligibleVoterParams(c)
+	if !ok {
+		return
+	}
+
+	if err := pa.pollList.RemoveEligibleVoter(pollIDUint, voterIDUint); err != nil {
+		log.Println("Error removing eligible voter: ", err)
+		if errors.Is(err, poll.ErrNotOnAllowlist) {
+			apierror.Abort(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+			return
+		}
+		apierror.AbortWithError(c, http.StatusNotFound, "Could not remove eligible voter", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Eligible voter removed successfully.",
+	})
+}
+
+// Parse the :id and :voterId of an allowlist path, aborting the request
+// when either is invalid.
+func eligibleVoterParams(c *gin.Context) (uint, uint, bool) {
+	pollIDUint, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		log.Println("Error converting poll ID to uint: ", err)
+		apierror.AbortInvalidID(c, "Poll ID", err)
+		return 0, 0, false
+	}
+
+	voterIDUint, err := strconv.ParseUint(c.Param("voterId"), 10, 32)
+	if err != nil {
+		log.Println("Error converting voter ID to uint: ", err)
+		apierror.AbortInvalidID(c, "Voter ID", err)
+		return 0, 0, false
+	}
+
+	return uint(pollIDUint), uint(voterIDUint), true
+}
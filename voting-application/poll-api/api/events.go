@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"poll-api/poll"
+
+	"shared/events"
+)
+
+const (
+	DefaultPollEventsStream = "events:polls"
+)
+
+// Return the stream poll events are published to, POLL_EVENTS_STREAM or
+// events:polls. Publishing is disabled when it is set to "off".
+func pollEventsStream() string {
+	stream := os.Getenv("POLL_EVENTS_STREAM")
+	if stream == "" {
+		return DefaultPollEventsStream
+	}
+
+	return stream
+}
+
+// Return the event of a change to a poll.
+func pollChanged(p poll.Poll) events.PollChanged {
+	return events.PollChanged{
+		PollID:     p.PollID,
+		PollTitle:  p.PollTitle,
+		PollStatus: p.PollStatus,
+		ChangedAt:  time.Now().UTC(),
+	}
+}
+
+// Record a change to a poll: bump its version, so the ETags and cached
+// responses of the poll no longer match, and publish the event.
+func (pa *PollAPI) recordPollChange(eventType string, event events.PollChanged) {
+	pa.invalidatePoll(event.PollID)
+	pa.publishPollEvent(eventType, event)
+}
+
+// Publish a poll lifecycle or mutation event. A failure to publish is
+// logged and does not fail the change.
+func (pa *PollAPI) publishPollEvent(eventType string, event events.PollChanged) {
+	if pa.pollEventsStream == "off" {
+		return
+	}
+
+	if event.ChangedAt.IsZero() {
+		event.ChangedAt = time.Now().UTC()
+	}
+
+	if _, err := events.Publish(context.Background(), pa.pollCache.RedisClient(), pa.pollEventsStream, eventType, event); err != nil {
+		log.Printf("Error publishing %s event: %v", eventType, err)
+	}
+}
+
+// Record a change to the options of a poll.
+func (pa *PollAPI) recordOptionsChange(pollID, optionID uint) {
+	pa.recordPollChange(events.EventTypePollOptionsChanged, events.PollChanged{PollID: pollID, OptionID: optionID})
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"shared/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// How many expirations GET /admin/expirations returns without ?limit=.
+const DefaultExpirationsLimit = 100
+
+// Implementation of GET /admin/expirations.
+// Lists the polls whose keys have a TTL, the first to expire first, up to
+// ?limit=, with the TTL of draft polls.
+func (pa *PollAPI) GetExpirations(c *gin.Context) {
+	if pa.pollCache == nil {
+		apierror.Abort(c, http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, "Redis is not connected")
+		return
+	}
+
+	limit := DefaultExpirationsLimit
+	if query := c.Query("limit"); query != "" {
+		parsed, err := strconv.Atoi(query)
+		if err != nil || parsed < 1 {
+			apierror.Abort(c, http.StatusBadRequest, apierror.CodeBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = parsed
+	}
+
+	expirations, err := pa.pollCache.GetExpirations()
+	if err != nil {
+		log.Println("Error listing poll expirations: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not list poll expirations", err)
+		return
+	}
+
+	total := len(expirations)
+	if len(expirations) > limit {
+		expirations = expirations[:limit]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"policy": gin.H{
+			"draftPollDays": pa.pollCache.DraftTTL().Hours() / 24,
+		},
+		"total":       total,
+		"expirations": expirations,
+	})
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"poll-api/poll"
+
+	"shared/export"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The CSV columns of a poll export.
+var pollExportHeader = []string{"pollId", "pollTitle", "pollQuestion", "pollStatus", "seriesId", "createdAt", "closedAt", "certifiedAt", "pollOptions", "tags", "anonymous", "maxSelections", "allowWriteIn"}
+
+// Implementation of GET /polls/export?format=csv|json.
+// Stream every poll as CSV or as a JSON array, row by row as they are read
+// from the store. In CSV the options are a list of optionId=text and the
+// tags a list of tags, both separated by semicolons.
+func (pa *PollAPI) ExportPolls(c *gin.Context) {
+	writer := export.NewWriter(c, "polls", pollExportHeader)
+	if writer == nil {
+		return
+	}
+
+	err := pa.pollList.EachPoll(func(p poll.Poll) error {
+		options := make([]string, len(p.PollOptions))
+		for i, option := range p.PollOptions {
+			options[i] = fmt.Sprintf("%d=%s", option.PollOptionID, option.PollOptionText)
+		}
+
+		return writer.Write(p, []string{
+			export.Uint(p.PollID),
+			p.PollTitle,
+			p.PollQuestion,
+			p.PollStatus,
+			export.Uint(p.SeriesID),
+			p.CreatedAt.Format(time.RFC3339),
+			formatExportTime(p.ClosedAt),
+			formatExportTime(p.CertifiedAt),
+			strings.Join(options, ";"),
+			strings.Join(p.Tags, ";"),
+			strconv.FormatBool(p.Anonymous),
+			export.Uint(p.MaxSelections),
+			strconv.FormatBool(p.AllowWriteIn),
+		})
+	})
+	if err != nil {
+		log.Println("Error exporting polls: ", err)
+	}
+
+	writer.Close(err)
+}
+
+// Format an optional time for a CSV row, empty when it is not set.
+func formatExportTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
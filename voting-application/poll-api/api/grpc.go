@@ -0,0 +1,145 @@
+package api
+
+import (
+	"context"
+	"log"
+
+	"poll-api/poll"
+
+	"shared/events"
+	"shared/rpc"
+	"shared/votingpb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// pollServer serves the PollService over gRPC from the same store as the
+// REST endpoints.
+type pollServer struct {
+	votingpb.UnimplementedPollServiceServer
+	pa *PollAPI
+}
+
+// Register the PollService on a gRPC server.
+func (pa *PollAPI) RegisterGRPC(server *grpc.Server) {
+	votingpb.RegisterPollServiceServer(server, &pollServer{pa: pa})
+}
+
+// Convert a poll to its protobuf message.
+func pollToProto(p poll.Poll) *votingpb.Poll {
+	options := make([]*votingpb.PollOption, len(p.PollOptions))
+	for i, option := range p.PollOptions {
+		options[i] = &votingpb.PollOption{
+			PollOptionId:   uint32(option.PollOptionID),
+			PollOptionText: option.PollOptionText,
+			MaxVotes:       uint32(option.MaxVotes),
+		}
+	}
+
+	return &votingpb.Poll{
+		PollId:       uint32(p.PollID),
+		PollTitle:    p.PollTitle,
+		PollQuestion: p.PollQuestion,
+		PollOptions:  options,
+		PollStatus:   p.PollStatus,
+		SeriesId:     uint32(p.SeriesID),
+		CreatedAt:    rpc.Timestamp(&p.CreatedAt),
+		ClosedAt:     rpc.Timestamp(p.ClosedAt),
+		CertifiedAt:  rpc.Timestamp(p.CertifiedAt),
+	}
+}
+
+// Check the edit window of a poll. The admin override of the REST
+// endpoints is not available over gRPC.
+func (s *pollServer) checkEditWindow(pollID uint) error {
+	voteCount, err := s.pa.countPollVotes(pollID)
+	if err != nil {
+		log.Println("Error checking votes for poll: ", err)
+		return status.Error(codes.Unavailable, "could not verify votes for poll")
+	}
+
+	if voteCount > 0 {
+		return status.Error(codes.FailedPrecondition, "poll can not be edited after voting has started")
+	}
+
+	return nil
+}
+
+func (s *pollServer) ListPolls(ctx context.Context, request *votingpb.ListPollsRequest) (*votingpb.ListPollsResponse, error) {
+	polls, err := s.pa.pollList.GetAllPolls()
+	if err != nil {
+		log.Println("Error getting polls: ", err)
+		return nil, status.Error(codes.Internal, "could not get polls")
+	}
+
+	response := &votingpb.ListPollsResponse{Polls: make([]*votingpb.Poll, len(polls))}
+	for i, p := range polls {
+		response.Polls[i] = pollToProto(p)
+	}
+
+	return response, nil
+}
+
+func (s *pollServer) GetPoll(ctx context.Context, request *votingpb.GetPollRequest) (*votingpb.Poll, error) {
+	p, err := s.pa.pollList.GetPoll(uint(request.PollId))
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+
+	return pollToProto(p), nil
+}
+
+func (s *pollServer) AddPoll(ctx context.Context, request *votingpb.AddPollRequest) (*votingpb.Poll, error) {
+	if request.Poll == nil {
+		return nil, status.Error(codes.InvalidArgument, "poll is required")
+	}
+
+	newPoll := poll.NewPoll(uint(request.Poll.PollId), request.Poll.PollTitle, request.Poll.PollQuestion)
+	newPoll.SeriesID = uint(request.Poll.SeriesId)
+
+	if err := s.pa.pollList.AddPoll(newPoll); err != nil {
+		log.Println("Error adding poll: ", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	s.pa.recordPollChange(events.EventTypePollCreated, pollChanged(newPoll))
+
+	return pollToProto(newPoll), nil
+}
+
+func (s *pollServer) UpdatePoll(ctx context.Context, request *votingpb.UpdatePollRequest) (*votingpb.Poll, error) {
+	if request.Poll == nil {
+		return nil, status.Error(codes.InvalidArgument, "poll is required")
+	}
+
+	pollID := uint(request.Poll.PollId)
+	if err := s.checkEditWindow(pollID); err != nil {
+		return nil, err
+	}
+
+	updatedPoll, err := s.pa.pollList.UpdatePoll(poll.Poll{
+		PollID:       pollID,
+		PollTitle:    request.Poll.PollTitle,
+		PollQuestion: request.Poll.PollQuestion,
+	})
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+
+	s.pa.recordPollChange(events.EventTypePollUpdated, pollChanged(updatedPoll))
+
+	return pollToProto(updatedPoll), nil
+}
+
+func (s *pollServer) DeletePoll(ctx context.Context, request *votingpb.DeletePollRequest) (*emptypb.Empty, error) {
+	if err := s.pa.pollList.DeletePoll(uint(request.PollId)); err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+
+	s.pa.recordPollChange(events.EventTypePollDeleted, events.PollChanged{PollID: uint(request.PollId)})
+
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,203 @@
+package api
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"poll-api/poll"
+
+	"shared/negotiate"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// A poll with options, an eligibility and a schedule.
+const testPollJSON = `{
+	"pollId": 7,
+	"pollTitle": "Favorite pet",
+	"pollQuestion": "Which pet do you like best?",
+	"pollOptions": [
+		{"pollOptionId": 1, "pollOptionText": "Dog", "maxVotes": 10},
+		{"pollOptionId": 2, "pollOptionText": "Cat"}
+	],
+	"pollStatus": "open",
+	"createdAt": "2026-10-14T12:00:00Z",
+	"eligibility": {"voterIdRanges": [{"from": 1, "to": 100}], "allowlist": true},
+	"startTime": "2026-10-15T08:00:00Z"
+}`
+
+func testPoll(t *testing.T) poll.Poll {
+	t.Helper()
+
+	var p poll.Poll
+	if err := json.Unmarshal([]byte(testPollJSON), &p); err != nil {
+		t.Fatalf("decoding test poll: %v", err)
+	}
+
+	return p
+}
+
+func TestPollResponseXML(t *testing.T) {
+	data, err := xml.Marshal(negotiate.NewList("polls", pollResponses([]poll.Poll{testPoll(t)})))
+	if err != nil {
+		t.Fatalf("encoding polls as XML: %v", err)
+	}
+
+	var polls struct {
+		XMLName xml.Name `xml:"polls"`
+		Polls   []struct {
+			PollID      uint   `xml:"pollId"`
+			PollTitle   string `xml:"pollTitle"`
+			PollOptions []struct {
+				PollOptionID   uint   `xml:"pollOptionId"`
+				PollOptionText string `xml:"pollOptionText"`
+				MaxVotes       uint   `xml:"maxVotes"`
+			} `xml:"pollOptions>option"`
+			Ranges []struct {
+				From uint `xml:"from,attr"`
+				To   uint `xml:"to,attr"`
+			} `xml:"eligibility>voterIdRanges>range"`
+			StartTime string  `xml:"startTime"`
+			EndTime   *string `xml:"endTime"`
+			Links     struct {
+				Get struct {
+					URL string `xml:"url,attr"`
+				} `xml:"get"`
+			} `xml:"links"`
+		} `xml:"poll"`
+	}
+	if err := xml.Unmarshal(data, &polls); err != nil {
+		t.Fatalf("decoding %s: %v", data, err)
+	}
+
+	if len(polls.Polls) != 1 {
+		t.Fatalf("XML has %d polls, want 1: %s", len(polls.Polls), data)
+	}
+	p := polls.Polls[0]
+	if p.PollID != 7 || p.PollTitle != "Favorite pet" || len(p.PollOptions) != 2 || p.PollOptions[0].MaxVotes != 10 {
+		t.Fatalf("XML poll = %+v", p)
+	}
+	if len(p.Ranges) != 1 || p.Ranges[0].From != 1 || p.Ranges[0].To != 100 {
+		t.Fatalf("XML voter ID ranges = %+v, want 1 to 100", p.Ranges)
+	}
+	if p.StartTime != "2026-10-15T08:00:00Z" || p.EndTime != nil {
+		t.Fatalf("XML startTime = %q and endTime = %v, want the start time only", p.StartTime, p.EndTime)
+	}
+	if p.Links.Get.URL != "/polls/7" {
+		t.Fatalf("XML get link = %q, want /polls/7", p.Links.Get.URL)
+	}
+}
+
+func TestPollResponseJSONAndMsgpack(t *testing.T) {
+	response := newPollResponse(testPoll(t))
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		t.Fatalf("encoding poll as JSON: %v", err)
+	}
+	var fromJSON map[string]interface{}
+	if err := json.Unmarshal(data, &fromJSON); err != nil {
+		t.Fatalf("decoding %s: %v", data, err)
+	}
+
+	data, err = msgpack.Marshal(response)
+	if err != nil {
+		t.Fatalf("encoding poll as msgpack: %v", err)
+	}
+	var fromMsgpack map[string]interface{}
+	if err := msgpack.Unmarshal(data, &fromMsgpack); err != nil {
+		t.Fatalf("decoding msgpack: %v", err)
+	}
+
+	// Both formats have the same fields, and the ones without a value.
+	for _, field := range []string{"pollId", "pollOptions", "closedAt", "endTime", "eligibility", "links"} {
+		if _, ok := fromJSON[field]; !ok {
+			t.Errorf("JSON has no %s", field)
+		}
+		if _, ok := fromMsgpack[field]; !ok {
+			t.Errorf("msgpack has no %s", field)
+		}
+	}
+	if len(fromJSON) != len(fromMsgpack) {
+		t.Fatalf("JSON has %d fields and msgpack %d", len(fromJSON), len(fromMsgpack))
+	}
+}
+
+// Serve a versioned read of the test poll at version 3 with accept as the
+// Accept header, counting the loads of the poll.
+func serveTestPoll(t *testing.T, pa *PollAPI, loads *int, accept, ifNoneMatch string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	r := gin.New()
+	r.GET("/polls/7", func(c *gin.Context) {
+		version := func() (int64, error) { return 3, nil }
+		pa.serveVersioned(c, pollCacheKey(7), version, func() (interface{}, bool) {
+			*loads++
+			return newPollResponse(testPoll(t)), true
+		})
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/polls/7", nil)
+	request.Header.Set("Accept", accept)
+	if ifNoneMatch != "" {
+		request.Header.Set("If-None-Match", ifNoneMatch)
+	}
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func TestServeVersionedFormats(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	pa := &PollAPI{responseCache: newResponseCache(10)}
+	loads := 0
+
+	tests := []struct {
+		accept      string
+		etag        string
+		contentType string
+	}{
+		{"application/json", `"poll:7-v3"`, "application/json; charset=utf-8"},
+		{"application/xml", `"poll:7-v3-xml"`, "application/xml; charset=utf-8"},
+		{"application/msgpack", `"poll:7-v3-msgpack"`, "application/msgpack; charset=utf-8"},
+	}
+
+	for _, test := range tests {
+		recorder := serveTestPoll(t, pa, &loads, test.accept, "")
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("Accept: %s: status = %d, want %d", test.accept, recorder.Code, http.StatusOK)
+		}
+		if got := recorder.Header().Get("ETag"); got != test.etag {
+			t.Errorf("Accept: %s: ETag = %s, want %s", test.accept, got, test.etag)
+		}
+		if got := recorder.Header().Get("Content-Type"); got != test.contentType {
+			t.Errorf("Accept: %s: Content-Type = %q, want %q", test.accept, got, test.contentType)
+		}
+
+		recorder = serveTestPoll(t, pa, &loads, test.accept, test.etag)
+		if recorder.Code != http.StatusNotModified {
+			t.Errorf("Accept: %s with its ETag: status = %d, want %d", test.accept, recorder.Code, http.StatusNotModified)
+		}
+	}
+
+	// The response is cached once and rendered in every format.
+	if loads != 1 {
+		t.Fatalf("the poll was loaded %d times, want 1", loads)
+	}
+
+	// A JSON ETag does not match the XML response.
+	if recorder := serveTestPoll(t, pa, &loads, "application/xml", `"poll:7-v3"`); recorder.Code != http.StatusOK {
+		t.Fatalf("Accept: application/xml with the JSON ETag: status = %d, want %d", recorder.Code, http.StatusOK)
+	}
+
+	if recorder := serveTestPoll(t, pa, &loads, "text/csv", ""); recorder.Code != http.StatusNotAcceptable {
+		t.Fatalf("Accept: text/csv: status = %d, want %d", recorder.Code, http.StatusNotAcceptable)
+	}
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"log"
+	"math"
+
+	"github.com/gin-gonic/gin"
+)
+
+// optionStat is the standing of an option in the results of its poll.
+type optionStat struct {
+	Votes uint `json:"votes" xml:"votes" msgpack:"votes"`
+	// The share of the votes of the poll that selected the option, from 0
+	// to 100.
+	Percentage float64 `json:"percentage" xml:"percentage" msgpack:"percentage"`
+}
+
+// Return the standing of every option of a poll by option ID, from the
+// results the votes API gives the caller: results that are not released
+// to the caller, or a votes API that cannot be reached, give no stats and
+// false.
+func (pa *PollAPI) getOptionStats(c *gin.Context, pollID uint) (map[uint]optionStat, bool) {
+	results, err := pa.getPollResults(c, pollID)
+	if err != nil {
+		log.Println("Error getting poll results: ", err)
+		return nil, false
+	}
+
+	stats := make(map[uint]optionStat, len(results.Results))
+	for _, result := range results.Results {
+		percentage := 0.0
+		if results.TotalVotes > 0 {
+			percentage = math.Round(float64(result.Votes)/float64(results.TotalVotes)*10000) / 100
+		}
+
+		stats[result.OptionID] = optionStat{Votes: result.Votes, Percentage: percentage}
+	}
+
+	return stats, true
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"poll-api/poll"
+
+	"shared/apierror"
+	"shared/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The error codes of the conflicts of poll options, so clients can tell a
+// taken option ID from a repeated option text.
+const (
+	CodeDuplicateOptionID   = "duplicate_option_id"
+	CodeDuplicateOptionText = "duplicate_option_text"
+)
+
+// Answer the errors of invalid or conflicting poll options: 422 on the
+// field at fault, or 409 with a code of its own for a duplicate. It
+// returns false for any other error, which the caller answers.
+func abortWithOptionError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, poll.ErrEmptyOptionText):
+		validation.AbortWithFields(c, []validation.FieldError{{
+			Field:   "optionText",
+			Rule:    "required",
+			Message: err.Error(),
+		}})
+	case errors.Is(err, poll.ErrOptionTextTooLong):
+		validation.AbortWithFields(c, []validation.FieldError{{
+			Field:   "optionText",
+			Rule:    "max",
+			Param:   strconv.Itoa(poll.MaxOptionTextLength),
+			Message: err.Error(),
+		}})
+	case errors.Is(err, poll.ErrTooManyOptions):
+		validation.AbortWithFields(c, []validation.FieldError{{
+			Field:   "pollOptions",
+			Rule:    "max",
+			Param:   strconv.Itoa(poll.MaxPollOptions),
+			Message: err.Error(),
+		}})
+	case errors.Is(err, poll.ErrDuplicateOptionID):
+		apierror.AbortWithDetails(c, http.StatusConflict, CodeDuplicateOptionID, "Could not add poll option", err.Error())
+	case errors.Is(err, poll.ErrDuplicateOptionText):
+		apierror.AbortWithDetails(c, http.StatusConflict, CodeDuplicateOptionText, "Could not save poll option", err.Error())
+	default:
+		return false
+	}
+
+	return true
+}
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"encoding/xml"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -9,256 +11,574 @@ import (
 
 	"poll-api/poll"
 
+	"shared/apierror"
+	"shared/apikey"
+	"shared/conflict"
+	"shared/events"
+	"shared/failover"
+	"shared/healthkit"
+	"shared/listorder"
+	"shared/negotiate"
+	"shared/pagination"
+	"shared/validation"
+	"shared/webhook"
+	"shared/worker"
+
 	"github.com/gin-gonic/gin"
+	"github.com/go-resty/resty/v2"
 )
 
 // The API handler that handles incoming requests.
 type PollAPI struct {
-	pollList         *poll.PollCache
-	totalCalls       uint64
-	errorCalls       uint64
-	bootTime         time.Time
-	totalRequestTime time.Duration
+	pollList         poll.Store
+	pollCache        *poll.PollCache
+	votesAPIURL      string
+	apiClient        *resty.Client
+	retention        retentionConfig
+	scheduleInterval time.Duration
+	scheduler        *worker.Scheduler
+	pollEventsStream string
+	pollFeed         *pollFeed
+	responseCache    *responseCache
+	webhooks         *webhook.Registry
+	apiKeys          *apikey.Store
+	metrics          *healthkit.Metrics
 }
 
-// Create a new instance of VoterAPI with an initialized poll cache.
-func NewPollHandler() *PollAPI {
+// Create a new instance of VoterAPI with an initialized poll cache and
+// the poll store selected by STORAGE_BACKEND.
+func NewPollHandler(votesAPIURL string) *PollAPI {
 	pollCache, _ := poll.NewPollCache()
+	apiClient := resty.New()
+
+	pollStore, err := poll.NewPollStore(pollCache)
+	if err != nil {
+		log.Println("Error initializing poll store: ", err)
+	}
 
 	return &PollAPI{
-		pollList:         pollCache,
-		totalCalls:       0,
-		errorCalls:       0,
-		bootTime:         time.Now(),
-		totalRequestTime: 0,
+		pollList:         pollStore,
+		pollCache:        pollCache,
+		scheduler:        worker.NewScheduler(pollCache.RedisClient(), "poll-api"),
+		votesAPIURL:      votesAPIURL,
+		apiClient:        apiClient,
+		retention:        loadRetentionConfig(),
+		scheduleInterval: loadScheduleInterval(),
+		pollEventsStream: pollEventsStream(),
+		pollFeed:         newPollFeed(),
+		responseCache:    newResponseCache(responseCacheSize()),
+		webhooks:         webhook.NewRegistry(pollCache.RedisClient(), "poll-api"),
+		apiKeys:          apikey.NewStore(pollCache.RedisClient()),
+		metrics:          healthkit.New(),
 	}
 }
 
 // The custom middleware to handle health metadata.
 func HealthMiddleware(pa *PollAPI) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// Update total API calls count.
-		pa.totalCalls++
+	return pa.metrics.Middleware()
+}
 
-		// Record the start time of the request.
-		start := time.Now()
+// pollResponse is a poll as GET /polls and GET /polls/:id show it, in
+// JSON, XML or msgpack.
+type pollResponse struct {
+	XMLName       xml.Name          `json:"-" xml:"poll" msgpack:"-"`
+	PollID        uint              `json:"pollId" xml:"pollId" msgpack:"pollId"`
+	PollTitle     string            `json:"pollTitle" xml:"pollTitle" msgpack:"pollTitle"`
+	PollQuestion  string            `json:"pollQuestion" xml:"pollQuestion" msgpack:"pollQuestion"`
+	PollOptions   []pollOptionEntry `json:"pollOptions" xml:"pollOptions>option" msgpack:"pollOptions"`
+	PollStatus    string            `json:"pollStatus" xml:"pollStatus" msgpack:"pollStatus"`
+	ClosedAt      *time.Time        `json:"closedAt" xml:"closedAt,omitempty" msgpack:"closedAt"`
+	CertifiedAt   *time.Time        `json:"certifiedAt" xml:"certifiedAt,omitempty" msgpack:"certifiedAt"`
+	SeriesID      uint              `json:"seriesId" xml:"seriesId,omitempty" msgpack:"seriesId"`
+	CreatedAt     time.Time         `json:"createdAt" xml:"createdAt" msgpack:"createdAt"`
+	Anonymous     bool              `json:"anonymous" xml:"anonymous" msgpack:"anonymous"`
+	MaxSelections uint              `json:"maxSelections" xml:"maxSelections,omitempty" msgpack:"maxSelections"`
+	AllowWriteIn  bool              `json:"allowWriteIn" xml:"allowWriteIn" msgpack:"allowWriteIn"`
+	Eligibility   *poll.Eligibility `json:"eligibility" xml:"eligibility,omitempty" msgpack:"eligibility"`
+	StartTime     *time.Time        `json:"startTime" xml:"startTime,omitempty" msgpack:"startTime"`
+	EndTime       *time.Time        `json:"endTime" xml:"endTime,omitempty" msgpack:"endTime"`
+	Links         negotiate.Links   `json:"links" xml:"links" msgpack:"links"`
+}
 
-		// Process the request.
-		c.Next()
+// pollOptionEntry is an option of a poll response.
+type pollOptionEntry struct {
+	PollOptionID   uint   `json:"pollOptionId" xml:"pollOptionId" msgpack:"pollOptionId"`
+	PollOptionText string `json:"pollOptionText" xml:"pollOptionText" msgpack:"pollOptionText"`
+	MaxVotes       uint   `json:"maxVotes,omitempty" xml:"maxVotes,omitempty" msgpack:"maxVotes,omitempty"`
+}
 
-		// Update error API calls count if there's an error.
-		if c.Writer.Status() >= 400 {
-			pa.errorCalls++
+// Return a poll as GET /polls and GET /polls/:id show it.
+func newPollResponse(p poll.Poll) pollResponse {
+	options := make([]pollOptionEntry, len(p.PollOptions))
+	for i, option := range p.PollOptions {
+		options[i] = pollOptionEntry{
+			PollOptionID:   option.PollOptionID,
+			PollOptionText: option.PollOptionText,
+			MaxVotes:       option.MaxVotes,
 		}
+	}
 
-		// Calculate the request duration.
-		duration := time.Since(start)
+	return pollResponse{
+		PollID:        p.PollID,
+		PollTitle:     p.PollTitle,
+		PollQuestion:  p.PollQuestion,
+		PollOptions:   options,
+		PollStatus:    p.PollStatus,
+		ClosedAt:      p.ClosedAt,
+		CertifiedAt:   p.CertifiedAt,
+		SeriesID:      p.SeriesID,
+		CreatedAt:     p.CreatedAt,
+		Anonymous:     p.Anonymous,
+		MaxSelections: p.MaxSelections,
+		AllowWriteIn:  p.AllowWriteIn,
+		Eligibility:   p.Eligibility,
+		StartTime:     p.StartTime,
+		EndTime:       p.EndTime,
+		Links:         negotiate.ResourceLinks(fmt.Sprintf("/polls/%d", p.PollID)),
+	}
+}
+
+// pollOptionResponse is an option as GET /polls/:id/options and GET
+// /polls/:id/options/:optionId show it.
+type pollOptionResponse struct {
+	XMLName        xml.Name        `json:"-" xml:"option" msgpack:"-"`
+	PollOptionID   uint            `json:"pollOptionID" xml:"pollOptionID" msgpack:"pollOptionID"`
+	PollOptionText string          `json:"pollOptionText" xml:"pollOptionText" msgpack:"pollOptionText"`
+	MaxVotes       uint            `json:"maxVotes" xml:"maxVotes" msgpack:"maxVotes"`
+	Links          negotiate.Links `json:"links" xml:"links" msgpack:"links"`
+	// Set with ?includeStats=true only.
+	StatsAvailable *bool       `json:"statsAvailable,omitempty" xml:"statsAvailable,omitempty" msgpack:"statsAvailable,omitempty"`
+	Stats          *optionStat `json:"stats,omitempty" xml:"stats,omitempty" msgpack:"stats,omitempty"`
+}
 
-		// Update the total request time.
-		pa.totalRequestTime += duration
+func newPollOptionResponse(pollID, optionID uint, text string, maxVotes uint) pollOptionResponse {
+	return pollOptionResponse{
+		PollOptionID:   optionID,
+		PollOptionText: text,
+		MaxVotes:       maxVotes,
+		Links:          negotiate.ResourceLinks(fmt.Sprintf("/polls/%d/options/%d", pollID, optionID)),
 	}
 }
 
-// The root endpoint that welcomes users to the API.
-func (pa *PollAPI) WelcomeToPollAPI(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Welcome to poll API.",
-	})
+// The fields GET /polls can be sorted by.
+var pollSortFields = listorder.Fields[poll.Poll]{
+	"pollId":     func(a, b poll.Poll) int { return listorder.Compare(a.PollID, b.PollID) },
+	"pollTitle":  func(a, b poll.Poll) int { return listorder.Compare(a.PollTitle, b.PollTitle) },
+	"pollStatus": func(a, b poll.Poll) int { return listorder.Compare(a.PollStatus, b.PollStatus) },
+	"createdAt":  func(a, b poll.Poll) int { return a.CreatedAt.Compare(b.CreatedAt) },
+	"startTime":  func(a, b poll.Poll) int { return listorder.CompareTimes(a.StartTime, b.StartTime) },
+	"endTime":    func(a, b poll.Poll) int { return listorder.CompareTimes(a.EndTime, b.EndTime) },
 }
 
 // Implementation of GET /polls.
-// Returns all polls with all poll options.
+// Returns all polls with all poll options, by poll ID or in the order of
+// ?sort=, or one page of them with ?page= or ?cursor=. The whole list
+// carries an ETag and is 304 while no poll changed.
 func (pa *PollAPI) ListAllVPolls(c *gin.Context) {
-	polls, err := pa.pollList.GetAllPolls()
-	if err != nil {
-		log.Println("Error getting polls: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
-		return
-	}
-
-	if polls == nil {
-		polls = make([]poll.Poll, 0)
-	}
-
-	pollResponses := make([]map[string]interface{}, len(polls))
-
-	for i, poll := range polls {
-		pollResponse := map[string]interface{}{
-			"pollId":       poll.PollID,
-			"pollTitle":    poll.PollTitle,
-			"pollQuestion": poll.PollQuestion,
-			"pollOptions":  poll.PollOptions,
-			"links": map[string]interface{}{
-				"get": map[string]interface{}{
-					"method": "GET",
-					"url":    fmt.Sprintf("/polls/%d", poll.PollID),
-				},
-				"update": map[string]interface{}{
-					"method": "PUT",
-					"url":    fmt.Sprintf("/polls/%d", poll.PollID),
-				},
-				"delete": map[string]interface{}{
-					"method": "DELETE",
-					"url":    fmt.Sprintf("/polls/%d", poll.PollID),
-				},
-			},
+	request, ok := pagination.Parse(c)
+	if !ok {
+		return
+	}
+
+	listPolls := func() ([]poll.Poll, bool) {
+		polls, err := pa.pollList.GetAllPolls()
+		if err != nil {
+			log.Println("Error getting polls: ", err)
+			apierror.AbortWithError(c, http.StatusBadRequest, "Could not get polls", err)
+			return nil, false
+		}
+
+		return polls, listorder.Sort(c, polls, pollSortFields)
+	}
+
+	// The response cache keeps whole lists, so pages are built every time.
+	if request.Paged {
+		polls, ok := listPolls()
+		if !ok {
+			return
+		}
+		pagination.Negotiate(c, request, len(polls), "polls", pollResponses(pagination.Slice(request, polls)))
+		return
+	}
+
+	key := pollsCacheKey
+	if order := listorder.Query(c); order != "" {
+		key += ":" + order
+	}
+
+	pa.serveVersioned(c, key, pa.pollCache.PollsVersion, func() (interface{}, bool) {
+		polls, ok := listPolls()
+		if !ok {
+			return nil, false
 		}
-		pollResponses[i] = pollResponse
+
+		return negotiate.NewList("polls", pollResponses(polls)), true
+	})
+}
+
+// Return the responses of polls.
+func pollResponses(polls []poll.Poll) []pollResponse {
+	responses := make([]pollResponse, len(polls))
+	for i, p := range polls {
+		responses[i] = newPollResponse(p)
 	}
 
-	c.JSON(http.StatusOK, pollResponses)
+	return responses
 }
 
 // Implementation of GET /polls/:id.
-// Returns a single poll by :id.
+// Returns a single poll by :id. The response carries an ETag and is 304
+// while the poll did not change.
 func (pa *PollAPI) GetPoll(c *gin.Context) {
 	pollID := c.Param("id")
 	pollIDUint, err := strconv.ParseUint(pollID, 10, 32)
 	if err != nil {
 		log.Println("Error converting poll ID to uint: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
+		apierror.AbortInvalidID(c, "Poll ID", err)
 		return
 	}
 
-	poll, err := pa.pollList.GetPoll(uint(pollIDUint))
-	if err != nil {
-		log.Println("Error getting poll: ", err)
-		c.AbortWithStatus(http.StatusNotFound)
-		return
-	}
-
-	response := map[string]interface{}{
-		"pollId":       poll.PollID,
-		"pollTitle":    poll.PollTitle,
-		"pollQuestion": poll.PollQuestion,
-		"pollOptions":  poll.PollOptions,
-		"links": map[string]interface{}{
-			"get": map[string]interface{}{
-				"method": "GET",
-				"url":    fmt.Sprintf("/polls/%d", poll.PollID),
-			},
-			"update": map[string]interface{}{
-				"method": "PUT",
-				"url":    fmt.Sprintf("/polls/%d", poll.PollID),
-			},
-			"delete": map[string]interface{}{
-				"method": "DELETE",
-				"url":    fmt.Sprintf("/polls/%d", poll.PollID),
-			},
-		},
-	}
-
-	c.JSON(http.StatusOK, response)
+	version := func() (int64, error) {
+		return pa.pollCache.PollVersion(uint(pollIDUint))
+	}
+
+	pa.serveVersioned(c, pollCacheKey(uint(pollIDUint)), version, func() (interface{}, bool) {
+		poll, err := pa.pollList.GetPoll(uint(pollIDUint))
+		if err != nil {
+			log.Println("Error getting poll: ", err)
+			apierror.AbortWithError(c, http.StatusNotFound, "Could not get poll", err)
+			return nil, false
+		}
+
+		return newPollResponse(poll), true
+	})
 }
 
 // Implementation of POST /polls/:id.
-// Add a new poll with :id.
+// Add a new poll with :id. A poll with a startTime in the future is a
+// draft until the schedule opens it. When a poll already has :id,
+// ?onConflict= fails with 409 (the default), replaces or merges into the
+// stored poll; X-Create-Outcome says which happened.
 func (pa *PollAPI) AddPoll(c *gin.Context) {
 	pollID := c.Param("id")
 	pollIDUint, err := strconv.ParseUint(pollID, 10, 32)
 	if err != nil {
 		log.Println("Error converting poll ID to uint: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
+		apierror.AbortInvalidID(c, "Poll ID", err)
+		return
+	}
+
+	onConflict, err := conflict.Parse(c.Query("onConflict"))
+	if err != nil {
+		apierror.AbortWithError(c, http.StatusBadRequest, "Invalid onConflict", err)
 		return
 	}
 
 	var newPoll poll.Poll
-	if err := c.ShouldBindJSON(&newPoll); err != nil {
+	if err := validation.Bind(c, &newPoll); err != nil {
 		log.Println("Error binding JSON: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
 		return
 	}
 
+	tags, err := poll.NormalizeTags(newPoll.Tags)
+	if err != nil {
+		log.Println("Error normalizing poll tags: ", err)
+		abortWithTagError(c, err)
+		return
+	}
+
+	if !validateSchedule(c, newPoll.StartTime, newPoll.EndTime) {
+		return
+	}
+
+	seriesID, anonymous, maxSelections, allowWriteIn := newPoll.SeriesID, newPoll.Anonymous, newPoll.MaxSelections, newPoll.AllowWriteIn
+	eligibility := poll.NormalizeEligibility(newPoll.Eligibility)
+	startTime, endTime := newPoll.StartTime, newPoll.EndTime
 	newPoll = poll.NewPoll(uint(pollIDUint), newPoll.PollTitle, newPoll.PollQuestion)
+	newPoll.SeriesID = seriesID
+	newPoll.Tags = tags
+	newPoll.Anonymous = anonymous
+	newPoll.MaxSelections = maxSelections
+	newPoll.AllowWriteIn = allowWriteIn
+	newPoll.Eligibility = eligibility
+	newPoll.StartTime = startTime
+	newPoll.EndTime = endTime
+	if startTime != nil && startTime.After(time.Now()) {
+		newPoll.PollStatus = poll.PollStatusDraft
+	}
 
-	if err := pa.pollList.AddPoll(newPoll); err != nil {
+	createdPoll, outcome, err := pa.pollList.CreatePoll(newPoll, onConflict)
+	if errors.Is(err, poll.ErrTooManyTags) {
+		// The tags of a merge are added to the tags of the stored poll.
+		abortWithTagError(c, err)
+		return
+	} else if err != nil {
 		log.Println("Error adding poll: ", err)
-		c.AbortWithStatus(http.StatusInternalServerError)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not add poll", err)
+		return
+	}
+
+	eventType := events.EventTypePollCreated
+	if outcome != conflict.Created {
+		eventType = events.EventTypePollUpdated
+	}
+	pa.recordPollChange(eventType, pollChanged(createdPoll))
+
+	conflict.SetOutcome(c, outcome)
+	c.JSON(http.StatusOK, createdPoll)
+}
+
+// Implementation of PUT /polls/:id.
+// Update the title and question of an existing poll with :id.
+func (pa *PollAPI) UpdatePoll(c *gin.Context) {
+	pollID := c.Param("id")
+	pollIDUint, err := strconv.ParseUint(pollID, 10, 32)
+	if err != nil {
+		log.Println("Error converting poll ID to uint: ", err)
+		apierror.AbortInvalidID(c, "Poll ID", err)
+		return
+	}
+
+	var poll poll.Poll
+	if err := validation.Bind(c, &poll); err != nil {
+		log.Println("Error binding JSON: ", err)
+		return
+	}
+
+	if !pa.checkEditWindow(c, uint(pollIDUint), "update-poll") {
+		return
+	}
+
+	poll.PollID = uint(pollIDUint)
+	updatedPoll, err := pa.pollList.UpdatePoll(poll)
+	if err != nil {
+		log.Println("Error updating poll: ", err)
+		apierror.AbortWithError(c, http.StatusNotFound, "Could not update poll", err)
+		return
+	}
+
+	pa.recordPollChange(events.EventTypePollUpdated, pollChanged(updatedPoll))
+
+	c.JSON(http.StatusOK, updatedPoll)
+}
+
+// Implementation of POST /polls/:id/clone/:newId.
+// Clone the poll with :id into a new poll with :newId in the same series.
+func (pa *PollAPI) ClonePoll(c *gin.Context) {
+	pollID := c.Param("id")
+	pollIDUint, err := strconv.ParseUint(pollID, 10, 32)
+	if err != nil {
+		log.Println("Error converting poll ID to uint: ", err)
+		apierror.AbortInvalidID(c, "Poll ID", err)
+		return
+	}
+
+	newPollID := c.Param("newId")
+	newPollIDUint, err := strconv.ParseUint(newPollID, 10, 32)
+	if err != nil {
+		log.Println("Error converting new poll ID to uint: ", err)
+		apierror.AbortInvalidID(c, "New poll ID", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, newPoll)
+	clonedPoll, err := pa.pollList.ClonePoll(uint(pollIDUint), uint(newPollIDUint))
+	if err != nil {
+		log.Println("Error cloning poll: ", err)
+		apierror.AbortWithError(c, http.StatusConflict, "Could not clone poll", err)
+		return
+	}
+
+	pa.recordPollChange(events.EventTypePollCreated, pollChanged(clonedPoll))
+
+	c.JSON(http.StatusCreated, clonedPoll)
+}
+
+// Implementation of POST /polls/:id/close.
+// Close an open poll with :id and freeze its results, admin only.
+func (pa *PollAPI) ClosePoll(c *gin.Context) {
+	if !isAdminRequest(c) {
+		apierror.Abort(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "A valid admin token is required")
+		return
+	}
+
+	pollID := c.Param("id")
+	pollIDUint, err := strconv.ParseUint(pollID, 10, 32)
+	if err != nil {
+		log.Println("Error converting poll ID to uint: ", err)
+		apierror.AbortInvalidID(c, "Poll ID", err)
+		return
+	}
+
+	closedPoll, err := pa.pollList.ClosePoll(uint(pollIDUint))
+	if err != nil {
+		log.Println("Error closing poll: ", err)
+		apierror.AbortWithError(c, http.StatusConflict, "Could not close poll", err)
+		return
+	}
+
+	pa.recordPollChange(events.EventTypePollClosed, pollChanged(closedPoll))
+	pa.freezePollResults(closedPoll.PollID)
+	pa.pollClosed(closedPoll)
+
+	c.JSON(http.StatusOK, closedPoll)
+}
+
+// Implementation of POST /polls/:id/certify.
+// Certify a closed poll with :id and release its results, admin only.
+func (pa *PollAPI) CertifyPoll(c *gin.Context) {
+	if !isAdminRequest(c) {
+		apierror.Abort(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "A valid admin token is required")
+		return
+	}
+
+	pollID := c.Param("id")
+	pollIDUint, err := strconv.ParseUint(pollID, 10, 32)
+	if err != nil {
+		log.Println("Error converting poll ID to uint: ", err)
+		apierror.AbortInvalidID(c, "Poll ID", err)
+		return
+	}
+
+	certifiedPoll, err := pa.pollList.CertifyPoll(uint(pollIDUint))
+	if err != nil {
+		log.Println("Error certifying poll: ", err)
+		apierror.AbortWithError(c, http.StatusConflict, "Could not certify poll", err)
+		return
+	}
+
+	pa.recordPollChange(events.EventTypePollCertified, pollChanged(certifiedPoll))
+
+	c.JSON(http.StatusOK, certifiedPoll)
 }
 
 // Implementation of DELETE /polls.
 // Delete all polls.
 func (pa *PollAPI) DeleteAllPolls(c *gin.Context) {
+	polls, err := pa.pollList.GetAllPolls()
+	if err != nil {
+		log.Println("Error getting polls: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not delete polls", err)
+		return
+	}
+
+	// Delete the votes of every poll first, like DeletePoll.
+	for _, p := range polls {
+		if _, err := pa.cascadePollDelete(p.PollID, false); err != nil {
+			log.Println("Error deleting the votes of poll: ", err)
+			apierror.AbortWithError(c, http.StatusServiceUnavailable, "Could not delete the votes of the polls", err)
+			return
+		}
+	}
+
 	if err := pa.pollList.DeleteAllPolls(); err != nil {
 		log.Println("Error deleting polls: ", err)
-		c.AbortWithStatus(http.StatusNotFound)
+		apierror.AbortWithError(c, http.StatusNotFound, "Could not delete polls", err)
 		return
 	}
 
+	for _, p := range polls {
+		pa.recordPollChange(events.EventTypePollDeleted, events.PollChanged{PollID: p.PollID, PollTitle: p.PollTitle})
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "All polls deleted successfully.",
 	})
 }
 
-// Implementation of DELETE /polls/:id.
-// Delete a single poll by :id.
+// Implementation of DELETE /polls/:id?dryRun=true.
+// Delete a single poll by :id, together with its votes and the vote
+// history entries that reference it. The votes go first, so a poll is
+// never left with orphan votes; when the votes API cannot be reached the
+// poll is kept. With dryRun=true nothing is deleted and the response
+// reports what would be.
 func (pa *PollAPI) DeletePoll(c *gin.Context) {
 	pollID := c.Param("id")
 	pollIDUint, err := strconv.ParseUint(pollID, 10, 32)
 	if err != nil {
 		log.Println("Error converting poll ID to uint: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
+		apierror.AbortInvalidID(c, "Poll ID", err)
+		return
+	}
+
+	deletedPoll, err := pa.pollList.GetPoll(uint(pollIDUint))
+	if err != nil {
+		log.Println("Error getting poll: ", err)
+		apierror.AbortWithError(c, http.StatusNotFound, "Could not delete poll", err)
+		return
+	}
+
+	dryRun := c.Query("dryRun") == "true"
+
+	cascade, err := pa.cascadePollDelete(uint(pollIDUint), dryRun)
+	if err != nil {
+		log.Println("Error deleting the votes of poll: ", err)
+		apierror.AbortWithError(c, http.StatusServiceUnavailable, "Could not delete the votes of the poll", err)
+		return
+	}
+
+	if dryRun {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Dry run, nothing was deleted.",
+			"cascade": cascade,
+		})
 		return
 	}
 
 	if err := pa.pollList.DeletePoll(uint(pollIDUint)); err != nil {
 		log.Println("Error deleting poll: ", err)
-		c.AbortWithStatus(http.StatusNotFound)
+		apierror.AbortWithError(c, http.StatusNotFound, "Could not delete poll", err)
 		return
 	}
 
+	pa.recordPollChange(events.EventTypePollDeleted, events.PollChanged{PollID: deletedPoll.PollID, PollTitle: deletedPoll.PollTitle})
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Poll deleted successfully.",
+		"cascade": cascade,
 	})
 }
 
 // Implementation of GET /polls/:id/options.
-// Get the poll options of a poll by :id.
+// Get the poll options of a poll by :id. With ?includeStats=true every
+// option also has the stats of its votes in the results of the poll.
 func (pa *PollAPI) GetPollOptions(c *gin.Context) {
 	pollID := c.Param("id")
 	pollIDUint, err := strconv.ParseUint(pollID, 10, 32)
 	if err != nil {
 		log.Println("Error converting poll ID to uint: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
+		apierror.AbortInvalidID(c, "Poll ID", err)
 		return
 	}
 
 	pollOptions, err := pa.pollList.GetPollOptions(uint(pollIDUint))
 	if err != nil {
 		log.Println("Error getting poll options: ", err)
-		c.AbortWithStatus(http.StatusNotFound)
+		apierror.AbortWithError(c, http.StatusNotFound, "Could not get poll options", err)
 		return
 	}
 
-	pollOptionsResponses := make([]map[string]interface{}, len(pollOptions))
+	var stats map[uint]optionStat
+	includeStats := c.Query("includeStats") == "true"
+	statsAvailable := false
+	if includeStats {
+		stats, statsAvailable = pa.getOptionStats(c, uint(pollIDUint))
+	}
 
+	pollOptionsResponses := make([]pollOptionResponse, len(pollOptions))
 	for i, pollOption := range pollOptions {
-		pollOptionResponse := map[string]interface{}{
-			"pollOptionID":   pollOption.PollOptionID,
-			"pollOptionText": pollOption.PollOptionText,
-			"links": map[string]interface{}{
-				"get": map[string]interface{}{
-					"method": "GET",
-					"url":    fmt.Sprintf("/polls/%s/options/%d", strconv.Itoa(int(pollIDUint)), pollOption.PollOptionID),
-				},
-				"update": map[string]interface{}{
-					"method": "PUT",
-					"url":    fmt.Sprintf("/polls/%s/options/%d", strconv.Itoa(int(pollIDUint)), pollOption.PollOptionID),
-				},
-				"delete": map[string]interface{}{
-					"method": "DELETE",
-					"url":    fmt.Sprintf("/polls/%s/options/%d", strconv.Itoa(int(pollIDUint)), pollOption.PollOptionID),
-				},
-			},
+		pollOptionResponse := newPollOptionResponse(uint(pollIDUint), pollOption.PollOptionID, pollOption.PollOptionText, pollOption.MaxVotes)
+		if includeStats {
+			pollOptionResponse.StatsAvailable = &statsAvailable
+			if statsAvailable {
+				// An option added since the results were read has none.
+				stat := stats[pollOption.PollOptionID]
+				pollOptionResponse.Stats = &stat
+			}
 		}
 		pollOptionsResponses[i] = pollOptionResponse
 	}
 
-	c.JSON(http.StatusOK, pollOptionsResponses)
+	negotiate.Respond(c, http.StatusOK, negotiate.NewList("options", pollOptionsResponses))
 }
 
 // Implementation of GET /polls/:id/options/:optionid.
@@ -268,7 +588,7 @@ func (pa *PollAPI) GetPollOption(c *gin.Context) {
 	pollIDUint, err := strconv.ParseUint(pollID, 10, 32)
 	if err != nil {
 		log.Println("Error converting poll ID to uint: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
+		apierror.AbortInvalidID(c, "Poll ID", err)
 		return
 	}
 
@@ -276,47 +596,30 @@ func (pa *PollAPI) GetPollOption(c *gin.Context) {
 	pollOptionIDUint, err := strconv.ParseUint(pollOptionID, 10, 32)
 	if err != nil {
 		log.Println("Error converting poll option ID to uint: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
+		apierror.AbortInvalidID(c, "Poll option ID", err)
 		return
 	}
 
 	pollOption, err := pa.pollList.GetPollOption(uint(pollIDUint), uint(pollOptionIDUint))
 	if err != nil {
 		log.Println("Error getting poll option: ", err)
-		c.AbortWithStatus(http.StatusNotFound)
-		return
-	}
-
-	response := map[string]interface{}{
-		"pollOptionID":   pollOption.PollOptionID,
-		"pollOptionText": pollOption.PollOptionText,
-		"links": map[string]interface{}{
-			"get": map[string]interface{}{
-				"method": "GET",
-				"url":    fmt.Sprintf("/polls/%s/options/%d", strconv.Itoa(int(pollIDUint)), pollOption.PollOptionID),
-			},
-			"update": map[string]interface{}{
-				"method": "PUT",
-				"url":    fmt.Sprintf("/polls/%s/options/%d", strconv.Itoa(int(pollIDUint)), pollOption.PollOptionID),
-			},
-			"delete": map[string]interface{}{
-				"method": "DELETE",
-				"url":    fmt.Sprintf("/polls/%s/options/%d", strconv.Itoa(int(pollIDUint)), pollOption.PollOptionID),
-			},
-		},
-	}
-
-	c.JSON(http.StatusOK, response)
+		apierror.AbortWithError(c, http.StatusNotFound, "Could not get poll option", err)
+		return
+	}
+
+	negotiate.Respond(c, http.StatusOK, newPollOptionResponse(uint(pollIDUint), pollOption.PollOptionID, pollOption.PollOptionText, pollOption.MaxVotes))
 }
 
-// Implementation of POST /polls/:id/polls/:optionid.
-// Add a new poll to a poll's voting history with :id & :optionid.
+// Implementation of POST /polls/:id/options/:optionid.
+// Add a new option with :optionid to the poll with :id. The trimmed text
+// must be unique within the poll; the created option is returned with 201
+// and its URL in the Location header.
 func (pa *PollAPI) AddPollOption(c *gin.Context) {
 	pollID := c.Param("id")
 	pollIDUint, err := strconv.ParseUint(pollID, 10, 32)
 	if err != nil {
 		log.Println("Error converting poll ID to uint: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
+		apierror.AbortInvalidID(c, "Poll ID", err)
 		return
 	}
 
@@ -324,28 +627,99 @@ func (pa *PollAPI) AddPollOption(c *gin.Context) {
 	pollOptionIDUint, err := strconv.ParseUint(pollOptionID, 10, 32)
 	if err != nil {
 		log.Println("Error converting poll option ID to uint: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
+		apierror.AbortInvalidID(c, "Poll option ID", err)
 		return
 	}
 
 	var requestBody struct {
-		OptionText string `json:"optionText"`
+		OptionText string `json:"optionText" binding:"required,max=200"`
+		MaxVotes   uint   `json:"maxVotes"`
 	}
 
-	if err := c.ShouldBindJSON(&requestBody); err != nil {
+	if err := validation.Bind(c, &requestBody); err != nil {
 		log.Println("Error parsing JSON request body: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
 		return
 	}
 
-	newPollOption, err := pa.pollList.AddPollOption(uint(pollIDUint), uint(pollOptionIDUint), requestBody.OptionText)
+	optionText, err := poll.NormalizeOptionText(requestBody.OptionText)
+	if err != nil {
+		log.Println("Error validating poll option text: ", err)
+		abortWithOptionError(c, err)
+		return
+	}
+
+	if !pa.checkEditWindow(c, uint(pollIDUint), "add-poll-option") {
+		return
+	}
+
+	newPollOption, err := pa.pollList.AddPollOption(uint(pollIDUint), uint(pollOptionIDUint), optionText, requestBody.MaxVotes)
 	if err != nil {
 		log.Println("Error adding poll option: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
+		if abortWithOptionError(c, err) {
+			return
+		}
+		apierror.AbortWithError(c, http.StatusNotFound, "Could not add poll option", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, newPollOption)
+	pa.recordOptionsChange(uint(pollIDUint), newPollOption.PollOptionID)
+
+	c.Header("Location", fmt.Sprintf("/polls/%d/options/%d", pollIDUint, newPollOption.PollOptionID))
+	c.JSON(http.StatusCreated, newPollOption)
+}
+
+// Implementation of PUT /polls/:id/options/:optionid.
+// Update the text of a poll option with :id & :optionid.
+func (pa *PollAPI) UpdatePollOption(c *gin.Context) {
+	pollID := c.Param("id")
+	pollIDUint, err := strconv.ParseUint(pollID, 10, 32)
+	if err != nil {
+		log.Println("Error converting poll ID to uint: ", err)
+		apierror.AbortInvalidID(c, "Poll ID", err)
+		return
+	}
+
+	pollOptionID := c.Param("optionId")
+	pollOptionIDUint, err := strconv.ParseUint(pollOptionID, 10, 32)
+	if err != nil {
+		log.Println("Error converting poll option ID to uint: ", err)
+		apierror.AbortInvalidID(c, "Poll option ID", err)
+		return
+	}
+
+	var requestBody struct {
+		OptionText string `json:"optionText" binding:"required,max=200"`
+	}
+
+	if err := validation.Bind(c, &requestBody); err != nil {
+		log.Println("Error parsing JSON request body: ", err)
+		return
+	}
+
+	optionText, err := poll.NormalizeOptionText(requestBody.OptionText)
+	if err != nil {
+		log.Println("Error validating poll option text: ", err)
+		abortWithOptionError(c, err)
+		return
+	}
+
+	if !pa.checkEditWindow(c, uint(pollIDUint), "update-poll-option") {
+		return
+	}
+
+	updatedPollOption, err := pa.pollList.UpdatePollOption(uint(pollIDUint), uint(pollOptionIDUint), optionText)
+	if err != nil {
+		log.Println("Error updating poll option: ", err)
+		if abortWithOptionError(c, err) {
+			return
+		}
+		apierror.AbortWithError(c, http.StatusNotFound, "Could not update poll option", err)
+		return
+	}
+
+	pa.recordOptionsChange(uint(pollIDUint), updatedPollOption.PollOptionID)
+
+	c.JSON(http.StatusOK, updatedPollOption)
 }
 
 // Implementation of DELETE /polls/:id/polls/:pollid.
@@ -355,7 +729,7 @@ func (pa *PollAPI) DeletePollOption(c *gin.Context) {
 	pollIDUint, err := strconv.ParseUint(pollID, 10, 32)
 	if err != nil {
 		log.Println("Error converting poll ID to uint: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
+		apierror.AbortInvalidID(c, "Poll ID", err)
 		return
 	}
 
@@ -363,37 +737,127 @@ func (pa *PollAPI) DeletePollOption(c *gin.Context) {
 	pollOptionIDUint, err := strconv.ParseUint(pollOptionID, 10, 32)
 	if err != nil {
 		log.Println("Error converting poll option ID to uint: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
+		apierror.AbortInvalidID(c, "Poll option ID", err)
+		return
+	}
+
+	if !pa.checkEditWindow(c, uint(pollIDUint), "delete-poll-option") {
 		return
 	}
 
 	if err := pa.pollList.DeletePollOption(uint(pollIDUint), uint(pollOptionIDUint)); err != nil {
 		log.Println("Error deleting poll option: ", err)
-		c.AbortWithStatus(http.StatusNotFound)
+		apierror.AbortWithError(c, http.StatusNotFound, "Could not delete poll option", err)
 		return
 	}
 
+	pa.recordOptionsChange(uint(pollIDUint), uint(pollOptionIDUint))
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Poll option deleted successfully.",
 	})
 }
 
+// Implementation of GET /polls/audit.
+// Returns the audit log of admin overrides, admin only.
+func (pa *PollAPI) GetAuditLog(c *gin.Context) {
+	if !isAdminRequest(c) {
+		apierror.Abort(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "A valid admin token is required")
+		return
+	}
+
+	entries, err := pa.pollList.GetAuditEntries()
+	if err != nil {
+		log.Println("Error getting audit entries: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not get audit entries", err)
+		return
+	}
+
+	if entries == nil {
+		entries = make([]poll.AuditEntry, 0)
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
 // Implementation of GET polls/health.
 // Get the health status of the poll API.
 func (pa *PollAPI) HealthCheck(c *gin.Context) {
-	uptime := time.Since(pa.bootTime).String()
-	averageRequestTime := time.Duration(0)
-	if pa.totalCalls > 0 {
-		averageRequestTime = pa.totalRequestTime / time.Duration(pa.totalCalls)
+	report := pa.metrics.Report()
+	report["schedulerLeader"] = pa.scheduler.IsLeader()
+	report["jobs"] = pa.scheduler.Stats()
+	report["storageCodec"] = pa.pollCache.CodecStats()
+	report["redisShards"] = pa.pollCache.ShardHealth()
+	report["pollEventsStream"] = pa.pollEventsStream
+	report["openStreams"] = pa.pollFeed.count()
+	report["responseCache"] = pa.responseCache.stats()
+	report["redisFailover"] = failover.CurrentStats()
+
+	c.JSON(http.StatusOK, report)
+}
+
+// Implementation of GET /healthz.
+// Liveness probe, reports that the process is up and serving requests.
+func (pa *PollAPI) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+	})
+}
+
+// Implementation of GET /readyz.
+// Ping the store, treating a store that failed to initialize as down.
+func pingStore(store poll.Store) error {
+	if store == nil {
+		return errors.New("store is not initialized")
+	}
+
+	return store.Ping()
+}
+
+// Return the checks of the stores of the poll API, for the boot report.
+func (pa *PollAPI) DependencyChecks() map[string]func() error {
+	checks := map[string]func() error{
+		"redis": pa.pollCache.Ping,
+	}
+	if poll.StorageBackend() == poll.StorageBackendPostgres {
+		checks["postgres"] = func() error { return pingStore(pa.pollList) }
+	}
+
+	return checks
+}
+
+// Readiness probe, checks every dependency of the poll API and returns
+// 503 with per-dependency detail when any of them is down.
+func (pa *PollAPI) Readiness(c *gin.Context) {
+	dependencies := map[string]interface{}{}
+	ready := true
+
+	if err := pa.pollCache.Ping(); err != nil {
+		dependencies["redis"] = gin.H{"status": "down", "error": err.Error()}
+		ready = false
+	} else {
+		dependencies["redis"] = gin.H{"status": "up"}
+	}
+
+	if poll.StorageBackend() == poll.StorageBackendPostgres {
+		if err := pingStore(pa.pollList); err != nil {
+			dependencies["postgres"] = gin.H{"status": "down", "error": err.Error()}
+			ready = false
+		} else {
+			dependencies["postgres"] = gin.H{"status": "up"}
+		}
+	}
+
+	if !ready {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status":       "not ready",
+			"dependencies": dependencies,
+		})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"status":             "ok",
-		"uptime":             uptime,
-		"totalAPICalls":      pa.totalCalls,
-		"totalAPICallsError": pa.errorCalls,
-		"bootTime":           pa.bootTime,
-		"totalRequestTime":   pa.totalRequestTime.String(),
-		"averageRequestTime": averageRequestTime.String(),
+		"status":       "ready",
+		"dependencies": dependencies,
 	})
 }
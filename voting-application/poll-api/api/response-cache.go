@@ -0,0 +1,213 @@
+package api
+
+import (
+	"container/list"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"shared/negotiate"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultResponseCacheSize = 1000
+
+	pollsCacheKey = "polls"
+)
+
+// Return how many poll responses are cached, POLL_RESPONSE_CACHE_SIZE or
+// DefaultResponseCacheSize. Zero disables the cache; the ETags are still
+// sent.
+func responseCacheSize() int {
+	if size, err := strconv.ParseUint(os.Getenv("POLL_RESPONSE_CACHE_SIZE"), 10, 32); err == nil {
+		return int(size)
+	}
+
+	return DefaultResponseCacheSize
+}
+
+// cachedResponse is the response of a read at a version, rendered in
+// the format of every request it is served to.
+type cachedResponse struct {
+	key      string
+	version  int64
+	response interface{}
+}
+
+// ResponseCacheStats reports the use of the response cache.
+type ResponseCacheStats struct {
+	Size     int    `json:"size"`
+	Capacity int    `json:"capacity"`
+	Hits     uint64 `json:"hits"`
+	Misses   uint64 `json:"misses"`
+}
+
+// responseCache keeps the most recently used poll responses of the
+// replica. An entry is only served for the version it was built at, so a
+// change made through another replica is never served stale.
+type responseCache struct {
+	lock     sync.Mutex
+	capacity int
+	order    *list.List
+	entries  map[string]*list.Element
+	hits     uint64
+	misses   uint64
+}
+
+func newResponseCache(capacity int) *responseCache {
+	return &responseCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Return the response cached under key at version.
+func (rc *responseCache) get(key string, version int64) (interface{}, bool) {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	element, ok := rc.entries[key]
+	if !ok || element.Value.(*cachedResponse).version != version {
+		rc.misses++
+		return nil, false
+	}
+
+	rc.hits++
+	rc.order.MoveToFront(element)
+	return element.Value.(*cachedResponse).response, true
+}
+
+// Cache the response of key at version, evicting the least recently used
+// entry when the cache is full.
+func (rc *responseCache) add(key string, version int64, response interface{}) {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	if rc.capacity <= 0 {
+		return
+	}
+
+	if element, ok := rc.entries[key]; ok {
+		element.Value = &cachedResponse{key: key, version: version, response: response}
+		rc.order.MoveToFront(element)
+		return
+	}
+
+	rc.entries[key] = rc.order.PushFront(&cachedResponse{key: key, version: version, response: response})
+
+	if rc.order.Len() > rc.capacity {
+		oldest := rc.order.Back()
+		rc.order.Remove(oldest)
+		delete(rc.entries, oldest.Value.(*cachedResponse).key)
+	}
+}
+
+// Drop the entries of keys.
+func (rc *responseCache) remove(keys ...string) {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	for _, key := range keys {
+		if element, ok := rc.entries[key]; ok {
+			rc.order.Remove(element)
+			delete(rc.entries, key)
+		}
+	}
+}
+
+func (rc *responseCache) stats() ResponseCacheStats {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	return ResponseCacheStats{
+		Size:     rc.order.Len(),
+		Capacity: rc.capacity,
+		Hits:     rc.hits,
+		Misses:   rc.misses,
+	}
+}
+
+// Get the response cache key of a poll.
+func pollCacheKey(pollID uint) string {
+	return fmt.Sprintf("poll:%d", pollID)
+}
+
+// Report whether an If-None-Match header lists the ETag.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Bump the version of a poll after a change and drop its responses from
+// the cache of the replica. A failure is logged; the responses of other
+// replicas then stay valid until the next change to the poll.
+func (pa *PollAPI) invalidatePoll(pollID uint) {
+	pa.responseCache.remove(pollCacheKey(pollID), pollsCacheKey)
+
+	if err := pa.pollCache.BumpPollVersion(pollID); err != nil {
+		log.Println("Error bumping poll version: ", err)
+	}
+}
+
+// Serve a versioned poll read in the format of the Accept header. The
+// response carries an ETag made of key, version and the format when it is
+// not JSON, and is 304 when the client sent it in If-None-Match. The
+// response is served from the response cache when it has the version, and
+// is otherwise built by load, which aborts the request itself when it
+// fails. When the version cannot be read the response is served without an
+// ETag.
+func (pa *PollAPI) serveVersioned(c *gin.Context, key string, version func() (int64, error), load func() (interface{}, bool)) {
+	format := negotiate.Format(c)
+	if format == "" {
+		negotiate.AbortNotAcceptable(c)
+		return
+	}
+
+	currentVersion, err := version()
+	if err != nil {
+		log.Println("Error getting poll version: ", err)
+		if response, ok := load(); ok {
+			negotiate.Respond(c, http.StatusOK, response)
+		}
+		return
+	}
+
+	etag := fmt.Sprintf(`"%s-v%d"`, key, currentVersion)
+	if format != negotiate.FormatJSON {
+		etag = fmt.Sprintf(`"%s-v%d-%s"`, key, currentVersion, format)
+	}
+	ifNoneMatch := c.GetHeader("If-None-Match")
+
+	response, cached := pa.responseCache.get(key, currentVersion)
+	if !cached && !etagMatches(ifNoneMatch, etag) {
+		var ok bool
+		if response, ok = load(); !ok {
+			return
+		}
+		pa.responseCache.add(key, currentVersion, response)
+	}
+
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "no-cache")
+
+	if etagMatches(ifNoneMatch, etag) {
+		c.Header("Vary", "Accept")
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	negotiate.Respond(c, http.StatusOK, response)
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"shared/apierror"
+	"shared/endpoints"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The header votes-api reads embargo tokens from.
+const EmbargoTokenHeader = "X-Embargo-Token"
+
+// Ask the votes API to freeze the results of a poll that was closed, with
+// the admin token of this API. A poll the votes API could not freeze now
+// is frozen when its result is first read.
+func (pa *PollAPI) freezePollResults(pollID uint) {
+	freezePath := fmt.Sprintf("%s/admin/polls/%d/freeze", pa.votesAPIURL, pollID)
+
+	resp, err := pa.apiClient.R().
+		SetHeader(AdminTokenHeader, os.Getenv("ADMIN_TOKEN")).
+		Post(freezePath)
+	if err != nil {
+		log.Println("Error freezing poll results: ", endpoints.VotesAPI.Unreachable(pa.votesAPIURL, err))
+		return
+	}
+
+	if resp.IsError() {
+		log.Println("Error freezing poll results: ", errors.New("votes API returned "+resp.Status()))
+	}
+}
+
+// Implementation of GET /polls/:id/result.
+// Returns the result snapshot the votes API froze when the poll with :id
+// closed: the counts, the winner, the turnout and a checksum. It is read
+// from the votes API with the admin and embargo tokens of the caller, so
+// the same rules as for the live results apply.
+func (pa *PollAPI) GetPollResult(c *gin.Context) {
+	pollID := c.Param("id")
+	pollIDUint, err := strconv.ParseUint(pollID, 10, 32)
+	if err != nil {
+		log.Println("Error converting poll ID to uint: ", err)
+		apierror.AbortInvalidID(c, "Poll ID", err)
+		return
+	}
+
+	if _, err := pa.pollList.GetPoll(uint(pollIDUint)); err != nil {
+		log.Println("Error getting poll: ", err)
+		apierror.AbortWithError(c, http.StatusNotFound, "Could not get poll", err)
+		return
+	}
+
+	snapshotPath := fmt.Sprintf("%s/votes/results/%d/snapshot", pa.votesAPIURL, pollIDUint)
+	request := pa.apiClient.R().
+		SetHeader(AdminTokenHeader, c.GetHeader(AdminTokenHeader)).
+		SetHeader(EmbargoTokenHeader, c.GetHeader(EmbargoTokenHeader))
+	if embargoToken := c.Query("embargoToken"); embargoToken != "" {
+		request.SetQueryParam("embargoToken", embargoToken)
+	}
+
+	resp, err := request.Get(snapshotPath)
+	if err != nil {
+		err = endpoints.VotesAPI.Unreachable(pa.votesAPIURL, err)
+		log.Println("Error getting poll result: ", err)
+		apierror.Upstream(c, endpoints.VotesAPI.Name)
+		apierror.AbortWithError(c, http.StatusServiceUnavailable, "Could not get the result of the poll", err)
+		return
+	}
+
+	// The answer of the votes API, a snapshot or an error, is passed on.
+	c.Data(resp.StatusCode(), resp.Header().Get("Content-Type"), resp.Body())
+}
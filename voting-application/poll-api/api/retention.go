@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"poll-api/poll"
+
+	"shared/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultRetentionInterval = time.Hour
+)
+
+// retentionConfig holds how long audit logs are kept. A zero duration
+// keeps the records forever.
+type retentionConfig struct {
+	interval  time.Duration
+	auditLogs time.Duration
+}
+
+// Load the retention configuration from the environment.
+// RETENTION_AUDIT_DAYS keeps audit log entries for M days and
+// RETENTION_INTERVAL sets how often the janitor runs.
+func loadRetentionConfig() retentionConfig {
+	config := retentionConfig{
+		interval: DefaultRetentionInterval,
+	}
+
+	if days, err := strconv.ParseUint(os.Getenv("RETENTION_AUDIT_DAYS"), 10, 32); err == nil {
+		config.auditLogs = time.Duration(days) * 24 * time.Hour
+	}
+
+	if interval, err := time.ParseDuration(os.Getenv("RETENTION_INTERVAL")); err == nil && interval > 0 {
+		config.interval = interval
+	}
+
+	return config
+}
+
+// Purge every audit entry past its retention period.
+func (pa *PollAPI) runRetention(ctx context.Context) error {
+	if pa.retention.auditLogs == 0 {
+		return nil
+	}
+
+	purged, err := pa.pollList.PurgeAuditEntries(time.Now().Add(-pa.retention.auditLogs), false)
+	if err != nil {
+		return err
+	}
+
+	if len(purged) > 0 {
+		log.Printf("Retention janitor purged %d audit entries", len(purged))
+	}
+
+	return nil
+}
+
+// Implementation of GET /admin/retention/report.
+// Dry run of the retention janitor, lists what the next run would purge.
+func (pa *PollAPI) GetRetentionReport(c *gin.Context) {
+	auditEntries := make([]poll.AuditEntry, 0)
+
+	if pa.retention.auditLogs > 0 {
+		purged, err := pa.pollList.PurgeAuditEntries(time.Now().Add(-pa.retention.auditLogs), true)
+		if err != nil {
+			log.Println("Error computing retention candidates: ", err)
+			apierror.AbortWithError(c, http.StatusInternalServerError, "Could not compute retention candidates", err)
+			return
+		}
+
+		auditEntries = purged
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"policy": gin.H{
+			"auditLogDays": pa.retention.auditLogs.Hours() / 24,
+			"interval":     pa.retention.interval.String(),
+		},
+		"auditLogs": auditEntries,
+	})
+}
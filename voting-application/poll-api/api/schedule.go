@@ -0,0 +1,181 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+
+	"poll-api/poll"
+
+	"shared/apierror"
+	"shared/events"
+	"shared/listorder"
+	"shared/pagination"
+	"shared/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultScheduleInterval = 30 * time.Second
+)
+
+// Load how often the schedule opens and closes the polls that are due
+// from POLL_SCHEDULE_INTERVAL.
+func loadScheduleInterval() time.Duration {
+	if interval, err := time.ParseDuration(os.Getenv("POLL_SCHEDULE_INTERVAL")); err == nil && interval > 0 {
+		return interval
+	}
+
+	return DefaultScheduleInterval
+}
+
+// Check the startTime and endTime of a new poll, answering 422 when the
+// poll would close before it opens or has already ended.
+func validateSchedule(c *gin.Context, startTime, endTime *time.Time) bool {
+	if endTime == nil {
+		return true
+	}
+
+	switch {
+	case startTime != nil && !endTime.After(*startTime):
+		validation.AbortWithFields(c, []validation.FieldError{{
+			Field:   "endTime",
+			Rule:    "gtfield",
+			Param:   "startTime",
+			Message: "endTime must be after startTime",
+		}})
+		return false
+	case !endTime.After(time.Now()):
+		validation.AbortWithFields(c, []validation.FieldError{{
+			Field:   "endTime",
+			Rule:    "future",
+			Message: "endTime must be in the future",
+		}})
+		return false
+	}
+
+	return true
+}
+
+// Open the draft polls whose start time has come and close the open polls
+// whose end time has passed, publishing the change of each. A poll whose
+// whole schedule passed while the job was not running is opened and
+// closed in the same run.
+func (pa *PollAPI) runPollSchedule(ctx context.Context) error {
+	now := time.Now()
+
+	var due []poll.Poll
+	err := pa.pollList.EachPoll(func(p poll.Poll) error {
+		if dueToOpen(p, now) || dueToClose(p, now) {
+			due = append(due, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, p := range due {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if dueToOpen(p, now) {
+			openedPoll, err := pa.pollList.OpenPoll(p.PollID)
+			if err != nil {
+				log.Println("Error opening scheduled poll: ", err)
+				continue
+			}
+
+			pa.recordPollChange(events.EventTypePollOpened, pollChanged(openedPoll))
+			log.Printf("Poll schedule opened poll %d", p.PollID)
+			p = openedPoll
+		}
+
+		if dueToClose(p, now) {
+			closedPoll, err := pa.pollList.ClosePoll(p.PollID)
+			if err != nil {
+				log.Println("Error closing scheduled poll: ", err)
+				continue
+			}
+
+			pa.recordPollChange(events.EventTypePollClosed, pollChanged(closedPoll))
+			pa.freezePollResults(closedPoll.PollID)
+			pa.pollClosed(closedPoll)
+			log.Printf("Poll schedule closed poll %d", p.PollID)
+		}
+	}
+
+	return nil
+}
+
+// Report whether a draft poll is due to open.
+func dueToOpen(p poll.Poll, now time.Time) bool {
+	return p.PollStatus == poll.PollStatusDraft && (p.StartTime == nil || !p.StartTime.After(now))
+}
+
+// Report whether an open poll is due to close.
+func dueToClose(p poll.Poll, now time.Time) bool {
+	return p.PollStatus == poll.PollStatusOpen && p.EndTime != nil && !p.EndTime.After(now)
+}
+
+// Implementation of GET /polls/upcoming.
+// Returns the draft polls, the next to open first, or in the order of
+// ?sort=. ?page= or ?cursor= returns one page of them.
+func (pa *PollAPI) ListUpcomingPolls(c *gin.Context) {
+	pa.listPollsWithStatus(c, poll.PollStatusDraft, func(p poll.Poll) *time.Time { return p.StartTime })
+}
+
+// Implementation of GET /polls/active.
+// Returns the open polls, the next to close first and the polls without
+// an end time last, or in the order of ?sort=. ?page= or ?cursor= returns
+// one page of them.
+func (pa *PollAPI) ListActivePolls(c *gin.Context) {
+	pa.listPollsWithStatus(c, poll.PollStatusOpen, func(p poll.Poll) *time.Time { return p.EndTime })
+}
+
+// Answer the polls with a status, ordered by the time timeOf returns
+// unless the request has a ?sort= of its own.
+func (pa *PollAPI) listPollsWithStatus(c *gin.Context, status string, timeOf func(poll.Poll) *time.Time) {
+	request, ok := pagination.Parse(c)
+	if !ok {
+		return
+	}
+
+	polls := make([]poll.Poll, 0)
+	err := pa.pollList.EachPoll(func(p poll.Poll) error {
+		if p.PollStatus == status {
+			polls = append(polls, p)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Println("Error getting polls: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not get polls", err)
+		return
+	}
+
+	if listorder.Query(c) == "" {
+		sortByTime(polls, timeOf)
+	} else if !listorder.Sort(c, polls, pollSortFields) {
+		return
+	}
+
+	pagination.Negotiate(c, request, len(polls), "polls", pollResponses(pagination.Slice(request, polls)))
+}
+
+// Order polls by a time, earliest first and the polls without one last.
+// Polls with the same time keep their poll ID order.
+func sortByTime(polls []poll.Poll, timeOf func(poll.Poll) *time.Time) {
+	sort.SliceStable(polls, func(i, j int) bool {
+		a, b := timeOf(polls[i]), timeOf(polls[j])
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return a.Before(*b)
+	})
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"context"
+	"errors"
+
+	"poll-api/poll"
+
+	"shared/seed"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+// Add the polls of a fixture with their options. Polls and options that
+// already exist are skipped, so the missing options of an existing poll
+// are still added.
+func (pa *PollAPI) Seed(ctx context.Context, fixture *seed.Fixture) seed.Report {
+	report := seed.NewReport()
+
+	for _, p := range fixture.Polls {
+		if ctx.Err() != nil {
+			report.Failed("poll", p.PollID, ctx.Err())
+			continue
+		}
+
+		if !pa.seedPoll(p, &report) {
+			continue
+		}
+
+		for _, option := range p.PollOptions {
+			pa.seedPollOption(p.PollID, option, &report)
+		}
+	}
+
+	return report
+}
+
+// Add a poll of a fixture. It returns whether the poll exists afterwards.
+func (pa *PollAPI) seedPoll(p seed.Poll, report *seed.Report) bool {
+	if _, err := pa.pollList.GetPoll(p.PollID); err == nil {
+		report.Skipped("poll")
+		return true
+	}
+
+	tags, err := poll.NormalizeTags(p.Tags)
+	if err != nil {
+		report.Failed("poll", p.PollID, err)
+		return false
+	}
+
+	newPoll := poll.NewPoll(p.PollID, p.PollTitle, p.PollQuestion)
+	newPoll.Tags = tags
+	newPoll.Anonymous = p.Anonymous
+	newPoll.MaxSelections = p.MaxSelections
+	newPoll.AllowWriteIn = p.AllowWriteIn
+
+	if err := binding.Validator.ValidateStruct(newPoll); err != nil {
+		report.Failed("poll", p.PollID, err)
+		return false
+	}
+
+	if err := pa.pollList.AddPoll(newPoll); err != nil {
+		report.Failed("poll", p.PollID, err)
+		return false
+	}
+	pa.invalidatePoll(p.PollID)
+
+	report.Created("poll")
+	return true
+}
+
+// Add an option of a poll of a fixture.
+func (pa *PollAPI) seedPollOption(pollID uint, option seed.PollOption, report *seed.Report) {
+	text, err := poll.NormalizeOptionText(option.PollOptionText)
+	if err != nil {
+		report.Failed("pollOption", option.PollOptionID, err)
+		return
+	}
+
+	if _, err := pa.pollList.AddPollOption(pollID, option.PollOptionID, text, option.MaxVotes); err != nil {
+		if errors.Is(err, poll.ErrDuplicateOptionID) {
+			report.Skipped("pollOption")
+			return
+		}
+		report.Failed("pollOption", option.PollOptionID, err)
+		return
+	}
+	pa.invalidatePoll(pollID)
+
+	report.Created("pollOption")
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"poll-api/poll"
+
+	"shared/snapshot"
+)
+
+var errStopEach = errors.New("stop")
+
+// snapshotPoll is a poll of a snapshot, with the allowlist of a poll whose
+// eligibility has one.
+type snapshotPoll struct {
+	poll.Poll
+	EligibleVoterIDs []uint `json:"eligibleVoterIds,omitempty"`
+}
+
+// Add the polls of a snapshot as they are, with their status, options and
+// allowlists, into an empty poll API. The allowlists are checked against
+// the voters by the archive.
+func (pa *PollAPI) ImportSnapshot(ctx context.Context, records []json.RawMessage) (int, error) {
+	err := pa.pollList.EachPoll(func(poll.Poll) error { return errStopEach })
+	if errors.Is(err, errStopEach) {
+		return 0, snapshot.ErrNotEmpty
+	} else if err != nil {
+		return 0, err
+	}
+
+	polls := make([]snapshotPoll, len(records))
+	var problems []string
+	for i, record := range records {
+		if err := json.Unmarshal(record, &polls[i]); err != nil || polls[i].PollID == 0 {
+			problems = append(problems, fmt.Sprintf("poll %d of the snapshot is unreadable or has no pollId", i+1))
+		}
+	}
+	if len(problems) > 0 {
+		return 0, &snapshot.InvalidError{Problems: problems}
+	}
+
+	for i, p := range polls {
+		if ctx.Err() != nil {
+			return i, ctx.Err()
+		}
+
+		if err := pa.pollList.AddPoll(p.Poll); err != nil {
+			return i, fmt.Errorf("adding poll %d: %w", p.PollID, err)
+		}
+
+		if len(p.EligibleVoterIDs) > 0 {
+			if _, err := pa.pollList.AddEligibleVoters(p.PollID, p.EligibleVoterIDs); err != nil {
+				return i, fmt.Errorf("adding the allowlist of poll %d: %w", p.PollID, err)
+			}
+		}
+	}
+
+	return len(polls), nil
+}
@@ -0,0 +1,342 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"shared/apierror"
+	"shared/events"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// The most events a resuming client reads from the stream at once.
+	PollStreamBacklog = 1000
+	// How many events a client may fall behind before it is disconnected.
+	PollStreamBuffer    = 64
+	PollStreamKeepalive = 15 * time.Second
+	PollFeedRetry       = 5 * time.Second
+)
+
+var (
+	streamIDPattern = regexp.MustCompile(`^[0-9]+(-[0-9]+)?$`)
+
+	errSlowStreamClient = errors.New("stream client fell behind the poll events")
+)
+
+// pollFeed fans the poll events out to the open streams of the replica. A
+// single reader tails the stream for all of them, so the number of
+// dashboards does not change the load on redis.
+type pollFeed struct {
+	lock        sync.Mutex
+	subscribers map[chan events.Message]struct{}
+}
+
+func newPollFeed() *pollFeed {
+	return &pollFeed{subscribers: make(map[chan events.Message]struct{})}
+}
+
+func (f *pollFeed) subscribe() chan events.Message {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	subscription := make(chan events.Message, PollStreamBuffer)
+	f.subscribers[subscription] = struct{}{}
+
+	return subscription
+}
+
+func (f *pollFeed) unsubscribe(subscription chan events.Message) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if _, ok := f.subscribers[subscription]; ok {
+		delete(f.subscribers, subscription)
+		close(subscription)
+	}
+}
+
+// Send a message to every subscriber. A subscriber whose buffer is full is
+// dropped rather than holding up the others; its client resumes from the
+// last event it got.
+func (f *pollFeed) broadcast(message events.Message) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for subscription := range f.subscribers {
+		select {
+		case subscription <- message:
+		default:
+			delete(f.subscribers, subscription)
+			close(subscription)
+		}
+	}
+}
+
+// Return the number of open streams.
+func (f *pollFeed) count() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return len(f.subscribers)
+}
+
+// Tail the poll events stream and broadcast every event to the open
+// streams until the context is done. It starts from the events published
+// from now on, and waits and tries again when redis is unavailable.
+func (pa *PollAPI) runPollFeed(ctx context.Context) {
+	client := pa.pollCache.RedisClient()
+
+	lastID := ""
+	for ctx.Err() == nil {
+		var err error
+		if lastID == "" {
+			lastID, err = events.LastID(ctx, client, pa.pollEventsStream)
+		}
+
+		var messages []events.Message
+		if err == nil {
+			messages, err = events.Tail(ctx, client, pa.pollEventsStream, lastID, PollStreamBacklog, events.DefaultBlock)
+		}
+
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Println("Error reading poll events: ", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(PollFeedRetry):
+			}
+			continue
+		}
+
+		for _, message := range messages {
+			pa.pollFeed.broadcast(message)
+			lastID = message.ID
+		}
+	}
+}
+
+// Report whether the stream ID a comes after b.
+func streamIDAfter(a, b string) bool {
+	parse := func(id string) (uint64, uint64) {
+		ms, seq, _ := strings.Cut(id, "-")
+		msValue, _ := strconv.ParseUint(ms, 10, 64)
+		seqValue, _ := strconv.ParseUint(seq, 10, 64)
+		return msValue, seqValue
+	}
+
+	aMs, aSeq := parse(a)
+	bMs, bSeq := parse(b)
+
+	return aMs > bMs || (aMs == bMs && aSeq > bSeq)
+}
+
+// pollStreamRequest is what a stream client asked for: the poll it
+// follows, zero for every poll, and the event it resumes after.
+type pollStreamRequest struct {
+	pollID uint
+	since  string
+}
+
+// Parse the ?pollId= and ?since= queries of a stream request, or the
+// Last-Event-ID header an EventSource sends when it reconnects. It aborts
+// the request when they are not valid.
+func parsePollStreamRequest(c *gin.Context) (pollStreamRequest, bool) {
+	var request pollStreamRequest
+
+	if pollID := c.Query("pollId"); pollID != "" {
+		pollIDUint, err := strconv.ParseUint(pollID, 10, 32)
+		if err != nil {
+			log.Println("Error converting poll ID to uint: ", err)
+			apierror.AbortInvalidID(c, "Poll ID", err)
+			return request, false
+		}
+		request.pollID = uint(pollIDUint)
+	}
+
+	request.since = c.GetHeader("Last-Event-ID")
+	if request.since == "" {
+		request.since = c.Query("since")
+	}
+
+	if request.since != "" && !streamIDPattern.MatchString(request.since) {
+		apierror.AbortWithDetails(c, http.StatusBadRequest, apierror.CodeBadRequest, "since must be the ID of a poll event", request.since)
+		return request, false
+	}
+
+	return request, true
+}
+
+// Report whether a poll event concerns the poll a client follows.
+func (r pollStreamRequest) matches(message events.Message) bool {
+	if r.pollID == 0 {
+		return true
+	}
+
+	var event events.PollChanged
+	if err := message.Decode(&event); err != nil {
+		return false
+	}
+
+	return event.PollID == r.pollID
+}
+
+// pollStreamEvent is a poll event as it is sent to stream clients.
+type pollStreamEvent struct {
+	ID   string          `json:"id"`
+	Type string          `json:"type"`
+	Poll json.RawMessage `json:"poll"`
+}
+
+// Send the poll events a client asked for until the context is done or a
+// send fails: first the events after the one it resumes from, then the
+// live ones. keepalive runs whenever the stream has been quiet for
+// PollStreamKeepalive.
+func (pa *PollAPI) watchPollEvents(ctx context.Context, request pollStreamRequest, send func(event pollStreamEvent) error, keepalive func() error) error {
+	subscription := pa.pollFeed.subscribe()
+	defer pa.pollFeed.unsubscribe(subscription)
+
+	deliver := func(message events.Message) error {
+		if !request.matches(message) {
+			return nil
+		}
+		return send(pollStreamEvent{ID: message.ID, Type: message.Type, Poll: json.RawMessage(message.Data)})
+	}
+
+	// The subscription is open before the backlog is read, so no event is
+	// missed in between; the ones read twice are skipped by their ID.
+	lastID := request.since
+	for lastID != "" {
+		backlog, err := events.Tail(ctx, pa.pollCache.RedisClient(), pa.pollEventsStream, lastID, PollStreamBacklog, -1)
+		if err != nil {
+			return err
+		}
+
+		if len(backlog) == 0 {
+			break
+		}
+
+		for _, message := range backlog {
+			if err := deliver(message); err != nil {
+				return err
+			}
+			lastID = message.ID
+		}
+	}
+
+	ticker := time.NewTicker(PollStreamKeepalive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := keepalive(); err != nil {
+				return err
+			}
+		case message, ok := <-subscription:
+			if !ok {
+				return errSlowStreamClient
+			}
+			if lastID != "" && !streamIDAfter(message.ID, lastID) {
+				continue
+			}
+			lastID = message.ID
+
+			if err := deliver(message); err != nil {
+				return err
+			}
+			ticker.Reset(PollStreamKeepalive)
+		}
+	}
+}
+
+var streamUpgrader = websocket.Upgrader{
+	// Origins are not restricted, matching the CORS policy of the API.
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+}
+
+// Implementation of GET /polls/stream?pollId=&since=.
+// Stream the lifecycle and mutation events of the polls over a WebSocket.
+func (pa *PollAPI) StreamPollEvents(c *gin.Context) {
+	request, ok := parsePollStreamRequest(c)
+	if !ok {
+		return
+	}
+
+	conn, err := streamUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Println("Error upgrading to WebSocket: ", err)
+		return
+	}
+	defer conn.Close()
+
+	// Stop streaming once the client goes away; messages from the client
+	// are not used.
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	err = pa.watchPollEvents(ctx, request, func(event pollStreamEvent) error {
+		return conn.WriteJSON(event)
+	}, func() error {
+		return conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(PollStreamKeepalive))
+	})
+	if err != nil {
+		log.Println("Error streaming poll events over WebSocket: ", err)
+	}
+}
+
+// Implementation of GET /polls/stream/sse?pollId=&since=.
+// Stream the lifecycle and mutation events of the polls as server-sent
+// events, named by their type and carrying their ID, so an EventSource
+// resumes where it stopped when it reconnects.
+func (pa *PollAPI) StreamPollEventsSSE(c *gin.Context) {
+	request, ok := parsePollStreamRequest(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	err := pa.watchPollEvents(c.Request.Context(), request, func(event pollStreamEvent) error {
+		c.Render(-1, sse.Event{Id: event.ID, Event: event.Type, Data: event})
+		c.Writer.Flush()
+		return c.Request.Context().Err()
+	}, func() error {
+		if _, err := c.Writer.WriteString(": keepalive\n\n"); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if err != nil {
+		log.Println("Error streaming poll events over SSE: ", err)
+	}
+}
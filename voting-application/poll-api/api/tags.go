@@ -0,0 +1,158 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"poll-api/poll"
+
+	"shared/apierror"
+	"shared/events"
+	"shared/pagination"
+	"shared/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Answer an invalid tag or a poll with too many tags with 422 on the tags
+// field.
+func abortWithTagError(c *gin.Context, err error) {
+	rule, param := "tag", ""
+	if errors.Is(err, poll.ErrTooManyTags) {
+		rule, param = "max", strconv.Itoa(poll.MaxPollTags)
+	}
+
+	validation.AbortWithFields(c, []validation.FieldError{{
+		Field:   "tags",
+		Rule:    rule,
+		Param:   param,
+		Message: err.Error(),
+	}})
+}
+
+// Implementation of POST /polls/:id/tags/:tag.
+// Add a tag to the poll with :id. Tags are stored in lowercase.
+func (pa *PollAPI) AddPollTag(c *gin.Context) {
+	pollID := c.Param("id")
+	pollIDUint, err := strconv.ParseUint(pollID, 10, 32)
+	if err != nil {
+		log.Println("Error converting poll ID to uint: ", err)
+		apierror.AbortInvalidID(c, "Poll ID", err)
+		return
+	}
+
+	tag, err := poll.NormalizeTag(c.Param("tag"))
+	if err != nil {
+		log.Println("Error normalizing poll tag: ", err)
+		abortWithTagError(c, err)
+		return
+	}
+
+	taggedPoll, err := pa.pollList.AddPollTag(uint(pollIDUint), tag)
+	if err != nil {
+		log.Println("Error adding poll tag: ", err)
+		if errors.Is(err, poll.ErrTooManyTags) {
+			abortWithTagError(c, err)
+			return
+		}
+		apierror.AbortWithError(c, http.StatusNotFound, "Could not add poll tag", err)
+		return
+	}
+
+	pa.recordPollChange(events.EventTypePollUpdated, pollChanged(taggedPoll))
+
+	c.JSON(http.StatusOK, taggedPoll)
+}
+
+// Implementation of DELETE /polls/:id/tags/:tag.
+// Remove a tag from the poll with :id.
+func (pa *PollAPI) RemovePollTag(c *gin.Context) {
+	pollID := c.Param("id")
+	pollIDUint, err := strconv.ParseUint(pollID, 10, 32)
+	if err != nil {
+		log.Println("Error converting poll ID to uint: ", err)
+		apierror.AbortInvalidID(c, "Poll ID", err)
+		return
+	}
+
+	tag, err := poll.NormalizeTag(c.Param("tag"))
+	if err != nil {
+		log.Println("Error normalizing poll tag: ", err)
+		abortWithTagError(c, err)
+		return
+	}
+
+	untaggedPoll, err := pa.pollList.RemovePollTag(uint(pollIDUint), tag)
+	if err != nil {
+		log.Println("Error removing poll tag: ", err)
+		apierror.AbortWithError(c, http.StatusNotFound, "Could not remove poll tag", err)
+		return
+	}
+
+	pa.recordPollChange(events.EventTypePollUpdated, pollChanged(untaggedPoll))
+
+	c.JSON(http.StatusOK, untaggedPoll)
+}
+
+// Implementation of GET /polls/search?tag=election&q=budget.
+// Returns the polls that have the tag and whose title or question contain
+// every word of q, ordered by poll ID. Either parameter can be left out,
+// but not both. ?page= or ?cursor= returns one page of the polls found.
+func (pa *PollAPI) SearchPolls(c *gin.Context) {
+	tag := c.Query("tag")
+	query := c.Query("q")
+
+	if tag != "" {
+		normalized, err := poll.NormalizeTag(tag)
+		if err != nil {
+			log.Println("Error normalizing poll tag: ", err)
+			abortWithTagError(c, err)
+			return
+		}
+		tag = normalized
+	}
+
+	if tag == "" && len(poll.SearchWords(query)) == 0 {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeBadRequest, "The tag or q query parameter is required")
+		return
+	}
+
+	request, ok := pagination.Parse(c)
+	if !ok {
+		return
+	}
+
+	polls, err := pa.pollList.SearchPolls(tag, query)
+	if err != nil {
+		log.Println("Error searching polls: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not search polls", err)
+		return
+	}
+
+	pagination.Respond(c, request, len(polls), pagination.Slice(request, polls))
+}
+
+// Implementation of POST /admin/polls/search/reindex.
+// Rebuild the tag and word index of the poll search from the stored polls,
+// for polls that were stored before the index existed.
+func (pa *PollAPI) RebuildSearchIndex(c *gin.Context) {
+	if pa.pollCache == nil {
+		apierror.Abort(c, http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, "Redis is not connected")
+		return
+	}
+
+	indexed, err := pa.pollCache.RebuildSearchIndex()
+	if err != nil {
+		log.Println("Error rebuilding poll search index: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not rebuild the poll search index", err)
+		return
+	}
+
+	log.Printf("Admin rebuilt the poll search index: %d polls", indexed)
+
+	c.JSON(http.StatusOK, gin.H{
+		"indexed": indexed,
+	})
+}
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"shared/apierror"
+	"shared/endpoints"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pollResults is the subset of the votes-api results document used to
+// compute trends.
+type pollResults struct {
+	PollID     uint `json:"pollId"`
+	TotalVotes uint `json:"totalVotes"`
+	Results    []struct {
+		OptionID   uint   `json:"optionId"`
+		OptionText string `json:"optionText"`
+		Votes      uint   `json:"votes"`
+	} `json:"results"`
+}
+
+// trendPoint is the standing of an option in one poll of a series.
+type trendPoint struct {
+	PollID uint      `json:"pollId"`
+	Date   time.Time `json:"date"`
+	Votes  uint      `json:"votes"`
+	Share  float64   `json:"share"`
+}
+
+// Fetch the results of a poll from the votes API, forwarding the admin
+// token of the caller so that unreleased results follow the same rules.
+func (pa *PollAPI) getPollResults(c *gin.Context, pollID uint) (pollResults, error) {
+	var results pollResults
+	resultsPath := fmt.Sprintf("%s/votes/results/%d", pa.votesAPIURL, pollID)
+
+	resp, err := pa.apiClient.R().
+		SetHeader(AdminTokenHeader, c.GetHeader(AdminTokenHeader)).
+		SetResult(&results).
+		Get(resultsPath)
+	if err != nil {
+		return pollResults{}, endpoints.VotesAPI.Unreachable(pa.votesAPIURL, err)
+	}
+
+	if resp.IsError() {
+		return pollResults{}, errors.New("votes API returned " + resp.Status())
+	}
+
+	return results, nil
+}
+
+// Implementation of GET /polls/series/:seriesId/trends.
+// Aligns the results of every poll in a series over time and returns a
+// trend line per option, matched across polls by option text.
+func (pa *PollAPI) GetSeriesTrends(c *gin.Context) {
+	seriesID := c.Param("seriesId")
+	seriesIDUint, err := strconv.ParseUint(seriesID, 10, 32)
+	if err != nil {
+		log.Println("Error converting series ID to uint: ", err)
+		apierror.AbortInvalidID(c, "Series ID", err)
+		return
+	}
+
+	polls, err := pa.pollList.GetSeriesPolls(uint(seriesIDUint))
+	if err != nil {
+		log.Println("Error getting series polls: ", err)
+		apierror.AbortWithError(c, http.StatusNotFound, "Could not get series polls", err)
+		return
+	}
+
+	seriesPolls := make([]map[string]interface{}, 0, len(polls))
+	trendLines := make(map[string][]trendPoint)
+	optionOrder := make([]string, 0)
+
+	for _, p := range polls {
+		seriesPoll := map[string]interface{}{
+			"pollId":     p.PollID,
+			"pollTitle":  p.PollTitle,
+			"pollStatus": p.PollStatus,
+			"date":       p.CreatedAt,
+		}
+
+		results, err := pa.getPollResults(c, p.PollID)
+		if err != nil {
+			log.Println("Error getting poll results: ", err)
+			seriesPoll["resultsAvailable"] = false
+			seriesPolls = append(seriesPolls, seriesPoll)
+			continue
+		}
+
+		seriesPoll["resultsAvailable"] = true
+		seriesPoll["totalVotes"] = results.TotalVotes
+		seriesPolls = append(seriesPolls, seriesPoll)
+
+		for _, result := range results.Results {
+			if _, exists := trendLines[result.OptionText]; !exists {
+				optionOrder = append(optionOrder, result.OptionText)
+			}
+
+			share := 0.0
+			if results.TotalVotes > 0 {
+				share = float64(result.Votes) / float64(results.TotalVotes)
+			}
+
+			trendLines[result.OptionText] = append(trendLines[result.OptionText], trendPoint{
+				PollID: p.PollID,
+				Date:   p.CreatedAt,
+				Votes:  result.Votes,
+				Share:  share,
+			})
+		}
+	}
+
+	trends := make([]map[string]interface{}, len(optionOrder))
+	for i, optionText := range optionOrder {
+		trends[i] = map[string]interface{}{
+			"optionText": optionText,
+			"points":     trendLines[optionText],
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"seriesId": seriesIDUint,
+		"polls":    seriesPolls,
+		"trends":   trends,
+	})
+}
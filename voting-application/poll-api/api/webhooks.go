@@ -0,0 +1,17 @@
+package api
+
+import (
+	"poll-api/poll"
+
+	"shared/webhook"
+)
+
+// Return the registry of the webhooks, served on /webhooks.
+func (pa *PollAPI) Webhooks() *webhook.Registry {
+	return pa.webhooks
+}
+
+// Deliver poll.closed to the hooks subscribed to it, with the closed poll.
+func (pa *PollAPI) pollClosed(closedPoll poll.Poll) {
+	pa.webhooks.Dispatch(webhook.EventPollClosed, closedPoll)
+}
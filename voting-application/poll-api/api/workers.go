@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"shared/apierror"
+	"shared/keyaudit"
+	"shared/worker"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Register the background jobs of the poll API and start the scheduler.
+// Only the replica holding the leader lease runs them.
+func (pa *PollAPI) StartWorkers(ctx context.Context) {
+	pa.scheduler.Register(worker.Job{
+		Name:     "retention",
+		Interval: pa.retention.interval,
+		Run:      pa.runRetention,
+	})
+	pa.scheduler.Register(worker.Job{
+		Name:     "poll-schedule",
+		Interval: pa.scheduleInterval,
+		Run:      pa.runPollSchedule,
+	})
+
+	pa.scheduler.Start(ctx)
+
+	// Every replica migrates the legacy keys it finds; a key moved by
+	// another one is skipped.
+	if pa.pollCache != nil {
+		go keyaudit.Migrate(pa.pollCache.AuditKeys)
+	}
+
+	// Every replica feeds its own stream clients.
+	if pa.pollEventsStream != "off" {
+		go pa.runPollFeed(ctx)
+	}
+}
+
+// Implementation of GET /admin/jobs.
+// List the registered background jobs with their run status.
+func (pa *PollAPI) ListJobs(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"leader": pa.scheduler.IsLeader(),
+		"jobs":   pa.scheduler.Stats(),
+	})
+}
+
+// Implementation of POST /admin/jobs/:name/run.
+// Ask the leader to run a job on its next tick.
+func (pa *PollAPI) RunJob(c *gin.Context) {
+	pa.controlJob(c, pa.scheduler.Trigger, http.StatusAccepted, "Job run requested successfully.")
+}
+
+// Implementation of POST /admin/jobs/:name/pause.
+// Stop the scheduled runs of a job until it is resumed.
+func (pa *PollAPI) PauseJob(c *gin.Context) {
+	pa.controlJob(c, pa.scheduler.Pause, http.StatusOK, "Job paused successfully.")
+}
+
+// Implementation of POST /admin/jobs/:name/resume.
+// Restart the scheduled runs of a paused job.
+func (pa *PollAPI) ResumeJob(c *gin.Context) {
+	pa.controlJob(c, pa.scheduler.Resume, http.StatusOK, "Job resumed successfully.")
+}
+
+// Apply a job control to the job named in the path.
+func (pa *PollAPI) controlJob(c *gin.Context, control func(name string) error, status int, message string) {
+	name := c.Param("name")
+
+	if err := control(name); err != nil {
+		if errors.Is(err, worker.ErrUnknownJob) {
+			apierror.AbortWithDetails(c, http.StatusNotFound, apierror.CodeNotFound, "Unknown job", err)
+			return
+		}
+
+		log.Println("Error controlling job: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not control job", err)
+		return
+	}
+
+	log.Printf("Admin job control on %s: %s", name, message)
+	c.JSON(status, gin.H{"message": message})
+}
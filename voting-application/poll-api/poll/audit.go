@@ -0,0 +1,81 @@
+package poll
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditKey = "audit:poll"
+)
+
+// AuditEntry records an administrative action that bypassed a poll policy.
+type AuditEntry struct {
+	Action    string    `json:"action"`
+	PollID    uint      `json:"pollId"`
+	Detail    string    `json:"detail"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Append an audit entry to the poll audit log in redis.
+func (pc *PollCache) AddAuditEntry(entry AuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	return pc.cacheClient.LPush(pc.context, AuditKey, data).Err()
+}
+
+// Return all entries of the poll audit log, newest first.
+func (pc *PollCache) GetAuditEntries() ([]AuditEntry, error) {
+	var entries []AuditEntry
+
+	items, err := pc.cacheClient.LRange(pc.context, AuditKey, 0, -1).Result()
+	if err != nil {
+		return entries, err
+	}
+
+	for _, item := range items {
+		var entry AuditEntry
+		if err := json.Unmarshal([]byte(item), &entry); err != nil {
+			return entries, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// Return the audit entries recorded before the cutoff and, unless dryRun
+// is set, remove them from the audit log.
+func (pc *PollCache) PurgeAuditEntries(cutoff time.Time, dryRun bool) ([]AuditEntry, error) {
+	purged := make([]AuditEntry, 0)
+
+	items, err := pc.cacheClient.LRange(pc.context, AuditKey, 0, -1).Result()
+	if err != nil {
+		return purged, err
+	}
+
+	for _, item := range items {
+		var entry AuditEntry
+		if err := json.Unmarshal([]byte(item), &entry); err != nil {
+			return purged, err
+		}
+
+		if !entry.Timestamp.Before(cutoff) {
+			continue
+		}
+
+		if !dryRun {
+			if err := pc.cacheClient.LRem(pc.context, AuditKey, 1, item).Err(); err != nil {
+				return purged, err
+			}
+		}
+
+		purged = append(purged, entry)
+	}
+
+	return purged, nil
+}
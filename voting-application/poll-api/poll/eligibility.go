@@ -0,0 +1,150 @@
+package poll
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+const (
+	EligibleKeyPrefix = "poll-eligible:"
+	// The most voter ID ranges a poll can have, also the max rule of
+	// VoterIDRanges.
+	MaxVoterIDRanges = 20
+)
+
+// ErrNotOnAllowlist is returned when a voter that is not on the allowlist
+// of a poll is removed from it.
+var ErrNotOnAllowlist = errors.New("voter is not on the allowlist of the poll")
+
+// VoterIDRange is a range of voter IDs, both ends included.
+type VoterIDRange struct {
+	From uint `json:"from" xml:"from,attr" msgpack:"from" binding:"required"`
+	To   uint `json:"to" xml:"to,attr" msgpack:"to" binding:"required,gtefield=From"`
+}
+
+// Eligibility restricts who can vote in a poll. A voter has to pass every
+// rule that is set.
+type Eligibility struct {
+	// The voter IDs that can vote, any when empty.
+	VoterIDRanges []VoterIDRange `json:"voterIdRanges,omitempty" xml:"voterIdRanges>range,omitempty" msgpack:"voterIdRanges,omitempty" binding:"max=20,dive"`
+	// Only the voters on the allowlist of the poll can vote.
+	Allowlist bool `json:"allowlist,omitempty" xml:"allowlist,omitempty" msgpack:"allowlist,omitempty"`
+}
+
+// Return the eligibility with its rules, nil when it has none.
+func NormalizeEligibility(eligibility *Eligibility) *Eligibility {
+	if eligibility == nil || (len(eligibility.VoterIDRanges) == 0 && !eligibility.Allowlist) {
+		return nil
+	}
+
+	return eligibility
+}
+
+// Return the key of the allowlist of a poll.
+func eligibleKey(pollID uint) string {
+	return fmt.Sprintf("%s%d", EligibleKeyPrefix, pollID)
+}
+
+// Set the eligibility rules of a poll, removing them when eligibility is
+// nil. The allowlist is kept.
+func (pc *PollCache) SetPollEligibility(pollID uint, eligibility *Eligibility) (Poll, error) {
+	return pc.polls.Update(pollID, func(poll *Poll) error {
+		poll.Eligibility = eligibility
+		return nil
+	})
+}
+
+// Return the voter IDs on the allowlist of a poll, in order.
+func (pc *PollCache) GetEligibleVoters(pollID uint) ([]uint, error) {
+	if _, err := pc.GetPoll(pollID); err != nil {
+		return nil, err
+	}
+
+	members, err := pc.cacheClient.SMembers(pc.context, eligibleKey(pollID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	voterIDs := make([]uint, 0, len(members))
+	for _, member := range members {
+		voterID, err := strconv.ParseUint(member, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		voterIDs = append(voterIDs, uint(voterID))
+	}
+
+	sort.Slice(voterIDs, func(i, j int) bool { return voterIDs[i] < voterIDs[j] })
+
+	return voterIDs, nil
+}
+
+// Add voters to the allowlist of a poll and return how many were not on
+// it yet.
+func (pc *PollCache) AddEligibleVoters(pollID uint, voterIDs []uint) (int, error) {
+	if _, err := pc.GetPoll(pollID); err != nil {
+		return 0, err
+	}
+
+	members := make([]interface{}, len(voterIDs))
+	for i, voterID := range voterIDs {
+		members[i] = voterID
+	}
+
+	added, err := pc.cacheClient.SAdd(pc.context, eligibleKey(pollID), members...).Result()
+
+	return int(added), err
+}
+
+// Report whether a voter is on the allowlist of a poll.
+func (pc *PollCache) IsEligibleVoter(pollID, voterID uint) (bool, error) {
+	if _, err := pc.GetPoll(pollID); err != nil {
+		return false, err
+	}
+
+	return pc.cacheClient.SIsMember(pc.context, eligibleKey(pollID), voterID).Result()
+}
+
+// Remove a voter from the allowlist of a poll.
+func (pc *PollCache) RemoveEligibleVoter(pollID, voterID uint) error {
+	if _, err := pc.GetPoll(pollID); err != nil {
+		return err
+	}
+
+	removed, err := pc.cacheClient.SRem(pc.context, eligibleKey(pollID), voterID).Result()
+	if err != nil {
+		return err
+	}
+
+	if removed == 0 {
+		return ErrNotOnAllowlist
+	}
+
+	return nil
+}
+
+// Copy the allowlist of a poll to another poll.
+func (pc *PollCache) copyEligibleVoters(sourcePollID, newPollID uint) error {
+	return pc.cacheClient.SUnionStore(pc.context, eligibleKey(newPollID), eligibleKey(sourcePollID)).Err()
+}
+
+// Delete the allowlist of a poll.
+func (pc *PollCache) deleteEligibleVoters(pollID uint) error {
+	return pc.cacheClient.Del(pc.context, eligibleKey(pollID)).Err()
+}
+
+// Delete the allowlists of every poll.
+func (pc *PollCache) deleteAllEligibleVoters() error {
+	keys, err := pc.cacheClient.Keys(pc.context, EligibleKeyPrefix+"*").Result()
+	if err != nil {
+		return err
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	return pc.cacheClient.Del(pc.context, keys...).Err()
+}
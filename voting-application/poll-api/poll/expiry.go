@@ -0,0 +1,43 @@
+package poll
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"shared/repository"
+)
+
+// Load how long draft polls are kept from TTL_DRAFT_POLLS_DAYS. A draft
+// that is not opened within that many days after it was created expires.
+// Zero, the default, keeps drafts until they open.
+func loadDraftTTL() time.Duration {
+	days, err := strconv.ParseUint(os.Getenv("TTL_DRAFT_POLLS_DAYS"), 10, 32)
+	if err != nil {
+		return 0
+	}
+
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// Return when a poll expires: a draft draftTTL after it was created, any
+// other poll never, so opening a draft keeps it.
+func (pc *PollCache) pollExpiry(poll Poll) time.Time {
+	// Drafts stored without a creation time are kept, rather than expiring
+	// at once.
+	if pc.draftTTL == 0 || poll.PollStatus != PollStatusDraft || poll.CreatedAt.IsZero() {
+		return time.Time{}
+	}
+
+	return poll.CreatedAt.Add(pc.draftTTL)
+}
+
+// Return how long draft polls are kept, forever when zero.
+func (pc *PollCache) DraftTTL() time.Duration {
+	return pc.draftTTL
+}
+
+// Return the polls that will expire, the first to expire first.
+func (pc *PollCache) GetExpirations() ([]repository.Expiration, error) {
+	return pc.polls.Expirations()
+}
@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS polls (
+    poll_id BIGINT PRIMARY KEY,
+    poll_title TEXT NOT NULL,
+    poll_question TEXT NOT NULL,
+    poll_status TEXT NOT NULL DEFAULT 'open',
+    series_id BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    closed_at TIMESTAMPTZ,
+    certified_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS polls_series_id_idx ON polls (series_id) WHERE series_id <> 0;
+
+CREATE TABLE IF NOT EXISTS poll_options (
+    poll_id BIGINT NOT NULL REFERENCES polls (poll_id) ON DELETE CASCADE,
+    poll_option_id BIGINT NOT NULL,
+    poll_option_text TEXT NOT NULL,
+    max_votes BIGINT NOT NULL DEFAULT 0,
+    position BIGSERIAL,
+    PRIMARY KEY (poll_id, poll_option_id)
+);
+
+CREATE TABLE IF NOT EXISTS poll_audit (
+    audit_id BIGSERIAL PRIMARY KEY,
+    action TEXT NOT NULL,
+    poll_id BIGINT NOT NULL,
+    detail TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,5 @@
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS polls_tags_idx ON polls USING GIN (tags);
+
+CREATE INDEX IF NOT EXISTS polls_search_idx ON polls USING GIN (to_tsvector('simple', poll_title || ' ' || poll_question));
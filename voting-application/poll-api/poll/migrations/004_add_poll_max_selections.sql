@@ -0,0 +1,3 @@
+-- A vote in a multi-choice poll selects up to max_selections options, 0
+-- keeps the poll single-choice.
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS max_selections INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+-- Votes in a poll that allows write-ins can give a free text in place of
+-- an option.
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS allow_write_in BOOLEAN NOT NULL DEFAULT false;
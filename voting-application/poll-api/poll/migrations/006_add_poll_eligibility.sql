@@ -0,0 +1,9 @@
+-- Who can vote in a poll: its eligibility rules, NULL when anyone can, and
+-- the allowlist of the polls whose rules use one.
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS eligibility JSONB;
+
+CREATE TABLE IF NOT EXISTS poll_eligible_voters (
+    poll_id BIGINT NOT NULL REFERENCES polls (poll_id) ON DELETE CASCADE,
+    voter_id BIGINT NOT NULL,
+    PRIMARY KEY (poll_id, voter_id)
+);
@@ -0,0 +1,3 @@
+-- When the schedule opens and closes a poll, NULL when it does not.
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS start_time TIMESTAMPTZ;
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS end_time TIMESTAMPTZ;
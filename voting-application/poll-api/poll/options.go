@@ -0,0 +1,67 @@
+package poll
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// The longest option text, also the max rule of PollOptionText.
+const MaxOptionTextLength = 200
+
+var (
+	// ErrEmptyOptionText is returned for an option text that is empty once
+	// trimmed.
+	ErrEmptyOptionText = errors.New("option text cannot be empty")
+
+	// ErrOptionTextTooLong is returned for an option text longer than
+	// MaxOptionTextLength characters.
+	ErrOptionTextTooLong = fmt.Errorf("option text can be at most %d characters", MaxOptionTextLength)
+
+	// ErrDuplicateOptionID is returned when an option is added with the ID
+	// of an option the poll already has.
+	ErrDuplicateOptionID = errors.New("poll option already exists")
+
+	// ErrDuplicateOptionText is returned when an option would get the text
+	// of another option of the poll, ignoring case.
+	ErrDuplicateOptionText = errors.New("another option of the poll has the same text")
+)
+
+// Return an option text in the form it is stored in: trimmed.
+func NormalizeOptionText(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", ErrEmptyOptionText
+	}
+
+	if utf8.RuneCountInString(text) > MaxOptionTextLength {
+		return "", ErrOptionTextTooLong
+	}
+
+	return text, nil
+}
+
+// Check that an option can be stored among the options of a poll.
+// Updating an option passes isNew false, so its own ID and text are not
+// reported as duplicates.
+func checkOption(options []pollOption, option pollOption, isNew bool) error {
+	for _, existing := range options {
+		if existing.PollOptionID == option.PollOptionID {
+			if isNew {
+				return ErrDuplicateOptionID
+			}
+			continue
+		}
+
+		if strings.EqualFold(existing.PollOptionText, option.PollOptionText) {
+			return ErrDuplicateOptionText
+		}
+	}
+
+	if isNew && len(options) >= MaxPollOptions {
+		return ErrTooManyOptions
+	}
+
+	return nil
+}
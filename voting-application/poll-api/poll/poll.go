@@ -2,45 +2,97 @@ package poll
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
 	"os"
+	"time"
+
+	"shared/codec"
+	"shared/conflict"
+	"shared/failover"
+	"shared/keyaudit"
+	"shared/keyring"
+	"shared/repository"
 
 	"github.com/go-redis/redis/v8"
-	"github.com/nitishm/go-rejson/v4"
 )
 
 const (
 	RedisNilError        = "redis: nil"
 	RedisDefaultLocation = "redis:6379"
 	RedisKeyPrefix       = "poll:"
+	// The most options a poll can have, also the max rule of PollOptions.
+	MaxPollOptions = 20
+)
+
+// ErrTooManyOptions is returned when an option is added to a poll that
+// already has MaxPollOptions options.
+var ErrTooManyOptions = fmt.Errorf("a poll can have at most %d options", MaxPollOptions)
+
+// ErrAnonymityChanged is returned when a create request would change
+// whether a stored poll is anonymous, which never changes.
+var ErrAnonymityChanged = errors.New("poll already exists with another anonymity")
+
+const (
+	// A poll with a start time in the future is a draft until the
+	// schedule opens it.
+	PollStatusDraft     = "draft"
+	PollStatusOpen      = "open"
+	PollStatusClosed    = "closed"
+	PollStatusCertified = "certified"
 )
 
 // pollOptions represents the poll information for a specific poll.
 type pollOption struct {
 	PollOptionID   uint   `json:"pollOptionId"`
-	PollOptionText string `json:"pollOptionText"`
+	PollOptionText string `json:"pollOptionText" binding:"required,max=200"`
+	MaxVotes       uint   `json:"maxVotes,omitempty"`
 }
 
 // Poll represents a poll with a unique ID and poll information.
 type Poll struct {
 	PollID       uint         `json:"pollId"`
-	PollTitle    string       `json:"pollTitle"`
-	PollQuestion string       `json:"pollQuestion"`
-	PollOptions  []pollOption `json:"pollOptions"`
+	PollTitle    string       `json:"pollTitle" binding:"required,min=3,max=200"`
+	PollQuestion string       `json:"pollQuestion" binding:"max=1000"`
+	PollOptions  []pollOption `json:"pollOptions" binding:"max=20,dive"`
+	PollStatus   string       `json:"pollStatus"`
+	SeriesID     uint         `json:"seriesId,omitempty"`
+	Tags         []string     `json:"tags,omitempty" binding:"max=10,dive,max=32"`
+	// A vote in a multi-choice poll selects up to MaxSelections options. A
+	// poll with 0 or 1 is single-choice. It is set when the poll is created.
+	MaxSelections uint `json:"maxSelections,omitempty" binding:"max=20"`
+	// Votes in a poll that allows write-ins can give a free text in place
+	// of an option. It is set when the poll is created.
+	AllowWriteIn bool `json:"allowWriteIn,omitempty"`
+	// The votes of an anonymous poll are stored without their voter. It
+	// is set when the poll is created and never changes.
+	Anonymous   bool       `json:"anonymous,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	ClosedAt    *time.Time `json:"closedAt,omitempty"`
+	CertifiedAt *time.Time `json:"certifiedAt,omitempty"`
+	// Who can vote in the poll, anyone when nil.
+	Eligibility *Eligibility `json:"eligibility,omitempty"`
+	// When the schedule opens and closes the poll. A poll without a start
+	// time is open when it is created, one without an end time stays open
+	// until it is closed.
+	StartTime *time.Time `json:"startTime,omitempty"`
+	EndTime   *time.Time `json:"endTime,omitempty"`
 }
 
 type cache struct {
-	cacheClient *redis.Client
-	jsonHelper  *rejson.Handler
+	cacheClient redis.UniversalClient
+	ring        *keyring.Ring
+	documents   *codec.Store
+	polls       *repository.Repository[Poll]
 	context     context.Context
 }
 
 // The reference to a cache object.
 type PollCache struct {
 	cache
+	// How long draft polls are kept, forever when zero.
+	draftTTL time.Duration
 }
 
 // The constructor function that returns a pointer to a new PollCache.
@@ -57,33 +109,115 @@ func NewPollCache() (*PollCache, error) {
 
 // The constructor function that returns a pointer to a new PollCache.
 func newPollCacheInstance(url string) (*PollCache, error) {
-	client := redis.NewClient(&redis.Options{
-		Addr: url,
-	})
+	client, err := failover.Connect(url)
+	if err != nil {
+		log.Println("Error configuring redis: ", err)
+		return nil, err
+	}
 
 	ctx := context.Background()
 
-	err := client.Ping(ctx).Err()
+	err = client.Ping(ctx).Err()
 	if err != nil {
 		log.Println("Error connecting to redis" + err.Error())
 		return nil, err
 	}
 
-	jsonHelper := rejson.NewReJSONHandler()
-	jsonHelper.SetGoRedisClientWithContext(ctx, client)
+	ring, err := keyring.FromEnv(ctx, client)
+	if err != nil {
+		log.Println("Error connecting to redis shards: ", err)
+		return nil, err
+	}
+	ring.StartHealthChecks(ctx, keyring.DefaultHealthInterval)
 
-	return &PollCache{
+	pc := &PollCache{
 		cache: cache{
 			cacheClient: client,
-			jsonHelper:  jsonHelper,
+			ring:        ring,
+			documents:   codec.NewStore(codec.FromEnv()),
 			context:     ctx,
 		},
-	}, nil
+		draftTTL: loadDraftTTL(),
+	}
+
+	pc.polls = repository.New(ctx, ring, pc.documents, repository.Config[Poll]{
+		Kind:   "poll",
+		Prefix: RedisKeyPrefix,
+		ID:     func(poll Poll) uint { return poll.PollID },
+		Hooks: repository.Hooks[Poll]{
+			Loaded:  defaultPollStatus,
+			Index:   pc.indexPoll,
+			Unindex: pc.unindexPoll,
+			Cleared: pc.clearIndexes,
+		},
+		Expiry: pc.pollExpiry,
+	})
+
+	return pc, nil
+}
+
+// Check that the redis connection of the PollCache is alive.
+func (pc *PollCache) Ping() error {
+	if pc == nil {
+		return errors.New("redis is not connected")
+	}
+
+	if err := pc.cacheClient.Ping(pc.context).Err(); err != nil {
+		return err
+	}
+
+	return pc.ring.Ping(pc.context)
+}
+
+// Return the health of the redis shards of the PollCache, or nil when it
+// is not connected.
+func (pc *PollCache) ShardHealth() []keyring.ShardHealth {
+	if pc == nil {
+		return nil
+	}
+
+	return pc.ring.Health()
+}
+
+// Move the polls that are on the wrong redis shard to the shard they
+// belong to.
+func (pc *PollCache) RebalanceShards(dryRun bool) (keyring.RebalanceReport, error) {
+	return pc.polls.Rebalance(dryRun)
+}
+
+// Audit the keys of the polls and the keyspace of redis, fixing them as fix
+// says, one of the keyaudit fixes.
+func (pc *PollCache) AuditKeys(fix string) (keyaudit.Report, error) {
+	report := keyaudit.Report{Fix: fix}
+
+	documents, err := pc.polls.Audit(fix)
+	report.Documents = documents
+	if err != nil {
+		return report, err
+	}
+
+	report.Unknown, err = keyaudit.Unknown(pc.context, pc.cacheClient, pc.ring, keyaudit.KnownPrefixes())
+
+	return report, err
 }
 
-// Get a string that can be used as a key in redis.
-func redisKeyFromId(id uint) string {
-	return fmt.Sprintf("%s%d", RedisKeyPrefix, id)
+// Return the codec metrics of the PollCache, or nil when it is not connected.
+func (pc *PollCache) CodecStats() *codec.Stats {
+	if pc == nil {
+		return nil
+	}
+
+	stats := pc.documents.Stats()
+	return &stats
+}
+
+// Return the redis client of the PollCache, or nil when it is not connected.
+func (pc *PollCache) RedisClient() redis.UniversalClient {
+	if pc == nil {
+		return nil
+	}
+
+	return pc.cacheClient
 }
 
 // Create a new Poll instance with the provided details.
@@ -93,103 +227,245 @@ func NewPoll(pollId uint, pollTitle string, pollQuestion string) Poll {
 		PollTitle:    pollTitle,
 		PollQuestion: pollQuestion,
 		PollOptions:  make([]pollOption, 0),
+		PollStatus:   PollStatusOpen,
+		CreatedAt:    time.Now(),
 	}
 
 	return poll
 }
 
-// Helper to return a Poll from redis provided a key.
-func (pc *PollCache) getItemFromRedis(key string, item *Poll) error {
-	itemObject, err := pc.jsonHelper.JSONGet(key, ".")
-	if err != nil {
-		return err
+// Return the stored poll after a create request, a poll as it would be
+// added, found it with the policy. Replace takes every field the request
+// can set: the title, question, series, tags, selections, write-ins,
+// eligibility and schedule. Merge takes the title and the fields the
+// request sets, and adds its tags to the stored ones. The options, status
+// and timestamps are kept, and neither policy changes whether the poll is
+// anonymous.
+func resolvePollConflict(stored, request Poll, policy string) (Poll, error) {
+	if request.Anonymous != stored.Anonymous && (policy == conflict.Replace || request.Anonymous) {
+		return Poll{}, ErrAnonymityChanged
 	}
 
-	err = json.Unmarshal(itemObject.([]byte), item)
-	if err != nil {
-		return err
+	resolved := stored
+	resolved.PollTitle = request.PollTitle
+
+	if policy == conflict.Replace {
+		resolved.PollQuestion = request.PollQuestion
+		resolved.SeriesID = request.SeriesID
+		resolved.Tags = request.Tags
+		resolved.MaxSelections = request.MaxSelections
+		resolved.AllowWriteIn = request.AllowWriteIn
+		resolved.Eligibility = request.Eligibility
+		resolved.StartTime = request.StartTime
+		resolved.EndTime = request.EndTime
+		return resolved, nil
 	}
 
-	return nil
+	if request.PollQuestion != "" {
+		resolved.PollQuestion = request.PollQuestion
+	}
+	if request.SeriesID != 0 {
+		resolved.SeriesID = request.SeriesID
+	}
+	if len(request.Tags) > 0 {
+		tags, err := NormalizeTags(append(append([]string{}, stored.Tags...), request.Tags...))
+		if err != nil {
+			return Poll{}, err
+		}
+		resolved.Tags = tags
+	}
+	if request.MaxSelections != 0 {
+		resolved.MaxSelections = request.MaxSelections
+	}
+	if request.AllowWriteIn {
+		resolved.AllowWriteIn = true
+	}
+	if request.Eligibility != nil {
+		resolved.Eligibility = request.Eligibility
+	}
+	if request.StartTime != nil {
+		resolved.StartTime = request.StartTime
+	}
+	if request.EndTime != nil {
+		resolved.EndTime = request.EndTime
+	}
+
+	return resolved, nil
+}
+
+// Polls stored before the lifecycle was introduced are open.
+func defaultPollStatus(poll *Poll) {
+	if poll.PollStatus == "" {
+		poll.PollStatus = PollStatusOpen
+	}
 }
 
 // Return a slice of all polls from the PollCache.
 func (pc *PollCache) GetAllPolls() ([]Poll, error) {
-	var polls []Poll
+	return pc.polls.All()
+}
 
-	pattern := fmt.Sprintf("%s*", RedisKeyPrefix)
-	keys, err := pc.cacheClient.Keys(pc.context, pattern).Result()
+// Call fn with every poll of the PollCache. The polls are never all held
+// in memory; a poll changed during the scan may be seen twice.
+func (pc *PollCache) EachPoll(fn func(Poll) error) error {
+	return pc.polls.Each(fn)
+}
 
-	if err != nil {
-		return polls, err
+// Retrieve a single poll from the PollCache by pollId.
+func (pc *PollCache) GetPoll(pollID uint) (Poll, error) {
+	return pc.polls.Get(pollID)
+}
+
+// Add a poll to the PollCache.
+func (pc *PollCache) AddPoll(poll Poll) error {
+	if err := pc.polls.Add(poll); err != nil {
+		return err
 	}
 
-	for _, key := range keys {
-		var poll Poll
+	if poll.SeriesID != 0 {
+		return pc.addPollToSeries(poll.SeriesID, poll.PollID)
+	}
 
-		err := pc.getItemFromRedis(key, &poll)
+	return nil
+}
+
+// Add a poll of a create request. When a poll has its ID the request
+// fails, replaces or merges into the stored poll as onConflict says. It
+// returns the stored poll and the outcome of the create.
+func (pc *PollCache) CreatePoll(poll Poll, onConflict string) (Poll, string, error) {
+	err := pc.AddPoll(poll)
+	if err == nil || onConflict == conflict.Fail || !errors.Is(err, repository.ErrExists) {
+		return poll, conflict.Created, err
+	}
+
+	var previousPoll Poll
+
+	resolvedPoll, err := pc.polls.Update(poll.PollID, func(existingPoll *Poll) error {
+		previousPoll = *existingPoll
+		resolved, err := resolvePollConflict(*existingPoll, poll, onConflict)
 		if err != nil {
-			return polls, err
+			return err
 		}
+		*existingPoll = resolved
+		return nil
+	})
+	if err != nil {
+		return Poll{}, "", err
+	}
 
-		polls = append(polls, poll)
+	if err := pc.reindexPoll(previousPoll, resolvedPoll); err != nil {
+		return Poll{}, "", err
 	}
 
-	return polls, nil
+	if previousPoll.SeriesID != resolvedPoll.SeriesID {
+		if previousPoll.SeriesID != 0 {
+			if err := pc.removePollFromSeries(previousPoll.SeriesID, poll.PollID); err != nil {
+				return Poll{}, "", err
+			}
+		}
+		if resolvedPoll.SeriesID != 0 {
+			if err := pc.addPollToSeries(resolvedPoll.SeriesID, poll.PollID); err != nil {
+				return Poll{}, "", err
+			}
+		}
+	}
+
+	return resolvedPoll, conflict.Outcome(onConflict), nil
 }
 
-// Retrieve a single poll from the PollCache by pollId.
-func (pc *PollCache) GetPoll(pollID uint) (Poll, error) {
-	var poll Poll
+// Update the title and question of an existing poll in the PollCache.
+func (pc *PollCache) UpdatePoll(poll Poll) (Poll, error) {
+	var previousPoll Poll
 
-	redisKey := redisKeyFromId(pollID)
-	if err := pc.getItemFromRedis(redisKey, &poll); err != nil {
-		return Poll{}, errors.New("poll does not exist")
+	updatedPoll, err := pc.polls.Update(poll.PollID, func(existingPoll *Poll) error {
+		previousPoll = *existingPoll
+		existingPoll.PollTitle = poll.PollTitle
+		existingPoll.PollQuestion = poll.PollQuestion
+		return nil
+	})
+	if err != nil {
+		return Poll{}, err
 	}
 
-	return poll, nil
+	if err := pc.reindexPoll(previousPoll, updatedPoll); err != nil {
+		return Poll{}, err
+	}
+
+	return updatedPoll, nil
 }
 
-// Add a poll to the PollCache.
-func (pc *PollCache) AddPoll(poll Poll) error {
-	if _, err := pc.GetPoll(poll.PollID); err == nil {
-		return errors.New("poll already exists")
-	}
+// Close an open poll so that it stops accepting votes.
+func (pc *PollCache) ClosePoll(pollID uint) (Poll, error) {
+	return pc.polls.Update(pollID, func(poll *Poll) error {
+		if poll.PollStatus != PollStatusOpen {
+			return errors.New("poll is not open")
+		}
 
-	redisKey := redisKeyFromId(poll.PollID)
-	if _, setErr := pc.jsonHelper.JSONSet(redisKey, ".", poll); setErr != nil {
-		return setErr
-	}
+		now := time.Now()
+		poll.PollStatus = PollStatusClosed
+		poll.ClosedAt = &now
+		return nil
+	})
+}
 
-	return nil
+// Open a draft poll so that it starts accepting votes.
+func (pc *PollCache) OpenPoll(pollID uint) (Poll, error) {
+	return pc.polls.Update(pollID, func(poll *Poll) error {
+		if poll.PollStatus != PollStatusDraft {
+			return errors.New("poll is not draft")
+		}
+
+		poll.PollStatus = PollStatusOpen
+		return nil
+	})
+}
+
+// Certify a closed poll, releasing its results to the public.
+func (pc *PollCache) CertifyPoll(pollID uint) (Poll, error) {
+	return pc.polls.Update(pollID, func(poll *Poll) error {
+		if poll.PollStatus != PollStatusClosed {
+			return errors.New("poll is not closed")
+		}
+
+		now := time.Now()
+		poll.PollStatus = PollStatusCertified
+		poll.CertifiedAt = &now
+		return nil
+	})
 }
 
 // Delete all polls from the PollCache.
 func (pc *PollCache) DeleteAllPolls() error {
-	pattern := fmt.Sprintf("%s*", RedisKeyPrefix)
-	keys, err := pc.cacheClient.Keys(pc.context, pattern).Result()
-	if err != nil {
+	return pc.polls.DeleteAll()
+}
+
+// Delete the tag and series indexes and the allowlists once every poll is
+// deleted.
+func (pc *PollCache) clearIndexes() error {
+	if err := pc.deleteSearchIndex(); err != nil {
 		return err
 	}
 
-	for _, key := range keys {
-		if _, deleteErr := pc.cacheClient.Del(pc.context, key).Result(); deleteErr != nil {
-			return deleteErr
-		}
+	if err := pc.deleteAllEligibleVoters(); err != nil {
+		return err
 	}
 
-	return nil
+	return pc.deleteAllSeries()
 }
 
 // Delete a single poll from the PollCache by pollID.
 func (pc *PollCache) DeletePoll(pollID uint) error {
-	if _, err := pc.GetPoll(pollID); err != nil {
-		return errors.New("poll does not exist")
+	poll, err := pc.polls.Delete(pollID)
+	if err != nil {
+		return err
+	}
+
+	if err := pc.deleteEligibleVoters(pollID); err != nil {
+		return err
 	}
 
-	redisKey := redisKeyFromId(pollID)
-	if _, deleteErr := pc.jsonHelper.JSONDel(redisKey, "."); deleteErr != nil {
-		return deleteErr
+	if poll.SeriesID != 0 {
+		return pc.removePollFromSeries(poll.SeriesID, poll.PollID)
 	}
 
 	return nil
@@ -199,7 +475,7 @@ func (pc *PollCache) DeletePoll(pollID uint) error {
 func (pc *PollCache) GetPollOptions(pollID uint) ([]pollOption, error) {
 	poll, err := pc.GetPoll(pollID)
 	if err != nil {
-		return nil, errors.New("poll does not exist")
+		return nil, err
 	}
 
 	return poll.PollOptions, nil
@@ -209,7 +485,7 @@ func (pc *PollCache) GetPollOptions(pollID uint) ([]pollOption, error) {
 func (pc *PollCache) GetPollOption(pollID, pollOptionID uint) (pollOption, error) {
 	poll, err := pc.GetPoll(pollID)
 	if err != nil {
-		return pollOption{}, errors.New("poll does not exist")
+		return pollOption{}, err
 	}
 
 	for _, option := range poll.PollOptions {
@@ -222,56 +498,75 @@ func (pc *PollCache) GetPollOption(pollID, pollOptionID uint) (pollOption, error
 }
 
 // Add a new poll option to the poll options of a poll.
-func (pc *PollCache) AddPollOption(pollID, pollOptionID uint, pollOptionText string) (pollOption, error) {
-	poll, err := pc.GetPoll(pollID)
-	if err != nil {
-		return pollOption{}, errors.New("poll does not exist")
-	}
-
-	for _, option := range poll.PollOptions {
-		if option.PollOptionID == pollOptionID {
-			return pollOption{}, errors.New("poll option has already in poll")
-		}
-	}
-
+// A non-zero maxVotes caps the number of votes the option can receive.
+func (pc *PollCache) AddPollOption(pollID, pollOptionID uint, pollOptionText string, maxVotes uint) (pollOption, error) {
 	newPollOption := pollOption{
 		PollOptionID:   pollOptionID,
 		PollOptionText: pollOptionText,
+		MaxVotes:       maxVotes,
 	}
 
-	poll.PollOptions = append(poll.PollOptions, newPollOption)
+	_, err := pc.polls.Update(pollID, func(poll *Poll) error {
+		if err := checkOption(poll.PollOptions, newPollOption, true); err != nil {
+			return err
+		}
 
-	redisKey := redisKeyFromId(poll.PollID)
-	if _, setErr := pc.jsonHelper.JSONSet(redisKey, ".", poll); setErr != nil {
-		return pollOption{}, setErr
+		poll.PollOptions = append(poll.PollOptions, newPollOption)
+		return nil
+	})
+	if err != nil {
+		return pollOption{}, err
 	}
 
 	return newPollOption, nil
 }
 
-// Remove a specific poll option from the poll options of a poll.
-func (pc *PollCache) DeletePollOption(pollID, pollOptionID uint) error {
-	poll, err := pc.GetPoll(pollID)
+// Update the text of an existing poll option of a poll.
+func (pc *PollCache) UpdatePollOption(pollID, pollOptionID uint, pollOptionText string) (pollOption, error) {
+	var updatedPollOption pollOption
+
+	_, err := pc.polls.Update(pollID, func(poll *Poll) error {
+		for i, option := range poll.PollOptions {
+			if option.PollOptionID == pollOptionID {
+				updatedPollOption = pollOption{
+					PollOptionID:   pollOptionID,
+					PollOptionText: pollOptionText,
+					MaxVotes:       option.MaxVotes,
+				}
+				if err := checkOption(poll.PollOptions, updatedPollOption, false); err != nil {
+					return err
+				}
+				poll.PollOptions[i] = updatedPollOption
+				return nil
+			}
+		}
+
+		return errors.New("poll option not found")
+	})
 	if err != nil {
-		return errors.New("poll does not exist")
+		return pollOption{}, err
 	}
 
-	var updatedPollOptions []pollOption
-	for _, pollOpt := range poll.PollOptions {
-		if pollOpt.PollOptionID != pollOptionID {
-			updatedPollOptions = append(updatedPollOptions, pollOpt)
+	return updatedPollOption, nil
+}
+
+// Remove a specific poll option from the poll options of a poll.
+func (pc *PollCache) DeletePollOption(pollID, pollOptionID uint) error {
+	_, err := pc.polls.Update(pollID, func(poll *Poll) error {
+		updatedPollOptions := make([]pollOption, 0, len(poll.PollOptions))
+		for _, pollOpt := range poll.PollOptions {
+			if pollOpt.PollOptionID != pollOptionID {
+				updatedPollOptions = append(updatedPollOptions, pollOpt)
+			}
 		}
-	}
 
-	if len(updatedPollOptions) == len(poll.PollOptions) {
-		return errors.New("poll option not found")
-	}
+		if len(updatedPollOptions) == len(poll.PollOptions) {
+			return errors.New("poll option not found")
+		}
 
-	poll.PollOptions = updatedPollOptions
-	redisKey := redisKeyFromId(poll.PollID)
-	if _, setErr := pc.jsonHelper.JSONSet(redisKey, ".", poll); setErr != nil {
-		return setErr
-	}
+		poll.PollOptions = updatedPollOptions
+		return nil
+	})
 
-	return nil
+	return err
 }
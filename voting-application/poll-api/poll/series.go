@@ -0,0 +1,129 @@
+package poll
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+const (
+	SeriesKeyPrefix = "series:"
+)
+
+// Get a string that can be used as a series key in redis.
+func seriesKeyFromId(id uint) string {
+	return fmt.Sprintf("%s%d", SeriesKeyPrefix, id)
+}
+
+// Record a poll as a member of a series.
+func (pc *PollCache) addPollToSeries(seriesID, pollID uint) error {
+	return pc.cacheClient.SAdd(pc.context, seriesKeyFromId(seriesID), pollID).Err()
+}
+
+// Remove a poll from the members of a series.
+func (pc *PollCache) removePollFromSeries(seriesID, pollID uint) error {
+	return pc.cacheClient.SRem(pc.context, seriesKeyFromId(seriesID), pollID).Err()
+}
+
+// Delete the membership of every series.
+func (pc *PollCache) deleteAllSeries() error {
+	pattern := fmt.Sprintf("%s*", SeriesKeyPrefix)
+	keys, err := pc.cacheClient.Keys(pc.context, pattern).Result()
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if _, deleteErr := pc.cacheClient.Del(pc.context, key).Result(); deleteErr != nil {
+			return deleteErr
+		}
+	}
+
+	return nil
+}
+
+// Return the polls of a series ordered by creation time.
+func (pc *PollCache) GetSeriesPolls(seriesID uint) ([]Poll, error) {
+	var polls []Poll
+
+	members, err := pc.cacheClient.SMembers(pc.context, seriesKeyFromId(seriesID)).Result()
+	if err != nil {
+		return polls, err
+	}
+
+	if len(members) == 0 {
+		return polls, errors.New("series does not exist")
+	}
+
+	for _, member := range members {
+		pollID, err := strconv.ParseUint(member, 10, 32)
+		if err != nil {
+			return polls, err
+		}
+
+		poll, err := pc.GetPoll(uint(pollID))
+		if err != nil {
+			// The poll was removed without updating the series.
+			continue
+		}
+
+		polls = append(polls, poll)
+	}
+
+	sort.Slice(polls, func(i, j int) bool {
+		if polls[i].CreatedAt.Equal(polls[j].CreatedAt) {
+			return polls[i].PollID < polls[j].PollID
+		}
+		return polls[i].CreatedAt.Before(polls[j].CreatedAt)
+	})
+
+	return polls, nil
+}
+
+// Clone a poll with its question, options, eligibility rules and
+// allowlist into a new open poll. Both polls become members of the same
+// series; a poll that is not part of a series yet starts a new series
+// named after its own ID.
+func (pc *PollCache) ClonePoll(sourcePollID, newPollID uint) (Poll, error) {
+	sourcePoll, err := pc.GetPoll(sourcePollID)
+	if err != nil {
+		return Poll{}, err
+	}
+
+	seriesID := sourcePoll.SeriesID
+	if seriesID == 0 {
+		seriesID = sourcePoll.PollID
+
+		_, err := pc.polls.Update(sourcePoll.PollID, func(poll *Poll) error {
+			poll.SeriesID = seriesID
+			return nil
+		})
+		if err != nil {
+			return Poll{}, err
+		}
+
+		if err := pc.addPollToSeries(seriesID, sourcePoll.PollID); err != nil {
+			return Poll{}, err
+		}
+	}
+
+	newPoll := NewPoll(newPollID, sourcePoll.PollTitle, sourcePoll.PollQuestion)
+	newPoll.PollOptions = append(newPoll.PollOptions, sourcePoll.PollOptions...)
+	newPoll.SeriesID = seriesID
+	newPoll.Tags = append([]string(nil), sourcePoll.Tags...)
+	newPoll.Anonymous = sourcePoll.Anonymous
+	newPoll.MaxSelections = sourcePoll.MaxSelections
+	newPoll.AllowWriteIn = sourcePoll.AllowWriteIn
+	newPoll.Eligibility = sourcePoll.Eligibility
+
+	if err := pc.AddPoll(newPoll); err != nil {
+		return Poll{}, err
+	}
+
+	if err := pc.copyEligibleVoters(sourcePollID, newPollID); err != nil {
+		return Poll{}, err
+	}
+
+	return newPoll, nil
+}
@@ -0,0 +1,66 @@
+package poll
+
+import (
+	"os"
+	"time"
+)
+
+const (
+	StorageBackendRedis    = "redis"
+	StorageBackendPostgres = "postgres"
+)
+
+// Store is the storage the poll handlers work against. Both the redis
+// PollCache and the postgres PollPostgres implement it.
+type Store interface {
+	GetAllPolls() ([]Poll, error)
+	EachPoll(fn func(Poll) error) error
+	GetPoll(pollID uint) (Poll, error)
+	AddPoll(poll Poll) error
+	CreatePoll(poll Poll, onConflict string) (Poll, string, error)
+	UpdatePoll(poll Poll) (Poll, error)
+	OpenPoll(pollID uint) (Poll, error)
+	ClosePoll(pollID uint) (Poll, error)
+	CertifyPoll(pollID uint) (Poll, error)
+	DeleteAllPolls() error
+	DeletePoll(pollID uint) error
+	GetPollOptions(pollID uint) ([]pollOption, error)
+	GetPollOption(pollID, pollOptionID uint) (pollOption, error)
+	AddPollOption(pollID, pollOptionID uint, pollOptionText string, maxVotes uint) (pollOption, error)
+	UpdatePollOption(pollID, pollOptionID uint, pollOptionText string) (pollOption, error)
+	DeletePollOption(pollID, pollOptionID uint) error
+	GetSeriesPolls(seriesID uint) ([]Poll, error)
+	ClonePoll(sourcePollID, newPollID uint) (Poll, error)
+	AddPollTag(pollID uint, tag string) (Poll, error)
+	RemovePollTag(pollID uint, tag string) (Poll, error)
+	SearchPolls(tag, query string) ([]Poll, error)
+	SetPollEligibility(pollID uint, eligibility *Eligibility) (Poll, error)
+	GetEligibleVoters(pollID uint) ([]uint, error)
+	AddEligibleVoters(pollID uint, voterIDs []uint) (int, error)
+	IsEligibleVoter(pollID, voterID uint) (bool, error)
+	RemoveEligibleVoter(pollID, voterID uint) error
+	AddAuditEntry(entry AuditEntry) error
+	GetAuditEntries() ([]AuditEntry, error)
+	PurgeAuditEntries(cutoff time.Time, dryRun bool) ([]AuditEntry, error)
+	Ping() error
+}
+
+// Return the configured storage backend, redis unless STORAGE_BACKEND is set.
+func StorageBackend() string {
+	backend := os.Getenv("STORAGE_BACKEND")
+	if backend == "" {
+		return StorageBackendRedis
+	}
+
+	return backend
+}
+
+// Return the Store selected by STORAGE_BACKEND. The redis backend reuses
+// the provided PollCache.
+func NewPollStore(pollCache *PollCache) (Store, error) {
+	if StorageBackend() == StorageBackendPostgres {
+		return NewPollPostgres()
+	}
+
+	return pollCache, nil
+}
@@ -0,0 +1,270 @@
+package poll
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	TagKeyPrefix  = "poll-tag:"
+	WordKeyPrefix = "poll-word:"
+	// The most tags a poll can have, also the max rule of Tags.
+	MaxPollTags  = 10
+	MaxTagLength = 32
+)
+
+var (
+	// ErrInvalidTag is returned for a tag that is not made of lowercase
+	// letters, digits, dashes and underscores.
+	ErrInvalidTag = fmt.Errorf("a tag is 1 to %d letters, digits, dashes or underscores", MaxTagLength)
+
+	// ErrTooManyTags is returned when a tag is added to a poll that already
+	// has MaxPollTags tags.
+	ErrTooManyTags = fmt.Errorf("a poll can have at most %d tags", MaxPollTags)
+
+	tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+)
+
+// Return a tag in the form it is stored in: trimmed and lowercase.
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if len(tag) > MaxTagLength || !tagPattern.MatchString(tag) {
+		return "", ErrInvalidTag
+	}
+
+	return tag, nil
+}
+
+// Normalize a list of tags, dropping duplicates.
+func NormalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag, err := NormalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+
+	if len(normalized) > MaxPollTags {
+		return nil, ErrTooManyTags
+	}
+
+	return normalized, nil
+}
+
+// Split text into the lowercase words the search matches.
+func SearchWords(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	words := make([]string, 0, len(fields))
+	seen := make(map[string]bool)
+	for _, word := range fields {
+		if !seen[word] {
+			seen[word] = true
+			words = append(words, word)
+		}
+	}
+
+	return words
+}
+
+// The search index lives on the primary redis node next to the series. A
+// set per tag and a set per word of the titles and questions hold the IDs
+// of the polls they appear in, so a search is a SINTER of a few sets.
+
+// Return the index keys of a poll.
+func searchKeys(poll Poll) []string {
+	var keys []string
+	for _, tag := range poll.Tags {
+		keys = append(keys, TagKeyPrefix+tag)
+	}
+
+	for _, word := range SearchWords(poll.PollTitle + " " + poll.PollQuestion) {
+		keys = append(keys, WordKeyPrefix+word)
+	}
+
+	return keys
+}
+
+// Add a poll to the search index.
+func (pc *PollCache) indexPoll(poll Poll) error {
+	_, err := pc.cacheClient.Pipelined(pc.context, func(pipe redis.Pipeliner) error {
+		for _, key := range searchKeys(poll) {
+			pipe.SAdd(pc.context, key, poll.PollID)
+		}
+		return nil
+	})
+
+	return err
+}
+
+// Remove a poll from the search index.
+func (pc *PollCache) unindexPoll(poll Poll) error {
+	_, err := pc.cacheClient.Pipelined(pc.context, func(pipe redis.Pipeliner) error {
+		for _, key := range searchKeys(poll) {
+			pipe.SRem(pc.context, key, poll.PollID)
+		}
+		return nil
+	})
+
+	return err
+}
+
+// Move a poll in the search index from its previous to its current form.
+func (pc *PollCache) reindexPoll(previous, current Poll) error {
+	if err := pc.unindexPoll(previous); err != nil {
+		return err
+	}
+
+	return pc.indexPoll(current)
+}
+
+// Delete the search index.
+func (pc *PollCache) deleteSearchIndex() error {
+	for _, prefix := range []string{TagKeyPrefix, WordKeyPrefix} {
+		keys, err := pc.cacheClient.Keys(pc.context, prefix+"*").Result()
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			if _, deleteErr := pc.cacheClient.Del(pc.context, key).Result(); deleteErr != nil {
+				return deleteErr
+			}
+		}
+	}
+
+	return nil
+}
+
+// Rebuild the search index from the stored polls, for polls that were
+// stored before the index existed. It returns the number of polls indexed.
+func (pc *PollCache) RebuildSearchIndex() (int, error) {
+	if err := pc.deleteSearchIndex(); err != nil {
+		return 0, err
+	}
+
+	indexed := 0
+	err := pc.EachPoll(func(poll Poll) error {
+		if err := pc.indexPoll(poll); err != nil {
+			return fmt.Errorf("indexing poll %d: %w", poll.PollID, err)
+		}
+
+		indexed++
+		return nil
+	})
+
+	return indexed, err
+}
+
+// Add a tag to a poll. Adding a tag the poll already has changes nothing.
+func (pc *PollCache) AddPollTag(pollID uint, tag string) (Poll, error) {
+	taggedPoll, err := pc.polls.Update(pollID, func(poll *Poll) error {
+		for _, existing := range poll.Tags {
+			if existing == tag {
+				return nil
+			}
+		}
+
+		if len(poll.Tags) >= MaxPollTags {
+			return ErrTooManyTags
+		}
+
+		poll.Tags = append(poll.Tags, tag)
+		return nil
+	})
+	if err != nil {
+		return Poll{}, err
+	}
+
+	if err := pc.cacheClient.SAdd(pc.context, TagKeyPrefix+tag, pollID).Err(); err != nil {
+		return Poll{}, err
+	}
+
+	return taggedPoll, nil
+}
+
+// Remove a tag from a poll.
+func (pc *PollCache) RemovePollTag(pollID uint, tag string) (Poll, error) {
+	untaggedPoll, err := pc.polls.Update(pollID, func(poll *Poll) error {
+		tags := make([]string, 0, len(poll.Tags))
+		for _, existing := range poll.Tags {
+			if existing != tag {
+				tags = append(tags, existing)
+			}
+		}
+
+		if len(tags) == len(poll.Tags) {
+			return errors.New("poll tag not found")
+		}
+
+		poll.Tags = tags
+		return nil
+	})
+	if err != nil {
+		return Poll{}, err
+	}
+
+	if err := pc.cacheClient.SRem(pc.context, TagKeyPrefix+tag, pollID).Err(); err != nil {
+		return Poll{}, err
+	}
+
+	return untaggedPoll, nil
+}
+
+// Return the polls that have the tag, when it is not empty, and whose
+// title or question contain every word of query, ordered by poll ID.
+func (pc *PollCache) SearchPolls(tag, query string) ([]Poll, error) {
+	var keys []string
+	if tag != "" {
+		keys = append(keys, TagKeyPrefix+tag)
+	}
+
+	for _, word := range SearchWords(query) {
+		keys = append(keys, WordKeyPrefix+word)
+	}
+
+	polls := make([]Poll, 0)
+	if len(keys) == 0 {
+		return polls, nil
+	}
+
+	members, err := pc.cacheClient.SInter(pc.context, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, member := range members {
+		pollID, err := strconv.ParseUint(member, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+
+		poll, err := pc.GetPoll(uint(pollID))
+		if err != nil {
+			// The poll was deleted since it was indexed.
+			continue
+		}
+
+		polls = append(polls, poll)
+	}
+
+	sort.Slice(polls, func(i, j int) bool { return polls[i].PollID < polls[j].PollID })
+
+	return polls, nil
+}
@@ -0,0 +1,62 @@
+package poll
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	VersionKeyPrefix = "poll-version:"
+	// Counts the changes to any poll, the version of the list of polls.
+	AllPollsVersionKey = VersionKeyPrefix + "all"
+)
+
+// Get the key of the version counter of a poll.
+func versionKeyFromId(id uint) string {
+	return fmt.Sprintf("%s%d", VersionKeyPrefix, id)
+}
+
+// Read a version counter, zero when it was never bumped.
+func (pc *PollCache) getVersion(key string) (int64, error) {
+	if pc == nil {
+		return 0, errors.New("redis is not connected")
+	}
+
+	version, err := pc.cacheClient.Get(pc.context, key).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+
+	return version, err
+}
+
+// Return the version of a poll. It changes every time the poll does,
+// whichever storage backend holds the poll.
+func (pc *PollCache) PollVersion(pollID uint) (int64, error) {
+	return pc.getVersion(versionKeyFromId(pollID))
+}
+
+// Return the version of the list of polls. It changes every time any poll
+// does.
+func (pc *PollCache) PollsVersion() (int64, error) {
+	return pc.getVersion(AllPollsVersionKey)
+}
+
+// Bump the version of a poll and of the list of polls after a change. The
+// counter of a deleted poll is kept, so a poll created again with the same
+// ID never reuses a version.
+func (pc *PollCache) BumpPollVersion(pollID uint) error {
+	if pc == nil {
+		return errors.New("redis is not connected")
+	}
+
+	_, err := pc.cacheClient.TxPipelined(pc.context, func(pipe redis.Pipeliner) error {
+		pipe.Incr(pc.context, versionKeyFromId(pollID))
+		pipe.Incr(pc.context, AllPollsVersionKey)
+		return nil
+	})
+
+	return err
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"net/http"
+
+	"poll-api/api"
+
+	"shared/apierror"
+	"shared/routes"
+	"shared/seed"
+	"shared/snapshot"
+)
+
+var (
+	exportLimit  = routes.PerMinute(10)
+	searchLimit  = routes.PerMinute(120)
+	rebuildLimit = routes.PerMinute(2)
+	streamLimit  = routes.PerMinute(30)
+	adminScope   = []string{routes.ScopeAdmin}
+)
+
+// Define the API endpoints and map them to the corresponding handler.
+func routeTable(pollHandler *api.PollAPI) *routes.Table {
+	return &routes.Table{
+		Service: "poll-api",
+		Version: "1.1.0",
+		// 1.1 only added fields and routes.
+		SupportedVersions: []string{"1.0.0", "1.1.0"},
+		Welcome:           "Welcome to poll API.",
+		Scopes: []routes.Scope{
+			{
+				Name:        routes.ScopeAdmin,
+				Description: "Requires the X-Admin-Token header matching ADMIN_TOKEN",
+				Middleware:  api.AdminMiddleware(),
+				Header:      api.AdminTokenHeader,
+			},
+		},
+		Routes: []routes.Route{
+			{Method: http.MethodGet, Path: "/polls", Handler: pollHandler.ListAllVPolls, Summary: "List every poll"},
+			{Method: http.MethodGet, Path: "/polls/export", Handler: pollHandler.ExportPolls, Summary: "Export every poll as CSV or JSON lines", Limit: exportLimit},
+			{Method: http.MethodGet, Path: "/polls/upcoming", Handler: pollHandler.ListUpcomingPolls, Summary: "List the draft polls by the time they open"},
+			{Method: http.MethodGet, Path: "/polls/active", Handler: pollHandler.ListActivePolls, Summary: "List the open polls by the time they close"},
+			{Method: http.MethodGet, Path: "/polls/search", Handler: pollHandler.SearchPolls, Summary: "Search polls by tag and by words of their title or question", Limit: searchLimit},
+			{Method: http.MethodGet, Path: "/polls/stream", Handler: pollHandler.StreamPollEvents, Summary: "Stream poll lifecycle and change events over a WebSocket", Limit: streamLimit},
+			{Method: http.MethodGet, Path: "/polls/stream/sse", Handler: pollHandler.StreamPollEventsSSE, Summary: "Stream poll lifecycle and change events as server-sent events", Limit: streamLimit},
+			{Method: http.MethodGet, Path: "/polls/:id", Handler: pollHandler.GetPoll, Summary: "Get a poll"},
+			{Method: http.MethodPost, Path: "/polls/:id", Handler: pollHandler.AddPoll, Summary: "Add a poll, ?onConflict=replace or merge when it exists"},
+			{Method: http.MethodPut, Path: "/polls/:id", Handler: pollHandler.UpdatePoll, Summary: "Change the title and question of a poll"},
+			{Method: http.MethodPost, Path: "/polls/:id/clone/:newId", Handler: pollHandler.ClonePoll, Summary: "Clone a poll into a new poll of its series"},
+			{Method: http.MethodPost, Path: "/polls/:id/close", Handler: pollHandler.ClosePoll, Summary: "Close a poll"},
+			{Method: http.MethodGet, Path: "/polls/:id/result", Handler: pollHandler.GetPollResult, Summary: "Get the results of a poll as they were frozen when it closed"},
+			{Method: http.MethodPost, Path: "/polls/:id/certify", Handler: pollHandler.CertifyPoll, Summary: "Certify a closed poll"},
+			{Method: http.MethodDelete, Path: "/polls", Handler: pollHandler.DeleteAllPolls, Summary: "Delete every poll"},
+			{Method: http.MethodDelete, Path: "/polls/:id", Handler: pollHandler.DeletePoll, Summary: "Delete a poll"},
+			{Method: http.MethodGet, Path: "/polls/:id/options", Handler: pollHandler.GetPollOptions, Summary: "List the options of a poll"},
+			{Method: http.MethodGet, Path: "/polls/:id/options/:optionId", Handler: pollHandler.GetPollOption, Summary: "Get an option of a poll"},
+			{Method: http.MethodPost, Path: "/polls/:id/options/:optionId", Handler: pollHandler.AddPollOption, Summary: "Add an option to a poll"},
+			{Method: http.MethodPut, Path: "/polls/:id/options/:optionId", Handler: pollHandler.UpdatePollOption, Summary: "Change the text of an option"},
+			{Method: http.MethodDelete, Path: "/polls/:id/options/:optionId", Handler: pollHandler.DeletePollOption, Summary: "Remove an option from a poll"},
+			{Method: http.MethodPost, Path: "/polls/:id/tags/:tag", Handler: pollHandler.AddPollTag, Summary: "Add a tag to a poll"},
+			{Method: http.MethodDelete, Path: "/polls/:id/tags/:tag", Handler: pollHandler.RemovePollTag, Summary: "Remove a tag from a poll"},
+			{Method: http.MethodPut, Path: "/polls/:id/eligibility", Handler: pollHandler.SetPollEligibility, Summary: "Set who can vote in a poll"},
+			{Method: http.MethodGet, Path: "/polls/:id/eligibility/voters", Handler: pollHandler.GetEligibleVoters, Summary: "List the allowlist of a poll"},
+			{Method: http.MethodPost, Path: "/polls/:id/eligibility/voters", Handler: pollHandler.AddEligibleVoters, Summary: "Add voters to the allowlist of a poll"},
+			{Method: http.MethodGet, Path: "/polls/:id/eligibility/voters/:voterId", Handler: pollHandler.GetEligibleVoter, Summary: "Check whether a voter is on the allowlist of a poll"},
+			{Method: http.MethodDelete, Path: "/polls/:id/eligibility/voters/:voterId", Handler: pollHandler.RemoveEligibleVoter, Summary: "Remove a voter from the allowlist of a poll"},
+			{Method: http.MethodGet, Path: "/polls/series/:seriesId/trends", Handler: pollHandler.GetSeriesTrends, Summary: "Compare the results of the polls of a series"},
+			{Method: http.MethodGet, Path: "/polls/audit", Handler: pollHandler.GetAuditLog, Summary: "List the forced edits of polls"},
+			{Method: http.MethodGet, Path: "/polls/health", Handler: pollHandler.HealthCheck, Summary: "Request metrics of the API", Access: routes.Internal},
+			{Method: http.MethodGet, Path: "/healthz", Handler: pollHandler.Liveness, Summary: "Liveness probe", Access: routes.Internal},
+			{Method: http.MethodGet, Path: "/readyz", Handler: pollHandler.Readiness, Summary: "Readiness probe", Access: routes.Internal},
+
+			{Method: http.MethodGet, Path: "/admin/retention/report", Handler: pollHandler.GetRetentionReport, Summary: "Report the polls past their retention period", Scopes: adminScope},
+			{Method: http.MethodGet, Path: "/admin/expirations", Handler: pollHandler.GetExpirations, Summary: "List the polls that will expire, such as stale drafts", Scopes: adminScope},
+			{Method: http.MethodGet, Path: "/admin/redis/shards", Handler: pollHandler.GetRedisShards, Summary: "List the Redis shards and their documents", Scopes: adminScope},
+			{Method: http.MethodPost, Path: "/admin/redis/rebalance", Handler: pollHandler.RebalanceRedisShards, Summary: "Move documents to the shard the hash ring assigns them", Scopes: adminScope, Limit: rebuildLimit},
+			{Method: http.MethodPost, Path: "/admin/redis/audit", Handler: pollHandler.AuditRedisKeys, Summary: "Audit the Redis keyspace and fix legacy, duplicated or broken keys", Scopes: adminScope, Limit: rebuildLimit},
+			{Method: http.MethodGet, Path: apierror.RecentErrorsPath, Handler: apierror.RecentErrors.ServeRecentErrors, Summary: "List the latest error responses of the service", Scopes: adminScope},
+			{Method: http.MethodPost, Path: snapshot.ImportPath, Handler: snapshot.Handler("polls", pollHandler.ImportSnapshot), Summary: "Restore the polls of a snapshot into an empty poll API", Scopes: adminScope, Limit: rebuildLimit},
+			{Method: http.MethodPost, Path: "/admin/seed", Handler: seed.Handler(pollHandler.Seed), Summary: "Add the polls and options of a JSON or YAML fixture, or of the -seed file", Scopes: adminScope, Limit: rebuildLimit},
+			{Method: http.MethodPost, Path: "/admin/polls/search/reindex", Handler: pollHandler.RebuildSearchIndex, Summary: "Rebuild the poll search index", Scopes: adminScope, Limit: rebuildLimit},
+			{Method: http.MethodGet, Path: "/admin/jobs", Handler: pollHandler.ListJobs, Summary: "List the background jobs and their last runs", Scopes: adminScope},
+			{Method: http.MethodPost, Path: "/admin/jobs/:name/run", Handler: pollHandler.RunJob, Summary: "Run a background job now", Scopes: adminScope},
+			{Method: http.MethodPost, Path: "/admin/jobs/:name/pause", Handler: pollHandler.PauseJob, Summary: "Pause a background job", Scopes: adminScope},
+			{Method: http.MethodPost, Path: "/admin/jobs/:name/resume", Handler: pollHandler.ResumeJob, Summary: "Resume a paused background job", Scopes: adminScope},
+			{Method: http.MethodPost, Path: "/webhooks", Handler: pollHandler.Webhooks().ServeAddHook, Summary: "Register a URL for voter.created, poll.closed or vote.cast events", Scopes: adminScope},
+			{Method: http.MethodGet, Path: "/webhooks", Handler: pollHandler.Webhooks().ServeListHooks, Summary: "List the registered webhooks", Scopes: adminScope},
+			{Method: http.MethodGet, Path: "/webhooks/:id", Handler: pollHandler.Webhooks().ServeGetHook, Summary: "Get a webhook", Scopes: adminScope},
+			{Method: http.MethodDelete, Path: "/webhooks/:id", Handler: pollHandler.Webhooks().ServeDeleteHook, Summary: "Delete a webhook and its failed deliveries", Scopes: adminScope},
+			{Method: http.MethodGet, Path: "/webhooks/:id/failures", Handler: pollHandler.Webhooks().ServeHookFailures, Summary: "List the deliveries to a webhook that failed after every retry", Scopes: adminScope},
+			{Method: http.MethodPost, Path: "/admin/apikeys", Handler: pollHandler.APIKeys().ServeCreateKey, Summary: "Create an API key with scopes such as read:polls or write:votes", Scopes: adminScope},
+			{Method: http.MethodGet, Path: "/admin/apikeys", Handler: pollHandler.APIKeys().ServeListKeys, Summary: "List the API keys, without the keys themselves", Scopes: adminScope},
+			{Method: http.MethodDelete, Path: "/admin/apikeys/:id", Handler: pollHandler.APIKeys().ServeRevokeKey, Summary: "Revoke an API key", Scopes: adminScope},
+		},
+	}
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"shared/events"
+)
+
+const (
+	DefaultVoteEventsStream = "events:votes"
+	ConsumerGroup           = "results-api"
+	ConsumerRetryInterval   = 5 * time.Second
+)
+
+// Return the stream vote events are consumed from, VOTE_EVENTS_STREAM or
+// events:votes.
+func voteEventsStream() string {
+	stream := os.Getenv("VOTE_EVENTS_STREAM")
+	if stream == "" {
+		return DefaultVoteEventsStream
+	}
+
+	return stream
+}
+
+// Return the name of this replica in the consumer group, the hostname so
+// that a restarted container picks up the messages it left pending.
+func consumerName() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		return fmt.Sprintf("results-api-%d", os.Getpid())
+	}
+
+	return hostname
+}
+
+// Start consuming vote events in the background until the context is
+// done. The consumer is restarted when redis is unavailable. A new
+// consumer group only receives new events; the events already in the
+// stream are replayed by a rebuild.
+func (ra *ResultsAPI) StartConsumer(ctx context.Context) {
+	go func() {
+		for ctx.Err() == nil {
+			created, err := ra.resultsCache.CreateGroup(ra.voteEventsStream, ConsumerGroup)
+			if err != nil {
+				log.Println("Error creating consumer group: ", err)
+			} else if created {
+				if _, err := ra.startRebuild(ctx); err != nil {
+					log.Println("Error starting results rebuild: ", err)
+				}
+			}
+
+			consumer := events.NewConsumer(ra.resultsCache.RedisClient(), ra.voteEventsStream, ConsumerGroup, consumerName())
+			if err := consumer.Run(ctx, ra.handleVoteEvent); err != nil {
+				log.Println("Error consuming vote events: ", err)
+			}
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(ConsumerRetryInterval):
+			}
+		}
+	}()
+}
+
+// Apply a vote event delivered to the consumer and fire the milestones the
+// poll reaches. Events replayed by a rebuild do not fire milestones, the
+// next vote of the poll does.
+func (ra *ResultsAPI) handleVoteEvent(ctx context.Context, message events.Message) error {
+	if err := ra.applyVoteEvent(message); err != nil {
+		return err
+	}
+
+	ra.recordEvent()
+
+	if pollID := eventPollID(message); pollID != 0 {
+		ra.checkMilestones(ctx, pollID)
+	}
+
+	return nil
+}
+
+// Apply a vote event to the materialized tallies. Unknown event types and
+// events that cannot be decoded are skipped.
+func (ra *ResultsAPI) applyVoteEvent(message events.Message) error {
+	switch message.Type {
+	case events.EventTypeVoteCast:
+		var event events.VoteCast
+		if err := message.Decode(&event); err != nil {
+			log.Println("Error decoding VoteCast event: ", err)
+			return nil
+		}
+
+		// The tallies count options, write-ins are tallied by the votes
+		// API alone.
+		if event.WriteInValue != "" {
+			return nil
+		}
+
+		options := event.OptionIDs
+		if len(options) == 0 {
+			options = []uint{event.VoteValue}
+		}
+
+		if _, err := ra.resultsCache.CountVote(event.PollID, event.VoteID, options, event.CastAt); err != nil {
+			return err
+		}
+
+	case events.EventTypeVoteDeleted:
+		var event events.VoteDeleted
+		if err := message.Decode(&event); err != nil {
+			log.Println("Error decoding VoteDeleted event: ", err)
+			return nil
+		}
+
+		if _, err := ra.resultsCache.UncountVote(event.PollID, event.VoteID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
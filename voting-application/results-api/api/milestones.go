@@ -0,0 +1,245 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"results-api/results"
+
+	"shared/apierror"
+	"shared/events"
+	"shared/validation"
+	"shared/webhook"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	MilestoneEvent = "poll.milestone"
+
+	// How long the delivery of a milestone may take with its retries.
+	MilestoneDeliveryTimeout = time.Minute
+)
+
+// milestoneRequest is the body of POST /results/:pollId/milestones.
+type milestoneRequest struct {
+	Kind     string `json:"kind" binding:"required,oneof=votes lead"`
+	Votes    uint   `json:"votes" binding:"required_if=Kind votes"`
+	OptionID uint   `json:"optionId" binding:"required_if=Kind lead"`
+	URL      string `json:"url" binding:"required,url,max=2048"`
+	Secret   string `json:"secret" binding:"max=256"`
+}
+
+// milestonePayload is the body of a milestone delivery.
+type milestonePayload struct {
+	Event      string                `json:"event"`
+	Milestone  results.Milestone     `json:"milestone"`
+	PollID     uint                  `json:"pollId"`
+	TotalVotes uint                  `json:"totalVotes"`
+	Results    []results.OptionTally `json:"results"`
+	ReachedAt  time.Time             `json:"reachedAt"`
+}
+
+// Return a milestone as the API shows it, without its secret.
+func publicMilestone(milestone results.Milestone) results.Milestone {
+	milestone.Secret = ""
+	return milestone
+}
+
+// Implementation of POST /results/:pollId/milestones.
+// Register a webhook fired once when the poll reaches a number of votes,
+// or when an option takes the lead.
+func (ra *ResultsAPI) AddMilestone(c *gin.Context) {
+	pollID, ok := parsePollID(c)
+	if !ok {
+		return
+	}
+
+	var request milestoneRequest
+	if err := validation.Bind(c, &request); err != nil {
+		log.Println("Error binding milestone: ", err)
+		return
+	}
+
+	if err := webhook.ValidateURL(request.URL); err != nil {
+		validation.AbortWithFields(c, []validation.FieldError{{
+			Field:   "url",
+			Rule:    "url",
+			Message: err.Error(),
+		}})
+		return
+	}
+
+	milestone := results.Milestone{
+		PollID: pollID,
+		Kind:   request.Kind,
+		URL:    request.URL,
+		Secret: request.Secret,
+	}
+	if request.Kind == results.MilestoneVotes {
+		milestone.Votes = request.Votes
+	} else {
+		milestone.OptionID = request.OptionID
+	}
+
+	milestone, err := ra.resultsCache.AddMilestone(milestone)
+	if err != nil {
+		log.Println("Error adding milestone: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not add milestone", err)
+		return
+	}
+
+	// A poll may already be past the milestone.
+	go ra.checkMilestones(context.Background(), pollID)
+
+	c.JSON(http.StatusCreated, publicMilestone(milestone))
+}
+
+// Implementation of GET /results/:pollId/milestones.
+// Returns the milestones of a poll, with the time they fired and the
+// outcome of their delivery.
+func (ra *ResultsAPI) ListMilestones(c *gin.Context) {
+	pollID, ok := parsePollID(c)
+	if !ok {
+		return
+	}
+
+	milestones, err := ra.resultsCache.ListMilestones(pollID)
+	if err != nil {
+		log.Println("Error getting milestones: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not get milestones", err)
+		return
+	}
+
+	for i := range milestones {
+		milestones[i] = publicMilestone(milestones[i])
+	}
+
+	c.JSON(http.StatusOK, milestones)
+}
+
+// Implementation of DELETE /results/:pollId/milestones/:milestoneId.
+// Delete a milestone of a poll.
+func (ra *ResultsAPI) DeleteMilestone(c *gin.Context) {
+	pollID, ok := parsePollID(c)
+	if !ok {
+		return
+	}
+
+	milestoneIDUint, err := strconv.ParseUint(c.Param("milestoneId"), 10, 32)
+	if err != nil {
+		log.Println("Error converting milestone ID to uint: ", err)
+		apierror.AbortInvalidID(c, "Milestone ID", err)
+		return
+	}
+
+	if err := ra.resultsCache.DeleteMilestone(pollID, uint(milestoneIDUint)); err != nil {
+		log.Println("Error deleting milestone: ", err)
+		if errors.Is(err, results.ErrMilestoneNotFound) {
+			apierror.AbortWithError(c, http.StatusNotFound, "Could not find milestone", err)
+			return
+		}
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not delete milestone", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Milestone deleted successfully.",
+	})
+}
+
+// Return the poll of a vote event, zero when it cannot be decoded.
+func eventPollID(message events.Message) uint {
+	var event struct {
+		PollID uint `json:"pollId"`
+	}
+	if err := json.Unmarshal(message.Data, &event); err != nil {
+		return 0
+	}
+
+	return event.PollID
+}
+
+// Fire the milestones of a poll its tally reaches. A milestone fires once:
+// the replica that claims it delivers it in the background and stores the
+// outcome.
+func (ra *ResultsAPI) checkMilestones(ctx context.Context, pollID uint) {
+	milestones, err := ra.resultsCache.ListMilestones(pollID)
+	if err != nil {
+		log.Println("Error getting milestones: ", err)
+		return
+	}
+
+	var pending []results.Milestone
+	for _, milestone := range milestones {
+		if milestone.FiredAt == nil {
+			pending = append(pending, milestone)
+		}
+	}
+
+	if len(pending) == 0 {
+		return
+	}
+
+	tally, totalVotes, err := ra.resultsCache.GetTally(pollID)
+	if err != nil {
+		log.Println("Error getting poll tally: ", err)
+		return
+	}
+
+	for _, milestone := range pending {
+		if !milestone.Reached(tally, totalVotes) {
+			continue
+		}
+
+		claimed, err := ra.resultsCache.ClaimMilestone(pollID, milestone.MilestoneID)
+		if err != nil {
+			log.Println("Error claiming milestone: ", err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		reachedAt := time.Now().UTC()
+		milestone.FiredAt = &reachedAt
+		if err := ra.resultsCache.SaveMilestone(milestone); err != nil {
+			log.Println("Error saving milestone: ", err)
+		}
+
+		go ra.deliverMilestone(ctx, milestone, milestonePayload{
+			Event:      MilestoneEvent,
+			Milestone:  publicMilestone(milestone),
+			PollID:     pollID,
+			TotalVotes: totalVotes,
+			Results:    tally,
+			ReachedAt:  reachedAt,
+		})
+	}
+}
+
+// Deliver a milestone that fired and store the outcome.
+func (ra *ResultsAPI) deliverMilestone(ctx context.Context, milestone results.Milestone, payload milestonePayload) {
+	ctx, cancel := context.WithTimeout(ctx, MilestoneDeliveryTimeout)
+	defer cancel()
+
+	result := ra.webhooks.Send(ctx, webhook.Delivery{
+		URL:     milestone.URL,
+		Secret:  milestone.Secret,
+		Event:   MilestoneEvent,
+		Payload: payload,
+	})
+	if !result.Delivered() {
+		log.Printf("Error delivering milestone %d of poll %d after %d attempts: %s", milestone.MilestoneID, milestone.PollID, result.Attempts, result.Error)
+	}
+
+	milestone.Delivery = &result
+	if err := ra.resultsCache.SaveMilestone(milestone); err != nil {
+		log.Println("Error saving milestone: ", err)
+	}
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"results-api/results"
+
+	"shared/apierror"
+	"shared/events"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	RebuildBatchSize = 500
+)
+
+// Implementation of POST /admin/results/rebuild.
+// Start rebuilding the tallies of every poll from the vote event stream.
+// The results report a rebuilding tallyStatus until it finishes.
+func (ra *ResultsAPI) RebuildResults(c *gin.Context) {
+	started, err := ra.startRebuild(context.Background())
+	if err != nil {
+		log.Println("Error starting results rebuild: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not start results rebuild", err)
+		return
+	}
+
+	if !started {
+		apierror.Abort(c, http.StatusConflict, apierror.CodeConflict, "A results rebuild is already running")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Results rebuild started successfully.",
+	})
+}
+
+// Mark the tallies as rebuilding and rebuild them in the background. It
+// returns false when a rebuild is already running.
+func (ra *ResultsAPI) startRebuild(ctx context.Context) (bool, error) {
+	started, err := ra.resultsCache.StartRebuild()
+	if err != nil || !started {
+		return false, err
+	}
+
+	go func() {
+		if err := ra.rebuild(ctx); err != nil {
+			log.Println("Error rebuilding results: ", err)
+		}
+	}()
+
+	return true, nil
+}
+
+// Reset the tallies and replay every event still in the stream. The live
+// consumer keeps applying new events meanwhile; votes are counted once, so
+// events seen by both are not counted twice.
+func (ra *ResultsAPI) rebuild(ctx context.Context) error {
+	defer func() {
+		if err := ra.resultsCache.FinishRebuild(); err != nil {
+			log.Println("Error finishing results rebuild: ", err)
+		}
+	}()
+
+	if err := ra.resultsCache.ResetTallies(); err != nil {
+		return err
+	}
+
+	client := ra.resultsCache.RedisClient()
+	start := "-"
+	replayed := 0
+	for ctx.Err() == nil {
+		messages, err := client.XRangeN(ctx, ra.voteEventsStream, start, "+", RebuildBatchSize).Result()
+		if err != nil {
+			return err
+		}
+
+		for _, message := range messages {
+			eventType, _ := message.Values["type"].(string)
+			data, _ := message.Values["data"].(string)
+
+			if err := ra.applyVoteEvent(events.Message{ID: message.ID, Type: eventType, Data: []byte(data)}); err != nil {
+				return err
+			}
+			replayed++
+		}
+
+		if len(messages) < RebuildBatchSize {
+			break
+		}
+
+		// Continue after the last entry of the batch.
+		start = "(" + messages[len(messages)-1].ID
+	}
+
+	log.Printf("Results rebuild replayed %d events", replayed)
+
+	return nil
+}
+
+// Return the tallyStatus reported with results: rebuilding while a
+// rebuild runs, stale while the consumer has not caught up with the
+// stream or its state cannot be read, and complete otherwise.
+func (ra *ResultsAPI) tallyStatus() string {
+	rebuilding, err := ra.resultsCache.IsRebuilding()
+	if err != nil {
+		return results.TallyStatusStale
+	}
+
+	if rebuilding {
+		return results.TallyStatusRebuilding
+	}
+
+	caughtUp, err := ra.resultsCache.IsCaughtUp(ra.voteEventsStream, ConsumerGroup)
+	if err != nil || !caughtUp {
+		return results.TallyStatusStale
+	}
+
+	return results.TallyStatusComplete
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"results-api/results"
+
+	"shared/apierror"
+	"shared/apikey"
+	"shared/failover"
+	"shared/healthkit"
+	"shared/webhook"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The API handler that handles incoming requests.
+type ResultsAPI struct {
+	resultsCache     *results.ResultsCache
+	voteEventsStream string
+	streaming        streamConfig
+	webhooks         *webhook.Sender
+	openStreams      int64
+	eventsLock       sync.Mutex
+	eventsProcessed  uint64
+	lastEventAt      *time.Time
+	apiKeys          *apikey.Store
+	metrics          *healthkit.Metrics
+}
+
+// Create a new instance of ResultsAPI with an initialized results cache.
+func NewResultsHandler() *ResultsAPI {
+	resultsCache, _ := results.NewResultsCache()
+
+	return &ResultsAPI{
+		resultsCache:     resultsCache,
+		voteEventsStream: voteEventsStream(),
+		streaming:        loadStreamConfig(),
+		webhooks:         webhook.NewSender(),
+		apiKeys:          apikey.NewStore(resultsCache.RedisClient()),
+		metrics:          healthkit.New(),
+	}
+}
+
+// The custom middleware to handle health metadata.
+func HealthMiddleware(ra *ResultsAPI) gin.HandlerFunc {
+	return ra.metrics.Middleware()
+}
+
+// Record that a vote event was applied to the tallies.
+func (ra *ResultsAPI) recordEvent() {
+	ra.eventsLock.Lock()
+	defer ra.eventsLock.Unlock()
+
+	now := time.Now()
+	ra.eventsProcessed++
+	ra.lastEventAt = &now
+}
+
+// Parse the :pollId parameter, aborting the request when it is invalid.
+func parsePollID(c *gin.Context) (uint, bool) {
+	pollIDUint, err := strconv.ParseUint(c.Param("pollId"), 10, 32)
+	if err != nil {
+		log.Println("Error converting poll ID to uint: ", err)
+		apierror.AbortInvalidID(c, "Poll ID", err)
+		return 0, false
+	}
+
+	return uint(pollIDUint), true
+}
+
+// Implementation of GET /results/:pollId.
+// Returns the materialized per-option tally of a poll. Options without
+// votes are left out.
+func (ra *ResultsAPI) GetPollResults(c *gin.Context) {
+	pollID, ok := parsePollID(c)
+	if !ok {
+		return
+	}
+
+	tally, totalVotes, err := ra.resultsCache.GetTally(pollID)
+	if err != nil {
+		log.Println("Error getting poll tally: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not get poll tally", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pollId":      pollID,
+		"totalVotes":  totalVotes,
+		"results":     tally,
+		"tallyStatus": ra.tallyStatus(),
+	})
+}
+
+// Implementation of GET /results/:pollId/timeseries.
+// Returns the number of votes cast per minute in a poll, oldest first.
+func (ra *ResultsAPI) GetPollTimeseries(c *gin.Context) {
+	pollID, ok := parsePollID(c)
+	if !ok {
+		return
+	}
+
+	series, err := ra.resultsCache.GetTimeseries(pollID)
+	if err != nil {
+		log.Println("Error getting poll timeseries: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not get poll timeseries", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pollId":      pollID,
+		"interval":    "1m",
+		"series":      series,
+		"tallyStatus": ra.tallyStatus(),
+	})
+}
+
+// Implementation of GET /results/health.
+// Get the health status of the results API.
+func (ra *ResultsAPI) HealthCheck(c *gin.Context) {
+	ra.eventsLock.Lock()
+	eventsProcessed := ra.eventsProcessed
+	lastEventAt := ra.lastEventAt
+	ra.eventsLock.Unlock()
+
+	report := ra.metrics.Report()
+	report["voteEventsStream"] = ra.voteEventsStream
+	report["eventsProcessed"] = eventsProcessed
+	report["lastEventAt"] = lastEventAt
+	report["tallyStatus"] = ra.tallyStatus()
+	report["openStreams"] = atomic.LoadInt64(&ra.openStreams)
+	report["redisFailover"] = failover.CurrentStats()
+
+	c.JSON(http.StatusOK, report)
+}
+
+// Implementation of GET /healthz.
+// Liveness probe, reports that the process is up and serving requests.
+func (ra *ResultsAPI) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+	})
+}
+
+// Implementation of GET /readyz.
+// Return the check of the redis connection the tallies are kept in, for
+// the boot report.
+func (ra *ResultsAPI) DependencyChecks() map[string]func() error {
+	return map[string]func() error{
+		"redis": ra.resultsCache.Ping,
+	}
+}
+
+// Readiness probe, checks the redis connection the tallies are kept in
+// and returns 503 with detail when it is down.
+func (ra *ResultsAPI) Readiness(c *gin.Context) {
+	dependencies := map[string]interface{}{}
+
+	if err := ra.resultsCache.Ping(); err != nil {
+		dependencies["redis"] = gin.H{"status": "down", "error": err.Error()}
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status":       "not ready",
+			"dependencies": dependencies,
+		})
+		return
+	}
+
+	dependencies["redis"] = gin.H{"status": "up"}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":       "ready",
+		"dependencies": dependencies,
+	})
+}
@@ -0,0 +1,404 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"shared/apierror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	TransportWebSocket = "websocket"
+	TransportSSE       = "sse"
+	TransportLongPoll  = "long-poll"
+
+	DefaultStreamInterval = 5 * time.Second
+	MinStreamInterval     = time.Second
+	MinLongPollInterval   = 5 * time.Second
+	MaxStreamInterval     = time.Minute
+	LongPollTimeout       = 30 * time.Second
+
+	// Every StreamClientsPerStep open streams raise the minimum interval by
+	// one more minimum, so a crowd of dashboards does not overload redis.
+	StreamClientsPerStep = 100
+)
+
+// The transports the server offers, best first.
+var defaultStreamTransports = []string{TransportWebSocket, TransportSSE, TransportLongPoll}
+
+// streamConfig is the streaming configuration of the results API.
+type streamConfig struct {
+	transports []string
+}
+
+// Load the streaming configuration. STREAM_TRANSPORTS restricts the offered
+// transports, for example to "sse,long-poll" behind a proxy that does not
+// pass WebSocket upgrades; the order of the list is the preference order.
+func loadStreamConfig() streamConfig {
+	config := streamConfig{transports: defaultStreamTransports}
+
+	value := os.Getenv("STREAM_TRANSPORTS")
+	if value == "" {
+		return config
+	}
+
+	var transports []string
+	for _, transport := range strings.Split(value, ",") {
+		transport = strings.TrimSpace(transport)
+		if isKnownTransport(transport) {
+			transports = append(transports, transport)
+		} else {
+			log.Printf("Ignoring unknown stream transport %q", transport)
+		}
+	}
+
+	if len(transports) > 0 {
+		config.transports = transports
+	}
+
+	return config
+}
+
+// Report whether the transport is one the results API implements.
+func isKnownTransport(transport string) bool {
+	for _, known := range defaultStreamTransports {
+		if transport == known {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Report whether the transport is offered by the server.
+func (sc streamConfig) offers(transport string) bool {
+	for _, offered := range sc.transports {
+		if transport == offered {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Pick the best offered transport among those the client supports.
+func (sc streamConfig) choose(clientTransports []string) (string, bool) {
+	supported := make(map[string]bool, len(clientTransports))
+	for _, transport := range clientTransports {
+		supported[strings.ToLower(strings.TrimSpace(transport))] = true
+	}
+
+	for _, transport := range sc.transports {
+		if supported[transport] {
+			return transport, true
+		}
+	}
+
+	return "", false
+}
+
+// Tune the update interval a client asked for. It is kept between the
+// minimum of the transport, raised with the number of open streams, and
+// MaxStreamInterval.
+func (ra *ResultsAPI) tuneInterval(transport string, requested time.Duration) time.Duration {
+	minimum := MinStreamInterval
+	if transport == TransportLongPoll {
+		minimum = MinLongPollInterval
+	}
+
+	openStreams := atomic.LoadInt64(&ra.openStreams)
+	minimum *= time.Duration(1 + openStreams/StreamClientsPerStep)
+	if minimum > MaxStreamInterval {
+		minimum = MaxStreamInterval
+	}
+
+	if requested <= 0 {
+		requested = DefaultStreamInterval
+	}
+
+	if requested < minimum {
+		return minimum
+	}
+
+	if requested > MaxStreamInterval {
+		return MaxStreamInterval
+	}
+
+	return requested
+}
+
+// negotiateRequest is the body of a stream negotiation.
+type negotiateRequest struct {
+	Transports []string `json:"transports"`
+	Interval   string   `json:"interval"`
+}
+
+// Implementation of POST /results/:pollId/stream/negotiate.
+// Streaming clients declare the transports they support and the update
+// interval they would like; the response names the transport to use, the
+// tuned interval and the URL to connect to.
+func (ra *ResultsAPI) NegotiateStream(c *gin.Context) {
+	pollID, ok := parsePollID(c)
+	if !ok {
+		return
+	}
+
+	var request negotiateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Println("Error binding JSON: ", err)
+		apierror.AbortInvalidBody(c, err)
+		return
+	}
+
+	var requested time.Duration
+	if request.Interval != "" {
+		interval, err := time.ParseDuration(request.Interval)
+		if err != nil {
+			apierror.AbortWithDetails(c, http.StatusBadRequest, apierror.CodeValidationFailed, "interval must be a duration such as 5s", err)
+			return
+		}
+		requested = interval
+	}
+
+	transport, ok := ra.streaming.choose(request.Transports)
+	if !ok {
+		apierror.AbortWithDetails(c, http.StatusNotAcceptable, apierror.CodeNotAcceptable, "None of the requested transports is supported",
+			gin.H{"supported": ra.streaming.transports})
+		return
+	}
+
+	interval := ra.tuneInterval(transport, requested)
+
+	c.JSON(http.StatusOK, gin.H{
+		"pollId":     pollID,
+		"transport":  transport,
+		"interval":   interval.String(),
+		"intervalMs": interval.Milliseconds(),
+		"url":        fmt.Sprintf("/results/%d/stream/%s?interval=%s", pollID, transport, interval),
+	})
+}
+
+// Return the tuned interval of a stream request, from its interval query
+// parameter.
+func (ra *ResultsAPI) streamInterval(c *gin.Context, transport string) time.Duration {
+	requested, _ := time.ParseDuration(c.Query("interval"))
+
+	return ra.tuneInterval(transport, requested)
+}
+
+// Build a tally update of a poll, with the tally version it was read at.
+func (ra *ResultsAPI) tallyUpdate(pollID uint) (gin.H, int64, error) {
+	version, err := ra.resultsCache.GetVersion(pollID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	tally, totalVotes, err := ra.resultsCache.GetTally(pollID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return gin.H{
+		"pollId":      pollID,
+		"version":     version,
+		"totalVotes":  totalVotes,
+		"results":     tally,
+		"tallyStatus": ra.tallyStatus(),
+	}, version, nil
+}
+
+// Send a tally update of the poll every time its version changes, checking
+// at the interval, until the context is done or send fails. The first
+// update is sent right away.
+func (ra *ResultsAPI) watchTally(ctx context.Context, pollID uint, interval time.Duration, send func(update gin.H) error) error {
+	atomic.AddInt64(&ra.openStreams, 1)
+	defer atomic.AddInt64(&ra.openStreams, -1)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	lastVersion := int64(-1)
+	for {
+		version, err := ra.resultsCache.GetVersion(pollID)
+		if err != nil {
+			return err
+		}
+
+		if version != lastVersion {
+			update, updateVersion, err := ra.tallyUpdate(pollID)
+			if err != nil {
+				return err
+			}
+
+			if err := send(update); err != nil {
+				return err
+			}
+			lastVersion = updateVersion
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+var streamUpgrader = websocket.Upgrader{
+	// Origins are not restricted, matching the CORS policy of the API.
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+}
+
+// Implementation of GET /results/:pollId/stream/websocket.
+// Stream tally updates of a poll over a WebSocket.
+func (ra *ResultsAPI) StreamWebSocket(c *gin.Context) {
+	if !ra.streaming.offers(TransportWebSocket) {
+		apierror.Abort(c, http.StatusNotFound, apierror.CodeNotFound, "This stream transport is not offered")
+		return
+	}
+
+	pollID, ok := parsePollID(c)
+	if !ok {
+		return
+	}
+
+	interval := ra.streamInterval(c, TransportWebSocket)
+
+	conn, err := streamUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Println("Error upgrading to WebSocket: ", err)
+		return
+	}
+	defer conn.Close()
+
+	// Stop streaming once the client goes away; messages from the client
+	// are not used.
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	err = ra.watchTally(ctx, pollID, interval, func(update gin.H) error {
+		return conn.WriteJSON(update)
+	})
+	if err != nil {
+		log.Println("Error streaming tally over WebSocket: ", err)
+	}
+}
+
+// Implementation of GET /results/:pollId/stream/sse.
+// Stream tally updates of a poll as server-sent events.
+func (ra *ResultsAPI) StreamSSE(c *gin.Context) {
+	if !ra.streaming.offers(TransportSSE) {
+		apierror.Abort(c, http.StatusNotFound, apierror.CodeNotFound, "This stream transport is not offered")
+		return
+	}
+
+	pollID, ok := parsePollID(c)
+	if !ok {
+		return
+	}
+
+	interval := ra.streamInterval(c, TransportSSE)
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+
+	err := ra.watchTally(c.Request.Context(), pollID, interval, func(update gin.H) error {
+		c.SSEvent("tally", update)
+		c.Writer.Flush()
+		return c.Request.Context().Err()
+	})
+	if err != nil {
+		log.Println("Error streaming tally over SSE: ", err)
+	}
+}
+
+// Implementation of GET /results/:pollId/stream/long-poll?version=.
+// Wait until the tally version of a poll differs from the version the
+// client has, and return the new tally. Returns 204 when nothing changed
+// within LongPollTimeout.
+func (ra *ResultsAPI) StreamLongPoll(c *gin.Context) {
+	if !ra.streaming.offers(TransportLongPoll) {
+		apierror.Abort(c, http.StatusNotFound, apierror.CodeNotFound, "This stream transport is not offered")
+		return
+	}
+
+	pollID, ok := parsePollID(c)
+	if !ok {
+		return
+	}
+
+	since := int64(-1)
+	if value := c.Query("version"); value != "" {
+		version, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			log.Println("Error converting version to int: ", err)
+			apierror.AbortWithDetails(c, http.StatusBadRequest, apierror.CodeBadRequest, "version must be an integer", err)
+			return
+		}
+		since = version
+	}
+
+	// Long-polls check more often than they return, so a change is seen
+	// within a second or two.
+	interval := ra.streamInterval(c, TransportLongPoll) / 5
+	if interval < MinStreamInterval {
+		interval = MinStreamInterval
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), LongPollTimeout)
+	defer cancel()
+
+	atomic.AddInt64(&ra.openStreams, 1)
+	defer atomic.AddInt64(&ra.openStreams, -1)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		version, err := ra.resultsCache.GetVersion(pollID)
+		if err != nil {
+			log.Println("Error getting tally version: ", err)
+			apierror.AbortWithError(c, http.StatusInternalServerError, "Could not get tally version", err)
+			return
+		}
+
+		if version != since {
+			update, _, err := ra.tallyUpdate(pollID)
+			if err != nil {
+				log.Println("Error getting poll tally: ", err)
+				apierror.AbortWithError(c, http.StatusInternalServerError, "Could not get poll tally", err)
+				return
+			}
+
+			c.JSON(http.StatusOK, update)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			c.Status(http.StatusNoContent)
+			return
+		case <-ticker.C:
+		}
+	}
+}
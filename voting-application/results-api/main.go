@@ -0,0 +1,24 @@
+package main
+
+import (
+	"results-api/api"
+
+	"shared/bootstrap"
+)
+
+func main() {
+	server := bootstrap.New("results-api", bootstrap.Config{
+		Port: 1083,
+	})
+
+	// Create a new instance of the ResultsAPI handler.
+	resultsHandler := api.NewResultsHandler()
+
+	server.Run(
+		bootstrap.WithMiddleware(api.HealthMiddleware(resultsHandler), resultsHandler.APIKeys().Middleware()),
+		bootstrap.WithRoutes(routeTable(resultsHandler).Register),
+		bootstrap.WithWorkers(resultsHandler.StartConsumer),
+		bootstrap.WithStorageBackend("redis"),
+		bootstrap.WithDependencyChecks(resultsHandler.DependencyChecks()),
+	)
+}
@@ -0,0 +1,184 @@
+package results
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"shared/webhook"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// A milestone fires when the poll reaches a number of votes.
+	MilestoneVotes = "votes"
+	// A milestone fires when an option has more votes than any other.
+	MilestoneLead = "lead"
+
+	MilestoneIDKey = RedisKeyPrefix + "milestones:next"
+)
+
+// ErrMilestoneNotFound is returned when a milestone does not exist.
+var ErrMilestoneNotFound = errors.New("milestone does not exist")
+
+// Milestone is a webhook fired once when the tally of a poll reaches a
+// threshold.
+type Milestone struct {
+	MilestoneID uint   `json:"milestoneId"`
+	PollID      uint   `json:"pollId"`
+	Kind        string `json:"kind"`
+	// The number of votes of a votes milestone.
+	Votes uint `json:"votes,omitempty"`
+	// The option of a lead milestone.
+	OptionID uint   `json:"optionId,omitempty"`
+	URL      string `json:"url"`
+	// Signs the deliveries, it is never returned by the API.
+	Secret    string          `json:"secret,omitempty"`
+	CreatedAt time.Time       `json:"createdAt"`
+	FiredAt   *time.Time      `json:"firedAt,omitempty"`
+	Delivery  *webhook.Result `json:"delivery,omitempty"`
+}
+
+// Report whether a tally reaches the milestone.
+func (m Milestone) Reached(tally []OptionTally, totalVotes uint) bool {
+	switch m.Kind {
+	case MilestoneVotes:
+		return totalVotes >= m.Votes
+	case MilestoneLead:
+		var optionVotes, otherVotes uint
+		for _, option := range tally {
+			if option.OptionID == m.OptionID {
+				optionVotes = option.Votes
+			} else if option.Votes > otherVotes {
+				otherVotes = option.Votes
+			}
+		}
+		return optionVotes > otherVotes
+	}
+
+	return false
+}
+
+// saveMilestoneScript stores a milestone only if it was not deleted in the
+// meantime.
+var saveMilestoneScript = redis.NewScript(`
+if redis.call("HEXISTS", KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+redis.call("HSET", KEYS[1], ARGV[1], ARGV[2])
+return 1
+`)
+
+// Get the keys of the milestones of a poll and of the ones that fired.
+func milestoneKeys(pollID uint) (string, string) {
+	return fmt.Sprintf("%smilestones:%d", RedisKeyPrefix, pollID),
+		fmt.Sprintf("%smilestones:fired:%d", RedisKeyPrefix, pollID)
+}
+
+// Add a milestone to its poll, with a new ID.
+func (rc *ResultsCache) AddMilestone(milestone Milestone) (Milestone, error) {
+	if rc == nil {
+		return Milestone{}, errors.New("redis is not connected")
+	}
+
+	id, err := rc.cacheClient.Incr(rc.context, MilestoneIDKey).Result()
+	if err != nil {
+		return Milestone{}, err
+	}
+
+	milestone.MilestoneID = uint(id)
+	milestone.CreatedAt = time.Now().UTC()
+	milestone.FiredAt = nil
+	milestone.Delivery = nil
+
+	data, err := json.Marshal(milestone)
+	if err != nil {
+		return Milestone{}, err
+	}
+
+	key, _ := milestoneKeys(milestone.PollID)
+	if err := rc.cacheClient.HSet(rc.context, key, strconv.FormatUint(uint64(id), 10), data).Err(); err != nil {
+		return Milestone{}, err
+	}
+
+	return milestone, nil
+}
+
+// Return the milestones of a poll ordered by ID.
+func (rc *ResultsCache) ListMilestones(pollID uint) ([]Milestone, error) {
+	if rc == nil {
+		return nil, errors.New("redis is not connected")
+	}
+
+	key, _ := milestoneKeys(pollID)
+	entries, err := rc.cacheClient.HGetAll(rc.context, key).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	milestones := make([]Milestone, 0, len(entries))
+	for _, data := range entries {
+		var milestone Milestone
+		if err := json.Unmarshal([]byte(data), &milestone); err != nil {
+			continue
+		}
+		milestones = append(milestones, milestone)
+	}
+
+	sort.Slice(milestones, func(i, j int) bool {
+		return milestones[i].MilestoneID < milestones[j].MilestoneID
+	})
+
+	return milestones, nil
+}
+
+// Delete a milestone of a poll.
+func (rc *ResultsCache) DeleteMilestone(pollID, milestoneID uint) error {
+	if rc == nil {
+		return errors.New("redis is not connected")
+	}
+
+	key, firedKey := milestoneKeys(pollID)
+	field := strconv.FormatUint(uint64(milestoneID), 10)
+
+	deleted, err := rc.cacheClient.HDel(rc.context, key, field).Result()
+	if err != nil {
+		return err
+	}
+
+	if deleted == 0 {
+		return ErrMilestoneNotFound
+	}
+
+	return rc.cacheClient.HDel(rc.context, firedKey, field).Err()
+}
+
+// Mark a milestone as fired. It returns false when it already fired, so
+// only one replica delivers it.
+func (rc *ResultsCache) ClaimMilestone(pollID, milestoneID uint) (bool, error) {
+	if rc == nil {
+		return false, errors.New("redis is not connected")
+	}
+
+	_, firedKey := milestoneKeys(pollID)
+	return rc.cacheClient.HSetNX(rc.context, firedKey, strconv.FormatUint(uint64(milestoneID), 10), time.Now().UTC().Format(time.RFC3339)).Result()
+}
+
+// Store the firing time and delivery of a milestone, unless it was deleted.
+func (rc *ResultsCache) SaveMilestone(milestone Milestone) error {
+	if rc == nil {
+		return errors.New("redis is not connected")
+	}
+
+	data, err := json.Marshal(milestone)
+	if err != nil {
+		return err
+	}
+
+	key, _ := milestoneKeys(milestone.PollID)
+	return saveMilestoneScript.Run(rc.context, rc.cacheClient, []string{key}, milestone.MilestoneID, data).Err()
+}
@@ -0,0 +1,256 @@
+package results
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"shared/failover"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	RedisDefaultLocation = "redis:6379"
+	RedisKeyPrefix       = "results:"
+	MinuteLayout         = "2006-01-02T15:04Z"
+)
+
+// OptionTally is the number of votes an option received.
+type OptionTally struct {
+	OptionID uint `json:"optionId"`
+	Votes    uint `json:"votes"`
+}
+
+// MinuteTally is the number of votes cast during a minute.
+type MinuteTally struct {
+	Minute string `json:"minute"`
+	Votes  uint   `json:"votes"`
+}
+
+// Count a vote once: the vote is recorded with its options, separated by
+// commas, and minute, and the counters are only incremented when the vote
+// was not seen before, so a redelivered event is not counted twice.
+var countVoteScript = redis.NewScript(`
+if redis.call("HSETNX", KEYS[1], ARGV[1], ARGV[2] .. " " .. ARGV[3]) == 0 then
+	return 0
+end
+for option in string.gmatch(ARGV[2], "[^,]+") do
+	redis.call("HINCRBY", KEYS[2], option, 1)
+end
+redis.call("HINCRBY", KEYS[3], ARGV[3], 1)
+redis.call("INCR", KEYS[4])
+return 1
+`)
+
+// Uncount a vote recorded by countVoteScript, using the options and minute
+// it was counted under.
+var uncountVoteScript = redis.NewScript(`
+local entry = redis.call("HGET", KEYS[1], ARGV[1])
+if not entry then
+	return 0
+end
+local options, minute = string.match(entry, "^(%S+) (%S+)$")
+redis.call("HDEL", KEYS[1], ARGV[1])
+for option in string.gmatch(options, "[^,]+") do
+	redis.call("HINCRBY", KEYS[2], option, -1)
+end
+redis.call("HINCRBY", KEYS[3], minute, -1)
+redis.call("INCR", KEYS[4])
+return 1
+`)
+
+// The reference to a cache object.
+type ResultsCache struct {
+	cacheClient redis.UniversalClient
+	context     context.Context
+}
+
+// The constructor function that returns a pointer to a new ResultsCache.
+// It uses the default Redis URL with the companion constructor newResultsCacheInstance.
+func NewResultsCache() (*ResultsCache, error) {
+	redisUrl := os.Getenv("REDIS_URL")
+
+	if redisUrl == "" {
+		redisUrl = RedisDefaultLocation
+	}
+
+	return newResultsCacheInstance(redisUrl)
+}
+
+// The constructor function that returns a pointer to a new ResultsCache.
+func newResultsCacheInstance(url string) (*ResultsCache, error) {
+	client, err := failover.Connect(url)
+	if err != nil {
+		log.Println("Error configuring redis: ", err)
+		return nil, err
+	}
+
+	ctx := context.Background()
+
+	err = client.Ping(ctx).Err()
+	if err != nil {
+		log.Println("Error connecting to redis" + err.Error())
+		return nil, err
+	}
+
+	return &ResultsCache{
+		cacheClient: client,
+		context:     ctx,
+	}, nil
+}
+
+// Check that the redis connection of the ResultsCache is alive.
+func (rc *ResultsCache) Ping() error {
+	if rc == nil {
+		return errors.New("redis is not connected")
+	}
+
+	return rc.cacheClient.Ping(rc.context).Err()
+}
+
+// Return the redis client of the ResultsCache, or nil when it is not connected.
+func (rc *ResultsCache) RedisClient() redis.UniversalClient {
+	if rc == nil {
+		return nil
+	}
+
+	return rc.cacheClient
+}
+
+// Get the keys of the counted votes, the option tally, the per-minute
+// tally and the tally version of a poll.
+func pollKeys(pollID uint) []string {
+	return []string{
+		fmt.Sprintf("%svotes:%d", RedisKeyPrefix, pollID),
+		fmt.Sprintf("%stally:%d", RedisKeyPrefix, pollID),
+		fmt.Sprintf("%sminutes:%d", RedisKeyPrefix, pollID),
+		fmt.Sprintf("%sversion:%d", RedisKeyPrefix, pollID),
+	}
+}
+
+// Return the version of the tallies of a poll, which changes every time a
+// vote is counted or removed. Clients compare versions to detect changes.
+func (rc *ResultsCache) GetVersion(pollID uint) (int64, error) {
+	if rc == nil {
+		return 0, errors.New("redis is not connected")
+	}
+
+	version, err := rc.cacheClient.Get(rc.context, pollKeys(pollID)[3]).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+
+	return version, err
+}
+
+// Count a cast vote in the tallies of its poll, once for each option it
+// selects. It reports whether the vote was counted, false means it had
+// already been counted.
+func (rc *ResultsCache) CountVote(pollID, voteID uint, optionIDs []uint, castAt time.Time) (bool, error) {
+	if rc == nil {
+		return false, errors.New("redis is not connected")
+	}
+
+	minute := castAt.UTC().Truncate(time.Minute).Format(MinuteLayout)
+
+	options := make([]string, len(optionIDs))
+	for i, optionID := range optionIDs {
+		options[i] = strconv.FormatUint(uint64(optionID), 10)
+	}
+
+	counted, err := countVoteScript.Run(rc.context, rc.cacheClient, pollKeys(pollID), voteID, strings.Join(options, ","), minute).Int()
+	if err != nil {
+		return false, err
+	}
+
+	return counted == 1, nil
+}
+
+// Remove a deleted vote from the tallies of its poll. It reports whether
+// the vote was removed, false means it was never counted.
+func (rc *ResultsCache) UncountVote(pollID, voteID uint) (bool, error) {
+	if rc == nil {
+		return false, errors.New("redis is not connected")
+	}
+
+	removed, err := uncountVoteScript.Run(rc.context, rc.cacheClient, pollKeys(pollID), voteID).Int()
+	if err != nil {
+		return false, err
+	}
+
+	return removed == 1, nil
+}
+
+// Return the per-option tally of a poll ordered by option ID, and the
+// total number of votes. A vote of a multi-choice poll is counted in the
+// total once for each option it selects.
+func (rc *ResultsCache) GetTally(pollID uint) ([]OptionTally, uint, error) {
+	if rc == nil {
+		return nil, 0, errors.New("redis is not connected")
+	}
+
+	counts, err := rc.cacheClient.HGetAll(rc.context, pollKeys(pollID)[1]).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	tally := make([]OptionTally, 0, len(counts))
+	var totalVotes uint
+	for field, value := range counts {
+		optionID, err := strconv.ParseUint(field, 10, 32)
+		if err != nil {
+			continue
+		}
+
+		votes, err := strconv.ParseUint(value, 10, 32)
+		if err != nil || votes == 0 {
+			continue
+		}
+
+		tally = append(tally, OptionTally{OptionID: uint(optionID), Votes: uint(votes)})
+		totalVotes += uint(votes)
+	}
+
+	sort.Slice(tally, func(i, j int) bool {
+		return tally[i].OptionID < tally[j].OptionID
+	})
+
+	return tally, totalVotes, nil
+}
+
+// Return the number of votes cast per minute in a poll, oldest first.
+// Minutes without votes are left out.
+func (rc *ResultsCache) GetTimeseries(pollID uint) ([]MinuteTally, error) {
+	if rc == nil {
+		return nil, errors.New("redis is not connected")
+	}
+
+	counts, err := rc.cacheClient.HGetAll(rc.context, pollKeys(pollID)[2]).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	series := make([]MinuteTally, 0, len(counts))
+	for minute, value := range counts {
+		votes, err := strconv.ParseUint(value, 10, 32)
+		if err != nil || votes == 0 {
+			continue
+		}
+
+		series = append(series, MinuteTally{Minute: minute, Votes: uint(votes)})
+	}
+
+	// The minute layout sorts in time order.
+	sort.Slice(series, func(i, j int) bool {
+		return series[i].Minute < series[j].Minute
+	})
+
+	return series, nil
+}
@@ -0,0 +1,183 @@
+package results
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	TallyStatusComplete   = "complete"
+	TallyStatusRebuilding = "rebuilding"
+	TallyStatusStale      = "stale"
+
+	RebuildKey   = RedisKeyPrefix + "rebuild"
+	RebuildLease = time.Hour
+)
+
+// Mark the tallies as being rebuilt. It returns false when another rebuild
+// is already running. The mark expires after RebuildLease, so a rebuild
+// that died does not leave the tallies marked forever.
+func (rc *ResultsCache) StartRebuild() (bool, error) {
+	if rc == nil {
+		return false, errors.New("redis is not connected")
+	}
+
+	return rc.cacheClient.SetNX(rc.context, RebuildKey, time.Now().Format(time.RFC3339), RebuildLease).Result()
+}
+
+// Clear the rebuild mark.
+func (rc *ResultsCache) FinishRebuild() error {
+	if rc == nil {
+		return errors.New("redis is not connected")
+	}
+
+	return rc.cacheClient.Del(rc.context, RebuildKey).Err()
+}
+
+// Report whether the tallies are being rebuilt.
+func (rc *ResultsCache) IsRebuilding() (bool, error) {
+	if rc == nil {
+		return false, errors.New("redis is not connected")
+	}
+
+	count, err := rc.cacheClient.Exists(rc.context, RebuildKey).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// Delete the tallies of every poll. The tally versions are kept and
+// increased, so streaming clients see the reset and versions keep
+// increasing across rebuilds.
+func (rc *ResultsCache) ResetTallies() error {
+	if rc == nil {
+		return errors.New("redis is not connected")
+	}
+
+	tallyKeys, err := rc.cacheClient.Keys(rc.context, RedisKeyPrefix+"tally:*").Result()
+	if err != nil {
+		return err
+	}
+
+	for _, key := range tallyKeys {
+		versionKey := RedisKeyPrefix + "version:" + strings.TrimPrefix(key, RedisKeyPrefix+"tally:")
+		if err := rc.cacheClient.Incr(rc.context, versionKey).Err(); err != nil {
+			return err
+		}
+	}
+
+	for _, kind := range []string{"votes", "tally", "minutes"} {
+		pattern := fmt.Sprintf("%s%s:*", RedisKeyPrefix, kind)
+		keys, err := rc.cacheClient.Keys(rc.context, pattern).Result()
+		if err != nil {
+			return err
+		}
+
+		if len(keys) == 0 {
+			continue
+		}
+
+		if err := rc.cacheClient.Del(rc.context, keys...).Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Create the consumer group of the stream so it only receives new
+// messages. It returns false when the group already exists.
+func (rc *ResultsCache) CreateGroup(stream, group string) (bool, error) {
+	if rc == nil {
+		return false, errors.New("redis is not connected")
+	}
+
+	err := rc.cacheClient.XGroupCreateMkStream(rc.context, stream, group, "$").Err()
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Report whether the consumer group has caught up with the stream: every
+// message was delivered to it and acknowledged.
+func (rc *ResultsCache) IsCaughtUp(stream, group string) (bool, error) {
+	if rc == nil {
+		return false, errors.New("redis is not connected")
+	}
+
+	// XINFO GROUPS is read generically, the reply gained fields in newer
+	// redis versions that the typed command of the client rejects.
+	reply, err := rc.cacheClient.Do(rc.context, "XINFO", "GROUPS", stream).Result()
+	if err != nil {
+		return false, err
+	}
+
+	groups, _ := reply.([]interface{})
+	for _, entry := range groups {
+		fields, _ := entry.([]interface{})
+
+		info := make(map[string]interface{}, len(fields)/2)
+		for i := 0; i+1 < len(fields); i += 2 {
+			if key, ok := fields[i].(string); ok {
+				info[key] = fields[i+1]
+			}
+		}
+
+		if info["name"] != group {
+			continue
+		}
+
+		if pending, _ := info["pending"].(int64); pending > 0 {
+			return false, nil
+		}
+
+		lastDelivered, _ := info["last-delivered-id"].(string)
+
+		last, err := rc.cacheClient.XRevRangeN(rc.context, stream, "+", "-", 1).Result()
+		if err != nil {
+			return false, err
+		}
+
+		if len(last) == 0 {
+			return true, nil
+		}
+
+		return !streamIDLess(lastDelivered, last[0].ID), nil
+	}
+
+	return false, redis.Nil
+}
+
+// Report whether stream entry ID a comes before b.
+func streamIDLess(a, b string) bool {
+	aMillis, aSeq := parseStreamID(a)
+	bMillis, bSeq := parseStreamID(b)
+
+	if aMillis != bMillis {
+		return aMillis < bMillis
+	}
+
+	return aSeq < bSeq
+}
+
+// Split a stream entry ID into its milliseconds and sequence parts.
+func parseStreamID(id string) (uint64, uint64) {
+	millis, seq, _ := strings.Cut(id, "-")
+
+	millisUint, _ := strconv.ParseUint(millis, 10, 64)
+	seqUint, _ := strconv.ParseUint(seq, 10, 64)
+
+	return millisUint, seqUint
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"net/http"
+
+	"results-api/api"
+
+	"shared/apierror"
+	"shared/routes"
+)
+
+var (
+	rebuildLimit   = routes.PerMinute(2)
+	milestoneLimit = routes.PerMinute(30)
+	adminScope     = []string{routes.ScopeAdmin}
+)
+
+// Define the API endpoints and map them to the corresponding handler.
+// Materialized results are exact and include unreleased polls, and none of
+// the release rules of the votes API apply to them, so the results API is
+// internal: every results route requires the admin token and is left out
+// of the public documents. Public results are read from GET
+// /votes/results/:pollId on the votes API.
+func routeTable(resultsHandler *api.ResultsAPI) *routes.Table {
+	return &routes.Table{
+		Service: "results-api",
+		Welcome: "Welcome to results API.",
+		Scopes: []routes.Scope{
+			{
+				Name:        routes.ScopeAdmin,
+				Description: "Requires the X-Admin-Token header matching ADMIN_TOKEN",
+				Middleware:  api.AdminMiddleware(),
+				Header:      api.AdminTokenHeader,
+			},
+		},
+		Routes: []routes.Route{
+			{Method: http.MethodGet, Path: "/results/health", Handler: resultsHandler.HealthCheck, Summary: "Request metrics of the API", Access: routes.Internal},
+			{Method: http.MethodGet, Path: "/healthz", Handler: resultsHandler.Liveness, Summary: "Liveness probe", Access: routes.Internal},
+			{Method: http.MethodGet, Path: "/readyz", Handler: resultsHandler.Readiness, Summary: "Readiness probe", Access: routes.Internal},
+
+			{Method: http.MethodGet, Path: "/results/:pollId", Handler: resultsHandler.GetPollResults, Summary: "Get the materialized results of a poll", Scopes: adminScope, Access: routes.Internal},
+			{Method: http.MethodGet, Path: "/results/:pollId/timeseries", Handler: resultsHandler.GetPollTimeseries, Summary: "Get the votes of a poll over time", Scopes: adminScope, Access: routes.Internal},
+			{Method: http.MethodPost, Path: "/results/:pollId/stream/negotiate", Handler: resultsHandler.NegotiateStream, Summary: "Pick the transport of a result stream", Scopes: adminScope, Access: routes.Internal},
+			{Method: http.MethodGet, Path: "/results/:pollId/stream/websocket", Handler: resultsHandler.StreamWebSocket, Summary: "Stream the results of a poll over a WebSocket", Scopes: adminScope, Access: routes.Internal},
+			{Method: http.MethodGet, Path: "/results/:pollId/stream/sse", Handler: resultsHandler.StreamSSE, Summary: "Stream the results of a poll as server-sent events", Scopes: adminScope, Access: routes.Internal},
+			{Method: http.MethodGet, Path: "/results/:pollId/stream/long-poll", Handler: resultsHandler.StreamLongPoll, Summary: "Wait for the next results of a poll", Scopes: adminScope, Access: routes.Internal},
+
+			{Method: http.MethodGet, Path: "/results/:pollId/milestones", Handler: resultsHandler.ListMilestones, Summary: "List the milestone webhooks of a poll", Scopes: adminScope, Access: routes.Internal},
+			{Method: http.MethodPost, Path: "/results/:pollId/milestones", Handler: resultsHandler.AddMilestone, Summary: "Register a webhook fired when a poll reaches a milestone", Scopes: adminScope, Access: routes.Internal, Limit: milestoneLimit},
+			{Method: http.MethodDelete, Path: "/results/:pollId/milestones/:milestoneId", Handler: resultsHandler.DeleteMilestone, Summary: "Delete a milestone webhook of a poll", Scopes: adminScope, Access: routes.Internal},
+
+			{Method: http.MethodGet, Path: apierror.RecentErrorsPath, Handler: apierror.RecentErrors.ServeRecentErrors, Summary: "List the latest error responses of the service", Scopes: adminScope},
+			{Method: http.MethodPost, Path: "/admin/results/rebuild", Handler: resultsHandler.RebuildResults, Summary: "Rebuild the materialized results from the vote events", Scopes: adminScope, Limit: rebuildLimit},
+			{Method: http.MethodPost, Path: "/admin/apikeys", Handler: resultsHandler.APIKeys().ServeCreateKey, Summary: "Create an API key with scopes such as read:polls or write:votes", Scopes: adminScope},
+			{Method: http.MethodGet, Path: "/admin/apikeys", Handler: resultsHandler.APIKeys().ServeListKeys, Summary: "List the API keys, without the keys themselves", Scopes: adminScope},
+			{Method: http.MethodDelete, Path: "/admin/apikeys/:id", Handler: resultsHandler.APIKeys().ServeRevokeKey, Summary: "Revoke an API key", Scopes: adminScope},
+		},
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"results-api/api"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The results are exact and unreleased, so every results route refuses
+// requests without the admin token and is left out of the public
+// OpenAPI document.
+func TestResultsRoutesAreInternal(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("ADMIN_TOKEN", "secret")
+
+	table := routeTable(&api.ResultsAPI{})
+	r := gin.New()
+	table.Register(r)
+
+	checked := 0
+	for _, route := range table.Routes {
+		if !strings.HasPrefix(route.Path, "/results/:pollId") {
+			continue
+		}
+		checked++
+
+		path := strings.NewReplacer(":pollId", "1", ":milestoneId", "1").Replace(route.Path)
+		recorder := httptest.NewRecorder()
+		r.ServeHTTP(recorder, httptest.NewRequest(route.Method, path, strings.NewReader("{}")))
+
+		if recorder.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s without the admin token = %d, want %d", route.Method, path, recorder.Code, http.StatusUnauthorized)
+		}
+	}
+	if checked == 0 {
+		t.Fatal("the route table has no results routes")
+	}
+
+	paths := table.OpenAPI(false)["paths"].(map[string]gin.H)
+	for path := range paths {
+		if strings.HasPrefix(path, "/results/{pollId}") {
+			t.Errorf("the public OpenAPI document has %s", path)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"scenario-runner/scenario"
+)
+
+var (
+	fileFlag     string
+	voterAPIFlag string
+	pollAPIFlag  string
+	votesAPIFlag string
+	adminFlag    string
+)
+
+func processCmdLineFlags() {
+	flag.StringVar(&fileFlag, "f", "scenarios/tea-or-coffee.yaml", "Scenario file to run")
+	flag.StringVar(&voterAPIFlag, "voterapi", "http://localhost:1080", "Voter API location")
+	flag.StringVar(&pollAPIFlag, "pollapi", "http://localhost:1081", "Poll API location")
+	flag.StringVar(&votesAPIFlag, "votesapi", "http://localhost:1082", "Votes API location")
+	flag.StringVar(&adminFlag, "admin", os.Getenv("ADMIN_TOKEN"), "Admin token sent with every request")
+
+	flag.Parse()
+}
+
+func main() {
+	processCmdLineFlags()
+
+	s, err := scenario.Load(fileFlag)
+	if err != nil {
+		log.Fatalln("Error loading scenario: ", err)
+	}
+
+	log.Printf("Running scenario %q", s.Name)
+
+	runner := scenario.NewRunner(voterAPIFlag, pollAPIFlag, votesAPIFlag, adminFlag)
+	result, err := runner.Run(s)
+	if err != nil {
+		log.Println("Error running scenario: ", err)
+	}
+
+	log.Printf("Scenario %q: %d steps, %d checks, %d failures", result.Name, result.Steps, result.Checks, len(result.Failures))
+
+	if err != nil || !result.Passed() {
+		os.Exit(1)
+	}
+}
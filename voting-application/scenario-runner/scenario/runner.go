@@ -0,0 +1,381 @@
+package scenario
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const (
+	AdminTokenHeader = "X-Admin-Token"
+)
+
+// Runner executes scenarios against live voter, poll and votes APIs.
+type Runner struct {
+	VoterAPIURL string
+	PollAPIURL  string
+	VotesAPIURL string
+	AdminToken  string
+	client      *resty.Client
+}
+
+// Create a new runner for the services at the given locations.
+func NewRunner(voterAPIURL, pollAPIURL, votesAPIURL, adminToken string) *Runner {
+	return &Runner{
+		VoterAPIURL: voterAPIURL,
+		PollAPIURL:  pollAPIURL,
+		VotesAPIURL: votesAPIURL,
+		AdminToken:  adminToken,
+		client:      resty.New(),
+	}
+}
+
+// Result is the outcome of a scenario run. Failures lists every check that
+// did not hold; the run stops at the first action that fails.
+type Result struct {
+	Name     string
+	Steps    int
+	Checks   int
+	Failures []string
+}
+
+// Passed reports whether every check of the run held.
+func (r Result) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+func (r *Result) fail(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	log.Println("FAIL:", message)
+	r.Failures = append(r.Failures, message)
+}
+
+func (rn *Runner) request() *resty.Request {
+	req := rn.client.R().SetHeader("Content-Type", "application/json")
+	if rn.AdminToken != "" {
+		req.SetHeader(AdminTokenHeader, rn.AdminToken)
+	}
+
+	return req
+}
+
+// Run the scenario: optionally reset the services, create its voters and
+// polls, then execute the timeline and the final expectations.
+func (rn *Runner) Run(s Scenario) (Result, error) {
+	result := Result{Name: s.Name}
+
+	if s.Reset {
+		if err := rn.reset(); err != nil {
+			return result, fmt.Errorf("resetting services: %w", err)
+		}
+	}
+
+	if err := rn.setup(s); err != nil {
+		return result, fmt.Errorf("setting up scenario: %w", err)
+	}
+
+	for i, step := range s.Timeline {
+		result.Steps++
+
+		if step.Expect != nil && step.Vote == nil && step.DeleteVote == 0 && step.Close == 0 && step.Certify == 0 && step.DeletePoll == 0 {
+			log.Printf("Step %d: checking expectations", i+1)
+			rn.check(&result, fmt.Sprintf("step %d", i+1), *step.Expect)
+			continue
+		}
+
+		description, resp, err := rn.act(step)
+		if err != nil {
+			return result, fmt.Errorf("step %d (%s): %w", i+1, description, err)
+		}
+
+		log.Printf("Step %d: %s -> %d", i+1, description, resp.StatusCode())
+
+		result.Checks++
+		if !statusMatches(step.ExpectStatus, resp.StatusCode()) {
+			result.fail("step %d (%s): expected status %s, got %d: %s", i+1, description,
+				expectedStatus(step.ExpectStatus), resp.StatusCode(), strings.TrimSpace(resp.String()))
+		}
+
+		if step.Expect != nil {
+			rn.check(&result, fmt.Sprintf("step %d", i+1), *step.Expect)
+		}
+	}
+
+	if s.Expect != nil {
+		log.Println("Checking final expectations")
+		rn.check(&result, "final state", *s.Expect)
+	}
+
+	return result, nil
+}
+
+func statusMatches(expected, actual int) bool {
+	if expected == 0 {
+		return actual >= 200 && actual < 300
+	}
+
+	return expected == actual
+}
+
+func expectedStatus(expected int) string {
+	if expected == 0 {
+		return "2xx"
+	}
+
+	return fmt.Sprint(expected)
+}
+
+// Remove every vote, poll and voter so the scenario starts from scratch.
+func (rn *Runner) reset() error {
+	var votes []struct {
+		VoteID uint `json:"voteId"`
+	}
+
+	if _, err := rn.request().SetResult(&votes).Get(rn.VotesAPIURL + "/votes"); err != nil {
+		return err
+	}
+
+	for _, vote := range votes {
+		if _, err := rn.request().Delete(fmt.Sprintf("%s/votes/%d", rn.VotesAPIURL, vote.VoteID)); err != nil {
+			return err
+		}
+	}
+
+	if _, err := rn.request().Delete(rn.PollAPIURL + "/polls"); err != nil {
+		return err
+	}
+
+	_, err := rn.request().Delete(rn.VoterAPIURL + "/voters")
+	return err
+}
+
+// Create the voters and polls of the scenario.
+func (rn *Runner) setup(s Scenario) error {
+	for _, voter := range s.Voters {
+		resp, err := rn.request().
+			SetBody(map[string]interface{}{"firstName": voter.FirstName, "lastName": voter.LastName}).
+			Post(fmt.Sprintf("%s/voters/%d", rn.VoterAPIURL, voter.ID))
+		if err := checkResponse(fmt.Sprintf("adding voter %d", voter.ID), resp, err); err != nil {
+			return err
+		}
+	}
+
+	for _, poll := range s.Polls {
+		resp, err := rn.request().
+			SetBody(map[string]interface{}{"pollTitle": poll.Title, "pollQuestion": poll.Question}).
+			Post(fmt.Sprintf("%s/polls/%d", rn.PollAPIURL, poll.ID))
+		if err := checkResponse(fmt.Sprintf("adding poll %d", poll.ID), resp, err); err != nil {
+			return err
+		}
+
+		for _, option := range poll.Options {
+			resp, err := rn.request().
+				SetBody(map[string]interface{}{"optionText": option.Text, "maxVotes": option.MaxVotes}).
+				Post(fmt.Sprintf("%s/polls/%d/options/%d", rn.PollAPIURL, poll.ID, option.ID))
+			if err := checkResponse(fmt.Sprintf("adding option %d to poll %d", option.ID, poll.ID), resp, err); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func checkResponse(description string, resp *resty.Response, err error) error {
+	if err != nil {
+		return fmt.Errorf("%s: %w", description, err)
+	}
+
+	if resp.IsError() {
+		return fmt.Errorf("%s: %s: %s", description, resp.Status(), strings.TrimSpace(resp.String()))
+	}
+
+	return nil
+}
+
+// Perform the action of a timeline step.
+func (rn *Runner) act(step Step) (string, *resty.Response, error) {
+	switch {
+	case step.Vote != nil:
+		v := step.Vote
+		description := fmt.Sprintf("voter %d votes option %d in poll %d", v.VoterID, v.Option, v.PollID)
+		resp, err := rn.request().
+			SetBody(map[string]interface{}{"voterId": v.VoterID, "pollId": v.PollID, "optionId": v.Option}).
+			Post(fmt.Sprintf("%s/votes/%d", rn.VotesAPIURL, v.ID))
+		return description, resp, err
+	case step.DeleteVote != 0:
+		description := fmt.Sprintf("delete vote %d", step.DeleteVote)
+		resp, err := rn.request().Delete(fmt.Sprintf("%s/votes/%d", rn.VotesAPIURL, step.DeleteVote))
+		return description, resp, err
+	case step.DeletePoll != 0:
+		description := fmt.Sprintf("delete poll %d", step.DeletePoll)
+		resp, err := rn.request().Delete(fmt.Sprintf("%s/polls/%d", rn.PollAPIURL, step.DeletePoll))
+		return description, resp, err
+	case step.Close != 0:
+		description := fmt.Sprintf("close poll %d", step.Close)
+		resp, err := rn.request().Post(fmt.Sprintf("%s/polls/%d/close", rn.PollAPIURL, step.Close))
+		return description, resp, err
+	default:
+		description := fmt.Sprintf("certify poll %d", step.Certify)
+		resp, err := rn.request().Post(fmt.Sprintf("%s/polls/%d/certify", rn.PollAPIURL, step.Certify))
+		return description, resp, err
+	}
+}
+
+// Verify an expectation against the live services.
+func (rn *Runner) check(result *Result, at string, expect Expectation) {
+	if expect.TotalVotes != nil {
+		result.Checks++
+
+		var votes []struct {
+			VoteID uint `json:"voteId"`
+		}
+
+		if _, err := rn.request().SetResult(&votes).Get(rn.VotesAPIURL + "/votes"); err != nil {
+			result.fail("%s: listing votes: %v", at, err)
+		} else if len(votes) != *expect.TotalVotes {
+			result.fail("%s: expected %d votes, got %d", at, *expect.TotalVotes, len(votes))
+		}
+	}
+
+	for _, poll := range expect.Polls {
+		if poll.Deleted {
+			result.Checks++
+			rn.checkPollDeleted(result, at, poll)
+		}
+
+		if poll.Votes != nil {
+			result.Checks++
+			rn.checkPollVotes(result, at, poll)
+		}
+
+		if poll.Status != "" {
+			result.Checks++
+			rn.checkPollStatus(result, at, poll)
+		}
+
+		if poll.Results != nil {
+			result.Checks++
+			rn.checkPollResults(result, at, poll)
+		}
+	}
+
+	for _, voter := range expect.Voters {
+		result.Checks++
+		rn.checkVoterHistory(result, at, voter)
+	}
+}
+
+func (rn *Runner) checkPollStatus(result *Result, at string, expect PollExpectation) {
+	var poll struct {
+		PollStatus string `json:"pollStatus"`
+	}
+
+	resp, err := rn.request().SetResult(&poll).Get(fmt.Sprintf("%s/polls/%d", rn.PollAPIURL, expect.ID))
+	if err := checkResponse(fmt.Sprintf("getting poll %d", expect.ID), resp, err); err != nil {
+		result.fail("%s: %v", at, err)
+		return
+	}
+
+	if poll.PollStatus != expect.Status {
+		result.fail("%s: expected poll %d to be %s, got %s", at, expect.ID, expect.Status, poll.PollStatus)
+	}
+}
+
+func (rn *Runner) checkPollDeleted(result *Result, at string, expect PollExpectation) {
+	resp, err := rn.request().Get(fmt.Sprintf("%s/polls/%d", rn.PollAPIURL, expect.ID))
+	if err != nil {
+		result.fail("%s: getting poll %d: %v", at, expect.ID, err)
+		return
+	}
+
+	if resp.StatusCode() != http.StatusNotFound {
+		result.fail("%s: expected poll %d to be deleted, got %d", at, expect.ID, resp.StatusCode())
+	}
+}
+
+func (rn *Runner) checkPollVotes(result *Result, at string, expect PollExpectation) {
+	var votes []struct {
+		VoteID uint `json:"voteId"`
+	}
+
+	resp, err := rn.request().SetResult(&votes).SetQueryParam("pollId", fmt.Sprint(expect.ID)).Get(rn.VotesAPIURL + "/votes")
+	if err := checkResponse(fmt.Sprintf("listing votes of poll %d", expect.ID), resp, err); err != nil {
+		result.fail("%s: %v", at, err)
+		return
+	}
+
+	if len(votes) != *expect.Votes {
+		result.fail("%s: expected poll %d to have %d votes, got %d", at, expect.ID, *expect.Votes, len(votes))
+	}
+}
+
+func (rn *Runner) checkPollResults(result *Result, at string, expect PollExpectation) {
+	var results struct {
+		Results []struct {
+			OptionID uint `json:"optionId"`
+			Votes    uint `json:"votes"`
+		} `json:"results"`
+	}
+
+	resp, err := rn.request().SetResult(&results).Get(fmt.Sprintf("%s/votes/results/%d", rn.VotesAPIURL, expect.ID))
+	if err := checkResponse(fmt.Sprintf("getting results of poll %d", expect.ID), resp, err); err != nil {
+		result.fail("%s: %v", at, err)
+		return
+	}
+
+	if resp.StatusCode() == http.StatusForbidden {
+		result.fail("%s: results of poll %d are not released, set the admin token", at, expect.ID)
+		return
+	}
+
+	actual := make(map[uint]uint)
+	for _, option := range results.Results {
+		if option.Votes > 0 {
+			actual[option.OptionID] = option.Votes
+		}
+	}
+
+	for optionID, votes := range expect.Results {
+		if actual[optionID] != votes {
+			result.fail("%s: expected option %d of poll %d to have %d votes, got %d", at, optionID, expect.ID, votes, actual[optionID])
+		}
+	}
+
+	for optionID, votes := range actual {
+		if _, expected := expect.Results[optionID]; !expected {
+			result.fail("%s: expected option %d of poll %d to have no votes, got %d", at, optionID, expect.ID, votes)
+		}
+	}
+}
+
+func (rn *Runner) checkVoterHistory(result *Result, at string, expect VoterExpectation) {
+	var voter struct {
+		VoteHistory []struct {
+			PollID uint `json:"pollId"`
+		} `json:"voteHistory"`
+	}
+
+	resp, err := rn.request().SetResult(&voter).Get(fmt.Sprintf("%s/voters/%d", rn.VoterAPIURL, expect.ID))
+	if err := checkResponse(fmt.Sprintf("getting voter %d", expect.ID), resp, err); err != nil {
+		result.fail("%s: %v", at, err)
+		return
+	}
+
+	actual := make([]uint, 0, len(voter.VoteHistory))
+	for _, poll := range voter.VoteHistory {
+		actual = append(actual, poll.PollID)
+	}
+
+	expected := append([]uint(nil), expect.VotedIn...)
+	sort.Slice(actual, func(i, j int) bool { return actual[i] < actual[j] })
+	sort.Slice(expected, func(i, j int) bool { return expected[i] < expected[j] })
+
+	if fmt.Sprint(actual) != fmt.Sprint(expected) {
+		result.fail("%s: expected voter %d to have voted in %v, got %v", at, expect.ID, expected, actual)
+	}
+}
@@ -0,0 +1,162 @@
+package scenario
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Scenario is a declarative description of voters, polls and a timeline
+// of votes and poll state changes, with the states expected along the way.
+type Scenario struct {
+	Name     string       `yaml:"name"`
+	Reset    bool         `yaml:"reset"`
+	Voters   []VoterSpec  `yaml:"voters"`
+	Polls    []PollSpec   `yaml:"polls"`
+	Timeline []Step       `yaml:"timeline"`
+	Expect   *Expectation `yaml:"expect"`
+}
+
+// VoterSpec is a voter created before the timeline runs.
+type VoterSpec struct {
+	ID        uint   `yaml:"id"`
+	FirstName string `yaml:"firstName"`
+	LastName  string `yaml:"lastName"`
+}
+
+// PollSpec is a poll created with its options before the timeline runs.
+type PollSpec struct {
+	ID       uint         `yaml:"id"`
+	Title    string       `yaml:"title"`
+	Question string       `yaml:"question"`
+	Options  []OptionSpec `yaml:"options"`
+}
+
+// OptionSpec is an option of a PollSpec.
+type OptionSpec struct {
+	ID       uint   `yaml:"id"`
+	Text     string `yaml:"text"`
+	MaxVotes uint   `yaml:"maxVotes"`
+}
+
+// Step is one entry of the timeline. Exactly one action (vote, deleteVote,
+// close, certify or deletePoll) or an expect block is set. ExpectStatus checks the
+// HTTP status of the action and defaults to any 2xx status.
+type Step struct {
+	Vote         *VoteSpec    `yaml:"vote"`
+	DeleteVote   uint         `yaml:"deleteVote"`
+	Close        uint         `yaml:"close"`
+	Certify      uint         `yaml:"certify"`
+	DeletePoll   uint         `yaml:"deletePoll"`
+	ExpectStatus int          `yaml:"expectStatus"`
+	Expect       *Expectation `yaml:"expect"`
+}
+
+// VoteSpec is a vote cast by a timeline step.
+type VoteSpec struct {
+	ID      uint `yaml:"id"`
+	VoterID uint `yaml:"voterId"`
+	PollID  uint `yaml:"pollId"`
+	Option  uint `yaml:"option"`
+}
+
+// Expectation describes the state the services must be in.
+type Expectation struct {
+	TotalVotes *int               `yaml:"totalVotes"`
+	Polls      []PollExpectation  `yaml:"polls"`
+	Voters     []VoterExpectation `yaml:"voters"`
+}
+
+// PollExpectation checks the status, per-option results and number of
+// votes of a poll, or that it was deleted.
+type PollExpectation struct {
+	ID      uint          `yaml:"id"`
+	Status  string        `yaml:"status"`
+	Results map[uint]uint `yaml:"results"`
+	Votes   *int          `yaml:"votes"`
+	Deleted bool          `yaml:"deleted"`
+}
+
+// VoterExpectation checks the polls a voter has voted in.
+type VoterExpectation struct {
+	ID      uint   `yaml:"id"`
+	VotedIn []uint `yaml:"votedIn"`
+}
+
+// Load and validate a scenario file.
+func Load(path string) (Scenario, error) {
+	var s Scenario
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return s, err
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&s); err != nil {
+		return s, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	return s, s.validate()
+}
+
+func (s Scenario) validate() error {
+	for i, step := range s.Timeline {
+		actions := 0
+		if step.Vote != nil {
+			actions++
+		}
+		if step.DeleteVote != 0 {
+			actions++
+		}
+		if step.Close != 0 {
+			actions++
+		}
+		if step.Certify != 0 {
+			actions++
+		}
+		if step.DeletePoll != 0 {
+			actions++
+		}
+
+		if actions > 1 || (actions == 0 && step.Expect == nil) {
+			return fmt.Errorf("timeline step %d must have exactly one action or an expect block", i+1)
+		}
+
+		if actions == 0 && step.ExpectStatus != 0 {
+			return fmt.Errorf("timeline step %d has expectStatus without an action", i+1)
+		}
+
+		if step.Expect != nil {
+			if err := step.Expect.validate(); err != nil {
+				return fmt.Errorf("timeline step %d: %w", i+1, err)
+			}
+		}
+	}
+
+	if s.Expect != nil {
+		if err := s.Expect.validate(); err != nil {
+			return fmt.Errorf("final expectations: %w", err)
+		}
+	}
+
+	if len(s.Voters) == 0 && len(s.Polls) == 0 && len(s.Timeline) == 0 {
+		return errors.New("scenario is empty")
+	}
+
+	return nil
+}
+
+func (e Expectation) validate() error {
+	for _, poll := range e.Polls {
+		if poll.Deleted && (poll.Status != "" || poll.Results != nil || poll.Votes != nil) {
+			return fmt.Errorf("poll %d cannot be deleted and have a status, results or votes", poll.ID)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,201 @@
+// Package apierror writes the error responses of the APIs. Every error is
+// a JSON body with a machine readable code, a message for people, optional
+// details and the ID of the request, so a failed call can be matched with
+// the server logs:
+//
+//	{"code": "not_found", "message": "Could not get voter", "details": "voter does not exist", "requestId": "..."}
+package apierror
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+	requestIDKey    = "requestId"
+)
+
+// The error codes. Clients should branch on the code, not the message.
+const (
+	CodeBadRequest         = "bad_request"
+	CodeInvalidID          = "invalid_id"
+	CodeInvalidBody        = "invalid_body"
+	CodeValidationFailed   = "validation_failed"
+	CodeUnauthorized       = "unauthorized"
+	CodeForbidden          = "forbidden"
+	CodeNotFound           = "not_found"
+	CodeNotAcceptable      = "not_acceptable"
+	CodeConflict           = "conflict"
+	CodeUnprocessable      = "unprocessable"
+	CodeRateLimited        = "rate_limited"
+	CodeInternal           = "internal_error"
+	CodeServiceUnavailable = "service_unavailable"
+)
+
+// Response is the body of an error response.
+type Response struct {
+	Code      string      `json:"code"`
+	Message   string      `json:"message"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"requestId"`
+}
+
+// Return the code of an HTTP status.
+func CodeForStatus(status int) string {
+	switch status {
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusNotAcceptable:
+		return CodeNotAcceptable
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusUnprocessableEntity:
+		return CodeUnprocessable
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusGatewayTimeout:
+		return CodeServiceUnavailable
+	}
+
+	if status >= http.StatusInternalServerError {
+		return CodeInternal
+	}
+
+	return CodeBadRequest
+}
+
+// Classify a store error by its message. The stores report missing and
+// duplicate records with errors such as "voter does not exist" and "poll
+// already exists"; those get their own status and code, anything else is
+// not classified. Errors that tell clients to retry later, such as those
+// of a redis failover, are 503.
+func Classify(err error) (int, string, bool) {
+	var retryable interface{ RetryAfter() time.Duration }
+	if errors.As(err, &retryable) {
+		return http.StatusServiceUnavailable, CodeServiceUnavailable, true
+	}
+
+	message := strings.ToLower(err.Error())
+
+	switch {
+	case strings.Contains(message, "does not exist"), strings.Contains(message, "not found"):
+		return http.StatusNotFound, CodeNotFound, true
+	case strings.Contains(message, "already"), strings.Contains(message, "modified concurrently"), strings.Contains(message, "is full"):
+		return http.StatusConflict, CodeConflict, true
+	}
+
+	return 0, "", false
+}
+
+// The middleware that gives every request an ID. An X-Request-ID sent by
+// the client or a proxy is kept, otherwise one is generated; it is echoed
+// in the response header and in error bodies.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > 128 {
+			requestID = newRequestID()
+		}
+
+		c.Set(requestIDKey, requestID)
+		c.Header(RequestIDHeader, requestID)
+
+		c.Next()
+	}
+}
+
+// Return the ID of the request, generating one when the RequestID
+// middleware did not run.
+func RequestIDFrom(c *gin.Context) string {
+	if requestID := c.GetString(requestIDKey); requestID != "" {
+		return requestID
+	}
+
+	requestID := newRequestID()
+	c.Set(requestIDKey, requestID)
+	c.Header(RequestIDHeader, requestID)
+
+	return requestID
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "unknown"
+	}
+
+	return hex.EncodeToString(id)
+}
+
+// Build the error body of a request.
+func NewResponse(c *gin.Context, code, message string, details interface{}) Response {
+	if err, ok := details.(error); ok {
+		details = err.Error()
+	}
+
+	return Response{
+		Code:      code,
+		Message:   message,
+		Details:   details,
+		RequestID: RequestIDFrom(c),
+	}
+}
+
+// Abort the request with an error response.
+func Abort(c *gin.Context, status int, code, message string) {
+	AbortWithDetails(c, status, code, message, nil)
+}
+
+// Abort the request with an error response that carries details, such as
+// the underlying error or the accepted values.
+func AbortWithDetails(c *gin.Context, status int, code, message string, details interface{}) {
+	// Kept for the recent errors.
+	c.Set(errorCodeKey, code)
+	c.Set(errorMessageKey, message)
+	if err, ok := details.(error); ok {
+		upstreamFromError(c, err)
+	}
+
+	c.AbortWithStatusJSON(status, NewResponse(c, code, message, details))
+}
+
+// Abort the request because of an error. Store errors that are classified
+// get their own status and code, others the given status; the error is
+// sent as details. Errors with a RetryAfter set the Retry-After header.
+func AbortWithError(c *gin.Context, status int, message string, err error) {
+	code := CodeForStatus(status)
+	if classifiedStatus, classifiedCode, ok := Classify(err); ok {
+		status, code = classifiedStatus, classifiedCode
+	}
+
+	var retryable interface{ RetryAfter() time.Duration }
+	if errors.As(err, &retryable) {
+		seconds := int(math.Ceil(retryable.RetryAfter().Seconds()))
+		c.Header("Retry-After", strconv.Itoa(seconds))
+	}
+
+	AbortWithDetails(c, status, code, message, err)
+}
+
+// Abort the request because a path or query parameter is not a valid ID.
+func AbortInvalidID(c *gin.Context, name string, err error) {
+	AbortWithDetails(c, http.StatusBadRequest, CodeInvalidID, name+" must be a positive integer", err)
+}
+
+// Abort the request because its body could not be read.
+func AbortInvalidBody(c *gin.Context, err error) {
+	AbortWithDetails(c, http.StatusBadRequest, CodeInvalidBody, "Request body is not valid JSON", err)
+}
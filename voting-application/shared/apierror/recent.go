@@ -0,0 +1,185 @@
+package apierror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultRecentErrorsSize      = 100
+	DefaultRecentErrorsMinStatus = http.StatusInternalServerError
+	// How many events GET /admin/recent-errors returns without ?limit=.
+	DefaultRecentErrorsLimit = 20
+
+	RecentErrorsPath = "/admin/recent-errors"
+
+	errorCodeKey    = "apierror.code"
+	errorMessageKey = "apierror.message"
+	upstreamKey     = "apierror.upstream"
+)
+
+// ErrorEvent is an error response of the service, as operators see it in
+// the recent errors.
+type ErrorEvent struct {
+	Time   time.Time `json:"time"`
+	Method string    `json:"method"`
+	// The route of the request, such as /votes/:id, or its path when no
+	// route matched.
+	Route     string `json:"route"`
+	Status    int    `json:"status"`
+	Code      string `json:"code"`
+	Message   string `json:"message,omitempty"`
+	RequestID string `json:"requestId"`
+	// The API the service could not reach, if that caused the error.
+	Upstream string `json:"upstream,omitempty"`
+}
+
+// ErrorLog keeps the latest error events of the service in a ring buffer.
+type ErrorLog struct {
+	mutex     sync.Mutex
+	events    []ErrorEvent
+	next      int
+	full      bool
+	minStatus int
+}
+
+// RecentErrors is the error log of the service, sized by
+// RECENT_ERRORS_SIZE. It records the responses with a status of at least
+// RECENT_ERRORS_MIN_STATUS, 500 by default; set it to 400 to also record
+// the errors of clients.
+var RecentErrors = NewErrorLog(loadRecentErrorsSetting("RECENT_ERRORS_SIZE", DefaultRecentErrorsSize), loadRecentErrorsSetting("RECENT_ERRORS_MIN_STATUS", DefaultRecentErrorsMinStatus))
+
+func loadRecentErrorsSetting(name string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(name)); err == nil && value > 0 {
+		return value
+	}
+
+	return defaultValue
+}
+
+// Create an error log of the latest size events with a status of at least
+// minStatus.
+func NewErrorLog(size, minStatus int) *ErrorLog {
+	return &ErrorLog{events: make([]ErrorEvent, size), minStatus: minStatus}
+}
+
+// Record an error event, replacing the oldest once the log is full.
+func (l *ErrorLog) Add(event ErrorEvent) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.events[l.next] = event
+	l.next = (l.next + 1) % len(l.events)
+	if l.next == 0 {
+		l.full = true
+	}
+}
+
+// Return up to limit events, the latest first.
+func (l *ErrorLog) Latest(limit int) []ErrorEvent {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	count := l.next
+	if l.full {
+		count = len(l.events)
+	}
+	if limit > count {
+		limit = count
+	}
+
+	events := make([]ErrorEvent, limit)
+	for i := range events {
+		events[i] = l.events[(l.next-1-i+len(l.events))%len(l.events)]
+	}
+
+	return events
+}
+
+// The middleware that records every response of the service with an error
+// status, and the requests that panicked. The code and message are those
+// of the error body, the upstream is set by AbortWithError or Upstream.
+func (l *ErrorLog) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// The recovery middleware answers 500 once the panic reached it.
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				c.Set(errorMessageKey, fmt.Sprint("panic: ", recovered))
+				l.record(c, http.StatusInternalServerError)
+				panic(recovered)
+			}
+		}()
+
+		c.Next()
+
+		l.record(c, c.Writer.Status())
+	}
+}
+
+func (l *ErrorLog) record(c *gin.Context, status int) {
+	if status < l.minStatus {
+		return
+	}
+
+	route := c.FullPath()
+	if route == "" {
+		route = c.Request.URL.Path
+	}
+
+	code := c.GetString(errorCodeKey)
+	if code == "" {
+		code = CodeForStatus(status)
+	}
+
+	l.Add(ErrorEvent{
+		Time:      time.Now().UTC(),
+		Method:    c.Request.Method,
+		Route:     route,
+		Status:    status,
+		Code:      code,
+		Message:   c.GetString(errorMessageKey),
+		RequestID: RequestIDFrom(c),
+		Upstream:  c.GetString(upstreamKey),
+	})
+}
+
+// Implementation of GET /admin/recent-errors.
+// Returns the latest error events of the service, up to ?limit=.
+func (l *ErrorLog) ServeRecentErrors(c *gin.Context) {
+	limit := DefaultRecentErrorsLimit
+	if query := c.Query("limit"); query != "" {
+		parsed, err := strconv.Atoi(query)
+		if err != nil || parsed < 1 {
+			Abort(c, http.StatusBadRequest, CodeBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = parsed
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"minStatus": l.minStatus,
+		"size":      len(l.events),
+		"errors":    l.Latest(limit),
+	})
+}
+
+// Name the API whose failure causes the error response of the request.
+func Upstream(c *gin.Context, name string) {
+	c.Set(upstreamKey, name)
+}
+
+// Set the upstream of a request from an error that names one, such as an
+// *endpoints.UnreachableError.
+func upstreamFromError(c *gin.Context, err error) {
+	var upstream interface{ Upstream() string }
+	if errors.As(err, &upstream) {
+		Upstream(c, upstream.Upstream())
+	}
+}
@@ -0,0 +1,355 @@
+// Package apikey authenticates requests by API keys, a lighter-weight
+// alternative to sharing the admin token. Admins create keys with
+// POST /admin/apikeys and send them in the X-API-Key header:
+//
+//	X-API-Key: vk_3_<64 hex digits>
+//
+// A key carries scopes, such as read:polls or write:votes, and a request
+// with a key is refused with 403 when the key lacks the scope of the route.
+// Requests without a key are handled as before. The keys are kept in the
+// redis the services share, hashed with SHA-256, so a key works with every
+// API and cannot be read back from redis.
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"shared/apierror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	Header = "X-API-Key"
+
+	RedisKeyPrefix = "apikeys"
+	KeysKey        = RedisKeyPrefix
+	KeyIDKey       = RedisKeyPrefix + ":next"
+
+	// Every key starts with it, followed by the key ID and the secret.
+	keyPrefix  = "vk_"
+	contextKey = "apikey"
+)
+
+// ScopeAdmin allows everything the admin token does, and every other
+// scope.
+const ScopeAdmin = "admin"
+
+// Resources are the first segments of the paths the read: and write:
+// scopes cover.
+var Resources = []string{"voters", "polls", "votes"}
+
+var (
+	// ErrKeyNotFound is returned when a key does not exist.
+	ErrKeyNotFound = errors.New("API key does not exist")
+	// ErrInvalidKey is returned for a key that is malformed, unknown,
+	// revoked or expired.
+	ErrInvalidKey = errors.New("API key is invalid, revoked or expired")
+)
+
+// Return the scopes a key can have: admin, and read: and write: for every
+// resource.
+func Scopes() []string {
+	scopes := []string{ScopeAdmin}
+	for _, resource := range Resources {
+		scopes = append(scopes, "read:"+resource, "write:"+resource)
+	}
+
+	return scopes
+}
+
+// Report whether a key can have a scope.
+func ValidScope(scope string) bool {
+	for _, valid := range Scopes() {
+		if scope == valid {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Return the scope a request needs: read: for GET, HEAD and OPTIONS and
+// write: for the other methods, on the resource its path starts with, or
+// admin for the /admin and /webhooks routes. Other routes, such as the
+// health probes and the root document, need none.
+func RequiredScope(method, path string) string {
+	segment := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)[0]
+	if segment == "admin" || segment == "webhooks" {
+		return ScopeAdmin
+	}
+
+	for _, resource := range Resources {
+		if segment != resource {
+			continue
+		}
+
+		switch method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			return "read:" + resource
+		default:
+			return "write:" + resource
+		}
+	}
+
+	return ""
+}
+
+// Key is an API key as it is stored, without the key itself.
+type Key struct {
+	KeyID  uint     `json:"keyId"`
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	// The start of the key, so its owner can tell which key it is.
+	Prefix string `json:"prefix"`
+	// The SHA-256 of the key, hex. It is never returned by the API.
+	Hash      string     `json:"hash,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+}
+
+// Report whether the key has a scope, which the admin scope always does.
+func (k Key) Allows(scope string) bool {
+	for _, granted := range k.Scopes {
+		if granted == scope || granted == ScopeAdmin {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Report whether the key can be used at a time.
+func (k Key) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// Store keeps the API keys in redis.
+type Store struct {
+	client redis.UniversalClient
+}
+
+// Create a store of the keys in a redis.
+func NewStore(client redis.UniversalClient) *Store {
+	return &Store{client: client}
+}
+
+func (s *Store) connected() error {
+	if s == nil || s.client == nil {
+		return errors.New("redis is not connected")
+	}
+
+	return nil
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Create a key with a new ID. It returns the stored key and the key
+// itself, which is not stored and cannot be recovered.
+func (s *Store) Create(ctx context.Context, name string, scopes []string, expiresAt *time.Time) (Key, string, error) {
+	if err := s.connected(); err != nil {
+		return Key{}, "", err
+	}
+
+	id, err := s.client.Incr(ctx, KeyIDKey).Result()
+	if err != nil {
+		return Key{}, "", err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return Key{}, "", err
+	}
+
+	presented := fmt.Sprintf("%s%d_%s", keyPrefix, id, hex.EncodeToString(secret))
+	key := Key{
+		KeyID:     uint(id),
+		Name:      name,
+		Scopes:    scopes,
+		Prefix:    presented[:len(presented)-56],
+		Hash:      hashKey(presented),
+		CreatedAt: time.Now().UTC(),
+		ExpiresAt: expiresAt,
+	}
+
+	if err := s.save(ctx, key); err != nil {
+		return Key{}, "", err
+	}
+
+	return key, presented, nil
+}
+
+func (s *Store) save(ctx context.Context, key Key) error {
+	data, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+
+	return s.client.HSet(ctx, KeysKey, strconv.FormatUint(uint64(key.KeyID), 10), data).Err()
+}
+
+// Return the keys ordered by ID, the revoked ones included.
+func (s *Store) List(ctx context.Context) ([]Key, error) {
+	if err := s.connected(); err != nil {
+		return nil, err
+	}
+
+	entries, err := s.client.HGetAll(ctx, KeysKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]Key, 0, len(entries))
+	for _, data := range entries {
+		var key Key
+		if err := json.Unmarshal([]byte(data), &key); err != nil {
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].KeyID < keys[j].KeyID
+	})
+
+	return keys, nil
+}
+
+// Return a key, ErrKeyNotFound when it does not exist.
+func (s *Store) Get(ctx context.Context, keyID uint) (Key, error) {
+	if err := s.connected(); err != nil {
+		return Key{}, err
+	}
+
+	data, err := s.client.HGet(ctx, KeysKey, strconv.FormatUint(uint64(keyID), 10)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return Key{}, ErrKeyNotFound
+	}
+	if err != nil {
+		return Key{}, err
+	}
+
+	var key Key
+	if err := json.Unmarshal(data, &key); err != nil {
+		return Key{}, err
+	}
+
+	return key, nil
+}
+
+// Revoke a key. It stays listed, with the time it was revoked; a key
+// revoked before keeps that time.
+func (s *Store) Revoke(ctx context.Context, keyID uint) (Key, error) {
+	key, err := s.Get(ctx, keyID)
+	if err != nil {
+		return Key{}, err
+	}
+
+	if key.RevokedAt != nil {
+		return key, nil
+	}
+
+	revokedAt := time.Now().UTC()
+	key.RevokedAt = &revokedAt
+
+	return key, s.save(ctx, key)
+}
+
+// Return the key a request presented, ErrInvalidKey unless it is an active
+// key.
+func (s *Store) Authenticate(ctx context.Context, presented string) (Key, error) {
+	rest, ok := strings.CutPrefix(presented, keyPrefix)
+	if !ok {
+		return Key{}, ErrInvalidKey
+	}
+
+	id, _, _ := strings.Cut(rest, "_")
+	keyID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return Key{}, ErrInvalidKey
+	}
+
+	key, err := s.Get(ctx, uint(keyID))
+	if errors.Is(err, ErrKeyNotFound) {
+		return Key{}, ErrInvalidKey
+	}
+	if err != nil {
+		return Key{}, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashKey(presented)), []byte(key.Hash)) != 1 || !key.Active(time.Now()) {
+		return Key{}, ErrInvalidKey
+	}
+
+	return key, nil
+}
+
+// The middleware that authenticates the requests with an X-API-Key header
+// and refuses them when the key lacks the scope of the route. Requests
+// without the header are passed on.
+func (s *Store) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		presented := c.GetHeader(Header)
+		if presented == "" {
+			c.Next()
+			return
+		}
+
+		key, err := s.Authenticate(c.Request.Context(), presented)
+		if errors.Is(err, ErrInvalidKey) {
+			apierror.Abort(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "The API key is invalid, revoked or expired")
+			return
+		}
+		if err != nil {
+			apierror.AbortWithError(c, http.StatusServiceUnavailable, "Could not check the API key", err)
+			return
+		}
+
+		path := c.FullPath()
+		if path == "" {
+			path = c.Request.URL.Path
+		}
+
+		if scope := RequiredScope(c.Request.Method, path); scope != "" && !key.Allows(scope) {
+			apierror.AbortWithDetails(c, http.StatusForbidden, apierror.CodeForbidden, "The API key lacks the scope of the route", gin.H{"scope": scope})
+			return
+		}
+
+		c.Set(contextKey, key)
+		c.Next()
+	}
+}
+
+// Return the key a request was authenticated with, if any.
+func FromContext(c *gin.Context) (Key, bool) {
+	value, ok := c.Get(contextKey)
+	if !ok {
+		return Key{}, false
+	}
+
+	key, ok := value.(Key)
+	return key, ok
+}
+
+// Report whether a request was authenticated with a key that has a scope.
+func HasScope(c *gin.Context, scope string) bool {
+	key, ok := FromContext(c)
+	return ok && key.Allows(scope)
+}
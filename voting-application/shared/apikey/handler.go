@@ -0,0 +1,121 @@
+package apikey
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"shared/apierror"
+	"shared/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+// keyRequest is the body of POST /admin/apikeys.
+type keyRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1,dive,required"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// Return a key as the API shows it, without its hash.
+func publicKey(key Key) Key {
+	key.Hash = ""
+	return key
+}
+
+// Implementation of POST /admin/apikeys.
+// Create an API key with a name and scopes, and optionally an expiry. The
+// key is only returned now; it is stored hashed.
+func (s *Store) ServeCreateKey(c *gin.Context) {
+	var request keyRequest
+	if err := validation.Bind(c, &request); err != nil {
+		log.Println("Error binding API key: ", err)
+		return
+	}
+
+	var fields []validation.FieldError
+	for i, scope := range request.Scopes {
+		if !ValidScope(scope) {
+			fields = append(fields, validation.FieldError{
+				Field:   fmt.Sprintf("scopes[%d]", i),
+				Rule:    "oneof",
+				Param:   strings.Join(Scopes(), " "),
+				Message: fmt.Sprintf("scope %q is not one of %s", scope, strings.Join(Scopes(), ", ")),
+			})
+		}
+	}
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		fields = append(fields, validation.FieldError{
+			Field:   "expiresAt",
+			Rule:    "future",
+			Message: "expiresAt must be in the future",
+		})
+	}
+	if len(fields) > 0 {
+		validation.AbortWithFields(c, fields)
+		return
+	}
+
+	key, presented, err := s.Create(c.Request.Context(), request.Name, request.Scopes, request.ExpiresAt)
+	if err != nil {
+		log.Println("Error creating API key: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not create API key", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "API key created. Keep it safe, it cannot be shown again.",
+		"key":     presented,
+		"apiKey":  publicKey(key),
+	})
+}
+
+// Implementation of GET /admin/apikeys.
+// Returns every API key, the revoked and expired ones included, without
+// the keys themselves.
+func (s *Store) ServeListKeys(c *gin.Context) {
+	keys, err := s.List(c.Request.Context())
+	if err != nil {
+		log.Println("Error getting API keys: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not get API keys", err)
+		return
+	}
+
+	for i := range keys {
+		keys[i] = publicKey(keys[i])
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// Implementation of DELETE /admin/apikeys/:id.
+// Revoke an API key; the requests that send it are refused from now on.
+func (s *Store) ServeRevokeKey(c *gin.Context) {
+	keyIDUint, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		log.Println("Error converting API key ID to uint: ", err)
+		apierror.AbortInvalidID(c, "API key ID", err)
+		return
+	}
+
+	key, err := s.Revoke(c.Request.Context(), uint(keyIDUint))
+	if err != nil {
+		log.Println("Error revoking API key: ", err)
+		if errors.Is(err, ErrKeyNotFound) {
+			apierror.AbortWithError(c, http.StatusNotFound, "Could not find API key", err)
+			return
+		}
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not revoke API key", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "API key revoked successfully.",
+		"apiKey":  publicKey(key),
+	})
+}
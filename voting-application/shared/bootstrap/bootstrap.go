@@ -0,0 +1,330 @@
+// Package bootstrap starts an API the same way in every service: it parses
+// the common flags, resolves the downstream endpoints, sets up the gin
+// engine with the shared middleware, serves REST and gRPC, and shuts both
+// down gracefully on SIGINT or SIGTERM. A main.go only describes what is
+// specific to its service:
+//
+//	server := bootstrap.New("votes-api", bootstrap.Config{
+//		Port:      1082,
+//		GRPCPort:  2082,
+//		Endpoints: []endpoints.Endpoint{endpoints.VoterAPI, endpoints.PollAPI},
+//	})
+//
+//	handler := api.NewVotesHandler(server.URL(endpoints.PollAPI), server.URL(endpoints.VoterAPI))
+//
+//	server.Run(
+//		bootstrap.WithMiddleware(api.HealthMiddleware(handler)),
+//		bootstrap.WithRoutes(func(r *gin.Engine) { registerRoutes(r, handler) }),
+//		bootstrap.WithWorkers(handler.StartWorkers),
+//		bootstrap.WithGRPC(handler.RegisterGRPC),
+//	)
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"shared/apierror"
+	"shared/deprecation"
+	"shared/endpoints"
+	"shared/payloadlog"
+	"shared/rpc"
+	"shared/seed"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+)
+
+const (
+	DefaultHost     = "0.0.0.0"
+	ShutdownTimeout = 10 * time.Second
+)
+
+// Config describes the flags and endpoints of a service.
+type Config struct {
+	// The default REST port, set with -p.
+	Port uint
+	// The default gRPC port, set with -g. Zero means the service has no
+	// gRPC interface and no -g flag.
+	GRPCPort uint
+	// The APIs the service calls, each gets its flag.
+	Endpoints []endpoints.Endpoint
+}
+
+// Server is a service being started.
+type Server struct {
+	name     string
+	host     string
+	port     uint
+	grpcPort uint
+	urls     map[endpoints.Endpoint]string
+	payloads *payloadlog.Config
+	cors     CORSConfig
+	// When the service started, and the file its boot report is written
+	// to, if any.
+	startedAt      time.Time
+	bootReportFile string
+}
+
+// Option adds what is specific to a service to its server.
+type Option func(*options)
+
+type options struct {
+	middleware []gin.HandlerFunc
+	routes     []func(r *gin.Engine)
+	workers    []func(ctx context.Context)
+	grpc       []func(server *grpc.Server)
+	shutdown   []func(ctx context.Context) error
+	seeders    []seed.Seeder
+
+	storageBackend string
+	checks         map[string]func() error
+}
+
+// Add middleware that runs after the request ID is set and before the
+// deprecation headers, so it sees every request with its ID.
+func WithMiddleware(middleware ...gin.HandlerFunc) Option {
+	return func(o *options) {
+		o.middleware = append(o.middleware, middleware...)
+	}
+}
+
+// Add routes to the engine, once all middleware is registered.
+func WithRoutes(register func(r *gin.Engine)) Option {
+	return func(o *options) {
+		o.routes = append(o.routes, register)
+	}
+}
+
+// Start background work before the server listens. The context is
+// cancelled when the server shuts down.
+func WithWorkers(start func(ctx context.Context)) Option {
+	return func(o *options) {
+		o.workers = append(o.workers, start)
+	}
+}
+
+// Register gRPC services, served on the -g port.
+func WithGRPC(register func(server *grpc.Server)) Option {
+	return func(o *options) {
+		o.grpc = append(o.grpc, register)
+	}
+}
+
+// Load the part of the -seed fixture the service owns once the server
+// listens. The seeding runs in the background and is logged; it stops
+// when the server shuts down.
+func WithSeeder(seeder seed.Seeder) Option {
+	return func(o *options) {
+		o.seeders = append(o.seeders, seeder)
+	}
+}
+
+// Run a hook once the servers have stopped, such as flushing buffered
+// writes. Hooks run in reverse order of registration, within the shutdown
+// timeout.
+func WithShutdownHook(hook func(ctx context.Context) error) Option {
+	return func(o *options) {
+		o.shutdown = append(o.shutdown, hook)
+	}
+}
+
+// Parse the flags of a service and resolve its endpoints. An invalid
+// endpoint URL, payload logging or CORS configuration stops the service
+// here, unreachable endpoints are only logged in the boot report.
+func New(name string, config Config) *Server {
+	s := &Server{name: name, urls: make(map[endpoints.Endpoint]string), startedAt: time.Now()}
+
+	flag.StringVar(&s.host, "h", DefaultHost, "Listen on all interfaces")
+	flag.UintVar(&s.port, "p", config.Port, "Default Port")
+	if config.GRPCPort != 0 {
+		flag.UintVar(&s.grpcPort, "g", config.GRPCPort, "Default gRPC Port, 0 disables gRPC")
+	}
+
+	flag.StringVar(&seed.File, "seed", os.Getenv("SEED_FILE"), "Fixture file loaded at startup and by POST /admin/seed")
+	flag.StringVar(&s.bootReportFile, "boot-report", os.Getenv("BOOT_REPORT_FILE"), "File the JSON boot report is written to at startup")
+
+	corsOrigins := flag.String("cors-origins", "", "Comma separated origins allowed to call the API, overrides CORS_ALLOWED_ORIGINS")
+	corsDev := flag.Bool("cors-dev", os.Getenv("CORS_DEV") == "true", "Allow any origin, for local development only")
+
+	flagValues := make([]*string, len(config.Endpoints))
+	for i, endpoint := range config.Endpoints {
+		flagValues[i] = flag.String(endpoint.Flag, "", endpoint.Usage())
+	}
+
+	flag.Parse()
+
+	// The endpoints are probed for the boot report once the server
+	// listens; unreachable ones are logged then.
+	for i, endpoint := range config.Endpoints {
+		s.urls[endpoint] = endpoint.MustResolve(*flagValues[i])
+	}
+
+	payloads, err := payloadlog.FromEnv()
+	if err != nil {
+		log.Fatalf("Error configuring payload logging of %s: %v", name, err)
+	}
+	s.payloads = payloads
+
+	if *corsDev {
+		s.cors = DevCORSConfig()
+		log.Printf("CORS of %s allows any origin, -cors-dev must not be used in production", name)
+	} else {
+		corsConfig, err := CORSFromEnv(*corsOrigins)
+		if err != nil {
+			log.Fatalf("Error configuring CORS of %s: %v", name, err)
+		}
+		s.cors = corsConfig
+	}
+
+	return s
+}
+
+// Return the resolved URL of an endpoint of the Config.
+func (s *Server) URL(endpoint endpoints.Endpoint) string {
+	url, ok := s.urls[endpoint]
+	if !ok {
+		log.Fatalf("%s has no %s endpoint configured", s.name, endpoint.Name)
+	}
+
+	return url
+}
+
+// Build the engine and serve until SIGINT or SIGTERM, then shut down.
+func (s *Server) Run(opts ...Option) {
+	o := options{checks: make(map[string]func() error)}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	r := gin.Default()
+
+	// Answer cross-origin requests from the allowed origins only.
+	if middleware := s.cors.Middleware(); middleware != nil {
+		r.Use(middleware)
+	}
+
+	// Give every request an ID, it is echoed in error responses.
+	r.Use(apierror.RequestID())
+
+	// Keep the latest error responses for GET /admin/recent-errors.
+	r.Use(apierror.RecentErrors.Middleware())
+
+	// Log the redacted bodies of the requests when LOG_PAYLOADS is set. It
+	// comes before the other middleware so it sees the final response.
+	r.Use(s.payloads.Middleware())
+
+	r.Use(o.middleware...)
+
+	// Signal the use of deprecated routes and fields to clients.
+	r.Use(deprecation.Default.Middleware())
+
+	for _, register := range o.routes {
+		register(r)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	workerCtx, cancelWorkers := context.WithCancel(context.Background())
+	defer cancelWorkers()
+	for _, start := range o.workers {
+		start(workerCtx)
+	}
+
+	// Serve gRPC on its own port next to the REST endpoints.
+	var grpcServer *grpc.Server
+	if len(o.grpc) > 0 {
+		grpcServer = rpc.Serve(s.host, s.grpcPort, func(server *grpc.Server) {
+			for _, register := range o.grpc {
+				register(server)
+			}
+		})
+	}
+
+	server := &http.Server{
+		Addr:    fmt.Sprintf("%s:%d", s.host, s.port),
+		Handler: r,
+	}
+
+	log.Printf("%s listening on %s", s.name, server.Addr)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+
+	// Report the effective configuration and the dependencies once, in
+	// the background so the checks do not hold up the first requests.
+	go s.logBootReport(s.bootReport(&o))
+
+	if seed.File != "" && len(o.seeders) > 0 {
+		go s.seed(workerCtx, o.seeders)
+	}
+
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Error serving %s: %v", s.name, err)
+		}
+	case <-ctx.Done():
+	}
+
+	log.Printf("Shutting down %s", s.name)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Println("Error shutting down the HTTP server: ", err)
+	}
+
+	if grpcServer != nil {
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+		case <-shutdownCtx.Done():
+			grpcServer.Stop()
+		}
+	}
+
+	cancelWorkers()
+
+	for i := len(o.shutdown) - 1; i >= 0; i-- {
+		if err := o.shutdown[i](shutdownCtx); err != nil {
+			log.Println("Error running shutdown hook: ", err)
+		}
+	}
+}
+
+// Load the -seed fixture with the seeders of the service.
+func (s *Server) seed(ctx context.Context, seeders []seed.Seeder) {
+	fixture, err := seed.Load(seed.File)
+	if err != nil {
+		log.Printf("Error loading seed file %s: %v", seed.File, err)
+		return
+	}
+
+	for _, seeder := range seeders {
+		report := seeder(ctx, fixture)
+		for kind, counts := range report.Counts {
+			log.Printf("Seeded %s %s: %d created, %d skipped, %d failed", s.name, kind, counts.Created, counts.Skipped, counts.Failed)
+		}
+		for _, message := range report.Errors {
+			log.Println("Error seeding: ", message)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package bootstrap
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultCORSMaxAge = 12 * time.Hour
+)
+
+var (
+	DefaultCORSMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
+	// The request headers the APIs read.
+	DefaultCORSHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Admin-Token", "X-API-Key", "X-Embargo-Token", "X-Request-ID", "Idempotency-Key", "If-None-Match", "Last-Event-ID"}
+	// The response headers browsers let scripts read, besides the simple
+	// ones.
+	DefaultCORSExposeHeaders = []string{"X-Request-ID", "ETag", "Location", "Retry-After", "Deprecation", "Sunset", "X-RateLimit-Limit", "X-RateLimit-Remaining", "Link", "X-Total-Count"}
+)
+
+// CORSConfig is the cross-origin policy of a service. Without allowed
+// origins no CORS headers are sent, so browsers only let pages served
+// from the same origin call the API.
+type CORSConfig struct {
+	// Origins such as https://admin.example.com, https://*.example.com
+	// for the subdomains, or * for any origin.
+	AllowOrigins     []string
+	AllowMethods     []string
+	AllowHeaders     []string
+	ExposeHeaders    []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// Return the policy of -cors-dev: any origin, method and header, as during
+// local development.
+func DevCORSConfig() CORSConfig {
+	return CORSConfig{
+		AllowOrigins:  []string{"*"},
+		AllowMethods:  DefaultCORSMethods,
+		AllowHeaders:  DefaultCORSHeaders,
+		ExposeHeaders: DefaultCORSExposeHeaders,
+		MaxAge:        DefaultCORSMaxAge,
+	}
+}
+
+// Split a comma separated list, dropping the empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// Return the policy of CORS_ALLOWED_ORIGINS, CORS_ALLOWED_METHODS,
+// CORS_ALLOWED_HEADERS, CORS_EXPOSE_HEADERS, CORS_ALLOW_CREDENTIALS and
+// CORS_MAX_AGE. origins, from -cors-origins, replaces CORS_ALLOWED_ORIGINS
+// when it is set.
+func CORSFromEnv(origins string) (CORSConfig, error) {
+	config := CORSConfig{
+		AllowMethods:  DefaultCORSMethods,
+		AllowHeaders:  DefaultCORSHeaders,
+		ExposeHeaders: DefaultCORSExposeHeaders,
+		MaxAge:        DefaultCORSMaxAge,
+	}
+
+	if origins == "" {
+		origins = os.Getenv("CORS_ALLOWED_ORIGINS")
+	}
+	config.AllowOrigins = splitList(origins)
+
+	if methods := splitList(os.Getenv("CORS_ALLOWED_METHODS")); methods != nil {
+		config.AllowMethods = methods
+	}
+	if headers := splitList(os.Getenv("CORS_ALLOWED_HEADERS")); headers != nil {
+		config.AllowHeaders = headers
+	}
+	if headers := splitList(os.Getenv("CORS_EXPOSE_HEADERS")); headers != nil {
+		config.ExposeHeaders = headers
+	}
+
+	if value := os.Getenv("CORS_ALLOW_CREDENTIALS"); value != "" {
+		credentials, err := strconv.ParseBool(value)
+		if err != nil {
+			return CORSConfig{}, fmt.Errorf("CORS_ALLOW_CREDENTIALS must be true or false, got %q", value)
+		}
+		config.AllowCredentials = credentials
+	}
+
+	if value := os.Getenv("CORS_MAX_AGE"); value != "" {
+		maxAge, err := time.ParseDuration(value)
+		if err != nil || maxAge < 0 {
+			return CORSConfig{}, fmt.Errorf("CORS_MAX_AGE must be a duration such as 10m, got %q", value)
+		}
+		config.MaxAge = maxAge
+	}
+
+	return config, config.Validate()
+}
+
+// Report whether the policy allows any origin.
+func (c CORSConfig) allowAll() bool {
+	for _, origin := range c.AllowOrigins {
+		if origin == "*" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Check that the policy can be enforced. Credentials cannot be allowed
+// for any origin, browsers reject such responses.
+func (c CORSConfig) Validate() error {
+	allowAll := c.allowAll()
+	if allowAll && len(c.AllowOrigins) > 1 {
+		return fmt.Errorf("CORS origin * cannot be combined with other origins")
+	}
+
+	if allowAll && c.AllowCredentials {
+		return fmt.Errorf("CORS credentials cannot be allowed for any origin, list the origins instead")
+	}
+
+	for _, origin := range c.AllowOrigins {
+		if origin != "*" && !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://") {
+			return fmt.Errorf("CORS origin %q must start with http:// or https://", origin)
+		}
+	}
+
+	return nil
+}
+
+// Return the middleware enforcing the policy, nil when it allows no
+// origin.
+func (c CORSConfig) Middleware() gin.HandlerFunc {
+	if len(c.AllowOrigins) == 0 {
+		return nil
+	}
+
+	config := cors.Config{
+		AllowMethods:     c.AllowMethods,
+		AllowHeaders:     c.AllowHeaders,
+		ExposeHeaders:    c.ExposeHeaders,
+		AllowCredentials: c.AllowCredentials,
+		MaxAge:           c.MaxAge,
+	}
+
+	if c.allowAll() {
+		config.AllowAllOrigins = true
+	} else {
+		config.AllowOrigins = c.AllowOrigins
+		config.AllowWildcard = strings.Contains(strings.Join(c.AllowOrigins, ","), "*")
+	}
+
+	return cors.New(config)
+}
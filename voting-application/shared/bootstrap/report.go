@@ -0,0 +1,266 @@
+package bootstrap
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"shared/seed"
+	"shared/version"
+)
+
+// The value of the secrets in a boot report, as in the payload logs.
+const Redacted = "[REDACTED]"
+
+// The prefixes of the environment variables that configure the services.
+// Only these are in a boot report, the rest of the environment is not the
+// business of deployment tooling.
+var ConfigPrefixes = []string{
+	"ADMIN_", "API_", "BOOT_", "COMPATIBILITY_", "CORS_", "DOWNSTREAM_", "HEALTH_",
+	"IDEMPOTENCY_", "IMPORT_", "LOG_", "POLL_", "POSTGRES_", "RECEIPT_", "REDIS_",
+	"RESULTS_", "RETENTION_", "SEED_", "STORAGE_", "STREAM_", "TALLY_", "TTL_",
+	"VOTER_", "VOTE_", "WRITE_IN_",
+}
+
+// The suffixes of the names of the variables that hold secrets.
+var secretSuffixes = []string{"_SECRET", "_TOKEN", "_PASSWORD", "_KEY"}
+
+// BootReport is the effective configuration of an instance, logged once
+// it listens so deployment tooling can check what every instance runs
+// with.
+type BootReport struct {
+	Service   string    `json:"service"`
+	Build     string    `json:"build"`
+	Commit    string    `json:"commit,omitempty"`
+	GoVersion string    `json:"goVersion"`
+	Hostname  string    `json:"hostname"`
+	PID       int       `json:"pid"`
+	StartedAt time.Time `json:"startedAt"`
+	Listen    Listen    `json:"listen"`
+	// The store of the documents of the service, empty when it has none.
+	StorageBackend string          `json:"storageBackend,omitempty"`
+	Features       map[string]bool `json:"features"`
+	// The resolved URLs of the APIs the service calls.
+	Endpoints map[string]string `json:"endpoints"`
+	// The value of every flag, set or default.
+	Flags map[string]string `json:"flags"`
+	// The configuration variables that are set, with secrets redacted.
+	Environment  map[string]string           `json:"environment"`
+	Dependencies map[string]DependencyStatus `json:"dependencies"`
+}
+
+// Listen are the addresses an instance listens on.
+type Listen struct {
+	HTTP string `json:"http"`
+	GRPC string `json:"grpc,omitempty"`
+}
+
+// DependencyStatus is the result of a check of a dependency at startup.
+type DependencyStatus struct {
+	Status  string `json:"status"`
+	Latency string `json:"latency"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Set the storage backend the boot report names.
+func WithStorageBackend(backend string) Option {
+	return func(o *options) {
+		o.storageBackend = backend
+	}
+}
+
+// Check dependencies other than the endpoints, such as Redis or Postgres,
+// for the boot report. A failed check is reported and logged; it does not
+// stop the service.
+func WithDependencyChecks(checks map[string]func() error) Option {
+	return func(o *options) {
+		for name, check := range checks {
+			o.checks[name] = check
+		}
+	}
+}
+
+// Build the boot report of the server once it listens. The endpoints and
+// the dependencies are checked at the same time, within the probe timeout.
+func (s *Server) bootReport(o *options) BootReport {
+	info := version.Current(s.name, "")
+	hostname, _ := os.Hostname()
+	grpcEnabled := len(o.grpc) > 0 && s.grpcPort != 0
+
+	report := BootReport{
+		Service:        s.name,
+		Build:          info.Build,
+		Commit:         info.Commit,
+		GoVersion:      info.GoVersion,
+		Hostname:       hostname,
+		PID:            os.Getpid(),
+		StartedAt:      s.startedAt,
+		Listen:         Listen{HTTP: fmt.Sprintf("%s:%d", s.host, s.port)},
+		StorageBackend: o.storageBackend,
+		Features: map[string]bool{
+			"grpc":           grpcEnabled,
+			"payloadLogging": s.payloads.Enabled,
+			"cors":           len(s.cors.AllowOrigins) > 0,
+			"corsAnyOrigin":  contains(s.cors.AllowOrigins, "*"),
+			"seed":           seed.File != "" && len(o.seeders) > 0,
+			"adminAPI":       os.Getenv("ADMIN_TOKEN") != "",
+		},
+		Endpoints:    make(map[string]string),
+		Flags:        make(map[string]string),
+		Environment:  configEnvironment(os.Environ()),
+		Dependencies: make(map[string]DependencyStatus),
+	}
+	if grpcEnabled {
+		report.Listen.GRPC = fmt.Sprintf("%s:%d", s.host, s.grpcPort)
+	}
+
+	flag.VisitAll(func(f *flag.Flag) {
+		report.Flags[f.Name] = f.Value.String()
+	})
+
+	checks := make(map[string]func() error, len(o.checks)+len(s.urls))
+	for name, check := range o.checks {
+		checks[name] = check
+	}
+	// The endpoints are named as in the readiness probes, such as
+	// voter-api.
+	for endpoint, endpointURL := range s.urls {
+		endpoint, endpointURL := endpoint, endpointURL
+		name := strings.ReplaceAll(strings.ToLower(endpoint.Name), " ", "-")
+		report.Endpoints[name] = redactURL(endpointURL)
+		checks[name] = func() error {
+			return endpoint.Probe(context.Background(), endpointURL)
+		}
+	}
+
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check func() error) {
+			defer wg.Done()
+
+			start := time.Now()
+			err := check()
+			status := DependencyStatus{Status: "up", Latency: time.Since(start).String()}
+			if err != nil {
+				status.Status = "down"
+				status.Error = err.Error()
+				log.Printf("Warning: %v", err)
+			}
+
+			lock.Lock()
+			report.Dependencies[name] = status
+			lock.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+
+	return report
+}
+
+// Log the boot report as one line of JSON, and write it to the -boot-report
+// file when one is set.
+func (s *Server) logBootReport(report BootReport) {
+	data, err := json.Marshal(report)
+	if err != nil {
+		log.Println("Error encoding the boot report: ", err)
+		return
+	}
+	log.Printf("Boot report: %s", data)
+
+	if s.bootReportFile == "" {
+		return
+	}
+
+	data, err = json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		log.Println("Error encoding the boot report: ", err)
+		return
+	}
+	if err := os.WriteFile(s.bootReportFile, append(data, '\n'), 0644); err != nil {
+		log.Printf("Error writing the boot report to %s: %v", s.bootReportFile, err)
+	}
+}
+
+// Return the configuration variables of an environment, with the values
+// of secrets and the passwords of URLs redacted.
+func configEnvironment(environ []string) map[string]string {
+	config := make(map[string]string)
+
+	for _, variable := range environ {
+		name, value, _ := strings.Cut(variable, "=")
+		if !isConfigVariable(name) {
+			continue
+		}
+
+		switch {
+		case isSecret(name) && value != "":
+			config[name] = Redacted
+		case strings.Contains(value, "://"):
+			config[name] = redactURL(value)
+		default:
+			config[name] = value
+		}
+	}
+
+	return config
+}
+
+func isConfigVariable(name string) bool {
+	for _, prefix := range ConfigPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Report whether a variable holds a secret by its name, such as
+// ADMIN_TOKEN or REDIS_PASSWORD.
+func isSecret(name string) bool {
+	for _, suffix := range secretSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Redact the password of the URLs of a value, such as
+// postgres://voting:secret@db/voting, which becomes
+// postgres://voting:xxxxx@db/voting. A value may hold URLs separated by
+// commas.
+func redactURL(value string) string {
+	parts := strings.Split(value, ",")
+	for i, part := range parts {
+		parsed, err := url.Parse(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		if _, ok := parsed.User.Password(); ok {
+			parts[i] = parsed.Redacted()
+		}
+	}
+
+	return strings.Join(parts, ",")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,370 @@
+// Package codec stores documents in redis with a configurable serializer.
+// ReJSON keeps documents queryable with JSON.GET from redis-cli, msgpack
+// and gob store them in plain keys, which is more compact and faster for
+// large deployments. Every Store records the size and latency of its codec
+// and, for a sample of writes, of the other codecs, so the choice can be
+// justified with numbers.
+package codec
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const (
+	CodecReJSON  = "rejson"
+	CodecMsgpack = "msgpack"
+	CodecGob     = "gob"
+
+	// Every CompareEvery writes the document is also encoded with the other
+	// codecs, to compare their size and latency.
+	CompareEvery = 100
+)
+
+// Codec serializes documents.
+type Codec interface {
+	Name() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+type reJSONCodec struct{}
+
+func (reJSONCodec) Name() string {
+	return CodecReJSON
+}
+
+func (reJSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (reJSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// msgpackCodec uses the json tags of the documents, so field names and
+// omitempty match the JSON documents.
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string {
+	return CodecMsgpack
+}
+
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	var buffer bytes.Buffer
+	encoder := msgpack.NewEncoder(&buffer)
+	encoder.SetCustomStructTag("json")
+	encoder.UseCompactInts(true)
+
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	decoder := msgpack.NewDecoder(bytes.NewReader(data))
+	decoder.SetCustomStructTag("json")
+
+	return decoder.Decode(v)
+}
+
+type gobCodec struct{}
+
+func (gobCodec) Name() string {
+	return CodecGob
+}
+
+func (gobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := gob.NewEncoder(&buffer).Encode(v); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// The codecs by name.
+var codecs = map[string]Codec{
+	CodecReJSON:  reJSONCodec{},
+	CodecMsgpack: msgpackCodec{},
+	CodecGob:     gobCodec{},
+}
+
+// Return the codec selected by REDIS_CODEC, ReJSON unless it is set.
+func FromEnv() Codec {
+	name := strings.ToLower(os.Getenv("REDIS_CODEC"))
+	if name == "" {
+		return codecs[CodecReJSON]
+	}
+
+	codec, ok := codecs[name]
+	if !ok {
+		log.Printf("Unknown REDIS_CODEC %q, using %s", name, CodecReJSON)
+		return codecs[CodecReJSON]
+	}
+
+	return codec
+}
+
+// Processor runs redis commands. A client, a transaction and a pipeline
+// all are one, so documents can be read and written inside transactions.
+type Processor interface {
+	Process(ctx context.Context, cmd redis.Cmder) error
+}
+
+// Store reads and writes documents in redis with a codec.
+type Store struct {
+	codec   Codec
+	lock    sync.Mutex
+	metrics map[string]*codecMetrics
+	writes  uint64
+}
+
+// Create a Store that uses the codec.
+func NewStore(codec Codec) *Store {
+	metrics := make(map[string]*codecMetrics, len(codecs))
+	for name := range codecs {
+		metrics[name] = &codecMetrics{}
+	}
+
+	return &Store{
+		codec:   codec,
+		metrics: metrics,
+	}
+}
+
+// Return the name of the codec of the Store.
+func (s *Store) Codec() string {
+	return s.codec.Name()
+}
+
+// Read the document at key into v. A missing key returns redis.Nil.
+// Documents written with ReJSON before the codec was switched are still
+// read, and are converted the next time they are written.
+func (s *Store) Get(ctx context.Context, p Processor, key string, v interface{}) error {
+	if s.codec.Name() == CodecReJSON {
+		return s.getReJSON(ctx, p, key, v, s.codec)
+	}
+
+	cmd := redis.NewStringCmd(ctx, "GET", key)
+	if err := p.Process(ctx, cmd); err != nil {
+		if strings.HasPrefix(err.Error(), "WRONGTYPE") {
+			return s.getReJSON(ctx, p, key, v, codecs[CodecReJSON])
+		}
+		return err
+	}
+
+	return s.decode(s.codec, []byte(cmd.Val()), v)
+}
+
+func (s *Store) getReJSON(ctx context.Context, p Processor, key string, v interface{}, codec Codec) error {
+	cmd := redis.NewStringCmd(ctx, "JSON.GET", key, ".")
+	if err := p.Process(ctx, cmd); err != nil {
+		return err
+	}
+
+	return s.decode(codec, []byte(cmd.Val()), v)
+}
+
+// Write v as the document at key. Inside a pipeline the command is queued.
+func (s *Store) Set(ctx context.Context, p Processor, key string, v interface{}) error {
+	data, err := s.encode(v)
+	if err != nil {
+		return err
+	}
+
+	var cmd redis.Cmder
+	if s.codec.Name() == CodecReJSON {
+		cmd = redis.NewStatusCmd(ctx, "JSON.SET", key, ".", string(data))
+	} else {
+		cmd = redis.NewStatusCmd(ctx, "SET", key, data)
+	}
+
+	return p.Process(ctx, cmd)
+}
+
+// Write v as the document at key unless a document exists there, in one
+// command, so two writers cannot both create it. It returns whether v was
+// written.
+func (s *Store) SetNX(ctx context.Context, p Processor, key string, v interface{}) (bool, error) {
+	data, err := s.encode(v)
+	if err != nil {
+		return false, err
+	}
+
+	var cmd *redis.StatusCmd
+	if s.codec.Name() == CodecReJSON {
+		cmd = redis.NewStatusCmd(ctx, "JSON.SET", key, ".", string(data), "NX")
+	} else {
+		cmd = redis.NewStatusCmd(ctx, "SET", key, data, "NX")
+	}
+
+	err = p.Process(ctx, cmd)
+	switch {
+	case err == redis.Nil:
+		return false, nil
+	// A document another codec wrote is not JSON, but exists all the same.
+	case err != nil && strings.HasPrefix(err.Error(), "WRONGTYPE"):
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Delete the document at key, whatever codec wrote it.
+func (s *Store) Del(ctx context.Context, p Processor, key string) error {
+	return p.Process(ctx, redis.NewIntCmd(ctx, "DEL", key))
+}
+
+func (s *Store) encode(v interface{}) ([]byte, error) {
+	start := time.Now()
+	data, err := s.codec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	s.record(s.codec.Name(), len(data), time.Since(start), 0)
+
+	s.lock.Lock()
+	s.writes++
+	compare := s.writes%CompareEvery == 1
+	s.lock.Unlock()
+
+	if compare {
+		s.compare(v)
+	}
+
+	return data, nil
+}
+
+func (s *Store) decode(codec Codec, data []byte, v interface{}) error {
+	start := time.Now()
+	if err := codec.Unmarshal(data, v); err != nil {
+		return err
+	}
+	s.record(codec.Name(), 0, 0, time.Since(start))
+
+	return nil
+}
+
+// Encode and decode the document with the other codecs, recording their
+// size and latency without storing anything.
+func (s *Store) compare(v interface{}) {
+	for name, codec := range codecs {
+		if name == s.codec.Name() {
+			continue
+		}
+
+		start := time.Now()
+		data, err := codec.Marshal(v)
+		if err != nil {
+			continue
+		}
+		encodeTime := time.Since(start)
+
+		decoded := reflect.New(reflect.TypeOf(v)).Interface()
+		start = time.Now()
+		if err := codec.Unmarshal(data, decoded); err != nil {
+			continue
+		}
+
+		s.record(name, len(data), encodeTime, time.Since(start))
+	}
+}
+
+// codecMetrics are the totals of a codec. A zero size or duration is not
+// counted.
+type codecMetrics struct {
+	encoded    uint64
+	bytes      uint64
+	encodeTime time.Duration
+	decoded    uint64
+	decodeTime time.Duration
+}
+
+func (s *Store) record(name string, size int, encodeTime, decodeTime time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	metrics := s.metrics[name]
+	if size > 0 {
+		metrics.encoded++
+		metrics.bytes += uint64(size)
+		metrics.encodeTime += encodeTime
+	}
+	if decodeTime > 0 {
+		metrics.decoded++
+		metrics.decodeTime += decodeTime
+	}
+}
+
+// CodecStats are the size and latency metrics of one codec.
+type CodecStats struct {
+	Active            bool   `json:"active"`
+	Encoded           uint64 `json:"encoded"`
+	TotalBytes        uint64 `json:"totalBytes"`
+	AverageSize       uint64 `json:"averageSize"`
+	AverageEncodeTime string `json:"averageEncodeTime"`
+	Decoded           uint64 `json:"decoded"`
+	AverageDecodeTime string `json:"averageDecodeTime"`
+}
+
+// Stats are the metrics of every codec of a Store. The active codec counts
+// every read and write, the others only the sampled writes.
+type Stats struct {
+	Codec  string                `json:"codec"`
+	Codecs map[string]CodecStats `json:"codecs"`
+}
+
+// Return the metrics of the Store.
+func (s *Store) Stats() Stats {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	stats := Stats{
+		Codec:  s.codec.Name(),
+		Codecs: make(map[string]CodecStats, len(s.metrics)),
+	}
+
+	for name, metrics := range s.metrics {
+		codecStats := CodecStats{
+			Active:            name == s.codec.Name(),
+			Encoded:           metrics.encoded,
+			TotalBytes:        metrics.bytes,
+			Decoded:           metrics.decoded,
+			AverageEncodeTime: time.Duration(0).String(),
+			AverageDecodeTime: time.Duration(0).String(),
+		}
+
+		if metrics.encoded > 0 {
+			codecStats.AverageSize = metrics.bytes / metrics.encoded
+			codecStats.AverageEncodeTime = (metrics.encodeTime / time.Duration(metrics.encoded)).String()
+		}
+
+		if metrics.decoded > 0 {
+			codecStats.AverageDecodeTime = (metrics.decodeTime / time.Duration(metrics.decoded)).String()
+		}
+
+		stats.Codecs[name] = codecStats
+	}
+
+	return stats
+}
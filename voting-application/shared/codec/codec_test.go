@@ -0,0 +1,65 @@
+package codec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// processor records the commands it is given and answers each with err.
+type processor struct {
+	commands [][]interface{}
+	err      error
+}
+
+func (p *processor) Process(ctx context.Context, cmd redis.Cmder) error {
+	p.commands = append(p.commands, cmd.Args())
+	cmd.SetErr(p.err)
+	return p.err
+}
+
+type document struct {
+	ID uint `json:"id"`
+}
+
+func TestSetNXSendsTheCommandOfTheCodec(t *testing.T) {
+	tests := []struct {
+		codec   string
+		command []interface{}
+	}{
+		{CodecReJSON, []interface{}{"JSON.SET", "vote:1", ".", `{"id":1}`, "NX"}},
+		{CodecMsgpack, []interface{}{"SET", "vote:1", []byte("\x81\xa2id\x01"), "NX"}},
+	}
+
+	for _, test := range tests {
+		p := &processor{}
+		created, err := NewStore(codecs[test.codec]).SetNX(context.Background(), p, "vote:1", document{ID: 1})
+		if err != nil || !created {
+			t.Fatalf("%s: SetNX = %v, %v, want created", test.codec, created, err)
+		}
+		if len(p.commands) != 1 || fmt.Sprintf("%q", p.commands[0]) != fmt.Sprintf("%q", test.command) {
+			t.Fatalf("%s: commands = %q, want %q", test.codec, p.commands, test.command)
+		}
+	}
+}
+
+func TestSetNXOfAnExistingDocument(t *testing.T) {
+	for name, err := range map[string]error{
+		"nil reply": redis.Nil,
+		// A document another codec wrote.
+		"wrong type": errors.New("WRONGTYPE Operation against a key holding the wrong kind of value"),
+	} {
+		created, setErr := NewStore(codecs[CodecReJSON]).SetNX(context.Background(), &processor{err: err}, "vote:1", document{ID: 1})
+		if setErr != nil || created {
+			t.Fatalf("%s: SetNX = %v, %v, want not created without an error", name, created, setErr)
+		}
+	}
+
+	failure := errors.New("connection refused")
+	if _, err := NewStore(codecs[CodecReJSON]).SetNX(context.Background(), &processor{err: failure}, "vote:1", document{ID: 1}); !errors.Is(err, failure) {
+		t.Fatalf("SetNX = %v, want %v", err, failure)
+	}
+}
@@ -0,0 +1,60 @@
+// Package conflict is the ?onConflict= policy of the requests that create
+// a record under an ID chosen by the client, POST /voters/:id and
+// POST /polls/:id, for when a record already has the ID:
+//
+//	fail     409, the stored record is left as it is (the default)
+//	replace  200, the stored record becomes the record of the request
+//	merge    200, the fields the request sets are written over the stored record
+//
+// The X-Create-Outcome header of a successful response says whether the
+// record was created, replaced or merged, so importers and provisioning
+// scripts can run the same requests again.
+package conflict
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	Fail    = "fail"
+	Replace = "replace"
+	Merge   = "merge"
+)
+
+// The outcomes of a create request.
+const (
+	Created  = "created"
+	Replaced = "replaced"
+	Merged   = "merged"
+)
+
+const OutcomeHeader = "X-Create-Outcome"
+
+// Return the policy named by policy, Fail when it is empty.
+func Parse(policy string) (string, error) {
+	switch policy {
+	case "":
+		return Fail, nil
+	case Fail, Replace, Merge:
+		return policy, nil
+	}
+
+	return "", fmt.Errorf("unknown onConflict %q, use %s, %s or %s", policy, Fail, Replace, Merge)
+}
+
+// Return the outcome of a create with the policy that found a stored
+// record.
+func Outcome(policy string) string {
+	if policy == Merge {
+		return Merged
+	}
+
+	return Replaced
+}
+
+// Write the outcome of a create in the header of the response.
+func SetOutcome(c *gin.Context, outcome string) {
+	c.Header(OutcomeHeader, outcome)
+}
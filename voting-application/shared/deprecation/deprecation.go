@@ -0,0 +1,260 @@
+package deprecation
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	WarningDeprecatedRoute = "deprecated_route"
+	WarningDeprecatedField = "deprecated_field"
+
+	// Request bodies larger than this are not inspected for deprecated fields.
+	MaxInspectedBody = 1 << 20
+)
+
+// Deprecation describes a route, or a field of the JSON request body of a
+// route, that clients should stop using.
+type Deprecation struct {
+	// Method and Path identify the route, Path as registered with gin.
+	Method string
+	Path   string
+	// Field is a top-level field of the request body. When it is empty
+	// the whole route is deprecated.
+	Field       string
+	Replacement string
+	Since       time.Time
+	Sunset      time.Time
+}
+
+// Warning is reported in the warnings array of a response when a request
+// uses something deprecated.
+type Warning struct {
+	Code        string    `json:"code"`
+	Route       string    `json:"route,omitempty"`
+	Field       string    `json:"field,omitempty"`
+	Replacement string    `json:"replacement,omitempty"`
+	Sunset      time.Time `json:"sunset"`
+	Message     string    `json:"message"`
+}
+
+// Registry holds the deprecations of every service.
+type Registry struct {
+	routes map[string]Deprecation
+	fields map[string][]Deprecation
+}
+
+// Create a registry of the provided deprecations.
+func NewRegistry(deprecations ...Deprecation) *Registry {
+	r := &Registry{
+		routes: make(map[string]Deprecation),
+		fields: make(map[string][]Deprecation),
+	}
+
+	for _, d := range deprecations {
+		route := d.Method + " " + d.Path
+		if d.Field == "" {
+			r.routes[route] = d
+		} else {
+			r.fields[route] = append(r.fields[route], d)
+		}
+	}
+
+	return r
+}
+
+// Default is the central registry, shared by all services. Add an entry
+// here when a field or route is renamed so that clients still using the
+// old name are told about it before it is removed.
+var Default = NewRegistry(
+	Deprecation{
+		Method:      http.MethodPost,
+		Path:        "/votes/:id",
+		Field:       "voteValue",
+		Replacement: "optionId",
+		Since:       time.Date(2026, time.October, 14, 0, 0, 0, 0, time.UTC),
+		Sunset:      time.Date(2027, time.April, 1, 0, 0, 0, 0, time.UTC),
+	},
+)
+
+// Return the warnings for a request to the route.
+func (r *Registry) warnings(c *gin.Context, route string) []Warning {
+	var warnings []Warning
+
+	if d, ok := r.routes[route]; ok {
+		message := route + " is deprecated"
+		if d.Replacement != "" {
+			message += ", use " + d.Replacement
+		}
+
+		warnings = append(warnings, Warning{
+			Code:        WarningDeprecatedRoute,
+			Route:       route,
+			Replacement: d.Replacement,
+			Sunset:      d.Sunset,
+			Message:     message,
+		})
+	}
+
+	fieldDeprecations := r.fields[route]
+	if len(fieldDeprecations) == 0 || c.Request.Body == nil {
+		return warnings
+	}
+
+	// Peek at the top-level fields of the body and put it back for the
+	// handler.
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, MaxInspectedBody+1))
+	if err != nil {
+		return warnings
+	}
+	c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+
+	if len(body) > MaxInspectedBody {
+		return warnings
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return warnings
+	}
+
+	for _, d := range fieldDeprecations {
+		if _, ok := fields[d.Field]; !ok {
+			continue
+		}
+
+		message := d.Field + " is deprecated"
+		if d.Replacement != "" {
+			message += ", use " + d.Replacement
+		}
+
+		warnings = append(warnings, Warning{
+			Code:        WarningDeprecatedField,
+			Field:       d.Field,
+			Replacement: d.Replacement,
+			Sunset:      d.Sunset,
+			Message:     message,
+		})
+	}
+
+	return warnings
+}
+
+// The middleware that signals the use of deprecated routes and fields. It
+// sets the Deprecation and Sunset headers and adds a warnings array to
+// JSON object responses. Requests that use nothing deprecated are not
+// touched.
+func (r *Registry) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.Request.Method + " " + c.FullPath()
+
+		warnings := r.warnings(c, route)
+		if len(warnings) == 0 {
+			c.Next()
+			return
+		}
+
+		since, sunset := r.dates(route, warnings)
+		c.Header("Deprecation", "@"+strconv.FormatInt(since.Unix(), 10))
+		c.Header("Sunset", sunset.UTC().Format(http.TimeFormat))
+
+		writer := &warningWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		c.Writer = writer.ResponseWriter
+		writer.flush(warnings)
+	}
+}
+
+// Return the earliest deprecation and sunset dates of the warnings, the
+// headers announce the first thing that goes away.
+func (r *Registry) dates(route string, warnings []Warning) (time.Time, time.Time) {
+	var since, sunset time.Time
+
+	consider := func(d Deprecation) {
+		if since.IsZero() || d.Since.Before(since) {
+			since = d.Since
+		}
+		if sunset.IsZero() || d.Sunset.Before(sunset) {
+			sunset = d.Sunset
+		}
+	}
+
+	for _, warning := range warnings {
+		if warning.Code == WarningDeprecatedRoute {
+			consider(r.routes[route])
+			continue
+		}
+
+		for _, d := range r.fields[route] {
+			if d.Field == warning.Field {
+				consider(d)
+			}
+		}
+	}
+
+	return since, sunset
+}
+
+// warningWriter holds back the response body so the warnings can be added
+// to it. A flush from a streaming handler sends what was written so far
+// and lets the rest through unchanged.
+type warningWriter struct {
+	gin.ResponseWriter
+	body        bytes.Buffer
+	passthrough bool
+}
+
+func (w *warningWriter) Write(data []byte) (int, error) {
+	if w.passthrough {
+		return w.ResponseWriter.Write(data)
+	}
+
+	return w.body.Write(data)
+}
+
+func (w *warningWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *warningWriter) Flush() {
+	if !w.passthrough {
+		w.passthrough = true
+		w.ResponseWriter.Write(w.body.Bytes())
+		w.body.Reset()
+	}
+
+	w.ResponseWriter.Flush()
+}
+
+// Write the held back body, with the warnings added when it is a JSON
+// object.
+func (w *warningWriter) flush(warnings []Warning) {
+	if w.passthrough {
+		return
+	}
+
+	body := w.body.Bytes()
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(body, &object); err == nil && object != nil {
+		if encoded, err := json.Marshal(warnings); err == nil {
+			object["warnings"] = encoded
+			if merged, err := json.Marshal(object); err == nil {
+				body = merged
+			}
+		}
+	}
+
+	if len(body) > 0 {
+		w.ResponseWriter.Write(body)
+	}
+}
@@ -0,0 +1,172 @@
+// Package endpoints resolves where the APIs reach each other. The URL of
+// an API comes from, in order:
+//
+//  1. its command line flag, such as -v for the voter API,
+//  2. its environment variable, such as VOTER_API_URL,
+//  3. its service name, such as http://voter-api:1080.
+//
+// The service names are those of docker-compose and of Kubernetes Services
+// in the same namespace, so the defaults work in both; on bare metal set
+// the variables, for example VOTER_API_URL=http://localhost:1080.
+package endpoints
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	ProbeTimeout = 3 * time.Second
+)
+
+// Endpoint describes an API other services call.
+type Endpoint struct {
+	Name       string
+	Env        string
+	Flag       string
+	Default    string
+	HealthPath string
+}
+
+var (
+	VoterAPI = Endpoint{Name: "voter API", Env: "VOTER_API_URL", Flag: "v", Default: "http://voter-api:1080", HealthPath: "/healthz"}
+	PollAPI  = Endpoint{Name: "poll API", Env: "POLL_API_URL", Flag: "papi", Default: "http://poll-api:1081", HealthPath: "/healthz"}
+	VotesAPI = Endpoint{Name: "votes API", Env: "VOTES_API_URL", Flag: "vapi", Default: "http://votes-api:1082", HealthPath: "/healthz"}
+)
+
+// Return the flag usage of the endpoint.
+func (e Endpoint) Usage() string {
+	return fmt.Sprintf("%s location, overrides %s (default %s)", e.Name, e.Env, e.Default)
+}
+
+// Resolve the URL of the endpoint from the flag value, the environment or
+// the default, and check that it is an absolute http or https URL. The
+// trailing slash is removed so paths can be appended.
+func (e Endpoint) Resolve(flagValue string) (string, error) {
+	value, source := flagValue, "-"+e.Flag
+	if value == "" {
+		value, source = os.Getenv(e.Env), e.Env
+	}
+	if value == "" {
+		value, source = e.Default, "default"
+	}
+
+	parsed, err := url.Parse(value)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", fmt.Errorf("%s URL %q from %s is not an absolute http(s) URL", e.Name, value, source)
+	}
+
+	return strings.TrimRight(value, "/"), nil
+}
+
+// Resolve the URL of the endpoint and exit when it is invalid, so a
+// misconfigured service fails at startup instead of on the first request.
+func (e Endpoint) MustResolve(flagValue string) string {
+	resolved, err := e.Resolve(flagValue)
+	if err != nil {
+		log.Fatalf("Invalid endpoint configuration: %v", err)
+	}
+
+	log.Printf("Using %s at %s", e.Name, resolved)
+
+	return resolved
+}
+
+// UnreachableError is returned when an API could not be reached at all, as
+// opposed to an error response.
+type UnreachableError struct {
+	Endpoint Endpoint
+	URL      string
+	Err      error
+}
+
+func (e *UnreachableError) Error() string {
+	return fmt.Sprintf("%s is unreachable at %s (%s): %v", e.Endpoint.Name, e.URL, e.hint(), e.Err)
+}
+
+func (e *UnreachableError) Unwrap() error {
+	return e.Err
+}
+
+// Return the name of the endpoint, the upstream of the recent errors.
+func (e *UnreachableError) Upstream() string {
+	return e.Endpoint.Name
+}
+
+// A hint at the likely cause of the error.
+func (e *UnreachableError) hint() string {
+	var dnsErr *net.DNSError
+	if errors.As(e.Err, &dnsErr) {
+		return fmt.Sprintf("the host does not resolve, set %s or -%s", e.Endpoint.Env, e.Endpoint.Flag)
+	}
+
+	var opErr *net.OpError
+	if errors.As(e.Err, &opErr) && opErr.Op == "dial" {
+		return "nothing is listening there, check that it is running"
+	}
+
+	if errors.Is(e.Err, context.DeadlineExceeded) {
+		return "it did not answer in time"
+	}
+
+	return "check " + e.Endpoint.Env
+}
+
+// Wrap an error of a call to the endpoint at url. Transport errors become
+// an *UnreachableError; nil stays nil.
+func (e Endpoint) Unreachable(url string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var unreachable *UnreachableError
+	if errors.As(err, &unreachable) {
+		return err
+	}
+
+	return &UnreachableError{Endpoint: e, URL: url, Err: err}
+}
+
+// Check that the endpoint at url answers its health path. Services start
+// in any order, so callers log the error rather than exit.
+func (e Endpoint) Probe(ctx context.Context, url string) error {
+	ctx, cancel := context.WithTimeout(ctx, ProbeTimeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url+e.HealthPath, nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return e.Unreachable(url, err)
+	}
+	response.Body.Close()
+
+	if response.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("%s at %s is not healthy: %s", e.Name, url, response.Status)
+	}
+
+	return nil
+}
+
+// Probe the endpoints in the background and log the ones that cannot be
+// reached.
+func ProbeAll(urls map[Endpoint]string) {
+	for endpoint, url := range urls {
+		go func(endpoint Endpoint, url string) {
+			if err := endpoint.Probe(context.Background(), url); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+		}(endpoint, url)
+	}
+}
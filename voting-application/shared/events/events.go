@@ -0,0 +1,345 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	EventTypeVoteCast    = "VoteCast"
+	EventTypeVoteDeleted = "VoteDeleted"
+
+	EventTypePollCreated        = "PollCreated"
+	EventTypePollUpdated        = "PollUpdated"
+	EventTypePollOptionsChanged = "PollOptionsChanged"
+	EventTypePollOpened         = "PollOpened"
+	EventTypePollClosed         = "PollClosed"
+	EventTypePollCertified      = "PollCertified"
+	EventTypePollDeleted        = "PollDeleted"
+
+	DefaultStreamMaxLen = 100000
+	DefaultBatchSize    = 10
+	DefaultBlock        = 5 * time.Second
+	DefaultClaimIdle    = time.Minute
+)
+
+// VoteCast is published by the votes API when it accepts a vote.
+type VoteCast struct {
+	VoteID    uint      `json:"voteId"`
+	VoterID   uint      `json:"voterId"`
+	PollID    uint      `json:"pollId"`
+	VoteValue uint      `json:"voteValue"`
+	CastAt    time.Time `json:"castAt"`
+	// The options of a vote of a multi-choice poll, empty when the vote
+	// selects VoteValue alone.
+	OptionIDs []uint `json:"optionIds,omitempty"`
+	// The text of a write-in vote, which selects no option.
+	WriteInValue string `json:"writeInValue,omitempty"`
+}
+
+// VoteDeleted is published by the votes API when a vote is deleted or
+// voided and no longer counts.
+type VoteDeleted struct {
+	VoteID    uint      `json:"voteId"`
+	VoterID   uint      `json:"voterId"`
+	PollID    uint      `json:"pollId"`
+	VoteValue uint      `json:"voteValue"`
+	DeletedAt time.Time `json:"deletedAt"`
+	// The options of a vote of a multi-choice poll.
+	OptionIDs []uint `json:"optionIds,omitempty"`
+}
+
+// PollChanged is published by the poll API when a poll is created, changes
+// or is deleted. Polls are open when they are created.
+type PollChanged struct {
+	PollID     uint   `json:"pollId"`
+	PollTitle  string `json:"pollTitle,omitempty"`
+	PollStatus string `json:"pollStatus,omitempty"`
+	// The option of a PollOptionsChanged event.
+	OptionID  uint      `json:"optionId,omitempty"`
+	ChangedAt time.Time `json:"changedAt"`
+}
+
+// Message is an event read from a stream.
+type Message struct {
+	ID   string
+	Type string
+	Data []byte
+}
+
+// Decode the payload of the message into v.
+func (m Message) Decode(v interface{}) error {
+	return json.Unmarshal(m.Data, v)
+}
+
+// Publish an event to a redis stream. The stream is trimmed to about
+// DefaultStreamMaxLen entries. It returns the ID of the stream entry.
+func Publish(ctx context.Context, client redis.UniversalClient, stream, eventType string, payload interface{}) (string, error) {
+	if client == nil {
+		return "", errors.New("redis is not connected")
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	return client.XAdd(ctx, &redis.XAddArgs{
+		Stream:       stream,
+		MaxLenApprox: DefaultStreamMaxLen,
+		Values:       map[string]interface{}{"type": eventType, "data": string(data)},
+	}).Result()
+}
+
+// Consumer reads a stream as a member of a consumer group. Every message
+// is acknowledged only after its handler succeeds; messages whose handler
+// failed, or that were left pending by a consumer that went away, are
+// delivered again.
+type Consumer struct {
+	client    redis.UniversalClient
+	stream    string
+	group     string
+	name      string
+	BatchSize int64
+	Block     time.Duration
+	ClaimIdle time.Duration
+}
+
+// Create a consumer named name in the consumer group of the stream.
+// Consumers of the same group share the messages of the stream, each
+// group receives every message.
+func NewConsumer(client redis.UniversalClient, stream, group, name string) *Consumer {
+	return &Consumer{
+		client:    client,
+		stream:    stream,
+		group:     group,
+		name:      name,
+		BatchSize: DefaultBatchSize,
+		Block:     DefaultBlock,
+		ClaimIdle: DefaultClaimIdle,
+	}
+}
+
+// Handler processes a message. Returning an error leaves the message
+// pending so it is delivered again.
+type Handler func(ctx context.Context, message Message) error
+
+// Run the consumer until the context is done. It creates the group when it
+// does not exist yet, so a new group starts from the beginning of the
+// stream.
+func (c *Consumer) Run(ctx context.Context, handler Handler) error {
+	if c.client == nil {
+		return errors.New("redis is not connected")
+	}
+
+	err := c.client.XGroupCreateMkStream(ctx, c.stream, c.group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+
+	// Deliver the messages this consumer read before a restart but never
+	// acknowledged, then switch to new messages.
+	if err := c.drainPending(ctx, handler); err != nil {
+		return err
+	}
+
+	lastClaim := time.Now()
+	for {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if time.Since(lastClaim) >= c.ClaimIdle {
+			if err := c.claimStale(ctx, handler); err != nil {
+				log.Println("Error claiming stale messages: ", err)
+			}
+			lastClaim = time.Now()
+		}
+
+		streams, err := c.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    c.group,
+			Consumer: c.name,
+			Streams:  []string{c.stream, ">"},
+			Count:    c.BatchSize,
+			Block:    c.Block,
+		}).Result()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			log.Println("Error reading stream: ", err)
+			time.Sleep(time.Second)
+			continue
+		}
+
+		for _, stream := range streams {
+			c.handle(ctx, handler, stream.Messages)
+		}
+	}
+}
+
+func (c *Consumer) drainPending(ctx context.Context, handler Handler) error {
+	for {
+		streams, err := c.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    c.group,
+			Consumer: c.name,
+			Streams:  []string{c.stream, "0"},
+			Count:    c.BatchSize,
+		}).Result()
+		if err == redis.Nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		delivered := 0
+		for _, stream := range streams {
+			delivered += len(stream.Messages)
+			if failed := c.handle(ctx, handler, stream.Messages); failed > 0 {
+				// Failed messages stay pending, they are retried by claimStale.
+				return nil
+			}
+		}
+
+		if delivered == 0 {
+			return nil
+		}
+	}
+}
+
+// Take over the messages other consumers of the group left pending for
+// longer than ClaimIdle, and process them.
+func (c *Consumer) claimStale(ctx context.Context, handler Handler) error {
+	pending, err := c.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: c.stream,
+		Group:  c.group,
+		Start:  "-",
+		End:    "+",
+		Count:  c.BatchSize,
+	}).Result()
+	if err != nil {
+		return err
+	}
+
+	ids := make([]string, 0, len(pending))
+	for _, entry := range pending {
+		if entry.Idle >= c.ClaimIdle {
+			ids = append(ids, entry.ID)
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	messages, err := c.client.XClaim(ctx, &redis.XClaimArgs{
+		Stream:   c.stream,
+		Group:    c.group,
+		Consumer: c.name,
+		MinIdle:  c.ClaimIdle,
+		Messages: ids,
+	}).Result()
+	if err != nil {
+		return err
+	}
+
+	c.handle(ctx, handler, messages)
+
+	return nil
+}
+
+// Run the handler on each message and acknowledge the ones it processed.
+// It returns the number of messages that failed.
+func (c *Consumer) handle(ctx context.Context, handler Handler, messages []redis.XMessage) int {
+	failed := 0
+
+	for _, raw := range messages {
+		message := messageFrom(raw)
+
+		if err := handler(ctx, message); err != nil {
+			log.Printf("Error handling %s message %s: %v", message.Type, message.ID, err)
+			failed++
+			continue
+		}
+
+		if err := c.client.XAck(ctx, c.stream, c.group, raw.ID).Err(); err != nil {
+			log.Println("Error acknowledging message: ", err)
+		}
+	}
+
+	return failed
+}
+
+// Convert a stream entry to a Message.
+func messageFrom(raw redis.XMessage) Message {
+	message := Message{ID: raw.ID}
+	if eventType, ok := raw.Values["type"].(string); ok {
+		message.Type = eventType
+	}
+	if data, ok := raw.Values["data"].(string); ok {
+		message.Data = []byte(data)
+	}
+
+	return message
+}
+
+// Return the ID of the last message of a stream, "0-0" when it is empty.
+// Tailing from it reads the messages published from now on.
+func LastID(ctx context.Context, client redis.UniversalClient, stream string) (string, error) {
+	if client == nil {
+		return "", errors.New("redis is not connected")
+	}
+
+	entries, err := client.XRevRangeN(ctx, stream, "+", "-", 1).Result()
+	if err != nil {
+		return "", err
+	}
+
+	if len(entries) == 0 {
+		return "0-0", nil
+	}
+
+	return entries[0].ID, nil
+}
+
+// Read the messages of a stream that follow lastID, without a consumer
+// group, waiting up to block for one when there are none; with a negative
+// block it does not wait. It returns no messages when the wait
+// ends without any. Every reader gets every message, which suits feeds
+// sent to clients, not processing that must happen once.
+func Tail(ctx context.Context, client redis.UniversalClient, stream, lastID string, count int64, block time.Duration) ([]Message, error) {
+	if client == nil {
+		return nil, errors.New("redis is not connected")
+	}
+
+	streams, err := client.XRead(ctx, &redis.XReadArgs{
+		Streams: []string{stream, lastID},
+		Count:   count,
+		Block:   block,
+	}).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []Message
+	for _, stream := range streams {
+		for _, raw := range stream.Messages {
+			messages = append(messages, messageFrom(raw))
+		}
+	}
+
+	return messages, nil
+}
@@ -0,0 +1,124 @@
+// Package export streams the records of an API to the client as CSV or as
+// a JSON array. Rows are written and flushed as the store reads them, with
+// chunked transfer encoding, so an export of hundreds of thousands of
+// records is never held in memory.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"shared/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+
+	// Rows are flushed to the client in chunks of this many rows.
+	FlushEvery = 200
+
+	RowsTrailer  = "X-Export-Rows"
+	ErrorTrailer = "X-Export-Error"
+)
+
+// Writer writes the rows of an export.
+type Writer struct {
+	c      *gin.Context
+	format string
+	csv    *csv.Writer
+	rows   int
+}
+
+// Start an export named name, in the format of the ?format query, json by
+// default. header is the CSV header row. An unknown format aborts the
+// request with 400 and returns nil.
+func NewWriter(c *gin.Context, name string, header []string) *Writer {
+	format := c.DefaultQuery("format", FormatJSON)
+	if format != FormatCSV && format != FormatJSON {
+		apierror.AbortWithDetails(c, http.StatusBadRequest, apierror.CodeBadRequest, "Unsupported export format", []string{FormatCSV, FormatJSON})
+		return nil
+	}
+
+	w := &Writer{c: c, format: format}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+"."+format))
+	c.Header("Trailer", RowsTrailer+", "+ErrorTrailer)
+	c.Header("X-Content-Type-Options", "nosniff")
+
+	if format == FormatCSV {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Status(http.StatusOK)
+		w.csv = csv.NewWriter(c.Writer)
+		w.csv.Write(header)
+		return w
+	}
+
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Status(http.StatusOK)
+	c.Writer.WriteString("[")
+
+	return w
+}
+
+// Write a record: row in CSV exports and record itself in JSON exports.
+func (w *Writer) Write(record interface{}, row []string) error {
+	if w.format == FormatCSV {
+		if err := w.csv.Write(row); err != nil {
+			return err
+		}
+	} else {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+
+		if w.rows > 0 {
+			w.c.Writer.WriteString(",")
+		}
+		w.c.Writer.WriteString("\n")
+		if _, err := w.c.Writer.Write(data); err != nil {
+			return err
+		}
+	}
+
+	w.rows++
+	if w.rows%FlushEvery == 0 {
+		w.flush()
+	}
+
+	return nil
+}
+
+// Finish the export. The row count and, when the store failed part way,
+// the error are sent as trailers, since the status is already sent.
+func (w *Writer) Close(err error) {
+	if w.format == FormatJSON {
+		w.c.Writer.WriteString("\n]\n")
+	}
+
+	w.flush()
+
+	w.c.Writer.Header().Set(RowsTrailer, strconv.Itoa(w.rows))
+	if err != nil {
+		w.c.Writer.Header().Set(ErrorTrailer, err.Error())
+	}
+}
+
+func (w *Writer) flush() {
+	if w.csv != nil {
+		w.csv.Flush()
+	}
+
+	w.c.Writer.Flush()
+}
+
+// Format a uint for a CSV row.
+func Uint(value uint) string {
+	return strconv.FormatUint(uint64(value), 10)
+}
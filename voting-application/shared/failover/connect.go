@@ -0,0 +1,175 @@
+package failover
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// How the services reach redis, set by REDIS_MODE.
+const (
+	// A single server at REDIS_URL.
+	ModeStandalone = "standalone"
+	// The master of REDIS_SENTINEL_MASTER, found through the sentinels of
+	// REDIS_SENTINEL_ADDRS, which is followed when it fails over.
+	ModeSentinel = "sentinel"
+	// The Redis Cluster of the nodes of REDIS_CLUSTER_ADDRS.
+	ModeCluster = "cluster"
+)
+
+// Pool is the connection pool configuration of REDIS_POOL_SIZE,
+// REDIS_MIN_IDLE_CONNS, REDIS_DIAL_TIMEOUT, REDIS_READ_TIMEOUT,
+// REDIS_WRITE_TIMEOUT, REDIS_POOL_TIMEOUT and REDIS_IDLE_TIMEOUT. Zero
+// values keep the defaults of the redis client: 10 connections per CPU and
+// timeouts of 5 seconds to dial and 3 seconds to read and write.
+type Pool struct {
+	PoolSize     int
+	MinIdleConns int
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	PoolTimeout  time.Duration
+	IdleTimeout  time.Duration
+}
+
+// Return the pool configuration of the environment, ignoring the values
+// that are not valid.
+func PoolFromEnv() Pool {
+	count := func(name string) int {
+		value := os.Getenv(name)
+		if value == "" {
+			return 0
+		}
+		count, err := strconv.Atoi(value)
+		if err != nil || count < 0 {
+			log.Printf("Ignoring %s=%q, it is not a number of connections", name, value)
+			return 0
+		}
+		return count
+	}
+
+	duration := func(name string) time.Duration {
+		value := os.Getenv(name)
+		if value == "" {
+			return 0
+		}
+		duration, err := time.ParseDuration(value)
+		if err != nil || duration < 0 {
+			log.Printf("Ignoring %s=%q, it is not a duration", name, value)
+			return 0
+		}
+		return duration
+	}
+
+	return Pool{
+		PoolSize:     count("REDIS_POOL_SIZE"),
+		MinIdleConns: count("REDIS_MIN_IDLE_CONNS"),
+		DialTimeout:  duration("REDIS_DIAL_TIMEOUT"),
+		ReadTimeout:  duration("REDIS_READ_TIMEOUT"),
+		WriteTimeout: duration("REDIS_WRITE_TIMEOUT"),
+		PoolTimeout:  duration("REDIS_POOL_TIMEOUT"),
+		IdleTimeout:  duration("REDIS_IDLE_TIMEOUT"),
+	}
+}
+
+// Split a comma-separated list of addresses.
+func splitAddrs(value string) []string {
+	var addrs []string
+	for _, addr := range strings.Split(value, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+
+	return addrs
+}
+
+// Connect to the redis of a service as REDIS_MODE says: the server at
+// addr by default, the master of a Sentinel deployment or a Redis Cluster,
+// with the pool of PoolFromEnv and the password of REDIS_PASSWORD. The
+// client rides out failovers like those of NewClient.
+func Connect(addr string) (redis.UniversalClient, error) {
+	pool := PoolFromEnv()
+	password := os.Getenv("REDIS_PASSWORD")
+
+	var client redis.UniversalClient
+	where := addr
+	switch mode := os.Getenv("REDIS_MODE"); mode {
+	case "", ModeStandalone:
+		client = redis.NewClient(&redis.Options{
+			Addr:         addr,
+			Password:     password,
+			PoolSize:     pool.PoolSize,
+			MinIdleConns: pool.MinIdleConns,
+			DialTimeout:  pool.DialTimeout,
+			ReadTimeout:  pool.ReadTimeout,
+			WriteTimeout: pool.WriteTimeout,
+			PoolTimeout:  pool.PoolTimeout,
+			IdleTimeout:  pool.IdleTimeout,
+		})
+	case ModeSentinel:
+		master := os.Getenv("REDIS_SENTINEL_MASTER")
+		sentinels := splitAddrs(os.Getenv("REDIS_SENTINEL_ADDRS"))
+		if master == "" || len(sentinels) == 0 {
+			return nil, errors.New("REDIS_MODE=sentinel needs REDIS_SENTINEL_MASTER and REDIS_SENTINEL_ADDRS")
+		}
+
+		where = "sentinel master " + master
+		client = redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       master,
+			SentinelAddrs:    sentinels,
+			SentinelPassword: os.Getenv("REDIS_SENTINEL_PASSWORD"),
+			Password:         password,
+			PoolSize:         pool.PoolSize,
+			MinIdleConns:     pool.MinIdleConns,
+			DialTimeout:      pool.DialTimeout,
+			ReadTimeout:      pool.ReadTimeout,
+			WriteTimeout:     pool.WriteTimeout,
+			PoolTimeout:      pool.PoolTimeout,
+			IdleTimeout:      pool.IdleTimeout,
+		})
+	case ModeCluster:
+		nodes := splitAddrs(os.Getenv("REDIS_CLUSTER_ADDRS"))
+		if len(nodes) == 0 {
+			nodes = []string{addr}
+		}
+
+		where = "cluster " + strings.Join(nodes, ",")
+		client = redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:        nodes,
+			Password:     password,
+			PoolSize:     pool.PoolSize,
+			MinIdleConns: pool.MinIdleConns,
+			DialTimeout:  pool.DialTimeout,
+			ReadTimeout:  pool.ReadTimeout,
+			WriteTimeout: pool.WriteTimeout,
+			PoolTimeout:  pool.PoolTimeout,
+			IdleTimeout:  pool.IdleTimeout,
+		})
+	default:
+		return nil, fmt.Errorf("unknown REDIS_MODE %q, use %s, %s or %s", mode, ModeStandalone, ModeSentinel, ModeCluster)
+	}
+
+	client.AddHook(&hook{client: client, addr: where, config: FromEnv()})
+
+	return client, nil
+}
+
+// Return where a client connects: the address of a server, the master of
+// a Sentinel deployment or the nodes of a cluster.
+func Addr(client redis.UniversalClient) string {
+	switch client := client.(type) {
+	case *redis.Client:
+		return client.Options().Addr
+	case *redis.ClusterClient:
+		return strings.Join(client.Options().Addrs, ",")
+	}
+
+	return ""
+}
@@ -0,0 +1,266 @@
+// Package failover keeps redis failovers from reaching the users of the
+// APIs as raw errors. The clients of NewClient carry a hook that
+// recognizes the errors a failover causes, such as READONLY from a replica
+// that was just demoted, MOVED from a cluster being resharded or a
+// connection reset by a node going away:
+//
+//   - idempotent reads are tried again with a growing wait, so a failover
+//     that completes within a second or two goes unnoticed;
+//   - writes, and reads that still fail, return an *Error, which the
+//     apierror package answers with 503 and a Retry-After header.
+//
+// Every retry and failure is logged and counted in the Stats reported on
+// the /health endpoints. Pipelines and transactions are not retried, their
+// commands are sent again by the caller.
+package failover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	DefaultRetries    = 3
+	DefaultRetryWait  = 200 * time.Millisecond
+	DefaultRetryAfter = 2 * time.Second
+)
+
+// The reply prefixes redis sends while a failover or resharding is going
+// on.
+var failoverPrefixes = []string{"READONLY ", "MOVED ", "ASK ", "LOADING ", "TRYAGAIN ", "CLUSTERDOWN ", "MASTERDOWN "}
+
+// The commands that read without changing anything, which are safe to send
+// again.
+var readCommands = map[string]bool{
+	"ping": true, "exists": true, "get": true, "mget": true, "strlen": true, "getrange": true,
+	"type": true, "ttl": true, "pttl": true, "keys": true, "scan": true,
+	"hget": true, "hmget": true, "hgetall": true, "hlen": true, "hexists": true, "hkeys": true, "hvals": true, "hscan": true,
+	"smembers": true, "sismember": true, "scard": true, "sinter": true, "sunion": true, "sdiff": true, "sscan": true,
+	"zrange": true, "zrevrange": true, "zrangebyscore": true, "zrevrangebyscore": true, "zrangebylex": true,
+	"zscore": true, "zcard": true, "zcount": true, "zrank": true, "zrevrank": true, "zscan": true,
+	"lrange": true, "llen": true, "lindex": true,
+	"xrange": true, "xrevrange": true, "xlen": true, "xinfo": true, "xpending": true,
+	"json.get": true, "json.mget": true, "json.type": true,
+}
+
+// Error is a redis command that failed because of a failover.
+type Error struct {
+	Command string
+	Err     error
+	// How long clients should wait before they try again.
+	After time.Duration
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("redis is failing over, %s failed: %v", e.Command, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Return how long clients should wait before they try again.
+func (e *Error) RetryAfter() time.Duration {
+	return e.After
+}
+
+// Report whether err is caused by a failover or resharding rather than by
+// the command: a redirection, a read-only or loading node, or a lost
+// connection.
+func IsFailoverError(err error) bool {
+	if err == nil || errors.Is(err, redis.Nil) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var failoverErr *Error
+	if errors.As(err, &failoverErr) {
+		return true
+	}
+
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	message := err.Error()
+	for _, prefix := range failoverPrefixes {
+		if strings.HasPrefix(message, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Stats are the failover events of the process since it started.
+type Stats struct {
+	// Reads tried again, and those that then succeeded.
+	Retries   uint64 `json:"retries"`
+	Recovered uint64 `json:"recovered"`
+	// Commands that failed because of a failover.
+	FailedReads  uint64     `json:"failedReads"`
+	FailedWrites uint64     `json:"failedWrites"`
+	LastEventAt  *time.Time `json:"lastEventAt,omitempty"`
+	LastError    string     `json:"lastError,omitempty"`
+}
+
+var (
+	statsLock sync.Mutex
+	stats     Stats
+)
+
+// Return the failover events of every client of the process.
+func CurrentStats() Stats {
+	statsLock.Lock()
+	defer statsLock.Unlock()
+
+	return stats
+}
+
+// Count a failover event.
+func record(update func(stats *Stats), err error) {
+	statsLock.Lock()
+	defer statsLock.Unlock()
+
+	update(&stats)
+	now := time.Now().UTC()
+	stats.LastEventAt = &now
+	if err != nil {
+		stats.LastError = err.Error()
+	}
+}
+
+// Config is how a client rides out a failover.
+type Config struct {
+	// How many times a read is tried again, zero turns retries off.
+	Retries int
+	// The wait before the first retry, doubled on each attempt.
+	RetryWait time.Duration
+	// The Retry-After of the errors returned to the APIs.
+	RetryAfter time.Duration
+}
+
+// Return the configuration of REDIS_FAILOVER_RETRIES and
+// REDIS_FAILOVER_RETRY_WAIT, the defaults when they are not set or not
+// valid.
+func FromEnv() Config {
+	config := Config{Retries: DefaultRetries, RetryWait: DefaultRetryWait, RetryAfter: DefaultRetryAfter}
+
+	if value := os.Getenv("REDIS_FAILOVER_RETRIES"); value != "" {
+		if retries, err := strconv.Atoi(value); err == nil && retries >= 0 {
+			config.Retries = retries
+		} else {
+			log.Printf("Ignoring REDIS_FAILOVER_RETRIES=%q, it is not a number of retries", value)
+		}
+	}
+
+	if value := os.Getenv("REDIS_FAILOVER_RETRY_WAIT"); value != "" {
+		if wait, err := time.ParseDuration(value); err == nil && wait > 0 {
+			config.RetryWait = wait
+		} else {
+			log.Printf("Ignoring REDIS_FAILOVER_RETRY_WAIT=%q, it is not a duration", value)
+		}
+	}
+
+	return config
+}
+
+// Create a redis client that rides out failovers as configured by
+// FromEnv.
+func NewClient(options *redis.Options) *redis.Client {
+	client := redis.NewClient(options)
+	client.AddHook(&hook{client: client, addr: options.Addr, config: FromEnv()})
+
+	return client
+}
+
+type retryKey struct{}
+
+// hook retries the reads and wraps the errors of the commands of a client.
+type hook struct {
+	client redis.UniversalClient
+	// Where the client connects, for the logs.
+	addr   string
+	config Config
+}
+
+func (h *hook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (h *hook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	err := cmd.Err()
+	if !IsFailoverError(err) {
+		return nil
+	}
+
+	// A retry below runs the hooks again; it is not retried in turn.
+	if ctx.Value(retryKey{}) != nil {
+		return nil
+	}
+
+	name := strings.ToLower(cmd.Name())
+	if !readCommands[name] {
+		record(func(stats *Stats) { stats.FailedWrites++ }, err)
+		log.Printf("Redis failover: %s failed at %s: %v", name, h.addr, err)
+		return &Error{Command: name, Err: err, After: h.config.RetryAfter}
+	}
+
+	retryCtx := context.WithValue(ctx, retryKey{}, true)
+	wait := h.config.RetryWait
+	for attempt := 1; attempt <= h.config.Retries; attempt++ {
+		record(func(stats *Stats) { stats.Retries++ }, err)
+		log.Printf("Redis failover: retrying %s at %s in %s (%d/%d): %v", name, h.addr, wait, attempt, h.config.Retries, err)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			record(func(stats *Stats) { stats.FailedReads++ }, err)
+			return &Error{Command: name, Err: err, After: h.config.RetryAfter}
+		case <-timer.C:
+		}
+
+		err = h.client.Process(retryCtx, cmd)
+		if err == nil || !IsFailoverError(err) {
+			record(func(stats *Stats) { stats.Recovered++ }, nil)
+			return nil
+		}
+
+		wait *= 2
+	}
+
+	record(func(stats *Stats) { stats.FailedReads++ }, err)
+	return &Error{Command: name, Err: err, After: h.config.RetryAfter}
+}
+
+func (h *hook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (h *hook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	for _, cmd := range cmds {
+		if err := cmd.Err(); IsFailoverError(err) {
+			record(func(stats *Stats) { stats.FailedWrites++ }, err)
+			log.Printf("Redis failover: pipeline failed at %s: %v", h.addr, err)
+			return &Error{Command: "pipeline", Err: err, After: h.config.RetryAfter}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,168 @@
+// Package healthkit keeps the request metrics every API reports on its
+// /health endpoint: the calls and errors since boot, the total and average
+// request time, and the window of the recent requests. A service creates
+// its Metrics once, serves its middleware, and adds its own sections to
+// the report:
+//
+//	metrics := healthkit.New(
+//		healthkit.WithCounter("votesCast", healthkit.Any(
+//			healthkit.Succeeded(http.MethodPost, "/votes/:id"),
+//			healthkit.Succeeded(http.MethodPost, "/votes/:id/confirm"),
+//		)),
+//	)
+//
+//	bootstrap.WithMiddleware(metrics.Middleware())
+//
+//	report := metrics.Report()
+//	report["redisShards"] = cache.ShardHealth()
+//	c.JSON(http.StatusOK, report)
+package healthkit
+
+import (
+	"sync"
+	"time"
+
+	"shared/requeststats"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics are the request metrics of an API. They are safe for concurrent
+// use.
+type Metrics struct {
+	bootTime time.Time
+	requests *requeststats.Window
+
+	lock             sync.Mutex
+	totalCalls       uint64
+	errorCalls       uint64
+	totalRequestTime time.Duration
+	// The custom counters; a counter with a rule counts the requests it
+	// matches, the others are counted with Add.
+	counters map[string]uint64
+	rules    map[string]func(c *gin.Context) bool
+}
+
+// Option configures the Metrics of an API.
+type Option func(*Metrics)
+
+// Add a counter to the report. With a rule, the middleware counts every
+// request the rule matches once it was handled; with a nil rule the
+// service counts with Add. The counter is reported from 0.
+func WithCounter(name string, rule func(c *gin.Context) bool) Option {
+	return func(m *Metrics) {
+		m.counters[name] = 0
+		if rule != nil {
+			m.rules[name] = rule
+		}
+	}
+}
+
+// Return a rule that matches the requests to a route, as registered with
+// gin, that succeeded.
+func Succeeded(method, path string) func(c *gin.Context) bool {
+	return func(c *gin.Context) bool {
+		return c.Request.Method == method && c.FullPath() == path && c.Writer.Status() < 400
+	}
+}
+
+// Return a rule that matches the requests any of rules matches, for a
+// counter of several routes.
+func Any(rules ...func(c *gin.Context) bool) func(c *gin.Context) bool {
+	return func(c *gin.Context) bool {
+		for _, rule := range rules {
+			if rule(c) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Return new Metrics, booted now.
+func New(options ...Option) *Metrics {
+	m := &Metrics{
+		bootTime: time.Now(),
+		requests: requeststats.FromEnv(),
+		counters: make(map[string]uint64),
+		rules:    make(map[string]func(c *gin.Context) bool),
+	}
+	for _, option := range options {
+		option(m)
+	}
+
+	return m
+}
+
+// Add delta to a custom counter, which is added to the report if it was
+// not declared with WithCounter.
+func (m *Metrics) Add(name string, delta uint64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.counters[name] += delta
+}
+
+// The middleware that counts every request once it was handled.
+func (m *Metrics) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Record the start time of the request.
+		start := time.Now()
+
+		// Process the request.
+		c.Next()
+
+		// Calculate the request duration.
+		duration := time.Since(start)
+		status := c.Writer.Status()
+
+		m.lock.Lock()
+		m.totalCalls++
+		if status >= 400 {
+			m.errorCalls++
+		}
+		m.totalRequestTime += duration
+		for name, rule := range m.rules {
+			if rule(c) {
+				m.counters[name]++
+			}
+		}
+		m.lock.Unlock()
+
+		// Keep the request in the window of the recent requests.
+		m.requests.Record(requeststats.Route(c), status, duration)
+	}
+}
+
+// Return the metrics section of a /health response, which the service
+// adds its own fields to.
+func (m *Metrics) Report() gin.H {
+	m.lock.Lock()
+	totalCalls, errorCalls, totalRequestTime := m.totalCalls, m.errorCalls, m.totalRequestTime
+	counters := make(map[string]uint64, len(m.counters))
+	for name, count := range m.counters {
+		counters[name] = count
+	}
+	m.lock.Unlock()
+
+	averageRequestTime := time.Duration(0)
+	if totalCalls > 0 {
+		averageRequestTime = totalRequestTime / time.Duration(totalCalls)
+	}
+
+	report := gin.H{
+		"status":             "ok",
+		"uptime":             time.Since(m.bootTime).String(),
+		"totalAPICalls":      totalCalls,
+		"totalAPICallsError": errorCalls,
+		"bootTime":           m.bootTime,
+		"totalRequestTime":   totalRequestTime.String(),
+		"averageRequestTime": averageRequestTime.String(),
+		"recentRequests":     m.requests.Summary(),
+	}
+	if len(counters) > 0 {
+		report["counters"] = counters
+	}
+
+	return report
+}
@@ -0,0 +1,214 @@
+package healthkit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// Return a router with the middleware of m and the routes the tests call:
+// POST /votes/:id and POST /votes/:id/confirm answer the status of
+// ?status=, 201 by default, and GET /votes/:id answers 200.
+func newRouter(m *Metrics) *gin.Engine {
+	r := gin.New()
+	r.Use(m.Middleware())
+
+	answer := func(c *gin.Context) {
+		switch c.Query("status") {
+		case "400":
+			c.Status(http.StatusBadRequest)
+		case "500":
+			c.Status(http.StatusInternalServerError)
+		default:
+			c.Status(http.StatusCreated)
+		}
+	}
+	r.POST("/votes/:id", answer)
+	r.POST("/votes/:id/confirm", answer)
+	r.GET("/votes/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	return r
+}
+
+func send(r *gin.Engine, method, path string) {
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, path, nil))
+}
+
+func counters(t *testing.T, m *Metrics) map[string]uint64 {
+	t.Helper()
+
+	counters, ok := m.Report()["counters"].(map[string]uint64)
+	if !ok {
+		t.Fatalf("the report has no counters: %v", m.Report())
+	}
+
+	return counters
+}
+
+func TestReportCountsCallsAndErrors(t *testing.T) {
+	m := New()
+	r := newRouter(m)
+
+	send(r, http.MethodPost, "/votes/1")
+	send(r, http.MethodPost, "/votes/2?status=400")
+	send(r, http.MethodPost, "/votes/3?status=500")
+	send(r, http.MethodGet, "/votes/1")
+
+	report := m.Report()
+	if got := report["totalAPICalls"]; got != uint64(4) {
+		t.Errorf("totalAPICalls = %v, want 4", got)
+	}
+	if got := report["totalAPICallsError"]; got != uint64(2) {
+		t.Errorf("totalAPICallsError = %v, want 2", got)
+	}
+	if got := report["status"]; got != "ok" {
+		t.Errorf("status = %v, want ok", got)
+	}
+	for _, field := range []string{"uptime", "bootTime", "totalRequestTime", "averageRequestTime", "recentRequests"} {
+		if _, ok := report[field]; !ok {
+			t.Errorf("the report has no %s", field)
+		}
+	}
+
+	// Without counters the report has no counters section.
+	if _, ok := report["counters"]; ok {
+		t.Errorf("the report has counters without any declared: %v", report["counters"])
+	}
+}
+
+func TestReportAverageRequestTimeWithoutCalls(t *testing.T) {
+	report := New().Report()
+
+	if got := report["totalAPICalls"]; got != uint64(0) {
+		t.Errorf("totalAPICalls = %v, want 0", got)
+	}
+	if got := report["averageRequestTime"]; got != "0s" {
+		t.Errorf("averageRequestTime = %v, want 0s", got)
+	}
+}
+
+func TestSucceededMatchesTheRouteAndStatus(t *testing.T) {
+	m := New(WithCounter("votesCast", Succeeded(http.MethodPost, "/votes/:id")))
+	r := newRouter(m)
+
+	send(r, http.MethodPost, "/votes/1")
+	send(r, http.MethodPost, "/votes/2")
+	// A failed request, another method, another route and an unknown path
+	// are not counted.
+	send(r, http.MethodPost, "/votes/3?status=400")
+	send(r, http.MethodGet, "/votes/1")
+	send(r, http.MethodPost, "/votes/1/confirm")
+	send(r, http.MethodPost, "/unknown")
+
+	if got := counters(t, m)["votesCast"]; got != 2 {
+		t.Fatalf("votesCast = %d, want 2", got)
+	}
+}
+
+func TestAnyMatchesEveryRule(t *testing.T) {
+	m := New(WithCounter("votesCast", Any(
+		Succeeded(http.MethodPost, "/votes/:id"),
+		Succeeded(http.MethodPost, "/votes/:id/confirm"),
+	)))
+	r := newRouter(m)
+
+	send(r, http.MethodPost, "/votes/1")
+	send(r, http.MethodPost, "/votes/2/confirm")
+	send(r, http.MethodPost, "/votes/3/confirm?status=500")
+	send(r, http.MethodGet, "/votes/1")
+
+	if got := counters(t, m)["votesCast"]; got != 2 {
+		t.Fatalf("votesCast = %d, want 2", got)
+	}
+
+	// Any of no rules matches nothing.
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	if Any()(c) {
+		t.Fatal("Any() matched a request")
+	}
+}
+
+func TestWithCounterIsReportedFromZero(t *testing.T) {
+	m := New(
+		WithCounter("votesCast", Succeeded(http.MethodPost, "/votes/:id")),
+		WithCounter("votesReceived", nil),
+	)
+
+	got := counters(t, m)
+	if count, ok := got["votesCast"]; !ok || count != 0 {
+		t.Errorf("votesCast = %d, %v, want 0 reported", count, ok)
+	}
+	if count, ok := got["votesReceived"]; !ok || count != 0 {
+		t.Errorf("votesReceived = %d, %v, want 0 reported", count, ok)
+	}
+
+	// A counter without a rule is not counted by the middleware.
+	send(newRouter(m), http.MethodPost, "/votes/1")
+	if count := counters(t, m)["votesReceived"]; count != 0 {
+		t.Errorf("votesReceived = %d after a request, want 0", count)
+	}
+}
+
+func TestAdd(t *testing.T) {
+	m := New(WithCounter("votesReceived", nil))
+
+	m.Add("votesReceived", 2)
+	m.Add("votesReceived", 3)
+	// A counter that was not declared is added to the report.
+	m.Add("webhooksSent", 1)
+
+	got := counters(t, m)
+	if got["votesReceived"] != 5 {
+		t.Errorf("votesReceived = %d, want 5", got["votesReceived"])
+	}
+	if got["webhooksSent"] != 1 {
+		t.Errorf("webhooksSent = %d, want 1", got["webhooksSent"])
+	}
+}
+
+func TestReportCopiesTheCounters(t *testing.T) {
+	m := New(WithCounter("votesReceived", nil))
+
+	report := counters(t, m)
+	report["votesReceived"] = 10
+
+	if got := counters(t, m)["votesReceived"]; got != 0 {
+		t.Fatalf("votesReceived = %d after changing a report, want 0", got)
+	}
+}
+
+// Count from many goroutines at once; run with -race.
+func TestConcurrentCounting(t *testing.T) {
+	m := New(WithCounter("votesCast", Succeeded(http.MethodPost, "/votes/:id")))
+	r := newRouter(m)
+
+	const workers, requests = 8, 50
+	var wait sync.WaitGroup
+	for worker := 0; worker < workers; worker++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for i := 0; i < requests; i++ {
+				send(r, http.MethodPost, "/votes/1")
+				m.Add("votesReceived", 1)
+				m.Report()
+			}
+		}()
+	}
+	wait.Wait()
+
+	got := counters(t, m)
+	if got["votesCast"] != workers*requests || got["votesReceived"] != workers*requests {
+		t.Fatalf("counters = %v, want %d of each", got, workers*requests)
+	}
+	if calls := m.Report()["totalAPICalls"]; calls != uint64(workers*requests) {
+		t.Fatalf("totalAPICalls = %v, want %d", calls, workers*requests)
+	}
+}
@@ -0,0 +1,230 @@
+// Package keyaudit audits the redis keyspace the services share: the keys
+// of the documents that are malformed, unreadable with the current schema,
+// stored under a legacy key or duplicated, and the keys outside of every
+// prefix the application writes, as left by someone trying commands by
+// hand. The documents are audited by their repository; this package holds
+// what the audits of every service have in common.
+package keyaudit
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"shared/keyring"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// How many keys a finding lists. Every key is counted.
+	MaxListedKeys = 100
+	ScanBatchSize = 500
+)
+
+// What an audit fixes, set by the ?fix= query parameter.
+const (
+	// Only report.
+	FixNone = "none"
+	// Move the documents stored under a legacy key to the key of their
+	// ID and delete their duplicates, as the startup migration does.
+	FixLegacy = "legacy"
+	// Also delete the malformed and unreadable document keys. The keys
+	// outside of the keyspace are never deleted.
+	FixAll = "all"
+)
+
+// What a service does with the legacy and duplicated document keys when it
+// starts, set by REDIS_KEY_MIGRATION.
+const (
+	MigrationOff     = "off"
+	MigrationReport  = "report"
+	MigrationMigrate = "migrate"
+)
+
+// Keyspace lists the key prefixes written by the services, which share one
+// redis. A key that starts with none of them was not written by the
+// application.
+var Keyspace = []string{
+	// voter-api
+	"voter:", "voter-search",
+	// poll-api
+	"poll:", "poll-version:", "poll-tag:", "poll-word:", "poll-eligible:", "series:", "audit:poll", "events:polls",
+	// votes-api
+	"votes:", "vote-poll:", "vote-voter:", "tally:", "vote-stats:", "vote-hold:", "participation:", "embargo:", "result-snapshot:", "ballot-tokens:", "idempotency:", "receipt-secret", "voter-hash-secret", "privacy-noise-secret", "events:votes",
+	// results-api
+	"results:",
+	// The job scheduler, the webhooks and the API keys of every service.
+	"leader:", "jobs:", "webhooks", "apikeys",
+}
+
+// Finding counts the keys with a problem and lists the first
+// MaxListedKeys of them.
+type Finding struct {
+	Count int      `json:"count"`
+	Keys  []string `json:"keys,omitempty"`
+}
+
+// Record a key with the problem.
+func (f *Finding) Add(key string) {
+	f.Count++
+	if len(f.Keys) < MaxListedKeys {
+		f.Keys = append(f.Keys, key)
+	}
+}
+
+// Documents is what the audit of the documents of a kind found.
+type Documents struct {
+	Kind    string `json:"kind"`
+	Scanned int    `json:"scanned"`
+	// Keys whose ID is not a number, such as voter:abc.
+	Malformed Finding `json:"malformed"`
+	// Documents that cannot be decoded into the current schema, or that
+	// have no ID, such as a hash written where a document is expected.
+	Unreadable Finding `json:"unreadable"`
+	// Documents stored under another key than the key of their ID, such
+	// as voter:007 for voter 7.
+	Legacy Finding `json:"legacy"`
+	// Legacy keys of a document that is also stored under the key of its
+	// ID, which is the one kept.
+	Duplicates Finding `json:"duplicates"`
+	// How many keys were moved or deleted.
+	Fixed int `json:"fixed"`
+	// The keys that could not be fixed, with why.
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// Record a key that could not be fixed.
+func (d *Documents) Failed(key string, err error) {
+	if d.Errors == nil {
+		d.Errors = make(map[string]string)
+	}
+	d.Errors[key] = err.Error()
+}
+
+// Report is the result of the audit of the keyspace of a service.
+type Report struct {
+	Fix       string    `json:"fix"`
+	Documents Documents `json:"documents"`
+	// The keys outside of the keyspace, left as they are.
+	Unknown Finding `json:"unknown"`
+}
+
+// Return the fix named by fix, FixNone when it is empty.
+func ParseFix(fix string) (string, error) {
+	switch fix {
+	case "":
+		return FixNone, nil
+	case FixNone, FixLegacy, FixAll:
+		return fix, nil
+	}
+
+	return "", fmt.Errorf("unknown fix %q, use %s, %s or %s", fix, FixNone, FixLegacy, FixAll)
+}
+
+// Return the prefixes of the keyspace: Keyspace and the comma-separated
+// prefixes of REDIS_KEY_PREFIXES, such as the names of event streams that
+// were configured.
+func KnownPrefixes() []string {
+	prefixes := append([]string{}, Keyspace...)
+
+	for _, prefix := range strings.Split(os.Getenv("REDIS_KEY_PREFIXES"), ",") {
+		if prefix = strings.TrimSpace(prefix); prefix != "" {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+
+	return prefixes
+}
+
+// Return the keys that start with none of the prefixes, on primary, the
+// redis of REDIS_URL, and on the shards of ring.
+func Unknown(ctx context.Context, primary redis.UniversalClient, ring *keyring.Ring, prefixes []string) (Finding, error) {
+	var unknown Finding
+
+	scan := func(client *redis.Client) error {
+		iter := client.Scan(ctx, 0, "*", ScanBatchSize).Iterator()
+
+		for iter.Next(ctx) {
+			if !hasAnyPrefix(iter.Val(), prefixes) {
+				unknown.Add(iter.Val())
+			}
+		}
+
+		return iter.Err()
+	}
+
+	// A cluster is scanned on each of its masters, and a node that is both
+	// the primary and a shard only once.
+	scanned := make(map[*redis.Client]bool)
+
+	nodes, err := keyring.Masters(primary)
+	if err != nil {
+		return unknown, err
+	}
+	for _, node := range nodes {
+		scanned[node] = true
+		if err := scan(node); err != nil {
+			return unknown, err
+		}
+	}
+
+	err = ring.ForEachShard(func(client *redis.Client) error {
+		if scanned[client] {
+			return nil
+		}
+		scanned[client] = true
+		return scan(client)
+	})
+
+	return unknown, err
+}
+
+func hasAnyPrefix(key string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Run the startup migration of REDIS_KEY_MIGRATION with audit, the audit
+// of the keyspace of a service: by default the legacy and duplicated
+// document keys are fixed, with report they are only logged and with off
+// nothing is scanned.
+func Migrate(audit func(fix string) (Report, error)) {
+	var fix string
+
+	switch mode := os.Getenv("REDIS_KEY_MIGRATION"); mode {
+	case MigrationOff:
+		return
+	case MigrationReport:
+		fix = FixNone
+	case "", MigrationMigrate:
+		fix = FixLegacy
+	default:
+		log.Printf("Unknown REDIS_KEY_MIGRATION %q, use %s, %s or %s", mode, MigrationOff, MigrationReport, MigrationMigrate)
+		return
+	}
+
+	report, err := audit(fix)
+	if err != nil {
+		log.Println("Error auditing redis keys: ", err)
+		return
+	}
+
+	documents := report.Documents
+	if documents.Legacy.Count > 0 || documents.Duplicates.Count > 0 {
+		log.Printf("Redis key migration: %d legacy and %d duplicated %s keys, fixed %d", documents.Legacy.Count, documents.Duplicates.Count, documents.Kind, documents.Fixed)
+	}
+	if documents.Malformed.Count > 0 || documents.Unreadable.Count > 0 {
+		log.Printf("Redis key audit: %d malformed and %d unreadable %s keys, fix them with POST /admin/redis/audit?fix=all", documents.Malformed.Count, documents.Unreadable.Count, documents.Kind)
+	}
+	if report.Unknown.Count > 0 {
+		log.Printf("Redis key audit: %d keys outside of the keyspace of the application", report.Unknown.Count)
+	}
+}
@@ -0,0 +1,424 @@
+// Package keyring spreads the voter, poll and vote documents over several
+// redis nodes with consistent hashing, for deployments that outgrew a
+// single node but do not run Redis Cluster. The nodes are listed in
+// REDIS_SHARDS:
+//
+//	REDIS_SHARDS=a=redis-a:6379,b=redis-b:6379,c=redis-c:6379
+//
+// A key belongs to the node whose points on the ring follow the hash of
+// the key; the points are derived from the node names, so a node can move
+// to another address without moving its keys. When part of a key is
+// between braces only that part is hashed, so related keys can be kept on
+// the same node. Without REDIS_SHARDS the ring has a single node, the
+// primary client of the cache.
+//
+// Nodes that are being removed go to REDIS_DRAINING_SHARDS: they own no
+// keys any more, but their keys are still listed and Rebalance moves them
+// to the nodes that own them now.
+package keyring
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"shared/failover"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	VirtualNodes          = 160
+	DefaultHealthInterval = 10 * time.Second
+	PrimaryShardName      = "primary"
+	rebalanceScanCount    = 500
+)
+
+// Shard is a redis node of the ring.
+type Shard struct {
+	Name   string
+	Addr   string
+	client redis.UniversalClient
+
+	lock      sync.Mutex
+	healthy   bool
+	lastCheck time.Time
+	lastError string
+	latency   time.Duration
+}
+
+// ShardHealth is the result of the last health check of a shard.
+type ShardHealth struct {
+	Name      string    `json:"name"`
+	Addr      string    `json:"addr"`
+	Draining  bool      `json:"draining,omitempty"`
+	Healthy   bool      `json:"healthy"`
+	LastCheck time.Time `json:"lastCheck"`
+	LastError string    `json:"lastError,omitempty"`
+	LatencyMs float64   `json:"latencyMs"`
+}
+
+// Ring routes keys to the shards.
+type Ring struct {
+	shards   []*Shard
+	draining []*Shard
+	points   []uint32
+	owners   []*Shard
+}
+
+// Build the ring of REDIS_SHARDS and REDIS_DRAINING_SHARDS. The entries
+// are addresses or name=addr pairs; a shard at the address of the primary
+// reuses the primary client. Without REDIS_SHARDS the ring only holds the
+// primary.
+func FromEnv(ctx context.Context, primary redis.UniversalClient) (*Ring, error) {
+	names := make(map[string]bool)
+
+	draining, err := parseShards(ctx, primary, os.Getenv("REDIS_DRAINING_SHARDS"), names)
+	if err != nil {
+		return nil, err
+	}
+
+	value := strings.TrimSpace(os.Getenv("REDIS_SHARDS"))
+	if value == "" {
+		ring := New([]*Shard{{Name: PrimaryShardName, Addr: failover.Addr(primary), client: primary, healthy: true}})
+		ring.draining = draining
+		return ring, nil
+	}
+
+	shards, err := parseShards(ctx, primary, value, names)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(shards) == 0 {
+		return nil, errors.New("REDIS_SHARDS does not list any shard")
+	}
+
+	ring := New(shards)
+	ring.draining = draining
+
+	return ring, nil
+}
+
+// Connect to the shards of a REDIS_SHARDS list. names holds the names that
+// are already taken.
+func parseShards(ctx context.Context, primary redis.UniversalClient, value string, names map[string]bool) ([]*Shard, error) {
+	var shards []*Shard
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, addr := entry, entry
+		if i := strings.Index(entry, "="); i >= 0 {
+			name, addr = entry[:i], entry[i+1:]
+		}
+
+		if names[name] {
+			return nil, fmt.Errorf("redis shard %q is listed twice", name)
+		}
+		names[name] = true
+
+		client := primary
+		if addr != failover.Addr(primary) {
+			client = failover.NewClient(&redis.Options{Addr: addr})
+		}
+
+		if err := client.Ping(ctx).Err(); err != nil {
+			log.Printf("Error connecting to redis shard %s at %s: %v", name, addr, err)
+			return nil, err
+		}
+
+		shards = append(shards, &Shard{Name: name, Addr: addr, client: client, healthy: true})
+	}
+
+	return shards, nil
+}
+
+// Build a ring of the shards.
+func New(shards []*Shard) *Ring {
+	ring := &Ring{shards: shards}
+
+	type point struct {
+		hash  uint32
+		shard *Shard
+	}
+
+	points := make([]point, 0, len(shards)*VirtualNodes)
+	for _, shard := range shards {
+		for i := 0; i < VirtualNodes; i++ {
+			points = append(points, point{hash: hash(shard.Name + "#" + strconv.Itoa(i)), shard: shard})
+		}
+	}
+
+	sort.Slice(points, func(i, j int) bool { return points[i].hash < points[j].hash })
+
+	ring.points = make([]uint32, len(points))
+	ring.owners = make([]*Shard, len(points))
+	for i, p := range points {
+		ring.points[i] = p.hash
+		ring.owners[i] = p.shard
+	}
+
+	return ring
+}
+
+// Hash a key or a point of the ring, with the first 32 bits of its MD5
+// like ketama, which spreads short similar names evenly.
+func hash(value string) uint32 {
+	sum := md5.Sum([]byte(value))
+	return binary.LittleEndian.Uint32(sum[:4])
+}
+
+// Return the part of a key that is hashed: the text between the first
+// braces when there is any, the whole key otherwise.
+func hashTag(key string) string {
+	if start := strings.Index(key, "{"); start >= 0 {
+		if end := strings.Index(key[start+1:], "}"); end > 0 {
+			return key[start+1 : start+1+end]
+		}
+	}
+
+	return key
+}
+
+// Return the shard a key belongs to.
+func (r *Ring) Shard(key string) *Shard {
+	if len(r.shards) == 1 {
+		return r.shards[0]
+	}
+
+	hash := hash(hashTag(key))
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= hash })
+	if i == len(r.points) {
+		i = 0
+	}
+
+	return r.owners[i]
+}
+
+// Return the client of the shard a key belongs to.
+func (r *Ring) Client(key string) redis.UniversalClient {
+	return r.Shard(key).client
+}
+
+// Return the number of shards that own keys.
+func (r *Ring) Len() int {
+	return len(r.shards)
+}
+
+// Return every shard, the draining ones last.
+func (r *Ring) all() []*Shard {
+	return append(append([]*Shard{}, r.shards...), r.draining...)
+}
+
+// Call fn with the client of every shard, draining ones included, for
+// commands such as KEYS that have to look at every node. A shard that is a
+// Redis Cluster is called once for each of its masters, one at a time.
+func (r *Ring) ForEachShard(fn func(client *redis.Client) error) error {
+	for _, shard := range r.all() {
+		nodes, err := Masters(shard.client)
+		if err != nil {
+			return fmt.Errorf("redis shard %s: %w", shard.Name, err)
+		}
+
+		for _, node := range nodes {
+			if err := fn(node); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Return the clients of the masters of a cluster, or the client itself
+// when it talks to a single server.
+func Masters(client redis.UniversalClient) ([]*redis.Client, error) {
+	switch client := client.(type) {
+	case *redis.Client:
+		return []*redis.Client{client}, nil
+	case *redis.ClusterClient:
+		var lock sync.Mutex
+		var nodes []*redis.Client
+		err := client.ForEachMaster(context.Background(), func(ctx context.Context, node *redis.Client) error {
+			lock.Lock()
+			defer lock.Unlock()
+			nodes = append(nodes, node)
+			return nil
+		})
+		return nodes, err
+	}
+
+	return nil, fmt.Errorf("unsupported redis client %T", client)
+}
+
+// Check that every shard is reachable.
+func (r *Ring) Ping(ctx context.Context) error {
+	for _, shard := range r.all() {
+		if err := shard.client.Ping(ctx).Err(); err != nil {
+			return fmt.Errorf("redis shard %s: %w", shard.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// Ping every shard now and record the result.
+func (r *Ring) CheckHealth(ctx context.Context) {
+	for _, shard := range r.all() {
+		start := time.Now()
+		err := shard.client.Ping(ctx).Err()
+
+		shard.lock.Lock()
+		shard.healthy = err == nil
+		shard.lastCheck = time.Now()
+		shard.latency = time.Since(start)
+		shard.lastError = ""
+		if err != nil {
+			shard.lastError = err.Error()
+		}
+		shard.lock.Unlock()
+	}
+}
+
+// Check the health of the shards every interval until ctx is done.
+func (r *Ring) StartHealthChecks(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			r.CheckHealth(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Return the result of the last health check of every shard.
+func (r *Ring) Health() []ShardHealth {
+	shards := r.all()
+	health := make([]ShardHealth, len(shards))
+	for i, shard := range shards {
+		shard.lock.Lock()
+		health[i] = ShardHealth{
+			Name:      shard.Name,
+			Addr:      shard.Addr,
+			Draining:  i >= len(r.shards),
+			Healthy:   shard.healthy,
+			LastCheck: shard.lastCheck,
+			LastError: shard.lastError,
+			LatencyMs: float64(shard.latency.Microseconds()) / 1000,
+		}
+		shard.lock.Unlock()
+	}
+
+	return health
+}
+
+// RebalanceReport describes the keys a rebalance found on the wrong shard.
+type RebalanceReport struct {
+	DryRun    bool              `json:"dryRun"`
+	Scanned   int               `json:"scanned"`
+	Misplaced int               `json:"misplaced"`
+	Moved     int               `json:"moved"`
+	Moves     map[string]int    `json:"moves"`
+	Errors    map[string]string `json:"errors,omitempty"`
+}
+
+// Move the keys matching pattern to the shard they belong to, after
+// shards were added or moved to draining. Keys are copied with DUMP and RESTORE and
+// then deleted from the old shard; a key that was already written again on
+// its new shard keeps the newer value. With dryRun the keys are only
+// counted.
+func (r *Ring) Rebalance(ctx context.Context, pattern string, dryRun bool) (RebalanceReport, error) {
+	report := RebalanceReport{DryRun: dryRun, Moves: make(map[string]int)}
+
+	for _, shard := range r.all() {
+		nodes, err := Masters(shard.client)
+		if err != nil {
+			return report, fmt.Errorf("redis shard %s: %w", shard.Name, err)
+		}
+
+		for _, node := range nodes {
+			if err := r.rebalanceNode(ctx, shard, node, pattern, dryRun, &report); err != nil {
+				return report, fmt.Errorf("redis shard %s: %w", shard.Name, err)
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// Move the keys matching pattern of a node of shard, one of the masters
+// when the shard is a cluster, to the shard they belong to.
+func (r *Ring) rebalanceNode(ctx context.Context, shard *Shard, node *redis.Client, pattern string, dryRun bool, report *RebalanceReport) error {
+	iter := node.Scan(ctx, 0, pattern, rebalanceScanCount).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		report.Scanned++
+
+		owner := r.Shard(key)
+		if owner == shard {
+			continue
+		}
+
+		report.Misplaced++
+		if dryRun {
+			report.Moves[shard.Name+"->"+owner.Name]++
+			continue
+		}
+
+		if err := moveKey(ctx, shard.client, owner.client, key); err != nil {
+			if report.Errors == nil {
+				report.Errors = make(map[string]string)
+			}
+			report.Errors[key] = err.Error()
+			continue
+		}
+
+		report.Moved++
+		report.Moves[shard.Name+"->"+owner.Name]++
+	}
+
+	return iter.Err()
+}
+
+// Copy a key to another shard and delete it from its old one.
+func moveKey(ctx context.Context, from, to redis.UniversalClient, key string) error {
+	data, err := from.Dump(ctx, key).Result()
+	if err == redis.Nil {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	ttl, err := from.PTTL(ctx, key).Result()
+	if err != nil {
+		return err
+	}
+	if ttl < 0 {
+		ttl = 0
+	}
+
+	if err := to.Restore(ctx, key, ttl, data).Err(); err != nil && !strings.HasPrefix(err.Error(), "BUSYKEY") {
+		return err
+	}
+
+	return from.Del(ctx, key).Err()
+}
@@ -0,0 +1,120 @@
+// Package listorder orders the items of the list endpoints. Lists are in
+// ID order by default, so identical requests return identical arrays; the
+// ?sort= query parameter orders them by other fields instead:
+//
+//	GET /voters?sort=lastName,firstName
+//	GET /polls?sort=-pollId
+//
+// Fields are separated by commas and a leading - orders by a field in
+// descending order. Items that compare equal on every field keep their ID
+// order.
+package listorder
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"shared/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Fields are the fields a list can be ordered by, by their name in the
+// ?sort= query parameter. Each compares two items and returns a negative
+// number, zero or a positive number.
+type Fields[T any] map[string]func(a, b T) int
+
+type ordered interface {
+	~int | ~int64 | ~uint | ~uint32 | ~uint64 | ~string
+}
+
+// Compare two values of a field.
+func Compare[V ordered](a, b V) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
+
+// Compare two optional times of a field; missing times come first.
+func CompareTimes(a, b *time.Time) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+
+	return a.Compare(*b)
+}
+
+// Return the ?sort= query parameter of a request, empty for the default
+// ID order. Cached responses are keyed by it.
+func Query(c *gin.Context) string {
+	return c.Query("sort")
+}
+
+// Order items, which are in ID order, by the ?sort= fields of the request.
+// It aborts the request with 400 and returns false when a field is not
+// one of fields.
+func Sort[T any](c *gin.Context, items []T, fields Fields[T]) bool {
+	query := Query(c)
+	if query == "" {
+		return true
+	}
+
+	type key struct {
+		compare    func(a, b T) int
+		descending bool
+	}
+
+	var keys []key
+	for _, name := range strings.Split(query, ",") {
+		name = strings.TrimSpace(name)
+		descending := strings.HasPrefix(name, "-")
+		name = strings.TrimPrefix(name, "-")
+
+		compare, ok := fields[name]
+		if !ok {
+			apierror.AbortWithDetails(c, http.StatusBadRequest, apierror.CodeBadRequest, fmt.Sprintf("Cannot sort by %q", name), gin.H{"sort": names(fields)})
+			return false
+		}
+		keys = append(keys, key{compare: compare, descending: descending})
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		for _, k := range keys {
+			result := k.compare(items[i], items[j])
+			if k.descending {
+				result = -result
+			}
+			if result != 0 {
+				return result < 0
+			}
+		}
+
+		return false
+	})
+
+	return true
+}
+
+// Return the names of the fields in alphabetical order.
+func names[T any](fields Fields[T]) []string {
+	list := make([]string, 0, len(fields))
+	for name := range fields {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+
+	return list
+}
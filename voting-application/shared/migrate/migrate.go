@@ -0,0 +1,94 @@
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Apply every migration in the filesystem that has not been applied yet
+// for the service. Migration files are named <version>_<description>.sql
+// and applied in version order, each in its own transaction.
+func Apply(db *sql.DB, service string, migrations fs.FS) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		service TEXT NOT NULL,
+		version INTEGER NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		PRIMARY KEY (service, version)
+	)`); err != nil {
+		return err
+	}
+
+	files, err := fs.Glob(migrations, "*.sql")
+	if err != nil {
+		return err
+	}
+
+	type migration struct {
+		version int
+		name    string
+	}
+
+	pending := make([]migration, 0, len(files))
+	for _, name := range files {
+		prefix, _, found := strings.Cut(name, "_")
+		if !found {
+			return fmt.Errorf("migration %s is not named <version>_<description>.sql", name)
+		}
+
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return fmt.Errorf("migration %s has an invalid version: %w", name, err)
+		}
+
+		pending = append(pending, migration{version: version, name: name})
+	}
+
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].version < pending[j].version
+	})
+
+	for _, m := range pending {
+		var applied bool
+		err := db.QueryRow(`SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE service = $1 AND version = $2)`, service, m.version).Scan(&applied)
+		if err != nil {
+			return err
+		}
+
+		if applied {
+			continue
+		}
+
+		statements, err := fs.ReadFile(migrations, m.name)
+		if err != nil {
+			return err
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(string(statements)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %s failed: %w", m.name, err)
+		}
+
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (service, version) VALUES ($1, $2)`, service, m.version); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+
+		log.Printf("Applied %s migration %s", service, m.name)
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+// Package negotiate answers the GET endpoints of the voter, poll and votes
+// APIs as XML or msgpack, in addition to JSON, to the clients that ask for
+// it in their Accept header:
+//
+//	Accept: application/xml
+//	Accept: application/msgpack
+//
+// A handler passes its response to Respond, which picks the format with
+// c.NegotiateFormat and writes it with the gin renderers. The field names
+// come from the json, xml and msgpack tags of the response types, so those
+// types carry all three. A list is written with List, an array in JSON and
+// msgpack and an element holding the items in XML. A request that accepts
+// none of the formats gets 406; one without an Accept header gets JSON.
+package negotiate
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+
+	"shared/apierror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const (
+	FormatJSON    = "json"
+	FormatXML     = "xml"
+	FormatMsgpack = "msgpack"
+)
+
+// The media types of the Accept header, JSON first so that */* gets it.
+var offered = []string{
+	binding.MIMEJSON,
+	binding.MIMEXML,
+	binding.MIMEXML2,
+	binding.MIMEMSGPACK2,
+	binding.MIMEMSGPACK,
+}
+
+// Return the format a request asks for in its Accept header, FormatJSON
+// when it has none, and "" when it accepts none of the formats.
+func Format(c *gin.Context) string {
+	switch c.NegotiateFormat(offered...) {
+	case binding.MIMEJSON:
+		return FormatJSON
+	case binding.MIMEXML, binding.MIMEXML2:
+		return FormatXML
+	case binding.MIMEMSGPACK, binding.MIMEMSGPACK2:
+		return FormatMsgpack
+	default:
+		return ""
+	}
+}
+
+// Answer a request with status and data in the format of its Accept
+// header, and with Vary: Accept so a cache never serves one format for
+// another.
+func Respond(c *gin.Context, status int, data interface{}) {
+	c.Writer.Header().Add("Vary", "Accept")
+
+	switch Format(c) {
+	case FormatJSON:
+		c.JSON(status, data)
+	case FormatXML:
+		c.XML(status, data)
+	case FormatMsgpack:
+		c.Render(status, MsgPack{Data: data})
+	default:
+		AbortNotAcceptable(c)
+	}
+}
+
+// Abort a request whose Accept header accepts none of the formats with 406.
+func AbortNotAcceptable(c *gin.Context) {
+	apierror.Abort(c, http.StatusNotAcceptable, apierror.CodeNotAcceptable,
+		fmt.Sprintf("The Accept header must accept %s, %s or %s", binding.MIMEJSON, binding.MIMEXML, binding.MIMEMSGPACK2))
+}
+
+// MsgPack renders data as msgpack with the field names of its msgpack
+// struct tags. gin's render.MsgPack only reads the codec and json tags.
+type MsgPack struct {
+	Data interface{}
+}
+
+var msgpackContentType = []string{binding.MIMEMSGPACK2 + "; charset=utf-8"}
+
+func (r MsgPack) WriteContentType(w http.ResponseWriter) {
+	if header := w.Header(); len(header["Content-Type"]) == 0 {
+		header["Content-Type"] = msgpackContentType
+	}
+}
+
+func (r MsgPack) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+
+	encoder := msgpack.NewEncoder(w)
+	encoder.UseCompactInts(true)
+
+	return encoder.Encode(r.Data)
+}
+
+// List is a list response: an array of its items in JSON and msgpack, and
+// in XML an element named Name holding the items, each named by its own
+// XMLName, such as <voters><voter>...</voter></voters>.
+type List[T any] struct {
+	Name  string
+	Items []T
+}
+
+// Return the list of items written as the element name in XML.
+func NewList[T any](name string, items []T) List[T] {
+	return List[T]{Name: name, Items: items}
+}
+
+func (l List[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.Items)
+}
+
+func (l List[T]) EncodeMsgpack(encoder *msgpack.Encoder) error {
+	return encoder.Encode(l.Items)
+}
+
+func (l List[T]) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if l.Name != "" {
+		start = xml.StartElement{Name: xml.Name{Local: l.Name}}
+	}
+
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	for _, item := range l.Items {
+		if err := e.Encode(item); err != nil {
+			return err
+		}
+	}
+
+	return e.EncodeToken(start.End())
+}
+
+// Link is a link of a response to a method on a URL.
+type Link struct {
+	Method string `json:"method" xml:"method,attr" msgpack:"method"`
+	URL    string `json:"url" xml:"url,attr" msgpack:"url"`
+}
+
+// Links are the links of a resource to its get, update and delete
+// endpoints.
+type Links struct {
+	Get    Link `json:"get" xml:"get" msgpack:"get"`
+	Update Link `json:"update" xml:"update" msgpack:"update"`
+	Delete Link `json:"delete" xml:"delete" msgpack:"delete"`
+}
+
+// Return the links of the resource at url.
+func ResourceLinks(url string) Links {
+	return Links{
+		Get:    Link{Method: http.MethodGet, URL: url},
+		Update: Link{Method: http.MethodPut, URL: url},
+		Delete: Link{Method: http.MethodDelete, URL: url},
+	}
+}
@@ -0,0 +1,255 @@
+package negotiate_test
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"shared/apierror"
+	"shared/negotiate"
+	"shared/pagination"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// item is a response type with its field names in every format.
+type item struct {
+	XMLName xml.Name        `json:"-" xml:"item" msgpack:"-"`
+	ItemID  uint            `json:"itemId" xml:"itemId" msgpack:"itemId"`
+	Name    string          `json:"name" xml:"name" msgpack:"name"`
+	Tags    []string        `json:"tags" xml:"tags>tag" msgpack:"tags"`
+	Links   negotiate.Links `json:"links" xml:"links" msgpack:"links"`
+}
+
+func newItem(id uint, name string) item {
+	return item{ItemID: id, Name: name, Tags: []string{"a", "b"}, Links: negotiate.ResourceLinks("/items/1")}
+}
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// Serve GET /item, GET /items and GET /items/paged and send a request with
+// accept as its Accept header.
+func serve(t *testing.T, path, accept string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	r := gin.New()
+	r.GET("/item", func(c *gin.Context) {
+		negotiate.Respond(c, http.StatusOK, newItem(1, "first"))
+	})
+	r.GET("/items", func(c *gin.Context) {
+		negotiate.Respond(c, http.StatusOK, negotiate.NewList("items", []item{newItem(1, "first"), newItem(2, "second")}))
+	})
+	r.GET("/items/paged", func(c *gin.Context) {
+		request, ok := pagination.Parse(c)
+		if !ok {
+			return
+		}
+		items := []item{newItem(1, "first"), newItem(2, "second"), newItem(3, "third")}
+		pagination.Negotiate(c, request, len(items), "items", pagination.Slice(request, items))
+	})
+
+	request := httptest.NewRequest(http.MethodGet, path, nil)
+	if accept != "" {
+		request.Header.Set("Accept", accept)
+	}
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func checkResponse(t *testing.T, recorder *httptest.ResponseRecorder, contentType string) {
+	t.Helper()
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusOK, recorder.Body.String())
+	}
+	if got := recorder.Header().Get("Content-Type"); !strings.HasPrefix(got, contentType) {
+		t.Fatalf("Content-Type = %q, want %q", got, contentType)
+	}
+	if got := recorder.Header().Get("Vary"); got != "Accept" {
+		t.Fatalf("Vary = %q, want Accept", got)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", negotiate.FormatJSON},
+		{"*/*", negotiate.FormatJSON},
+		{"application/json", negotiate.FormatJSON},
+		{"application/xml", negotiate.FormatXML},
+		{"text/xml", negotiate.FormatXML},
+		{"application/msgpack", negotiate.FormatMsgpack},
+		{"application/x-msgpack", negotiate.FormatMsgpack},
+		{"text/csv, application/xml;q=0.5", negotiate.FormatXML},
+		{"text/csv", ""},
+	}
+
+	for _, test := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		c.Request.Header.Set("Accept", test.accept)
+
+		if got := negotiate.Format(c); got != test.want {
+			t.Errorf("Format(Accept: %q) = %q, want %q", test.accept, got, test.want)
+		}
+	}
+}
+
+func TestRespondJSON(t *testing.T) {
+	recorder := serve(t, "/item", "application/json")
+	checkResponse(t, recorder, "application/json")
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decoding JSON: %v", err)
+	}
+	if got["itemId"] != float64(1) || got["name"] != "first" {
+		t.Fatalf("JSON = %v, want itemId 1 and name first", got)
+	}
+	if _, ok := got["XMLName"]; ok {
+		t.Fatalf("JSON has the XMLName field: %v", got)
+	}
+	links := got["links"].(map[string]interface{})
+	if get := links["get"].(map[string]interface{}); get["method"] != "GET" || get["url"] != "/items/1" {
+		t.Fatalf("JSON links.get = %v, want GET /items/1", get)
+	}
+}
+
+func TestRespondJSONWithoutAccept(t *testing.T) {
+	checkResponse(t, serve(t, "/item", ""), "application/json")
+}
+
+func TestRespondXML(t *testing.T) {
+	recorder := serve(t, "/item", "application/xml")
+	checkResponse(t, recorder, "application/xml")
+
+	want := `<item><itemId>1</itemId><name>first</name><tags><tag>a</tag><tag>b</tag></tags>` +
+		`<links><get method="GET" url="/items/1"></get><update method="PUT" url="/items/1"></update>` +
+		`<delete method="DELETE" url="/items/1"></delete></links></item>`
+	if got := recorder.Body.String(); got != want {
+		t.Fatalf("XML =\n%s\nwant\n%s", got, want)
+	}
+
+	var decoded item
+	if err := xml.Unmarshal(recorder.Body.Bytes(), &decoded); err != nil {
+		t.Fatalf("decoding XML: %v", err)
+	}
+	if decoded.ItemID != 1 || decoded.Name != "first" || len(decoded.Tags) != 2 {
+		t.Fatalf("XML decoded to %+v", decoded)
+	}
+}
+
+func TestRespondMsgpack(t *testing.T) {
+	recorder := serve(t, "/item", "application/msgpack")
+	checkResponse(t, recorder, "application/msgpack")
+
+	var got map[string]interface{}
+	if err := msgpack.Unmarshal(recorder.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decoding msgpack: %v", err)
+	}
+	if got["itemId"] != int8(1) || got["name"] != "first" {
+		t.Fatalf("msgpack = %v, want itemId 1 and name first", got)
+	}
+	if _, ok := got["XMLName"]; ok {
+		t.Fatalf("msgpack has the XMLName field: %v", got)
+	}
+
+	var decoded item
+	if err := msgpack.Unmarshal(recorder.Body.Bytes(), &decoded); err != nil {
+		t.Fatalf("decoding msgpack: %v", err)
+	}
+	if decoded.Links.Delete.Method != http.MethodDelete || len(decoded.Tags) != 2 {
+		t.Fatalf("msgpack decoded to %+v", decoded)
+	}
+}
+
+func TestRespondNotAcceptable(t *testing.T) {
+	recorder := serve(t, "/item", "text/csv")
+
+	if recorder.Code != http.StatusNotAcceptable {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusNotAcceptable)
+	}
+
+	var got apierror.Response
+	if err := json.Unmarshal(recorder.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decoding error response: %v", err)
+	}
+	if got.Code != apierror.CodeNotAcceptable {
+		t.Fatalf("error code = %q, want %q", got.Code, apierror.CodeNotAcceptable)
+	}
+}
+
+func TestListFormats(t *testing.T) {
+	recorder := serve(t, "/items", "application/json")
+	checkResponse(t, recorder, "application/json")
+
+	var items []item
+	if err := json.Unmarshal(recorder.Body.Bytes(), &items); err != nil || len(items) != 2 {
+		t.Fatalf("JSON list = %s, want an array of 2 items", recorder.Body.String())
+	}
+
+	recorder = serve(t, "/items", "application/msgpack")
+	checkResponse(t, recorder, "application/msgpack")
+
+	items = nil
+	if err := msgpack.Unmarshal(recorder.Body.Bytes(), &items); err != nil || len(items) != 2 || items[1].Name != "second" {
+		t.Fatalf("msgpack list decoded to %+v, %v, want an array of 2 items", items, err)
+	}
+
+	recorder = serve(t, "/items", "application/xml")
+	checkResponse(t, recorder, "application/xml")
+
+	var list struct {
+		XMLName xml.Name `xml:"items"`
+		Items   []item   `xml:"item"`
+	}
+	if err := xml.Unmarshal(recorder.Body.Bytes(), &list); err != nil || len(list.Items) != 2 || list.Items[1].Name != "second" {
+		t.Fatalf("XML list = %s, want <items> with 2 <item> elements", recorder.Body.String())
+	}
+}
+
+func TestPageFormats(t *testing.T) {
+	recorder := serve(t, "/items/paged?pageSize=2", "application/xml")
+	checkResponse(t, recorder, "application/xml")
+
+	var page struct {
+		XMLName    xml.Name `xml:"page"`
+		Items      []item   `xml:"items>item"`
+		Total      int      `xml:"total"`
+		Page       int      `xml:"page"`
+		PageSize   int      `xml:"pageSize"`
+		NextCursor string   `xml:"nextCursor"`
+	}
+	if err := xml.Unmarshal(recorder.Body.Bytes(), &page); err != nil {
+		t.Fatalf("decoding XML page %s: %v", recorder.Body.String(), err)
+	}
+	if len(page.Items) != 2 || page.Total != 3 || page.Page != 1 || page.PageSize != 2 || page.NextCursor == "" {
+		t.Fatalf("XML page = %s", recorder.Body.String())
+	}
+
+	recorder = serve(t, "/items/paged?pageSize=2", "application/msgpack")
+	checkResponse(t, recorder, "application/msgpack")
+
+	var decoded pagination.Page[item]
+	if err := msgpack.Unmarshal(recorder.Body.Bytes(), &decoded); err != nil {
+		t.Fatalf("decoding msgpack page: %v", err)
+	}
+	if len(decoded.Items) != 2 || decoded.Total != 3 || decoded.NextCursor != page.NextCursor {
+		t.Fatalf("msgpack page decoded to %+v", decoded)
+	}
+
+	if recorder := serve(t, "/items/paged?pageSize=2", "text/csv"); recorder.Code != http.StatusNotAcceptable {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusNotAcceptable)
+	}
+}
@@ -0,0 +1,275 @@
+// Package pagination pages the items of the list endpoints. A request
+// with ?page=, ?pageSize= or ?cursor= gets one page of the list in an
+// envelope, and RFC 5988 Link headers to the other pages:
+//
+//	GET /voters?page=2&pageSize=20
+//
+//	Link: </voters?page=1&pageSize=20>; rel="first", </voters?page=1&pageSize=20>; rel="prev", ...
+//	{"items": [...], "total": 135, "page": 2, "pageSize": 20, "nextCursor": "NDA"}
+//
+// ?cursor= takes the nextCursor of the previous page instead of a page
+// number. Requests without them keep getting the whole list as an array.
+// Pages are cut after the filters and the ?sort= order of the list.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"shared/apierror"
+	"shared/negotiate"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 1000
+
+	TotalCountHeader = "X-Total-Count"
+)
+
+// Request is the page a list request asks for.
+type Request struct {
+	// Whether the request asked for a page; the whole list otherwise.
+	Paged bool
+	// The page, from 1, and its size.
+	Page     int
+	PageSize int
+	// The index of the first item of the page.
+	Offset int
+	// Whether the page was asked for with ?cursor=.
+	cursor bool
+}
+
+// Page is the envelope of a page of a list.
+type Page[T any] struct {
+	Items    []T `json:"items" msgpack:"items"`
+	Total    int `json:"total" msgpack:"total"`
+	Page     int `json:"page" msgpack:"page"`
+	PageSize int `json:"pageSize" msgpack:"pageSize"`
+	// The ?cursor= of the next page, empty on the last page.
+	NextCursor string `json:"nextCursor,omitempty" msgpack:"nextCursor,omitempty"`
+}
+
+// pageXML is a page as XML: a <page> element with its items in <items>,
+// each named by its own XMLName.
+type pageXML[T any] struct {
+	XMLName    xml.Name          `xml:"page"`
+	Items      negotiate.List[T] `xml:"items"`
+	Total      int               `xml:"total"`
+	Page       int               `xml:"page"`
+	PageSize   int               `xml:"pageSize"`
+	NextCursor string            `xml:"nextCursor,omitempty"`
+}
+
+func (p Page[T]) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	return e.Encode(pageXML[T]{
+		Items:      negotiate.NewList("items", p.Items),
+		Total:      p.Total,
+		Page:       p.Page,
+		PageSize:   p.PageSize,
+		NextCursor: p.NextCursor,
+	})
+}
+
+// Return the page a request asks for. It aborts the request with 400 and
+// returns false when ?page=, ?pageSize= or ?cursor= is not valid.
+func Parse(c *gin.Context) (Request, bool) {
+	page, pageSize, cursor := c.Query("page"), c.Query("pageSize"), c.Query("cursor")
+
+	request := Request{Page: 1, PageSize: DefaultPageSize}
+	if page == "" && pageSize == "" && cursor == "" {
+		return request, true
+	}
+	request.Paged = true
+
+	if pageSize != "" {
+		size, err := strconv.Atoi(pageSize)
+		if err != nil || size < 1 || size > MaxPageSize {
+			apierror.Abort(c, http.StatusBadRequest, apierror.CodeBadRequest, "The pageSize query parameter must be between 1 and "+strconv.Itoa(MaxPageSize))
+			return request, false
+		}
+		request.PageSize = size
+	}
+
+	switch {
+	case page != "" && cursor != "":
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeBadRequest, "The page and cursor query parameters cannot be used together")
+		return request, false
+	case cursor != "":
+		offset, err := decodeCursor(cursor)
+		if err != nil {
+			apierror.AbortWithError(c, http.StatusBadRequest, "Invalid cursor", err)
+			return request, false
+		}
+		request.Offset = offset
+		request.Page = offset/request.PageSize + 1
+		request.cursor = true
+	case page != "":
+		number, err := strconv.Atoi(page)
+		if err != nil || number < 1 {
+			apierror.Abort(c, http.StatusBadRequest, apierror.CodeBadRequest, "The page query parameter must be a number from 1")
+			return request, false
+		}
+		request.Page = number
+		request.Offset = (number - 1) * request.PageSize
+	}
+
+	return request, true
+}
+
+// Return the request of a page of limit items from an offset, for the
+// endpoints that take ?offset= and ?limit=. The page links to the first
+// and the next page by cursor, as the offset need not fall on a page
+// boundary.
+func FromOffset(offset, limit int) Request {
+	return Request{
+		Paged:    true,
+		Page:     offset/limit + 1,
+		PageSize: limit,
+		Offset:   offset,
+		cursor:   true,
+	}
+}
+
+// Return the items of the page, all of them when the request did not ask
+// for a page.
+func Slice[T any](request Request, items []T) []T {
+	if !request.Paged {
+		return items
+	}
+
+	start := request.Offset
+	if start > len(items) {
+		start = len(items)
+	}
+	end := start + request.PageSize
+	if end > len(items) {
+		end = len(items)
+	}
+
+	return items[start:end]
+}
+
+// Answer a list request with the items of its page, out of total items:
+// in the envelope with the Link and X-Total-Count headers when the
+// request asked for a page, as an array otherwise.
+func Respond[T any](c *gin.Context, request Request, total int, items []T) {
+	if !request.Paged {
+		c.JSON(http.StatusOK, items)
+		return
+	}
+
+	c.JSON(http.StatusOK, newPage(c, request, total, items))
+}
+
+// Answer a list request like Respond, in the format of its Accept header
+// (see negotiate.Respond). In XML the whole list is an element named name
+// holding the items, and a page is a <page> element with them in <items>.
+func Negotiate[T any](c *gin.Context, request Request, total int, name string, items []T) {
+	if !request.Paged {
+		negotiate.Respond(c, http.StatusOK, negotiate.NewList(name, items))
+		return
+	}
+
+	negotiate.Respond(c, http.StatusOK, newPage(c, request, total, items))
+}
+
+// Return the envelope of the page of a request, and set its Link and
+// X-Total-Count headers.
+func newPage[T any](c *gin.Context, request Request, total int, items []T) Page[T] {
+	if items == nil {
+		items = []T{}
+	}
+
+	next := request.Offset + request.PageSize
+	page := Page[T]{
+		Items:    items,
+		Total:    total,
+		Page:     request.Page,
+		PageSize: request.PageSize,
+	}
+	if next < total {
+		page.NextCursor = encodeCursor(next)
+	}
+
+	c.Header(TotalCountHeader, strconv.Itoa(total))
+	if links := linkHeader(c.Request.URL, request, total, page.NextCursor); links != "" {
+		c.Header("Link", links)
+	}
+
+	return page
+}
+
+// Return the Link header of a page. Pages asked for by number link to the
+// first, previous, next and last pages by number; pages asked for by
+// cursor link to the first page and to the next one by cursor.
+func linkHeader(requestURL *url.URL, request Request, total int, nextCursor string) string {
+	var links []string
+	link := func(rel string, set func(query url.Values)) {
+		query := requestURL.Query()
+		query.Del("page")
+		query.Del("cursor")
+		query.Del("offset")
+		query.Del("limit")
+		query.Set("pageSize", strconv.Itoa(request.PageSize))
+		set(query)
+		links = append(links, fmt.Sprintf(`<%s?%s>; rel="%s"`, requestURL.Path, query.Encode(), rel))
+	}
+	byPage := func(number int) func(url.Values) {
+		return func(query url.Values) { query.Set("page", strconv.Itoa(number)) }
+	}
+
+	lastPage := (total + request.PageSize - 1) / request.PageSize
+	if lastPage < 1 {
+		lastPage = 1
+	}
+
+	link("first", byPage(1))
+	if request.cursor {
+		if nextCursor != "" {
+			link("next", func(query url.Values) { query.Set("cursor", nextCursor) })
+		}
+		return strings.Join(links, ", ")
+	}
+
+	if request.Page > 1 {
+		prev := request.Page - 1
+		if prev > lastPage {
+			prev = lastPage
+		}
+		link("prev", byPage(prev))
+	}
+	if request.Page < lastPage {
+		link("next", byPage(request.Page+1))
+	}
+	link("last", byPage(lastPage))
+
+	return strings.Join(links, ", ")
+}
+
+// A cursor is the offset of the first item of a page. Clients should pass
+// it on as it is; it is not meant to be read or built.
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("cursor %q is not a cursor of this API", cursor)
+	}
+
+	offset, err := strconv.Atoi(string(data))
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("cursor %q is not a cursor of this API", cursor)
+	}
+
+	return offset, nil
+}
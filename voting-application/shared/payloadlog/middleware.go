@@ -0,0 +1,105 @@
+package payloadlog
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"shared/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The middleware that logs the redacted request and response bodies of
+// every request when logging is enabled. It only reads the first MaxBody
+// bytes of a body, so streamed exports are not held in memory.
+func (c *Config) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !c.Enabled {
+			ctx.Next()
+			return
+		}
+
+		start := time.Now()
+		route := ctx.Request.Method + " " + ctx.FullPath()
+		paths := c.paths(route)
+
+		var requestBody []byte
+		if ctx.Request.Body != nil {
+			body, err := io.ReadAll(io.LimitReader(ctx.Request.Body, int64(c.MaxBody)+1))
+			if err == nil {
+				requestBody = body
+				ctx.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), ctx.Request.Body))
+			}
+		}
+
+		requestSize := len(requestBody)
+		if ctx.Request.ContentLength > int64(requestSize) {
+			requestSize = int(ctx.Request.ContentLength)
+		}
+
+		writer := &captureWriter{ResponseWriter: ctx.Writer, limit: c.MaxBody}
+		ctx.Writer = writer
+
+		ctx.Next()
+
+		ctx.Writer = writer.ResponseWriter
+
+		log.Printf("[payload] %s %s%s %d %s request_id=%s request=%s response=%s",
+			ctx.Request.Method,
+			ctx.Request.URL.Path,
+			redactQuery(ctx.Request.URL.Query(), paths),
+			writer.Status(),
+			time.Since(start),
+			apierror.RequestIDFrom(ctx),
+			c.formatBody(requestBody, requestSize, paths),
+			c.formatBody(writer.body.Bytes(), writer.size, paths),
+		)
+	}
+}
+
+// Return the query string with the parameters named by a one-field path
+// redacted.
+func redactQuery(query url.Values, paths [][]string) string {
+	if len(query) == 0 {
+		return ""
+	}
+
+	for name := range query {
+		for _, path := range paths {
+			if len(path) == 1 && (path[0] == "*" || strings.EqualFold(path[0], name)) {
+				query[name] = []string{Redacted}
+			}
+		}
+	}
+
+	return "?" + query.Encode()
+}
+
+// captureWriter keeps a copy of the first bytes of a response body while
+// it is written through.
+type captureWriter struct {
+	gin.ResponseWriter
+	body  bytes.Buffer
+	size  int
+	limit int
+}
+
+func (w *captureWriter) Write(data []byte) (int, error) {
+	if room := w.limit + 1 - w.body.Len(); room > 0 {
+		if len(data) < room {
+			room = len(data)
+		}
+		w.body.Write(data[:room])
+	}
+	w.size += len(data)
+
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *captureWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
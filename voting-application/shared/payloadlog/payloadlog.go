@@ -0,0 +1,157 @@
+// Package payloadlog logs the request and response bodies of an API for
+// debugging, with the fields that hold personal data redacted. It is off
+// unless LOG_PAYLOADS is true. The fields are listed per route in the
+// LOG_REDACT_FILE file:
+//
+//	# Redacted on every route.
+//	default: [firstName, lastName, email]
+//	routes:
+//	  GET /voters/search: [q]
+//	  POST /graphql: [data.*.voter.name]
+//
+// A path names object fields separated by dots, * matches any field and
+// arrays are looked through, so firstName also redacts the first name of
+// every voter of a list. Query parameters are redacted by their names.
+package payloadlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// The value redacted fields are replaced with.
+	Redacted = "[REDACTED]"
+
+	// The most bytes of a body that are logged. A longer body cannot be
+	// redacted reliably, so only its size is logged.
+	DefaultMaxBody = 4096
+)
+
+// DefaultFields are redacted on every route when no LOG_REDACT_FILE is set.
+var DefaultFields = []string{"firstName", "lastName", "email", "phone", "secret", "key"}
+
+// Config is what is logged and redacted.
+type Config struct {
+	Enabled bool `yaml:"-"`
+	MaxBody int  `yaml:"-"`
+	// The field paths redacted on every route.
+	Default []string `yaml:"default"`
+	// The field paths redacted on a route, keyed by method and path as
+	// registered with gin, such as POST /voters/:id.
+	Routes map[string][]string `yaml:"routes"`
+}
+
+// Load a redaction file. YAML and JSON files are both accepted.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("parsing redaction file %s: %w", path, err)
+	}
+
+	return &config, nil
+}
+
+// Return the configuration of LOG_PAYLOADS, LOG_PAYLOAD_MAX_BYTES and
+// LOG_REDACT_FILE.
+func FromEnv() (*Config, error) {
+	config := &Config{Default: DefaultFields}
+	if path := os.Getenv("LOG_REDACT_FILE"); path != "" {
+		loaded, err := Load(path)
+		if err != nil {
+			return nil, err
+		}
+		config = loaded
+	}
+
+	config.Enabled = os.Getenv("LOG_PAYLOADS") == "true"
+
+	config.MaxBody = DefaultMaxBody
+	if value := os.Getenv("LOG_PAYLOAD_MAX_BYTES"); value != "" {
+		maxBody, err := strconv.Atoi(value)
+		if err != nil || maxBody <= 0 {
+			return nil, fmt.Errorf("LOG_PAYLOAD_MAX_BYTES must be a positive number of bytes, got %q", value)
+		}
+		config.MaxBody = maxBody
+	}
+
+	return config, nil
+}
+
+// Return the parsed field paths redacted on a route.
+func (c *Config) paths(route string) [][]string {
+	fields := append(append([]string{}, c.Default...), c.Routes[route]...)
+
+	paths := make([][]string, 0, len(fields))
+	for _, field := range fields {
+		if field = strings.TrimSpace(field); field != "" {
+			paths = append(paths, strings.Split(field, "."))
+		}
+	}
+
+	return paths
+}
+
+// Replace the values at path with Redacted. Field names match ignoring
+// case, as they do when gin binds a body.
+func redact(value interface{}, path []string) {
+	switch v := value.(type) {
+	case []interface{}:
+		for _, element := range v {
+			redact(element, path)
+		}
+	case map[string]interface{}:
+		for key, field := range v {
+			if path[0] != "*" && !strings.EqualFold(path[0], key) {
+				continue
+			}
+
+			if len(path) == 1 {
+				v[key] = Redacted
+			} else {
+				redact(field, path[1:])
+			}
+		}
+	}
+}
+
+// Return a body as it is logged: redacted JSON, or a note of its size when
+// it is not JSON or too long to redact.
+func (c *Config) formatBody(body []byte, size int, paths [][]string) string {
+	if size == 0 {
+		return "-"
+	}
+	if size > c.MaxBody {
+		return fmt.Sprintf("<%d bytes, not logged>", size)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		return fmt.Sprintf("<%d bytes of non-JSON, not logged>", size)
+	}
+
+	for _, path := range paths {
+		redact(value, path)
+	}
+
+	redacted, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("<%d bytes, not logged>", size)
+	}
+
+	return string(redacted)
+}
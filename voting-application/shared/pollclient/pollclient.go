@@ -0,0 +1,270 @@
+// Package pollclient is the Go client of the poll API:
+//
+//	client, err := pollclient.New(restclient.Config{BaseURL: "http://localhost:1081"})
+//	poll, err := client.GetPoll(ctx, 1)
+//
+// Error responses are *restclient.Error, calls that could not reach the
+// API are *endpoints.UnreachableError.
+package pollclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"shared/endpoints"
+	"shared/restclient"
+)
+
+const (
+	PollStatusDraft     = "draft"
+	PollStatusOpen      = "open"
+	PollStatusClosed    = "closed"
+	PollStatusCertified = "certified"
+)
+
+// PollOption is an option of a poll. A non-zero MaxVotes caps its votes.
+type PollOption struct {
+	PollOptionID   uint   `json:"pollOptionId"`
+	PollOptionText string `json:"pollOptionText"`
+	MaxVotes       uint   `json:"maxVotes,omitempty"`
+}
+
+// Poll is a poll with its options.
+type Poll struct {
+	PollID       uint         `json:"pollId"`
+	PollTitle    string       `json:"pollTitle"`
+	PollQuestion string       `json:"pollQuestion"`
+	PollOptions  []PollOption `json:"pollOptions"`
+	PollStatus   string       `json:"pollStatus"`
+	SeriesID     uint         `json:"seriesId,omitempty"`
+	Tags         []string     `json:"tags,omitempty"`
+	Anonymous    bool         `json:"anonymous,omitempty"`
+	CreatedAt    time.Time    `json:"createdAt"`
+	ClosedAt     *time.Time   `json:"closedAt,omitempty"`
+	CertifiedAt  *time.Time   `json:"certifiedAt,omitempty"`
+	// Up to how many options a vote selects, single-choice when 0 or 1.
+	MaxSelections uint `json:"maxSelections,omitempty"`
+	// Whether votes can give a free text in place of an option.
+	AllowWriteIn bool `json:"allowWriteIn,omitempty"`
+	// Who can vote in the poll, anyone when nil.
+	Eligibility *Eligibility `json:"eligibility,omitempty"`
+	// When the schedule opens and closes the poll, if it does.
+	StartTime *time.Time `json:"startTime,omitempty"`
+	EndTime   *time.Time `json:"endTime,omitempty"`
+}
+
+// VoterIDRange is a range of voter IDs, both ends included.
+type VoterIDRange struct {
+	From uint `json:"from"`
+	To   uint `json:"to"`
+}
+
+// Eligibility restricts who can vote in a poll. A voter has to pass every
+// rule that is set.
+type Eligibility struct {
+	// The voter IDs that can vote, any when empty.
+	VoterIDRanges []VoterIDRange `json:"voterIdRanges,omitempty"`
+	// Only the voters on the allowlist of the poll can vote.
+	Allowlist bool `json:"allowlist,omitempty"`
+}
+
+// Client calls the poll API.
+type Client struct {
+	rest *restclient.Client
+}
+
+// Create a client of the poll API. An empty BaseURL resolves POLL_API_URL
+// or the service name.
+func New(config restclient.Config) (*Client, error) {
+	rest, err := restclient.New(endpoints.PollAPI, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{rest: rest}, nil
+}
+
+// Return the URL of the poll API the client calls.
+func (c *Client) BaseURL() string {
+	return c.rest.BaseURL()
+}
+
+func pollPath(pollID uint) string {
+	return fmt.Sprintf("/polls/%d", pollID)
+}
+
+func optionPath(pollID, optionID uint) string {
+	return fmt.Sprintf("/polls/%d/options/%d", pollID, optionID)
+}
+
+// Return every poll.
+func (c *Client) ListPolls(ctx context.Context) ([]Poll, error) {
+	polls := []Poll{}
+	err := c.rest.Get(ctx, "/polls", nil, &polls)
+	return polls, err
+}
+
+// Return the polls that have the tag and whose title or question contain
+// every word of query. Either can be empty, but not both.
+func (c *Client) SearchPolls(ctx context.Context, tag, query string) ([]Poll, error) {
+	params := map[string]string{}
+	if tag != "" {
+		params["tag"] = tag
+	}
+	if query != "" {
+		params["q"] = query
+	}
+
+	polls := []Poll{}
+	err := c.rest.Get(ctx, "/polls/search", params, &polls)
+	return polls, err
+}
+
+// Return a poll.
+func (c *Client) GetPoll(ctx context.Context, pollID uint) (Poll, error) {
+	var poll Poll
+	err := c.rest.Get(ctx, pollPath(pollID), nil, &poll)
+	return poll, err
+}
+
+// Add a poll with the ID, title, question, series and tags of poll.
+// Options are added with AddPollOption.
+func (c *Client) AddPoll(ctx context.Context, poll Poll) (Poll, error) {
+	var added Poll
+	err := c.rest.Post(ctx, pollPath(poll.PollID), poll, &added)
+	return added, err
+}
+
+// Change the title and question of a poll.
+func (c *Client) UpdatePoll(ctx context.Context, poll Poll) (Poll, error) {
+	var updated Poll
+	err := c.rest.Put(ctx, pollPath(poll.PollID), poll, &updated)
+	return updated, err
+}
+
+// Delete a poll.
+func (c *Client) DeletePoll(ctx context.Context, pollID uint) error {
+	return c.rest.Delete(ctx, pollPath(pollID), nil)
+}
+
+// Clone a poll into a new poll of the same series.
+func (c *Client) ClonePoll(ctx context.Context, pollID, newPollID uint) (Poll, error) {
+	var cloned Poll
+	err := c.rest.Post(ctx, fmt.Sprintf("/polls/%d/clone/%d", pollID, newPollID), nil, &cloned)
+	return cloned, err
+}
+
+// Close an open poll.
+func (c *Client) ClosePoll(ctx context.Context, pollID uint) (Poll, error) {
+	var closed Poll
+	err := c.rest.Post(ctx, pollPath(pollID)+"/close", nil, &closed)
+	return closed, err
+}
+
+// Certify a closed poll, releasing its results.
+func (c *Client) CertifyPoll(ctx context.Context, pollID uint) (Poll, error) {
+	var certified Poll
+	err := c.rest.Post(ctx, pollPath(pollID)+"/certify", nil, &certified)
+	return certified, err
+}
+
+// Return the options of a poll.
+func (c *Client) GetPollOptions(ctx context.Context, pollID uint) ([]PollOption, error) {
+	options := []PollOption{}
+	err := c.rest.Get(ctx, pollPath(pollID)+"/options", nil, &options)
+	return options, err
+}
+
+// Return an option of a poll.
+func (c *Client) GetPollOption(ctx context.Context, pollID, optionID uint) (PollOption, error) {
+	var option PollOption
+	err := c.rest.Get(ctx, optionPath(pollID, optionID), nil, &option)
+	return option, err
+}
+
+// Add an option to a poll. A non-zero maxVotes caps its votes.
+func (c *Client) AddPollOption(ctx context.Context, pollID, optionID uint, text string, maxVotes uint) (PollOption, error) {
+	body := map[string]interface{}{"optionText": text}
+	if maxVotes > 0 {
+		body["maxVotes"] = maxVotes
+	}
+
+	var option PollOption
+	err := c.rest.Post(ctx, optionPath(pollID, optionID), body, &option)
+	return option, err
+}
+
+// Change the text of an option of a poll.
+func (c *Client) UpdatePollOption(ctx context.Context, pollID, optionID uint, text string) (PollOption, error) {
+	var option PollOption
+	err := c.rest.Put(ctx, optionPath(pollID, optionID), map[string]interface{}{"optionText": text}, &option)
+	return option, err
+}
+
+// Remove an option from a poll.
+func (c *Client) DeletePollOption(ctx context.Context, pollID, optionID uint) error {
+	return c.rest.Delete(ctx, optionPath(pollID, optionID), nil)
+}
+
+// Add a tag to a poll.
+func (c *Client) AddPollTag(ctx context.Context, pollID uint, tag string) (Poll, error) {
+	var poll Poll
+	err := c.rest.Post(ctx, pollPath(pollID)+"/tags/"+url.PathEscape(tag), nil, &poll)
+	return poll, err
+}
+
+// Remove a tag from a poll.
+func (c *Client) RemovePollTag(ctx context.Context, pollID uint, tag string) (Poll, error) {
+	var poll Poll
+	err := c.rest.Delete(ctx, pollPath(pollID)+"/tags/"+url.PathEscape(tag), &poll)
+	return poll, err
+}
+
+// Set the eligibility rules of a poll. Rules with nothing set let anyone
+// vote.
+func (c *Client) SetPollEligibility(ctx context.Context, pollID uint, eligibility Eligibility) (Poll, error) {
+	var poll Poll
+	err := c.rest.Put(ctx, pollPath(pollID)+"/eligibility", eligibility, &poll)
+	return poll, err
+}
+
+// Return the voter IDs on the allowlist of a poll.
+func (c *Client) GetEligibleVoters(ctx context.Context, pollID uint) ([]uint, error) {
+	var response struct {
+		VoterIDs []uint `json:"voterIds"`
+	}
+	err := c.rest.Get(ctx, pollPath(pollID)+"/eligibility/voters", nil, &response)
+	return response.VoterIDs, err
+}
+
+// Add voters to the allowlist of a poll and return how many were not on
+// it yet.
+func (c *Client) AddEligibleVoters(ctx context.Context, pollID uint, voterIDs []uint) (int, error) {
+	var response struct {
+		Added int `json:"added"`
+	}
+	err := c.rest.Post(ctx, pollPath(pollID)+"/eligibility/voters", map[string]interface{}{"voterIds": voterIDs}, &response)
+	return response.Added, err
+}
+
+// Report whether a voter is on the allowlist of a poll. A poll that does
+// not exist has no voter on its allowlist.
+func (c *Client) IsEligibleVoter(ctx context.Context, pollID, voterID uint) (bool, error) {
+	err := c.rest.Get(ctx, fmt.Sprintf("%s/eligibility/voters/%d", pollPath(pollID), voterID), nil, nil)
+
+	var apiErr *restclient.Error
+	if errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// Remove a voter from the allowlist of a poll.
+func (c *Client) RemoveEligibleVoter(ctx context.Context, pollID, voterID uint) error {
+	return c.rest.Delete(ctx, fmt.Sprintf("%s/eligibility/voters/%d", pollPath(pollID), voterID), nil)
+}
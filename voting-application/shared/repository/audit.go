@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"strconv"
+	"strings"
+
+	"shared/keyaudit"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Audit the keys of the documents and fix them as fix says, one of the
+// keyaudit fixes. A document stored under a legacy key, such as voter:007,
+// is moved to the key of its ID and indexed, unless a document is already
+// stored there: the legacy key is then a duplicate and deleted.
+func (r *Repository[T]) Audit(fix string) (keyaudit.Documents, error) {
+	report := keyaudit.Documents{Kind: r.config.Kind}
+
+	fixAll := fix == keyaudit.FixAll
+	fixLegacy := fixAll || fix == keyaudit.FixLegacy
+
+	// Delete a key that is beyond repair.
+	discard := func(client *redis.Client, key string) {
+		if err := r.documents.Del(r.context, client, key); err != nil {
+			report.Failed(key, err)
+			return
+		}
+		report.Fixed++
+	}
+
+	err := r.eachKey(func(client *redis.Client, key string) error {
+		report.Scanned++
+
+		if _, err := strconv.ParseUint(strings.TrimPrefix(key, r.config.Prefix), 10, 32); err != nil {
+			report.Malformed.Add(key)
+			if fixAll {
+				discard(client, key)
+			}
+			return nil
+		}
+
+		var item T
+		err := r.Read(client, key, &item)
+		if err == redis.Nil {
+			// Deleted since the key was scanned.
+			return nil
+		}
+		if err != nil || r.config.ID(item) == 0 {
+			report.Unreadable.Add(key)
+			if fixAll {
+				discard(client, key)
+			}
+			return nil
+		}
+
+		canonical := r.Key(r.config.ID(item))
+		if key == canonical {
+			return nil
+		}
+
+		exists, err := r.ring.Client(canonical).Exists(r.context, canonical).Result()
+		if err != nil {
+			return err
+		}
+
+		if exists > 0 {
+			report.Duplicates.Add(key)
+			if fixLegacy {
+				discard(client, key)
+			}
+			return nil
+		}
+
+		report.Legacy.Add(key)
+		if !fixLegacy {
+			return nil
+		}
+
+		if err := r.Put(item); err != nil {
+			report.Failed(key, err)
+			return nil
+		}
+		if err := r.documents.Del(r.context, client, key); err != nil {
+			report.Failed(key, err)
+			return nil
+		}
+		if r.config.Hooks.Index != nil {
+			if err := r.config.Hooks.Index(item); err != nil {
+				report.Failed(key, err)
+				return nil
+			}
+		}
+		report.Fixed++
+
+		return nil
+	})
+
+	return report, err
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Expiration is a document that redis will delete when its key expires.
+type Expiration struct {
+	ID        uint      `json:"id"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Set the TTL of the key of a document to the time Expiry returns for it,
+// or remove the TTL when it returns the zero time.
+func (r *Repository[T]) expire(cmd redis.Cmdable, key string, item T) error {
+	if r.config.Expiry == nil {
+		return nil
+	}
+
+	if at := r.config.Expiry(item); !at.IsZero() {
+		return cmd.ExpireAt(r.context, key, at).Err()
+	}
+
+	return cmd.Persist(r.context, key).Err()
+}
+
+// Return the documents whose keys have a TTL, the first to expire first.
+func (r *Repository[T]) Expirations() ([]Expiration, error) {
+	expirations := make([]Expiration, 0)
+	now := time.Now()
+
+	err := r.eachKey(func(client *redis.Client, key string) error {
+		id, err := strconv.ParseUint(strings.TrimPrefix(key, r.config.Prefix), 10, 32)
+		if err != nil {
+			return nil
+		}
+
+		ttl, err := client.PTTL(r.context, key).Result()
+		if err != nil {
+			return err
+		}
+
+		// Keys without a TTL, or deleted since they were scanned.
+		if ttl < 0 {
+			return nil
+		}
+
+		expirations = append(expirations, Expiration{ID: uint(id), ExpiresAt: now.Add(ttl).UTC().Truncate(time.Second)})
+		return nil
+	})
+
+	sort.Slice(expirations, func(i, j int) bool {
+		if !expirations[i].ExpiresAt.Equal(expirations[j].ExpiresAt) {
+			return expirations[i].ExpiresAt.Before(expirations[j].ExpiresAt)
+		}
+		return expirations[i].ID < expirations[j].ID
+	})
+
+	return expirations, err
+}
@@ -0,0 +1,336 @@
+// Package repository stores the documents of one kind, such as the voters,
+// in redis. A Repository keys every document by its ID under a prefix,
+// places it on its shard of the key ring, serializes it with the codec of
+// the service and keeps the indexes of the package in sync through hooks:
+//
+//	voters := repository.New(ctx, ring, documents, repository.Config[Voter]{
+//		Kind:   "voter",
+//		Prefix: "voter:",
+//		ID:     func(voter Voter) uint { return voter.VoterID },
+//		Hooks:  repository.Hooks[Voter]{Index: indexVoter, Unindex: unindexVoter},
+//	})
+//
+// Each walks the keys with SCAN, so the documents are never all read at
+// once, All returns them in ID order, and every error about a document matches ErrNotFound, ErrExists or
+// ErrConflict with errors.Is.
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"shared/codec"
+	"shared/keyring"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	MaxUpdateRetries = 10
+	ScanBatchSize    = 500
+)
+
+var (
+	// ErrNotFound is matched by the errors about a missing document.
+	ErrNotFound = errors.New("does not exist")
+	// ErrExists is matched by the errors about a document added twice.
+	ErrExists = errors.New("already exists")
+	// ErrConflict is matched by the errors about an update that kept
+	// losing to concurrent ones.
+	ErrConflict = errors.New("was modified concurrently, try again")
+)
+
+// Error is an error about a document of a kind. Its message names the
+// kind, such as "voter does not exist", as the API error responses show
+// it.
+type Error struct {
+	Kind string
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Kind + " " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Hooks let the package of a kind react to the changes of its documents.
+// Every hook is optional.
+type Hooks[T any] struct {
+	// Loaded fixes up a document after it is read, such as the default of
+	// a field added after it was stored.
+	Loaded func(item *T)
+	// Index runs after a document is added, Unindex after it is deleted.
+	Index   func(item T) error
+	Unindex func(item T) error
+	// Cleared runs after every document is deleted.
+	Cleared func() error
+}
+
+// Config describes a kind of document.
+type Config[T any] struct {
+	// The name of the kind in errors, such as "voter".
+	Kind string
+	// The prefix of the keys of the documents, such as "voter:".
+	Prefix string
+	// Return the ID of a document.
+	ID    func(item T) uint
+	Hooks Hooks[T]
+	// Return when a document expires, the zero time when it never does.
+	// Every write sets the TTL of the key of the document to it. Without
+	// Expiry the TTLs of the keys are left as they are.
+	Expiry func(item T) time.Time
+}
+
+// Repository reads and writes the documents of a kind.
+type Repository[T any] struct {
+	config    Config[T]
+	ring      *keyring.Ring
+	documents *codec.Store
+	context   context.Context
+}
+
+// Create a repository of the documents of config on the shards of ring,
+// serialized with documents.
+func New[T any](ctx context.Context, ring *keyring.Ring, documents *codec.Store, config Config[T]) *Repository[T] {
+	return &Repository[T]{
+		config:    config,
+		ring:      ring,
+		documents: documents,
+		context:   ctx,
+	}
+}
+
+// Return an error about a document of the kind.
+func (r *Repository[T]) Err(err error) error {
+	return &Error{Kind: r.config.Kind, Err: err}
+}
+
+// Return the key of the document with the ID.
+func (r *Repository[T]) Key(id uint) string {
+	return fmt.Sprintf("%s%d", r.config.Prefix, id)
+}
+
+// Return the pattern matching the keys of every document.
+func (r *Repository[T]) Pattern() string {
+	return r.config.Prefix + "*"
+}
+
+// Read the document at key with p, which can be a transaction. A missing
+// document returns redis.Nil.
+func (r *Repository[T]) Read(p codec.Processor, key string, item *T) error {
+	if err := r.documents.Get(r.context, p, key, item); err != nil {
+		return err
+	}
+
+	if r.config.Hooks.Loaded != nil {
+		r.config.Hooks.Loaded(item)
+	}
+
+	return nil
+}
+
+// Return the document with the ID, an ErrNotFound error when it does not
+// exist.
+func (r *Repository[T]) Get(id uint) (T, error) {
+	var item T
+
+	key := r.Key(id)
+	if err := r.Read(r.ring.Client(key), key, &item); err != nil {
+		var zero T
+		if err == redis.Nil {
+			return zero, r.Err(ErrNotFound)
+		}
+		return zero, err
+	}
+
+	return item, nil
+}
+
+// Add a document, an ErrExists error when one with its ID exists, and index
+// it.
+func (r *Repository[T]) Add(item T) error {
+	if err := r.Create(item); err != nil {
+		return err
+	}
+
+	if r.config.Hooks.Index != nil {
+		return r.config.Hooks.Index(item)
+	}
+
+	return nil
+}
+
+// Write a document unless one with its ID exists, an ErrExists error then.
+// The document is created in one command, so of two concurrent creates of
+// an ID only one succeeds. The indexes are left as they are.
+func (r *Repository[T]) Create(item T) error {
+	key := r.Key(r.config.ID(item))
+	client := r.ring.Client(key)
+
+	created, err := r.documents.SetNX(r.context, client, key, item)
+	if err != nil {
+		return err
+	}
+	if !created {
+		return r.Err(ErrExists)
+	}
+
+	return r.expire(client, key, item)
+}
+
+// Write a document, replacing the stored one. The indexes are left as
+// they are.
+func (r *Repository[T]) Put(item T) error {
+	key := r.Key(r.config.ID(item))
+	client := r.ring.Client(key)
+	if err := r.documents.Set(r.context, client, key, item); err != nil {
+		return err
+	}
+
+	return r.expire(client, key, item)
+}
+
+// Apply a read-modify-write to a stored document inside a WATCH/MULTI
+// transaction and return the document written. When another client
+// changes the document before the write the transaction is retried, so
+// concurrent updates never overwrite each other; an update that keeps
+// losing returns an ErrConflict error. An error of update is returned
+// as is and nothing is written.
+func (r *Repository[T]) Update(id uint, update func(item *T) error) (T, error) {
+	key := r.Key(id)
+
+	var updated T
+	txUpdate := func(tx *redis.Tx) error {
+		var item T
+		if err := r.Read(tx, key, &item); err == redis.Nil {
+			return r.Err(ErrNotFound)
+		} else if err != nil {
+			return err
+		}
+
+		if err := update(&item); err != nil {
+			return err
+		}
+
+		_, err := tx.TxPipelined(r.context, func(pipe redis.Pipeliner) error {
+			if err := r.documents.Set(r.context, pipe, key, item); err != nil {
+				return err
+			}
+			return r.expire(pipe, key, item)
+		})
+		updated = item
+
+		return err
+	}
+
+	for i := 0; i < MaxUpdateRetries; i++ {
+		err := r.ring.Client(key).Watch(r.context, txUpdate, key)
+		if err != redis.TxFailedErr {
+			if err != nil {
+				var zero T
+				return zero, err
+			}
+			return updated, nil
+		}
+	}
+
+	var zero T
+	return zero, r.Err(ErrConflict)
+}
+
+// Delete the document with the ID, an ErrNotFound error when it does not
+// exist, and remove it from the indexes. It returns the deleted document.
+func (r *Repository[T]) Delete(id uint) (T, error) {
+	item, err := r.Get(id)
+	if err != nil {
+		return item, err
+	}
+
+	key := r.Key(id)
+	if err := r.documents.Del(r.context, r.ring.Client(key), key); err != nil {
+		return item, err
+	}
+
+	if r.config.Hooks.Unindex != nil {
+		return item, r.config.Hooks.Unindex(item)
+	}
+
+	return item, nil
+}
+
+// Call fn with every key of the documents of each shard, read in batches
+// with SCAN.
+func (r *Repository[T]) eachKey(fn func(client *redis.Client, key string) error) error {
+	return r.ring.ForEachShard(func(client *redis.Client) error {
+		iter := client.Scan(r.context, 0, r.Pattern(), ScanBatchSize).Iterator()
+
+		for iter.Next(r.context) {
+			if err := fn(client, iter.Val()); err != nil {
+				return err
+			}
+		}
+
+		return iter.Err()
+	})
+}
+
+// Call fn with every document. The documents are never all held in
+// memory; a document changed during the scan may be seen twice.
+func (r *Repository[T]) Each(fn func(item T) error) error {
+	return r.eachKey(func(client *redis.Client, key string) error {
+		var item T
+		if err := r.Read(client, key, &item); err == redis.Nil {
+			// Deleted since the key was scanned.
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		return fn(item)
+	})
+}
+
+// Return every document in ID order, so the same documents are always
+// listed the same way whatever the shards and the SCAN order.
+func (r *Repository[T]) All() ([]T, error) {
+	var items []T
+
+	err := r.Each(func(item T) error {
+		items = append(items, item)
+		return nil
+	})
+
+	sort.Slice(items, func(i, j int) bool {
+		return r.config.ID(items[i]) < r.config.ID(items[j])
+	})
+
+	return items, err
+}
+
+// Delete every document, then run the Cleared hook.
+func (r *Repository[T]) DeleteAll() error {
+	err := r.eachKey(func(client *redis.Client, key string) error {
+		return r.documents.Del(r.context, client, key)
+	})
+	if err != nil {
+		return err
+	}
+
+	if r.config.Hooks.Cleared != nil {
+		return r.config.Hooks.Cleared()
+	}
+
+	return nil
+}
+
+// Move the documents that are on the wrong shard to the shard they belong
+// to.
+func (r *Repository[T]) Rebalance(dryRun bool) (keyring.RebalanceReport, error) {
+	return r.ring.Rebalance(r.context, r.Pattern(), dryRun)
+}
@@ -0,0 +1,191 @@
+// Package requeststats keeps the last requests of an API in a ring buffer
+// and reports their latency percentiles and error rates, overall and for
+// each route. The cumulative counters of the health endpoints hide a
+// service that got slow a minute ago behind hours of fast requests; the
+// window only holds the recent ones:
+//
+//	window := requeststats.FromEnv()
+//	window.Record(requeststats.Route(c), c.Writer.Status(), duration)
+//	window.Summary()
+//
+// HEALTH_WINDOW_SIZE sets how many requests the window holds.
+package requeststats
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultWindowSize = 1000
+
+	// The route of the requests that matched no route, so unknown paths
+	// cannot grow the routes of a summary without bound.
+	UnmatchedRoute = "unmatched"
+)
+
+// sample is a request in the window.
+type sample struct {
+	route    string
+	status   int
+	duration time.Duration
+	at       time.Time
+}
+
+// Window holds the last requests of an API. It is safe for concurrent use.
+type Window struct {
+	lock    sync.Mutex
+	samples []sample
+	// The index the next request is written to, the oldest request once
+	// the window is full.
+	next int
+	full bool
+}
+
+// Return a window of the last size requests, DefaultWindowSize when size
+// is not positive.
+func New(size int) *Window {
+	if size <= 0 {
+		size = DefaultWindowSize
+	}
+
+	return &Window{samples: make([]sample, size)}
+}
+
+// Return a window of the size HEALTH_WINDOW_SIZE sets.
+func FromEnv() *Window {
+	value := os.Getenv("HEALTH_WINDOW_SIZE")
+	if value == "" {
+		return New(DefaultWindowSize)
+	}
+
+	size, err := strconv.Atoi(value)
+	if err != nil || size <= 0 {
+		log.Printf("Invalid HEALTH_WINDOW_SIZE %q, using %d", value, DefaultWindowSize)
+		return New(DefaultWindowSize)
+	}
+
+	return New(size)
+}
+
+// Return the route of a request, such as "GET /voters/:id".
+func Route(c *gin.Context) string {
+	if c.FullPath() == "" {
+		return UnmatchedRoute
+	}
+
+	return c.Request.Method + " " + c.FullPath()
+}
+
+// Record a request, replacing the oldest one once the window is full.
+func (w *Window) Record(route string, status int, duration time.Duration) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.samples[w.next] = sample{route: route, status: status, duration: duration, at: time.Now()}
+	w.next++
+	if w.next == len(w.samples) {
+		w.next = 0
+		w.full = true
+	}
+}
+
+// Stats are the latencies and errors of requests. A request is an error
+// when its status is 400 or more, like the totalAPICallsError of the
+// health endpoints.
+type Stats struct {
+	Requests  int     `json:"requests"`
+	Errors    int     `json:"errors"`
+	ErrorRate float64 `json:"errorRate"`
+	P50       string  `json:"p50"`
+	P95       string  `json:"p95"`
+	P99       string  `json:"p99"`
+}
+
+// Summary is the report of the requests in the window.
+type Summary struct {
+	Stats
+	// The number of requests the window holds when it is full.
+	WindowSize int `json:"windowSize"`
+	// When the oldest request in the window was recorded.
+	Since  *time.Time       `json:"since,omitempty"`
+	Routes map[string]Stats `json:"routes"`
+}
+
+// Return the summary of the requests in the window.
+func (w *Window) Summary() Summary {
+	// Copy the requests oldest first, so the lock is not held while they
+	// are sorted.
+	w.lock.Lock()
+	samples := make([]sample, 0, len(w.samples))
+	if w.full {
+		samples = append(samples, w.samples[w.next:]...)
+	}
+	samples = append(samples, w.samples[:w.next]...)
+	size := len(w.samples)
+	w.lock.Unlock()
+
+	summary := Summary{
+		Stats:      stats(samples),
+		WindowSize: size,
+		Routes:     make(map[string]Stats),
+	}
+	if len(samples) > 0 {
+		summary.Since = &samples[0].at
+	}
+
+	byRoute := make(map[string][]sample)
+	for _, s := range samples {
+		byRoute[s.route] = append(byRoute[s.route], s)
+	}
+	for route, routeSamples := range byRoute {
+		summary.Routes[route] = stats(routeSamples)
+	}
+
+	return summary
+}
+
+// Return the stats of samples.
+func stats(samples []sample) Stats {
+	durations := make([]time.Duration, len(samples))
+	result := Stats{Requests: len(samples)}
+	for i, s := range samples {
+		durations[i] = s.duration
+		if s.status >= http.StatusBadRequest {
+			result.Errors++
+		}
+	}
+	if len(samples) > 0 {
+		result.ErrorRate = float64(result.Errors) / float64(len(samples))
+	}
+
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	result.P50 = percentile(durations, 50).String()
+	result.P95 = percentile(durations, 95).String()
+	result.P99 = percentile(durations, 99).String()
+
+	return result
+}
+
+// Return the nearest-rank percentile of sorted durations, 0 when there are
+// none.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1]
+}
@@ -0,0 +1,225 @@
+// Package restclient is the HTTP layer of the voterclient, pollclient and
+// votesclient packages. It decodes the JSON bodies of the APIs, turns
+// their error responses into *Error and the calls that never got an answer
+// into *endpoints.UnreachableError, and retries idempotent calls that hit
+// a transient failure.
+package restclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"shared/apierror"
+	"shared/endpoints"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const (
+	DefaultTimeout   = 5 * time.Second
+	DefaultRetries   = 2
+	DefaultRetryWait = 100 * time.Millisecond
+)
+
+// Config configures a client. The zero value of a field selects its
+// default.
+type Config struct {
+	// The URL of the API, such as http://voter-api:1080. Empty resolves
+	// the endpoint from its environment variable or service name.
+	BaseURL string
+	// The transport of the requests, http.DefaultTransport when nil.
+	Transport http.RoundTripper
+	// The timeout of each attempt.
+	Timeout time.Duration
+	// How many times a failed GET, PUT or DELETE is tried again; a
+	// negative value turns retries off. POST calls are never retried.
+	Retries int
+	// The wait before the first retry, doubled on each attempt.
+	RetryWait time.Duration
+	// Headers sent with every request, such as X-Admin-Token.
+	Headers map[string]string
+}
+
+// Error is an error response of an API.
+type Error struct {
+	Status    int
+	Code      string
+	Message   string
+	Details   interface{}
+	RequestID string
+}
+
+func (e *Error) Error() string {
+	message := e.Message
+	if message == "" {
+		message = http.StatusText(e.Status)
+	}
+
+	if e.RequestID != "" {
+		return fmt.Sprintf("%d %s: %s (request %s)", e.Status, e.Code, message, e.RequestID)
+	}
+
+	return fmt.Sprintf("%d %s: %s", e.Status, e.Code, message)
+}
+
+// Report whether err is an error response with the status.
+func IsStatus(err error, status int) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.Status == status
+}
+
+// Client calls one API.
+type Client struct {
+	endpoint endpoints.Endpoint
+	baseURL  string
+	resty    *resty.Client
+}
+
+// Create a client of the endpoint. A BaseURL that is not an absolute
+// http(s) URL is an error.
+func New(endpoint endpoints.Endpoint, config Config) (*Client, error) {
+	baseURL, err := endpoint.Resolve(config.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Timeout == 0 {
+		config.Timeout = DefaultTimeout
+	}
+	if config.Retries == 0 {
+		config.Retries = DefaultRetries
+	}
+	if config.RetryWait == 0 {
+		config.RetryWait = DefaultRetryWait
+	}
+
+	client := resty.New().
+		SetTimeout(config.Timeout).
+		SetHeaders(config.Headers).
+		SetHeader("Accept", "application/json")
+
+	if config.Transport != nil {
+		client.SetTransport(config.Transport)
+	}
+
+	if config.Retries > 0 {
+		client.SetRetryCount(config.Retries).
+			SetRetryWaitTime(config.RetryWait).
+			SetRetryMaxWaitTime(config.RetryWait * 8).
+			AddRetryCondition(shouldRetry)
+	}
+
+	return &Client{endpoint: endpoint, baseURL: baseURL, resty: client}, nil
+}
+
+// Return the URL of the API the client calls.
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// Retry idempotent calls that could not reach the API or that it could not
+// serve right now.
+func shouldRetry(resp *resty.Response, err error) bool {
+	if resp == nil || resp.Request == nil {
+		return false
+	}
+
+	switch resp.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+
+	if err != nil {
+		return !errors.Is(err, context.Canceled)
+	}
+
+	switch resp.StatusCode() {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+// Request describes a call.
+type Request struct {
+	Method  string
+	Path    string
+	Query   map[string]string
+	Headers map[string]string
+	Body    interface{}
+	// The value the JSON body of a successful response is decoded into,
+	// nil to ignore it.
+	Result interface{}
+}
+
+// Send a request and decode its answer. Error responses are *Error; a call
+// that got no answer at all is an *endpoints.UnreachableError.
+func (c *Client) Do(ctx context.Context, request Request) (*resty.Response, error) {
+	r := c.resty.R().
+		SetContext(ctx).
+		SetQueryParams(request.Query).
+		SetHeaders(request.Headers).
+		SetError(&apierror.Response{})
+
+	if request.Body != nil {
+		r.SetHeader("Content-Type", "application/json").SetBody(request.Body)
+	}
+
+	if request.Result != nil {
+		r.SetResult(request.Result)
+	}
+
+	resp, err := r.Execute(request.Method, c.baseURL+request.Path)
+	if err != nil {
+		if ctx.Err() != nil {
+			return resp, ctx.Err()
+		}
+		return resp, c.endpoint.Unreachable(c.baseURL, err)
+	}
+
+	if resp.IsError() {
+		apiErr := &Error{Status: resp.StatusCode(), Code: apierror.CodeForStatus(resp.StatusCode())}
+		if body, ok := resp.Error().(*apierror.Response); ok && body.Code != "" {
+			apiErr.Code = body.Code
+			apiErr.Message = body.Message
+			apiErr.Details = body.Details
+			apiErr.RequestID = body.RequestID
+		} else {
+			apiErr.Message = strings.TrimSpace(string(resp.Body()))
+		}
+
+		return resp, apiErr
+	}
+
+	return resp, nil
+}
+
+// Send a GET request and decode its answer into result.
+func (c *Client) Get(ctx context.Context, path string, query map[string]string, result interface{}) error {
+	_, err := c.Do(ctx, Request{Method: http.MethodGet, Path: path, Query: query, Result: result})
+	return err
+}
+
+// Send a POST request with body and decode its answer into result.
+func (c *Client) Post(ctx context.Context, path string, body, result interface{}) error {
+	_, err := c.Do(ctx, Request{Method: http.MethodPost, Path: path, Body: body, Result: result})
+	return err
+}
+
+// Send a PUT request with body and decode its answer into result.
+func (c *Client) Put(ctx context.Context, path string, body, result interface{}) error {
+	_, err := c.Do(ctx, Request{Method: http.MethodPut, Path: path, Body: body, Result: result})
+	return err
+}
+
+// Send a DELETE request and decode its answer into result.
+func (c *Client) Delete(ctx context.Context, path string, result interface{}) error {
+	_, err := c.Do(ctx, Request{Method: http.MethodDelete, Path: path, Result: result})
+	return err
+}
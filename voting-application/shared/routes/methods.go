@@ -0,0 +1,166 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"shared/pagination"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// The order of the methods of an Allow header.
+var methodOrder = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodOptions,
+}
+
+// Return the routes Register adds for the methods of the table: a HEAD
+// route for every GET route, and an OPTIONS route for every path, unless
+// the table has them.
+func (t *Table) implicitRoutes() []Route {
+	methods := make(map[string]map[string]bool)
+	var paths []string
+	for _, route := range t.Routes {
+		if methods[route.Path] == nil {
+			methods[route.Path] = make(map[string]bool)
+			paths = append(paths, route.Path)
+		}
+		methods[route.Path][route.Method] = true
+	}
+
+	var implicit []Route
+	for _, route := range t.Routes {
+		if route.Method != http.MethodGet || methods[route.Path][http.MethodHead] {
+			continue
+		}
+
+		head := route
+		head.Method = http.MethodHead
+		head.Middleware = append([]gin.HandlerFunc{countMiddleware}, route.Middleware...)
+		implicit = append(implicit, head)
+	}
+
+	for _, path := range paths {
+		if methods[path][http.MethodOptions] {
+			continue
+		}
+
+		allowed := methods[path]
+		if allowed[http.MethodGet] {
+			allowed[http.MethodHead] = true
+		}
+		allowed[http.MethodOptions] = true
+
+		implicit = append(implicit, Route{
+			Method:  http.MethodOptions,
+			Path:    path,
+			Handler: allowHandler(allowed),
+			Summary: "List the methods of the path in the Allow header",
+		})
+	}
+
+	return implicit
+}
+
+// Return the handler of an OPTIONS route, which answers 204 with the
+// methods of its path in the Allow header. CORS preflight requests are
+// answered by the CORS middleware before they get here.
+func allowHandler(allowed map[string]bool) gin.HandlerFunc {
+	methods := make([]string, 0, len(allowed))
+	for method := range allowed {
+		methods = append(methods, method)
+	}
+
+	rank := func(method string) int {
+		for i, ordered := range methodOrder {
+			if ordered == method {
+				return i
+			}
+		}
+		return len(methodOrder)
+	}
+	sort.Slice(methods, func(i, j int) bool {
+		return rank(methods[i]) < rank(methods[j])
+	})
+
+	allow := strings.Join(methods, ", ")
+
+	return func(c *gin.Context) {
+		c.Header("Allow", allow)
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// The middleware of the HEAD routes, which run the handlers of their GET
+// routes. The server sends no body for HEAD requests; the middleware adds
+// the number of items of a JSON array to X-Total-Count, as paged lists
+// have it, so the size of a list can be found without reading it.
+func countMiddleware(c *gin.Context) {
+	writer := &countWriter{ResponseWriter: c.Writer}
+	c.Writer = writer
+
+	c.Next()
+
+	c.Writer = writer.ResponseWriter
+	writer.flush()
+}
+
+// countWriter holds back the body of a HEAD response so its items can be
+// counted. A flush from a streaming handler sends the headers and lets the
+// rest through.
+type countWriter struct {
+	gin.ResponseWriter
+	body        bytes.Buffer
+	passthrough bool
+}
+
+func (w *countWriter) Write(data []byte) (int, error) {
+	if w.passthrough {
+		return w.ResponseWriter.Write(data)
+	}
+
+	return w.body.Write(data)
+}
+
+func (w *countWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *countWriter) Flush() {
+	if !w.passthrough {
+		w.passthrough = true
+		w.ResponseWriter.Write(w.body.Bytes())
+		w.body.Reset()
+	}
+
+	w.ResponseWriter.Flush()
+}
+
+// Write the held back body, after X-Total-Count when it is a JSON array.
+func (w *countWriter) flush() {
+	if w.passthrough {
+		return
+	}
+
+	header := w.Header()
+	body := w.body.Bytes()
+	if header.Get(pagination.TotalCountHeader) == "" && strings.HasPrefix(header.Get("Content-Type"), binding.MIMEJSON) {
+		var items []json.RawMessage
+		if err := json.Unmarshal(body, &items); err == nil {
+			header.Set(pagination.TotalCountHeader, strconv.Itoa(len(items)))
+		}
+	}
+
+	w.ResponseWriter.Write(body)
+}
@@ -0,0 +1,162 @@
+package routes
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var pathParamPattern = regexp.MustCompile(`[:*]([A-Za-z0-9_]+)`)
+
+// Return a gin path in OpenAPI form, /voters/:id as /voters/{id}, and the
+// names of its parameters.
+func openAPIPath(path string) (string, []string) {
+	var params []string
+	for _, match := range pathParamPattern.FindAllStringSubmatch(path, -1) {
+		params = append(params, match[1])
+	}
+
+	return pathParamPattern.ReplaceAllString(path, "{$1}"), params
+}
+
+// Return the tag of a route, the first segment of its path after /admin.
+func routeTag(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) > 1 && segments[0] == "admin" {
+		return "admin"
+	}
+
+	if segments[0] == "" {
+		return "root"
+	}
+
+	return strings.TrimPrefix(segments[0], ":")
+}
+
+// Return the OpenAPI operation of a route.
+func (t *Table) operation(route Route) gin.H {
+	_, params := openAPIPath(route.Path)
+
+	parameters := make([]gin.H, 0, len(params))
+	for _, name := range params {
+		parameters = append(parameters, gin.H{
+			"name":     name,
+			"in":       "path",
+			"required": true,
+			"schema":   gin.H{"type": "string"},
+		})
+	}
+
+	operation := gin.H{
+		"summary":    route.Summary,
+		"tags":       []string{routeTag(route.Path)},
+		"parameters": parameters,
+		"responses": gin.H{
+			"2XX":     gin.H{"description": "Success"},
+			"default": gin.H{"$ref": "#/components/responses/Error"},
+		},
+	}
+
+	if len(route.Scopes) > 0 {
+		operation["x-scopes"] = route.Scopes
+
+		security := make([]gin.H, 0, len(route.Scopes))
+		for _, name := range route.Scopes {
+			if scope, ok := t.scope(name); ok && scope.Header != "" {
+				security = append(security, gin.H{scope.Name: []string{}})
+			}
+		}
+		if len(security) > 0 {
+			operation["security"] = security
+		}
+	}
+
+	if route.Limit.Requests > 0 {
+		operation["x-rate-limit"] = gin.H{
+			"requests": route.Limit.Requests,
+			"seconds":  route.Limit.Per.Seconds(),
+		}
+	}
+
+	if route.Access == Internal {
+		operation["x-internal"] = true
+	}
+
+	return operation
+}
+
+// Return the OpenAPI 3 document of the table. Internal routes are only
+// included when internal is set.
+func (t *Table) OpenAPI(internal bool) gin.H {
+	paths := make(map[string]gin.H)
+	for _, route := range t.Routes {
+		if route.Access == Internal && !internal {
+			continue
+		}
+
+		path, _ := openAPIPath(route.Path)
+		if paths[path] == nil {
+			paths[path] = gin.H{}
+		}
+		paths[path][strings.ToLower(route.Method)] = t.operation(route)
+	}
+
+	securitySchemes := gin.H{}
+	scopes := make([]gin.H, 0, len(t.Scopes))
+	for _, scope := range t.Scopes {
+		scopes = append(scopes, gin.H{"name": scope.Name, "description": scope.Description})
+		if scope.Header != "" {
+			securitySchemes[scope.Name] = gin.H{
+				"type":        "apiKey",
+				"in":          "header",
+				"name":        scope.Header,
+				"description": scope.Description,
+			}
+		}
+	}
+	sort.Slice(scopes, func(i, j int) bool { return scopes[i]["name"].(string) < scopes[j]["name"].(string) })
+
+	return gin.H{
+		"openapi": "3.0.3",
+		"info": gin.H{
+			"title":   t.Service,
+			"version": t.APIVersion(),
+		},
+		"paths":    paths,
+		"x-scopes": scopes,
+		"components": gin.H{
+			"securitySchemes": securitySchemes,
+			"responses": gin.H{
+				"Error": gin.H{
+					"description": "Error",
+					"content": gin.H{
+						"application/json": gin.H{
+							"schema": gin.H{"$ref": "#/components/schemas/Error"},
+						},
+					},
+				},
+			},
+			"schemas": gin.H{
+				"Error": gin.H{
+					"type":     "object",
+					"required": []string{"code", "message", "requestId"},
+					"properties": gin.H{
+						"code":      gin.H{"type": "string"},
+						"message":   gin.H{"type": "string"},
+						"details":   gin.H{},
+						"requestId": gin.H{"type": "string"},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Implementation of GET /openapi.json.
+// Return the OpenAPI document of the table.
+func (t *Table) ServeOpenAPI(c *gin.Context) {
+	c.JSON(http.StatusOK, t.OpenAPI(c.Query("internal") == "true"))
+}
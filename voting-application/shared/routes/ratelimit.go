@@ -0,0 +1,68 @@
+package routes
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"shared/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// limiter counts the requests of each client in fixed windows. The counts
+// are dropped when a window ends, so it holds at most the clients of one
+// window.
+type limiter struct {
+	limit Limit
+
+	mu          sync.Mutex
+	windowStart time.Time
+	counts      map[string]int
+}
+
+func newLimiter(limit Limit) *limiter {
+	return &limiter{limit: limit, counts: make(map[string]int)}
+}
+
+// Count a request of the client. It returns whether the request is
+// allowed, the requests left in the window and when the window ends.
+func (l *limiter) allow(client string, now time.Time) (bool, int, time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.windowStart) >= l.limit.Per {
+		l.windowStart = now
+		l.counts = make(map[string]int)
+	}
+
+	reset := l.windowStart.Add(l.limit.Per)
+	if l.counts[client] >= l.limit.Requests {
+		return false, 0, reset
+	}
+
+	l.counts[client]++
+
+	return true, l.limit.Requests - l.counts[client], reset
+}
+
+// The middleware that answers 429 once a client went over the limit.
+func (l *limiter) middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed, remaining, reset := l.allow(c.ClientIP(), time.Now())
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(l.limit.Requests))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+
+		if !allowed {
+			retryAfter := int(math.Ceil(time.Until(reset).Seconds()))
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			apierror.Abort(c, http.StatusTooManyRequests, apierror.CodeRateLimited, "Too many requests, retry later")
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,113 @@
+package routes
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	"shared/version"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RootPath is where Register serves the root document.
+const RootPath = "/"
+
+// Root is the document served at GET /, the entry point of a client.
+type Root struct {
+	Message    string `json:"message"`
+	Service    string `json:"service"`
+	APIVersion string `json:"apiVersion"`
+	// The API versions the service still serves.
+	SupportedVersions []string `json:"supportedVersions"`
+	Build             string   `json:"build"`
+	// Where the API is documented, the OpenAPI document unless API_DOCS_URL
+	// is set.
+	Docs string `json:"docs"`
+	// The top-level resources of the API by their name, such as polls.
+	Links map[string]Link `json:"links"`
+}
+
+// Link is a route of the API.
+type Link struct {
+	Method  string `json:"method"`
+	URL     string `json:"url"`
+	Summary string `json:"summary,omitempty"`
+}
+
+// Return the welcome message of the table, API_WELCOME_MESSAGE when set.
+func (t *Table) welcome() string {
+	if message := os.Getenv("API_WELCOME_MESSAGE"); message != "" {
+		return message
+	}
+
+	if t.Welcome != "" {
+		return t.Welcome
+	}
+
+	return "Welcome to " + t.Service + "."
+}
+
+// Return the API versions the table serves, the current one when the
+// table does not list them.
+func (t *Table) supportedVersions() []string {
+	if len(t.SupportedVersions) == 0 {
+		return []string{t.APIVersion()}
+	}
+
+	return t.SupportedVersions
+}
+
+// Return a link to each top-level resource of the public routes: the
+// GET route of the resource itself, such as GET /polls, or its shortest
+// GET route when it has none. The admin routes are left out, they are
+// listed in the OpenAPI document.
+func (t *Table) links() map[string]Link {
+	links := make(map[string]Link)
+
+	for _, route := range t.Routes {
+		if route.Method != http.MethodGet || route.Access == Internal {
+			continue
+		}
+
+		tag := routeTag(route.Path)
+		if tag == "root" || tag == "admin" || strings.ContainsAny(route.Path, ":*") {
+			continue
+		}
+
+		name := strings.TrimSuffix(tag, ".json")
+		if link, ok := links[name]; ok && len(link.URL) <= len(route.Path) {
+			continue
+		}
+
+		links[name] = Link{Method: route.Method, URL: route.Path, Summary: route.Summary}
+	}
+
+	return links
+}
+
+// Return the root document of the table.
+func (t *Table) Root() Root {
+	docs := os.Getenv("API_DOCS_URL")
+	if docs == "" {
+		docs = OpenAPIPath
+	}
+
+	info := version.Current(t.Service, t.APIVersion())
+
+	return Root{
+		Message:           t.welcome(),
+		Service:           t.Service,
+		APIVersion:        info.APIVersion,
+		SupportedVersions: t.supportedVersions(),
+		Build:             info.Build,
+		Docs:              docs,
+		Links:             t.links(),
+	}
+}
+
+// Implementation of GET /.
+// Return the root document of the table.
+func (t *Table) ServeRoot(c *gin.Context) {
+	c.JSON(http.StatusOK, t.Root())
+}
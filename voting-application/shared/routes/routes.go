@@ -0,0 +1,181 @@
+// Package routes registers the routes of a service from a declarative
+// table. Every route states its method, path, handler, the scopes it
+// requires, its rate limit and whether it is internal, so the middleware
+// of a route follows from its entry and the OpenAPI document of the
+// service is generated from the same table:
+//
+//	table := &routes.Table{
+//		Service: "voter-api",
+//		Scopes:  []routes.Scope{{Name: routes.ScopeAdmin, Description: "...", Middleware: api.AdminMiddleware()}},
+//		Routes: []routes.Route{
+//			{Method: http.MethodGet, Path: "/voters/:id", Handler: h.GetVoter, Summary: "Get a voter"},
+//			{Method: http.MethodPost, Path: "/admin/voters/search/reindex", Handler: h.RebuildSearchIndex,
+//				Summary: "Rebuild the voter search index", Scopes: []string{routes.ScopeAdmin}},
+//		},
+//	}
+//
+//	server.Run(bootstrap.WithRoutes(table.Register))
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"shared/version"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// ScopeAdmin is the scope of the routes that require the admin token.
+	ScopeAdmin = "admin"
+
+	// OpenAPIPath is where Register serves the OpenAPI document.
+	OpenAPIPath = "/openapi.json"
+)
+
+// Access tells who a route is meant for.
+type Access string
+
+const (
+	// Public routes are part of the documented API.
+	Public Access = "public"
+	// Internal routes are probes and metrics for the platform running the
+	// service, or routes only other services call. They are left out of
+	// the OpenAPI document unless asked for.
+	Internal Access = "internal"
+)
+
+// Limit caps how many requests a client can send to a route in a window.
+// The zero value is no limit.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// Return a limit of requests a minute.
+func PerMinute(requests int) Limit {
+	return Limit{Requests: requests, Per: time.Minute}
+}
+
+// Scope is a permission a route can require. Its middleware rejects the
+// requests that do not have it.
+type Scope struct {
+	Name        string
+	Description string
+	Middleware  gin.HandlerFunc
+	// The OpenAPI security scheme of the scope, an API key in this header.
+	Header string
+}
+
+// Route is an entry of a route table.
+type Route struct {
+	// Method and Path as registered with gin.
+	Method string
+	Path   string
+	// Middleware runs before the handler, after the scopes and rate limit.
+	Middleware []gin.HandlerFunc
+	Handler    gin.HandlerFunc
+	// One line describing the route in the OpenAPI document.
+	Summary string
+	// The names of the scopes the route requires.
+	Scopes []string
+	Limit  Limit
+	// Public when empty.
+	Access Access
+}
+
+// Table is the route table of a service.
+type Table struct {
+	Service string
+	// The API version, major.minor.patch, 1.0.0 when empty. It is served
+	// at GET /version and in the OpenAPI document.
+	Version string
+	// The API versions the service still serves, such as the older minor
+	// versions it is compatible with. Only Version when empty.
+	SupportedVersions []string
+	// The message of the root document, "Welcome to <service>." when
+	// empty.
+	Welcome string
+	Scopes  []Scope
+	Routes  []Route
+}
+
+// Return the API version of the table.
+func (t *Table) APIVersion() string {
+	if t.Version == "" {
+		return "1.0.0"
+	}
+
+	return t.Version
+}
+
+// Return a scope of the table.
+func (t *Table) scope(name string) (Scope, bool) {
+	for _, scope := range t.Scopes {
+		if scope.Name == name {
+			return scope, true
+		}
+	}
+
+	return Scope{}, false
+}
+
+// Return the handlers of a route in the order they run: rate limit,
+// scopes, route middleware and handler. A route that requires a scope the
+// table does not define is a programming error.
+func (t *Table) handlers(route Route) []gin.HandlerFunc {
+	var handlers []gin.HandlerFunc
+
+	if route.Limit.Requests > 0 {
+		handlers = append(handlers, newLimiter(route.Limit).middleware())
+	}
+
+	for _, name := range route.Scopes {
+		scope, ok := t.scope(name)
+		if !ok {
+			panic(fmt.Sprintf("%s %s requires undefined scope %q", route.Method, route.Path, name))
+		}
+
+		if scope.Middleware != nil {
+			handlers = append(handlers, scope.Middleware)
+		}
+	}
+
+	handlers = append(handlers, route.Middleware...)
+
+	return append(handlers, route.Handler)
+}
+
+// Register every route of the table on the engine, GET / serving the root
+// document of the table, GET /openapi.json serving its OpenAPI document
+// and GET /version. Every GET route is served for HEAD as well, and every
+// path answers OPTIONS with its methods.
+func (t *Table) Register(r *gin.Engine) {
+	t.Routes = append(t.Routes, Route{
+		Method:  http.MethodGet,
+		Path:    RootPath,
+		Handler: t.ServeRoot,
+		Summary: "Get the service, its API versions and links to its resources",
+	}, Route{
+		Method:  http.MethodGet,
+		Path:    OpenAPIPath,
+		Handler: t.ServeOpenAPI,
+		Summary: "Get the OpenAPI document of the API, add ?internal=true for the internal routes",
+	}, Route{
+		Method:  http.MethodGet,
+		Path:    version.Path,
+		Handler: t.ServeVersion,
+		Summary: "Get the API version and build of the service",
+	})
+
+	for _, route := range append(t.Routes, t.implicitRoutes()...) {
+		r.Handle(route.Method, route.Path, t.handlers(route)...)
+	}
+}
+
+// Serve the version of the service.
+func (t *Table) ServeVersion(c *gin.Context) {
+	c.JSON(http.StatusOK, version.Current(t.Service, t.APIVersion()))
+}
@@ -0,0 +1,96 @@
+package rpc
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	DefaultCallTimeout = 5 * time.Second
+)
+
+// Serve gRPC on the port in the background and return the server, so it
+// can be stopped. The register function adds the services of the caller to
+// the server. It does nothing and returns nil when the port is zero.
+func Serve(host string, port uint, register func(server *grpc.Server)) *grpc.Server {
+	if port == 0 {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("%s:%d", host, port))
+	if err != nil {
+		log.Println("Error listening for gRPC: ", err)
+		return nil
+	}
+
+	server := grpc.NewServer()
+	register(server)
+
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			log.Println("Error serving gRPC: ", err)
+		}
+	}()
+
+	return server
+}
+
+// Open a client connection to a gRPC server. The services talk to each
+// other inside the compose network, so the connection is not encrypted.
+func Dial(address string) (*grpc.ClientConn, error) {
+	return grpc.Dial(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+}
+
+// Return the gRPC code matching an HTTP status, so both interfaces report
+// the same failures.
+func CodeFromHTTPStatus(status int) codes.Code {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusGatewayTimeout:
+		return codes.Unavailable
+	}
+
+	if status >= http.StatusInternalServerError {
+		return codes.Internal
+	}
+
+	return codes.Unknown
+}
+
+// Convert a time to a timestamp, leaving nil and zero times unset.
+func Timestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+
+	return timestamppb.New(*t)
+}
+
+// Convert a timestamp to a time, nil when the timestamp is unset.
+func Time(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+
+	t := ts.AsTime()
+	return &t
+}
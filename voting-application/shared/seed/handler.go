@@ -0,0 +1,60 @@
+package seed
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"shared/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The largest fixture accepted in a request body.
+const MaxFixtureBody = 8 << 20
+
+// Return the handler of POST /admin/seed. It loads the fixture of the
+// request body, YAML when the Content-Type says so and JSON otherwise, or
+// the File fixture when the body is empty, and answers with the report.
+// ?onMissing= replaces the import policy of the fixture.
+func Handler(seeder Seeder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, MaxFixtureBody))
+		if err != nil {
+			apierror.AbortInvalidBody(c, err)
+			return
+		}
+
+		var fixture *Fixture
+		switch {
+		case len(strings.TrimSpace(string(body))) > 0:
+			fixture, err = Parse(body, strings.Contains(c.ContentType(), "yaml"))
+			if err != nil {
+				log.Println("Error parsing fixture: ", err)
+				apierror.AbortInvalidBody(c, err)
+				return
+			}
+		case File != "":
+			fixture, err = Load(File)
+			if err != nil {
+				log.Println("Error loading fixture: ", err)
+				apierror.AbortWithError(c, http.StatusInternalServerError, "Could not load seed file", err)
+				return
+			}
+		default:
+			apierror.Abort(c, http.StatusBadRequest, apierror.CodeBadRequest, "Send a fixture or start the service with -seed")
+			return
+		}
+
+		if policy := c.Query("onMissing"); policy != "" {
+			if _, err := ParsePolicy(policy); err != nil {
+				apierror.Abort(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+				return
+			}
+			fixture.OnMissing = policy
+		}
+
+		c.JSON(http.StatusOK, seeder(c.Request.Context(), fixture))
+	}
+}
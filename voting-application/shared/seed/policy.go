@@ -0,0 +1,55 @@
+package seed
+
+import (
+	"fmt"
+	"os"
+)
+
+// What an import does with the rows that reference a voter, poll or
+// option that does not exist, set by the onMissing field of a fixture, the
+// ?onMissing= query parameter or IMPORT_ON_MISSING.
+const (
+	// Import nothing when a single row references something missing.
+	PolicyRejectAll = "reject-all"
+	// Import the other rows and report the ones skipped.
+	PolicySkipAndReport = "skip-and-report"
+	// Create a placeholder voter, poll or option for the missing
+	// reference and import the row.
+	PolicyAutoCreateStub = "auto-create-stub"
+)
+
+// The outcomes of the rows of an import.
+const (
+	RowCreated  = "created"
+	RowExisting = "existing"
+	RowSkipped  = "skipped"
+	RowRejected = "rejected"
+	RowStubbed  = "stubbed"
+	RowFailed   = "failed"
+)
+
+// Return the policy named by policy, IMPORT_ON_MISSING when it is empty
+// and skip-and-report when both are.
+func ParsePolicy(policy string) (string, error) {
+	if policy == "" {
+		policy = os.Getenv("IMPORT_ON_MISSING")
+	}
+
+	switch policy {
+	case "":
+		return PolicySkipAndReport, nil
+	case PolicyRejectAll, PolicySkipAndReport, PolicyAutoCreateStub:
+		return policy, nil
+	}
+
+	return "", fmt.Errorf("unknown import policy %q, use %s, %s or %s", policy, PolicyRejectAll, PolicySkipAndReport, PolicyAutoCreateStub)
+}
+
+// Row is the outcome of a row of an import.
+type Row struct {
+	Kind    string `json:"kind"`
+	ID      uint   `json:"id"`
+	Outcome string `json:"outcome"`
+	// Why the row was not imported as is, such as the missing voter.
+	Reason string `json:"reason,omitempty"`
+}
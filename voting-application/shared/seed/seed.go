@@ -0,0 +1,184 @@
+// Package seed loads fixture data into the APIs, so demo environments and
+// integration tests start with realistic voters, polls and votes. A
+// fixture is a JSON or YAML file:
+//
+//	voters:
+//	  - {voterId: 1, firstName: Ada, lastName: Lovelace}
+//	polls:
+//	  - pollId: 1
+//	    pollTitle: Breakfast
+//	    pollQuestion: Tea or coffee?
+//	    pollOptions:
+//	      - {pollOptionId: 1, pollOptionText: Tea}
+//	      - {pollOptionId: 2, pollOptionText: Coffee}
+//	votes:
+//	  - {voteId: 1, voterId: 1, pollId: 1, optionId: 2}
+//
+// Every service loads its own part of the fixture; records that already
+// exist are skipped, so the same fixture can be loaded again.
+package seed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File is the fixture loaded at startup, set by the -seed flag or the
+// SEED_FILE environment variable. POST /admin/seed loads it again when the
+// request has no body.
+var File string
+
+// Voter is a voter of a fixture.
+type Voter struct {
+	VoterID   uint   `json:"voterId" yaml:"voterId"`
+	FirstName string `json:"firstName" yaml:"firstName"`
+	LastName  string `json:"lastName" yaml:"lastName"`
+}
+
+// PollOption is an option of a poll of a fixture.
+type PollOption struct {
+	PollOptionID   uint   `json:"pollOptionId" yaml:"pollOptionId"`
+	PollOptionText string `json:"pollOptionText" yaml:"pollOptionText"`
+	MaxVotes       uint   `json:"maxVotes,omitempty" yaml:"maxVotes,omitempty"`
+}
+
+// Poll is a poll of a fixture, with its options.
+type Poll struct {
+	PollID       uint         `json:"pollId" yaml:"pollId"`
+	PollTitle    string       `json:"pollTitle" yaml:"pollTitle"`
+	PollQuestion string       `json:"pollQuestion" yaml:"pollQuestion"`
+	Tags         []string     `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Anonymous    bool         `json:"anonymous,omitempty" yaml:"anonymous,omitempty"`
+	PollOptions  []PollOption `json:"pollOptions" yaml:"pollOptions"`
+	// Up to how many options a vote selects, single-choice when 0 or 1.
+	MaxSelections uint `json:"maxSelections,omitempty" yaml:"maxSelections,omitempty"`
+	AllowWriteIn  bool `json:"allowWriteIn,omitempty" yaml:"allowWriteIn,omitempty"`
+}
+
+// Vote is a vote of a fixture. It is cast like any other vote, so its
+// voter, poll and option must exist.
+type Vote struct {
+	VoteID   uint `json:"voteId" yaml:"voteId"`
+	VoterID  uint `json:"voterId" yaml:"voterId"`
+	PollID   uint `json:"pollId" yaml:"pollId"`
+	OptionID uint `json:"optionId" yaml:"optionId"`
+	// The options of a vote of a multi-choice poll, used instead of
+	// OptionID.
+	OptionIDs []uint `json:"optionIds,omitempty" yaml:"optionIds,omitempty"`
+	// The free text of a write-in vote, used instead of an option.
+	WriteInValue string `json:"writeInValue,omitempty" yaml:"writeInValue,omitempty"`
+}
+
+// Return the options a vote selects, none for a write-in.
+func (v Vote) Options() []uint {
+	if v.WriteInValue != "" {
+		return nil
+	}
+
+	if len(v.OptionIDs) > 0 {
+		return v.OptionIDs
+	}
+
+	return []uint{v.OptionID}
+}
+
+// Fixture is the data of a fixture file.
+type Fixture struct {
+	Voters []Voter `json:"voters" yaml:"voters"`
+	Polls  []Poll  `json:"polls" yaml:"polls"`
+	Votes  []Vote  `json:"votes" yaml:"votes"`
+	// The import policy of the votes whose voter, poll or option does not
+	// exist, see ParsePolicy.
+	OnMissing string `json:"onMissing,omitempty" yaml:"onMissing,omitempty"`
+}
+
+// Parse a fixture, as YAML when isYAML is set and as JSON otherwise.
+func Parse(data []byte, isYAML bool) (*Fixture, error) {
+	var fixture Fixture
+
+	var err error
+	if isYAML {
+		err = yaml.Unmarshal(data, &fixture)
+	} else {
+		err = json.Unmarshal(data, &fixture)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing fixture: %w", err)
+	}
+
+	return &fixture, nil
+}
+
+// Load a fixture file. Files ending in .yaml or .yml are YAML, any other
+// file is JSON.
+func Load(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	extension := strings.ToLower(filepath.Ext(path))
+
+	return Parse(data, extension == ".yaml" || extension == ".yml")
+}
+
+// Counts are the records of one kind a seeding created, skipped because
+// they already existed, or failed to create.
+type Counts struct {
+	Created int `json:"created"`
+	Skipped int `json:"skipped"`
+	Failed  int `json:"failed"`
+}
+
+// Report is what a seeding did, per kind of record.
+type Report struct {
+	Counts map[string]*Counts `json:"counts"`
+	Errors []string           `json:"errors,omitempty"`
+	// The import policy and the outcome of every row, for the seeders
+	// that apply one.
+	Policy string `json:"policy,omitempty"`
+	Rows   []Row  `json:"rows,omitempty"`
+}
+
+// Create an empty report.
+func NewReport() Report {
+	return Report{Counts: make(map[string]*Counts)}
+}
+
+func (r *Report) counts(kind string) *Counts {
+	if r.Counts[kind] == nil {
+		r.Counts[kind] = &Counts{}
+	}
+
+	return r.Counts[kind]
+}
+
+// Count a created record.
+func (r *Report) Created(kind string) {
+	r.counts(kind).Created++
+}
+
+// Count a record that already existed.
+func (r *Report) Skipped(kind string) {
+	r.counts(kind).Skipped++
+}
+
+// Count a record that could not be created.
+func (r *Report) Failed(kind string, id uint, err error) {
+	r.counts(kind).Failed++
+	r.Errors = append(r.Errors, fmt.Sprintf("%s %d: %v", kind, id, err))
+}
+
+// Record the outcome of a row.
+func (r *Report) Row(kind string, id uint, outcome, reason string) {
+	r.Rows = append(r.Rows, Row{Kind: kind, ID: id, Outcome: outcome, Reason: reason})
+}
+
+// Seeder loads the part of a fixture a service owns.
+type Seeder func(ctx context.Context, fixture *Fixture) Report
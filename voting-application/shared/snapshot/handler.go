@@ -0,0 +1,59 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"shared/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The largest part of a snapshot accepted in a request body.
+const MaxImportBody = 256 << 20
+
+// Importer adds the records of a snapshot a service owns, as they are, and
+// returns how many it added. It returns ErrNotEmpty when the service
+// already has records and an *InvalidError when a record refers to one
+// that does not exist.
+type Importer func(ctx context.Context, records []json.RawMessage) (int, error)
+
+// Return the handler of POST /admin/import. It imports the JSON array of
+// the request body, the records of one kind of an archive, and answers 409
+// when the service is not empty and 422 with the problems when records are
+// invalid.
+func Handler(kind string, importer Importer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var records []json.RawMessage
+		if err := json.NewDecoder(io.LimitReader(c.Request.Body, MaxImportBody)).Decode(&records); err != nil {
+			log.Println("Error parsing snapshot: ", err)
+			apierror.AbortInvalidBody(c, err)
+			return
+		}
+
+		imported, err := importer(c.Request.Context(), records)
+
+		var invalid *InvalidError
+		switch {
+		case errors.Is(err, ErrNotEmpty):
+			apierror.Abort(c, http.StatusConflict, apierror.CodeConflict, "Only an empty service can import a snapshot")
+			return
+		case errors.As(err, &invalid):
+			apierror.AbortWithDetails(c, http.StatusUnprocessableEntity, apierror.CodeUnprocessable, "The snapshot has invalid "+kind, invalid.Problems)
+			return
+		case err != nil:
+			log.Println("Error importing snapshot: ", err)
+			apierror.AbortWithDetails(c, http.StatusInternalServerError, apierror.CodeInternal, "Could not import snapshot", gin.H{"imported": imported, "error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":  "Snapshot imported successfully.",
+			"imported": imported,
+		})
+	}
+}
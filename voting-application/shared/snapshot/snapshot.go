@@ -0,0 +1,230 @@
+// Package snapshot is the archive of a whole system: every voter, poll and
+// vote, as the JSON exports of the APIs write them, in one versioned JSON
+// file. votectl backup writes it and votectl restore loads it into empty
+// services, to move a system from one environment to another:
+//
+//	{"version": 1, "createdAt": "2026-10-14T09:00:00Z",
+//	 "voters": [{"voterId": 1, ...}], "polls": [{"pollId": 1, ...}], "votes": [{"voteId": 1, ...}]}
+//
+// The records are kept as the services wrote them, so a restored record
+// has every field it had, such as its status and timestamps.
+package snapshot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Version is the version of the archives this package writes and reads.
+const Version = 1
+
+// Where every service imports its records.
+const ImportPath = "/admin/import"
+
+// ErrNotEmpty is returned by an import into a service that already has
+// records. A snapshot only restores into empty services, so it never
+// merges with records it does not know.
+var ErrNotEmpty = errors.New("the service already has records")
+
+// Archive is a snapshot of the system.
+type Archive struct {
+	Version   int               `json:"version"`
+	CreatedAt time.Time         `json:"createdAt"`
+	Voters    []json.RawMessage `json:"voters"`
+	Polls     []json.RawMessage `json:"polls"`
+	Votes     []json.RawMessage `json:"votes"`
+}
+
+// InvalidError lists the problems that keep records from being imported,
+// such as a vote for a poll that is not in the archive.
+type InvalidError struct {
+	Problems []string
+}
+
+func (e *InvalidError) Error() string {
+	return fmt.Sprintf("%d invalid records: %s", len(e.Problems), strings.Join(e.Problems, "; "))
+}
+
+// Create an empty archive of the current version.
+func New() *Archive {
+	return &Archive{Version: Version, CreatedAt: time.Now().UTC()}
+}
+
+// Load an archive file, refusing the versions this package cannot read.
+func Load(path string) (*Archive, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var archive Archive
+	if err := json.Unmarshal(data, &archive); err != nil {
+		return nil, fmt.Errorf("parsing snapshot: %w", err)
+	}
+
+	if archive.Version != Version {
+		return nil, fmt.Errorf("snapshot version %d is not supported, use version %d", archive.Version, Version)
+	}
+
+	return &archive, nil
+}
+
+// Write the archive to a file.
+func (a *Archive) Save(path string) error {
+	data, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// The fields of the records that refer to other records.
+type voterRefs struct {
+	VoterID     uint `json:"voterId"`
+	VoteHistory []struct {
+		PollID uint `json:"pollId"`
+	} `json:"voteHistory"`
+}
+
+type pollRefs struct {
+	PollID      uint `json:"pollId"`
+	PollOptions []struct {
+		PollOptionID uint `json:"pollOptionId"`
+	} `json:"pollOptions"`
+	EligibleVoterIDs []uint `json:"eligibleVoterIds"`
+}
+
+type voteRefs struct {
+	VoteID       uint   `json:"voteId"`
+	VoterID      uint   `json:"voterId"`
+	PollID       uint   `json:"pollId"`
+	VoteValue    uint   `json:"voteValue"`
+	OptionIDs    []uint `json:"optionIds"`
+	WriteInValue string `json:"writeInValue"`
+	VoterHash    string `json:"voterHash"`
+}
+
+// Check that every reference of the archive is in the archive: the polls
+// of the vote histories and allowlists, and the voter, poll and options of
+// every vote. It also refuses records without an ID and IDs used twice.
+// It returns an *InvalidError listing every problem.
+func (a *Archive) Validate() error {
+	var problems []string
+	problem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	voters := make(map[uint][]uint)
+	for i, record := range a.Voters {
+		var v voterRefs
+		if err := json.Unmarshal(record, &v); err != nil || v.VoterID == 0 {
+			problem("voter %d of the archive is unreadable or has no voterId", i+1)
+			continue
+		}
+		if _, ok := voters[v.VoterID]; ok {
+			problem("voter %d appears more than once", v.VoterID)
+			continue
+		}
+		history := make([]uint, len(v.VoteHistory))
+		for j, poll := range v.VoteHistory {
+			history[j] = poll.PollID
+		}
+		voters[v.VoterID] = history
+	}
+
+	polls := make(map[uint]map[uint]bool)
+	eligible := make(map[uint][]uint)
+	for i, record := range a.Polls {
+		var p pollRefs
+		if err := json.Unmarshal(record, &p); err != nil || p.PollID == 0 {
+			problem("poll %d of the archive is unreadable or has no pollId", i+1)
+			continue
+		}
+		if _, ok := polls[p.PollID]; ok {
+			problem("poll %d appears more than once", p.PollID)
+			continue
+		}
+		options := make(map[uint]bool, len(p.PollOptions))
+		for _, option := range p.PollOptions {
+			options[option.PollOptionID] = true
+		}
+		polls[p.PollID] = options
+		eligible[p.PollID] = p.EligibleVoterIDs
+	}
+
+	for _, voterID := range sortedKeys(voters) {
+		for _, pollID := range voters[voterID] {
+			if _, ok := polls[pollID]; !ok {
+				problem("voter %d: voted in poll %d, which does not exist", voterID, pollID)
+			}
+		}
+	}
+
+	for _, pollID := range sortedKeys(eligible) {
+		for _, voterID := range eligible[pollID] {
+			if _, ok := voters[voterID]; !ok {
+				problem("poll %d: allowlisted voter %d does not exist", pollID, voterID)
+			}
+		}
+	}
+
+	votes := make(map[uint]bool)
+	for i, record := range a.Votes {
+		var v voteRefs
+		if err := json.Unmarshal(record, &v); err != nil || v.VoteID == 0 {
+			problem("vote %d of the archive is unreadable or has no voteId", i+1)
+			continue
+		}
+		if votes[v.VoteID] {
+			problem("vote %d appears more than once", v.VoteID)
+			continue
+		}
+		votes[v.VoteID] = true
+
+		// The votes of anonymous polls have no voter.
+		if _, ok := voters[v.VoterID]; !ok && v.VoterHash == "" {
+			problem("vote %d: voter %d does not exist", v.VoteID, v.VoterID)
+		}
+
+		options, ok := polls[v.PollID]
+		if !ok {
+			problem("vote %d: poll %d does not exist", v.VoteID, v.PollID)
+			continue
+		}
+
+		if v.WriteInValue != "" {
+			continue
+		}
+		selected := v.OptionIDs
+		if len(selected) == 0 {
+			selected = []uint{v.VoteValue}
+		}
+		for _, optionID := range selected {
+			if !options[optionID] {
+				problem("vote %d: poll %d has no option %d", v.VoteID, v.PollID, optionID)
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return &InvalidError{Problems: problems}
+	}
+
+	return nil
+}
+
+func sortedKeys[V any](m map[uint]V) []uint {
+	keys := make([]uint, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	return keys
+}
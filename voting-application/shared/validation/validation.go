@@ -0,0 +1,172 @@
+// Package validation checks request bodies against the binding tags of the
+// API structs. A body that is not valid JSON is rejected with 400, a body
+// that breaks a rule with 422 and the fields that failed:
+//
+//	{"code": "validation_failed", "message": "Request body failed validation", "details": [{"field": "firstName", "rule": "min", "param": "2", "message": "firstName must be at least 2 characters long"}], "requestId": "..."}
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"shared/apierror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	// Vote dates before this are rejected as typos.
+	MinVoteDate = "2000-01-01T00:00:00Z"
+	// How far in the future a vote date may be, for clock skew.
+	MaxVoteDateSkew = 5 * time.Minute
+)
+
+// FieldError is a field of a request body that broke a rule.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+var register sync.Once
+
+// Register the custom rules with the validator of gin and report fields by
+// their JSON names. It is called by Bind, calling it again does nothing.
+func Register() {
+	register.Do(func() {
+		validate, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			return
+		}
+
+		validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+			if name == "-" || name == "" {
+				return field.Name
+			}
+			return name
+		})
+
+		validate.RegisterValidation("votedate", validateVoteDate)
+	})
+}
+
+// The votedate rule: an RFC3339 time, or a string holding one, between
+// MinVoteDate and MaxVoteDateSkew from now.
+func validateVoteDate(fl validator.FieldLevel) bool {
+	var voteDate time.Time
+
+	switch value := fl.Field().Interface().(type) {
+	case time.Time:
+		voteDate = value
+	case string:
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return false
+		}
+		voteDate = parsed
+	default:
+		return false
+	}
+
+	minVoteDate, _ := time.Parse(time.RFC3339, MinVoteDate)
+
+	return !voteDate.Before(minVoteDate) && !voteDate.After(time.Now().Add(MaxVoteDateSkew))
+}
+
+// Bind the JSON body of a request to obj and validate it. On failure the
+// request is aborted and the error is returned for logging.
+func Bind(c *gin.Context, obj interface{}) error {
+	Register()
+
+	if err := c.ShouldBindJSON(obj); err != nil {
+		Abort(c, err)
+		return err
+	}
+
+	return nil
+}
+
+// Abort the request because of an error returned by binding its body.
+// Validation errors get 422 and the failed fields, anything else is an
+// invalid body.
+func Abort(c *gin.Context, err error) {
+	fields, ok := Fields(err)
+	if !ok {
+		apierror.AbortInvalidBody(c, err)
+		return
+	}
+
+	AbortWithFields(c, fields)
+}
+
+// Abort the request with 422 and the fields that failed.
+func AbortWithFields(c *gin.Context, fields []FieldError) {
+	apierror.AbortWithDetails(c, http.StatusUnprocessableEntity, apierror.CodeValidationFailed, "Request body failed validation", fields)
+}
+
+// Return the failed fields of a validation error.
+func Fields(err error) ([]FieldError, bool) {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return nil, false
+	}
+
+	fields := make([]FieldError, len(validationErrors))
+	for i, fieldErr := range validationErrors {
+		fields[i] = FieldError{
+			Field:   fieldPath(fieldErr),
+			Rule:    fieldErr.Tag(),
+			Param:   fieldErr.Param(),
+			Message: message(fieldErr),
+		}
+	}
+
+	return fields, true
+}
+
+// Return the path of a field without the name of the struct, such as
+// pollOptions[0].pollOptionText.
+func fieldPath(fieldErr validator.FieldError) string {
+	namespace := fieldErr.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+
+	return fieldErr.Field()
+}
+
+func message(fieldErr validator.FieldError) string {
+	field := fieldPath(fieldErr)
+	isString := fieldErr.Kind() == reflect.String
+
+	switch fieldErr.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", field)
+	case "min":
+		if isString {
+			return fmt.Sprintf("%s must be at least %s characters long", field, fieldErr.Param())
+		}
+		return fmt.Sprintf("%s must be at least %s", field, fieldErr.Param())
+	case "max":
+		if fieldErr.Kind() == reflect.Slice {
+			return fmt.Sprintf("%s must have at most %s entries", field, fieldErr.Param())
+		}
+		if isString {
+			return fmt.Sprintf("%s must be at most %s characters long", field, fieldErr.Param())
+		}
+		return fmt.Sprintf("%s must be at most %s", field, fieldErr.Param())
+	case "votedate":
+		return fmt.Sprintf("%s must be an RFC3339 time between %s and now", field, MinVoteDate)
+	}
+
+	return fmt.Sprintf("%s failed the %s rule", field, fieldErr.Tag())
+}
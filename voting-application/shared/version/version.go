@@ -0,0 +1,270 @@
+// Package version reports the version of a service at GET /version and
+// checks that the APIs a service calls speak a version it understands.
+//
+// The API version of a service is the version of its route table,
+// major.minor.patch. The minor version grows when the API gains fields or
+// routes its callers may rely on, the major version when it breaks them.
+// A caller states the versions it needs:
+//
+//	checker := version.NewChecker([]version.Requirement{
+//		{Endpoint: endpoints.PollAPI, URL: pollAPIURL, Major: 1, Minor: 1, Reason: "anonymous polls"},
+//	})
+//	checker.Check(ctx)
+package version
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"shared/endpoints"
+)
+
+const (
+	Path = "/version"
+
+	StatusCompatible   = "compatible"
+	StatusIncompatible = "incompatible"
+	// The API could not be reached or does not serve its version.
+	StatusUnknown = "unknown"
+)
+
+// Build is the version of the build, set with
+// -ldflags "-X shared/version.Build=1.4.2".
+var Build = "dev"
+
+// Info is what GET /version reports.
+type Info struct {
+	Service    string `json:"service"`
+	APIVersion string `json:"apiVersion"`
+	Build      string `json:"build"`
+	Commit     string `json:"commit,omitempty"`
+	GoVersion  string `json:"goVersion"`
+}
+
+// Return the Info of a service with the API version apiVersion.
+func Current(service, apiVersion string) Info {
+	info := Info{
+		Service:    service,
+		APIVersion: apiVersion,
+		Build:      Build,
+		GoVersion:  runtime.Version(),
+	}
+
+	if buildInfo, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range buildInfo.Settings {
+			if setting.Key == "vcs.revision" {
+				info.Commit = setting.Value
+			}
+		}
+	}
+
+	return info
+}
+
+// Parse the major and minor numbers of an API version.
+func parse(apiVersion string) (int, int, error) {
+	parts := strings.SplitN(strings.TrimPrefix(apiVersion, "v"), ".", 3)
+	if len(parts) < 2 {
+		return 0, 0, fmt.Errorf("API version %q is not major.minor.patch", apiVersion)
+	}
+
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("API version %q is not major.minor.patch", apiVersion)
+	}
+
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("API version %q is not major.minor.patch", apiVersion)
+	}
+
+	return major, minor, nil
+}
+
+// Requirement is an API a service calls and the versions it understands:
+// the same major version, at least the minor one.
+type Requirement struct {
+	Endpoint endpoints.Endpoint
+	URL      string
+	Major    int
+	Minor    int
+	// What the service needs from that minor version.
+	Reason string
+}
+
+// Check an API version against the requirement.
+func (r Requirement) accepts(apiVersion string) error {
+	major, minor, err := parse(apiVersion)
+	if err != nil {
+		return err
+	}
+
+	if major != r.Major || minor < r.Minor {
+		return fmt.Errorf("%s speaks API %s, %d.%d or a later %d.x is required for %s", r.Endpoint.Name, apiVersion, r.Major, r.Minor, r.Major, r.Reason)
+	}
+
+	return nil
+}
+
+// Peer is the outcome of the check of a required API.
+type Peer struct {
+	Name       string    `json:"name"`
+	URL        string    `json:"url"`
+	Required   string    `json:"required"`
+	APIVersion string    `json:"apiVersion,omitempty"`
+	Build      string    `json:"build,omitempty"`
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	CheckedAt  time.Time `json:"checkedAt"`
+}
+
+// Fetch the Info an API serves at url.
+func Fetch(ctx context.Context, endpoint endpoints.Endpoint, url string) (Info, error) {
+	ctx, cancel := context.WithTimeout(ctx, endpoints.ProbeTimeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url+Path, nil)
+	if err != nil {
+		return Info{}, err
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return Info{}, endpoint.Unreachable(url, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return Info{}, fmt.Errorf("%s at %s answered %s to %s", endpoint.Name, url, response.Status, Path)
+	}
+
+	var info Info
+	if err := json.NewDecoder(response.Body).Decode(&info); err != nil {
+		return Info{}, err
+	}
+
+	if info.APIVersion == "" {
+		return Info{}, errors.New(endpoint.Name + " did not report its API version")
+	}
+
+	return info, nil
+}
+
+// Checker keeps the outcome of the checks of the required APIs.
+type Checker struct {
+	requirements []Requirement
+	lock         sync.Mutex
+	peers        []Peer
+}
+
+// Create a checker of the requirements, every API is unknown until it is
+// checked.
+func NewChecker(requirements []Requirement) *Checker {
+	checker := &Checker{requirements: requirements, peers: make([]Peer, len(requirements))}
+	for i, requirement := range requirements {
+		checker.peers[i] = Peer{
+			Name:     requirement.Endpoint.Name,
+			URL:      requirement.URL,
+			Required: fmt.Sprintf("%d.%d+", requirement.Major, requirement.Minor),
+			Status:   StatusUnknown,
+		}
+	}
+
+	return checker
+}
+
+// Check the APIs that are not known yet to be compatible or not, and
+// return the outcome for each of them.
+func (c *Checker) Check(ctx context.Context) []Peer {
+	var checked []Peer
+	for i, requirement := range c.requirements {
+		if c.peer(i).Status != StatusUnknown {
+			continue
+		}
+
+		peer := c.peer(i)
+		peer.CheckedAt = time.Now().UTC()
+
+		info, err := Fetch(ctx, requirement.Endpoint, requirement.URL)
+		if err != nil {
+			peer.Error = err.Error()
+		} else {
+			peer.APIVersion, peer.Build, peer.Error = info.APIVersion, info.Build, ""
+			peer.Status = StatusCompatible
+			if err := requirement.accepts(info.APIVersion); err != nil {
+				peer.Status, peer.Error = StatusIncompatible, err.Error()
+			}
+		}
+
+		c.lock.Lock()
+		c.peers[i] = peer
+		c.lock.Unlock()
+
+		checked = append(checked, peer)
+	}
+
+	return checked
+}
+
+func (c *Checker) peer(i int) Peer {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.peers[i]
+}
+
+// Return the outcome of the last checks.
+func (c *Checker) Peers() []Peer {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return append([]Peer(nil), c.peers...)
+}
+
+// Return the APIs found incompatible.
+func (c *Checker) Incompatible() []Peer {
+	var incompatible []Peer
+	for _, peer := range c.Peers() {
+		if peer.Status == StatusIncompatible {
+			incompatible = append(incompatible, peer)
+		}
+	}
+
+	return incompatible
+}
+
+// Report whether some API is still unknown.
+func (c *Checker) pending() bool {
+	for _, peer := range c.Peers() {
+		if peer.Status == StatusUnknown {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Check the unknown APIs every interval until all of them answered or the
+// context is done, calling checked with the outcome of every round.
+func (c *Checker) Watch(ctx context.Context, interval time.Duration, checked func([]Peer)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for c.pending() {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			checked(c.Check(ctx))
+		}
+	}
+}
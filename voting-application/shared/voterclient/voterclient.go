@@ -0,0 +1,165 @@
+// Package voterclient is the Go client of the voter API:
+//
+//	client, err := voterclient.New(restclient.Config{BaseURL: "http://localhost:1080"})
+//	voter, err := client.GetVoter(ctx, 1)
+//
+// Error responses are *restclient.Error, calls that could not reach the
+// API are *endpoints.UnreachableError.
+package voterclient
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"shared/endpoints"
+	"shared/restclient"
+)
+
+// VoterPoll is a poll in the vote history of a voter.
+type VoterPoll struct {
+	PollID   uint      `json:"pollId"`
+	VoteDate time.Time `json:"voteDate"`
+}
+
+// Voter is a voter with their vote history.
+type Voter struct {
+	VoterID     uint        `json:"voterId"`
+	FirstName   string      `json:"firstName"`
+	LastName    string      `json:"lastName"`
+	VoteHistory []VoterPoll `json:"voteHistory"`
+	// Only active voters can vote. A voter added without a status is
+	// active.
+	Status string `json:"status,omitempty"`
+	// When the voter was added, none for voters added before it was
+	// recorded.
+	RegisteredAt *time.Time `json:"registeredAt,omitempty"`
+}
+
+// Client calls the voter API.
+type Client struct {
+	rest *restclient.Client
+}
+
+// Create a client of the voter API. An empty BaseURL resolves
+// VOTER_API_URL or the service name.
+func New(config restclient.Config) (*Client, error) {
+	rest, err := restclient.New(endpoints.VoterAPI, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{rest: rest}, nil
+}
+
+// Return the URL of the voter API the client calls.
+func (c *Client) BaseURL() string {
+	return c.rest.BaseURL()
+}
+
+func voterPath(voterID uint) string {
+	return fmt.Sprintf("/voters/%d", voterID)
+}
+
+func voterPollPath(voterID, pollID uint) string {
+	return fmt.Sprintf("/voters/%d/polls/%d", voterID, pollID)
+}
+
+// Return every voter.
+func (c *Client) ListVoters(ctx context.Context) ([]Voter, error) {
+	voters := []Voter{}
+	err := c.rest.Get(ctx, "/voters", nil, &voters)
+	return voters, err
+}
+
+// Return the voters whose first or last name contains query. A limit of
+// zero uses the default of the API.
+func (c *Client) SearchVoters(ctx context.Context, query string, limit int) ([]Voter, error) {
+	params := map[string]string{"q": query}
+	if limit > 0 {
+		params["limit"] = strconv.Itoa(limit)
+	}
+
+	voters := []Voter{}
+	err := c.rest.Get(ctx, "/voters/search", params, &voters)
+	return voters, err
+}
+
+// Return a voter.
+func (c *Client) GetVoter(ctx context.Context, voterID uint) (Voter, error) {
+	var voter Voter
+	err := c.rest.Get(ctx, voterPath(voterID), nil, &voter)
+	return voter, err
+}
+
+// Add a voter with the ID, first and last name of voter.
+func (c *Client) AddVoter(ctx context.Context, voter Voter) (Voter, error) {
+	var added Voter
+	err := c.rest.Post(ctx, voterPath(voter.VoterID), voter, &added)
+	return added, err
+}
+
+// Change the first and last name of a voter.
+func (c *Client) UpdateVoter(ctx context.Context, voter Voter) (Voter, error) {
+	var updated Voter
+	err := c.rest.Put(ctx, voterPath(voter.VoterID), voter, &updated)
+	return updated, err
+}
+
+// Activate a pending or suspended voter.
+func (c *Client) ActivateVoter(ctx context.Context, voterID uint) (Voter, error) {
+	var activated Voter
+	err := c.rest.Post(ctx, voterPath(voterID)+"/activate", nil, &activated)
+	return activated, err
+}
+
+// Suspend a voter, whose votes are refused until they are activated.
+func (c *Client) SuspendVoter(ctx context.Context, voterID uint) (Voter, error) {
+	var suspended Voter
+	err := c.rest.Post(ctx, voterPath(voterID)+"/suspend", nil, &suspended)
+	return suspended, err
+}
+
+// Delete a voter.
+func (c *Client) DeleteVoter(ctx context.Context, voterID uint) error {
+	return c.rest.Delete(ctx, voterPath(voterID), nil)
+}
+
+// Return the vote history of a voter.
+func (c *Client) GetVoterHistory(ctx context.Context, voterID uint) ([]VoterPoll, error) {
+	history := []VoterPoll{}
+	err := c.rest.Get(ctx, voterPath(voterID)+"/polls", nil, &history)
+	return history, err
+}
+
+// Return a poll of the vote history of a voter.
+func (c *Client) GetVoterPoll(ctx context.Context, voterID, pollID uint) (VoterPoll, error) {
+	var voterPoll VoterPoll
+	err := c.rest.Get(ctx, voterPollPath(voterID, pollID), nil, &voterPoll)
+	return voterPoll, err
+}
+
+// Add a poll to the vote history of a voter. A zero voteDate is now.
+func (c *Client) AddVoterPoll(ctx context.Context, voterID, pollID uint, voteDate time.Time) (VoterPoll, error) {
+	body := map[string]interface{}{}
+	if !voteDate.IsZero() {
+		body["voteDate"] = voteDate
+	}
+
+	var voterPoll VoterPoll
+	err := c.rest.Post(ctx, voterPollPath(voterID, pollID), body, &voterPoll)
+	return voterPoll, err
+}
+
+// Change the vote date of a poll in the vote history of a voter.
+func (c *Client) UpdateVoterPoll(ctx context.Context, voterID, pollID uint, voteDate time.Time) (VoterPoll, error) {
+	var voterPoll VoterPoll
+	err := c.rest.Put(ctx, voterPollPath(voterID, pollID), map[string]interface{}{"voteDate": voteDate}, &voterPoll)
+	return voterPoll, err
+}
+
+// Remove a poll from the vote history of a voter.
+func (c *Client) DeleteVoterPoll(ctx context.Context, voterID, pollID uint) error {
+	return c.rest.Delete(ctx, voterPollPath(voterID, pollID), nil)
+}
@@ -0,0 +1,255 @@
+// Package votesclient is the Go client of the votes API:
+//
+//	client, err := votesclient.New(restclient.Config{BaseURL: "http://localhost:1082"})
+//	vote, err := client.AddVote(ctx, votesclient.Vote{VoteID: 1, VoterID: 1, PollID: 1, VoteValue: 2}, "")
+//
+// Error responses are *restclient.Error, calls that could not reach the
+// API are *endpoints.UnreachableError.
+package votesclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"shared/endpoints"
+	"shared/restclient"
+)
+
+const (
+	AdminTokenHeader     = "X-Admin-Token"
+	EmbargoTokenHeader   = "X-Embargo-Token"
+	IdempotencyKeyHeader = "Idempotency-Key"
+)
+
+// Vote is a vote of a voter for an option of a poll.
+type Vote struct {
+	VoteID     uint       `json:"voteId"`
+	VoterID    uint       `json:"voterId"`
+	PollID     uint       `json:"pollId"`
+	VoteValue  uint       `json:"voteValue"`
+	FlaggedAt  *time.Time `json:"flaggedAt,omitempty"`
+	FlagReason string     `json:"flagReason,omitempty"`
+	CreatedAt  *time.Time `json:"createdAt,omitempty"`
+	UpdatedAt  *time.Time `json:"updatedAt,omitempty"`
+	// When the ballot was cast, which can be earlier than CreatedAt for a
+	// ballot recorded later. Lists of votes show CreatedAt for the votes
+	// stored before it existed.
+	VoteDate *time.Time `json:"voteDate,omitempty"`
+	// The signed receipt of the vote, only set on the vote AddVote returns.
+	Receipt string `json:"receipt,omitempty"`
+	// The options of a vote of a multi-choice poll.
+	OptionIDs []uint `json:"optionIds,omitempty"`
+	// The free text of a write-in vote.
+	WriteInValue string `json:"writeInValue,omitempty"`
+	// Who casts the vote instead of VoterID, such as the holder of a
+	// ballot token of an anonymous poll.
+	Identity *Identity `json:"identity,omitempty"`
+}
+
+// The kinds of Identity.
+const (
+	IdentityVoter = "voter"
+	IdentityToken = "token"
+)
+
+// Identity is who casts a vote: a voter by VoterID, or a ballot Token.
+type Identity struct {
+	Type    string `json:"type"`
+	VoterID uint   `json:"voterId,omitempty"`
+	Token   string `json:"token,omitempty"`
+}
+
+// Receipt is the vote a receipt proves, as it was accepted.
+type Receipt struct {
+	VoteID   uint      `json:"voteId"`
+	VoterID  uint      `json:"voterId"`
+	PollID   uint      `json:"pollId"`
+	OptionID uint      `json:"optionId"`
+	CastAt   time.Time `json:"castAt"`
+}
+
+// OptionResult is the vote count of an option.
+type OptionResult struct {
+	OptionID   uint   `json:"optionId"`
+	OptionText string `json:"optionText"`
+	Votes      *uint  `json:"votes"`
+}
+
+// PollResults are the results of a poll. The counts are nil when the
+// results are not released to the caller.
+type PollResults struct {
+	PollID     uint                   `json:"pollId"`
+	PollTitle  string                 `json:"pollTitle"`
+	PollStatus string                 `json:"pollStatus"`
+	TotalVotes *uint                  `json:"totalVotes"`
+	Results    []OptionResult         `json:"results"`
+	Meta       map[string]interface{} `json:"meta"`
+}
+
+// PollCascade lists the votes and vote history entries of a poll that
+// DeletePollVotes removed, or would remove in a dry run.
+type PollCascade struct {
+	PollID          uint   `json:"pollId"`
+	DryRun          bool   `json:"dryRun"`
+	VoteIDs         []uint `json:"voteIds"`
+	OrphanedHistory []uint `json:"orphanedHistory"`
+}
+
+// VoterCascade lists the votes of a voter that DeleteVoterVotes removed,
+// or would remove in a dry run.
+type VoterCascade struct {
+	VoterID uint   `json:"voterId"`
+	DryRun  bool   `json:"dryRun"`
+	VoteIDs []uint `json:"voteIds"`
+	PollIDs []uint `json:"pollIds"`
+}
+
+// Client calls the votes API.
+type Client struct {
+	rest *restclient.Client
+}
+
+// Create a client of the votes API. An empty BaseURL resolves
+// VOTES_API_URL or the service name. Set the X-Admin-Token header in the
+// Config to read unreleased results.
+func New(config restclient.Config) (*Client, error) {
+	rest, err := restclient.New(endpoints.VotesAPI, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{rest: rest}, nil
+}
+
+// Return the URL of the votes API the client calls.
+func (c *Client) BaseURL() string {
+	return c.rest.BaseURL()
+}
+
+func votePath(voteID uint) string {
+	return fmt.Sprintf("/votes/%d", voteID)
+}
+
+// Return every vote.
+func (c *Client) ListVotes(ctx context.Context) ([]Vote, error) {
+	votes := []Vote{}
+	err := c.rest.Get(ctx, "/votes", nil, &votes)
+	return votes, err
+}
+
+// Return the votes of a voter.
+func (c *Client) ListVoterVotes(ctx context.Context, voterID uint) ([]Vote, error) {
+	votes := []Vote{}
+	err := c.rest.Get(ctx, "/votes", map[string]string{"voterId": fmt.Sprint(voterID)}, &votes)
+	return votes, err
+}
+
+// Return the votes of a poll. The votes of an anonymous poll have no
+// VoterID.
+func (c *Client) ListPollVotes(ctx context.Context, pollID uint) ([]Vote, error) {
+	votes := []Vote{}
+	err := c.rest.Get(ctx, "/votes", map[string]string{"pollId": fmt.Sprint(pollID)}, &votes)
+	return votes, err
+}
+
+// Return a vote.
+func (c *Client) GetVote(ctx context.Context, voteID uint) (Vote, error) {
+	var vote Vote
+	err := c.rest.Get(ctx, votePath(voteID), nil, &vote)
+	return vote, err
+}
+
+// Cast a vote. A non-empty idempotencyKey makes the call safe to repeat:
+// the votes API answers a repeated key with the first response.
+func (c *Client) AddVote(ctx context.Context, vote Vote, idempotencyKey string) (Vote, error) {
+	request := restclient.Request{
+		Method: http.MethodPost,
+		Path:   votePath(vote.VoteID),
+		Body:   vote,
+		Result: &Vote{},
+	}
+	if idempotencyKey != "" {
+		request.Headers = map[string]string{IdempotencyKeyHeader: idempotencyKey}
+	}
+
+	if _, err := c.rest.Do(ctx, request); err != nil {
+		return Vote{}, err
+	}
+
+	return *request.Result.(*Vote), nil
+}
+
+// Check that the vote of a receipt is still recorded unchanged. A receipt
+// that is not valid is a 400 error, a deleted vote 404 and a changed vote
+// 409.
+func (c *Client) VerifyReceipt(ctx context.Context, receipt string) (Receipt, error) {
+	var verified struct {
+		Vote Receipt `json:"vote"`
+	}
+	err := c.rest.Get(ctx, "/votes/verify/"+url.PathEscape(receipt), nil, &verified)
+	return verified.Vote, err
+}
+
+// Delete a vote, removing it from the vote history of its voter.
+func (c *Client) DeleteVote(ctx context.Context, voteID uint) error {
+	return c.rest.Delete(ctx, votePath(voteID), nil)
+}
+
+// Delete the votes of a poll and strip the poll from the vote histories of
+// the voters. With dryRun nothing is removed.
+func (c *Client) DeletePollVotes(ctx context.Context, pollID uint, dryRun bool) (PollCascade, error) {
+	request := restclient.Request{
+		Method: http.MethodDelete,
+		Path:   fmt.Sprintf("/votes/by-poll/%d", pollID),
+		Result: &PollCascade{},
+	}
+	if dryRun {
+		request.Query = map[string]string{"dryRun": "true"}
+	}
+
+	if _, err := c.rest.Do(ctx, request); err != nil {
+		return PollCascade{}, err
+	}
+
+	return *request.Result.(*PollCascade), nil
+}
+
+// Delete the votes of a voter. With dryRun nothing is removed.
+func (c *Client) DeleteVoterVotes(ctx context.Context, voterID uint, dryRun bool) (VoterCascade, error) {
+	request := restclient.Request{
+		Method: http.MethodDelete,
+		Path:   fmt.Sprintf("/votes/by-voter/%d", voterID),
+		Result: &VoterCascade{},
+	}
+	if dryRun {
+		request.Query = map[string]string{"dryRun": "true"}
+	}
+
+	if _, err := c.rest.Do(ctx, request); err != nil {
+		return VoterCascade{}, err
+	}
+
+	return *request.Result.(*VoterCascade), nil
+}
+
+// Return the results of a poll. A non-empty embargoToken releases results
+// that are under embargo.
+func (c *Client) GetPollResults(ctx context.Context, pollID uint, embargoToken string) (PollResults, error) {
+	request := restclient.Request{
+		Method: http.MethodGet,
+		Path:   fmt.Sprintf("/votes/results/%d", pollID),
+		Result: &PollResults{},
+	}
+	if embargoToken != "" {
+		request.Headers = map[string]string{EmbargoTokenHeader: embargoToken}
+	}
+
+	if _, err := c.rest.Do(ctx, request); err != nil {
+		return PollResults{}, err
+	}
+
+	return *request.Result.(*PollResults), nil
+}
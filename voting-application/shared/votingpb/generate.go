@@ -0,0 +1,5 @@
+// Package votingpb holds the gRPC interface of the voting services,
+// generated from the schemas in shared/proto.
+package votingpb
+
+//go:generate protoc -I ../proto --go_out=.. --go_opt=module=shared --go-grpc_out=.. --go-grpc_opt=module=shared voter.proto poll.proto vote.proto
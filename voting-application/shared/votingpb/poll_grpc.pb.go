@@ -0,0 +1,258 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: poll.proto
+
+package votingpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	PollService_ListPolls_FullMethodName  = "/voting.v1.PollService/ListPolls"
+	PollService_GetPoll_FullMethodName    = "/voting.v1.PollService/GetPoll"
+	PollService_AddPoll_FullMethodName    = "/voting.v1.PollService/AddPoll"
+	PollService_UpdatePoll_FullMethodName = "/voting.v1.PollService/UpdatePoll"
+	PollService_DeletePoll_FullMethodName = "/voting.v1.PollService/DeletePoll"
+)
+
+// PollServiceClient is the client API for PollService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type PollServiceClient interface {
+	ListPolls(ctx context.Context, in *ListPollsRequest, opts ...grpc.CallOption) (*ListPollsResponse, error)
+	GetPoll(ctx context.Context, in *GetPollRequest, opts ...grpc.CallOption) (*Poll, error)
+	AddPoll(ctx context.Context, in *AddPollRequest, opts ...grpc.CallOption) (*Poll, error)
+	UpdatePoll(ctx context.Context, in *UpdatePollRequest, opts ...grpc.CallOption) (*Poll, error)
+	DeletePoll(ctx context.Context, in *DeletePollRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type pollServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPollServiceClient(cc grpc.ClientConnInterface) PollServiceClient {
+	return &pollServiceClient{cc}
+}
+
+func (c *pollServiceClient) ListPolls(ctx context.Context, in *ListPollsRequest, opts ...grpc.CallOption) (*ListPollsResponse, error) {
+	out := new(ListPollsResponse)
+	err := c.cc.Invoke(ctx, PollService_ListPolls_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pollServiceClient) GetPoll(ctx context.Context, in *GetPollRequest, opts ...grpc.CallOption) (*Poll, error) {
+	out := new(Poll)
+	err := c.cc.Invoke(ctx, PollService_GetPoll_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pollServiceClient) AddPoll(ctx context.Context, in *AddPollRequest, opts ...grpc.CallOption) (*Poll, error) {
+	out := new(Poll)
+	err := c.cc.Invoke(ctx, PollService_AddPoll_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pollServiceClient) UpdatePoll(ctx context.Context, in *UpdatePollRequest, opts ...grpc.CallOption) (*Poll, error) {
+	out := new(Poll)
+	err := c.cc.Invoke(ctx, PollService_UpdatePoll_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pollServiceClient) DeletePoll(ctx context.Context, in *DeletePollRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, PollService_DeletePoll_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PollServiceServer is the server API for PollService service.
+// All implementations must embed UnimplementedPollServiceServer
+// for forward compatibility
+type PollServiceServer interface {
+	ListPolls(context.Context, *ListPollsRequest) (*ListPollsResponse, error)
+	GetPoll(context.Context, *GetPollRequest) (*Poll, error)
+	AddPoll(context.Context, *AddPollRequest) (*Poll, error)
+	UpdatePoll(context.Context, *UpdatePollRequest) (*Poll, error)
+	DeletePoll(context.Context, *DeletePollRequest) (*emptypb.Empty, error)
+	mustEmbedUnimplementedPollServiceServer()
+}
+
+// UnimplementedPollServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedPollServiceServer struct {
+}
+
+func (UnimplementedPollServiceServer) ListPolls(context.Context, *ListPollsRequest) (*ListPollsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPolls not implemented")
+}
+func (UnimplementedPollServiceServer) GetPoll(context.Context, *GetPollRequest) (*Poll, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPoll not implemented")
+}
+func (UnimplementedPollServiceServer) AddPoll(context.Context, *AddPollRequest) (*Poll, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddPoll not implemented")
+}
+func (UnimplementedPollServiceServer) UpdatePoll(context.Context, *UpdatePollRequest) (*Poll, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdatePoll not implemented")
+}
+func (UnimplementedPollServiceServer) DeletePoll(context.Context, *DeletePollRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeletePoll not implemented")
+}
+func (UnimplementedPollServiceServer) mustEmbedUnimplementedPollServiceServer() {}
+
+// UnsafePollServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PollServiceServer will
+// result in compilation errors.
+type UnsafePollServiceServer interface {
+	mustEmbedUnimplementedPollServiceServer()
+}
+
+func RegisterPollServiceServer(s grpc.ServiceRegistrar, srv PollServiceServer) {
+	s.RegisterService(&PollService_ServiceDesc, srv)
+}
+
+func _PollService_ListPolls_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPollsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PollServiceServer).ListPolls(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PollService_ListPolls_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PollServiceServer).ListPolls(ctx, req.(*ListPollsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PollService_GetPoll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPollRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PollServiceServer).GetPoll(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PollService_GetPoll_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PollServiceServer).GetPoll(ctx, req.(*GetPollRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PollService_AddPoll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddPollRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PollServiceServer).AddPoll(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PollService_AddPoll_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PollServiceServer).AddPoll(ctx, req.(*AddPollRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PollService_UpdatePoll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdatePollRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PollServiceServer).UpdatePoll(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PollService_UpdatePoll_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PollServiceServer).UpdatePoll(ctx, req.(*UpdatePollRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PollService_DeletePoll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeletePollRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PollServiceServer).DeletePoll(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PollService_DeletePoll_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PollServiceServer).DeletePoll(ctx, req.(*DeletePollRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// PollService_ServiceDesc is the grpc.ServiceDesc for PollService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PollService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "voting.v1.PollService",
+	HandlerType: (*PollServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListPolls",
+			Handler:    _PollService_ListPolls_Handler,
+		},
+		{
+			MethodName: "GetPoll",
+			Handler:    _PollService_GetPoll_Handler,
+		},
+		{
+			MethodName: "AddPoll",
+			Handler:    _PollService_AddPoll_Handler,
+		},
+		{
+			MethodName: "UpdatePoll",
+			Handler:    _PollService_UpdatePoll_Handler,
+		},
+		{
+			MethodName: "DeletePoll",
+			Handler:    _PollService_DeletePoll_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "poll.proto",
+}
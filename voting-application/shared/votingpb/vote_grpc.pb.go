@@ -0,0 +1,221 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: vote.proto
+
+package votingpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	VoteService_ListVotes_FullMethodName  = "/voting.v1.VoteService/ListVotes"
+	VoteService_GetVote_FullMethodName    = "/voting.v1.VoteService/GetVote"
+	VoteService_AddVote_FullMethodName    = "/voting.v1.VoteService/AddVote"
+	VoteService_DeleteVote_FullMethodName = "/voting.v1.VoteService/DeleteVote"
+)
+
+// VoteServiceClient is the client API for VoteService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type VoteServiceClient interface {
+	ListVotes(ctx context.Context, in *ListVotesRequest, opts ...grpc.CallOption) (*ListVotesResponse, error)
+	GetVote(ctx context.Context, in *GetVoteRequest, opts ...grpc.CallOption) (*Vote, error)
+	AddVote(ctx context.Context, in *AddVoteRequest, opts ...grpc.CallOption) (*Vote, error)
+	DeleteVote(ctx context.Context, in *DeleteVoteRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type voteServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewVoteServiceClient(cc grpc.ClientConnInterface) VoteServiceClient {
+	return &voteServiceClient{cc}
+}
+
+func (c *voteServiceClient) ListVotes(ctx context.Context, in *ListVotesRequest, opts ...grpc.CallOption) (*ListVotesResponse, error) {
+	out := new(ListVotesResponse)
+	err := c.cc.Invoke(ctx, VoteService_ListVotes_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *voteServiceClient) GetVote(ctx context.Context, in *GetVoteRequest, opts ...grpc.CallOption) (*Vote, error) {
+	out := new(Vote)
+	err := c.cc.Invoke(ctx, VoteService_GetVote_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *voteServiceClient) AddVote(ctx context.Context, in *AddVoteRequest, opts ...grpc.CallOption) (*Vote, error) {
+	out := new(Vote)
+	err := c.cc.Invoke(ctx, VoteService_AddVote_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *voteServiceClient) DeleteVote(ctx context.Context, in *DeleteVoteRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, VoteService_DeleteVote_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// VoteServiceServer is the server API for VoteService service.
+// All implementations must embed UnimplementedVoteServiceServer
+// for forward compatibility
+type VoteServiceServer interface {
+	ListVotes(context.Context, *ListVotesRequest) (*ListVotesResponse, error)
+	GetVote(context.Context, *GetVoteRequest) (*Vote, error)
+	AddVote(context.Context, *AddVoteRequest) (*Vote, error)
+	DeleteVote(context.Context, *DeleteVoteRequest) (*emptypb.Empty, error)
+	mustEmbedUnimplementedVoteServiceServer()
+}
+
+// UnimplementedVoteServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedVoteServiceServer struct {
+}
+
+func (UnimplementedVoteServiceServer) ListVotes(context.Context, *ListVotesRequest) (*ListVotesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListVotes not implemented")
+}
+func (UnimplementedVoteServiceServer) GetVote(context.Context, *GetVoteRequest) (*Vote, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetVote not implemented")
+}
+func (UnimplementedVoteServiceServer) AddVote(context.Context, *AddVoteRequest) (*Vote, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddVote not implemented")
+}
+func (UnimplementedVoteServiceServer) DeleteVote(context.Context, *DeleteVoteRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteVote not implemented")
+}
+func (UnimplementedVoteServiceServer) mustEmbedUnimplementedVoteServiceServer() {}
+
+// UnsafeVoteServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to VoteServiceServer will
+// result in compilation errors.
+type UnsafeVoteServiceServer interface {
+	mustEmbedUnimplementedVoteServiceServer()
+}
+
+func RegisterVoteServiceServer(s grpc.ServiceRegistrar, srv VoteServiceServer) {
+	s.RegisterService(&VoteService_ServiceDesc, srv)
+}
+
+func _VoteService_ListVotes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListVotesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VoteServiceServer).ListVotes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VoteService_ListVotes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VoteServiceServer).ListVotes(ctx, req.(*ListVotesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VoteService_GetVote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetVoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VoteServiceServer).GetVote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VoteService_GetVote_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VoteServiceServer).GetVote(ctx, req.(*GetVoteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VoteService_AddVote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddVoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VoteServiceServer).AddVote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VoteService_AddVote_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VoteServiceServer).AddVote(ctx, req.(*AddVoteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VoteService_DeleteVote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteVoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VoteServiceServer).DeleteVote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VoteService_DeleteVote_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VoteServiceServer).DeleteVote(ctx, req.(*DeleteVoteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// VoteService_ServiceDesc is the grpc.ServiceDesc for VoteService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var VoteService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "voting.v1.VoteService",
+	HandlerType: (*VoteServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListVotes",
+			Handler:    _VoteService_ListVotes_Handler,
+		},
+		{
+			MethodName: "GetVote",
+			Handler:    _VoteService_GetVote_Handler,
+		},
+		{
+			MethodName: "AddVote",
+			Handler:    _VoteService_AddVote_Handler,
+		},
+		{
+			MethodName: "DeleteVote",
+			Handler:    _VoteService_DeleteVote_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "vote.proto",
+}
@@ -0,0 +1,835 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.30.0
+// 	protoc        (unknown)
+// source: voter.proto
+
+package votingpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// VoterPoll is a poll the voter voted in.
+type VoterPoll struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PollId   uint32                 `protobuf:"varint,1,opt,name=poll_id,json=pollId,proto3" json:"poll_id,omitempty"`
+	VoteDate *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=vote_date,json=voteDate,proto3" json:"vote_date,omitempty"`
+}
+
+func (x *VoterPoll) Reset() {
+	*x = VoterPoll{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_voter_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *VoterPoll) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VoterPoll) ProtoMessage() {}
+
+func (x *VoterPoll) ProtoReflect() protoreflect.Message {
+	mi := &file_voter_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VoterPoll.ProtoReflect.Descriptor instead.
+func (*VoterPoll) Descriptor() ([]byte, []int) {
+	return file_voter_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *VoterPoll) GetPollId() uint32 {
+	if x != nil {
+		return x.PollId
+	}
+	return 0
+}
+
+func (x *VoterPoll) GetVoteDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.VoteDate
+	}
+	return nil
+}
+
+// Voter is a voter with their vote history.
+type Voter struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	VoterId     uint32       `protobuf:"varint,1,opt,name=voter_id,json=voterId,proto3" json:"voter_id,omitempty"`
+	FirstName   string       `protobuf:"bytes,2,opt,name=first_name,json=firstName,proto3" json:"first_name,omitempty"`
+	LastName    string       `protobuf:"bytes,3,opt,name=last_name,json=lastName,proto3" json:"last_name,omitempty"`
+	VoteHistory []*VoterPoll `protobuf:"bytes,4,rep,name=vote_history,json=voteHistory,proto3" json:"vote_history,omitempty"`
+}
+
+func (x *Voter) Reset() {
+	*x = Voter{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_voter_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Voter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Voter) ProtoMessage() {}
+
+func (x *Voter) ProtoReflect() protoreflect.Message {
+	mi := &file_voter_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Voter.ProtoReflect.Descriptor instead.
+func (*Voter) Descriptor() ([]byte, []int) {
+	return file_voter_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Voter) GetVoterId() uint32 {
+	if x != nil {
+		return x.VoterId
+	}
+	return 0
+}
+
+func (x *Voter) GetFirstName() string {
+	if x != nil {
+		return x.FirstName
+	}
+	return ""
+}
+
+func (x *Voter) GetLastName() string {
+	if x != nil {
+		return x.LastName
+	}
+	return ""
+}
+
+func (x *Voter) GetVoteHistory() []*VoterPoll {
+	if x != nil {
+		return x.VoteHistory
+	}
+	return nil
+}
+
+type ListVotersRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ListVotersRequest) Reset() {
+	*x = ListVotersRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_voter_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListVotersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListVotersRequest) ProtoMessage() {}
+
+func (x *ListVotersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_voter_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListVotersRequest.ProtoReflect.Descriptor instead.
+func (*ListVotersRequest) Descriptor() ([]byte, []int) {
+	return file_voter_proto_rawDescGZIP(), []int{2}
+}
+
+type ListVotersResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Voters []*Voter `protobuf:"bytes,1,rep,name=voters,proto3" json:"voters,omitempty"`
+}
+
+func (x *ListVotersResponse) Reset() {
+	*x = ListVotersResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_voter_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListVotersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListVotersResponse) ProtoMessage() {}
+
+func (x *ListVotersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_voter_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListVotersResponse.ProtoReflect.Descriptor instead.
+func (*ListVotersResponse) Descriptor() ([]byte, []int) {
+	return file_voter_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListVotersResponse) GetVoters() []*Voter {
+	if x != nil {
+		return x.Voters
+	}
+	return nil
+}
+
+type GetVoterRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	VoterId uint32 `protobuf:"varint,1,opt,name=voter_id,json=voterId,proto3" json:"voter_id,omitempty"`
+}
+
+func (x *GetVoterRequest) Reset() {
+	*x = GetVoterRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_voter_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetVoterRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetVoterRequest) ProtoMessage() {}
+
+func (x *GetVoterRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_voter_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetVoterRequest.ProtoReflect.Descriptor instead.
+func (*GetVoterRequest) Descriptor() ([]byte, []int) {
+	return file_voter_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetVoterRequest) GetVoterId() uint32 {
+	if x != nil {
+		return x.VoterId
+	}
+	return 0
+}
+
+type AddVoterRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Voter *Voter `protobuf:"bytes,1,opt,name=voter,proto3" json:"voter,omitempty"`
+}
+
+func (x *AddVoterRequest) Reset() {
+	*x = AddVoterRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_voter_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AddVoterRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddVoterRequest) ProtoMessage() {}
+
+func (x *AddVoterRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_voter_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddVoterRequest.ProtoReflect.Descriptor instead.
+func (*AddVoterRequest) Descriptor() ([]byte, []int) {
+	return file_voter_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *AddVoterRequest) GetVoter() *Voter {
+	if x != nil {
+		return x.Voter
+	}
+	return nil
+}
+
+type UpdateVoterRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Voter *Voter `protobuf:"bytes,1,opt,name=voter,proto3" json:"voter,omitempty"`
+}
+
+func (x *UpdateVoterRequest) Reset() {
+	*x = UpdateVoterRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_voter_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *UpdateVoterRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateVoterRequest) ProtoMessage() {}
+
+func (x *UpdateVoterRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_voter_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateVoterRequest.ProtoReflect.Descriptor instead.
+func (*UpdateVoterRequest) Descriptor() ([]byte, []int) {
+	return file_voter_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *UpdateVoterRequest) GetVoter() *Voter {
+	if x != nil {
+		return x.Voter
+	}
+	return nil
+}
+
+type DeleteVoterRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	VoterId uint32 `protobuf:"varint,1,opt,name=voter_id,json=voterId,proto3" json:"voter_id,omitempty"`
+}
+
+func (x *DeleteVoterRequest) Reset() {
+	*x = DeleteVoterRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_voter_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DeleteVoterRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteVoterRequest) ProtoMessage() {}
+
+func (x *DeleteVoterRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_voter_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteVoterRequest.ProtoReflect.Descriptor instead.
+func (*DeleteVoterRequest) Descriptor() ([]byte, []int) {
+	return file_voter_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *DeleteVoterRequest) GetVoterId() uint32 {
+	if x != nil {
+		return x.VoterId
+	}
+	return 0
+}
+
+type AddVoterPollRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	VoterId  uint32                 `protobuf:"varint,1,opt,name=voter_id,json=voterId,proto3" json:"voter_id,omitempty"`
+	PollId   uint32                 `protobuf:"varint,2,opt,name=poll_id,json=pollId,proto3" json:"poll_id,omitempty"`
+	VoteDate *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=vote_date,json=voteDate,proto3" json:"vote_date,omitempty"`
+}
+
+func (x *AddVoterPollRequest) Reset() {
+	*x = AddVoterPollRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_voter_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AddVoterPollRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddVoterPollRequest) ProtoMessage() {}
+
+func (x *AddVoterPollRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_voter_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddVoterPollRequest.ProtoReflect.Descriptor instead.
+func (*AddVoterPollRequest) Descriptor() ([]byte, []int) {
+	return file_voter_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *AddVoterPollRequest) GetVoterId() uint32 {
+	if x != nil {
+		return x.VoterId
+	}
+	return 0
+}
+
+func (x *AddVoterPollRequest) GetPollId() uint32 {
+	if x != nil {
+		return x.PollId
+	}
+	return 0
+}
+
+func (x *AddVoterPollRequest) GetVoteDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.VoteDate
+	}
+	return nil
+}
+
+type DeleteVoterPollRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	VoterId uint32 `protobuf:"varint,1,opt,name=voter_id,json=voterId,proto3" json:"voter_id,omitempty"`
+	PollId  uint32 `protobuf:"varint,2,opt,name=poll_id,json=pollId,proto3" json:"poll_id,omitempty"`
+}
+
+func (x *DeleteVoterPollRequest) Reset() {
+	*x = DeleteVoterPollRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_voter_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DeleteVoterPollRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteVoterPollRequest) ProtoMessage() {}
+
+func (x *DeleteVoterPollRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_voter_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteVoterPollRequest.ProtoReflect.Descriptor instead.
+func (*DeleteVoterPollRequest) Descriptor() ([]byte, []int) {
+	return file_voter_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DeleteVoterPollRequest) GetVoterId() uint32 {
+	if x != nil {
+		return x.VoterId
+	}
+	return 0
+}
+
+func (x *DeleteVoterPollRequest) GetPollId() uint32 {
+	if x != nil {
+		return x.PollId
+	}
+	return 0
+}
+
+var File_voter_proto protoreflect.FileDescriptor
+
+var file_voter_proto_rawDesc = []byte{
+	0x0a, 0x0b, 0x76, 0x6f, 0x74, 0x65, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x09, 0x76,
+	0x6f, 0x74, 0x69, 0x6e, 0x67, 0x2e, 0x76, 0x31, 0x1a, 0x1b, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x65, 0x6d, 0x70, 0x74, 0x79, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x5d, 0x0a, 0x09, 0x56, 0x6f, 0x74, 0x65, 0x72, 0x50,
+	0x6f, 0x6c, 0x6c, 0x12, 0x17, 0x0a, 0x07, 0x70, 0x6f, 0x6c, 0x6c, 0x5f, 0x69, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x70, 0x6f, 0x6c, 0x6c, 0x49, 0x64, 0x12, 0x37, 0x0a, 0x09,
+	0x76, 0x6f, 0x74, 0x65, 0x5f, 0x64, 0x61, 0x74, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x08, 0x76, 0x6f, 0x74,
+	0x65, 0x44, 0x61, 0x74, 0x65, 0x22, 0x97, 0x01, 0x0a, 0x05, 0x56, 0x6f, 0x74, 0x65, 0x72, 0x12,
+	0x19, 0x0a, 0x08, 0x76, 0x6f, 0x74, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x07, 0x76, 0x6f, 0x74, 0x65, 0x72, 0x49, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x66, 0x69,
+	0x72, 0x73, 0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09,
+	0x66, 0x69, 0x72, 0x73, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x6c, 0x61, 0x73,
+	0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x6c, 0x61,
+	0x73, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x37, 0x0a, 0x0c, 0x76, 0x6f, 0x74, 0x65, 0x5f, 0x68,
+	0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x76,
+	0x6f, 0x74, 0x69, 0x6e, 0x67, 0x2e, 0x76, 0x31, 0x2e, 0x56, 0x6f, 0x74, 0x65, 0x72, 0x50, 0x6f,
+	0x6c, 0x6c, 0x52, 0x0b, 0x76, 0x6f, 0x74, 0x65, 0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x22,
+	0x13, 0x0a, 0x11, 0x4c, 0x69, 0x73, 0x74, 0x56, 0x6f, 0x74, 0x65, 0x72, 0x73, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x22, 0x3e, 0x0a, 0x12, 0x4c, 0x69, 0x73, 0x74, 0x56, 0x6f, 0x74, 0x65,
+	0x72, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x28, 0x0a, 0x06, 0x76, 0x6f,
+	0x74, 0x65, 0x72, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x76, 0x6f, 0x74,
+	0x69, 0x6e, 0x67, 0x2e, 0x76, 0x31, 0x2e, 0x56, 0x6f, 0x74, 0x65, 0x72, 0x52, 0x06, 0x76, 0x6f,
+	0x74, 0x65, 0x72, 0x73, 0x22, 0x2c, 0x0a, 0x0f, 0x47, 0x65, 0x74, 0x56, 0x6f, 0x74, 0x65, 0x72,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x19, 0x0a, 0x08, 0x76, 0x6f, 0x74, 0x65, 0x72,
+	0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x76, 0x6f, 0x74, 0x65, 0x72,
+	0x49, 0x64, 0x22, 0x39, 0x0a, 0x0f, 0x41, 0x64, 0x64, 0x56, 0x6f, 0x74, 0x65, 0x72, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x26, 0x0a, 0x05, 0x76, 0x6f, 0x74, 0x65, 0x72, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x76, 0x6f, 0x74, 0x69, 0x6e, 0x67, 0x2e, 0x76, 0x31,
+	0x2e, 0x56, 0x6f, 0x74, 0x65, 0x72, 0x52, 0x05, 0x76, 0x6f, 0x74, 0x65, 0x72, 0x22, 0x3c, 0x0a,
+	0x12, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x56, 0x6f, 0x74, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x26, 0x0a, 0x05, 0x76, 0x6f, 0x74, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x10, 0x2e, 0x76, 0x6f, 0x74, 0x69, 0x6e, 0x67, 0x2e, 0x76, 0x31, 0x2e, 0x56,
+	0x6f, 0x74, 0x65, 0x72, 0x52, 0x05, 0x76, 0x6f, 0x74, 0x65, 0x72, 0x22, 0x2f, 0x0a, 0x12, 0x44,
+	0x65, 0x6c, 0x65, 0x74, 0x65, 0x56, 0x6f, 0x74, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x19, 0x0a, 0x08, 0x76, 0x6f, 0x74, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x07, 0x76, 0x6f, 0x74, 0x65, 0x72, 0x49, 0x64, 0x22, 0x82, 0x01, 0x0a,
+	0x13, 0x41, 0x64, 0x64, 0x56, 0x6f, 0x74, 0x65, 0x72, 0x50, 0x6f, 0x6c, 0x6c, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x19, 0x0a, 0x08, 0x76, 0x6f, 0x74, 0x65, 0x72, 0x5f, 0x69, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x76, 0x6f, 0x74, 0x65, 0x72, 0x49, 0x64, 0x12,
+	0x17, 0x0a, 0x07, 0x70, 0x6f, 0x6c, 0x6c, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x06, 0x70, 0x6f, 0x6c, 0x6c, 0x49, 0x64, 0x12, 0x37, 0x0a, 0x09, 0x76, 0x6f, 0x74, 0x65,
+	0x5f, 0x64, 0x61, 0x74, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x08, 0x76, 0x6f, 0x74, 0x65, 0x44, 0x61, 0x74,
+	0x65, 0x22, 0x4c, 0x0a, 0x16, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x56, 0x6f, 0x74, 0x65, 0x72,
+	0x50, 0x6f, 0x6c, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x19, 0x0a, 0x08, 0x76,
+	0x6f, 0x74, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x76,
+	0x6f, 0x74, 0x65, 0x72, 0x49, 0x64, 0x12, 0x17, 0x0a, 0x07, 0x70, 0x6f, 0x6c, 0x6c, 0x5f, 0x69,
+	0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x70, 0x6f, 0x6c, 0x6c, 0x49, 0x64, 0x32,
+	0xe7, 0x03, 0x0a, 0x0c, 0x56, 0x6f, 0x74, 0x65, 0x72, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65,
+	0x12, 0x49, 0x0a, 0x0a, 0x4c, 0x69, 0x73, 0x74, 0x56, 0x6f, 0x74, 0x65, 0x72, 0x73, 0x12, 0x1c,
+	0x2e, 0x76, 0x6f, 0x74, 0x69, 0x6e, 0x67, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x56,
+	0x6f, 0x74, 0x65, 0x72, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x76,
+	0x6f, 0x74, 0x69, 0x6e, 0x67, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x56, 0x6f, 0x74,
+	0x65, 0x72, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x38, 0x0a, 0x08, 0x47,
+	0x65, 0x74, 0x56, 0x6f, 0x74, 0x65, 0x72, 0x12, 0x1a, 0x2e, 0x76, 0x6f, 0x74, 0x69, 0x6e, 0x67,
+	0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x56, 0x6f, 0x74, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x10, 0x2e, 0x76, 0x6f, 0x74, 0x69, 0x6e, 0x67, 0x2e, 0x76, 0x31, 0x2e,
+	0x56, 0x6f, 0x74, 0x65, 0x72, 0x12, 0x38, 0x0a, 0x08, 0x41, 0x64, 0x64, 0x56, 0x6f, 0x74, 0x65,
+	0x72, 0x12, 0x1a, 0x2e, 0x76, 0x6f, 0x74, 0x69, 0x6e, 0x67, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x64,
+	0x64, 0x56, 0x6f, 0x74, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x10, 0x2e,
+	0x76, 0x6f, 0x74, 0x69, 0x6e, 0x67, 0x2e, 0x76, 0x31, 0x2e, 0x56, 0x6f, 0x74, 0x65, 0x72, 0x12,
+	0x3e, 0x0a, 0x0b, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x56, 0x6f, 0x74, 0x65, 0x72, 0x12, 0x1d,
+	0x2e, 0x76, 0x6f, 0x74, 0x69, 0x6e, 0x67, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x70, 0x64, 0x61, 0x74,
+	0x65, 0x56, 0x6f, 0x74, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x10, 0x2e,
+	0x76, 0x6f, 0x74, 0x69, 0x6e, 0x67, 0x2e, 0x76, 0x31, 0x2e, 0x56, 0x6f, 0x74, 0x65, 0x72, 0x12,
+	0x44, 0x0a, 0x0b, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x56, 0x6f, 0x74, 0x65, 0x72, 0x12, 0x1d,
+	0x2e, 0x76, 0x6f, 0x74, 0x69, 0x6e, 0x67, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74,
+	0x65, 0x56, 0x6f, 0x74, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e,
+	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
+	0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x44, 0x0a, 0x0c, 0x41, 0x64, 0x64, 0x56, 0x6f, 0x74, 0x65,
+	0x72, 0x50, 0x6f, 0x6c, 0x6c, 0x12, 0x1e, 0x2e, 0x76, 0x6f, 0x74, 0x69, 0x6e, 0x67, 0x2e, 0x76,
+	0x31, 0x2e, 0x41, 0x64, 0x64, 0x56, 0x6f, 0x74, 0x65, 0x72, 0x50, 0x6f, 0x6c, 0x6c, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x76, 0x6f, 0x74, 0x69, 0x6e, 0x67, 0x2e, 0x76,
+	0x31, 0x2e, 0x56, 0x6f, 0x74, 0x65, 0x72, 0x50, 0x6f, 0x6c, 0x6c, 0x12, 0x4c, 0x0a, 0x0f, 0x44,
+	0x65, 0x6c, 0x65, 0x74, 0x65, 0x56, 0x6f, 0x74, 0x65, 0x72, 0x50, 0x6f, 0x6c, 0x6c, 0x12, 0x21,
+	0x2e, 0x76, 0x6f, 0x74, 0x69, 0x6e, 0x67, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74,
+	0x65, 0x56, 0x6f, 0x74, 0x65, 0x72, 0x50, 0x6f, 0x6c, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x42, 0x11, 0x5a, 0x0f, 0x73, 0x68, 0x61,
+	0x72, 0x65, 0x64, 0x2f, 0x76, 0x6f, 0x74, 0x69, 0x6e, 0x67, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_voter_proto_rawDescOnce sync.Once
+	file_voter_proto_rawDescData = file_voter_proto_rawDesc
+)
+
+func file_voter_proto_rawDescGZIP() []byte {
+	file_voter_proto_rawDescOnce.Do(func() {
+		file_voter_proto_rawDescData = protoimpl.X.CompressGZIP(file_voter_proto_rawDescData)
+	})
+	return file_voter_proto_rawDescData
+}
+
+var file_voter_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_voter_proto_goTypes = []interface{}{
+	(*VoterPoll)(nil),              // 0: voting.v1.VoterPoll
+	(*Voter)(nil),                  // 1: voting.v1.Voter
+	(*ListVotersRequest)(nil),      // 2: voting.v1.ListVotersRequest
+	(*ListVotersResponse)(nil),     // 3: voting.v1.ListVotersResponse
+	(*GetVoterRequest)(nil),        // 4: voting.v1.GetVoterRequest
+	(*AddVoterRequest)(nil),        // 5: voting.v1.AddVoterRequest
+	(*UpdateVoterRequest)(nil),     // 6: voting.v1.UpdateVoterRequest
+	(*DeleteVoterRequest)(nil),     // 7: voting.v1.DeleteVoterRequest
+	(*AddVoterPollRequest)(nil),    // 8: voting.v1.AddVoterPollRequest
+	(*DeleteVoterPollRequest)(nil), // 9: voting.v1.DeleteVoterPollRequest
+	(*timestamppb.Timestamp)(nil),  // 10: google.protobuf.Timestamp
+	(*emptypb.Empty)(nil),          // 11: google.protobuf.Empty
+}
+var file_voter_proto_depIdxs = []int32{
+	10, // 0: voting.v1.VoterPoll.vote_date:type_name -> google.protobuf.Timestamp
+	0,  // 1: voting.v1.Voter.vote_history:type_name -> voting.v1.VoterPoll
+	1,  // 2: voting.v1.ListVotersResponse.voters:type_name -> voting.v1.Voter
+	1,  // 3: voting.v1.AddVoterRequest.voter:type_name -> voting.v1.Voter
+	1,  // 4: voting.v1.UpdateVoterRequest.voter:type_name -> voting.v1.Voter
+	10, // 5: voting.v1.AddVoterPollRequest.vote_date:type_name -> google.protobuf.Timestamp
+	2,  // 6: voting.v1.VoterService.ListVoters:input_type -> voting.v1.ListVotersRequest
+	4,  // 7: voting.v1.VoterService.GetVoter:input_type -> voting.v1.GetVoterRequest
+	5,  // 8: voting.v1.VoterService.AddVoter:input_type -> voting.v1.AddVoterRequest
+	6,  // 9: voting.v1.VoterService.UpdateVoter:input_type -> voting.v1.UpdateVoterRequest
+	7,  // 10: voting.v1.VoterService.DeleteVoter:input_type -> voting.v1.DeleteVoterRequest
+	8,  // 11: voting.v1.VoterService.AddVoterPoll:input_type -> voting.v1.AddVoterPollRequest
+	9,  // 12: voting.v1.VoterService.DeleteVoterPoll:input_type -> voting.v1.DeleteVoterPollRequest
+	3,  // 13: voting.v1.VoterService.ListVoters:output_type -> voting.v1.ListVotersResponse
+	1,  // 14: voting.v1.VoterService.GetVoter:output_type -> voting.v1.Voter
+	1,  // 15: voting.v1.VoterService.AddVoter:output_type -> voting.v1.Voter
+	1,  // 16: voting.v1.VoterService.UpdateVoter:output_type -> voting.v1.Voter
+	11, // 17: voting.v1.VoterService.DeleteVoter:output_type -> google.protobuf.Empty
+	0,  // 18: voting.v1.VoterService.AddVoterPoll:output_type -> voting.v1.VoterPoll
+	11, // 19: voting.v1.VoterService.DeleteVoterPoll:output_type -> google.protobuf.Empty
+	13, // [13:20] is the sub-list for method output_type
+	6,  // [6:13] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_voter_proto_init() }
+func file_voter_proto_init() {
+	if File_voter_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_voter_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*VoterPoll); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_voter_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Voter); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_voter_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListVotersRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_voter_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListVotersResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_voter_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetVoterRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_voter_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AddVoterRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_voter_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*UpdateVoterRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_voter_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DeleteVoterRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_voter_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AddVoterPollRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_voter_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DeleteVoterPollRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_voter_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_voter_proto_goTypes,
+		DependencyIndexes: file_voter_proto_depIdxs,
+		MessageInfos:      file_voter_proto_msgTypes,
+	}.Build()
+	File_voter_proto = out.File
+	file_voter_proto_rawDesc = nil
+	file_voter_proto_goTypes = nil
+	file_voter_proto_depIdxs = nil
+}
@@ -0,0 +1,332 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: voter.proto
+
+package votingpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	VoterService_ListVoters_FullMethodName      = "/voting.v1.VoterService/ListVoters"
+	VoterService_GetVoter_FullMethodName        = "/voting.v1.VoterService/GetVoter"
+	VoterService_AddVoter_FullMethodName        = "/voting.v1.VoterService/AddVoter"
+	VoterService_UpdateVoter_FullMethodName     = "/voting.v1.VoterService/UpdateVoter"
+	VoterService_DeleteVoter_FullMethodName     = "/voting.v1.VoterService/DeleteVoter"
+	VoterService_AddVoterPoll_FullMethodName    = "/voting.v1.VoterService/AddVoterPoll"
+	VoterService_DeleteVoterPoll_FullMethodName = "/voting.v1.VoterService/DeleteVoterPoll"
+)
+
+// VoterServiceClient is the client API for VoterService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type VoterServiceClient interface {
+	ListVoters(ctx context.Context, in *ListVotersRequest, opts ...grpc.CallOption) (*ListVotersResponse, error)
+	GetVoter(ctx context.Context, in *GetVoterRequest, opts ...grpc.CallOption) (*Voter, error)
+	AddVoter(ctx context.Context, in *AddVoterRequest, opts ...grpc.CallOption) (*Voter, error)
+	UpdateVoter(ctx context.Context, in *UpdateVoterRequest, opts ...grpc.CallOption) (*Voter, error)
+	DeleteVoter(ctx context.Context, in *DeleteVoterRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	AddVoterPoll(ctx context.Context, in *AddVoterPollRequest, opts ...grpc.CallOption) (*VoterPoll, error)
+	DeleteVoterPoll(ctx context.Context, in *DeleteVoterPollRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type voterServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewVoterServiceClient(cc grpc.ClientConnInterface) VoterServiceClient {
+	return &voterServiceClient{cc}
+}
+
+func (c *voterServiceClient) ListVoters(ctx context.Context, in *ListVotersRequest, opts ...grpc.CallOption) (*ListVotersResponse, error) {
+	out := new(ListVotersResponse)
+	err := c.cc.Invoke(ctx, VoterService_ListVoters_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *voterServiceClient) GetVoter(ctx context.Context, in *GetVoterRequest, opts ...grpc.CallOption) (*Voter, error) {
+	out := new(Voter)
+	err := c.cc.Invoke(ctx, VoterService_GetVoter_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *voterServiceClient) AddVoter(ctx context.Context, in *AddVoterRequest, opts ...grpc.CallOption) (*Voter, error) {
+	out := new(Voter)
+	err := c.cc.Invoke(ctx, VoterService_AddVoter_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *voterServiceClient) UpdateVoter(ctx context.Context, in *UpdateVoterRequest, opts ...grpc.CallOption) (*Voter, error) {
+	out := new(Voter)
+	err := c.cc.Invoke(ctx, VoterService_UpdateVoter_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *voterServiceClient) DeleteVoter(ctx context.Context, in *DeleteVoterRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, VoterService_DeleteVoter_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *voterServiceClient) AddVoterPoll(ctx context.Context, in *AddVoterPollRequest, opts ...grpc.CallOption) (*VoterPoll, error) {
+	out := new(VoterPoll)
+	err := c.cc.Invoke(ctx, VoterService_AddVoterPoll_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *voterServiceClient) DeleteVoterPoll(ctx context.Context, in *DeleteVoterPollRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, VoterService_DeleteVoterPoll_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// VoterServiceServer is the server API for VoterService service.
+// All implementations must embed UnimplementedVoterServiceServer
+// for forward compatibility
+type VoterServiceServer interface {
+	ListVoters(context.Context, *ListVotersRequest) (*ListVotersResponse, error)
+	GetVoter(context.Context, *GetVoterRequest) (*Voter, error)
+	AddVoter(context.Context, *AddVoterRequest) (*Voter, error)
+	UpdateVoter(context.Context, *UpdateVoterRequest) (*Voter, error)
+	DeleteVoter(context.Context, *DeleteVoterRequest) (*emptypb.Empty, error)
+	AddVoterPoll(context.Context, *AddVoterPollRequest) (*VoterPoll, error)
+	DeleteVoterPoll(context.Context, *DeleteVoterPollRequest) (*emptypb.Empty, error)
+	mustEmbedUnimplementedVoterServiceServer()
+}
+
+// UnimplementedVoterServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedVoterServiceServer struct {
+}
+
+func (UnimplementedVoterServiceServer) ListVoters(context.Context, *ListVotersRequest) (*ListVotersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListVoters not implemented")
+}
+func (UnimplementedVoterServiceServer) GetVoter(context.Context, *GetVoterRequest) (*Voter, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetVoter not implemented")
+}
+func (UnimplementedVoterServiceServer) AddVoter(context.Context, *AddVoterRequest) (*Voter, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddVoter not implemented")
+}
+func (UnimplementedVoterServiceServer) UpdateVoter(context.Context, *UpdateVoterRequest) (*Voter, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateVoter not implemented")
+}
+func (UnimplementedVoterServiceServer) DeleteVoter(context.Context, *DeleteVoterRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteVoter not implemented")
+}
+func (UnimplementedVoterServiceServer) AddVoterPoll(context.Context, *AddVoterPollRequest) (*VoterPoll, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddVoterPoll not implemented")
+}
+func (UnimplementedVoterServiceServer) DeleteVoterPoll(context.Context, *DeleteVoterPollRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteVoterPoll not implemented")
+}
+func (UnimplementedVoterServiceServer) mustEmbedUnimplementedVoterServiceServer() {}
+
+// UnsafeVoterServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to VoterServiceServer will
+// result in compilation errors.
+type UnsafeVoterServiceServer interface {
+	mustEmbedUnimplementedVoterServiceServer()
+}
+
+func RegisterVoterServiceServer(s grpc.ServiceRegistrar, srv VoterServiceServer) {
+	s.RegisterService(&VoterService_ServiceDesc, srv)
+}
+
+func _VoterService_ListVoters_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListVotersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VoterServiceServer).ListVoters(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VoterService_ListVoters_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VoterServiceServer).ListVoters(ctx, req.(*ListVotersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VoterService_GetVoter_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetVoterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VoterServiceServer).GetVoter(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VoterService_GetVoter_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VoterServiceServer).GetVoter(ctx, req.(*GetVoterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VoterService_AddVoter_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddVoterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VoterServiceServer).AddVoter(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VoterService_AddVoter_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VoterServiceServer).AddVoter(ctx, req.(*AddVoterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VoterService_UpdateVoter_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateVoterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VoterServiceServer).UpdateVoter(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VoterService_UpdateVoter_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VoterServiceServer).UpdateVoter(ctx, req.(*UpdateVoterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VoterService_DeleteVoter_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteVoterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VoterServiceServer).DeleteVoter(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VoterService_DeleteVoter_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VoterServiceServer).DeleteVoter(ctx, req.(*DeleteVoterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VoterService_AddVoterPoll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddVoterPollRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VoterServiceServer).AddVoterPoll(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VoterService_AddVoterPoll_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VoterServiceServer).AddVoterPoll(ctx, req.(*AddVoterPollRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VoterService_DeleteVoterPoll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteVoterPollRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VoterServiceServer).DeleteVoterPoll(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VoterService_DeleteVoterPoll_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VoterServiceServer).DeleteVoterPoll(ctx, req.(*DeleteVoterPollRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// VoterService_ServiceDesc is the grpc.ServiceDesc for VoterService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var VoterService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "voting.v1.VoterService",
+	HandlerType: (*VoterServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListVoters",
+			Handler:    _VoterService_ListVoters_Handler,
+		},
+		{
+			MethodName: "GetVoter",
+			Handler:    _VoterService_GetVoter_Handler,
+		},
+		{
+			MethodName: "AddVoter",
+			Handler:    _VoterService_AddVoter_Handler,
+		},
+		{
+			MethodName: "UpdateVoter",
+			Handler:    _VoterService_UpdateVoter_Handler,
+		},
+		{
+			MethodName: "DeleteVoter",
+			Handler:    _VoterService_DeleteVoter_Handler,
+		},
+		{
+			MethodName: "AddVoterPoll",
+			Handler:    _VoterService_AddVoterPoll_Handler,
+		},
+		{
+			MethodName: "DeleteVoterPoll",
+			Handler:    _VoterService_DeleteVoterPoll_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "voter.proto",
+}
@@ -0,0 +1,182 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"shared/apierror"
+	"shared/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+// hookRequest is the body of POST /webhooks.
+type hookRequest struct {
+	URL    string   `json:"url" binding:"required,url,max=2048"`
+	Events []string `json:"events" binding:"required,min=1,dive,oneof=voter.created poll.closed vote.cast"`
+	Secret string   `json:"secret" binding:"max=256"`
+}
+
+// Return a hook as the API shows it, without its secret.
+func publicHook(hook Hook) gin.H {
+	return gin.H{
+		"hookId":    hook.HookID,
+		"url":       hook.URL,
+		"events":    hook.Events,
+		"createdAt": hook.CreatedAt,
+		"links": gin.H{
+			"failures": gin.H{
+				"method": "GET",
+				"url":    fmt.Sprintf("/webhooks/%d/failures", hook.HookID),
+			},
+			"delete": gin.H{
+				"method": "DELETE",
+				"url":    fmt.Sprintf("/webhooks/%d", hook.HookID),
+			},
+		},
+	}
+}
+
+// Return the hook ID of the request, aborting it when it is invalid.
+func parseHookID(c *gin.Context) (uint, bool) {
+	hookIDUint, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		log.Println("Error converting webhook ID to uint: ", err)
+		apierror.AbortInvalidID(c, "Webhook ID", err)
+		return 0, false
+	}
+
+	return uint(hookIDUint), true
+}
+
+// Abort a request for a hook that could not be read.
+func abortHookError(c *gin.Context, message string, err error) {
+	log.Println("Error getting webhook: ", err)
+	if errors.Is(err, ErrHookNotFound) {
+		apierror.AbortWithError(c, http.StatusNotFound, "Could not find webhook", err)
+		return
+	}
+
+	apierror.AbortWithError(c, http.StatusInternalServerError, message, err)
+}
+
+// Implementation of POST /webhooks.
+// Register a URL for some of the voter.created, poll.closed and vote.cast
+// events. The deliveries are signed with the secret of the request, or one
+// generated for the hook; it is only returned now.
+func (r *Registry) ServeAddHook(c *gin.Context) {
+	var request hookRequest
+	if err := validation.Bind(c, &request); err != nil {
+		log.Println("Error binding webhook: ", err)
+		return
+	}
+
+	if err := ValidateURL(request.URL); err != nil {
+		validation.AbortWithFields(c, []validation.FieldError{{
+			Field:   "url",
+			Rule:    "url",
+			Message: err.Error(),
+		}})
+		return
+	}
+
+	events := make([]string, 0, len(request.Events))
+	for _, event := range Events {
+		for _, requested := range request.Events {
+			if requested == event {
+				events = append(events, event)
+				break
+			}
+		}
+	}
+
+	hook, err := r.Add(c.Request.Context(), Hook{
+		URL:    request.URL,
+		Events: events,
+		Secret: request.Secret,
+	})
+	if err != nil {
+		log.Println("Error adding webhook: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not add webhook", err)
+		return
+	}
+
+	response := publicHook(hook)
+	response["secret"] = hook.Secret
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// Implementation of GET /webhooks.
+// Returns the registered hooks, without their secrets.
+func (r *Registry) ServeListHooks(c *gin.Context) {
+	hooks, err := r.List(c.Request.Context())
+	if err != nil {
+		log.Println("Error getting webhooks: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not get webhooks", err)
+		return
+	}
+
+	responses := make([]gin.H, len(hooks))
+	for i, hook := range hooks {
+		responses[i] = publicHook(hook)
+	}
+
+	c.JSON(http.StatusOK, responses)
+}
+
+// Implementation of GET /webhooks/:id.
+// Returns a hook, without its secret.
+func (r *Registry) ServeGetHook(c *gin.Context) {
+	hookID, ok := parseHookID(c)
+	if !ok {
+		return
+	}
+
+	hook, err := r.Get(c.Request.Context(), hookID)
+	if err != nil {
+		abortHookError(c, "Could not get webhook", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, publicHook(hook))
+}
+
+// Implementation of DELETE /webhooks/:id.
+// Delete a hook and its failed deliveries.
+func (r *Registry) ServeDeleteHook(c *gin.Context) {
+	hookID, ok := parseHookID(c)
+	if !ok {
+		return
+	}
+
+	if err := r.Delete(c.Request.Context(), hookID); err != nil {
+		abortHookError(c, "Could not delete webhook", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Webhook deleted successfully.",
+	})
+}
+
+// Implementation of GET /webhooks/:id/failures.
+// Returns the deliveries to a hook that failed after every attempt, the
+// latest first, with the payload that was sent.
+func (r *Registry) ServeHookFailures(c *gin.Context) {
+	hookID, ok := parseHookID(c)
+	if !ok {
+		return
+	}
+
+	failures, err := r.Failures(c.Request.Context(), hookID)
+	if err != nil {
+		abortHookError(c, "Could not get webhook failures", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, failures)
+}
@@ -0,0 +1,321 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// The events the services deliver to registered hooks.
+const (
+	EventVoterCreated = "voter.created"
+	EventPollClosed   = "poll.closed"
+	EventVoteCast     = "vote.cast"
+)
+
+// Events lists the events a hook can subscribe to.
+var Events = []string{EventVoterCreated, EventPollClosed, EventVoteCast}
+
+const (
+	RedisKeyPrefix = "webhooks"
+	HooksKey       = RedisKeyPrefix
+	HookIDKey      = RedisKeyPrefix + ":next"
+
+	// How many failed deliveries the dead-letter list of a hook keeps, the
+	// latest first.
+	MaxFailures = 100
+
+	// How long the delivery of an event to a hook may take with its
+	// retries.
+	DeliveryTimeout = time.Minute
+)
+
+// ErrHookNotFound is returned when a hook does not exist.
+var ErrHookNotFound = errors.New("webhook does not exist")
+
+// Hook is a URL registered for events of the services.
+type Hook struct {
+	HookID uint     `json:"hookId"`
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	// Signs the deliveries. It is returned when the hook is registered and
+	// never again.
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Report whether the hook subscribed to an event.
+func (h Hook) Subscribed(event string) bool {
+	for _, subscribed := range h.Events {
+		if subscribed == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Payload is the body of a delivery.
+type Payload struct {
+	Event      string      `json:"event"`
+	HookID     uint        `json:"hookId"`
+	OccurredAt time.Time   `json:"occurredAt"`
+	Data       interface{} `json:"data"`
+}
+
+// Failure is a delivery the hook did not accept after every attempt, as
+// kept in its dead-letter list.
+type Failure struct {
+	Result
+	Event    string    `json:"event"`
+	Payload  Payload   `json:"payload"`
+	FailedAt time.Time `json:"failedAt"`
+}
+
+// Registry stores the hooks in the redis the services share, so a hook
+// registered with any of them gets the events of all of them, and delivers
+// the events of the service it belongs to.
+type Registry struct {
+	client  redis.UniversalClient
+	sender  *Sender
+	service string
+}
+
+// Create the registry of a service. Its deliveries are logged with the
+// name of the service.
+func NewRegistry(client redis.UniversalClient, service string) *Registry {
+	return &Registry{
+		client:  client,
+		sender:  NewSender(),
+		service: service,
+	}
+}
+
+// Get the key of the dead-letter list of a hook.
+func failuresKey(hookID uint) string {
+	return fmt.Sprintf("%s:failures:%d", RedisKeyPrefix, hookID)
+}
+
+func (r *Registry) connected() error {
+	if r == nil || r.client == nil {
+		return errors.New("redis is not connected")
+	}
+
+	return nil
+}
+
+// Add a hook with a new ID, and a new secret when it has none.
+func (r *Registry) Add(ctx context.Context, hook Hook) (Hook, error) {
+	if err := r.connected(); err != nil {
+		return Hook{}, err
+	}
+
+	id, err := r.client.Incr(ctx, HookIDKey).Result()
+	if err != nil {
+		return Hook{}, err
+	}
+
+	hook.HookID = uint(id)
+	hook.CreatedAt = time.Now().UTC()
+	if hook.Secret == "" {
+		hook.Secret, err = newSecret()
+		if err != nil {
+			return Hook{}, err
+		}
+	}
+
+	data, err := json.Marshal(hook)
+	if err != nil {
+		return Hook{}, err
+	}
+
+	if err := r.client.HSet(ctx, HooksKey, strconv.FormatUint(uint64(id), 10), data).Err(); err != nil {
+		return Hook{}, err
+	}
+
+	return hook, nil
+}
+
+// Return the hooks ordered by ID, with their secrets.
+func (r *Registry) List(ctx context.Context) ([]Hook, error) {
+	if err := r.connected(); err != nil {
+		return nil, err
+	}
+
+	entries, err := r.client.HGetAll(ctx, HooksKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	hooks := make([]Hook, 0, len(entries))
+	for _, data := range entries {
+		var hook Hook
+		if err := json.Unmarshal([]byte(data), &hook); err != nil {
+			continue
+		}
+		hooks = append(hooks, hook)
+	}
+
+	sort.Slice(hooks, func(i, j int) bool {
+		return hooks[i].HookID < hooks[j].HookID
+	})
+
+	return hooks, nil
+}
+
+// Return a hook, ErrHookNotFound when it does not exist.
+func (r *Registry) Get(ctx context.Context, hookID uint) (Hook, error) {
+	if err := r.connected(); err != nil {
+		return Hook{}, err
+	}
+
+	data, err := r.client.HGet(ctx, HooksKey, strconv.FormatUint(uint64(hookID), 10)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return Hook{}, ErrHookNotFound
+	}
+	if err != nil {
+		return Hook{}, err
+	}
+
+	var hook Hook
+	if err := json.Unmarshal(data, &hook); err != nil {
+		return Hook{}, err
+	}
+
+	return hook, nil
+}
+
+// Delete a hook and its dead-letter list.
+func (r *Registry) Delete(ctx context.Context, hookID uint) error {
+	if err := r.connected(); err != nil {
+		return err
+	}
+
+	deleted, err := r.client.HDel(ctx, HooksKey, strconv.FormatUint(uint64(hookID), 10)).Result()
+	if err != nil {
+		return err
+	}
+
+	if deleted == 0 {
+		return ErrHookNotFound
+	}
+
+	return r.client.Del(ctx, failuresKey(hookID)).Err()
+}
+
+// Return the dead-letter list of a hook, the latest failure first.
+func (r *Registry) Failures(ctx context.Context, hookID uint) ([]Failure, error) {
+	if _, err := r.Get(ctx, hookID); err != nil {
+		return nil, err
+	}
+
+	entries, err := r.client.LRange(ctx, failuresKey(hookID), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	failures := make([]Failure, 0, len(entries))
+	for _, data := range entries {
+		var failure Failure
+		if err := json.Unmarshal([]byte(data), &failure); err != nil {
+			continue
+		}
+		failures = append(failures, failure)
+	}
+
+	return failures, nil
+}
+
+// Keep a failed delivery in the dead-letter list of its hook, dropping the
+// oldest beyond MaxFailures.
+func (r *Registry) addFailure(ctx context.Context, hookID uint, failure Failure) error {
+	data, err := json.Marshal(failure)
+	if err != nil {
+		return err
+	}
+
+	key := failuresKey(hookID)
+	pipe := r.client.TxPipeline()
+	pipe.LPush(ctx, key, data)
+	pipe.LTrim(ctx, key, 0, MaxFailures-1)
+	_, err = pipe.Exec(ctx)
+
+	return err
+}
+
+// Deliver an event to the hooks subscribed to it, in the background. A
+// delivery that fails after its retries goes to the dead-letter list of
+// its hook; it never fails what caused the event.
+func (r *Registry) Dispatch(event string, data interface{}) {
+	if r.connected() != nil {
+		return
+	}
+
+	occurredAt := time.Now().UTC()
+	go func() {
+		hooks, err := r.List(context.Background())
+		if err != nil {
+			log.Println("Error getting webhooks: ", err)
+			return
+		}
+
+		for _, hook := range hooks {
+			if !hook.Subscribed(event) {
+				continue
+			}
+
+			go r.deliver(hook, Payload{
+				Event:      event,
+				HookID:     hook.HookID,
+				OccurredAt: occurredAt,
+				Data:       data,
+			})
+		}
+	}()
+}
+
+// Deliver an event to a hook and keep the delivery when it failed.
+func (r *Registry) deliver(hook Hook, payload Payload) {
+	ctx, cancel := context.WithTimeout(context.Background(), DeliveryTimeout)
+	defer cancel()
+
+	result := r.sender.Send(ctx, Delivery{
+		URL:     hook.URL,
+		Secret:  hook.Secret,
+		Event:   payload.Event,
+		Payload: payload,
+	})
+	if result.Delivered() {
+		return
+	}
+
+	log.Printf("Error delivering %s from %s to webhook %d after %d attempts: %s", payload.Event, r.service, hook.HookID, result.Attempts, result.Error)
+
+	failure := Failure{
+		Result:   result,
+		Event:    payload.Event,
+		Payload:  payload,
+		FailedAt: time.Now().UTC(),
+	}
+	if err := r.addFailure(context.Background(), hook.HookID, failure); err != nil {
+		log.Println("Error saving webhook failure: ", err)
+	}
+}
+
+func newSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(secret), nil
+}
@@ -0,0 +1,175 @@
+// Package webhook delivers events to the URLs registered by the users of
+// the APIs. A delivery is a JSON POST with the event in the
+// X-Webhook-Event header and, when the hook has a secret, the HMAC-SHA256
+// of the body in X-Webhook-Signature:
+//
+//	X-Webhook-Signature: sha256=<hex of HMAC-SHA256(secret, body)>
+//
+// Receivers compare it with the HMAC of the body they got to check that the
+// delivery comes from the APIs. Deliveries that fail with a network error,
+// 429 or a 5xx status are tried again with a growing wait.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+	SignatureHeader = "X-Webhook-Signature"
+
+	DefaultAttempts  = 3
+	DefaultRetryWait = time.Second
+	DefaultTimeout   = 5 * time.Second
+)
+
+// Delivery is an event sent to a hook.
+type Delivery struct {
+	URL    string
+	Secret string
+	Event  string
+	// Encoded as the JSON body of the request.
+	Payload interface{}
+}
+
+// Result is the outcome of a delivery.
+type Result struct {
+	DeliveryID string    `json:"deliveryId"`
+	Attempts   int       `json:"attempts"`
+	StatusCode int       `json:"statusCode,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	SentAt     time.Time `json:"sentAt"`
+}
+
+// Report whether the hook accepted the delivery.
+func (r Result) Delivered() bool {
+	return r.Error == ""
+}
+
+// Sender delivers events over HTTP.
+type Sender struct {
+	client    *http.Client
+	attempts  int
+	retryWait time.Duration
+}
+
+// Create a sender with the default timeout and retries.
+func NewSender() *Sender {
+	return &Sender{
+		client:    &http.Client{Timeout: DefaultTimeout},
+		attempts:  DefaultAttempts,
+		retryWait: DefaultRetryWait,
+	}
+}
+
+// Check that a hook URL can be delivered to: an absolute http or https
+// URL.
+func ValidateURL(hookURL string) error {
+	parsed, err := url.Parse(hookURL)
+	if err != nil {
+		return err
+	}
+
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("webhook URL %q must be an absolute http or https URL", hookURL)
+	}
+
+	return nil
+}
+
+// Return the signature of a body, as sent in SignatureHeader.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Deliver an event, trying again until the hook accepts it, the attempts
+// run out or the context is done. Every attempt carries the same delivery
+// ID, so receivers can drop the duplicates of a retried delivery.
+func (s *Sender) Send(ctx context.Context, delivery Delivery) Result {
+	result := Result{DeliveryID: newDeliveryID(), SentAt: time.Now().UTC()}
+
+	body, err := json.Marshal(delivery.Payload)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	wait := s.retryWait
+	for result.Attempts < s.attempts {
+		result.Attempts++
+
+		status, err := s.post(ctx, delivery, result.DeliveryID, body)
+		result.StatusCode = status
+		if err == nil {
+			result.Error = ""
+			return result
+		}
+		result.Error = err.Error()
+
+		if status != 0 && status != http.StatusTooManyRequests && status < http.StatusInternalServerError {
+			return result
+		}
+
+		select {
+		case <-ctx.Done():
+			return result
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+
+	return result
+}
+
+// Make one attempt of a delivery. It returns the status of the response,
+// zero when there was none.
+func (s *Sender) post(ctx context.Context, delivery Delivery, deliveryID string, body []byte) (int, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(EventHeader, delivery.Event)
+	request.Header.Set(DeliveryHeader, deliveryID)
+	if delivery.Secret != "" {
+		request.Header.Set(SignatureHeader, Sign(delivery.Secret, body))
+	}
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, 64*1024))
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return response.StatusCode, fmt.Errorf("webhook answered %s", response.Status)
+	}
+
+	return response.StatusCode, nil
+}
+
+func newDeliveryID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,422 @@
+package worker
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	LeaderKeyPrefix   = "leader:"
+	JobsKeyPrefix     = "jobs:"
+	DefaultLeaderTTL  = 15 * time.Second
+	defaultTickPeriod = time.Second
+)
+
+// renewLeaseScript extends the leader lease only if it is still held by
+// this instance.
+var renewLeaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// ErrUnknownJob is returned when a job control targets a job that is not registered.
+var ErrUnknownJob = errors.New("job does not exist")
+
+// Job is a background task run on a fixed schedule by the leader.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// JobStats reports the schedule and run metrics of a job.
+type JobStats struct {
+	Name         string    `json:"name"`
+	Interval     string    `json:"interval"`
+	Runs         uint64    `json:"runs"`
+	Failures     uint64    `json:"failures"`
+	LastRun      time.Time `json:"lastRun"`
+	LastDuration string    `json:"lastDuration"`
+	LastError    string    `json:"lastError,omitempty"`
+	NextRun      time.Time `json:"nextRun"`
+	Paused       bool      `json:"paused"`
+}
+
+type jobState struct {
+	job       Job
+	stats     JobStats
+	triggered bool
+}
+
+// Scheduler runs registered jobs on the replica that holds the leader lease
+// of a service, so that replicas never run the same job simultaneously.
+type Scheduler struct {
+	client     redis.UniversalClient
+	service    string
+	leaderKey  string
+	instanceID string
+	leaderTTL  time.Duration
+
+	mu     sync.Mutex
+	leader bool
+	jobs   map[string]*jobState
+	order  []string
+}
+
+// Create a new scheduler for a service. Replicas of the same service
+// compete for one leader lease stored in redis.
+func NewScheduler(client redis.UniversalClient, service string) *Scheduler {
+	idBytes := make([]byte, 8)
+	if _, err := rand.Read(idBytes); err != nil {
+		log.Println("Error generating scheduler instance ID: ", err)
+	}
+
+	return &Scheduler{
+		client:     client,
+		service:    service,
+		leaderKey:  LeaderKeyPrefix + service,
+		instanceID: hex.EncodeToString(idBytes),
+		leaderTTL:  DefaultLeaderTTL,
+		jobs:       make(map[string]*jobState),
+	}
+}
+
+// Register a job with the scheduler. Jobs must be registered before Start.
+func (s *Scheduler) Register(job Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.jobs[job.Name] = &jobState{
+		job: job,
+		stats: JobStats{
+			Name:     job.Name,
+			Interval: job.Interval.String(),
+			NextRun:  time.Now().Add(job.Interval),
+		},
+	}
+	s.order = append(s.order, job.Name)
+}
+
+// Start the leader election and job loop in a background goroutine.
+func (s *Scheduler) Start(ctx context.Context) {
+	go s.loop(ctx)
+}
+
+// Report whether this instance currently holds the leader lease.
+func (s *Scheduler) IsLeader() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.leader
+}
+
+// Return the stats of every registered job, in registration order. Run
+// stats are shared through redis, so every replica reports the runs of
+// the current leader.
+func (s *Scheduler) Stats() []JobStats {
+	s.syncPaused()
+
+	var shared map[string]string
+	if s.client != nil {
+		fields, err := s.client.HGetAll(context.Background(), s.jobsKey("stats")).Result()
+		if err != nil {
+			log.Println("Error loading job stats: ", err)
+		}
+		shared = fields
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := make([]JobStats, 0, len(s.order))
+	for _, name := range s.order {
+		state := s.jobs[name]
+		jobStats := state.stats
+
+		if data, found := shared[name]; found {
+			if err := json.Unmarshal([]byte(data), &jobStats); err != nil {
+				log.Println("Error decoding job stats: ", err)
+				jobStats = state.stats
+			}
+			jobStats.Interval = state.stats.Interval
+			jobStats.Paused = state.stats.Paused
+		}
+
+		stats = append(stats, jobStats)
+	}
+
+	return stats
+}
+
+// Pause a job so the leader skips its scheduled runs until it is resumed.
+func (s *Scheduler) Pause(name string) error {
+	return s.setPaused(name, true)
+}
+
+// Resume a paused job.
+func (s *Scheduler) Resume(name string) error {
+	return s.setPaused(name, false)
+}
+
+// Ask the leader to run a job on its next tick, even if it is paused.
+func (s *Scheduler) Trigger(name string) error {
+	state, err := s.lookup(name)
+	if err != nil {
+		return err
+	}
+
+	if s.client != nil {
+		return s.client.SAdd(context.Background(), s.jobsKey("trigger"), name).Err()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state.triggered = true
+
+	return nil
+}
+
+// Get a string that can be used as a job control key in redis.
+func (s *Scheduler) jobsKey(kind string) string {
+	return JobsKeyPrefix + kind + ":" + s.service
+}
+
+func (s *Scheduler) lookup(name string) (*jobState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, found := s.jobs[name]
+	if !found {
+		return nil, ErrUnknownJob
+	}
+
+	return state, nil
+}
+
+func (s *Scheduler) setPaused(name string, paused bool) error {
+	state, err := s.lookup(name)
+	if err != nil {
+		return err
+	}
+
+	if s.client != nil {
+		ctx := context.Background()
+		if paused {
+			err = s.client.SAdd(ctx, s.jobsKey("paused"), name).Err()
+		} else {
+			err = s.client.SRem(ctx, s.jobsKey("paused"), name).Err()
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state.stats.Paused = paused
+
+	return nil
+}
+
+// Load the paused jobs shared through redis, so a pause issued on any
+// replica reaches the leader.
+func (s *Scheduler) syncPaused() {
+	if s.client == nil {
+		return
+	}
+
+	paused, err := s.client.SMembers(context.Background(), s.jobsKey("paused")).Result()
+	if err != nil {
+		log.Println("Error loading paused jobs: ", err)
+		return
+	}
+
+	pausedSet := make(map[string]bool, len(paused))
+	for _, name := range paused {
+		pausedSet[name] = true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for name, state := range s.jobs {
+		state.stats.Paused = pausedSet[name]
+	}
+}
+
+// Collect the run requests shared through redis.
+func (s *Scheduler) syncTriggers() {
+	if s.client == nil {
+		return
+	}
+
+	ctx := context.Background()
+	triggered, err := s.client.SMembers(ctx, s.jobsKey("trigger")).Result()
+	if err != nil {
+		log.Println("Error loading job run requests: ", err)
+		return
+	}
+
+	for _, name := range triggered {
+		if err := s.client.SRem(ctx, s.jobsKey("trigger"), name).Err(); err != nil {
+			log.Println("Error clearing job run request: ", err)
+			continue
+		}
+
+		if state, err := s.lookup(name); err == nil {
+			s.mu.Lock()
+			state.triggered = true
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context) {
+	ticker := time.NewTicker(defaultTickPeriod)
+	defer ticker.Stop()
+
+	lastElection := time.Time{}
+	for {
+		select {
+		case <-ctx.Done():
+			s.resign()
+			return
+		case now := <-ticker.C:
+			if now.Sub(lastElection) >= s.leaderTTL/3 {
+				s.elect()
+				lastElection = now
+			}
+
+			if s.IsLeader() {
+				s.runDueJobs(ctx, now)
+			}
+		}
+	}
+}
+
+// Acquire or renew the leader lease.
+func (s *Scheduler) elect() {
+	if s.client == nil {
+		s.setLeader(false)
+		return
+	}
+
+	ctx := context.Background()
+
+	if s.IsLeader() {
+		renewed, err := renewLeaseScript.Run(ctx, s.client, []string{s.leaderKey}, s.instanceID, s.leaderTTL.Milliseconds()).Int64()
+		if err != nil || renewed == 0 {
+			log.Println("Lost scheduler leadership for ", s.leaderKey)
+			s.setLeader(false)
+		}
+		return
+	}
+
+	acquired, err := s.client.SetNX(ctx, s.leaderKey, s.instanceID, s.leaderTTL).Result()
+	if err != nil {
+		log.Println("Error acquiring scheduler leadership: ", err)
+		return
+	}
+
+	if acquired {
+		log.Println("Acquired scheduler leadership for ", s.leaderKey)
+		s.setLeader(true)
+	}
+}
+
+// Give up the leader lease so another replica can take over immediately.
+func (s *Scheduler) resign() {
+	if s.client == nil || !s.IsLeader() {
+		return
+	}
+
+	ctx := context.Background()
+	if current, err := s.client.Get(ctx, s.leaderKey).Result(); err == nil && current == s.instanceID {
+		s.client.Del(ctx, s.leaderKey)
+	}
+
+	s.setLeader(false)
+}
+
+func (s *Scheduler) setLeader(leader bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.leader = leader
+}
+
+// Run every job that was triggered or whose next run time has passed,
+// skipping the scheduled runs of paused jobs.
+func (s *Scheduler) runDueJobs(ctx context.Context, now time.Time) {
+	s.syncPaused()
+	s.syncTriggers()
+
+	s.mu.Lock()
+	due := make([]*jobState, 0)
+	for _, name := range s.order {
+		state := s.jobs[name]
+		scheduled := !state.stats.Paused && !now.Before(state.stats.NextRun)
+		if scheduled || state.triggered {
+			state.triggered = false
+			due = append(due, state)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, state := range due {
+		s.runJob(ctx, state)
+	}
+}
+
+func (s *Scheduler) runJob(ctx context.Context, state *jobState) {
+	start := time.Now()
+	err := state.job.Run(ctx)
+	duration := time.Since(start)
+
+	s.mu.Lock()
+	state.stats.Runs++
+	state.stats.LastRun = start
+	state.stats.LastDuration = duration.String()
+	state.stats.LastError = ""
+	state.stats.NextRun = start.Add(state.job.Interval)
+
+	if err != nil {
+		state.stats.Failures++
+		state.stats.LastError = err.Error()
+		log.Printf("Job %s failed: %v", state.job.Name, err)
+	}
+	stats := state.stats
+	s.mu.Unlock()
+
+	s.saveStats(stats)
+}
+
+// Share the stats of a job run with the other replicas.
+func (s *Scheduler) saveStats(stats JobStats) {
+	if s.client == nil {
+		return
+	}
+
+	data, err := json.Marshal(stats)
+	if err != nil {
+		log.Println("Error encoding job stats: ", err)
+		return
+	}
+
+	if err := s.client.HSet(context.Background(), s.jobsKey("stats"), stats.Name, data).Err(); err != nil {
+		log.Println("Error saving job stats: ", err)
+	}
+}
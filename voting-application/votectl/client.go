@@ -0,0 +1,213 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"shared/export"
+	"shared/snapshot"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const (
+	AdminTokenHeader = "X-Admin-Token"
+)
+
+type client struct {
+	http *resty.Client
+}
+
+func newClient() *client {
+	return &client{http: resty.New()}
+}
+
+func (cl *client) request() *resty.Request {
+	req := cl.http.R().SetHeader("Content-Type", "application/json")
+	if adminFlag != "" {
+		req.SetHeader(AdminTokenHeader, adminFlag)
+	}
+
+	return req
+}
+
+// Return the records of the JSON export at url. An export that failed part
+// way is an error, since its status was sent before the failure.
+func (cl *client) exportRecords(url string) ([]json.RawMessage, error) {
+	var records []json.RawMessage
+
+	resp, err := cl.request().SetQueryParam("format", export.FormatJSON).SetResult(&records).Get(url)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status())
+	}
+	if exportErr := resp.RawResponse.Trailer.Get(export.ErrorTrailer); exportErr != "" {
+		return nil, fmt.Errorf("GET %s: %s", url, exportErr)
+	}
+
+	return records, nil
+}
+
+// Add the allowlist of a poll whose eligibility has one to its record, as
+// eligibleVoterIds.
+func (cl *client) addAllowlist(record json.RawMessage) (json.RawMessage, error) {
+	var poll struct {
+		PollID      uint `json:"pollId"`
+		Eligibility *struct {
+			Allowlist bool `json:"allowlist"`
+		} `json:"eligibility"`
+	}
+	if err := json.Unmarshal(record, &poll); err != nil {
+		return nil, err
+	}
+	if poll.Eligibility == nil || !poll.Eligibility.Allowlist {
+		return record, nil
+	}
+
+	var allowlist struct {
+		VoterIDs []uint `json:"voterIds"`
+	}
+	url := fmt.Sprintf("%s/polls/%d/eligibility/voters", pollAPIFlag, poll.PollID)
+	resp, err := cl.request().SetResult(&allowlist).Get(url)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status())
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(record, &fields); err != nil {
+		return nil, err
+	}
+	if fields["eligibleVoterIds"], err = json.Marshal(allowlist.VoterIDs); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(fields)
+}
+
+// Write a snapshot of every voter, poll and vote to path. The references
+// the snapshot cannot satisfy, such as votes of deleted voters, are logged;
+// such a snapshot is written but cannot be restored.
+func (cl *client) backup(path string) error {
+	archive := snapshot.New()
+
+	var err error
+	if archive.Voters, err = cl.exportRecords(voterAPIFlag + "/voters/export"); err != nil {
+		return err
+	}
+	if archive.Polls, err = cl.exportRecords(pollAPIFlag + "/polls/export"); err != nil {
+		return err
+	}
+	for i, record := range archive.Polls {
+		if archive.Polls[i], err = cl.addAllowlist(record); err != nil {
+			return err
+		}
+	}
+	if archive.Votes, err = cl.exportRecords(votesAPIFlag + "/votes/export"); err != nil {
+		return err
+	}
+
+	var invalid *snapshot.InvalidError
+	if err := archive.Validate(); errors.As(err, &invalid) {
+		for _, problem := range invalid.Problems {
+			log.Println("Warning:", problem)
+		}
+	}
+
+	if err := archive.Save(path); err != nil {
+		return err
+	}
+
+	log.Printf("Wrote %s: %d voters, %d polls, %d votes", path, len(archive.Voters), len(archive.Polls), len(archive.Votes))
+	return nil
+}
+
+// Import records into the service at baseURL.
+func (cl *client) importRecords(baseURL string, records []json.RawMessage) (int, error) {
+	var result struct {
+		Imported int `json:"imported"`
+	}
+
+	resp, err := cl.request().SetBody(records).SetResult(&result).Post(baseURL + snapshot.ImportPath)
+	if err != nil {
+		return 0, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return 0, fmt.Errorf("POST %s%s: %s: %s", baseURL, snapshot.ImportPath, resp.Status(), resp.String())
+	}
+
+	return result.Imported, nil
+}
+
+// Restore the snapshot at path into empty services: the voters, then the
+// polls, then the votes, which the votes API checks against both. Nothing
+// is restored when a reference of the snapshot is missing.
+func (cl *client) restore(path string) error {
+	archive, err := snapshot.Load(path)
+	if err != nil {
+		return err
+	}
+
+	var invalid *snapshot.InvalidError
+	if err := archive.Validate(); errors.As(err, &invalid) {
+		for _, problem := range invalid.Problems {
+			log.Println("Invalid:", problem)
+		}
+		return fmt.Errorf("%s has %d invalid records", path, len(invalid.Problems))
+	}
+
+	parts := []struct {
+		kind    string
+		baseURL string
+		records []json.RawMessage
+	}{
+		{"voters", voterAPIFlag, archive.Voters},
+		{"polls", pollAPIFlag, archive.Polls},
+		{"votes", votesAPIFlag, archive.Votes},
+	}
+
+	for _, part := range parts {
+		imported, err := cl.importRecords(part.baseURL, part.records)
+		if err != nil {
+			return fmt.Errorf("restoring %s: %w", part.kind, err)
+		}
+		log.Printf("Restored %d %s", imported, part.kind)
+	}
+
+	return nil
+}
+
+// Wipe the votes and vote histories of the services, and their polls when
+// polls is set, with POST /admin/reset of the gateway API at gatewayURL.
+func (cl *client) reset(gatewayURL string, polls bool) error {
+	var report struct {
+		VotesDeleted          int `json:"votesDeleted"`
+		VotersCleared         int `json:"votersCleared"`
+		HistoryEntriesCleared int `json:"historyEntriesCleared"`
+		PollsDeleted          int `json:"pollsDeleted"`
+	}
+
+	url := gatewayURL + "/admin/reset"
+	resp, err := cl.request().SetQueryParam("polls", strconv.FormatBool(polls)).SetResult(&report).Post(url)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("POST %s: %s: %s", url, resp.Status(), resp.String())
+	}
+
+	log.Printf("Deleted %d votes, cleared %d vote history entries of %d voters", report.VotesDeleted, report.HistoryEntriesCleared, report.VotersCleared)
+	if polls {
+		log.Printf("Deleted %d polls", report.PollsDeleted)
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+)
+
+var (
+	voterAPIFlag string
+	pollAPIFlag  string
+	votesAPIFlag string
+	adminFlag    string
+)
+
+// Add the flags every command has: where the services are and the admin
+// token.
+func addServiceFlags(flags *flag.FlagSet) {
+	flags.StringVar(&voterAPIFlag, "voterapi", "http://localhost:1080", "Voter API location")
+	flags.StringVar(&pollAPIFlag, "pollapi", "http://localhost:1081", "Poll API location")
+	flags.StringVar(&votesAPIFlag, "votesapi", "http://localhost:1082", "Votes API location")
+	flags.StringVar(&adminFlag, "admin", os.Getenv("ADMIN_TOKEN"), "Admin token sent with every request")
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: votectl backup -o snapshot.json | votectl restore -f snapshot.json | votectl reset [-polls]")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	flags := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	addServiceFlags(flags)
+
+	switch os.Args[1] {
+	case "backup":
+		output := flags.String("o", "snapshot.json", "Snapshot file to write")
+		flags.Parse(os.Args[2:])
+
+		if err := newClient().backup(*output); err != nil {
+			log.Fatalln("Error backing up: ", err)
+		}
+	case "restore":
+		input := flags.String("f", "snapshot.json", "Snapshot file to restore")
+		flags.Parse(os.Args[2:])
+
+		if err := newClient().restore(*input); err != nil {
+			log.Fatalln("Error restoring: ", err)
+		}
+	case "reset":
+		gatewayAPI := flags.String("gatewayapi", "http://localhost:1084", "Gateway API location")
+		polls := flags.Bool("polls", false, "Delete every poll too")
+		flags.Parse(os.Args[2:])
+
+		if err := newClient().reset(*gatewayAPI, *polls); err != nil {
+			log.Fatalln("Error resetting: ", err)
+		}
+	default:
+		usage()
+	}
+}
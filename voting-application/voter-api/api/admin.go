@@ -0,0 +1,52 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+
+	"shared/apierror"
+	"shared/apikey"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	AdminTokenHeader = "X-Admin-Token"
+)
+
+// Report whether the request carries the admin token configured through
+// the ADMIN_TOKEN environment variable, or an API key with the admin
+// scope. The admin token is disabled when the variable is unset.
+func isAdminRequest(c *gin.Context) bool {
+	if apikey.HasScope(c, apikey.ScopeAdmin) {
+		return true
+	}
+
+	adminToken := os.Getenv("ADMIN_TOKEN")
+	if adminToken == "" {
+		return false
+	}
+
+	requestToken := c.GetHeader(AdminTokenHeader)
+
+	return subtle.ConstantTimeCompare([]byte(requestToken), []byte(adminToken)) == 1
+}
+
+// The middleware that rejects requests without the admin token.
+func AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !isAdminRequest(c) {
+			apierror.Abort(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "A valid admin token is required")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// Return the store of the API keys, served on /admin/apikeys. Its
+// middleware authenticates the requests that send an X-API-Key.
+func (va *VoterAPI) APIKeys() *apikey.Store {
+	return va.apiKeys
+}
@@ -0,0 +1,22 @@
+package api
+
+import (
+	"log"
+
+	"shared/restclient"
+	"shared/votesclient"
+)
+
+// CodeVoterHasVotes is the code of the 409 answered for a voter that still
+// has votes when ?cascade=true is not passed.
+const CodeVoterHasVotes = "voter_has_votes"
+
+// Return the client of the votes API, which holds the votes of the voters.
+func loadVotesClient(votesAPIURL string) *votesclient.Client {
+	client, err := votesclient.New(restclient.Config{BaseURL: votesAPIURL})
+	if err != nil {
+		log.Fatalln("Error creating votes API client: ", err)
+	}
+
+	return client
+}
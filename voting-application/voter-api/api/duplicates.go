@@ -0,0 +1,115 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"voter-api/voter"
+
+	"shared/apierror"
+	"shared/pagination"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Implementation of GET /voters/duplicates.
+// List the pairs of voters that are probably the same person registered
+// twice: the same normalized name, give or take a typo, and the same
+// email or phone. Each pair links to the merge of the higher voter ID into
+// the lower.
+func (va *VoterAPI) FindDuplicateVoters(c *gin.Context) {
+	request, ok := pagination.Parse(c)
+	if !ok {
+		return
+	}
+
+	voters, err := va.voterList.GetAllVoters()
+	if err != nil {
+		log.Println("Error getting all voters: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not get voters", err)
+		return
+	}
+
+	duplicates := voter.FindDuplicates(voters)
+	total := len(duplicates)
+	duplicates = pagination.Slice(request, duplicates)
+
+	responses := make([]map[string]interface{}, len(duplicates))
+	for i, duplicate := range duplicates {
+		responses[i] = map[string]interface{}{
+			"voterIds":     duplicate.VoterIDs,
+			"matchedOn":    duplicate.MatchedOn,
+			"nameDistance": duplicate.NameDistance,
+			"links": map[string]interface{}{
+				"merge": map[string]interface{}{
+					"method": "POST",
+					"url":    fmt.Sprintf("/voters/%d/merge/%d", duplicate.VoterIDs[0], duplicate.VoterIDs[1]),
+				},
+			},
+		}
+	}
+
+	pagination.Respond(c, request, total, responses)
+}
+
+// Implementation of POST /voters/:id/merge/:otherId.
+// Merge the voter :otherId into the voter :id and delete it. The voter
+// keeps its names and status, takes the contact details it lacks, and gets
+// both vote histories. The votes of the Votes API belong to a voter ID, so
+// a voter :otherId that has votes is refused with 409.
+func (va *VoterAPI) MergeVoters(c *gin.Context) {
+	voterIDUint, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		log.Println("Error converting voter ID to uint: ", err)
+		apierror.AbortInvalidID(c, "Voter ID", err)
+		return
+	}
+
+	otherIDUint, err := strconv.ParseUint(c.Param("otherId"), 10, 32)
+	if err != nil {
+		log.Println("Error converting voter ID to uint: ", err)
+		apierror.AbortInvalidID(c, "Other voter ID", err)
+		return
+	}
+
+	if voterIDUint == otherIDUint {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeBadRequest, "A voter cannot be merged into itself")
+		return
+	}
+
+	for _, voterID := range []uint64{voterIDUint, otherIDUint} {
+		if _, err := va.voterList.GetVoter(uint(voterID)); err != nil {
+			log.Println("Error getting voter: ", err)
+			apierror.AbortWithError(c, http.StatusNotFound, fmt.Sprintf("Could not get voter %d", voterID), err)
+			return
+		}
+	}
+
+	dependents, err := va.votes.DeleteVoterVotes(c.Request.Context(), uint(otherIDUint), true)
+	if err != nil {
+		log.Println("Error getting the votes of voter: ", err)
+		apierror.AbortWithError(c, http.StatusServiceUnavailable, "Could not check the votes of the voter", err)
+		return
+	}
+
+	if len(dependents.VoteIDs) > 0 {
+		apierror.AbortWithDetails(c, http.StatusConflict, CodeVoterHasVotes,
+			"The voter merged in has votes, which cannot be moved to another voter", gin.H{"voteIds": dependents.VoteIDs})
+		return
+	}
+
+	merged, err := va.voterList.MergeVoters(uint(voterIDUint), uint(otherIDUint))
+	if err != nil {
+		log.Println("Error merging voters: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not merge voters", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Voters merged successfully.",
+		"voter":          merged,
+		"deletedVoterId": otherIDUint,
+	})
+}
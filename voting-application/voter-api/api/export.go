@@ -0,0 +1,41 @@
+package api
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"voter-api/voter"
+
+	"shared/export"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The CSV columns of a voter export.
+var voterExportHeader = []string{"voterId", "firstName", "lastName", "voteHistory", "status", "email", "phone"}
+
+// Implementation of GET /voters/export?format=csv|json.
+// Stream every voter as CSV or as a JSON array, row by row as they are
+// read from the store. In CSV the vote history is a list of
+// pollId@voteDate separated by semicolons.
+func (va *VoterAPI) ExportVoters(c *gin.Context) {
+	writer := export.NewWriter(c, "voters", voterExportHeader)
+	if writer == nil {
+		return
+	}
+
+	err := va.voterList.EachVoter(func(v voter.Voter) error {
+		history := make([]string, len(v.VoteHistory))
+		for i, poll := range v.VoteHistory {
+			history[i] = export.Uint(poll.PollID) + "@" + poll.VoteDate.Format(time.RFC3339)
+		}
+
+		return writer.Write(v, []string{export.Uint(v.VoterID), v.FirstName, v.LastName, strings.Join(history, ";"), v.Status, v.Email, v.Phone})
+	})
+	if err != nil {
+		log.Println("Error exporting voters: ", err)
+	}
+
+	writer.Close(err)
+}
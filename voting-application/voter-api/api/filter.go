@@ -0,0 +1,253 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"voter-api/voter"
+
+	"shared/apierror"
+	"shared/pagination"
+	"shared/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Return the filter of the ?status= and ?registeredBefore= query
+// parameters, answering 422 on the parameter at fault when either is
+// invalid. registeredBefore is a date, such as 2023-01-01, or an RFC 3339
+// time.
+func parseVoterFilter(c *gin.Context) (voter.Filter, bool) {
+	var filter voter.Filter
+
+	switch status := c.Query("status"); status {
+	case "", voter.VoterStatusPending, voter.VoterStatusActive, voter.VoterStatusSuspended:
+		filter.Status = status
+	default:
+		validation.AbortWithFields(c, []validation.FieldError{{
+			Field:   "status",
+			Rule:    "oneof",
+			Param:   "pending active suspended",
+			Message: "status must be one of pending, active or suspended",
+		}})
+		return voter.Filter{}, false
+	}
+
+	if registeredBefore := c.Query("registeredBefore"); registeredBefore != "" {
+		before, err := time.Parse(time.DateOnly, registeredBefore)
+		if err != nil {
+			before, err = time.Parse(time.RFC3339, registeredBefore)
+		}
+		if err != nil {
+			validation.AbortWithFields(c, []validation.FieldError{{
+				Field:   "registeredBefore",
+				Rule:    "datetime",
+				Message: "registeredBefore must be a date, such as 2023-01-01, or an RFC 3339 time",
+			}})
+			return voter.Filter{}, false
+		}
+		filter.RegisteredBefore = &before
+	}
+
+	return filter, true
+}
+
+// Return the filter of the ?from=, ?to=, ?limit= and ?offset= query
+// parameters of a vote history, and the page of the response, answering
+// 422 on the parameter at fault when one is invalid. from and to are
+// dates, such as 2024-01-01, or RFC 3339 times; a date to takes in the
+// whole day. Without ?limit= and ?offset=, ?page= and ?cursor= page the
+// history as the other lists.
+func parseHistoryFilter(c *gin.Context) (voter.HistoryFilter, pagination.Request, bool) {
+	var filter voter.HistoryFilter
+
+	for _, name := range []string{"from", "to"} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+
+		date, err := time.Parse(time.DateOnly, value)
+		if err == nil && name == "to" {
+			date = date.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+		if err != nil {
+			date, err = time.Parse(time.RFC3339, value)
+		}
+		if err != nil {
+			validation.AbortWithFields(c, []validation.FieldError{{
+				Field:   name,
+				Rule:    "datetime",
+				Message: name + " must be a date, such as 2024-01-01, or an RFC 3339 time",
+			}})
+			return voter.HistoryFilter{}, pagination.Request{}, false
+		}
+
+		if name == "from" {
+			filter.From = &date
+		} else {
+			filter.To = &date
+		}
+	}
+
+	if filter.From != nil && filter.To != nil && filter.To.Before(*filter.From) {
+		validation.AbortWithFields(c, []validation.FieldError{{
+			Field:   "to",
+			Rule:    "gtefield",
+			Param:   "from",
+			Message: "to must not be before from",
+		}})
+		return voter.HistoryFilter{}, pagination.Request{}, false
+	}
+
+	limit, offset := c.Query("limit"), c.Query("offset")
+	if limit == "" && offset == "" {
+		request, ok := pagination.Parse(c)
+		if ok && request.Paged {
+			filter.Offset, filter.Limit = request.Offset, request.PageSize
+		}
+		return filter, request, ok
+	}
+
+	for _, name := range []string{"page", "pageSize", "cursor"} {
+		if c.Query(name) != "" {
+			apierror.Abort(c, http.StatusBadRequest, apierror.CodeBadRequest, "The limit and offset query parameters cannot be used with "+name)
+			return voter.HistoryFilter{}, pagination.Request{}, false
+		}
+	}
+
+	filter.Limit = pagination.DefaultPageSize
+	if limit != "" {
+		size, err := strconv.Atoi(limit)
+		if err != nil || size < 1 || size > pagination.MaxPageSize {
+			validation.AbortWithFields(c, []validation.FieldError{{
+				Field:   "limit",
+				Rule:    "range",
+				Param:   "1 " + strconv.Itoa(pagination.MaxPageSize),
+				Message: "limit must be between 1 and " + strconv.Itoa(pagination.MaxPageSize),
+			}})
+			return voter.HistoryFilter{}, pagination.Request{}, false
+		}
+		filter.Limit = size
+	}
+
+	if offset != "" {
+		start, err := strconv.Atoi(offset)
+		if err != nil || start < 0 {
+			validation.AbortWithFields(c, []validation.FieldError{{
+				Field:   "offset",
+				Rule:    "min",
+				Param:   "0",
+				Message: "offset must be a number from 0",
+			}})
+			return voter.HistoryFilter{}, pagination.Request{}, false
+		}
+		filter.Offset = start
+	}
+
+	return filter, pagination.FromOffset(filter.Offset, filter.Limit), true
+}
+
+// Return the voters the filter selects, in their order.
+func filterVoters(voters []voter.Voter, filter voter.Filter) []voter.Voter {
+	filtered := make([]voter.Voter, 0, len(voters))
+	for _, v := range voters {
+		if filter.Matches(v) {
+			filtered = append(filtered, v)
+		}
+	}
+
+	return filtered
+}
+
+// Report whether a DELETE /voters request is a filtered bulk delete.
+func hasBulkDeleteQuery(c *gin.Context) bool {
+	for _, name := range []string{"status", "registeredBefore", "dryRun", "cascade"} {
+		if _, ok := c.GetQuery(name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Implementation of DELETE /voters?status=pending&registeredBefore=2023-01-01.
+// Delete the voters the filter selects. Like DELETE /voters/:id, voters
+// with a vote history are kept and listed in keptVoterIds unless
+// ?cascade=true also deletes their votes. ?dryRun=true lists the voters
+// that would be deleted and kept without deleting them.
+func (va *VoterAPI) DeleteVoters(c *gin.Context) {
+	filter, ok := parseVoterFilter(c)
+	if !ok {
+		return
+	}
+
+	dryRun := c.Query("dryRun") == "true"
+	cascade := c.Query("cascade") == "true"
+
+	matched, err := va.voterList.DeleteVoters(filter, true)
+	if err != nil {
+		log.Println("Error finding voters: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not find voters", err)
+		return
+	}
+
+	voterIDs := make([]uint, 0, len(matched))
+	keptVoterIDs := make([]uint, 0)
+	for _, v := range matched {
+		if len(v.VoteHistory) > 0 && !cascade {
+			keptVoterIDs = append(keptVoterIDs, v.VoterID)
+			continue
+		}
+		voterIDs = append(voterIDs, v.VoterID)
+	}
+
+	if dryRun {
+		c.JSON(http.StatusOK, gin.H{
+			"dryRun":       true,
+			"voterIds":     voterIDs,
+			"keptVoterIds": keptVoterIDs,
+		})
+		return
+	}
+
+	deletedVotes := make([]uint, 0)
+	if cascade {
+		for _, v := range matched {
+			if len(v.VoteHistory) == 0 {
+				continue
+			}
+
+			dependents, err := va.votes.DeleteVoterVotes(c.Request.Context(), v.VoterID, false)
+			if err != nil {
+				log.Println("Error deleting the votes of voter: ", err)
+				apierror.AbortWithError(c, http.StatusServiceUnavailable, "Could not delete the votes of the voters", err)
+				return
+			}
+			deletedVotes = append(deletedVotes, dependents.VoteIDs...)
+		}
+	} else {
+		filter.WithoutVotes = true
+	}
+
+	deleted, err := va.voterList.DeleteVoters(filter, false)
+	if err != nil {
+		log.Println("Error deleting voters: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not delete voters", err)
+		return
+	}
+
+	deletedIDs := make([]uint, len(deleted))
+	for i, v := range deleted {
+		deletedIDs[i] = v.VoterID
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Voters deleted successfully.",
+		"voterIds":     deletedIDs,
+		"keptVoterIds": keptVoterIDs,
+		"deletedVotes": deletedVotes,
+	})
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"voter-api/voter"
+
+	"shared/rpc"
+	"shared/votingpb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// voterServer serves the VoterService over gRPC from the same store as
+// the REST endpoints.
+type voterServer struct {
+	votingpb.UnimplementedVoterServiceServer
+	va *VoterAPI
+}
+
+// Register the VoterService on a gRPC server.
+func (va *VoterAPI) RegisterGRPC(server *grpc.Server) {
+	votingpb.RegisterVoterServiceServer(server, &voterServer{va: va})
+}
+
+// Convert a voter to its protobuf message.
+func voterToProto(v voter.Voter) *votingpb.Voter {
+	history := make([]*votingpb.VoterPoll, len(v.VoteHistory))
+	for i, voterPoll := range v.VoteHistory {
+		history[i] = &votingpb.VoterPoll{
+			PollId:   uint32(voterPoll.PollID),
+			VoteDate: rpc.Timestamp(&voterPoll.VoteDate),
+		}
+	}
+
+	return &votingpb.Voter{
+		VoterId:     uint32(v.VoterID),
+		FirstName:   v.FirstName,
+		LastName:    v.LastName,
+		VoteHistory: history,
+	}
+}
+
+func (s *voterServer) ListVoters(ctx context.Context, request *votingpb.ListVotersRequest) (*votingpb.ListVotersResponse, error) {
+	voters, err := s.va.voterList.GetAllVoters()
+	if err != nil {
+		log.Println("Error getting voters: ", err)
+		return nil, status.Error(codes.Internal, "could not get voters")
+	}
+
+	response := &votingpb.ListVotersResponse{Voters: make([]*votingpb.Voter, len(voters))}
+	for i, v := range voters {
+		response.Voters[i] = voterToProto(v)
+	}
+
+	return response, nil
+}
+
+func (s *voterServer) GetVoter(ctx context.Context, request *votingpb.GetVoterRequest) (*votingpb.Voter, error) {
+	v, err := s.va.voterList.GetVoter(uint(request.VoterId))
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+
+	return voterToProto(v), nil
+}
+
+func (s *voterServer) AddVoter(ctx context.Context, request *votingpb.AddVoterRequest) (*votingpb.Voter, error) {
+	if request.Voter == nil {
+		return nil, status.Error(codes.InvalidArgument, "voter is required")
+	}
+
+	newVoter := voter.NewVoter(uint(request.Voter.VoterId), request.Voter.FirstName, request.Voter.LastName)
+	if err := s.va.voterList.AddVoter(newVoter); err != nil {
+		log.Println("Error adding voter: ", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	s.va.voterCreated(newVoter)
+
+	return voterToProto(newVoter), nil
+}
+
+func (s *voterServer) UpdateVoter(ctx context.Context, request *votingpb.UpdateVoterRequest) (*votingpb.Voter, error) {
+	if request.Voter == nil {
+		return nil, status.Error(codes.InvalidArgument, "voter is required")
+	}
+
+	updatedVoter, err := s.va.voterList.UpdateVoter(voter.Voter{
+		VoterID:   uint(request.Voter.VoterId),
+		FirstName: request.Voter.FirstName,
+		LastName:  request.Voter.LastName,
+	})
+	if err != nil {
+		log.Println("Error updating voter: ", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return voterToProto(updatedVoter), nil
+}
+
+func (s *voterServer) DeleteVoter(ctx context.Context, request *votingpb.DeleteVoterRequest) (*emptypb.Empty, error) {
+	// The request has no cascade flag, so a voter with votes is kept.
+	dependents, err := s.va.votes.DeleteVoterVotes(ctx, uint(request.VoterId), true)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	if len(dependents.VoteIDs) > 0 {
+		return nil, status.Errorf(codes.FailedPrecondition, "voter has %d votes, delete them over REST with ?cascade=true", len(dependents.VoteIDs))
+	}
+
+	if err := s.va.voterList.DeleteVoter(uint(request.VoterId)); err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *voterServer) AddVoterPoll(ctx context.Context, request *votingpb.AddVoterPollRequest) (*votingpb.VoterPoll, error) {
+	voteDate := time.Now()
+	if request.VoteDate != nil {
+		voteDate = request.VoteDate.AsTime()
+	}
+
+	newVoterPoll, err := s.va.voterList.AddVoterPoll(uint(request.VoterId), uint(request.PollId), voteDate)
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	return &votingpb.VoterPoll{
+		PollId:   uint32(newVoterPoll.PollID),
+		VoteDate: rpc.Timestamp(&newVoterPoll.VoteDate),
+	}, nil
+}
+
+func (s *voterServer) DeleteVoterPoll(ctx context.Context, request *votingpb.DeleteVoterPollRequest) (*emptypb.Empty, error) {
+	if err := s.va.voterList.DeleteVoterPoll(uint(request.VoterId), uint(request.PollId)); err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"voter-api/voter"
+
+	"shared/negotiate"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// A voter who voted in two polls.
+const testVoterJSON = `{
+	"voterId": 3,
+	"firstName": "Ada",
+	"lastName": "Lovelace",
+	"voteHistory": [
+		{"pollId": 1, "voteDate": "2026-10-14T12:00:00Z"},
+		{"pollId": 2, "voteDate": "2026-10-14T13:00:00Z"}
+	],
+	"status": "active",
+	"email": "ada@example.com"
+}`
+
+func testVoter(t *testing.T) voter.Voter {
+	t.Helper()
+
+	var v voter.Voter
+	if err := json.Unmarshal([]byte(testVoterJSON), &v); err != nil {
+		t.Fatalf("decoding test voter: %v", err)
+	}
+
+	return v
+}
+
+func TestVoterResponseXML(t *testing.T) {
+	data, err := xml.Marshal(negotiate.NewList("voters", []voterResponse{newVoterResponse(testVoter(t))}))
+	if err != nil {
+		t.Fatalf("encoding voters as XML: %v", err)
+	}
+
+	var voters struct {
+		XMLName xml.Name `xml:"voters"`
+		Voters  []struct {
+			VoterID     uint    `xml:"voterId"`
+			FirstName   string  `xml:"firstName"`
+			Email       string  `xml:"email"`
+			Phone       *string `xml:"phone"`
+			Status      string  `xml:"status"`
+			VoteHistory []struct {
+				PollID   uint      `xml:"pollId"`
+				VoteDate time.Time `xml:"voteDate"`
+			} `xml:"voteHistory>poll"`
+			Links struct {
+				Delete struct {
+					Method string `xml:"method,attr"`
+					URL    string `xml:"url,attr"`
+				} `xml:"delete"`
+			} `xml:"links"`
+		} `xml:"voter"`
+	}
+	if err := xml.Unmarshal(data, &voters); err != nil {
+		t.Fatalf("decoding %s: %v", data, err)
+	}
+
+	if len(voters.Voters) != 1 {
+		t.Fatalf("XML has %d voters, want 1: %s", len(voters.Voters), data)
+	}
+	v := voters.Voters[0]
+	if v.VoterID != 3 || v.FirstName != "Ada" || v.Email != "ada@example.com" || v.Phone != nil || v.Status != "active" {
+		t.Fatalf("XML voter = %+v", v)
+	}
+	if len(v.VoteHistory) != 2 || v.VoteHistory[1].PollID != 2 || !v.VoteHistory[1].VoteDate.Equal(time.Date(2026, 10, 14, 13, 0, 0, 0, time.UTC)) {
+		t.Fatalf("XML vote history = %+v", v.VoteHistory)
+	}
+	if v.Links.Delete.Method != "DELETE" || v.Links.Delete.URL != "/voters/3" {
+		t.Fatalf("XML delete link = %+v, want DELETE /voters/3", v.Links.Delete)
+	}
+}
+
+func TestVoterPollResponseFormats(t *testing.T) {
+	response := newVoterPollResponse(3, 2, time.Date(2026, 10, 14, 13, 0, 0, 0, time.UTC))
+
+	data, err := xml.Marshal(response)
+	if err != nil {
+		t.Fatalf("encoding voter poll as XML: %v", err)
+	}
+	want := `<poll><pollId>2</pollId><voteDate>2026-10-14T13:00:00Z</voteDate><links>` +
+		`<get method="GET" url="/voters/3/polls/2"></get><update method="PUT" url="/voters/3/polls/2"></update>` +
+		`<delete method="DELETE" url="/voters/3/polls/2"></delete></links></poll>`
+	if string(data) != want {
+		t.Fatalf("XML =\n%s\nwant\n%s", data, want)
+	}
+
+	data, err = msgpack.Marshal(response)
+	if err != nil {
+		t.Fatalf("encoding voter poll as msgpack: %v", err)
+	}
+	var decoded voterPollResponse
+	if err := msgpack.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("decoding msgpack: %v", err)
+	}
+	if decoded.PollID != 2 || !decoded.VoteDate.Equal(response.VoteDate) || decoded.Links != response.Links {
+		t.Fatalf("msgpack decoded to %+v, want %+v", decoded, response)
+	}
+}
@@ -0,0 +1,186 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"shared/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// How far apart the date of a history entry and the date of its vote can be
+// before the entry is given the date of the vote.
+const historyDateTolerance = time.Second
+
+// historyChange is an entry of the vote history a rebuild added, removed,
+// redated or kept.
+type historyChange struct {
+	PollID   uint       `json:"pollId"`
+	VoteDate *time.Time `json:"voteDate,omitempty"`
+	// The date the entry had, for a redated entry.
+	PreviousDate *time.Time `json:"previousDate,omitempty"`
+	// Why an entry without a vote of the voter was kept.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Implementation of POST /voters/:id/history/rebuild.
+// Rebuild the vote history of the voter with :id from their votes in the
+// Votes API, which are taken as the truth: a poll the voter voted in is
+// added, and an entry without a vote is removed. An entry whose date is
+// not the date of its vote gets the date of the vote. The votes of
+// anonymous polls have no voter, so the entries of those polls are kept.
+// ?dryRun=true only reports the changes.
+func (va *VoterAPI) RebuildVoterHistory(c *gin.Context) {
+	voterIDUint, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		log.Println("Error converting voter ID to uint: ", err)
+		apierror.AbortInvalidID(c, "Voter ID", err)
+		return
+	}
+	voterID := uint(voterIDUint)
+	dryRun := c.Query("dryRun") == "true"
+
+	storedVoter, err := va.voterList.GetVoter(voterID)
+	if err != nil {
+		log.Println("Error getting voter: ", err)
+		apierror.AbortWithError(c, http.StatusNotFound, "Could not get voter", err)
+		return
+	}
+
+	voterVotes, err := va.votes.ListVoterVotes(c.Request.Context(), voterID)
+	if err != nil {
+		log.Println("Error getting the votes of voter: ", err)
+		apierror.AbortWithError(c, http.StatusServiceUnavailable, "Could not get the votes of the voter", err)
+		return
+	}
+
+	// The date of the earliest vote of the voter in every poll, nil when
+	// none of them has one.
+	voted := make(map[uint]*time.Time, len(voterVotes))
+	for _, vote := range voterVotes {
+		date, ok := voted[vote.PollID]
+		if !ok || (vote.VoteDate != nil && (date == nil || vote.VoteDate.Before(*date))) {
+			voted[vote.PollID] = vote.VoteDate
+		}
+	}
+
+	added := make([]historyChange, 0)
+	removed := make([]historyChange, 0)
+	redated := make([]historyChange, 0)
+	kept := make([]historyChange, 0)
+
+	inHistory := make(map[uint]bool, len(storedVoter.VoteHistory))
+	for _, entry := range storedVoter.VoteHistory {
+		inHistory[entry.PollID] = true
+		entryDate := entry.VoteDate
+
+		voteDate, ok := voted[entry.PollID]
+		if !ok {
+			anonymous, err := va.anonymousPoll(c.Request.Context(), entry.PollID)
+			if err != nil {
+				log.Println("Error getting the votes of poll: ", err)
+				apierror.AbortWithError(c, http.StatusServiceUnavailable, "Could not get the votes of a poll in the vote history", err)
+				return
+			}
+
+			if anonymous {
+				kept = append(kept, historyChange{PollID: entry.PollID, VoteDate: &entryDate, Reason: "anonymous poll"})
+			} else {
+				removed = append(removed, historyChange{PollID: entry.PollID, VoteDate: &entryDate})
+			}
+			continue
+		}
+
+		if voteDate == nil {
+			continue
+		}
+
+		diff := voteDate.Sub(entryDate)
+		if diff > historyDateTolerance || diff < -historyDateTolerance {
+			redated = append(redated, historyChange{PollID: entry.PollID, VoteDate: voteDate, PreviousDate: &entryDate})
+		}
+	}
+
+	for pollID, voteDate := range voted {
+		if !inHistory[pollID] {
+			added = append(added, historyChange{PollID: pollID, VoteDate: voteDate})
+		}
+	}
+	sort.Slice(added, func(i, j int) bool { return added[i].PollID < added[j].PollID })
+
+	if !dryRun {
+		if err := va.applyHistoryRebuild(voterID, added, removed, redated); err != nil {
+			log.Println("Error rebuilding vote history: ", err)
+			apierror.AbortWithError(c, http.StatusInternalServerError, "Could not rebuild the vote history", err)
+			return
+		}
+
+		if storedVoter, err = va.voterList.GetVoter(voterID); err != nil {
+			log.Println("Error getting voter: ", err)
+			apierror.AbortWithError(c, http.StatusInternalServerError, "Could not get voter", err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"voterId":     voterID,
+		"dryRun":      dryRun,
+		"votes":       len(voterVotes),
+		"added":       added,
+		"removed":     removed,
+		"redated":     redated,
+		"kept":        kept,
+		"voteHistory": storedVoter.VoteHistory,
+	})
+}
+
+// Report whether a poll is anonymous: its votes have no voter.
+func (va *VoterAPI) anonymousPoll(ctx context.Context, pollID uint) (bool, error) {
+	pollVotes, err := va.votes.ListPollVotes(ctx, pollID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, vote := range pollVotes {
+		if vote.VoterID == 0 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Make the changes of a rebuild to the vote history of a voter. An entry
+// added for a vote without a date is dated now.
+func (va *VoterAPI) applyHistoryRebuild(voterID uint, added, removed, redated []historyChange) error {
+	for _, change := range removed {
+		if err := va.voterList.DeleteVoterPoll(voterID, change.PollID); err != nil {
+			return err
+		}
+	}
+
+	for i, change := range added {
+		voteDate := time.Now().UTC()
+		if change.VoteDate != nil {
+			voteDate = *change.VoteDate
+		}
+
+		if _, err := va.voterList.AddVoterPoll(voterID, change.PollID, voteDate); err != nil {
+			return err
+		}
+		added[i].VoteDate = &voteDate
+	}
+
+	for _, change := range redated {
+		if _, err := va.voterList.UpdateVoterPoll(voterID, change.PollID, *change.VoteDate); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"voter-api/voter"
+
+	"shared/apierror"
+	"shared/pagination"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Implementation of GET /voters/search?q=smi&limit=20.
+// Returns the voters whose first or last name contains q, ignoring case.
+// Names that start with q come first; limit defaults to 20, at most 100.
+// ?page= or ?cursor= pages the voters found.
+func (va *VoterAPI) SearchVoters(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeBadRequest, "The q query parameter is required")
+		return
+	}
+
+	limit := voter.DefaultSearchLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > voter.MaxSearchLimit {
+			log.Println("Error converting search limit: ", value)
+			apierror.Abort(c, http.StatusBadRequest, apierror.CodeBadRequest, "The limit query parameter must be between 1 and "+strconv.Itoa(voter.MaxSearchLimit))
+			return
+		}
+		limit = parsed
+	}
+
+	request, ok := pagination.Parse(c)
+	if !ok {
+		return
+	}
+
+	voters, err := va.voterList.SearchVoters(query, limit)
+	if err != nil {
+		log.Println("Error searching voters: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not search voters", err)
+		return
+	}
+
+	pagination.Respond(c, request, len(voters), pagination.Slice(request, voters))
+}
+
+// Implementation of POST /admin/voters/search/reindex.
+// Rebuild the voter search index from the stored voters, for voters that
+// were stored before the index existed.
+func (va *VoterAPI) RebuildSearchIndex(c *gin.Context) {
+	if va.voterCache == nil {
+		apierror.Abort(c, http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, "Redis is not connected")
+		return
+	}
+
+	indexed, err := va.voterCache.RebuildSearchIndex()
+	if err != nil {
+		log.Println("Error rebuilding voter search index: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not rebuild the voter search index", err)
+		return
+	}
+
+	log.Printf("Admin rebuilt the voter search index: %d voters", indexed)
+
+	c.JSON(http.StatusOK, gin.H{
+		"indexed": indexed,
+	})
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"voter-api/voter"
+
+	"shared/apierror"
+	"shared/seed"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+// Add the voters of a fixture. Voters that already exist are skipped.
+func (va *VoterAPI) Seed(ctx context.Context, fixture *seed.Fixture) seed.Report {
+	report := seed.NewReport()
+
+	for _, v := range fixture.Voters {
+		if ctx.Err() != nil {
+			report.Failed("voter", v.VoterID, ctx.Err())
+			continue
+		}
+
+		if _, err := va.voterList.GetVoter(v.VoterID); err == nil {
+			report.Skipped("voter")
+			continue
+		}
+
+		newVoter := voter.NewVoter(v.VoterID, v.FirstName, v.LastName)
+		if err := binding.Validator.ValidateStruct(newVoter); err != nil {
+			report.Failed("voter", v.VoterID, err)
+			continue
+		}
+
+		if err := va.voterList.AddVoter(newVoter); err != nil {
+			if status, _, ok := apierror.Classify(err); ok && status == http.StatusConflict {
+				report.Skipped("voter")
+				continue
+			}
+			report.Failed("voter", v.VoterID, err)
+			continue
+		}
+
+		report.Created("voter")
+	}
+
+	return report
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"voter-api/voter"
+
+	"shared/snapshot"
+)
+
+var errStopEach = errors.New("stop")
+
+// Add the voters of a snapshot as they are, with their status and vote
+// history, into an empty voter API. The vote histories are checked
+// against the polls by the archive, the voter API has no polls.
+func (va *VoterAPI) ImportSnapshot(ctx context.Context, records []json.RawMessage) (int, error) {
+	err := va.voterList.EachVoter(func(voter.Voter) error { return errStopEach })
+	if errors.Is(err, errStopEach) {
+		return 0, snapshot.ErrNotEmpty
+	} else if err != nil {
+		return 0, err
+	}
+
+	voters := make([]voter.Voter, len(records))
+	var problems []string
+	for i, record := range records {
+		if err := json.Unmarshal(record, &voters[i]); err != nil || voters[i].VoterID == 0 {
+			problems = append(problems, fmt.Sprintf("voter %d of the snapshot is unreadable or has no voterId", i+1))
+		}
+	}
+	if len(problems) > 0 {
+		return 0, &snapshot.InvalidError{Problems: problems}
+	}
+
+	for i, v := range voters {
+		if ctx.Err() != nil {
+			return i, ctx.Err()
+		}
+
+		if err := va.voterList.AddVoter(v); err != nil {
+			return i, fmt.Errorf("adding voter %d: %w", v.VoterID, err)
+		}
+	}
+
+	return len(voters), nil
+}
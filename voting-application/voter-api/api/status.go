@@ -0,0 +1,52 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"voter-api/voter"
+
+	"shared/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Implementation of POST /voters/:id/activate.
+// Activate the pending or suspended voter with :id so that they can vote.
+func (va *VoterAPI) ActivateVoter(c *gin.Context) {
+	va.setVoterStatus(c, va.voterList.ActivateVoter, "Could not activate voter")
+}
+
+// Implementation of POST /voters/:id/suspend.
+// Suspend the voter with :id so that their votes are refused until they are
+// activated again.
+func (va *VoterAPI) SuspendVoter(c *gin.Context) {
+	va.setVoterStatus(c, va.voterList.SuspendVoter, "Could not suspend voter")
+}
+
+// Apply a status change to the voter with :id, answering 409 when the voter
+// already has the status.
+func (va *VoterAPI) setVoterStatus(c *gin.Context, change func(voterID uint) (voter.Voter, error), message string) {
+	voterIDUint, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		log.Println("Error converting voter ID to uint: ", err)
+		apierror.AbortInvalidID(c, "Voter ID", err)
+		return
+	}
+
+	if _, err := va.voterList.GetVoter(uint(voterIDUint)); err != nil {
+		log.Println("Error getting voter: ", err)
+		apierror.AbortWithError(c, http.StatusNotFound, "Could not get voter", err)
+		return
+	}
+
+	updatedVoter, err := change(uint(voterIDUint))
+	if err != nil {
+		log.Println("Error changing voter status: ", err)
+		apierror.AbortWithError(c, http.StatusConflict, message, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, updatedVoter)
+}
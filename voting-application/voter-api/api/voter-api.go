@@ -1,6 +1,8 @@
 package api
 
 import (
+	"encoding/xml"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -9,104 +11,159 @@ import (
 
 	"voter-api/voter"
 
+	"shared/apierror"
+	"shared/apikey"
+	"shared/conflict"
+	"shared/failover"
+	"shared/healthkit"
+	"shared/listorder"
+	"shared/negotiate"
+	"shared/pagination"
+	"shared/validation"
+	"shared/votesclient"
+	"shared/webhook"
+	"shared/worker"
+
 	"github.com/gin-gonic/gin"
 )
 
 // The API handler that handles incoming requests.
 type VoterAPI struct {
-	voterList        *voter.VoterCache
-	totalCalls       uint64
-	errorCalls       uint64
-	bootTime         time.Time
-	totalRequestTime time.Duration
+	voterList  voter.Store
+	voterCache *voter.VoterCache
+	scheduler  *worker.Scheduler
+	votes      *votesclient.Client
+	webhooks   *webhook.Registry
+	apiKeys    *apikey.Store
+	metrics    *healthkit.Metrics
 }
 
-// Create a new instance of VoterAPI with an initialized voter cache.
-func NewVoterHandler() *VoterAPI {
+// Create a new instance of VoterAPI with an initialized voter cache and
+// the voter store selected by STORAGE_BACKEND.
+func NewVoterHandler(votesAPIURL string) *VoterAPI {
 	voterCache, _ := voter.NewVoterCache()
 
+	voterStore, err := voter.NewVoterStore(voterCache)
+	if err != nil {
+		log.Println("Error initializing voter store: ", err)
+	}
+
 	return &VoterAPI{
-		voterList:        voterCache,
-		totalCalls:       0,
-		errorCalls:       0,
-		bootTime:         time.Now(),
-		totalRequestTime: 0,
+		voterList:  voterStore,
+		voterCache: voterCache,
+		scheduler:  worker.NewScheduler(voterCache.RedisClient(), "voter-api"),
+		votes:      loadVotesClient(votesAPIURL),
+		webhooks:   webhook.NewRegistry(voterCache.RedisClient(), "voter-api"),
+		apiKeys:    apikey.NewStore(voterCache.RedisClient()),
+		metrics:    healthkit.New(),
 	}
 }
 
 // The custom middleware to handle health metadata.
 func HealthMiddleware(va *VoterAPI) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// Update total API calls count.
-		va.totalCalls++
+	return va.metrics.Middleware()
+}
 
-		// Record the start time of the request.
-		start := time.Now()
+// voterResponse is a voter as the GET endpoints show it, in JSON, XML or
+// msgpack.
+type voterResponse struct {
+	XMLName     xml.Name        `json:"-" xml:"voter" msgpack:"-"`
+	VoterID     uint            `json:"voterId" xml:"voterId" msgpack:"voterId"`
+	FirstName   string          `json:"firstName" xml:"firstName" msgpack:"firstName"`
+	LastName    string          `json:"lastName" xml:"lastName" msgpack:"lastName"`
+	Email       string          `json:"email" xml:"email,omitempty" msgpack:"email"`
+	Phone       string          `json:"phone" xml:"phone,omitempty" msgpack:"phone"`
+	VoteHistory []votedPoll     `json:"voteHistory" xml:"voteHistory>poll" msgpack:"voteHistory"`
+	Status      string          `json:"status" xml:"status" msgpack:"status"`
+	Links       negotiate.Links `json:"links" xml:"links" msgpack:"links"`
+}
 
-		// Process the request.
-		c.Next()
+// votedPoll is a poll of the vote history of a voter response.
+type votedPoll struct {
+	PollID   uint      `json:"pollId" xml:"pollId" msgpack:"pollId"`
+	VoteDate time.Time `json:"voteDate" xml:"voteDate" msgpack:"voteDate"`
+}
 
-		// Update error API calls count if there's an error.
-		if c.Writer.Status() >= 400 {
-			va.errorCalls++
-		}
+func newVoterResponse(v voter.Voter) voterResponse {
+	history := make([]votedPoll, len(v.VoteHistory))
+	for i, voterPoll := range v.VoteHistory {
+		history[i] = votedPoll{PollID: voterPoll.PollID, VoteDate: voterPoll.VoteDate}
+	}
+
+	return voterResponse{
+		VoterID:     v.VoterID,
+		FirstName:   v.FirstName,
+		LastName:    v.LastName,
+		Email:       v.Email,
+		Phone:       v.Phone,
+		VoteHistory: history,
+		Status:      v.Status,
+		Links:       negotiate.ResourceLinks(fmt.Sprintf("/voters/%d", v.VoterID)),
+	}
+}
 
-		// Calculate the request duration.
-		duration := time.Since(start)
+// voterPollResponse is a poll of the vote history of a voter as the GET
+// endpoints show it.
+type voterPollResponse struct {
+	XMLName  xml.Name        `json:"-" xml:"poll" msgpack:"-"`
+	PollID   uint            `json:"pollId" xml:"pollId" msgpack:"pollId"`
+	VoteDate time.Time       `json:"voteDate" xml:"voteDate" msgpack:"voteDate"`
+	Links    negotiate.Links `json:"links" xml:"links" msgpack:"links"`
+}
 
-		// Update the total request time.
-		va.totalRequestTime += duration
+func newVoterPollResponse(voterID, pollID uint, voteDate time.Time) voterPollResponse {
+	return voterPollResponse{
+		PollID:   pollID,
+		VoteDate: voteDate,
+		Links:    negotiate.ResourceLinks(fmt.Sprintf("/voters/%d/polls/%d", voterID, pollID)),
 	}
 }
 
-// The root endpoint that welcomes users to the API.
-func (va *VoterAPI) WelcomeToVoterAPI(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Welcome to voter API.",
-	})
+// The fields GET /voters can be sorted by.
+var voterSortFields = listorder.Fields[voter.Voter]{
+	"voterId":   func(a, b voter.Voter) int { return listorder.Compare(a.VoterID, b.VoterID) },
+	"firstName": func(a, b voter.Voter) int { return listorder.Compare(a.FirstName, b.FirstName) },
+	"lastName":  func(a, b voter.Voter) int { return listorder.Compare(a.LastName, b.LastName) },
+	"status":    func(a, b voter.Voter) int { return listorder.Compare(a.Status, b.Status) },
 }
 
 // Implementation of GET /voters.
-// Returns all voters with all voter history.
+// Returns all voters with all voter history, by voter ID or in the order
+// of ?sort=. ?status= and ?registeredBefore= only return the voters they
+// select, and ?page= or ?cursor= one page of them.
 func (va *VoterAPI) ListAllVoters(c *gin.Context) {
+	request, ok := pagination.Parse(c)
+	if !ok {
+		return
+	}
+
 	voters, err := va.voterList.GetAllVoters()
 	if err != nil {
 		log.Println("Error getting voters: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
+		apierror.AbortWithError(c, http.StatusBadRequest, "Could not get voters", err)
+		return
+	}
+
+	filter, ok := parseVoterFilter(c)
+	if !ok {
 		return
 	}
 
-	if voters == nil {
-		voters = make([]voter.Voter, 0)
+	voters = filterVoters(voters, filter)
+
+	if !listorder.Sort(c, voters, voterSortFields) {
+		return
 	}
 
-	voterResponses := make([]map[string]interface{}, len(voters))
+	total := len(voters)
+	voters = pagination.Slice(request, voters)
 
+	voterResponses := make([]voterResponse, len(voters))
 	for i, voter := range voters {
-		voterResponse := map[string]interface{}{
-			"voterId":     voter.VoterID,
-			"firstName":   voter.FirstName,
-			"lastName":    voter.LastName,
-			"voteHistory": voter.VoteHistory,
-			"links": map[string]interface{}{
-				"get": map[string]interface{}{
-					"method": "GET",
-					"url":    fmt.Sprintf("/voters/%d", voter.VoterID),
-				},
-				"update": map[string]interface{}{
-					"method": "PUT",
-					"url":    fmt.Sprintf("/voters/%d", voter.VoterID),
-				},
-				"delete": map[string]interface{}{
-					"method": "DELETE",
-					"url":    fmt.Sprintf("/voters/%d", voter.VoterID),
-				},
-			},
-		}
-		voterResponses[i] = voterResponse
+		voterResponses[i] = newVoterResponse(voter)
 	}
 
-	c.JSON(http.StatusOK, voterResponses)
+	pagination.Negotiate(c, request, total, "voters", voterResponses)
 }
 
 // Implementation of GET /voters/:id.
@@ -116,85 +173,76 @@ func (va *VoterAPI) GetVoter(c *gin.Context) {
 	voterIDUint, err := strconv.ParseUint(voterID, 10, 32)
 	if err != nil {
 		log.Println("Error converting voter ID to uint: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
+		apierror.AbortInvalidID(c, "Voter ID", err)
 		return
 	}
 
 	voter, err := va.voterList.GetVoter(uint(voterIDUint))
 	if err != nil {
 		log.Println("Error getting voter: ", err)
-		c.AbortWithStatus(http.StatusNotFound)
-		return
-	}
-
-	response := map[string]interface{}{
-		"voterId":     voter.VoterID,
-		"firstName":   voter.FirstName,
-		"lastName":    voter.LastName,
-		"voteHistory": voter.VoteHistory,
-		"links": map[string]interface{}{
-			"get": map[string]interface{}{
-				"method": "GET",
-				"url":    fmt.Sprintf("/voters/%d", voter.VoterID),
-			},
-			"update": map[string]interface{}{
-				"method": "PUT",
-				"url":    fmt.Sprintf("/voters/%d", voter.VoterID),
-			},
-			"delete": map[string]interface{}{
-				"method": "DELETE",
-				"url":    fmt.Sprintf("/voters/%d", voter.VoterID),
-			},
-		},
-	}
-
-	c.JSON(http.StatusOK, response)
+		apierror.AbortWithError(c, http.StatusNotFound, "Could not get voter", err)
+		return
+	}
+
+	negotiate.Respond(c, http.StatusOK, newVoterResponse(voter))
 }
 
 // Implementation of POST /voters/:id.
-// Add a new voter with :id.
+// Add a new voter with :id. When a voter already has :id, ?onConflict=
+// fails with 409 (the default), replaces or merges into the stored voter;
+// X-Create-Outcome says which happened.
 func (va *VoterAPI) AddVoter(c *gin.Context) {
 	voterID := c.Param("id")
 	voterIDUint, err := strconv.ParseUint(voterID, 10, 32)
 	if err != nil {
 		log.Println("Error converting voter ID to uint: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
+		apierror.AbortInvalidID(c, "Voter ID", err)
+		return
+	}
+
+	onConflict, err := conflict.Parse(c.Query("onConflict"))
+	if err != nil {
+		apierror.AbortWithError(c, http.StatusBadRequest, "Invalid onConflict", err)
 		return
 	}
 
 	var newVoter voter.Voter
-	if err := c.ShouldBindJSON(&newVoter); err != nil {
+	if err := validation.Bind(c, &newVoter); err != nil {
 		log.Println("Error binding JSON: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
 		return
 	}
 
-	newVoter = voter.NewVoter(uint(voterIDUint), newVoter.FirstName, newVoter.LastName)
-
-	if err := va.voterList.AddVoter(newVoter); err != nil {
+	newVoter.VoterID = uint(voterIDUint)
+	createdVoter, outcome, err := va.voterList.CreateVoter(newVoter, onConflict)
+	if err != nil {
 		log.Println("Error adding voter: ", err)
-		c.AbortWithStatus(http.StatusInternalServerError)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not add voter", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, newVoter)
+	if outcome == conflict.Created {
+		va.voterCreated(createdVoter)
+	}
+
+	conflict.SetOutcome(c, outcome)
+	c.JSON(http.StatusOK, createdVoter)
 }
 
 // Implementation of PUT /voters/:id.
-// Update an existing voter with :id.
+// Update an existing voter with :id. The email and phone the request
+// leaves out are kept.
 func (va *VoterAPI) UpdateVoter(c *gin.Context) {
 	voterID := c.Param("id")
 	voterIDUint, err := strconv.ParseUint(voterID, 10, 32)
 	if err != nil {
 		log.Println("Error converting voter ID to uint: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
+		apierror.AbortInvalidID(c, "Voter ID", err)
 		return
 	}
 
 	var voter voter.Voter
-	if err := c.ShouldBindJSON(&voter); err != nil {
+	if err := validation.Bind(c, &voter); err != nil {
 		log.Println("Error binding JSON: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
 		return
 	}
 
@@ -202,7 +250,7 @@ func (va *VoterAPI) UpdateVoter(c *gin.Context) {
 	updatedVoter, err := va.voterList.UpdateVoter(voter)
 	if err != nil {
 		log.Println("Error updating voter: ", err)
-		c.AbortWithStatus(http.StatusInternalServerError)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not update voter", err)
 		return
 	}
 
@@ -210,11 +258,17 @@ func (va *VoterAPI) UpdateVoter(c *gin.Context) {
 }
 
 // Implementation of DELETE /voters.
-// Delete all voters.
+// Delete all voters. With a filter, ?dryRun= or ?cascade= only the voters
+// the filter selects are deleted, see DeleteVoters.
 func (va *VoterAPI) DeleteAllVoters(c *gin.Context) {
+	if hasBulkDeleteQuery(c) {
+		va.DeleteVoters(c)
+		return
+	}
+
 	if err := va.voterList.DeleteAllVoters(); err != nil {
 		log.Println("Error deleting voters: ", err)
-		c.AbortWithStatus(http.StatusNotFound)
+		apierror.AbortWithError(c, http.StatusNotFound, "Could not delete voters", err)
 		return
 	}
 
@@ -230,64 +284,84 @@ func (va *VoterAPI) DeleteVoter(c *gin.Context) {
 	voterIDUint, err := strconv.ParseUint(voterID, 10, 32)
 	if err != nil {
 		log.Println("Error converting voter ID to uint: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
+		apierror.AbortInvalidID(c, "Voter ID", err)
 		return
 	}
 
+	if _, err := va.voterList.GetVoter(uint(voterIDUint)); err != nil {
+		log.Println("Error getting voter: ", err)
+		apierror.AbortWithError(c, http.StatusNotFound, "Could not delete voter", err)
+		return
+	}
+
+	// Find the votes of the voter first, they are only deleted with
+	// ?cascade=true.
+	dependents, err := va.votes.DeleteVoterVotes(c.Request.Context(), uint(voterIDUint), true)
+	if err != nil {
+		log.Println("Error getting the votes of voter: ", err)
+		apierror.AbortWithError(c, http.StatusServiceUnavailable, "Could not check the votes of the voter", err)
+		return
+	}
+
+	cascade := c.Query("cascade") == "true"
+	if len(dependents.VoteIDs) > 0 && !cascade {
+		apierror.AbortWithDetails(c, http.StatusConflict, CodeVoterHasVotes,
+			"The voter has votes, delete them with ?cascade=true", gin.H{"voteIds": dependents.VoteIDs})
+		return
+	}
+
+	if len(dependents.VoteIDs) > 0 {
+		dependents, err = va.votes.DeleteVoterVotes(c.Request.Context(), uint(voterIDUint), false)
+		if err != nil {
+			log.Println("Error deleting the votes of voter: ", err)
+			apierror.AbortWithError(c, http.StatusServiceUnavailable, "Could not delete the votes of the voter", err)
+			return
+		}
+	}
+
 	if err := va.voterList.DeleteVoter(uint(voterIDUint)); err != nil {
 		log.Println("Error deleting voter: ", err)
-		c.AbortWithStatus(http.StatusNotFound)
+		apierror.AbortWithError(c, http.StatusNotFound, "Could not delete voter", err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Voter deleted successfully.",
+		"message":      "Voter deleted successfully.",
+		"deletedVotes": dependents.VoteIDs,
 	})
 }
 
 // Implementation of GET /voters/:id/polls.
-// Get the voting history of a voter by :id.
+// Get the voting history of a voter by :id, or one page of it with ?page=,
+// ?cursor= or ?limit= and ?offset=. ?from= and ?to= keep the polls voted
+// in a date range, such as ?from=2024-01-01&to=2024-12-31.
 func (va *VoterAPI) GetVoterHistory(c *gin.Context) {
 	voterID := c.Param("id")
 	voterIDUint, err := strconv.ParseUint(voterID, 10, 32)
 	if err != nil {
 		log.Println("Error converting voter ID to uint: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
+		apierror.AbortInvalidID(c, "Voter ID", err)
+		return
+	}
+
+	filter, request, ok := parseHistoryFilter(c)
+	if !ok {
 		return
 	}
 
-	voterHistory, err := va.voterList.GetVoterHistory(uint(voterIDUint))
+	voterHistory, total, err := va.voterList.GetVoterHistoryFiltered(uint(voterIDUint), filter)
 	if err != nil {
 		log.Println("Error getting voter history: ", err)
-		c.AbortWithStatus(http.StatusNotFound)
+		apierror.AbortWithError(c, http.StatusNotFound, "Could not get voter history", err)
 		return
 	}
 
-	voterHistoryResponses := make([]map[string]interface{}, len(voterHistory))
-
+	voterHistoryResponses := make([]voterPollResponse, len(voterHistory))
 	for i, voterPoll := range voterHistory {
-		voterHistoryResponse := map[string]interface{}{
-			"pollId":   voterPoll.PollID,
-			"voteDate": voterPoll.VoteDate,
-			"links": map[string]interface{}{
-				"get": map[string]interface{}{
-					"method": "GET",
-					"url":    fmt.Sprintf("/voters/%s/polls/%d", strconv.Itoa(int(voterIDUint)), voterPoll.PollID),
-				},
-				"update": map[string]interface{}{
-					"method": "PUT",
-					"url":    fmt.Sprintf("/voters/%s/polls/%d", strconv.Itoa(int(voterIDUint)), voterPoll.PollID),
-				},
-				"delete": map[string]interface{}{
-					"method": "DELETE",
-					"url":    fmt.Sprintf("/voters/%s/polls/%d", strconv.Itoa(int(voterIDUint)), voterPoll.PollID),
-				},
-			},
-		}
-		voterHistoryResponses[i] = voterHistoryResponse
+		voterHistoryResponses[i] = newVoterPollResponse(uint(voterIDUint), voterPoll.PollID, voterPoll.VoteDate)
 	}
 
-	c.JSON(http.StatusOK, voterHistoryResponses)
+	pagination.Negotiate(c, request, total, "polls", voterHistoryResponses)
 }
 
 // Implementation of GET /voters/:id/polls/:pollId.
@@ -297,7 +371,7 @@ func (va *VoterAPI) GetVoterPoll(c *gin.Context) {
 	voterIDUint, err := strconv.ParseUint(voterID, 10, 32)
 	if err != nil {
 		log.Println("Error converting voter ID to uint: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
+		apierror.AbortInvalidID(c, "Voter ID", err)
 		return
 	}
 
@@ -305,37 +379,18 @@ func (va *VoterAPI) GetVoterPoll(c *gin.Context) {
 	pollIDUint, err := strconv.ParseUint(pollID, 10, 32)
 	if err != nil {
 		log.Println("Error converting poll ID to uint: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
+		apierror.AbortInvalidID(c, "Poll ID", err)
 		return
 	}
 
 	voterPoll, err := va.voterList.GetVoterPoll(uint(voterIDUint), uint(pollIDUint))
 	if err != nil {
 		log.Println("Error getting voter poll: ", err)
-		c.AbortWithStatus(http.StatusNotFound)
-		return
-	}
-
-	response := map[string]interface{}{
-		"pollId":   voterPoll.PollID,
-		"voteDate": voterPoll.VoteDate,
-		"links": map[string]interface{}{
-			"get": map[string]interface{}{
-				"method": "GET",
-				"url":    fmt.Sprintf("/voters/%s/polls/%d", strconv.Itoa(int(voterIDUint)), voterPoll.PollID),
-			},
-			"update": map[string]interface{}{
-				"method": "PUT",
-				"url":    fmt.Sprintf("/voters/%s/polls/%d", strconv.Itoa(int(voterIDUint)), voterPoll.PollID),
-			},
-			"delete": map[string]interface{}{
-				"method": "DELETE",
-				"url":    fmt.Sprintf("/voters/%s/polls/%d", strconv.Itoa(int(voterIDUint)), voterPoll.PollID),
-			},
-		},
-	}
-
-	c.JSON(http.StatusOK, response)
+		apierror.AbortWithError(c, http.StatusNotFound, "Could not get voter poll", err)
+		return
+	}
+
+	negotiate.Respond(c, http.StatusOK, newVoterPollResponse(uint(voterIDUint), voterPoll.PollID, voterPoll.VoteDate))
 }
 
 // Implementation of POST /voters/:id/polls/:pollId.
@@ -345,7 +400,7 @@ func (va *VoterAPI) AddVoterPoll(c *gin.Context) {
 	voterIDUint, err := strconv.ParseUint(voterID, 10, 32)
 	if err != nil {
 		log.Println("Error converting voter ID to uint: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
+		apierror.AbortInvalidID(c, "Voter ID", err)
 		return
 	}
 
@@ -353,29 +408,28 @@ func (va *VoterAPI) AddVoterPoll(c *gin.Context) {
 	pollIDUint, err := strconv.ParseUint(pollID, 10, 32)
 	if err != nil {
 		log.Println("Error converting poll ID to uint: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
+		apierror.AbortInvalidID(c, "Poll ID", err)
 		return
 	}
 
 	var requestBody struct {
-		VoteDate time.Time `json:"voteDate"`
+		VoteDate string `json:"voteDate" binding:"omitempty,votedate"`
 	}
 
-	if err := c.ShouldBindJSON(&requestBody); err != nil {
+	if err := validation.Bind(c, &requestBody); err != nil {
 		log.Println("Error parsing JSON request body: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
 		return
 	}
 
-	if _, ok := c.GetPostForm("voteDate"); !ok {
-		now := time.Now()
-		requestBody.VoteDate = now
+	voteDate := time.Now()
+	if requestBody.VoteDate != "" {
+		voteDate, _ = time.Parse(time.RFC3339, requestBody.VoteDate)
 	}
 
-	newVoterPoll, err := va.voterList.AddVoterPoll(uint(voterIDUint), uint(pollIDUint), requestBody.VoteDate)
+	newVoterPoll, err := va.voterList.AddVoterPoll(uint(voterIDUint), uint(pollIDUint), voteDate)
 	if err != nil {
 		log.Println("Error adding voter poll: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
+		apierror.AbortWithError(c, http.StatusBadRequest, "Could not add voter poll", err)
 		return
 	}
 
@@ -389,7 +443,7 @@ func (va *VoterAPI) UpdateVoterPoll(c *gin.Context) {
 	voterIDUint, err := strconv.ParseUint(voterID, 10, 32)
 	if err != nil {
 		log.Println("Error converting voter ID to uint: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
+		apierror.AbortInvalidID(c, "Voter ID", err)
 		return
 	}
 
@@ -397,29 +451,28 @@ func (va *VoterAPI) UpdateVoterPoll(c *gin.Context) {
 	pollIDUint, err := strconv.ParseUint(pollID, 10, 32)
 	if err != nil {
 		log.Println("Error converting poll ID to uint: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
+		apierror.AbortInvalidID(c, "Poll ID", err)
 		return
 	}
 
 	var requestBody struct {
-		VoteDate time.Time `json:"voteDate"`
+		VoteDate string `json:"voteDate" binding:"omitempty,votedate"`
 	}
 
-	if err := c.ShouldBindJSON(&requestBody); err != nil {
+	if err := validation.Bind(c, &requestBody); err != nil {
 		log.Println("Error parsing JSON request body: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
 		return
 	}
 
-	if _, ok := c.GetPostForm("voteDate"); !ok {
-		now := time.Now()
-		requestBody.VoteDate = now
+	voteDate := time.Now()
+	if requestBody.VoteDate != "" {
+		voteDate, _ = time.Parse(time.RFC3339, requestBody.VoteDate)
 	}
 
-	updatedVoterPoll, err := va.voterList.UpdateVoterPoll(uint(voterIDUint), uint(pollIDUint), requestBody.VoteDate)
+	updatedVoterPoll, err := va.voterList.UpdateVoterPoll(uint(voterIDUint), uint(pollIDUint), voteDate)
 	if err != nil {
 		log.Println("Error updating voter poll: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
+		apierror.AbortWithError(c, http.StatusBadRequest, "Could not update voter poll", err)
 		return
 	}
 
@@ -433,7 +486,7 @@ func (va *VoterAPI) DeleteVoterPoll(c *gin.Context) {
 	voterIDUint, err := strconv.ParseUint(voterID, 10, 32)
 	if err != nil {
 		log.Println("Error converting voter ID to uint: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
+		apierror.AbortInvalidID(c, "Voter ID", err)
 		return
 	}
 
@@ -441,13 +494,13 @@ func (va *VoterAPI) DeleteVoterPoll(c *gin.Context) {
 	pollIDUint, err := strconv.ParseUint(pollID, 10, 32)
 	if err != nil {
 		log.Println("Error converting poll ID to uint: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
+		apierror.AbortInvalidID(c, "Poll ID", err)
 		return
 	}
 
 	if err := va.voterList.DeleteVoterPoll(uint(voterIDUint), uint(pollIDUint)); err != nil {
 		log.Println("Error deleting voter poll: ", err)
-		c.AbortWithStatus(http.StatusNotFound)
+		apierror.AbortWithError(c, http.StatusNotFound, "Could not delete voter poll", err)
 		return
 	}
 
@@ -459,19 +512,78 @@ func (va *VoterAPI) DeleteVoterPoll(c *gin.Context) {
 // Implementation of GET voters/health.
 // Get the health status of the voter API.
 func (va *VoterAPI) HealthCheck(c *gin.Context) {
-	uptime := time.Since(va.bootTime).String()
-	averageRequestTime := time.Duration(0)
-	if va.totalCalls > 0 {
-		averageRequestTime = va.totalRequestTime / time.Duration(va.totalCalls)
+	report := va.metrics.Report()
+	report["schedulerLeader"] = va.scheduler.IsLeader()
+	report["jobs"] = va.scheduler.Stats()
+	report["storageCodec"] = va.voterCache.CodecStats()
+	report["redisShards"] = va.voterCache.ShardHealth()
+	report["redisFailover"] = failover.CurrentStats()
+
+	c.JSON(http.StatusOK, report)
+}
+
+// Implementation of GET /healthz.
+// Liveness probe, reports that the process is up and serving requests.
+func (va *VoterAPI) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+	})
+}
+
+// Implementation of GET /readyz.
+// Ping the store, treating a store that failed to initialize as down.
+func pingStore(store voter.Store) error {
+	if store == nil {
+		return errors.New("store is not initialized")
+	}
+
+	return store.Ping()
+}
+
+// Return the checks of the stores of the voter API, for the boot report.
+func (va *VoterAPI) DependencyChecks() map[string]func() error {
+	checks := map[string]func() error{
+		"redis": va.voterCache.Ping,
+	}
+	if voter.StorageBackend() == voter.StorageBackendPostgres {
+		checks["postgres"] = func() error { return pingStore(va.voterList) }
+	}
+
+	return checks
+}
+
+// Readiness probe, checks every dependency of the voter API and returns
+// 503 with per-dependency detail when any of them is down.
+func (va *VoterAPI) Readiness(c *gin.Context) {
+	dependencies := map[string]interface{}{}
+	ready := true
+
+	if err := va.voterCache.Ping(); err != nil {
+		dependencies["redis"] = gin.H{"status": "down", "error": err.Error()}
+		ready = false
+	} else {
+		dependencies["redis"] = gin.H{"status": "up"}
+	}
+
+	if voter.StorageBackend() == voter.StorageBackendPostgres {
+		if err := pingStore(va.voterList); err != nil {
+			dependencies["postgres"] = gin.H{"status": "down", "error": err.Error()}
+			ready = false
+		} else {
+			dependencies["postgres"] = gin.H{"status": "up"}
+		}
+	}
+
+	if !ready {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status":       "not ready",
+			"dependencies": dependencies,
+		})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"status":             "ok",
-		"uptime":             uptime,
-		"totalAPICalls":      va.totalCalls,
-		"totalAPICallsError": va.errorCalls,
-		"bootTime":           va.bootTime,
-		"totalRequestTime":   va.totalRequestTime.String(),
-		"averageRequestTime": averageRequestTime.String(),
+		"status":       "ready",
+		"dependencies": dependencies,
 	})
 }
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"time"
+
+	"voter-api/voter"
+
+	"shared/webhook"
+)
+
+// voterCreatedData is the data of a voter.created delivery. The contact
+// details of the voter are left out, as they are from the request logs.
+type voterCreatedData struct {
+	VoterID      uint       `json:"voterId"`
+	FirstName    string     `json:"firstName"`
+	LastName     string     `json:"lastName"`
+	Status       string     `json:"status"`
+	RegisteredAt *time.Time `json:"registeredAt,omitempty"`
+}
+
+// Return the registry of the webhooks, served on /webhooks.
+func (va *VoterAPI) Webhooks() *webhook.Registry {
+	return va.webhooks
+}
+
+// Deliver voter.created to the hooks subscribed to it.
+func (va *VoterAPI) voterCreated(v voter.Voter) {
+	va.webhooks.Dispatch(webhook.EventVoterCreated, voterCreatedData{
+		VoterID:      v.VoterID,
+		FirstName:    v.FirstName,
+		LastName:     v.LastName,
+		Status:       v.Status,
+		RegisteredAt: v.RegisteredAt,
+	})
+}
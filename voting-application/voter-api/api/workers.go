@@ -0,0 +1,19 @@
+package api
+
+import (
+	"context"
+
+	"shared/keyaudit"
+)
+
+// Start the background job scheduler of the voter API. Only the replica
+// holding the leader lease runs the registered jobs.
+func (va *VoterAPI) StartWorkers(ctx context.Context) {
+	va.scheduler.Start(ctx)
+
+	// Every replica migrates the legacy keys it finds; a key moved by
+	// another one is skipped.
+	if va.voterCache != nil {
+		go keyaudit.Migrate(va.voterCache.AuditKeys)
+	}
+}
@@ -1,55 +1,30 @@
 package main
 
 import (
-	"flag"
-	"fmt"
-
 	"voter-api/api"
+	"voter-api/voter"
 
-	"github.com/gin-contrib/cors"
-	"github.com/gin-gonic/gin"
-)
-
-var (
-	hostFlag string
-	portFlag uint
+	"shared/bootstrap"
+	"shared/endpoints"
 )
 
-func processCmdLineFlags() {
-	flag.StringVar(&hostFlag, "h", "0.0.0.0", "Listen on all interfaces")
-	flag.UintVar(&portFlag, "p", 1080, "Default Port")
-
-	flag.Parse()
-}
-
 func main() {
-	processCmdLineFlags()
-
-	r := gin.Default()
-	r.Use(cors.Default())
+	server := bootstrap.New("voter-api", bootstrap.Config{
+		Port:      1080,
+		GRPCPort:  2080,
+		Endpoints: []endpoints.Endpoint{endpoints.VotesAPI},
+	})
 
 	// Create a new instance of the VoterAPI handler.
-	voterHandler := api.NewVoterHandler()
-
-	// Register the HealthMiddleware, it will be called for every request.
-	r.Use(api.HealthMiddleware(voterHandler))
-
-	// Define the API endpoints and map them to the corresponding handler.
-	r.GET("/", voterHandler.WelcomeToVoterAPI)
-	r.GET("/voters", voterHandler.ListAllVoters)
-	r.GET("/voters/:id", voterHandler.GetVoter)
-	r.POST("/voters/:id", voterHandler.AddVoter)
-	r.PUT("/voters/:id", voterHandler.UpdateVoter)
-	r.DELETE("/voters", voterHandler.DeleteAllVoters)
-	r.DELETE("/voters/:id", voterHandler.DeleteVoter)
-	r.GET("/voters/:id/polls", voterHandler.GetVoterHistory)
-	r.GET("/voters/:id/polls/:pollId", voterHandler.GetVoterPoll)
-	r.POST("/voters/:id/polls/:pollId", voterHandler.AddVoterPoll)
-	r.PUT("/voters/:id/polls/:pollId", voterHandler.UpdateVoterPoll)
-	r.DELETE("/voters/:id/polls/:pollId", voterHandler.DeleteVoterPoll)
-	r.GET("/voters/health", voterHandler.HealthCheck)
-
-	// Start the server.
-	serverPath := fmt.Sprintf("%s:%d", hostFlag, portFlag)
-	r.Run(serverPath)
+	voterHandler := api.NewVoterHandler(server.URL(endpoints.VotesAPI))
+
+	server.Run(
+		bootstrap.WithMiddleware(api.HealthMiddleware(voterHandler), voterHandler.APIKeys().Middleware()),
+		bootstrap.WithRoutes(routeTable(voterHandler).Register),
+		bootstrap.WithWorkers(voterHandler.StartWorkers),
+		bootstrap.WithGRPC(voterHandler.RegisterGRPC),
+		bootstrap.WithSeeder(voterHandler.Seed),
+		bootstrap.WithStorageBackend(voter.StorageBackend()),
+		bootstrap.WithDependencyChecks(voterHandler.DependencyChecks()),
+	)
 }
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net/http"
+
+	"voter-api/api"
+
+	"shared/apierror"
+	"shared/routes"
+	"shared/seed"
+	"shared/snapshot"
+)
+
+var (
+	exportLimit  = routes.PerMinute(10)
+	searchLimit  = routes.PerMinute(120)
+	rebuildLimit = routes.PerMinute(2)
+	adminScope   = []string{routes.ScopeAdmin}
+)
+
+// Define the API endpoints and map them to the corresponding handler.
+func routeTable(voterHandler *api.VoterAPI) *routes.Table {
+	return &routes.Table{
+		Service: "voter-api",
+		Welcome: "Welcome to voter API.",
+		Scopes: []routes.Scope{
+			{
+				Name:        routes.ScopeAdmin,
+				Description: "Requires the X-Admin-Token header matching ADMIN_TOKEN",
+				Middleware:  api.AdminMiddleware(),
+				Header:      api.AdminTokenHeader,
+			},
+		},
+		Routes: []routes.Route{
+			{Method: http.MethodGet, Path: "/voters", Handler: voterHandler.ListAllVoters, Summary: "List every voter"},
+			{Method: http.MethodGet, Path: "/voters/export", Handler: voterHandler.ExportVoters, Summary: "Export every voter as CSV or JSON lines", Limit: exportLimit},
+			{Method: http.MethodGet, Path: "/voters/search", Handler: voterHandler.SearchVoters, Summary: "Search voters by first or last name", Limit: searchLimit},
+			{Method: http.MethodGet, Path: "/voters/duplicates", Handler: voterHandler.FindDuplicateVoters, Summary: "List the pairs of voters that are probably registered twice"},
+			{Method: http.MethodGet, Path: "/voters/:id", Handler: voterHandler.GetVoter, Summary: "Get a voter"},
+			{Method: http.MethodPost, Path: "/voters/:id", Handler: voterHandler.AddVoter, Summary: "Add a voter, ?onConflict=replace or merge when it exists"},
+			{Method: http.MethodPut, Path: "/voters/:id", Handler: voterHandler.UpdateVoter, Summary: "Change the name of a voter"},
+			{Method: http.MethodPost, Path: "/voters/:id/activate", Handler: voterHandler.ActivateVoter, Summary: "Activate a pending or suspended voter"},
+			{Method: http.MethodPost, Path: "/voters/:id/suspend", Handler: voterHandler.SuspendVoter, Summary: "Suspend a voter, whose votes are refused until activated"},
+			{Method: http.MethodDelete, Path: "/voters", Handler: voterHandler.DeleteAllVoters, Summary: "Delete every voter, or those selected by ?status= and ?registeredBefore="},
+			{Method: http.MethodPost, Path: "/voters/:id/merge/:otherId", Handler: voterHandler.MergeVoters, Summary: "Merge a duplicate voter and their vote history into a voter"},
+			{Method: http.MethodDelete, Path: "/voters/:id", Handler: voterHandler.DeleteVoter, Summary: "Delete a voter, ?cascade=true also deletes their votes"},
+			{Method: http.MethodPost, Path: "/voters/:id/history/rebuild", Handler: voterHandler.RebuildVoterHistory, Summary: "Rebuild the vote history of a voter from their votes, ?dryRun=true only reports"},
+			{Method: http.MethodGet, Path: "/voters/:id/polls", Handler: voterHandler.GetVoterHistory, Summary: "Get the vote history of a voter, by date range and page"},
+			{Method: http.MethodGet, Path: "/voters/:id/polls/:pollId", Handler: voterHandler.GetVoterPoll, Summary: "Get a poll of the vote history of a voter"},
+			{Method: http.MethodPost, Path: "/voters/:id/polls/:pollId", Handler: voterHandler.AddVoterPoll, Summary: "Add a poll to the vote history of a voter"},
+			{Method: http.MethodPut, Path: "/voters/:id/polls/:pollId", Handler: voterHandler.UpdateVoterPoll, Summary: "Change the vote date of a poll in the vote history of a voter"},
+			{Method: http.MethodDelete, Path: "/voters/:id/polls/:pollId", Handler: voterHandler.DeleteVoterPoll, Summary: "Remove a poll from the vote history of a voter"},
+			{Method: http.MethodGet, Path: "/voters/health", Handler: voterHandler.HealthCheck, Summary: "Request metrics of the API", Access: routes.Internal},
+			{Method: http.MethodGet, Path: "/healthz", Handler: voterHandler.Liveness, Summary: "Liveness probe", Access: routes.Internal},
+			{Method: http.MethodGet, Path: "/readyz", Handler: voterHandler.Readiness, Summary: "Readiness probe", Access: routes.Internal},
+
+			{Method: http.MethodGet, Path: "/admin/redis/shards", Handler: voterHandler.GetRedisShards, Summary: "List the Redis shards and their documents", Scopes: adminScope},
+			{Method: http.MethodPost, Path: "/admin/redis/rebalance", Handler: voterHandler.RebalanceRedisShards, Summary: "Move documents to the shard the hash ring assigns them", Scopes: adminScope, Limit: rebuildLimit},
+			{Method: http.MethodPost, Path: "/admin/redis/audit", Handler: voterHandler.AuditRedisKeys, Summary: "Audit the Redis keyspace and fix legacy, duplicated or broken keys", Scopes: adminScope, Limit: rebuildLimit},
+			{Method: http.MethodGet, Path: apierror.RecentErrorsPath, Handler: apierror.RecentErrors.ServeRecentErrors, Summary: "List the latest error responses of the service", Scopes: adminScope},
+			{Method: http.MethodPost, Path: snapshot.ImportPath, Handler: snapshot.Handler("voters", voterHandler.ImportSnapshot), Summary: "Restore the voters of a snapshot into an empty voter API", Scopes: adminScope, Limit: rebuildLimit},
+			{Method: http.MethodPost, Path: "/admin/seed", Handler: seed.Handler(voterHandler.Seed), Summary: "Add the voters of a JSON or YAML fixture, or of the -seed file", Scopes: adminScope, Limit: rebuildLimit},
+			{Method: http.MethodPost, Path: "/admin/voters/search/reindex", Handler: voterHandler.RebuildSearchIndex, Summary: "Rebuild the voter search index", Scopes: adminScope, Limit: rebuildLimit},
+			{Method: http.MethodPost, Path: "/webhooks", Handler: voterHandler.Webhooks().ServeAddHook, Summary: "Register a URL for voter.created, poll.closed or vote.cast events", Scopes: adminScope},
+			{Method: http.MethodGet, Path: "/webhooks", Handler: voterHandler.Webhooks().ServeListHooks, Summary: "List the registered webhooks", Scopes: adminScope},
+			{Method: http.MethodGet, Path: "/webhooks/:id", Handler: voterHandler.Webhooks().ServeGetHook, Summary: "Get a webhook", Scopes: adminScope},
+			{Method: http.MethodDelete, Path: "/webhooks/:id", Handler: voterHandler.Webhooks().ServeDeleteHook, Summary: "Delete a webhook and its failed deliveries", Scopes: adminScope},
+			{Method: http.MethodGet, Path: "/webhooks/:id/failures", Handler: voterHandler.Webhooks().ServeHookFailures, Summary: "List the deliveries to a webhook that failed after every retry", Scopes: adminScope},
+			{Method: http.MethodPost, Path: "/admin/apikeys", Handler: voterHandler.APIKeys().ServeCreateKey, Summary: "Create an API key with scopes such as read:polls or write:votes", Scopes: adminScope},
+			{Method: http.MethodGet, Path: "/admin/apikeys", Handler: voterHandler.APIKeys().ServeListKeys, Summary: "List the API keys, without the keys themselves", Scopes: adminScope},
+			{Method: http.MethodDelete, Path: "/admin/apikeys/:id", Handler: voterHandler.APIKeys().ServeRevokeKey, Summary: "Revoke an API key", Scopes: adminScope},
+		},
+	}
+}
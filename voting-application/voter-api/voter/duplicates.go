@@ -0,0 +1,206 @@
+package voter
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// The reasons two voters are reported as probable duplicates.
+const (
+	MatchedOnName  = "name"
+	MatchedOnEmail = "email"
+	MatchedOnPhone = "phone"
+)
+
+// Duplicate is a pair of voters that are probably the same person
+// registered twice.
+type Duplicate struct {
+	// The voter IDs of the pair, the lower first.
+	VoterIDs [2]uint `json:"voterIds"`
+	// What the voters share: their name, and their email or phone.
+	MatchedOn []string `json:"matchedOn"`
+	// The number of letters the normalized names differ by, 0 when they
+	// are the same.
+	NameDistance int `json:"nameDistance"`
+}
+
+// Return the pairs of voters that are probable duplicates, in voter ID
+// order. Two voters are when their normalized names are within a few
+// typos of each other and they share an email or a phone, or when their
+// normalized names are the same and their contact details do not tell them
+// apart.
+func FindDuplicates(voters []Voter) []Duplicate {
+	type candidate struct {
+		voter Voter
+		name  string
+		email string
+		phone string
+	}
+
+	candidates := make([]candidate, len(voters))
+	for i, v := range voters {
+		candidates[i] = candidate{
+			voter: v,
+			name:  normalizeName(v.FirstName + " " + v.LastName),
+			email: strings.ToLower(strings.TrimSpace(v.Email)),
+			phone: normalizePhone(v.Phone),
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].voter.VoterID < candidates[j].voter.VoterID })
+
+	duplicates := make([]Duplicate, 0)
+	for i, a := range candidates {
+		for _, b := range candidates[i+1:] {
+			distance, ok := nameDistance(a.name, b.name)
+			if !ok {
+				continue
+			}
+
+			matchedOn := []string{MatchedOnName}
+			if a.email != "" && a.email == b.email {
+				matchedOn = append(matchedOn, MatchedOnEmail)
+			}
+			if a.phone != "" && a.phone == b.phone {
+				matchedOn = append(matchedOn, MatchedOnPhone)
+			}
+
+			sameContact := len(matchedOn) > 1
+			contactDiffers := (a.email != "" && b.email != "" && a.email != b.email) ||
+				(a.phone != "" && b.phone != "" && a.phone != b.phone)
+			if !sameContact && (distance > 0 || contactDiffers) {
+				continue
+			}
+
+			duplicates = append(duplicates, Duplicate{
+				VoterIDs:     [2]uint{a.voter.VoterID, b.voter.VoterID},
+				MatchedOn:    matchedOn,
+				NameDistance: distance,
+			})
+		}
+	}
+
+	return duplicates
+}
+
+// Return a name as it is compared: lowercased, with only its letters and
+// single spaces between its words, so "  Mary-Ann O'Neil" and "mary ann
+// oneil" are the same.
+func normalizeName(name string) string {
+	var words []string
+	var word strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case unicode.IsLetter(r):
+			word.WriteRune(r)
+		case r == '\'':
+			// O'Neil is Oneil.
+		default:
+			if word.Len() > 0 {
+				words = append(words, word.String())
+				word.Reset()
+			}
+		}
+	}
+	if word.Len() > 0 {
+		words = append(words, word.String())
+	}
+
+	return strings.Join(words, " ")
+}
+
+// Return the digits of a phone number, so +1 (215) 555-0100 and
+// 1-215-555-0100 are the same.
+func normalizePhone(phone string) string {
+	var digits strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+
+	return digits.String()
+}
+
+// Return the edit distance of two normalized names, and whether it is small
+// enough for them to be the same name mistyped: one edit in eight letters.
+func nameDistance(a, b string) (int, bool) {
+	ra, rb := []rune(a), []rune(b)
+	allowed := len(ra) / 8
+	if len(rb) < len(ra) {
+		allowed = len(rb) / 8
+	}
+
+	diff := len(ra) - len(rb)
+	if diff < 0 {
+		diff = -diff
+	}
+	if diff > allowed {
+		return 0, false
+	}
+
+	// The Levenshtein distance, one row at a time.
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min3(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	distance := previous[len(rb)]
+	return distance, distance <= allowed
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+
+	return a
+}
+
+// Return the voter a merge of other into voter keeps: the names, status and
+// ID of voter, the contact details of other it lacks, the earliest
+// registration date, none when either has none, and both vote histories.
+// A poll in both histories is kept once, with its earliest vote date.
+func mergeVoters(voter, other Voter) Voter {
+	merged := voter
+	if merged.Email == "" {
+		merged.Email = other.Email
+	}
+	if merged.Phone == "" {
+		merged.Phone = other.Phone
+	}
+	if merged.RegisteredAt != nil && (other.RegisteredAt == nil || other.RegisteredAt.Before(*merged.RegisteredAt)) {
+		merged.RegisteredAt = other.RegisteredAt
+	}
+
+	merged.VoteHistory = make([]voterPoll, 0, len(voter.VoteHistory)+len(other.VoteHistory))
+	positions := make(map[uint]int, len(voter.VoteHistory)+len(other.VoteHistory))
+	for _, poll := range append(append([]voterPoll{}, voter.VoteHistory...), other.VoteHistory...) {
+		if i, ok := positions[poll.PollID]; ok {
+			if poll.VoteDate.Before(merged.VoteHistory[i].VoteDate) {
+				merged.VoteHistory[i].VoteDate = poll.VoteDate
+			}
+			continue
+		}
+
+		positions[poll.PollID] = len(merged.VoteHistory)
+		merged.VoteHistory = append(merged.VoteHistory, poll)
+	}
+
+	return merged
+}
@@ -0,0 +1,115 @@
+package voter
+
+import (
+	"sort"
+	"time"
+)
+
+// Filter selects voters by their status and registration date. The zero
+// Filter selects every voter.
+type Filter struct {
+	// Only the voters with the status, any when empty.
+	Status string
+	// Only the voters registered before the time. Voters without a
+	// registration date are never selected by it.
+	RegisteredBefore *time.Time
+	// Leave out the voters with a vote history.
+	WithoutVotes bool
+}
+
+// Report whether the filter selects a voter.
+func (f Filter) Matches(voter Voter) bool {
+	if f.Status != "" && voter.Status != f.Status {
+		return false
+	}
+
+	if f.RegisteredBefore != nil && (voter.RegisteredAt == nil || !voter.RegisteredAt.Before(*f.RegisteredBefore)) {
+		return false
+	}
+
+	return !f.WithoutVotes || len(voter.VoteHistory) == 0
+}
+
+// Delete the voters the filter selects and return them, in voter ID order.
+// With dryRun they are only returned. The voters are scanned first and
+// deleted after the scan, one by one, so a scan is never disturbed by the
+// deletes; a voter that changed in between and no longer matches is kept.
+func (vc *VoterCache) DeleteVoters(filter Filter, dryRun bool) ([]Voter, error) {
+	matched := make([]Voter, 0)
+	seen := make(map[uint]bool)
+	err := vc.voters.Each(func(voter Voter) error {
+		if filter.Matches(voter) && !seen[voter.VoterID] {
+			seen[voter.VoterID] = true
+			matched = append(matched, voter)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(matched, func(i, j int) bool { return matched[i].VoterID < matched[j].VoterID })
+	if dryRun {
+		return matched, nil
+	}
+
+	deleted := make([]Voter, 0, len(matched))
+	for _, candidate := range matched {
+		voter, err := vc.voters.Get(candidate.VoterID)
+		if err != nil || !filter.Matches(voter) {
+			continue
+		}
+
+		if _, err := vc.voters.Delete(voter.VoterID); err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, voter)
+	}
+
+	return deleted, nil
+}
+
+// HistoryFilter selects the polls of a vote history by their vote date,
+// and cuts a page of them. The zero HistoryFilter selects the whole
+// history.
+type HistoryFilter struct {
+	// Only the polls voted at or after From and at or before To.
+	From *time.Time
+	To   *time.Time
+	// Skip the first Offset polls the dates select, and return at most
+	// Limit of the rest; every one when Limit is 0.
+	Offset int
+	Limit  int
+}
+
+// Report whether the filter selects a poll by its vote date.
+func (f HistoryFilter) Matches(poll voterPoll) bool {
+	if f.From != nil && poll.VoteDate.Before(*f.From) {
+		return false
+	}
+
+	return f.To == nil || !poll.VoteDate.After(*f.To)
+}
+
+// Return the page of the polls of a history the filter selects, in history
+// order, and the number of polls the dates select.
+func (f HistoryFilter) Apply(history []voterPoll) ([]voterPoll, int) {
+	selected := make([]voterPoll, 0, len(history))
+	for _, poll := range history {
+		if f.Matches(poll) {
+			selected = append(selected, poll)
+		}
+	}
+
+	total := len(selected)
+	start := f.Offset
+	if start > total {
+		start = total
+	}
+	end := total
+	if f.Limit > 0 && start+f.Limit < end {
+		end = start + f.Limit
+	}
+
+	return selected[start:end], total
+}
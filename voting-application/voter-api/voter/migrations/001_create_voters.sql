@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS voters (
+    voter_id BIGINT PRIMARY KEY,
+    first_name TEXT NOT NULL,
+    last_name TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS voter_polls (
+    voter_id BIGINT NOT NULL REFERENCES voters (voter_id) ON DELETE CASCADE,
+    poll_id BIGINT NOT NULL,
+    vote_date TIMESTAMPTZ NOT NULL,
+    position BIGSERIAL,
+    PRIMARY KEY (voter_id, poll_id)
+);
@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS voters_first_name_lower ON voters (lower(first_name) text_pattern_ops);
+
+CREATE INDEX IF NOT EXISTS voters_last_name_lower ON voters (lower(last_name) text_pattern_ops);
@@ -0,0 +1,2 @@
+-- The registration status of a voter. Voters added before it are active.
+ALTER TABLE voters ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'active';
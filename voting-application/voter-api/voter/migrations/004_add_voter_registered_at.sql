@@ -0,0 +1,2 @@
+-- When a voter was added, NULL for the voters added before it was recorded.
+ALTER TABLE voters ADD COLUMN IF NOT EXISTS registered_at TIMESTAMPTZ;
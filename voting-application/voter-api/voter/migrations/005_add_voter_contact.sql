@@ -0,0 +1,3 @@
+-- The optional contact details of a voter, empty when they are not known.
+ALTER TABLE voters ADD COLUMN IF NOT EXISTS email TEXT NOT NULL DEFAULT '';
+ALTER TABLE voters ADD COLUMN IF NOT EXISTS phone TEXT NOT NULL DEFAULT '';
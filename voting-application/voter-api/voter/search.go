@@ -0,0 +1,157 @@
+package voter
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	SearchIndexKey     = "voter-search"
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+	searchSeparator    = "\x00"
+)
+
+// The search index is a sorted set on the primary redis node whose members
+// all have score 0, so they are ordered by their bytes. Every suffix of the
+// lowercased first and last name of a voter is a member, followed by the
+// voter ID: a query matches the names that contain it as the members that
+// start with it, which one ZRANGEBYLEX finds without reading any voter.
+
+// Return the index members of a voter.
+func searchMembers(voter Voter) []interface{} {
+	id := strconv.FormatUint(uint64(voter.VoterID), 10)
+	seen := make(map[string]bool)
+
+	var members []interface{}
+	for _, name := range []string{voter.FirstName, voter.LastName} {
+		name = strings.ToLower(name)
+		for i := range name {
+			member := name[i:] + searchSeparator + id
+			if !seen[member] {
+				seen[member] = true
+				members = append(members, member)
+			}
+		}
+	}
+
+	return members
+}
+
+// Add a voter to the search index.
+func (vc *VoterCache) indexVoter(voter Voter) error {
+	members := searchMembers(voter)
+	if len(members) == 0 {
+		return nil
+	}
+
+	zs := make([]*redis.Z, len(members))
+	for i, member := range members {
+		zs[i] = &redis.Z{Member: member}
+	}
+
+	return vc.cacheClient.ZAdd(vc.context, SearchIndexKey, zs...).Err()
+}
+
+// Remove a voter from the search index.
+func (vc *VoterCache) unindexVoter(voter Voter) error {
+	members := searchMembers(voter)
+	if len(members) == 0 {
+		return nil
+	}
+
+	return vc.cacheClient.ZRem(vc.context, SearchIndexKey, members...).Err()
+}
+
+// Delete the search index.
+func (vc *VoterCache) deleteSearchIndex() error {
+	return vc.cacheClient.Del(vc.context, SearchIndexKey).Err()
+}
+
+// Return the voters whose first or last name contains query, ignoring
+// case. Names that start with query come first, then the voters are
+// ordered by last name, first name and ID; at most limit are returned.
+func (vc *VoterCache) SearchVoters(query string, limit int) ([]Voter, error) {
+	query = strings.ToLower(query)
+
+	members, err := vc.cacheClient.ZRangeByLex(vc.context, SearchIndexKey, &redis.ZRangeBy{
+		Min: "[" + query,
+		Max: "[" + query + "\xff",
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[uint]bool)
+	voters := make([]Voter, 0)
+	for _, member := range members {
+		i := strings.LastIndex(member, searchSeparator)
+		id, err := strconv.ParseUint(member[i+1:], 10, 32)
+		if err != nil || seen[uint(id)] {
+			continue
+		}
+		seen[uint(id)] = true
+
+		voter, err := vc.GetVoter(uint(id))
+		if err != nil {
+			// The voter was deleted since it was indexed.
+			continue
+		}
+
+		voters = append(voters, voter)
+	}
+
+	return rankSearchResults(voters, query, limit), nil
+}
+
+// Order search results and keep the first limit of them.
+func rankSearchResults(voters []Voter, query string, limit int) []Voter {
+	isPrefix := func(voter Voter) bool {
+		return strings.HasPrefix(strings.ToLower(voter.FirstName), query) ||
+			strings.HasPrefix(strings.ToLower(voter.LastName), query)
+	}
+
+	sort.SliceStable(voters, func(i, j int) bool {
+		a, b := voters[i], voters[j]
+		if isPrefix(a) != isPrefix(b) {
+			return isPrefix(a)
+		}
+		if lastA, lastB := strings.ToLower(a.LastName), strings.ToLower(b.LastName); lastA != lastB {
+			return lastA < lastB
+		}
+		if firstA, firstB := strings.ToLower(a.FirstName), strings.ToLower(b.FirstName); firstA != firstB {
+			return firstA < firstB
+		}
+		return a.VoterID < b.VoterID
+	})
+
+	if len(voters) > limit {
+		voters = voters[:limit]
+	}
+
+	return voters
+}
+
+// Rebuild the search index from the stored voters, for voters that were
+// stored before the index existed. It returns the number of voters indexed.
+func (vc *VoterCache) RebuildSearchIndex() (int, error) {
+	if err := vc.deleteSearchIndex(); err != nil {
+		return 0, err
+	}
+
+	indexed := 0
+	err := vc.EachVoter(func(voter Voter) error {
+		if err := vc.indexVoter(voter); err != nil {
+			return fmt.Errorf("indexing voter %d: %w", voter.VoterID, err)
+		}
+
+		indexed++
+		return nil
+	})
+
+	return indexed, err
+}
@@ -0,0 +1,56 @@
+package voter
+
+import (
+	"os"
+	"time"
+)
+
+const (
+	StorageBackendRedis    = "redis"
+	StorageBackendPostgres = "postgres"
+)
+
+// Store is the storage the voter handlers work against. Both the redis
+// VoterCache and the postgres VoterPostgres implement it.
+type Store interface {
+	GetAllVoters() ([]Voter, error)
+	EachVoter(fn func(Voter) error) error
+	SearchVoters(query string, limit int) ([]Voter, error)
+	GetVoter(voterID uint) (Voter, error)
+	AddVoter(voter Voter) error
+	CreateVoter(request Voter, onConflict string) (Voter, string, error)
+	UpdateVoter(voter Voter) (Voter, error)
+	ActivateVoter(voterID uint) (Voter, error)
+	SuspendVoter(voterID uint) (Voter, error)
+	DeleteAllVoters() error
+	DeleteVoters(filter Filter, dryRun bool) ([]Voter, error)
+	DeleteVoter(voterID uint) error
+	MergeVoters(voterID, otherID uint) (Voter, error)
+	GetVoterHistory(voterID uint) ([]voterPoll, error)
+	GetVoterHistoryFiltered(voterID uint, filter HistoryFilter) ([]voterPoll, int, error)
+	GetVoterPoll(voterID, pollID uint) (voterPoll, error)
+	AddVoterPoll(voterID, pollID uint, voteDate time.Time) (voterPoll, error)
+	UpdateVoterPoll(voterID, pollID uint, voteDate time.Time) (voterPoll, error)
+	DeleteVoterPoll(voterID, pollID uint) error
+	Ping() error
+}
+
+// Return the configured storage backend, redis unless STORAGE_BACKEND is set.
+func StorageBackend() string {
+	backend := os.Getenv("STORAGE_BACKEND")
+	if backend == "" {
+		return StorageBackendRedis
+	}
+
+	return backend
+}
+
+// Return the Store selected by STORAGE_BACKEND. The redis backend reuses
+// the provided VoterCache.
+func NewVoterStore(voterCache *VoterCache) (Store, error) {
+	if StorageBackend() == StorageBackendPostgres {
+		return NewVoterPostgres()
+	}
+
+	return voterCache, nil
+}
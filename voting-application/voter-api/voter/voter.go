@@ -2,16 +2,20 @@ package voter
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
-	"fmt"
 	"log"
 	"os"
 	"time"
 
+	"shared/codec"
+	"shared/conflict"
+	"shared/failover"
+	"shared/keyaudit"
+	"shared/keyring"
+	"shared/repository"
+
 	"github.com/go-redis/redis/v8"
 	"github.com/go-resty/resty/v2"
-	"github.com/nitishm/go-rejson/v4"
 )
 
 const (
@@ -20,18 +24,35 @@ const (
 	RedisKeyPrefix       = "voter:"
 )
 
+// The registration status of a voter. Only active voters can vote.
+const (
+	VoterStatusPending   = "pending"
+	VoterStatusActive    = "active"
+	VoterStatusSuspended = "suspended"
+)
+
 // voterPoll represents the voting information for a specific poll.
 type voterPoll struct {
-	PollID   uint      `json:"pollId"`
-	VoteDate time.Time `json:"voteDate"`
+	PollID   uint      `json:"pollId" binding:"required"`
+	VoteDate time.Time `json:"voteDate" binding:"omitempty,votedate"`
 }
 
 // Voter represents a voter with a unique ID and voting history.
 type Voter struct {
 	VoterID     uint        `json:"voterId"`
-	FirstName   string      `json:"firstName"`
-	LastName    string      `json:"lastName"`
-	VoteHistory []voterPoll `json:"voteHistory"`
+	FirstName   string      `json:"firstName" binding:"required,min=2,max=64"`
+	LastName    string      `json:"lastName" binding:"required,min=2,max=64"`
+	VoteHistory []voterPoll `json:"voteHistory" binding:"dive"`
+	// Set when the voter is added, active unless the voter is added as
+	// pending, then changed by activating and suspending the voter.
+	Status string `json:"status" binding:"omitempty,oneof=pending active suspended"`
+	// When the voter was added. Voters added before it was recorded have
+	// none.
+	RegisteredAt *time.Time `json:"registeredAt,omitempty"`
+	// The contact details of the voter, optional. With the names they tell
+	// duplicate registrations apart.
+	Email string `json:"email,omitempty" binding:"omitempty,email,max=254"`
+	Phone string `json:"phone,omitempty" binding:"omitempty,min=7,max=32"`
 }
 
 // VoterList is a collection of voters.
@@ -40,8 +61,10 @@ type VoterList struct {
 }
 
 type cache struct {
-	cacheClient *redis.Client
-	jsonHelper  *rejson.Handler
+	cacheClient redis.UniversalClient
+	ring        *keyring.Ring
+	documents   *codec.Store
+	voters      *repository.Repository[Voter]
 	context     context.Context
 }
 
@@ -66,179 +89,355 @@ func NewVoterCache() (*VoterCache, error) {
 // The constructor function that returns a pointer to a new VoterCache.
 func newVoterCacheInstance(url string) (*VoterCache, error) {
 	apiClient := resty.New()
-	client := redis.NewClient(&redis.Options{
-		Addr: url,
-	})
+	client, err := failover.Connect(url)
+	if err != nil {
+		log.Println("Error configuring redis: ", err)
+		return nil, err
+	}
 
 	ctx := context.Background()
 
-	err := client.Ping(ctx).Err()
+	err = client.Ping(ctx).Err()
 	if err != nil {
 		log.Println("Error connecting to redis" + err.Error())
 		return nil, err
 	}
 
-	jsonHelper := rejson.NewReJSONHandler()
-	jsonHelper.SetGoRedisClientWithContext(ctx, client)
+	ring, err := keyring.FromEnv(ctx, client)
+	if err != nil {
+		log.Println("Error connecting to redis shards: ", err)
+		return nil, err
+	}
+	ring.StartHealthChecks(ctx, keyring.DefaultHealthInterval)
 
-	return &VoterCache{
+	vc := &VoterCache{
 		cache: cache{
 			cacheClient: client,
-			jsonHelper:  jsonHelper,
+			ring:        ring,
+			documents:   codec.NewStore(codec.FromEnv()),
 			context:     ctx,
 		},
 		apiClient: apiClient,
-	}, nil
+	}
+
+	vc.voters = repository.New(ctx, ring, vc.documents, repository.Config[Voter]{
+		Kind:   "voter",
+		Prefix: RedisKeyPrefix,
+		ID:     func(voter Voter) uint { return voter.VoterID },
+		Hooks: repository.Hooks[Voter]{
+			Loaded:  defaultVoterStatus,
+			Index:   vc.indexVoter,
+			Unindex: vc.unindexVoter,
+			Cleared: vc.deleteSearchIndex,
+		},
+	})
+
+	return vc, nil
 }
 
-// Get a string that can be used as a key in redis.
-func redisKeyFromId(id uint) string {
-	return fmt.Sprintf("%s%d", RedisKeyPrefix, id)
+// Check that the redis connection of the VoterCache is alive.
+func (vc *VoterCache) Ping() error {
+	if vc == nil {
+		return errors.New("redis is not connected")
+	}
+
+	if err := vc.cacheClient.Ping(vc.context).Err(); err != nil {
+		return err
+	}
+
+	return vc.ring.Ping(vc.context)
 }
 
-// Create a new Voter instance with the provided details.
-func NewVoter(id uint, firstName string, lastName string) Voter {
-	voter := Voter{
-		VoterID:     id,
-		FirstName:   firstName,
-		LastName:    lastName,
-		VoteHistory: make([]voterPoll, 0),
+// Return the health of the redis shards of the VoterCache, or nil when it
+// is not connected.
+func (vc *VoterCache) ShardHealth() []keyring.ShardHealth {
+	if vc == nil {
+		return nil
 	}
 
-	return voter
+	return vc.ring.Health()
+}
+
+// Move the voters that are on the wrong redis shard to the shard they
+// belong to.
+func (vc *VoterCache) RebalanceShards(dryRun bool) (keyring.RebalanceReport, error) {
+	return vc.voters.Rebalance(dryRun)
 }
 
-// Helper to return a Voter from redis provided a key.
-func (vc *VoterCache) getItemFromRedis(key string, item *Voter) error {
-	itemObject, err := vc.jsonHelper.JSONGet(key, ".")
+// Audit the keys of the voters and the keyspace of redis, fixing them as fix
+// says, one of the keyaudit fixes.
+func (vc *VoterCache) AuditKeys(fix string) (keyaudit.Report, error) {
+	report := keyaudit.Report{Fix: fix}
+
+	documents, err := vc.voters.Audit(fix)
+	report.Documents = documents
 	if err != nil {
-		return err
+		return report, err
 	}
 
-	err = json.Unmarshal(itemObject.([]byte), item)
-	if err != nil {
-		return err
+	report.Unknown, err = keyaudit.Unknown(vc.context, vc.cacheClient, vc.ring, keyaudit.KnownPrefixes())
+
+	return report, err
+}
+
+// Return the codec metrics of the VoterCache, or nil when it is not connected.
+func (vc *VoterCache) CodecStats() *codec.Stats {
+	if vc == nil {
+		return nil
 	}
 
-	return nil
+	stats := vc.documents.Stats()
+	return &stats
 }
 
-// Return a slice of all voters from the VoterCache.
-func (vc *VoterCache) GetAllVoters() ([]Voter, error) {
-	var voters []Voter
+// Return the redis client of the VoterCache, or nil when it is not connected.
+func (vc *VoterCache) RedisClient() redis.UniversalClient {
+	if vc == nil {
+		return nil
+	}
 
-	pattern := fmt.Sprintf("%s*", RedisKeyPrefix)
-	keys, err := vc.cacheClient.Keys(vc.context, pattern).Result()
+	return vc.cacheClient
+}
 
-	if err != nil {
-		return voters, err
+// Create a new Voter instance with the provided details.
+func NewVoter(id uint, firstName string, lastName string) Voter {
+	now := time.Now().UTC()
+	voter := Voter{
+		VoterID:      id,
+		FirstName:    firstName,
+		LastName:     lastName,
+		VoteHistory:  make([]voterPoll, 0),
+		Status:       VoterStatusActive,
+		RegisteredAt: &now,
 	}
 
-	for _, key := range keys {
-		var voter Voter
+	return voter
+}
 
-		err := vc.getItemFromRedis(key, &voter)
-		if err != nil {
-			return voters, err
-		}
+// Return the voter a create request adds: a new voter with the names of
+// the request, and its status when it has one.
+func createdVoter(request Voter) Voter {
+	voter := NewVoter(request.VoterID, request.FirstName, request.LastName)
+	if request.Status != "" {
+		voter.Status = request.Status
+	}
+	voter.Email = request.Email
+	voter.Phone = request.Phone
 
-		voters = append(voters, voter)
+	return voter
+}
+
+// Return the stored voter after a create request found it with the
+// policy. Both policies take the names of the request; replace also takes
+// its status, active when it has none, and its contact details, and merge
+// only the status and contact details it sets. The vote history and
+// registration date, which a create cannot set, are kept.
+func resolveVoterConflict(stored, request Voter, policy string) Voter {
+	resolved := stored
+	resolved.FirstName = request.FirstName
+	resolved.LastName = request.LastName
+
+	switch {
+	case request.Status != "":
+		resolved.Status = request.Status
+	case policy == conflict.Replace:
+		resolved.Status = VoterStatusActive
 	}
 
-	return voters, nil
+	if request.Email != "" || policy == conflict.Replace {
+		resolved.Email = request.Email
+	}
+	if request.Phone != "" || policy == conflict.Replace {
+		resolved.Phone = request.Phone
+	}
+
+	return resolved
 }
 
-// Retrieve a single voter from the VoterCache by voterID.
-func (vc *VoterCache) GetVoter(voterID uint) (Voter, error) {
-	var voter Voter
+// Return the contact details of an update: those of the request, or the
+// stored ones the request leaves out.
+func updatedContact(stored, request Voter) (string, string) {
+	email, phone := stored.Email, stored.Phone
+	if request.Email != "" {
+		email = request.Email
+	}
+	if request.Phone != "" {
+		phone = request.Phone
+	}
 
-	redisKey := redisKeyFromId(voterID)
-	if err := vc.getItemFromRedis(redisKey, &voter); err != nil {
-		return Voter{}, errors.New("voter does not exist")
+	return email, phone
+}
+
+// Voters stored before the registration status was introduced are active.
+func defaultVoterStatus(voter *Voter) {
+	if voter.Status == "" {
+		voter.Status = VoterStatusActive
 	}
+}
+
+// Return a slice of all voters from the VoterCache.
+func (vc *VoterCache) GetAllVoters() ([]Voter, error) {
+	return vc.voters.All()
+}
 
-	return voter, nil
+// Call fn with every voter of the VoterCache. The voters are never all
+// held in memory; a voter changed during the scan may be seen twice.
+func (vc *VoterCache) EachVoter(fn func(Voter) error) error {
+	return vc.voters.Each(fn)
+}
+
+// Retrieve a single voter from the VoterCache by voterID.
+func (vc *VoterCache) GetVoter(voterID uint) (Voter, error) {
+	return vc.voters.Get(voterID)
 }
 
 // Add a new voter to the VoterCache.
 func (vc *VoterCache) AddVoter(voter Voter) error {
-	if _, err := vc.GetVoter(voter.VoterID); err == nil {
-		return errors.New("voter already exists")
+	return vc.voters.Add(voter)
+}
+
+// Create the voter of a create request. When a voter has its ID the
+// request fails, replaces or merges into the stored voter as onConflict
+// says. It returns the stored voter and the outcome of the create.
+func (vc *VoterCache) CreateVoter(request Voter, onConflict string) (Voter, string, error) {
+	voter := createdVoter(request)
+
+	err := vc.AddVoter(voter)
+	if err == nil || onConflict == conflict.Fail || !errors.Is(err, repository.ErrExists) {
+		return voter, conflict.Created, err
+	}
+
+	var previousVoter Voter
+
+	resolvedVoter, err := vc.voters.Update(voter.VoterID, func(existingVoter *Voter) error {
+		previousVoter = *existingVoter
+		*existingVoter = resolveVoterConflict(*existingVoter, request, onConflict)
+		return nil
+	})
+	if err != nil {
+		return Voter{}, "", err
+	}
+
+	if err := vc.unindexVoter(previousVoter); err != nil {
+		return Voter{}, "", err
 	}
 
-	redisKey := redisKeyFromId(voter.VoterID)
-	if _, setErr := vc.jsonHelper.JSONSet(redisKey, ".", voter); setErr != nil {
-		return setErr
+	if err := vc.indexVoter(resolvedVoter); err != nil {
+		return Voter{}, "", err
 	}
 
-	return nil
+	return resolvedVoter, conflict.Outcome(onConflict), nil
 }
 
 // Update an existing voter in the VoterCache.
 func (vc *VoterCache) UpdateVoter(voter Voter) (Voter, error) {
-	existingVoter, err := vc.GetVoter(voter.VoterID)
-
+	var previousVoter Voter
+
+	updatedVoter, err := vc.voters.Update(voter.VoterID, func(existingVoter *Voter) error {
+		previousVoter = *existingVoter
+		existingVoter.FirstName = voter.FirstName
+		existingVoter.LastName = voter.LastName
+		existingVoter.Email, existingVoter.Phone = updatedContact(*existingVoter, voter)
+		return nil
+	})
 	if err != nil {
-		return Voter{}, errors.New("voter does not exist")
+		return Voter{}, err
 	}
 
-	existingVoter.FirstName = voter.FirstName
-	existingVoter.LastName = voter.LastName
+	if err := vc.unindexVoter(previousVoter); err != nil {
+		return Voter{}, err
+	}
 
-	redisKey := redisKeyFromId(voter.VoterID)
-	if _, setErr := vc.jsonHelper.JSONSet(redisKey, ".", existingVoter); setErr != nil {
-		return Voter{}, setErr
+	if err := vc.indexVoter(updatedVoter); err != nil {
+		return Voter{}, err
 	}
 
-	return existingVoter, nil
+	return updatedVoter, nil
 }
 
-// Delete all voters from the VoterCache.
-func (vc *VoterCache) DeleteAllVoters() error {
-	pattern := fmt.Sprintf("%s*", RedisKeyPrefix)
-	keys, err := vc.cacheClient.Keys(vc.context, pattern).Result()
-	if err != nil {
-		return err
-	}
+// Activate a pending or suspended voter so that they can vote.
+func (vc *VoterCache) ActivateVoter(voterID uint) (Voter, error) {
+	return vc.setVoterStatus(voterID, VoterStatusActive)
+}
 
-	for _, key := range keys {
-		if _, deleteErr := vc.cacheClient.Del(vc.context, key).Result(); deleteErr != nil {
-			return deleteErr
+// Suspend a pending or active voter so that they can no longer vote.
+func (vc *VoterCache) SuspendVoter(voterID uint) (Voter, error) {
+	return vc.setVoterStatus(voterID, VoterStatusSuspended)
+}
+
+// Change the status of a voter, failing when the voter already has it.
+func (vc *VoterCache) setVoterStatus(voterID uint, status string) (Voter, error) {
+	return vc.voters.Update(voterID, func(voter *Voter) error {
+		if voter.Status == status {
+			return errors.New("voter is already " + status)
 		}
-	}
 
-	return nil
+		voter.Status = status
+		return nil
+	})
+}
+
+// Delete all voters from the VoterCache.
+func (vc *VoterCache) DeleteAllVoters() error {
+	return vc.voters.DeleteAll()
 }
 
 // Delete a single voter from the VoterCache by voterID.
 func (vc *VoterCache) DeleteVoter(voterID uint) error {
-	if _, err := vc.GetVoter(voterID); err != nil {
-		return errors.New("voter does not exist")
+	_, err := vc.voters.Delete(voterID)
+	return err
+}
+
+// Merge the voter otherID into the voter voterID, see mergeVoters, and
+// delete it. It returns the merged voter.
+func (vc *VoterCache) MergeVoters(voterID, otherID uint) (Voter, error) {
+	other, err := vc.GetVoter(otherID)
+	if err != nil {
+		return Voter{}, err
+	}
+
+	merged, err := vc.voters.Update(voterID, func(voter *Voter) error {
+		*voter = mergeVoters(*voter, other)
+		return nil
+	})
+	if err != nil {
+		return Voter{}, err
 	}
 
-	redisKey := redisKeyFromId(voterID)
-	if _, deleteErr := vc.jsonHelper.JSONDel(redisKey, "."); deleteErr != nil {
-		return deleteErr
+	if err := vc.DeleteVoter(otherID); err != nil {
+		return Voter{}, err
 	}
 
-	return nil
+	return merged, nil
 }
 
 // Retrieve the vote history of a voter by voterID.
 func (vc *VoterCache) GetVoterHistory(voterID uint) ([]voterPoll, error) {
 	voter, err := vc.GetVoter(voterID)
 	if err != nil {
-		return nil, errors.New("voter does not exist")
+		return nil, err
 	}
 
 	return voter.VoteHistory, nil
 }
 
+// Retrieve the polls of the vote history of a voter that the filter
+// selects, and the number of polls its dates select.
+func (vc *VoterCache) GetVoterHistoryFiltered(voterID uint, filter HistoryFilter) ([]voterPoll, int, error) {
+	voter, err := vc.GetVoter(voterID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	history, total := filter.Apply(voter.VoteHistory)
+	return history, total, nil
+}
+
 // Retrieve a specific voter poll by voterID and pollID.
 func (vc *VoterCache) GetVoterPoll(voterID, pollID uint) (voterPoll, error) {
 	voter, err := vc.GetVoter(voterID)
 	if err != nil {
-		return voterPoll{}, errors.New("voter does not exist")
+		return voterPoll{}, err
 	}
 
 	for _, poll := range voter.VoteHistory {
@@ -252,27 +451,23 @@ func (vc *VoterCache) GetVoterPoll(voterID, pollID uint) (voterPoll, error) {
 
 // Add a new voter poll to the vote history of a voter.
 func (vc *VoterCache) AddVoterPoll(voterID, pollID uint, voteDate time.Time) (voterPoll, error) {
-	voter, err := vc.GetVoter(voterID)
-	if err != nil {
-		return voterPoll{}, errors.New("voter does not exist")
-	}
-
-	for _, poll := range voter.VoteHistory {
-		if poll.PollID == pollID {
-			return voterPoll{}, errors.New("voter has already voted in this poll")
-		}
-	}
-
 	newVoterPoll := voterPoll{
 		PollID:   pollID,
 		VoteDate: voteDate,
 	}
 
-	voter.VoteHistory = append(voter.VoteHistory, newVoterPoll)
+	_, err := vc.voters.Update(voterID, func(voter *Voter) error {
+		for _, poll := range voter.VoteHistory {
+			if poll.PollID == pollID {
+				return errors.New("voter has already voted in this poll")
+			}
+		}
 
-	redisKey := redisKeyFromId(voter.VoterID)
-	if _, setErr := vc.jsonHelper.JSONSet(redisKey, ".", voter); setErr != nil {
-		return voterPoll{}, setErr
+		voter.VoteHistory = append(voter.VoteHistory, newVoterPoll)
+		return nil
+	})
+	if err != nil {
+		return voterPoll{}, err
 	}
 
 	return newVoterPoll, nil
@@ -280,30 +475,23 @@ func (vc *VoterCache) AddVoterPoll(voterID, pollID uint, voteDate time.Time) (vo
 
 // Update an existing voter poll in the vote history of a voter.
 func (vc *VoterCache) UpdateVoterPoll(voterID, pollID uint, voteDate time.Time) (voterPoll, error) {
-	voter, err := vc.GetVoter(voterID)
-	if err != nil {
-		return voterPoll{}, errors.New("voter does not exist")
+	updatedVoterPoll := voterPoll{
+		PollID:   pollID,
+		VoteDate: voteDate,
 	}
 
-	var updatedVoterPoll voterPoll
-	for i, poll := range voter.VoteHistory {
-		if poll.PollID == pollID {
-			updatedVoterPoll = voterPoll{
-				PollID:   pollID,
-				VoteDate: voteDate,
+	_, err := vc.voters.Update(voterID, func(voter *Voter) error {
+		for i, poll := range voter.VoteHistory {
+			if poll.PollID == pollID {
+				voter.VoteHistory[i] = updatedVoterPoll
+				return nil
 			}
-			voter.VoteHistory[i] = updatedVoterPoll
-			break
 		}
-	}
-
-	if updatedVoterPoll.PollID == 0 {
-		return voterPoll{}, errors.New("voter poll not found")
-	}
 
-	redisKey := redisKeyFromId(voter.VoterID)
-	if _, setErr := vc.jsonHelper.JSONSet(redisKey, ".", voter); setErr != nil {
-		return voterPoll{}, setErr
+		return errors.New("voter poll not found")
+	})
+	if err != nil {
+		return voterPoll{}, err
 	}
 
 	return updatedVoterPoll, nil
@@ -311,27 +499,21 @@ func (vc *VoterCache) UpdateVoterPoll(voterID, pollID uint, voteDate time.Time)
 
 // Remove a specific voter poll from the vote history of a voter.
 func (vc *VoterCache) DeleteVoterPoll(voterID, pollID uint) error {
-	voter, err := vc.GetVoter(voterID)
-	if err != nil {
-		return errors.New("voter does not exist")
-	}
-
-	var updatedVoteHistory []voterPoll
-	for _, poll := range voter.VoteHistory {
-		if poll.PollID != pollID {
-			updatedVoteHistory = append(updatedVoteHistory, poll)
+	_, err := vc.voters.Update(voterID, func(voter *Voter) error {
+		updatedVoteHistory := make([]voterPoll, 0, len(voter.VoteHistory))
+		for _, poll := range voter.VoteHistory {
+			if poll.PollID != pollID {
+				updatedVoteHistory = append(updatedVoteHistory, poll)
+			}
 		}
-	}
 
-	if len(updatedVoteHistory) == len(voter.VoteHistory) {
-		return errors.New("voter poll not found")
-	}
+		if len(updatedVoteHistory) == len(voter.VoteHistory) {
+			return errors.New("voter poll not found")
+		}
 
-	voter.VoteHistory = updatedVoteHistory
-	redisKey := redisKeyFromId(voter.VoterID)
-	if _, setErr := vc.jsonHelper.JSONSet(redisKey, ".", voter); setErr != nil {
-		return setErr
-	}
+		voter.VoteHistory = updatedVoteHistory
+		return nil
+	})
 
-	return nil
+	return err
 }
@@ -12,11 +12,17 @@ type Voter struct {
 	FirstName   string
 	LastName    string
 	VoteHistory []VoterPoll
+	// Only active voters can vote. Voters read over gRPC have no status.
+	Status string
+	// When the voter was added. Voters added before it was recorded, and
+	// voters read over gRPC, have none.
+	RegisteredAt *time.Time
 }
 
 type PollOption struct {
 	PollOptionID   uint
 	PollOptionText string
+	MaxVotes       uint
 }
 
 type Poll struct {
@@ -24,4 +30,30 @@ type Poll struct {
 	PollTitle    string
 	PollQuestion string
 	PollOptions  []PollOption
+	PollStatus   string
+	Anonymous    bool
+	CertifiedAt  *time.Time
+	// Up to how many options a vote selects, single-choice when 0 or 1.
+	MaxSelections uint
+	// Whether votes can give a free text in place of an option.
+	AllowWriteIn bool
+	// Who can vote in the poll, anyone when nil.
+	Eligibility *PollEligibility
+	Tags        []string
+	// When the poll was created and closed, and when its schedule opens
+	// and closes it. Polls read over gRPC have no schedule.
+	CreatedAt time.Time
+	ClosedAt  *time.Time
+	StartTime *time.Time
+	EndTime   *time.Time
+}
+
+type VoterIDRange struct {
+	From uint
+	To   uint
+}
+
+type PollEligibility struct {
+	VoterIDRanges []VoterIDRange
+	Allowlist     bool
 }
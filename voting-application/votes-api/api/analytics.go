@@ -0,0 +1,67 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"shared/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Implementation of GET /votes/analytics/overlap?pollA=&pollB=.
+// Reports how many voters participated in both polls and their Jaccard
+// overlap.
+func (va *VotesAPI) GetPollOverlap(c *gin.Context) {
+	pollAUint, err := strconv.ParseUint(c.Query("pollA"), 10, 32)
+	if err != nil {
+		log.Println("Error converting poll A ID to uint: ", err)
+		apierror.AbortInvalidID(c, "Poll A ID", err)
+		return
+	}
+
+	pollBUint, err := strconv.ParseUint(c.Query("pollB"), 10, 32)
+	if err != nil {
+		log.Println("Error converting poll B ID to uint: ", err)
+		apierror.AbortInvalidID(c, "Poll B ID", err)
+		return
+	}
+
+	overlap, err := va.votesList.GetPollOverlap(uint(pollAUint), uint(pollBUint))
+	if err != nil {
+		log.Println("Error computing poll overlap: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not compute poll overlap", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, overlap)
+}
+
+// Implementation of POST /admin/participation/rebuild.
+// Rebuild the poll participation sets from the stored votes.
+func (va *VotesAPI) RebuildParticipation(c *gin.Context) {
+	if err := va.votesList.RebuildParticipation(); err != nil {
+		log.Println("Error rebuilding participation: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not rebuild participation", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Participation rebuilt successfully.",
+	})
+}
+
+// Implementation of POST /admin/tally/rebuild.
+// Rebuild the live option vote counts from the stored votes.
+func (va *VotesAPI) RebuildTallies(c *gin.Context) {
+	if err := va.votesList.RebuildTallies(); err != nil {
+		log.Println("Error rebuilding tallies: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not rebuild tallies", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tallies rebuilt successfully.",
+	})
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"votes-api/votes"
+)
+
+// Return the hash an anonymous vote keeps instead of its voter. It is
+// keyed by a secret of the API and the poll, so it cannot be traced back to
+// the voter, and a voter has a different hash in every poll.
+func (va *VotesAPI) voterHash(pollID, voterID uint) string {
+	mac := hmac.New(sha256.New, va.voterHashKey)
+	mac.Write([]byte(fmt.Sprintf("%d:%d", pollID, voterID)))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Strip the voter from a vote of an anonymous poll. Its times are rounded
+// down to the hour, and the voter history is given the rounded vote date,
+// so the vote cannot be told apart from the others cast in that hour.
+func (va *VotesAPI) anonymize(vote votes.Vote) votes.Vote {
+	vote.VoterHash = va.voterHash(vote.PollID, vote.VoterID)
+	vote.VoterID = 0
+
+	return roundVoteTimes(vote)
+}
+
+// Strip the ballot token from a vote cast with one. The vote keeps a hash
+// of the token as its voterHash, which no voter can be found by.
+func (va *VotesAPI) anonymizeBallot(vote votes.Vote, token string) votes.Vote {
+	mac := hmac.New(sha256.New, va.voterHashKey)
+	mac.Write([]byte(fmt.Sprintf("%d:token:%s", vote.PollID, token)))
+
+	vote.VoterHash = hex.EncodeToString(mac.Sum(nil))
+	vote.VoterID = 0
+
+	return roundVoteTimes(vote)
+}
+
+// Round the times of an anonymous vote, and its vote date, down to the
+// hour.
+func roundVoteTimes(vote votes.Vote) votes.Vote {
+	if vote.CreatedAt != nil {
+		castAt := vote.CreatedAt.Truncate(time.Hour)
+		vote.CreatedAt = &castAt
+		vote.UpdatedAt = &castAt
+	}
+	if vote.VoteDate != nil {
+		voteDate := vote.VoteDate.Truncate(time.Hour)
+		vote.VoteDate = &voteDate
+	}
+
+	return vote
+}
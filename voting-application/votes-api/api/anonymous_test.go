@@ -0,0 +1,101 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	schema "votes-api/Schema"
+	"votes-api/votes"
+)
+
+// voterHistory is a voterClient with a single voter that records the vote
+// dates added to their history.
+type voterHistory struct {
+	voterID   uint
+	voteDates []time.Time
+}
+
+func (vh *voterHistory) listVoters() ([]schema.Voter, error) {
+	return []schema.Voter{{VoterID: vh.voterID}}, nil
+}
+
+func (vh *voterHistory) addVoter(voter schema.Voter) error { return nil }
+
+func (vh *voterHistory) addVoterPoll(voterID, pollID uint, voteDate time.Time) error {
+	vh.voteDates = append(vh.voteDates, voteDate)
+	return nil
+}
+
+func (vh *voterHistory) deleteVoterPoll(voterID, pollID uint) error { return nil }
+
+// onePoll is a pollClient with a single poll.
+type onePoll struct {
+	poll schema.Poll
+}
+
+func (op onePoll) listPolls() ([]schema.Poll, error)           { return []schema.Poll{op.poll}, nil }
+func (op onePoll) getPoll(pollID uint) (schema.Poll, error)    { return op.poll, nil }
+func (op onePoll) addPoll(poll schema.Poll) error              { return nil }
+func (op onePoll) addPollOption(uint, schema.PollOption) error { return nil }
+func (op onePoll) isEligibleVoter(uint, uint) (bool, error)    { return true, nil }
+
+// addedVotes is a votes.Store that records the votes added to it.
+type addedVotes struct {
+	votes.Store
+	added []votes.Vote
+}
+
+func (av *addedVotes) AddVote(vote votes.Vote, maxVotes map[uint]uint) error {
+	av.added = append(av.added, vote)
+	return nil
+}
+
+func TestAnonymousVoteDateCannotBeMatchedWithTheHistory(t *testing.T) {
+	history := &voterHistory{voterID: 1}
+	store := &addedVotes{}
+	va := &VotesAPI{
+		votesList:        store,
+		voters:           history,
+		polls:            onePoll{poll: schema.Poll{PollID: 2, Anonymous: true, PollOptions: []schema.PollOption{{PollOptionID: 1}}}},
+		pollMetadata:     newPollMetadataCache(),
+		voterHashKey:     []byte("secret"),
+		voteEventsStream: "off",
+	}
+
+	// A vote date the client gives is rounded like the one set by the
+	// server.
+	cast := time.Now().UTC().Add(-time.Minute)
+	for _, voteDate := range []*time.Time{nil, &cast} {
+		history.voteDates = nil
+		store.added = nil
+
+		vote, err := va.castVote(votes.Vote{VoteID: 3, VoterID: 1, PollID: 2, VoteValue: 1, VoteDate: voteDate}, "")
+		if err != nil {
+			t.Fatalf("castVote: %v", err)
+		}
+		if len(store.added) != 1 || len(history.voteDates) != 1 {
+			t.Fatalf("castVote added %d votes and %d history entries, want 1 of each", len(store.added), len(history.voteDates))
+		}
+
+		stored := store.added[0]
+		if stored.VoterID != 0 || stored.VoterHash == "" {
+			t.Fatalf("the anonymous vote was stored with voter %d and hash %q", stored.VoterID, stored.VoterHash)
+		}
+
+		historyDate := history.voteDates[0]
+		for name, at := range map[string]time.Time{
+			"history date": historyDate,
+			"vote date":    *stored.VoteDate,
+			"created at":   *stored.CreatedAt,
+			"updated at":   *stored.UpdatedAt,
+			"cast at":      *vote.CastAt(),
+		} {
+			if !at.Equal(at.Truncate(time.Hour)) {
+				t.Fatalf("the %s %v of an anonymous vote is not rounded to the hour", name, at)
+			}
+		}
+		if voteDate != nil && !historyDate.Equal(voteDate.Truncate(time.Hour)) {
+			t.Fatalf("the history date is %v, want the vote date %v rounded down", historyDate, *voteDate)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"shared/apierror"
+	"shared/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Implementation of POST /admin/ballot-tokens.
+// Issue ballot tokens for an anonymous poll. Every token casts one vote in
+// the poll without naming a voter, and is only returned by this request.
+func (va *VotesAPI) AddBallotTokens(c *gin.Context) {
+	var requestBody struct {
+		PollID uint `json:"pollId" binding:"required"`
+		Count  int  `json:"count" binding:"required,min=1,max=1000"`
+	}
+
+	if err := validation.Bind(c, &requestBody); err != nil {
+		log.Println("Error parsing JSON request body: ", err)
+		return
+	}
+
+	poll, err := va.getPoll(requestBody.PollID)
+	if err != nil {
+		log.Println("Error getting poll: ", err)
+		apierror.AbortWithDetails(c, http.StatusNotFound, apierror.CodeNotFound, "Could not find poll in cache", err)
+		return
+	}
+
+	if !poll.Anonymous {
+		apierror.Abort(c, http.StatusUnprocessableEntity, apierror.CodeUnprocessable, "Ballot tokens are only issued for anonymous polls")
+		return
+	}
+
+	tokens, err := va.votesCache.IssueBallotTokens(poll.PollID, requestBody.Count)
+	if err != nil {
+		log.Println("Error issuing ballot tokens: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not issue ballot tokens", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"pollId": poll.PollID,
+		"tokens": tokens,
+	})
+}
+
+// Implementation of GET /admin/ballot-tokens/:pollId.
+// Returns how many ballot tokens of a poll are still unused.
+func (va *VotesAPI) CountBallotTokens(c *gin.Context) {
+	pollIDUint, err := strconv.ParseUint(c.Param("pollId"), 10, 32)
+	if err != nil {
+		log.Println("Error converting poll ID to uint: ", err)
+		apierror.AbortInvalidID(c, "Poll ID", err)
+		return
+	}
+
+	unused, err := va.votesCache.CountBallotTokens(uint(pollIDUint))
+	if err != nil {
+		log.Println("Error counting ballot tokens: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not count ballot tokens", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pollId": pollIDUint,
+		"unused": unused,
+	})
+}
@@ -0,0 +1,249 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"votes-api/votes"
+
+	"shared/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pollCascade is what deleting the votes of a poll removes, or would
+// remove in a dry run.
+type pollCascade struct {
+	PollID uint `json:"pollId"`
+	DryRun bool `json:"dryRun"`
+	// The votes of the poll.
+	VoteIDs []uint `json:"voteIds"`
+	// The voters whose vote history has the poll without a matching vote.
+	OrphanedHistory []uint `json:"orphanedHistory"`
+}
+
+// Implementation of DELETE /votes/by-poll/:pollId?dryRun=true.
+// Delete every vote of a poll, removing the poll from the vote history of
+// its voters, and strip the poll from the vote histories that have it
+// without a vote. The poll API calls it when a poll is deleted, so the
+// result snapshot of the poll is deleted first. With dryRun=true nothing
+// is removed and the response reports what would be.
+func (va *VotesAPI) DeletePollVotes(c *gin.Context) {
+	pollID := c.Param("pollId")
+	pollIDUint, err := strconv.ParseUint(pollID, 10, 32)
+	if err != nil {
+		log.Println("Error converting poll ID to uint: ", err)
+		apierror.AbortInvalidID(c, "Poll ID", err)
+		return
+	}
+
+	cascade := pollCascade{
+		PollID:          uint(pollIDUint),
+		DryRun:          c.Query("dryRun") == "true",
+		VoteIDs:         make([]uint, 0),
+		OrphanedHistory: make([]uint, 0),
+	}
+
+	votedVoters := make(map[uint]bool)
+	err = va.votesList.EachVote(func(vote votes.Vote) error {
+		if vote.PollID == cascade.PollID {
+			cascade.VoteIDs = append(cascade.VoteIDs, vote.VoteID)
+			votedVoters[vote.VoterID] = true
+		}
+		return nil
+	})
+	if err != nil {
+		log.Println("Error getting votes: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not get votes", err)
+		return
+	}
+
+	voters, err := va.voters.listVoters()
+	if err != nil {
+		log.Println("Error getting voters: ", err)
+		if voteErr := unreachableError(err); voteErr != nil {
+			abortWithVoteError(c, voteErr)
+			return
+		}
+		apierror.AbortWithError(c, http.StatusBadGateway, "Could not get the vote history of the voters", err)
+		return
+	}
+
+	for _, voter := range voters {
+		if votedVoters[voter.VoterID] {
+			continue
+		}
+
+		for _, entry := range voter.VoteHistory {
+			if entry.PollID == cascade.PollID {
+				cascade.OrphanedHistory = append(cascade.OrphanedHistory, voter.VoterID)
+				break
+			}
+		}
+	}
+
+	if cascade.DryRun {
+		c.JSON(http.StatusOK, cascade)
+		return
+	}
+
+	// The poll is deleted, its frozen results go with it.
+	if err := va.votesCache.DeleteResultSnapshot(cascade.PollID); err != nil {
+		log.Println("Error deleting result snapshot: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not delete the result snapshot of the poll", err)
+		return
+	}
+
+	for _, voteID := range cascade.VoteIDs {
+		if err := va.removeVote(voteID); err != nil {
+			log.Println("Error deleting vote of poll: ", err)
+			abortWithVoteError(c, err)
+			return
+		}
+	}
+
+	for _, voterID := range cascade.OrphanedHistory {
+		if err := va.voters.deleteVoterPoll(voterID, cascade.PollID); err != nil {
+			log.Println("Error removing poll from voter's vote history: ", err)
+			if voteErr := unreachableError(err); voteErr != nil {
+				abortWithVoteError(c, voteErr)
+				return
+			}
+			apierror.AbortWithError(c, http.StatusBadGateway, "Could not remove the poll from a vote history", err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, cascade)
+}
+
+// voterCascade is what deleting the votes of a voter removes, or would
+// remove in a dry run.
+type voterCascade struct {
+	VoterID uint   `json:"voterId"`
+	DryRun  bool   `json:"dryRun"`
+	VoteIDs []uint `json:"voteIds"`
+	PollIDs []uint `json:"pollIds"`
+}
+
+// Implementation of DELETE /votes/by-voter/:voterId?dryRun=true.
+// Delete every vote of a voter, as DELETE /votes/:id would, refusing with
+// 409 when one is in a poll whose results are frozen. The voter API calls
+// it before it deletes a voter, with dryRun=true to find out whether the
+// voter has votes.
+func (va *VotesAPI) DeleteVoterVotes(c *gin.Context) {
+	voterID := c.Param("voterId")
+	voterIDUint, err := strconv.ParseUint(voterID, 10, 32)
+	if err != nil {
+		log.Println("Error converting voter ID to uint: ", err)
+		apierror.AbortInvalidID(c, "Voter ID", err)
+		return
+	}
+
+	cascade := voterCascade{
+		VoterID: uint(voterIDUint),
+		DryRun:  c.Query("dryRun") == "true",
+		VoteIDs: make([]uint, 0),
+		PollIDs: make([]uint, 0),
+	}
+
+	err = va.votesList.EachVote(func(vote votes.Vote) error {
+		if vote.VoterID == cascade.VoterID {
+			cascade.VoteIDs = append(cascade.VoteIDs, vote.VoteID)
+			cascade.PollIDs = append(cascade.PollIDs, vote.PollID)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Println("Error getting votes: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not get votes", err)
+		return
+	}
+
+	if cascade.DryRun {
+		c.JSON(http.StatusOK, cascade)
+		return
+	}
+
+	// No vote is deleted when one of them is in a poll whose results are
+	// frozen.
+	for _, pollID := range cascade.PollIDs {
+		if err := va.checkPollNotFrozen(pollID); err != nil {
+			abortWithVoteError(c, err)
+			return
+		}
+	}
+
+	for _, voteID := range cascade.VoteIDs {
+		if err := va.removeVote(voteID); err != nil {
+			log.Println("Error deleting vote of voter: ", err)
+			abortWithVoteError(c, err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, cascade)
+}
+
+// Implementation of DELETE /votes.
+// Delete every vote, with the result snapshots of their polls, the holds
+// of the prepared votes and the vote stats counters, for cleaning up test
+// environments; admins only. Unlike DELETE /votes/:id it deletes the votes
+// of frozen polls too, and leaves the vote histories of the voter API
+// alone: POST /admin/reset of the gateway API clears both.
+func (va *VotesAPI) DeleteAllVotes(c *gin.Context) {
+	// The snapshots, holds and counters are in redis, so without it they
+	// would outlive the votes.
+	if va.votesCache == nil {
+		apierror.Abort(c, http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, "Redis is not connected")
+		return
+	}
+
+	allVotes := make([]votes.Vote, 0)
+	err := va.votesList.EachVote(func(vote votes.Vote) error {
+		allVotes = append(allVotes, vote)
+		return nil
+	})
+	if err != nil {
+		log.Println("Error getting votes: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not get votes", err)
+		return
+	}
+
+	pollIDs := make(map[uint]bool)
+	for _, vote := range allVotes {
+		if err := va.votesList.DeleteVote(vote.VoteID); err != nil {
+			log.Println("Error deleting vote: ", err)
+			apierror.AbortWithError(c, http.StatusInternalServerError, "Could not delete votes", err)
+			return
+		}
+		va.publishVoteDeleted(vote)
+		pollIDs[vote.PollID] = true
+	}
+
+	for pollID := range pollIDs {
+		if err := va.votesCache.DeleteResultSnapshot(pollID); err != nil {
+			log.Println("Error deleting result snapshot: ", err)
+			apierror.AbortWithError(c, http.StatusInternalServerError, "Could not delete the result snapshots of the polls", err)
+			return
+		}
+	}
+
+	if err := va.votesCache.DeleteVoteHolds(); err != nil {
+		log.Println("Error deleting vote holds: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not delete the holds of the prepared votes", err)
+		return
+	}
+
+	if err := va.votesCache.DeleteVoteStats(); err != nil {
+		log.Println("Error deleting vote stats: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not delete the vote stats", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "All votes deleted successfully.",
+		"deleted": len(allVotes),
+	})
+}
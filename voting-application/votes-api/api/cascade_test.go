@@ -0,0 +1,25 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Without redis the result snapshots, holds and stats of the votes cannot
+// be deleted, so the votes are kept.
+func TestDeleteAllVotesWithoutRedis(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.DELETE("/votes", (&VotesAPI{}).DeleteAllVotes)
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(http.MethodDelete, "/votes", nil))
+
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("DELETE /votes without redis = %d, want %d", recorder.Code, http.StatusServiceUnavailable)
+	}
+}
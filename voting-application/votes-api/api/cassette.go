@@ -0,0 +1,179 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+)
+
+const (
+	DownstreamModeLive   = "live"
+	DownstreamModeRecord = "record"
+	DownstreamModeReplay = "replay"
+
+	DefaultCassettePath = "downstream-cassette.json"
+)
+
+// recordedResponse is a downstream response saved in a cassette.
+type recordedResponse struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"contentType,omitempty"`
+	Body        string `json:"body"`
+}
+
+// cassetteTransport records the voter API and poll API responses to a
+// cassette file, or replays them from it without calling the services.
+// Responses are keyed by method and URL; repeated calls are recorded in
+// order and replayed in the same order, the last one repeating once the
+// recording is exhausted.
+type cassetteTransport struct {
+	mode      string
+	path      string
+	next      http.RoundTripper
+	mu        sync.Mutex
+	responses map[string][]recordedResponse
+	replayed  map[string]int
+}
+
+// Load the downstream mode from the environment. DOWNSTREAM_MODE is live,
+// record or replay and DOWNSTREAM_CASSETTE is the cassette file. Recorded
+// calls go through next. It returns nil in live mode.
+func loadCassetteTransport(next http.RoundTripper) *cassetteTransport {
+	mode := os.Getenv("DOWNSTREAM_MODE")
+	if mode != DownstreamModeRecord && mode != DownstreamModeReplay {
+		return nil
+	}
+
+	path := os.Getenv("DOWNSTREAM_CASSETTE")
+	if path == "" {
+		path = DefaultCassettePath
+	}
+
+	transport := &cassetteTransport{
+		mode:      mode,
+		path:      path,
+		next:      next,
+		responses: make(map[string][]recordedResponse),
+		replayed:  make(map[string]int),
+	}
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		if err := json.Unmarshal(data, &transport.responses); err != nil {
+			log.Println("Error reading downstream cassette: ", err)
+		}
+	} else if mode == DownstreamModeReplay {
+		log.Println("Error opening downstream cassette: ", err)
+	}
+
+	// A new recording session starts from an empty cassette.
+	if mode == DownstreamModeRecord {
+		transport.responses = make(map[string][]recordedResponse)
+	}
+
+	log.Printf("Downstream calls are in %s mode using %s", mode, path)
+
+	return transport
+}
+
+func cassetteKey(req *http.Request) string {
+	return req.Method + " " + req.URL.String()
+}
+
+func (ct *cassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if ct.mode == DownstreamModeReplay {
+		return ct.replay(req), nil
+	}
+
+	return ct.record(req)
+}
+
+// Pass the request to the service and append its response to the cassette.
+func (ct *cassetteTransport) record(req *http.Request) (*http.Response, error) {
+	resp, err := ct.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+
+	key := cassetteKey(req)
+	ct.responses[key] = append(ct.responses[key], recordedResponse{
+		Status:      resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        string(body),
+	})
+
+	if err := ct.save(); err != nil {
+		log.Println("Error writing downstream cassette: ", err)
+	}
+
+	return resp, nil
+}
+
+// Write the cassette to disk, the caller holds mu.
+func (ct *cassetteTransport) save() error {
+	data, err := json.MarshalIndent(ct.responses, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(ct.path, data, 0644)
+}
+
+// Answer the request from the cassette. Requests that were never recorded
+// get a 502 so the handlers treat the service as unavailable.
+func (ct *cassetteTransport) replay(req *http.Request) *http.Response {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+
+	key := cassetteKey(req)
+	recorded := ct.responses[key]
+
+	response := recordedResponse{
+		Status:      http.StatusBadGateway,
+		ContentType: "application/json",
+		Body:        fmt.Sprintf(`{"error":"no recorded response for %s"}`, key),
+	}
+
+	if len(recorded) > 0 {
+		i := ct.replayed[key]
+		if i >= len(recorded) {
+			i = len(recorded) - 1
+		}
+		response = recorded[i]
+		ct.replayed[key] = i + 1
+	} else {
+		log.Println("No recorded downstream response for ", key)
+	}
+
+	header := make(http.Header)
+	if response.ContentType != "" {
+		header.Set("Content-Type", response.ContentType)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", response.Status, http.StatusText(response.Status)),
+		StatusCode:    response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader([]byte(response.Body))),
+		ContentLength: int64(len(response.Body)),
+		Request:       req,
+	}
+}
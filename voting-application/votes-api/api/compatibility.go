@@ -0,0 +1,85 @@
+package api
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"shared/endpoints"
+	"shared/version"
+)
+
+const (
+	// Stop when a required API speaks an incompatible version.
+	CompatibilityStrict = "strict"
+	// Keep serving but fail the readiness probe.
+	CompatibilityDegraded = "degraded"
+	CompatibilityOff      = "off"
+
+	// How often the APIs that could not be checked at boot are tried again.
+	CompatibilityRecheckInterval = 10 * time.Second
+)
+
+// Return how an incompatible API is handled, COMPATIBILITY_MODE or strict.
+func compatibilityMode() string {
+	switch mode := os.Getenv("COMPATIBILITY_MODE"); mode {
+	case CompatibilityDegraded, CompatibilityOff:
+		return mode
+	case "", CompatibilityStrict:
+	default:
+		log.Printf("Warning: unknown COMPATIBILITY_MODE %q, using %s", mode, CompatibilityStrict)
+	}
+
+	return CompatibilityStrict
+}
+
+// Return the API versions the votes API relies on.
+func compatibilityRequirements(voterAPIURL, pollAPIURL string) []version.Requirement {
+	return []version.Requirement{
+		{Endpoint: endpoints.VoterAPI, URL: voterAPIURL, Major: 1, Minor: 0, Reason: "voters and their vote history"},
+		{Endpoint: endpoints.PollAPI, URL: pollAPIURL, Major: 1, Minor: 1, Reason: "anonymous polls"},
+	}
+}
+
+// Check the API versions of the voter and poll APIs at boot and log them.
+// In strict mode an incompatible API stops the votes API; an API that
+// cannot be reached yet is checked again in the background.
+func (va *VotesAPI) CheckCompatibility(ctx context.Context) {
+	if va.compatMode == CompatibilityOff {
+		return
+	}
+
+	va.reportCompatibility(va.compatibility.Check(ctx), true)
+}
+
+// Log the outcome of a compatibility check. The APIs still unknown are
+// only logged at boot.
+func (va *VotesAPI) reportCompatibility(peers []version.Peer, logUnknown bool) {
+	for _, peer := range peers {
+		switch peer.Status {
+		case version.StatusCompatible:
+			log.Printf("Using %s version %s, build %s", peer.Name, peer.APIVersion, peer.Build)
+		case version.StatusUnknown:
+			if logUnknown {
+				log.Printf("Warning: could not check the API version of %s, trying again in the background: %s", peer.Name, peer.Error)
+			}
+		case version.StatusIncompatible:
+			if va.compatMode == CompatibilityStrict {
+				log.Fatalf("Error: %s, stopping since COMPATIBILITY_MODE is %s", peer.Error, CompatibilityStrict)
+			}
+			log.Printf("Warning: %s, the votes API is degraded", peer.Error)
+		}
+	}
+}
+
+// Check the APIs that could not be checked at boot until they answer.
+func (va *VotesAPI) watchCompatibility(ctx context.Context) {
+	if va.compatMode == CompatibilityOff {
+		return
+	}
+
+	va.compatibility.Watch(ctx, CompatibilityRecheckInterval, func(peers []version.Peer) {
+		va.reportCompatibility(peers, false)
+	})
+}
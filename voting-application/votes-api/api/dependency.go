@@ -0,0 +1,30 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-resty/resty/v2"
+)
+
+const (
+	DependencyCheckTimeout = 2 * time.Second
+)
+
+// Probe a downstream service and describe its status.
+func checkDependency(apiClient *resty.Client, url string) gin.H {
+	ctx, cancel := context.WithTimeout(context.Background(), DependencyCheckTimeout)
+	defer cancel()
+
+	resp, err := apiClient.R().SetContext(ctx).Get(url)
+	if err != nil {
+		return gin.H{"status": "down", "error": err.Error()}
+	}
+
+	if resp.IsError() {
+		return gin.H{"status": "down", "error": "unexpected status " + resp.Status()}
+	}
+
+	return gin.H{"status": "up"}
+}
@@ -0,0 +1,466 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	schema "votes-api/Schema"
+
+	"shared/pollclient"
+	"shared/restclient"
+	"shared/rpc"
+	"shared/voterclient"
+	"shared/votingpb"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// voterClient is how the votes API reaches the voter API.
+type voterClient interface {
+	listVoters() ([]schema.Voter, error)
+	addVoter(voter schema.Voter) error
+	addVoterPoll(voterID, pollID uint, voteDate time.Time) error
+	deleteVoterPoll(voterID, pollID uint) error
+}
+
+// pollClient is how the votes API reaches the poll API. Errors that mean
+// the poll API could not answer wrap errPollAPIUnavailable.
+type pollClient interface {
+	listPolls() ([]schema.Poll, error)
+	getPoll(pollID uint) (schema.Poll, error)
+	addPoll(poll schema.Poll) error
+	addPollOption(pollID uint, option schema.PollOption) error
+	isEligibleVoter(pollID, voterID uint) (bool, error)
+}
+
+// Return the voter and poll clients. They use REST through the voterclient
+// and pollclient packages, sending their requests over transport, or gRPC
+// when VOTER_API_GRPC_ADDR or POLL_API_GRPC_ADDR name the gRPC address of
+// the API.
+func loadDownstreamClients(transport http.RoundTripper, voterAPIURL, pollAPIURL string) (voterClient, pollClient) {
+	voterREST, err := voterclient.New(restclient.Config{BaseURL: voterAPIURL, Transport: transport})
+	if err != nil {
+		log.Fatalln("Error creating voter API client: ", err)
+	}
+
+	pollREST, err := pollclient.New(restclient.Config{BaseURL: pollAPIURL, Transport: transport})
+	if err != nil {
+		log.Fatalln("Error creating poll API client: ", err)
+	}
+
+	var voters voterClient = &restVoterClient{client: voterREST}
+	var polls pollClient = &restPollClient{client: pollREST}
+
+	if address := os.Getenv("VOTER_API_GRPC_ADDR"); address != "" {
+		conn, err := rpc.Dial(address)
+		if err != nil {
+			log.Println("Error connecting to voter API over gRPC, using REST: ", err)
+		} else {
+			log.Printf("Calling voter API over gRPC at %s", address)
+			voters = &grpcVoterClient{client: votingpb.NewVoterServiceClient(conn)}
+		}
+	}
+
+	if address := os.Getenv("POLL_API_GRPC_ADDR"); address != "" {
+		conn, err := rpc.Dial(address)
+		if err != nil {
+			log.Println("Error connecting to poll API over gRPC, using REST: ", err)
+		} else {
+			log.Printf("Calling poll API over gRPC at %s", address)
+			polls = &grpcPollClient{client: votingpb.NewPollServiceClient(conn)}
+		}
+	}
+
+	return voters, polls
+}
+
+// Report whether a REST error is an answer of the other API rejecting the
+// call, as opposed to the API not answering.
+func isRejected(err error) bool {
+	var apiErr *restclient.Error
+	return errors.As(err, &apiErr) && apiErr.Status < http.StatusInternalServerError
+}
+
+// restVoterClient calls the REST endpoints of the voter API. It only fails
+// when the voter API could not be reached.
+type restVoterClient struct {
+	client *voterclient.Client
+}
+
+func (rc *restVoterClient) listVoters() ([]schema.Voter, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), restclient.DefaultTimeout)
+	defer cancel()
+
+	response, err := rc.client.ListVoters(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	voters := make([]schema.Voter, len(response))
+	for i, v := range response {
+		history := make([]schema.VoterPoll, len(v.VoteHistory))
+		for j, voterPoll := range v.VoteHistory {
+			history[j] = schema.VoterPoll{PollID: voterPoll.PollID, VoteDate: voterPoll.VoteDate}
+		}
+
+		voters[i] = schema.Voter{
+			VoterID:      v.VoterID,
+			FirstName:    v.FirstName,
+			LastName:     v.LastName,
+			VoteHistory:  history,
+			Status:       v.Status,
+			RegisteredAt: v.RegisteredAt,
+		}
+	}
+
+	return voters, nil
+}
+
+func (rc *restVoterClient) addVoter(voter schema.Voter) error {
+	ctx, cancel := context.WithTimeout(context.Background(), restclient.DefaultTimeout)
+	defer cancel()
+
+	_, err := rc.client.AddVoter(ctx, voterclient.Voter{
+		VoterID:   voter.VoterID,
+		FirstName: voter.FirstName,
+		LastName:  voter.LastName,
+	})
+
+	return err
+}
+
+func (rc *restVoterClient) addVoterPoll(voterID, pollID uint, voteDate time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), restclient.DefaultTimeout)
+	defer cancel()
+
+	_, err := rc.client.AddVoterPoll(ctx, voterID, pollID, voteDate)
+	if err != nil && isRejected(err) {
+		log.Println("Voter API rejected vote history entry: ", err)
+		return nil
+	}
+
+	return err
+}
+
+func (rc *restVoterClient) deleteVoterPoll(voterID, pollID uint) error {
+	ctx, cancel := context.WithTimeout(context.Background(), restclient.DefaultTimeout)
+	defer cancel()
+
+	err := rc.client.DeleteVoterPoll(ctx, voterID, pollID)
+	if err != nil && isRejected(err) {
+		log.Println("Voter API rejected vote history removal: ", err)
+		return nil
+	}
+
+	return err
+}
+
+// restPollClient calls the REST endpoints of the poll API.
+type restPollClient struct {
+	client *pollclient.Client
+}
+
+// Convert a poll of the poll client to the poll schema of the votes API.
+func pollFromClient(p pollclient.Poll) schema.Poll {
+	options := make([]schema.PollOption, len(p.PollOptions))
+	for i, option := range p.PollOptions {
+		options[i] = schema.PollOption{
+			PollOptionID:   option.PollOptionID,
+			PollOptionText: option.PollOptionText,
+			MaxVotes:       option.MaxVotes,
+		}
+	}
+
+	return schema.Poll{
+		PollID:        p.PollID,
+		PollTitle:     p.PollTitle,
+		PollQuestion:  p.PollQuestion,
+		PollOptions:   options,
+		PollStatus:    p.PollStatus,
+		Anonymous:     p.Anonymous,
+		CertifiedAt:   p.CertifiedAt,
+		MaxSelections: p.MaxSelections,
+		AllowWriteIn:  p.AllowWriteIn,
+		Eligibility:   eligibilityFromClient(p.Eligibility),
+		Tags:          p.Tags,
+		CreatedAt:     p.CreatedAt,
+		ClosedAt:      p.ClosedAt,
+		StartTime:     p.StartTime,
+		EndTime:       p.EndTime,
+	}
+}
+
+// Convert the eligibility rules of a poll of the poll client.
+func eligibilityFromClient(e *pollclient.Eligibility) *schema.PollEligibility {
+	if e == nil {
+		return nil
+	}
+
+	ranges := make([]schema.VoterIDRange, len(e.VoterIDRanges))
+	for i, r := range e.VoterIDRanges {
+		ranges[i] = schema.VoterIDRange{From: r.From, To: r.To}
+	}
+
+	return &schema.PollEligibility{VoterIDRanges: ranges, Allowlist: e.Allowlist}
+}
+
+func (rc *restPollClient) listPolls() ([]schema.Poll, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), restclient.DefaultTimeout)
+	defer cancel()
+
+	response, err := rc.client.ListPolls(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	polls := make([]schema.Poll, len(response))
+	for i, p := range response {
+		polls[i] = pollFromClient(p)
+	}
+
+	return polls, nil
+}
+
+func (rc *restPollClient) getPoll(pollID uint) (schema.Poll, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), restclient.DefaultTimeout)
+	defer cancel()
+
+	p, err := rc.client.GetPoll(ctx, pollID)
+	if err != nil {
+		if !isRejected(err) {
+			return schema.Poll{}, fmt.Errorf("%w: %v", errPollAPIUnavailable, err)
+		}
+		return schema.Poll{}, err
+	}
+
+	return pollFromClient(p), nil
+}
+
+func (rc *restPollClient) addPoll(poll schema.Poll) error {
+	ctx, cancel := context.WithTimeout(context.Background(), restclient.DefaultTimeout)
+	defer cancel()
+
+	options := make([]pollclient.PollOption, len(poll.PollOptions))
+	for i, option := range poll.PollOptions {
+		options[i] = pollclient.PollOption{
+			PollOptionID:   option.PollOptionID,
+			PollOptionText: option.PollOptionText,
+			MaxVotes:       option.MaxVotes,
+		}
+	}
+
+	_, err := rc.client.AddPoll(ctx, pollclient.Poll{
+		PollID:        poll.PollID,
+		PollTitle:     poll.PollTitle,
+		PollQuestion:  poll.PollQuestion,
+		PollOptions:   options,
+		Anonymous:     poll.Anonymous,
+		MaxSelections: poll.MaxSelections,
+		AllowWriteIn:  poll.AllowWriteIn,
+	})
+
+	return err
+}
+
+func (rc *restPollClient) addPollOption(pollID uint, option schema.PollOption) error {
+	ctx, cancel := context.WithTimeout(context.Background(), restclient.DefaultTimeout)
+	defer cancel()
+
+	_, err := rc.client.AddPollOption(ctx, pollID, option.PollOptionID, option.PollOptionText, option.MaxVotes)
+	return err
+}
+
+func (rc *restPollClient) isEligibleVoter(pollID, voterID uint) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), restclient.DefaultTimeout)
+	defer cancel()
+
+	return rc.client.IsEligibleVoter(ctx, pollID, voterID)
+}
+
+// Report whether a gRPC error means the other API could not be reached,
+// as opposed to an answer rejecting the call.
+func isUnreachable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Canceled, codes.Internal, codes.Unknown:
+		return true
+	}
+
+	return false
+}
+
+// grpcVoterClient calls the VoterService of the voter API. Like the REST
+// client, it only fails when the voter API could not be reached.
+type grpcVoterClient struct {
+	client votingpb.VoterServiceClient
+}
+
+func (gc *grpcVoterClient) listVoters() ([]schema.Voter, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), rpc.DefaultCallTimeout)
+	defer cancel()
+
+	response, err := gc.client.ListVoters(ctx, &votingpb.ListVotersRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	voters := make([]schema.Voter, len(response.Voters))
+	for i, v := range response.Voters {
+		history := make([]schema.VoterPoll, len(v.VoteHistory))
+		for j, voterPoll := range v.VoteHistory {
+			history[j] = schema.VoterPoll{PollID: uint(voterPoll.PollId)}
+			if voteDate := rpc.Time(voterPoll.VoteDate); voteDate != nil {
+				history[j].VoteDate = *voteDate
+			}
+		}
+
+		voters[i] = schema.Voter{
+			VoterID:     uint(v.VoterId),
+			FirstName:   v.FirstName,
+			LastName:    v.LastName,
+			VoteHistory: history,
+		}
+	}
+
+	return voters, nil
+}
+
+func (gc *grpcVoterClient) addVoter(voter schema.Voter) error {
+	ctx, cancel := context.WithTimeout(context.Background(), rpc.DefaultCallTimeout)
+	defer cancel()
+
+	_, err := gc.client.AddVoter(ctx, &votingpb.AddVoterRequest{Voter: &votingpb.Voter{
+		VoterId:   uint32(voter.VoterID),
+		FirstName: voter.FirstName,
+		LastName:  voter.LastName,
+	}})
+
+	return err
+}
+
+func (gc *grpcVoterClient) addVoterPoll(voterID, pollID uint, voteDate time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), rpc.DefaultCallTimeout)
+	defer cancel()
+
+	_, err := gc.client.AddVoterPoll(ctx, &votingpb.AddVoterPollRequest{
+		VoterId:  uint32(voterID),
+		PollId:   uint32(pollID),
+		VoteDate: rpc.Timestamp(&voteDate),
+	})
+	if err != nil && !isUnreachable(err) {
+		log.Println("Voter API rejected vote history entry: ", err)
+		return nil
+	}
+
+	return err
+}
+
+func (gc *grpcVoterClient) deleteVoterPoll(voterID, pollID uint) error {
+	ctx, cancel := context.WithTimeout(context.Background(), rpc.DefaultCallTimeout)
+	defer cancel()
+
+	_, err := gc.client.DeleteVoterPoll(ctx, &votingpb.DeleteVoterPollRequest{
+		VoterId: uint32(voterID),
+		PollId:  uint32(pollID),
+	})
+	if err != nil && !isUnreachable(err) {
+		log.Println("Voter API rejected vote history removal: ", err)
+		return nil
+	}
+
+	return err
+}
+
+// grpcPollClient calls the PollService of the poll API.
+type grpcPollClient struct {
+	client votingpb.PollServiceClient
+}
+
+// Convert a poll message to the poll schema of the votes API. Poll
+// messages have no anonymous flag nor eligibility rules, polls read over
+// gRPC are never anonymous and let anyone vote.
+func pollFromProto(p *votingpb.Poll) schema.Poll {
+	options := make([]schema.PollOption, len(p.PollOptions))
+	for i, option := range p.PollOptions {
+		options[i] = schema.PollOption{
+			PollOptionID:   uint(option.PollOptionId),
+			PollOptionText: option.PollOptionText,
+			MaxVotes:       uint(option.MaxVotes),
+		}
+	}
+
+	poll := schema.Poll{
+		PollID:       uint(p.PollId),
+		PollTitle:    p.PollTitle,
+		PollQuestion: p.PollQuestion,
+		PollOptions:  options,
+		PollStatus:   p.PollStatus,
+		CertifiedAt:  rpc.Time(p.CertifiedAt),
+		ClosedAt:     rpc.Time(p.ClosedAt),
+	}
+	if createdAt := rpc.Time(p.CreatedAt); createdAt != nil {
+		poll.CreatedAt = *createdAt
+	}
+
+	return poll
+}
+
+func (gc *grpcPollClient) listPolls() ([]schema.Poll, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), rpc.DefaultCallTimeout)
+	defer cancel()
+
+	response, err := gc.client.ListPolls(ctx, &votingpb.ListPollsRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	polls := make([]schema.Poll, len(response.Polls))
+	for i, p := range response.Polls {
+		polls[i] = pollFromProto(p)
+	}
+
+	return polls, nil
+}
+
+func (gc *grpcPollClient) getPoll(pollID uint) (schema.Poll, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), rpc.DefaultCallTimeout)
+	defer cancel()
+
+	p, err := gc.client.GetPoll(ctx, &votingpb.GetPollRequest{PollId: uint32(pollID)})
+	if err != nil {
+		if isUnreachable(err) {
+			return schema.Poll{}, fmt.Errorf("%w: %v", errPollAPIUnavailable, err)
+		}
+		return schema.Poll{}, err
+	}
+
+	return pollFromProto(p), nil
+}
+
+// The AddPoll call of the PollService has no options, and the service
+// has no call to add them, so the options stay empty over gRPC.
+func (gc *grpcPollClient) addPoll(poll schema.Poll) error {
+	ctx, cancel := context.WithTimeout(context.Background(), rpc.DefaultCallTimeout)
+	defer cancel()
+
+	_, err := gc.client.AddPoll(ctx, &votingpb.AddPollRequest{Poll: &votingpb.Poll{
+		PollId:       uint32(poll.PollID),
+		PollTitle:    poll.PollTitle,
+		PollQuestion: poll.PollQuestion,
+	}})
+
+	return err
+}
+
+func (gc *grpcPollClient) addPollOption(pollID uint, option schema.PollOption) error {
+	return errors.New("the poll API cannot add poll options over gRPC")
+}
+
+func (gc *grpcPollClient) isEligibleVoter(pollID, voterID uint) (bool, error) {
+	return false, errors.New("the poll API cannot check allowlists over gRPC")
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	schema "votes-api/Schema"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The eligibility rules of a poll, as the 403 of a vote that fails one
+// names them.
+const (
+	eligibilityRuleVoterIDRanges = "voterIdRanges"
+	eligibilityRuleAllowlist     = "allowlist"
+)
+
+// Check that a voter passes every eligibility rule of the poll. A voter
+// that fails one gets a 403 naming the rule; the allowlist is asked of the
+// poll API. Failures are *voteError.
+func (va *VotesAPI) checkEligibility(poll schema.Poll, voterID uint) error {
+	rules := poll.Eligibility
+	if rules == nil {
+		return nil
+	}
+
+	if len(rules.VoterIDRanges) > 0 && !inVoterIDRanges(rules.VoterIDRanges, voterID) {
+		return notEligibleError(eligibilityRuleVoterIDRanges, "voter ID is outside the allowed ranges")
+	}
+
+	if rules.Allowlist {
+		eligible, err := va.polls.isEligibleVoter(poll.PollID, voterID)
+		if err != nil {
+			log.Println("Error checking poll allowlist: ", err)
+			if voteErr := unreachableError(err); voteErr != nil {
+				return voteErr
+			}
+			return &voteError{status: http.StatusInternalServerError, message: "Could not check the allowlist of the poll"}
+		}
+
+		if !eligible {
+			return notEligibleError(eligibilityRuleAllowlist, "voter is not on the allowlist of the poll")
+		}
+	}
+
+	return nil
+}
+
+// Report whether a voter ID is in one of the ranges.
+func inVoterIDRanges(ranges []schema.VoterIDRange, voterID uint) bool {
+	for _, r := range ranges {
+		if voterID >= r.From && voterID <= r.To {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Return the 403 of a voter that fails an eligibility rule.
+func notEligibleError(rule, reason string) *voteError {
+	return &voteError{
+		status:  http.StatusForbidden,
+		message: fmt.Sprintf("Voter is not eligible to vote in this poll: %s", reason),
+		details: gin.H{"rule": rule},
+	}
+}
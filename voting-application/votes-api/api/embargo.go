@@ -0,0 +1,76 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"votes-api/votes"
+
+	"shared/apierror"
+	"shared/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Implementation of POST /admin/embargo-tokens.
+// Issue an embargo token for early access to the results of a poll.
+func (va *VotesAPI) AddEmbargoToken(c *gin.Context) {
+	var requestBody struct {
+		PollID     uint   `json:"pollId" binding:"required"`
+		IssuedTo   string `json:"issuedTo" binding:"required"`
+		TTLMinutes uint   `json:"ttlMinutes" binding:"required"`
+	}
+
+	if err := validation.Bind(c, &requestBody); err != nil {
+		log.Println("Error parsing JSON request body: ", err)
+		return
+	}
+
+	if _, err := va.getPoll(requestBody.PollID); err != nil {
+		log.Println("Error getting poll: ", err)
+		apierror.AbortWithDetails(c, http.StatusNotFound, apierror.CodeNotFound, "Could not find poll in cache", err)
+		return
+	}
+
+	ttl := time.Duration(requestBody.TTLMinutes) * time.Minute
+	embargoToken, err := va.votesCache.CreateEmbargoToken(requestBody.PollID, requestBody.IssuedTo, ttl)
+	if err != nil {
+		log.Println("Error creating embargo token: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not create embargo token", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, embargoToken)
+}
+
+// Implementation of GET /admin/embargo-tokens.
+// Returns all unexpired embargo tokens.
+func (va *VotesAPI) ListEmbargoTokens(c *gin.Context) {
+	tokens, err := va.votesCache.GetAllEmbargoTokens()
+	if err != nil {
+		log.Println("Error getting embargo tokens: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not get embargo tokens", err)
+		return
+	}
+
+	if tokens == nil {
+		tokens = make([]votes.EmbargoToken, 0)
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Implementation of DELETE /admin/embargo-tokens/:token.
+// Revoke an embargo token.
+func (va *VotesAPI) DeleteEmbargoToken(c *gin.Context) {
+	if err := va.votesCache.DeleteEmbargoToken(c.Param("token")); err != nil {
+		log.Println("Error deleting embargo token: ", err)
+		apierror.AbortWithError(c, http.StatusNotFound, "Could not delete embargo token", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Embargo token revoked successfully.",
+	})
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"votes-api/votes"
+
+	"shared/events"
+)
+
+const (
+	DefaultVoteEventsStream = "events:votes"
+)
+
+// Return the stream vote events are published to, VOTE_EVENTS_STREAM
+// or events:votes. Publishing is disabled when it is set to "off".
+func voteEventsStream() string {
+	stream := os.Getenv("VOTE_EVENTS_STREAM")
+	if stream == "" {
+		return DefaultVoteEventsStream
+	}
+
+	return stream
+}
+
+// Publish a VoteCast event for an accepted vote. A failure to publish is
+// logged and does not reject the vote.
+func (va *VotesAPI) publishVoteCast(vote votes.Vote) {
+	if va.voteEventsStream == "off" {
+		return
+	}
+
+	event := events.VoteCast{
+		VoteID:       vote.VoteID,
+		VoterID:      vote.VoterID,
+		PollID:       vote.PollID,
+		VoteValue:    vote.VoteValue,
+		CastAt:       time.Now(),
+		OptionIDs:    vote.OptionIDs,
+		WriteInValue: vote.WriteInValue,
+	}
+
+	if _, err := events.Publish(context.Background(), va.votesCache.RedisClient(), va.voteEventsStream, events.EventTypeVoteCast, event); err != nil {
+		log.Println("Error publishing VoteCast event: ", err)
+	}
+}
+
+// Publish a VoteDeleted event for a vote that was deleted or voided. A
+// failure to publish is logged and does not fail the deletion.
+func (va *VotesAPI) publishVoteDeleted(vote votes.Vote) {
+	if va.voteEventsStream == "off" {
+		return
+	}
+
+	event := events.VoteDeleted{
+		VoteID:    vote.VoteID,
+		VoterID:   vote.VoterID,
+		PollID:    vote.PollID,
+		VoteValue: vote.VoteValue,
+		DeletedAt: time.Now(),
+		OptionIDs: vote.OptionIDs,
+	}
+
+	if _, err := events.Publish(context.Background(), va.votesCache.RedisClient(), va.voteEventsStream, events.EventTypeVoteDeleted, event); err != nil {
+		log.Println("Error publishing VoteDeleted event: ", err)
+	}
+}
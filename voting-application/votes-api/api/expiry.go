@@ -0,0 +1,56 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"shared/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// How many expirations GET /admin/expirations returns without ?limit=.
+const DefaultExpirationsLimit = 100
+
+// Implementation of GET /admin/expirations.
+// Lists the votes whose keys have a TTL, the first to expire first, up to
+// ?limit=, with the tag of test polls and the TTL of their votes.
+func (va *VotesAPI) GetExpirations(c *gin.Context) {
+	if va.votesCache == nil {
+		apierror.Abort(c, http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, "Redis is not connected")
+		return
+	}
+
+	limit := DefaultExpirationsLimit
+	if query := c.Query("limit"); query != "" {
+		parsed, err := strconv.Atoi(query)
+		if err != nil || parsed < 1 {
+			apierror.Abort(c, http.StatusBadRequest, apierror.CodeBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = parsed
+	}
+
+	expirations, err := va.votesCache.GetExpirations()
+	if err != nil {
+		log.Println("Error listing vote expirations: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not list vote expirations", err)
+		return
+	}
+
+	total := len(expirations)
+	if len(expirations) > limit {
+		expirations = expirations[:limit]
+	}
+
+	tag, ttl := va.votesCache.TestVoteTTL()
+	c.JSON(http.StatusOK, gin.H{
+		"policy": gin.H{
+			"testPollTag":  tag,
+			"testVoteDays": ttl.Hours() / 24,
+		},
+		"total":       total,
+		"expirations": expirations,
+	})
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"log"
+	"time"
+
+	schema "votes-api/Schema"
+	"votes-api/votes"
+
+	"shared/export"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The CSV columns of a vote export.
+var voteExportHeader = []string{"voteId", "voterId", "pollId", "voteValue", "flaggedAt", "flagReason", "createdAt", "updatedAt", "optionIds", "writeInValue", "voteDate"}
+
+// voteParquetRow is a vote as a row of the Parquet export, with the title
+// of its poll and the text of its option. Optional columns are null when
+// they are empty.
+type voteParquetRow struct {
+	VoteID uint32 `parquet:"vote_id"`
+	// Null for the votes of anonymous polls, which have a voter hash.
+	VoterID    uint32 `parquet:"voter_id,optional"`
+	VoterHash  string `parquet:"voter_hash,optional"`
+	PollID     uint32 `parquet:"poll_id"`
+	PollTitle  string `parquet:"poll_title,optional"`
+	OptionID   uint32 `parquet:"option_id"`
+	OptionText string `parquet:"option_text,optional"`
+	Flagged    bool   `parquet:"flagged"`
+	FlaggedAt  int64  `parquet:"flagged_at,optional,timestamp(millisecond)"`
+	FlagReason string `parquet:"flag_reason,optional"`
+	CreatedAt  int64  `parquet:"created_at,optional,timestamp(millisecond)"`
+	UpdatedAt  int64  `parquet:"updated_at,optional,timestamp(millisecond)"`
+	// The options of a vote of a multi-choice poll separated by commas,
+	// null for single-choice votes.
+	OptionIDs string `parquet:"option_ids,optional"`
+	// The text of a write-in vote, null for others.
+	WriteInValue string `parquet:"write_in_value,optional"`
+	// When the ballot was cast, created_at for votes stored without one.
+	VoteDate int64 `parquet:"vote_date,optional,timestamp(millisecond)"`
+}
+
+// Implementation of GET /votes/export?format=csv|json|parquet.
+// Stream every vote as CSV, as a JSON array or as Parquet, row by row as
+// they are read from the store.
+func (va *VotesAPI) ExportVotes(c *gin.Context) {
+	if c.Query("format") == FormatParquet {
+		va.exportVotesParquet(c)
+		return
+	}
+
+	writer := export.NewWriter(c, "votes", voteExportHeader)
+	if writer == nil {
+		return
+	}
+
+	err := va.votesList.EachVote(func(vote votes.Vote) error {
+		return writer.Write(vote, []string{
+			export.Uint(vote.VoteID),
+			export.Uint(vote.VoterID),
+			export.Uint(vote.PollID),
+			export.Uint(vote.VoteValue),
+			formatTime(vote.FlaggedAt),
+			vote.FlagReason,
+			formatTime(vote.CreatedAt),
+			formatTime(vote.UpdatedAt),
+			formatOptionIDs(vote.OptionIDs),
+			vote.WriteInValue,
+			formatTime(vote.CastAt()),
+		})
+	})
+	if err != nil {
+		log.Println("Error exporting votes: ", err)
+	}
+
+	writer.Close(err)
+}
+
+// Stream every vote as Parquet. The polls are read once before the first
+// row; when the poll API is unavailable the last known polls are used, and
+// the titles and texts of the others are null.
+func (va *VotesAPI) exportVotesParquet(c *gin.Context) {
+	polls := make(map[uint]schema.Poll)
+	if allPolls, err := va.polls.listPolls(); err == nil {
+		va.pollMetadata.store(allPolls...)
+		for _, poll := range allPolls {
+			polls[poll.PollID] = poll
+		}
+	} else {
+		log.Println("Error getting polls for the vote export: ", err)
+	}
+
+	writer := newParquetWriter[voteParquetRow](c, "votes")
+
+	err := va.votesList.EachVote(func(vote votes.Vote) error {
+		row := voteParquetRow{
+			VoteID:       uint32(vote.VoteID),
+			VoterID:      uint32(vote.VoterID),
+			VoterHash:    vote.VoterHash,
+			PollID:       uint32(vote.PollID),
+			OptionID:     uint32(vote.VoteValue),
+			Flagged:      vote.FlaggedAt != nil,
+			FlaggedAt:    parquetTime(vote.FlaggedAt),
+			FlagReason:   vote.FlagReason,
+			CreatedAt:    parquetTime(vote.CreatedAt),
+			UpdatedAt:    parquetTime(vote.UpdatedAt),
+			OptionIDs:    formatOptionIDs(vote.OptionIDs),
+			WriteInValue: vote.WriteInValue,
+			VoteDate:     parquetTime(vote.CastAt()),
+		}
+
+		poll, found := polls[vote.PollID]
+		if !found {
+			if cached, ok := va.pollMetadata.load(vote.PollID); ok {
+				poll, found = cached.poll, true
+			}
+		}
+		if found {
+			row.PollTitle = poll.PollTitle
+			for _, option := range poll.PollOptions {
+				if option.PollOptionID == vote.VoteValue {
+					row.OptionText = option.PollOptionText
+				}
+			}
+		}
+
+		return writer.Write(row)
+	})
+	if err != nil {
+		log.Println("Error exporting votes: ", err)
+	}
+
+	writer.Close(err)
+}
+
+// Return an optional time of a vote for a Parquet timestamp column, in
+// milliseconds since the epoch. Zero is written as null.
+func parquetTime(t *time.Time) int64 {
+	if t == nil {
+		return 0
+	}
+
+	return t.UnixMilli()
+}
+
+// Format an optional time of a vote for a CSV cell.
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
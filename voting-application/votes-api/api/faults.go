@@ -0,0 +1,127 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// faultRule injects latency and errors into the calls made to one
+// downstream service.
+type faultRule struct {
+	name      string
+	baseURL   string
+	latency   time.Duration
+	jitter    time.Duration
+	errorRate float64
+}
+
+// faultTransport simulates a slow or unreliable downstream service on the
+// caller side, before the request leaves the votes API. It is separate
+// from any chaos the services themselves inject.
+type faultTransport struct {
+	rules []faultRule
+	next  http.RoundTripper
+}
+
+// Load the fault rule of a downstream service from <PREFIX>_LATENCY,
+// <PREFIX>_JITTER (durations) and <PREFIX>_ERROR_RATE (0 to 1). It
+// reports false when nothing is configured.
+func loadFaultRule(name, prefix, baseURL string) (faultRule, bool) {
+	rule := faultRule{name: name, baseURL: baseURL}
+
+	if latency, err := time.ParseDuration(os.Getenv(prefix + "_LATENCY")); err == nil && latency > 0 {
+		rule.latency = latency
+	}
+
+	if jitter, err := time.ParseDuration(os.Getenv(prefix + "_JITTER")); err == nil && jitter > 0 {
+		rule.jitter = jitter
+	}
+
+	if errorRate, err := strconv.ParseFloat(os.Getenv(prefix+"_ERROR_RATE"), 64); err == nil && errorRate > 0 {
+		if errorRate > 1 {
+			errorRate = 1
+		}
+		rule.errorRate = errorRate
+	}
+
+	configured := rule.latency > 0 || rule.jitter > 0 || rule.errorRate > 0
+	if configured {
+		log.Printf("Injecting faults into %s calls: latency %s, jitter %s, error rate %.2f", name, rule.latency, rule.jitter, rule.errorRate)
+	}
+
+	return rule, configured
+}
+
+// Wrap the transport with the configured VOTER_CLIENT_* and POLL_CLIENT_*
+// fault rules. The transport is returned unchanged when there are none.
+func withFaultInjection(next http.RoundTripper, voterAPIURL, pollAPIURL string) http.RoundTripper {
+	transport := &faultTransport{next: next}
+
+	if rule, ok := loadFaultRule("voter API", "VOTER_CLIENT", voterAPIURL); ok {
+		transport.rules = append(transport.rules, rule)
+	}
+
+	if rule, ok := loadFaultRule("poll API", "POLL_CLIENT", pollAPIURL); ok {
+		transport.rules = append(transport.rules, rule)
+	}
+
+	if len(transport.rules) == 0 {
+		return next
+	}
+
+	return transport
+}
+
+func (ft *faultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	url := req.URL.String()
+
+	for _, rule := range ft.rules {
+		if rule.baseURL == "" || !strings.HasPrefix(url, rule.baseURL) {
+			continue
+		}
+
+		delay := rule.latency
+		if rule.jitter > 0 {
+			delay += time.Duration(rand.Int63n(int64(rule.jitter)))
+		}
+
+		if delay > 0 {
+			// Honor the caller's deadline, so client timeouts can be observed.
+			select {
+			case <-time.After(delay):
+			case <-req.Context().Done():
+				return nil, req.Context().Err()
+			}
+		}
+
+		if rule.errorRate > 0 && rand.Float64() < rule.errorRate {
+			body := fmt.Sprintf(`{"error":"injected %s failure"}`, rule.name)
+			header := make(http.Header)
+			header.Set("Content-Type", "application/json")
+
+			return &http.Response{
+				Status:        "503 Service Unavailable",
+				StatusCode:    http.StatusServiceUnavailable,
+				Proto:         "HTTP/1.1",
+				ProtoMajor:    1,
+				ProtoMinor:    1,
+				Header:        header,
+				Body:          io.NopCloser(bytes.NewReader([]byte(body))),
+				ContentLength: int64(len(body)),
+				Request:       req,
+			}, nil
+		}
+
+		break
+	}
+
+	return ft.next.RoundTrip(req)
+}
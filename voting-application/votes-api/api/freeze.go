@@ -0,0 +1,200 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	schema "votes-api/Schema"
+	"votes-api/votes"
+
+	"shared/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Report whether a poll is closed or certified, when its results can be
+// frozen.
+func pollEnded(poll schema.Poll) bool {
+	return poll.PollStatus == "closed" || poll.PollStatus == "certified"
+}
+
+// Freeze the results of an ended poll: count its votes and ballots and
+// store the snapshot, unless one was stored before. It returns the stored
+// snapshot and whether it was stored now.
+func (va *VotesAPI) freezePollResults(poll schema.Poll) (votes.ResultSnapshot, bool, error) {
+	results, _, err := va.tallyPoll(poll)
+	if err != nil {
+		return votes.ResultSnapshot{}, false, err
+	}
+
+	ballots, err := va.votesList.FindVotes(votes.VoteFilter{PollID: poll.PollID})
+	if err != nil {
+		return votes.ResultSnapshot{}, false, err
+	}
+
+	counts := make([]votes.OptionCount, len(results))
+	for i, result := range results {
+		counts[i] = votes.OptionCount(result)
+	}
+
+	snapshot := votes.NewResultSnapshot(poll.PollID, poll.PollTitle, poll.ClosedAt, counts, uint(len(ballots)))
+	return va.votesCache.FreezeResults(snapshot)
+}
+
+// Return a 409 voteError when the results of a poll are frozen, so its
+// votes can no longer change. Without redis no results are frozen.
+func (va *VotesAPI) checkPollNotFrozen(pollID uint) *voteError {
+	if va.votesCache == nil {
+		return nil
+	}
+
+	frozen, err := va.votesCache.IsFrozen(pollID)
+	if err != nil {
+		log.Println("Error checking result snapshot: ", err)
+		return &voteError{status: http.StatusInternalServerError}
+	}
+
+	if frozen {
+		return &voteError{status: http.StatusConflict, message: "Poll results are frozen"}
+	}
+
+	return nil
+}
+
+// Implementation of POST /admin/polls/:pollId/freeze.
+// Freeze the results of a closed or certified poll. The poll API calls it
+// when it closes a poll. A poll that is already frozen keeps its snapshot,
+// answered with 200 rather than 201.
+func (va *VotesAPI) FreezePollResults(c *gin.Context) {
+	pollID := c.Param("pollId")
+	pollIDUint, err := strconv.ParseUint(pollID, 10, 32)
+	if err != nil {
+		log.Println("Error converting poll ID to uint: ", err)
+		apierror.AbortInvalidID(c, "Poll ID", err)
+		return
+	}
+
+	poll, err := va.getPoll(uint(pollIDUint))
+	if err != nil {
+		log.Println("Error getting poll: ", err)
+		if errors.Is(err, errPollAPIUnavailable) {
+			apierror.AbortWithDetails(c, http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, "Poll API is unavailable", err)
+			return
+		}
+		apierror.AbortWithDetails(c, http.StatusNotFound, apierror.CodeNotFound, "Could not find poll in cache", err)
+		return
+	}
+
+	if !pollEnded(poll) {
+		apierror.Abort(c, http.StatusConflict, apierror.CodeConflict, "Poll is not closed")
+		return
+	}
+
+	snapshot, created, err := va.freezePollResults(poll)
+	if err != nil {
+		log.Println("Error freezing poll results: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not freeze poll results", err)
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		log.Printf("Froze the results of poll %d: %d ballots", poll.PollID, snapshot.Turnout)
+		status = http.StatusCreated
+	}
+
+	c.JSON(status, snapshot)
+}
+
+// Implementation of GET /votes/results/:pollId/snapshot.
+// Returns the result snapshot of a poll, frozen when it closed, to the
+// callers GET /votes/results/:pollId serves. A closed poll that was not
+// frozen yet, such as one closed while this API was down, is frozen now.
+// Results protected for privacy leave out the winner, the turnout and the
+// checksum, from which small counts could be guessed.
+func (va *VotesAPI) GetResultSnapshot(c *gin.Context) {
+	pollID := c.Param("pollId")
+	pollIDUint, err := strconv.ParseUint(pollID, 10, 32)
+	if err != nil {
+		log.Println("Error converting poll ID to uint: ", err)
+		apierror.AbortInvalidID(c, "Poll ID", err)
+		return
+	}
+
+	meta := map[string]interface{}{
+		"access": "public",
+	}
+
+	poll, err := va.getPollForRead(uint(pollIDUint), meta)
+	if err != nil {
+		log.Println("Error getting poll: ", err)
+		if errors.Is(err, errPollAPIUnavailable) {
+			apierror.AbortWithDetails(c, http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, "Poll API is unavailable", err)
+			return
+		}
+		apierror.AbortWithDetails(c, http.StatusNotFound, apierror.CodeNotFound, "Could not find poll in cache", err)
+		return
+	}
+
+	if !va.authorizeResults(c, poll, meta) {
+		return
+	}
+
+	snapshot, err := va.votesCache.GetResultSnapshot(poll.PollID)
+	if errors.Is(err, votes.ErrNotFrozen) && pollEnded(poll) {
+		snapshot, _, err = va.freezePollResults(poll)
+	}
+	if errors.Is(err, votes.ErrNotFrozen) {
+		apierror.Abort(c, http.StatusNotFound, apierror.CodeNotFound, "Poll results are not frozen, the poll is still open")
+		return
+	}
+	if err != nil {
+		log.Println("Error getting result snapshot: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not get the result snapshot", err)
+		return
+	}
+
+	response := gin.H{
+		"pollId":      snapshot.PollID,
+		"pollTitle":   snapshot.PollTitle,
+		"pollStatus":  poll.PollStatus,
+		"closedAt":    snapshot.ClosedAt,
+		"frozenAt":    snapshot.FrozenAt,
+		"results":     snapshot.Results,
+		"totalVotes":  snapshot.TotalVotes,
+		"turnout":     snapshot.Turnout,
+		"winner":      snapshot.Winner,
+		"tiedOptions": snapshot.TiedOptions,
+		"checksum":    snapshot.Checksum,
+		"meta":        meta,
+	}
+
+	// Exact counts are reserved for admins, as with the live results.
+	if meta["access"] != "admin" {
+		results := make([]optionResult, len(snapshot.Results))
+		for i, count := range snapshot.Results {
+			results[i] = optionResult(count)
+		}
+
+		protected, protection := va.privacy.protect(snapshot.PollID, results, snapshot.TotalVotes)
+		if protection != "" {
+			var totalVotes uint
+			for _, result := range protected {
+				totalVotes += result.Votes
+			}
+
+			response["results"] = protected
+			response["totalVotes"] = totalVotes
+			delete(response, "turnout")
+			delete(response, "winner")
+			delete(response, "tiedOptions")
+			delete(response, "checksum")
+			meta["privacy"] = protection
+			meta["privacyThreshold"] = va.privacy.threshold
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
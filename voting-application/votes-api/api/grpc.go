@@ -0,0 +1,101 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"votes-api/votes"
+
+	"shared/rpc"
+	"shared/votingpb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// voteServer serves the VoteService over gRPC. It casts and deletes votes
+// through the same checks as the REST endpoints.
+type voteServer struct {
+	votingpb.UnimplementedVoteServiceServer
+	va *VotesAPI
+}
+
+// Register the VoteService on a gRPC server.
+func (va *VotesAPI) RegisterGRPC(server *grpc.Server) {
+	votingpb.RegisterVoteServiceServer(server, &voteServer{va: va})
+}
+
+// Convert a vote to its protobuf message.
+func voteToProto(vote votes.Vote) *votingpb.Vote {
+	return &votingpb.Vote{
+		VoteId:     uint32(vote.VoteID),
+		VoterId:    uint32(vote.VoterID),
+		PollId:     uint32(vote.PollID),
+		OptionId:   uint32(vote.VoteValue),
+		FlaggedAt:  rpc.Timestamp(vote.FlaggedAt),
+		FlagReason: vote.FlagReason,
+	}
+}
+
+// Convert a vote error to a gRPC status error.
+func voteStatusError(err error) error {
+	var voteErr *voteError
+	if !errors.As(err, &voteErr) {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	return status.Error(rpc.CodeFromHTTPStatus(voteErr.status), voteErr.Error())
+}
+
+func (s *voteServer) ListVotes(ctx context.Context, request *votingpb.ListVotesRequest) (*votingpb.ListVotesResponse, error) {
+	allVotes, err := s.va.votesList.GetAllVotes()
+	if err != nil {
+		log.Println("Error getting Votes: ", err)
+		return nil, status.Error(codes.Internal, "could not get votes")
+	}
+
+	response := &votingpb.ListVotesResponse{Votes: make([]*votingpb.Vote, len(allVotes))}
+	for i, vote := range allVotes {
+		response.Votes[i] = voteToProto(vote)
+	}
+
+	return response, nil
+}
+
+func (s *voteServer) GetVote(ctx context.Context, request *votingpb.GetVoteRequest) (*votingpb.Vote, error) {
+	vote, err := s.va.votesList.GetVote(uint(request.VoteId))
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+
+	return voteToProto(vote), nil
+}
+
+func (s *voteServer) AddVote(ctx context.Context, request *votingpb.AddVoteRequest) (*votingpb.Vote, error) {
+	if request.Vote == nil {
+		return nil, status.Error(codes.InvalidArgument, "vote is required")
+	}
+
+	vote, err := s.va.castVote(votes.Vote{
+		VoteID:    uint(request.Vote.VoteId),
+		VoterID:   uint(request.Vote.VoterId),
+		PollID:    uint(request.Vote.PollId),
+		VoteValue: uint(request.Vote.OptionId),
+	}, "")
+	if err != nil {
+		return nil, voteStatusError(err)
+	}
+
+	return voteToProto(vote), nil
+}
+
+func (s *voteServer) DeleteVote(ctx context.Context, request *votingpb.DeleteVoteRequest) (*emptypb.Empty, error) {
+	if err := s.va.removeVote(uint(request.VoteId)); err != nil {
+		return nil, voteStatusError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
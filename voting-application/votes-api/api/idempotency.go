@@ -0,0 +1,173 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"votes-api/votes"
+
+	"shared/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	DefaultIdempotencyTTL    = 24 * time.Hour
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	// How long a key is reserved while its request runs. A vote makes a
+	// few calls to the voter and poll APIs of up to restclient's 5 second
+	// timeout each, so this is a few times the longest request.
+	DefaultIdempotencyLockTTL = time.Minute
+)
+
+// idempotencyStore keeps the reservations and recorded responses of the
+// idempotency keys, in redis.
+type idempotencyStore interface {
+	ReserveIdempotencyKey(key string, ttl time.Duration) (bool, error)
+	ReleaseIdempotencyKey(key string) error
+	SaveIdempotentResponse(key string, response votes.IdempotentResponse, ttl time.Duration) error
+	GetIdempotentResponse(key string) (votes.IdempotentResponse, bool, error)
+}
+
+// bodyRecorder copies everything written to the response so it can be
+// stored for later replays.
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (br *bodyRecorder) Write(data []byte) (int, error) {
+	br.body.Write(data)
+	return br.ResponseWriter.Write(data)
+}
+
+func (br *bodyRecorder) WriteString(data string) (int, error) {
+	br.body.WriteString(data)
+	return br.ResponseWriter.WriteString(data)
+}
+
+// Load the lifetime of recorded idempotent responses from IDEMPOTENCY_TTL.
+func loadIdempotencyTTL() time.Duration {
+	if ttl, err := time.ParseDuration(os.Getenv("IDEMPOTENCY_TTL")); err == nil && ttl > 0 {
+		return ttl
+	}
+
+	return DefaultIdempotencyTTL
+}
+
+// Load how long a key is reserved while its request runs from
+// IDEMPOTENCY_LOCK_TTL.
+func loadIdempotencyLockTTL() time.Duration {
+	if ttl, err := time.ParseDuration(os.Getenv("IDEMPOTENCY_LOCK_TTL")); err == nil && ttl > 0 {
+		return ttl
+	}
+
+	return DefaultIdempotencyLockTTL
+}
+
+// The middleware that makes a route idempotent when the client sends an
+// Idempotency-Key header. The first request with a key is executed and its
+// response recorded; repeats get the recorded response back without
+// re-running the handler. Server errors are not recorded so the client can
+// retry them.
+func IdempotencyMiddleware(va *VotesAPI) gin.HandlerFunc {
+	return idempotencyMiddleware(va.votesCache, loadIdempotencyLockTTL(), loadIdempotencyTTL())
+}
+
+// Return the idempotency middleware of store. A key is only reserved for
+// lockTTL while its request runs, and released when the request fails or
+// panics, so a crash does not lock the key out of its retries; recorded
+// responses are kept for ttl.
+func idempotencyMiddleware(store idempotencyStore, lockTTL, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			log.Println("Error reading request body: ", err)
+			apierror.AbortWithError(c, http.StatusBadRequest, "Could not read request body", err)
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		sum := sha256.Sum256(append([]byte(c.Request.Method+" "+c.Request.URL.Path+" "), body...))
+		fingerprint := hex.EncodeToString(sum[:])
+
+		reserved, err := store.ReserveIdempotencyKey(key, lockTTL)
+		if err != nil {
+			log.Println("Error reserving idempotency key: ", err)
+			apierror.AbortWithError(c, http.StatusInternalServerError, "Could not reserve idempotency key", err)
+			return
+		}
+
+		if !reserved {
+			response, pending, err := store.GetIdempotentResponse(key)
+			if err != nil {
+				log.Println("Error getting idempotent response: ", err)
+				apierror.AbortWithError(c, http.StatusInternalServerError, "Could not get idempotent response", err)
+				return
+			}
+
+			if pending {
+				apierror.Abort(c, http.StatusConflict, apierror.CodeConflict, "A request with this idempotency key is in progress")
+				return
+			}
+
+			if response.Fingerprint != fingerprint {
+				apierror.Abort(c, http.StatusUnprocessableEntity, apierror.CodeUnprocessable, "Idempotency key was used for a different request")
+				return
+			}
+
+			c.Header(IdempotentReplayedHeader, "true")
+			c.Data(response.StatusCode, response.ContentType, response.Body)
+			c.Abort()
+			return
+		}
+
+		// Unless its response is recorded, the key is released, also when
+		// the handler panics.
+		saved := false
+		defer func() {
+			if saved {
+				return
+			}
+			if err := store.ReleaseIdempotencyKey(key); err != nil {
+				log.Println("Error releasing idempotency key: ", err)
+			}
+		}()
+
+		recorder := &bodyRecorder{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = recorder
+
+		c.Next()
+
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			return
+		}
+
+		response := votes.IdempotentResponse{
+			Fingerprint: fingerprint,
+			StatusCode:  c.Writer.Status(),
+			ContentType: c.Writer.Header().Get("Content-Type"),
+			Body:        recorder.body.Bytes(),
+		}
+
+		if err := store.SaveIdempotentResponse(key, response, ttl); err != nil {
+			log.Println("Error saving idempotent response: ", err)
+			return
+		}
+		saved = true
+	}
+}
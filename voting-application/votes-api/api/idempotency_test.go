@@ -0,0 +1,145 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"votes-api/votes"
+
+	"github.com/gin-gonic/gin"
+)
+
+// idempotencyKeys is an idempotencyStore in memory that records the TTL
+// each key was last given.
+type idempotencyKeys struct {
+	pending   map[string]bool
+	responses map[string]votes.IdempotentResponse
+	ttls      map[string]time.Duration
+}
+
+func newIdempotencyKeys() *idempotencyKeys {
+	return &idempotencyKeys{
+		pending:   make(map[string]bool),
+		responses: make(map[string]votes.IdempotentResponse),
+		ttls:      make(map[string]time.Duration),
+	}
+}
+
+func (ik *idempotencyKeys) ReserveIdempotencyKey(key string, ttl time.Duration) (bool, error) {
+	if _, saved := ik.responses[key]; saved || ik.pending[key] {
+		return false, nil
+	}
+
+	ik.pending[key] = true
+	ik.ttls[key] = ttl
+	return true, nil
+}
+
+func (ik *idempotencyKeys) ReleaseIdempotencyKey(key string) error {
+	delete(ik.pending, key)
+	delete(ik.responses, key)
+	delete(ik.ttls, key)
+	return nil
+}
+
+func (ik *idempotencyKeys) SaveIdempotentResponse(key string, response votes.IdempotentResponse, ttl time.Duration) error {
+	delete(ik.pending, key)
+	ik.responses[key] = response
+	ik.ttls[key] = ttl
+	return nil
+}
+
+func (ik *idempotencyKeys) GetIdempotentResponse(key string) (votes.IdempotentResponse, bool, error) {
+	return ik.responses[key], ik.pending[key], nil
+}
+
+// Return a router whose POST /votes/:id runs handler behind the
+// idempotency middleware of store, and a function sending a vote with the
+// idempotency key "key".
+func newIdempotentRouter(store idempotencyStore, handler gin.HandlerFunc) func() *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered interface{}) {
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}))
+	r.POST("/votes/:id", idempotencyMiddleware(store, time.Minute, 24*time.Hour), handler)
+
+	return func() *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/votes/1", strings.NewReader(`{"pollId":1}`))
+		request.Header.Set(IdempotencyKeyHeader, "key")
+
+		recorder := httptest.NewRecorder()
+		r.ServeHTTP(recorder, request)
+		return recorder
+	}
+}
+
+func TestIdempotencyKeyIsReservedBrieflyAndRecordedForTheTTL(t *testing.T) {
+	store := newIdempotencyKeys()
+
+	var reservedFor time.Duration
+	calls := 0
+	send := newIdempotentRouter(store, func(c *gin.Context) {
+		calls++
+		reservedFor = store.ttls["key"]
+		c.JSON(http.StatusCreated, gin.H{"voteId": 1})
+	})
+
+	if recorder := send(); recorder.Code != http.StatusCreated {
+		t.Fatalf("POST /votes/1 = %d, want %d", recorder.Code, http.StatusCreated)
+	}
+	if reservedFor != time.Minute {
+		t.Fatalf("the key was reserved for %v while the request ran, want the lock TTL %v", reservedFor, time.Minute)
+	}
+	if store.ttls["key"] != 24*time.Hour {
+		t.Fatalf("the response was recorded for %v, want %v", store.ttls["key"], 24*time.Hour)
+	}
+
+	recorder := send()
+	if recorder.Code != http.StatusCreated || recorder.Header().Get(IdempotentReplayedHeader) != "true" || calls != 1 {
+		t.Fatalf("the repeat = %d, replayed %q, after %d calls, want the recorded response", recorder.Code, recorder.Header().Get(IdempotentReplayedHeader), calls)
+	}
+}
+
+// A request that panics or fails releases its key, so a retry is run
+// rather than answered 409 until the reservation expires.
+func TestIdempotencyKeyIsReleasedWhenTheRequestFails(t *testing.T) {
+	for name, fail := range map[string]gin.HandlerFunc{
+		"panic": func(c *gin.Context) {
+			panic("vote handler crashed")
+		},
+		"server error": func(c *gin.Context) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed"})
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			store := newIdempotencyKeys()
+
+			failed := false
+			send := newIdempotentRouter(store, func(c *gin.Context) {
+				if !failed {
+					failed = true
+					fail(c)
+					return
+				}
+				c.JSON(http.StatusCreated, gin.H{"voteId": 1})
+			})
+
+			if recorder := send(); recorder.Code != http.StatusInternalServerError {
+				t.Fatalf("the failing request = %d, want %d", recorder.Code, http.StatusInternalServerError)
+			}
+			if store.pending["key"] {
+				t.Fatal("the key is still reserved after the request failed")
+			}
+
+			if recorder := send(); recorder.Code != http.StatusCreated {
+				t.Fatalf("the retry = %d, want %d", recorder.Code, http.StatusCreated)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	schema "votes-api/Schema"
+)
+
+// The placeholders created for missing references by the
+// auto-create-stub import policy.
+const (
+	stubFirstName    = "Unknown"
+	stubLastName     = "Voter"
+	stubPollTitle    = "Imported poll %d"
+	stubPollQuestion = "Created by an import for votes that reference a missing poll"
+	stubOptionText   = "Option %d"
+)
+
+// references are the voters, polls and poll options an import can refer
+// to.
+type references struct {
+	voters map[uint]bool
+	// The options of every poll.
+	polls map[uint]map[uint]bool
+}
+
+// Load the voters and polls of the voter and poll APIs.
+func (va *VotesAPI) loadReferences() (references, error) {
+	refs := references{voters: make(map[uint]bool), polls: make(map[uint]map[uint]bool)}
+
+	voters, err := va.voters.listVoters()
+	if err != nil {
+		return refs, err
+	}
+	for _, voter := range voters {
+		refs.voters[voter.VoterID] = true
+	}
+
+	polls, err := va.polls.listPolls()
+	if err != nil {
+		return refs, err
+	}
+	for _, poll := range polls {
+		options := make(map[uint]bool, len(poll.PollOptions))
+		for _, option := range poll.PollOptions {
+			options[option.PollOptionID] = true
+		}
+		refs.polls[poll.PollID] = options
+	}
+
+	return refs, nil
+}
+
+// Return why a vote for the options of a poll cannot be imported, empty
+// when its voter, poll and options exist.
+func (refs references) missing(voterID, pollID uint, optionIDs []uint) string {
+	if !refs.voters[voterID] {
+		return fmt.Sprintf("voter %d does not exist", voterID)
+	}
+
+	return refs.missingPoll(pollID, optionIDs)
+}
+
+// Return why a vote for the options of a poll cannot be imported, empty
+// when its poll and options exist, for the votes without a voter.
+func (refs references) missingPoll(pollID uint, optionIDs []uint) string {
+	options, pollExists := refs.polls[pollID]
+	if !pollExists {
+		return fmt.Sprintf("poll %d does not exist", pollID)
+	}
+
+	for _, optionID := range optionIDs {
+		if !options[optionID] {
+			return fmt.Sprintf("poll %d has no option %d", pollID, optionID)
+		}
+	}
+
+	return ""
+}
+
+// Create the placeholder voter, poll and options a vote needs and are
+// missing, and return what was created.
+func (va *VotesAPI) createStubs(refs references, voterID, pollID uint, optionIDs []uint) (string, error) {
+	var created []string
+
+	if !refs.voters[voterID] {
+		err := va.voters.addVoter(schema.Voter{VoterID: voterID, FirstName: stubFirstName, LastName: stubLastName})
+		if err != nil {
+			return "", fmt.Errorf("creating stub voter %d: %w", voterID, err)
+		}
+		refs.voters[voterID] = true
+		created = append(created, fmt.Sprintf("voter %d", voterID))
+	}
+
+	// The stub poll of a write-in vote has no options.
+	if _, ok := refs.polls[pollID]; !ok && len(optionIDs) == 0 {
+		err := va.polls.addPoll(schema.Poll{
+			PollID:       pollID,
+			PollTitle:    fmt.Sprintf(stubPollTitle, pollID),
+			PollQuestion: stubPollQuestion,
+			AllowWriteIn: true,
+		})
+		if err != nil {
+			return "", fmt.Errorf("creating stub poll %d: %w", pollID, err)
+		}
+		refs.polls[pollID] = map[uint]bool{}
+		created = append(created, fmt.Sprintf("poll %d", pollID))
+	}
+
+	for _, optionID := range optionIDs {
+		option := schema.PollOption{PollOptionID: optionID, PollOptionText: fmt.Sprintf(stubOptionText, optionID)}
+
+		if _, ok := refs.polls[pollID]; !ok {
+			err := va.polls.addPoll(schema.Poll{
+				PollID:        pollID,
+				PollTitle:     fmt.Sprintf(stubPollTitle, pollID),
+				PollQuestion:  stubPollQuestion,
+				PollOptions:   []schema.PollOption{option},
+				MaxSelections: uint(len(optionIDs)),
+			})
+			if err != nil {
+				return "", fmt.Errorf("creating stub poll %d: %w", pollID, err)
+			}
+			refs.polls[pollID] = map[uint]bool{optionID: true}
+			created = append(created, fmt.Sprintf("poll %d with option %d", pollID, optionID))
+		} else if !refs.polls[pollID][optionID] {
+			if err := va.polls.addPollOption(pollID, option); err != nil {
+				return "", fmt.Errorf("creating stub option %d of poll %d: %w", optionID, pollID, err)
+			}
+			refs.polls[pollID][optionID] = true
+			created = append(created, fmt.Sprintf("option %d of poll %d", optionID, pollID))
+		}
+	}
+
+	return "created stub " + strings.Join(created, ", "), nil
+}
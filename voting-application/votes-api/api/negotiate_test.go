@@ -0,0 +1,156 @@
+package api
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"votes-api/votes"
+
+	"shared/negotiate"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// The XML of a vote, as far as the tests read it.
+type voteXML struct {
+	VoteID    uint   `xml:"voteId"`
+	VoterID   uint   `xml:"voterId"`
+	OptionIDs []uint `xml:"optionIds>optionId"`
+	VoteDate  string `xml:"voteDate"`
+	Links     struct {
+		Self struct {
+			Get struct {
+				URL string `xml:"url,attr"`
+			} `xml:"get"`
+			Update *struct{} `xml:"update"`
+		} `xml:"self"`
+		Voter *struct {
+			Get struct {
+				URL string `xml:"url,attr"`
+			} `xml:"get"`
+		} `xml:"voter"`
+	} `xml:"links"`
+}
+
+func TestVoteResponseXML(t *testing.T) {
+	castAt := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	list := negotiate.NewList("votes", []voteResponse{
+		newVoteResponse(votes.Vote{VoteID: 1, VoterID: 4, PollID: 2, VoteValue: 3, OptionIDs: []uint{3, 5}, VoteDate: &castAt}),
+		// An anonymous vote has no voter to link to.
+		newVoteResponse(votes.Vote{VoteID: 2, PollID: 2, VoteValue: 1}),
+	})
+
+	data, err := xml.Marshal(list)
+	if err != nil {
+		t.Fatalf("encoding votes as XML: %v", err)
+	}
+
+	var decoded struct {
+		XMLName xml.Name  `xml:"votes"`
+		Votes   []voteXML `xml:"vote"`
+	}
+	if err := xml.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("decoding %s: %v", data, err)
+	}
+
+	if len(decoded.Votes) != 2 {
+		t.Fatalf("XML has %d votes, want 2: %s", len(decoded.Votes), data)
+	}
+	first, anonymous := decoded.Votes[0], decoded.Votes[1]
+	if first.VoteID != 1 || len(first.OptionIDs) != 2 || first.OptionIDs[1] != 5 || first.VoteDate != "2026-10-14T12:00:00Z" {
+		t.Fatalf("XML vote = %+v", first)
+	}
+	if first.Links.Self.Get.URL != "/votes/1" || first.Links.Self.Update != nil {
+		t.Fatalf("XML self links = %+v, want get /votes/1 and no update", first.Links.Self)
+	}
+	if first.Links.Voter == nil || first.Links.Voter.Get.URL != "http://localhost:1080/voters/4" {
+		t.Fatalf("XML voter links = %+v, want the links of voter 4", first.Links.Voter)
+	}
+	if anonymous.Links.Voter != nil || anonymous.VoteDate != "" {
+		t.Fatalf("XML anonymous vote = %+v, want no voter links and no vote date", anonymous)
+	}
+}
+
+func TestVoteResponseJSONAndMsgpack(t *testing.T) {
+	response := newVoteResponse(votes.Vote{VoteID: 2, PollID: 2, VoteValue: 1})
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		t.Fatalf("encoding vote as JSON: %v", err)
+	}
+	var fromJSON map[string]interface{}
+	if err := json.Unmarshal(data, &fromJSON); err != nil {
+		t.Fatalf("decoding %s: %v", data, err)
+	}
+
+	data, err = msgpack.Marshal(response)
+	if err != nil {
+		t.Fatalf("encoding vote as msgpack: %v", err)
+	}
+	var fromMsgpack map[string]interface{}
+	if err := msgpack.Unmarshal(data, &fromMsgpack); err != nil {
+		t.Fatalf("decoding msgpack: %v", err)
+	}
+
+	for _, fromFormat := range []map[string]interface{}{fromJSON, fromMsgpack} {
+		if len(fromFormat) != 8 {
+			t.Fatalf("vote has %d fields, want 8: %v", len(fromFormat), fromFormat)
+		}
+		links := fromFormat["links"].(map[string]interface{})
+		if _, ok := links["voter"]; ok {
+			t.Fatalf("anonymous vote links to a voter: %v", links)
+		}
+		if _, ok := links["poll"]; !ok {
+			t.Fatalf("vote does not link to its poll: %v", links)
+		}
+	}
+}
+
+func TestPollResultsResponseXML(t *testing.T) {
+	expiresAt := time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)
+	response := pollResultsResponse{
+		PollID:     2,
+		PollTitle:  "Favorite pet",
+		PollStatus: "closed",
+		TotalVotes: 5,
+		Results:    []optionResult{{OptionID: 1, OptionText: "Dog", Votes: 3}, {OptionID: 2, OptionText: "Cat", Votes: 2}},
+		WriteIns:   &writeInTally{TotalVotes: 1, Results: []writeInResult{{Text: "fish", Votes: 1}}},
+		Meta:       map[string]interface{}{"access": "embargoed", "embargoed": true, "expiresAt": expiresAt},
+	}
+
+	data, err := xml.Marshal(response)
+	if err != nil {
+		t.Fatalf("encoding results as XML: %v", err)
+	}
+
+	var decoded struct {
+		XMLName xml.Name `xml:"pollResults"`
+		Results []struct {
+			OptionID uint `xml:"optionId"`
+			Votes    uint `xml:"votes"`
+		} `xml:"results>option"`
+		WriteIns []struct {
+			Text string `xml:"text"`
+		} `xml:"writeIns>results>writeIn"`
+		Meta struct {
+			Access    string    `xml:"access"`
+			Embargoed bool      `xml:"embargoed"`
+			ExpiresAt time.Time `xml:"expiresAt"`
+		} `xml:"meta"`
+	}
+	if err := xml.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("decoding %s: %v", data, err)
+	}
+
+	if len(decoded.Results) != 2 || decoded.Results[0].Votes != 3 {
+		t.Fatalf("XML results = %+v", decoded.Results)
+	}
+	if len(decoded.WriteIns) != 1 || decoded.WriteIns[0].Text != "fish" {
+		t.Fatalf("XML write-ins = %+v", decoded.WriteIns)
+	}
+	if decoded.Meta.Access != "embargoed" || !decoded.Meta.Embargoed || !decoded.Meta.ExpiresAt.Equal(expiresAt) {
+		t.Fatalf("XML meta = %+v", decoded.Meta)
+	}
+}
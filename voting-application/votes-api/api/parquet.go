@@ -0,0 +1,95 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"shared/export"
+
+	"github.com/gin-gonic/gin"
+	"github.com/parquet-go/parquet-go"
+)
+
+const (
+	FormatParquet = "parquet"
+
+	// Rows are written to the client in row groups of this many rows.
+	ParquetRowGroupSize = 10000
+)
+
+// parquetWriter writes the rows of a Parquet export, next to the CSV and
+// JSON writers of the export package. The columns are the fields of T,
+// named by their parquet tags.
+type parquetWriter[T any] struct {
+	c      *gin.Context
+	writer *parquet.GenericWriter[T]
+	group  []T
+	rows   int
+}
+
+// Start a Parquet export named name. Rows are compressed with Snappy and
+// sent one row group at a time, the footer that lists the row groups
+// follows the last one.
+func newParquetWriter[T any](c *gin.Context, name string) *parquetWriter[T] {
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+"."+FormatParquet))
+	c.Header("Content-Type", "application/vnd.apache.parquet")
+	c.Header("Trailer", export.RowsTrailer+", "+export.ErrorTrailer)
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Status(http.StatusOK)
+
+	return &parquetWriter[T]{
+		c:      c,
+		writer: parquet.NewGenericWriter[T](c.Writer, parquet.Compression(&parquet.Snappy)),
+		group:  make([]T, 0, ParquetRowGroupSize),
+	}
+}
+
+// Write a row.
+func (w *parquetWriter[T]) Write(row T) error {
+	w.group = append(w.group, row)
+	w.rows++
+
+	if len(w.group) == ParquetRowGroupSize {
+		return w.flush()
+	}
+
+	return nil
+}
+
+// Write the buffered rows as a row group and send it to the client.
+func (w *parquetWriter[T]) flush() error {
+	if len(w.group) > 0 {
+		if _, err := w.writer.Write(w.group); err != nil {
+			return err
+		}
+		w.group = w.group[:0]
+	}
+
+	if err := w.writer.Flush(); err != nil {
+		return err
+	}
+
+	w.c.Writer.Flush()
+
+	return nil
+}
+
+// Finish the export with the last row group and the footer. Like the
+// other formats, the row count and any error are sent as trailers.
+func (w *parquetWriter[T]) Close(err error) {
+	if flushErr := w.flush(); flushErr != nil && err == nil {
+		err = flushErr
+	}
+
+	if closeErr := w.writer.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+
+	w.c.Writer.Flush()
+
+	w.c.Writer.Header().Set(export.RowsTrailer, strconv.Itoa(w.rows))
+	if err != nil {
+		w.c.Writer.Header().Set(export.ErrorTrailer, err.Error())
+	}
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	schema "votes-api/Schema"
+)
+
+// errPollAPIUnavailable is returned when the poll API cannot be reached
+// or fails, as opposed to answering that a poll does not exist.
+var errPollAPIUnavailable = errors.New("poll API is unavailable")
+
+// cachedPoll is the last poll metadata received from the poll API.
+type cachedPoll struct {
+	poll      schema.Poll
+	fetchedAt time.Time
+}
+
+// pollMetadataCache keeps the last known metadata of every poll fetched
+// from the poll API, so read endpoints can keep serving while the poll API
+// is briefly down.
+type pollMetadataCache struct {
+	mu    sync.RWMutex
+	polls map[uint]cachedPoll
+}
+
+func newPollMetadataCache() *pollMetadataCache {
+	return &pollMetadataCache{polls: make(map[uint]cachedPoll)}
+}
+
+func (pmc *pollMetadataCache) store(polls ...schema.Poll) {
+	pmc.mu.Lock()
+	defer pmc.mu.Unlock()
+
+	now := time.Now()
+	for _, poll := range polls {
+		pmc.polls[poll.PollID] = cachedPoll{poll: poll, fetchedAt: now}
+	}
+}
+
+func (pmc *pollMetadataCache) load(pollID uint) (cachedPoll, bool) {
+	pmc.mu.RLock()
+	defer pmc.mu.RUnlock()
+
+	cached, found := pmc.polls[pollID]
+	return cached, found
+}
+
+// Fetch a poll for a read endpoint. When the poll API is unavailable the
+// last known metadata of the poll is returned instead and the meta of the
+// response is marked stale. Writes must use getPoll and fail instead.
+func (va *VotesAPI) getPollForRead(pollID uint, meta map[string]interface{}) (schema.Poll, error) {
+	poll, err := va.getPoll(pollID)
+	if !errors.Is(err, errPollAPIUnavailable) {
+		return poll, err
+	}
+
+	cached, found := va.pollMetadata.load(pollID)
+	if !found {
+		return schema.Poll{}, err
+	}
+
+	meta["stale"] = true
+	meta["pollFetchedAt"] = cached.fetchedAt
+
+	return cached.poll, nil
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"votes-api/votes"
+
+	"shared/apierror"
+	"shared/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+// confirmVoteRequest is the body of POST /votes/:id/confirm.
+type confirmVoteRequest struct {
+	ConfirmationToken string `json:"confirmationToken" binding:"required"`
+}
+
+// Load how long a prepared vote waits for its confirmation from
+// VOTE_HOLD_TTL.
+func loadVoteHoldTTL() time.Duration {
+	if ttl, err := time.ParseDuration(os.Getenv("VOTE_HOLD_TTL")); err == nil && ttl > 0 {
+		return ttl
+	}
+
+	return votes.DefaultVoteHoldTTL
+}
+
+// Return a 409 voteError when the ID of a vote, or the vote of its voter
+// in its poll, is held by a prepared vote that was not confirmed yet.
+func (va *VotesAPI) checkNotHeld(vote votes.Vote) error {
+	if va.votesCache == nil {
+		return nil
+	}
+
+	held, err := va.votesCache.VoteHeld(vote)
+	if err != nil {
+		log.Println("Error checking vote holds: ", err)
+		return &voteError{status: http.StatusInternalServerError}
+	}
+	if held {
+		return &voteError{status: http.StatusConflict, message: "Vote is prepared and waiting for its confirmation"}
+	}
+
+	return nil
+}
+
+// Implementation of POST /votes/:id/prepare.
+// Checks a vote like POST /votes/:id does and holds its ID, and the vote
+// of its voter in the poll, for VOTE_HOLD_TTL without casting it. The
+// response has the confirmationToken that POST /votes/:id/confirm casts
+// it with before expiresAt. A hold that is not confirmed expires on its
+// own, and as nothing else was changed, a client that goes away leaves no
+// vote behind. Ballot tokens are redeemed by POST /votes/:id only.
+func (va *VotesAPI) PrepareVote(c *gin.Context) {
+	if va.votesCache == nil {
+		apierror.Abort(c, http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, "Vote holds are unavailable, Redis is not connected")
+		return
+	}
+
+	vote, token, ok := bindVote(c)
+	if !ok {
+		return
+	}
+
+	if token != "" {
+		apierror.Abort(c, http.StatusUnprocessableEntity, apierror.CodeUnprocessable, "Votes with a ballot token cannot be prepared, cast them with POST /votes/:id")
+		return
+	}
+
+	if _, err := va.votesList.GetVote(vote.VoteID); err == nil {
+		apierror.Abort(c, http.StatusConflict, apierror.CodeConflict, "Vote already exists")
+		return
+	}
+
+	vote, _, _, err := va.validateVote(vote, "")
+	if err != nil {
+		abortWithVoteError(c, err)
+		return
+	}
+
+	hold, err := va.votesCache.HoldVote(vote, va.voteHoldTTL)
+	if errors.Is(err, votes.ErrVoteHeld) {
+		apierror.Abort(c, http.StatusConflict, apierror.CodeConflict, "Vote is prepared and waiting for its confirmation")
+		return
+	}
+	if err != nil {
+		log.Println("Error holding vote: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not prepare vote", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"voteId":            vote.VoteID,
+		"vote":              hold.Vote,
+		"confirmationToken": hold.ConfirmationToken,
+		"preparedAt":        hold.PreparedAt,
+		"expiresAt":         hold.ExpiresAt,
+	})
+}
+
+// Implementation of POST /votes/:id/confirm.
+// Casts a vote prepared by POST /votes/:id/prepare when the body has its
+// confirmationToken, and answers like POST /votes/:id. The vote is checked
+// again, as the poll may have closed or an option filled up since. A hold
+// that expired or was confirmed already is not found. When the vote cannot
+// be written the hold is kept, and the confirmation can be retried.
+func (va *VotesAPI) ConfirmVote(c *gin.Context) {
+	if va.votesCache == nil {
+		apierror.Abort(c, http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, "Vote holds are unavailable, Redis is not connected")
+		return
+	}
+
+	voteIDUint, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		log.Println("Error converting vote ID to uint: ", err)
+		apierror.AbortInvalidID(c, "Vote ID", err)
+		return
+	}
+
+	var request confirmVoteRequest
+	if err := validation.Bind(c, &request); err != nil {
+		log.Println("Error binding JSON: ", err)
+		return
+	}
+
+	hold, err := va.votesCache.TakeVoteHold(uint(voteIDUint), request.ConfirmationToken)
+	switch {
+	case errors.Is(err, votes.ErrVoteHoldNotFound):
+		apierror.Abort(c, http.StatusNotFound, apierror.CodeNotFound, "Prepared vote does not exist or has expired")
+		return
+	case errors.Is(err, votes.ErrVoteHoldToken):
+		apierror.Abort(c, http.StatusForbidden, apierror.CodeForbidden, "Confirmation token is not valid for this vote")
+		return
+	case err != nil:
+		log.Println("Error taking vote hold: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not confirm vote", err)
+		return
+	}
+
+	vote, err := va.castVote(hold.Vote, "")
+	if err != nil {
+		// The hold is released only once the vote and its history entry
+		// are written. After a server error it is put back so the
+		// confirmation can be retried; a refused vote is final.
+		var voteErr *voteError
+		if !errors.As(err, &voteErr) || voteErr.status >= http.StatusInternalServerError {
+			if restoreErr := va.votesCache.RestoreVoteHold(hold); restoreErr != nil {
+				log.Println("Error restoring vote hold: ", restoreErr)
+			}
+		}
+		abortWithVoteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, struct {
+		votes.Vote
+		Receipt string `json:"receipt"`
+	}{vote, va.signReceipt(vote)})
+}
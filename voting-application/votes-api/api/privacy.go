@@ -0,0 +1,124 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"votes-api/votes"
+)
+
+const (
+	PrivacyModeOff      = "off"
+	PrivacyModeSuppress = "suppress"
+	PrivacyModeNoise    = "noise"
+
+	DefaultPrivacyThreshold = 10
+	DefaultPrivacyEpsilon   = 1.0
+
+	PrivacyNoiseSecretKey = "privacy-noise-secret"
+)
+
+// privacyConfig controls how non-admin results of small polls are
+// protected against deanonymizing voters.
+type privacyConfig struct {
+	mode      string
+	threshold uint
+	epsilon   float64
+	// The secret the noise is drawn from, shared by every replica.
+	noiseKey []byte
+}
+
+// Load the results privacy configuration from the environment.
+// RESULTS_PRIVACY_MODE is off, suppress or noise; polls with fewer
+// participants than RESULTS_PRIVACY_THRESHOLD are protected, and
+// RESULTS_PRIVACY_EPSILON sets the strength of the Laplace noise, which is
+// drawn from RESULTS_PRIVACY_SECRET or the secret kept in redis.
+func loadPrivacyConfig(votesCache *votes.VotesCache) privacyConfig {
+	config := privacyConfig{
+		mode:      os.Getenv("RESULTS_PRIVACY_MODE"),
+		threshold: DefaultPrivacyThreshold,
+		epsilon:   DefaultPrivacyEpsilon,
+	}
+
+	if config.mode != PrivacyModeSuppress && config.mode != PrivacyModeNoise {
+		config.mode = PrivacyModeOff
+	}
+
+	if threshold, err := strconv.ParseUint(os.Getenv("RESULTS_PRIVACY_THRESHOLD"), 10, 32); err == nil {
+		config.threshold = uint(threshold)
+	}
+
+	if epsilon, err := strconv.ParseFloat(os.Getenv("RESULTS_PRIVACY_EPSILON"), 64); err == nil && epsilon > 0 {
+		config.epsilon = epsilon
+	}
+
+	if config.mode == PrivacyModeNoise {
+		config.noiseKey = loadSecret(votesCache, "RESULTS_PRIVACY_SECRET", PrivacyNoiseSecretKey)
+	}
+
+	return config
+}
+
+// Return the Laplace noise of an option of a poll with the tally results.
+// The noise is drawn from an HMAC of the poll, the option and the whole
+// tally, so every request for the same tally gets the same noise and
+// averaging many responses does not recover the counts. A new vote
+// changes the tally and draws new noise.
+func (pc privacyConfig) noise(pollID uint, results []optionResult, optionID uint) float64 {
+	tally := make([]string, len(results))
+	for i, result := range results {
+		tally[i] = fmt.Sprintf("%d=%d", result.OptionID, result.Votes)
+	}
+
+	mac := hmac.New(sha256.New, pc.noiseKey)
+	fmt.Fprintf(mac, "%d:%d:%s", pollID, optionID, strings.Join(tally, ","))
+	sum := mac.Sum(nil)
+
+	// The top 53 bits are uniform in (0, 1), never 0 or 1 themselves.
+	u := (float64(binary.BigEndian.Uint64(sum)>>11) + 0.5) / (1 << 53)
+
+	return laplaceNoise(1/pc.epsilon, u)
+}
+
+// Return the sample of a Laplace distribution centered on zero at the
+// uniform u in (0, 1).
+func laplaceNoise(scale, u float64) float64 {
+	u -= 0.5
+	if u < 0 {
+		return scale * math.Log(1+2*u)
+	}
+
+	return -scale * math.Log(1-2*u)
+}
+
+// Protect the results of a poll with too few participants. It returns the
+// results to publish and a description of the protection applied, or an
+// empty string when the results are published as they are.
+func (pc privacyConfig) protect(pollID uint, results []optionResult, totalVotes uint) ([]optionResult, string) {
+	if pc.mode == PrivacyModeOff || totalVotes >= pc.threshold {
+		return results, ""
+	}
+
+	if pc.mode == PrivacyModeSuppress {
+		return make([]optionResult, 0), PrivacyModeSuppress
+	}
+
+	noisyResults := make([]optionResult, len(results))
+	for i, result := range results {
+		noisyVotes := math.Round(float64(result.Votes) + pc.noise(pollID, results, result.OptionID))
+		if noisyVotes < 0 {
+			noisyVotes = 0
+		}
+
+		noisyResults[i] = result
+		noisyResults[i].Votes = uint(noisyVotes)
+	}
+
+	return noisyResults, PrivacyModeNoise
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testPrivacyConfig(key string) privacyConfig {
+	return privacyConfig{mode: PrivacyModeNoise, threshold: 10, epsilon: 1, noiseKey: []byte(key)}
+}
+
+func TestPrivacyNoiseIsTheSameForATally(t *testing.T) {
+	pc := testPrivacyConfig("secret")
+	results := []optionResult{{OptionID: 1, Votes: 3}, {OptionID: 2, Votes: 1}}
+
+	first, protection := pc.protect(7, results, 4)
+	if protection != PrivacyModeNoise {
+		t.Fatalf("protection = %q, want %q", protection, PrivacyModeNoise)
+	}
+
+	// Asking again gets the same noise, so averaging the responses does
+	// not recover the counts.
+	for i := 0; i < 20; i++ {
+		again, _ := pc.protect(7, results, 4)
+		if !reflect.DeepEqual(again, first) {
+			t.Fatalf("request %d got %+v, the first %+v", i, again, first)
+		}
+	}
+
+	// The results themselves are not changed.
+	if results[0].Votes != 3 || results[1].Votes != 1 {
+		t.Fatalf("protect changed the tally to %+v", results)
+	}
+}
+
+func TestPrivacyNoiseDependsOnThePollTallyAndSecret(t *testing.T) {
+	pc := testPrivacyConfig("secret")
+	results := []optionResult{{OptionID: 1, Votes: 3}, {OptionID: 2, Votes: 1}}
+	changed := []optionResult{{OptionID: 1, Votes: 3}, {OptionID: 2, Votes: 2}}
+
+	noise := pc.noise(7, results, 1)
+	for name, other := range map[string]float64{
+		"another poll":   pc.noise(8, results, 1),
+		"another option": pc.noise(7, results, 2),
+		"another tally":  pc.noise(7, changed, 1),
+		"another secret": testPrivacyConfig("other").noise(7, results, 1),
+	} {
+		if other == noise {
+			t.Errorf("%s has the same noise %v", name, noise)
+		}
+	}
+}
+
+func TestPrivacyNoiseIsLaplace(t *testing.T) {
+	if got := laplaceNoise(1, 0.5); got != 0 {
+		t.Fatalf("laplaceNoise(1, 0.5) = %v, want 0", got)
+	}
+	if low, high := laplaceNoise(2, 0.25), laplaceNoise(2, 0.75); low >= 0 || low != -high {
+		t.Fatalf("laplaceNoise(2, 0.25) = %v and laplaceNoise(2, 0.75) = %v, want opposites below and above 0", low, high)
+	}
+
+	// Over many tallies the noise is centered on zero.
+	pc := testPrivacyConfig("secret")
+	var sum float64
+	const tallies = 10000
+	for votes := uint(0); votes < tallies; votes++ {
+		sum += pc.noise(7, []optionResult{{OptionID: 1, Votes: votes}}, 1)
+	}
+	if mean := sum / tallies; mean < -0.1 || mean > 0.1 {
+		t.Fatalf("the mean noise is %v, want about 0", mean)
+	}
+}
+
+func TestPrivacyProtect(t *testing.T) {
+	results := []optionResult{{OptionID: 1, Votes: 3}}
+
+	// Polls at the threshold and the off mode are published as they are.
+	if got, protection := testPrivacyConfig("secret").protect(7, results, 10); protection != "" || !reflect.DeepEqual(got, results) {
+		t.Fatalf("protect at the threshold = %+v, %q", got, protection)
+	}
+	if got, protection := (privacyConfig{mode: PrivacyModeOff, threshold: 10}).protect(7, results, 3); protection != "" || !reflect.DeepEqual(got, results) {
+		t.Fatalf("protect with privacy off = %+v, %q", got, protection)
+	}
+
+	got, protection := (privacyConfig{mode: PrivacyModeSuppress, threshold: 10}).protect(7, results, 3)
+	if protection != PrivacyModeSuppress || len(got) != 0 {
+		t.Fatalf("protect in suppress mode = %+v, %q", got, protection)
+	}
+}
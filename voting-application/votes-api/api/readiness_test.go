@@ -0,0 +1,68 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-resty/resty/v2"
+)
+
+// Return a peer API that is alive but answers its readiness probe with
+// readyStatus.
+func newPeer(t *testing.T, readyStatus int) string {
+	t.Helper()
+
+	peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/healthz":
+			w.WriteHeader(http.StatusOK)
+		case "/readyz":
+			w.WriteHeader(readyStatus)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(peer.Close)
+
+	return peer.URL
+}
+
+func TestReadinessProbesThePeersReadiness(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	va := &VotesAPI{
+		apiClient:   resty.New(),
+		voterAPIURL: newPeer(t, http.StatusServiceUnavailable),
+		pollAPIURL:  newPeer(t, http.StatusOK),
+	}
+
+	r := gin.New()
+	r.GET("/readyz", va.Readiness)
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("GET /readyz = %d, want %d", recorder.Code, http.StatusServiceUnavailable)
+	}
+
+	var body struct {
+		Dependencies map[string]struct {
+			Status string `json:"status"`
+		} `json:"dependencies"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding %s: %v", recorder.Body, err)
+	}
+
+	// The voter API is alive but not ready, so it is down.
+	if got := body.Dependencies["voter-api"].Status; got != "down" {
+		t.Errorf("voter-api = %q, want down", got)
+	}
+	if got := body.Dependencies["poll-api"].Status; got != "up" {
+		t.Errorf("poll-api = %q, want up", got)
+	}
+}
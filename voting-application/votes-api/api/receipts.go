@@ -0,0 +1,203 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"votes-api/votes"
+
+	"shared/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	receiptVersion = "v1"
+
+	CodeInvalidReceipt = "invalid_receipt"
+	CodeVoteModified   = "vote_modified"
+)
+
+var errInvalidReceipt = errors.New("receipt is malformed or its signature does not match")
+
+// receipt is what a vote receipt proves: the vote as it was accepted.
+type receipt struct {
+	VoteID   uint      `json:"voteId"`
+	VoterID  uint      `json:"voterId"`
+	PollID   uint      `json:"pollId"`
+	OptionID uint      `json:"optionId"`
+	CastAt   time.Time `json:"castAt"`
+	// The options of a vote of a multi-choice poll.
+	OptionIDs []uint `json:"optionIds,omitempty"`
+	// The text of a write-in vote.
+	WriteInValue string `json:"writeInValue,omitempty"`
+}
+
+// Return the secret set in the env variable, or else the one kept in redis
+// at key so every replica uses the same. Without either the secret is
+// random and only lasts until the API restarts; receipts signed with it
+// stop verifying, and anonymous voters could vote again.
+func loadSecret(votesCache *votes.VotesCache, env, key string) []byte {
+	if secret := os.Getenv(env); secret != "" {
+		return []byte(secret)
+	}
+
+	secret, err := votesCache.Secret(key)
+	if err == nil {
+		return secret
+	}
+
+	log.Printf("Error loading %s, set %s so it survives a restart: %v", key, env, err)
+	secret = make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		log.Fatalf("Error generating %s: %v", key, err)
+	}
+
+	return secret
+}
+
+// Return the receipt of a vote: its fields and the time it was cast,
+// followed by their HMAC-SHA256. The options of a multi-choice vote and
+// the base64 text of a write-in come last, so the receipts of
+// single-choice votes keep their format.
+func (va *VotesAPI) signReceipt(vote votes.Vote) string {
+	var castAt int64
+	if vote.CreatedAt != nil {
+		castAt = vote.CreatedAt.UnixMicro()
+	}
+
+	payload := fmt.Sprintf("%s:%d:%d:%d:%d:%d", receiptVersion, vote.VoteID, vote.VoterID, vote.PollID, vote.VoteValue, castAt)
+	if len(vote.OptionIDs) > 0 || vote.WriteInValue != "" {
+		payload += ":" + formatOptionIDs(vote.OptionIDs)
+	}
+	if vote.WriteInValue != "" {
+		payload += ":" + base64.RawURLEncoding.EncodeToString([]byte(vote.WriteInValue))
+	}
+
+	mac := hmac.New(sha256.New, va.receiptKey)
+	mac.Write([]byte(payload))
+
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Check the signature of a receipt and return what it proves.
+func (va *VotesAPI) parseReceipt(token string) (receipt, error) {
+	encodedPayload, encodedMAC, found := strings.Cut(token, ".")
+	if !found {
+		return receipt{}, errInvalidReceipt
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return receipt{}, errInvalidReceipt
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encodedMAC)
+	if err != nil {
+		return receipt{}, errInvalidReceipt
+	}
+
+	mac := hmac.New(sha256.New, va.receiptKey)
+	mac.Write(payload)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return receipt{}, errInvalidReceipt
+	}
+
+	fields := strings.Split(string(payload), ":")
+	if len(fields) < 6 || len(fields) > 8 || fields[0] != receiptVersion {
+		return receipt{}, errInvalidReceipt
+	}
+
+	var ids [4]uint64
+	for i := range ids {
+		if ids[i], err = strconv.ParseUint(fields[i+1], 10, 32); err != nil {
+			return receipt{}, errInvalidReceipt
+		}
+	}
+
+	castAt, err := strconv.ParseInt(fields[5], 10, 64)
+	if err != nil {
+		return receipt{}, errInvalidReceipt
+	}
+
+	var optionIDs []uint
+	if len(fields) > 6 && fields[6] != "" {
+		for _, field := range strings.Split(fields[6], ",") {
+			optionID, err := strconv.ParseUint(field, 10, 32)
+			if err != nil {
+				return receipt{}, errInvalidReceipt
+			}
+			optionIDs = append(optionIDs, uint(optionID))
+		}
+	}
+
+	var writeIn []byte
+	if len(fields) == 8 {
+		if writeIn, err = base64.RawURLEncoding.DecodeString(fields[7]); err != nil {
+			return receipt{}, errInvalidReceipt
+		}
+	}
+
+	return receipt{
+		VoteID:       uint(ids[0]),
+		VoterID:      uint(ids[1]),
+		PollID:       uint(ids[2]),
+		OptionID:     uint(ids[3]),
+		CastAt:       time.UnixMicro(castAt).UTC(),
+		OptionIDs:    optionIDs,
+		WriteInValue: string(writeIn),
+	}, nil
+}
+
+// Return option IDs separated by commas.
+func formatOptionIDs(optionIDs []uint) string {
+	fields := make([]string, len(optionIDs))
+	for i, optionID := range optionIDs {
+		fields[i] = strconv.FormatUint(uint64(optionID), 10)
+	}
+
+	return strings.Join(fields, ",")
+}
+
+// Implementation of GET /votes/verify/:receipt.
+// Confirm that the vote of a receipt is still recorded as it was accepted.
+// Only the vote of the receipt is looked at and reported.
+func (va *VotesAPI) VerifyReceipt(c *gin.Context) {
+	proof, err := va.parseReceipt(c.Param("receipt"))
+	if err != nil {
+		apierror.AbortWithDetails(c, http.StatusBadRequest, CodeInvalidReceipt, "Receipt is not valid", err)
+		return
+	}
+
+	vote, err := va.votesList.GetVote(proof.VoteID)
+	if err != nil {
+		log.Println("Error getting vote of receipt: ", err)
+		apierror.AbortWithError(c, http.StatusNotFound, "The vote of the receipt is no longer recorded", err)
+		return
+	}
+
+	var castAt int64
+	if vote.CreatedAt != nil {
+		castAt = vote.CreatedAt.UnixMicro()
+	}
+
+	if vote.VoterID != proof.VoterID || vote.PollID != proof.PollID || vote.VoteValue != proof.OptionID || castAt != proof.CastAt.UnixMicro() || formatOptionIDs(vote.OptionIDs) != formatOptionIDs(proof.OptionIDs) || vote.WriteInValue != proof.WriteInValue {
+		apierror.Abort(c, http.StatusConflict, CodeVoteModified, "The vote of the receipt was changed since it was accepted")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"verified": true,
+		"vote":     proof,
+	})
+}
@@ -0,0 +1,266 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	schema "votes-api/Schema"
+	"votes-api/votes"
+
+	"shared/apierror"
+	"shared/seed"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The largest difference between the time of a vote and the date of its
+// vote history entry that still counts as the same date.
+const reconciliationTolerance = time.Second
+
+// dateMismatch is a vote whose vote history entry has another date.
+type dateMismatch struct {
+	VoteID      uint      `json:"voteId"`
+	VoterID     uint      `json:"voterId"`
+	VoteTime    time.Time `json:"voteTime"`
+	HistoryDate time.Time `json:"historyDate"`
+}
+
+// missingHistory is a vote without a vote history entry.
+type missingHistory struct {
+	VoteID  uint `json:"voteId"`
+	VoterID uint `json:"voterId"`
+}
+
+// orphanedHistory is a vote history entry without a vote.
+type orphanedHistory struct {
+	VoterID     uint      `json:"voterId"`
+	HistoryDate time.Time `json:"historyDate"`
+}
+
+// reconciliation compares the votes of a poll with the vote histories of
+// the voter API.
+type reconciliation struct {
+	PollID         uint              `json:"pollId"`
+	Fixed          bool              `json:"fixed"`
+	Votes          int               `json:"votes"`
+	HistoryEntries int               `json:"historyEntries"`
+	DateMismatches []dateMismatch    `json:"dateMismatches"`
+	MissingHistory []missingHistory  `json:"missingHistory"`
+	Orphaned       []orphanedHistory `json:"orphanedHistory"`
+	// Votes cast before votes had timestamps, whose dates cannot be compared.
+	Undated int `json:"undated"`
+	// The voters of the votes without a history entry that do not exist.
+	UnknownVoters []uint `json:"unknownVoters"`
+	// The import policy applied to them by POST, and the outcome of every
+	// missing history entry.
+	Policy string     `json:"policy,omitempty"`
+	Rows   []seed.Row `json:"rows,omitempty"`
+}
+
+// Implementation of GET and POST /admin/reconciliation?pollId=.
+// Cross-check the votes of a poll with the vote histories of the voter API
+// and report the votes whose history entry has another date, the votes
+// without a history entry and the history entries without a vote. POST
+// also fixes them, taking the votes as the truth: history entries are
+// added for votes, redated to the time of their vote, or removed. The
+// ?onMissing= import policy decides what happens to the votes of voters
+// that do not exist.
+func (va *VotesAPI) ReconcilePoll(c *gin.Context) {
+	pollIDUint, err := strconv.ParseUint(c.Query("pollId"), 10, 32)
+	if err != nil {
+		log.Println("Error converting poll ID to uint: ", err)
+		apierror.AbortInvalidID(c, "Poll ID", err)
+		return
+	}
+
+	report := reconciliation{
+		PollID:         uint(pollIDUint),
+		Fixed:          c.Request.Method == http.MethodPost,
+		DateMismatches: make([]dateMismatch, 0),
+		MissingHistory: make([]missingHistory, 0),
+		Orphaned:       make([]orphanedHistory, 0),
+		UnknownVoters:  make([]uint, 0),
+	}
+
+	if report.Fixed {
+		policy, err := seed.ParsePolicy(c.Query("onMissing"))
+		if err != nil {
+			apierror.Abort(c, http.StatusBadRequest, apierror.CodeBadRequest, err.Error())
+			return
+		}
+		report.Policy = policy
+	}
+
+	pollVotes := make(map[uint]votes.Vote)
+	anonymous := false
+	err = va.votesList.EachVote(func(vote votes.Vote) error {
+		if vote.PollID == report.PollID {
+			pollVotes[vote.VoterID] = vote
+			anonymous = anonymous || vote.VoterHash != ""
+			report.Votes++
+		}
+		return nil
+	})
+	if err != nil {
+		log.Println("Error getting votes: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not get votes", err)
+		return
+	}
+
+	// The votes of an anonymous poll cannot be matched with the histories,
+	// every entry would look orphaned.
+	if anonymous {
+		apierror.Abort(c, http.StatusUnprocessableEntity, apierror.CodeUnprocessable, "The votes of an anonymous poll cannot be reconciled with the voter histories")
+		return
+	}
+
+	voters, err := va.voters.listVoters()
+	if err != nil {
+		log.Println("Error getting voters: ", err)
+		if voteErr := unreachableError(err); voteErr != nil {
+			abortWithVoteError(c, voteErr)
+			return
+		}
+		apierror.AbortWithError(c, http.StatusBadGateway, "Could not get the vote history of the voters", err)
+		return
+	}
+
+	knownVoters := make(map[uint]bool, len(voters))
+	historyDates := make(map[uint]time.Time)
+	for _, voter := range voters {
+		knownVoters[voter.VoterID] = true
+		for _, entry := range voter.VoteHistory {
+			if entry.PollID == report.PollID {
+				historyDates[voter.VoterID] = entry.VoteDate
+			}
+		}
+	}
+	report.HistoryEntries = len(historyDates)
+
+	for voterID, vote := range pollVotes {
+		historyDate, ok := historyDates[voterID]
+		switch {
+		case !ok:
+			report.MissingHistory = append(report.MissingHistory, missingHistory{VoteID: vote.VoteID, VoterID: voterID})
+			if !knownVoters[voterID] {
+				report.UnknownVoters = append(report.UnknownVoters, voterID)
+			}
+		case vote.CastAt() == nil:
+			report.Undated++
+		case absDuration(vote.CastAt().Sub(historyDate)) > reconciliationTolerance:
+			report.DateMismatches = append(report.DateMismatches, dateMismatch{
+				VoteID:      vote.VoteID,
+				VoterID:     voterID,
+				VoteTime:    *vote.CastAt(),
+				HistoryDate: historyDate,
+			})
+		}
+	}
+
+	for voterID, historyDate := range historyDates {
+		if _, ok := pollVotes[voterID]; !ok {
+			report.Orphaned = append(report.Orphaned, orphanedHistory{VoterID: voterID, HistoryDate: historyDate})
+		}
+	}
+
+	sort.Slice(report.DateMismatches, func(i, j int) bool { return report.DateMismatches[i].VoterID < report.DateMismatches[j].VoterID })
+	sort.Slice(report.MissingHistory, func(i, j int) bool { return report.MissingHistory[i].VoterID < report.MissingHistory[j].VoterID })
+	sort.Slice(report.Orphaned, func(i, j int) bool { return report.Orphaned[i].VoterID < report.Orphaned[j].VoterID })
+	sort.Slice(report.UnknownVoters, func(i, j int) bool { return report.UnknownVoters[i] < report.UnknownVoters[j] })
+
+	if report.Fixed && report.Policy == seed.PolicyRejectAll && len(report.UnknownVoters) > 0 {
+		report.Fixed = false
+		for _, missing := range report.MissingHistory {
+			reason := "another vote of the poll has a voter that does not exist"
+			if !knownVoters[missing.VoterID] {
+				reason = fmt.Sprintf("voter %d does not exist", missing.VoterID)
+			}
+			report.Row("vote", missing.VoteID, seed.RowRejected, reason)
+		}
+		apierror.AbortWithDetails(c, http.StatusUnprocessableEntity, apierror.CodeUnprocessable, "Some votes of the poll have voters that do not exist, nothing was fixed", report)
+		return
+	}
+
+	if report.Fixed {
+		if err := va.fixReconciliation(&report, pollVotes, knownVoters); err != nil {
+			log.Println("Error fixing vote histories: ", err)
+			if voteErr := unreachableError(err); voteErr != nil {
+				abortWithVoteError(c, voteErr)
+				return
+			}
+			apierror.AbortWithError(c, http.StatusBadGateway, "Could not fix the vote histories", err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// Record the outcome of a missing history entry.
+func (r *reconciliation) Row(kind string, id uint, outcome, reason string) {
+	r.Rows = append(r.Rows, seed.Row{Kind: kind, ID: id, Outcome: outcome, Reason: reason})
+}
+
+// Bring the vote histories in line with the votes of a reconciliation.
+// Redating an entry removes and adds it again, which works over REST and
+// gRPC alike. The entries of unknown voters are skipped or get a stub
+// voter, as the policy of the reconciliation says.
+func (va *VotesAPI) fixReconciliation(report *reconciliation, pollVotes map[uint]votes.Vote, knownVoters map[uint]bool) error {
+	for _, missing := range report.MissingHistory {
+		outcome, reason := seed.RowCreated, ""
+		if !knownVoters[missing.VoterID] {
+			reason = fmt.Sprintf("voter %d does not exist", missing.VoterID)
+			if report.Policy != seed.PolicyAutoCreateStub {
+				report.Row("vote", missing.VoteID, seed.RowSkipped, reason)
+				continue
+			}
+
+			voter := schema.Voter{VoterID: missing.VoterID, FirstName: stubFirstName, LastName: stubLastName}
+			if err := va.voters.addVoter(voter); err != nil {
+				return fmt.Errorf("creating stub voter %d: %w", missing.VoterID, err)
+			}
+			outcome, reason = seed.RowStubbed, fmt.Sprintf("created stub voter %d", missing.VoterID)
+		}
+
+		voteDate := time.Now().UTC()
+		if castAt := pollVotes[missing.VoterID].CastAt(); castAt != nil {
+			voteDate = *castAt
+		}
+
+		if err := va.voters.addVoterPoll(missing.VoterID, report.PollID, voteDate); err != nil {
+			return err
+		}
+		report.Row("vote", missing.VoteID, outcome, reason)
+	}
+
+	for _, mismatch := range report.DateMismatches {
+		if err := va.voters.deleteVoterPoll(mismatch.VoterID, report.PollID); err != nil {
+			return err
+		}
+
+		if err := va.voters.addVoterPoll(mismatch.VoterID, report.PollID, mismatch.VoteTime); err != nil {
+			return err
+		}
+	}
+
+	for _, orphaned := range report.Orphaned {
+		if err := va.voters.deleteVoterPoll(orphaned.VoterID, report.PollID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Return the absolute value of a duration.
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+
+	return d
+}
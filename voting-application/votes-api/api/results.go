@@ -0,0 +1,240 @@
+package api
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+
+	schema "votes-api/Schema"
+	"votes-api/votes"
+
+	"shared/apierror"
+	"shared/negotiate"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	EmbargoTokenHeader = "X-Embargo-Token"
+)
+
+// optionResult is the number of votes an option received.
+type optionResult struct {
+	OptionID   uint   `json:"optionId" xml:"optionId" msgpack:"optionId"`
+	OptionText string `json:"optionText" xml:"optionText" msgpack:"optionText"`
+	Votes      uint   `json:"votes" xml:"votes" msgpack:"votes"`
+}
+
+// pollResultsResponse is the results of a poll as GET
+// /votes/results/:pollId shows them, in JSON, XML or msgpack.
+type pollResultsResponse struct {
+	XMLName    xml.Name       `json:"-" xml:"pollResults" msgpack:"-"`
+	PollID     uint           `json:"pollId" xml:"pollId" msgpack:"pollId"`
+	PollTitle  string         `json:"pollTitle" xml:"pollTitle" msgpack:"pollTitle"`
+	PollStatus string         `json:"pollStatus" xml:"pollStatus" msgpack:"pollStatus"`
+	TotalVotes uint           `json:"totalVotes" xml:"totalVotes" msgpack:"totalVotes"`
+	Results    []optionResult `json:"results" xml:"results>option" msgpack:"results"`
+	// Only polls that allow write-ins have them.
+	WriteIns *writeInTally `json:"writeIns,omitempty" xml:"writeIns,omitempty" msgpack:"writeIns,omitempty"`
+	Meta     resultsMeta   `json:"meta" xml:"meta" msgpack:"meta"`
+}
+
+// resultsMeta is the meta of the results of a poll, such as the access of
+// the caller; in XML every key is an element, in key order.
+type resultsMeta map[string]interface{}
+
+func (m resultsMeta) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if err := e.EncodeElement(m[key], xml.StartElement{Name: xml.Name{Local: key}}); err != nil {
+			return err
+		}
+	}
+
+	return e.EncodeToken(start.End())
+}
+
+// Fetch a single poll from the poll API.
+func (va *VotesAPI) getPoll(pollID uint) (schema.Poll, error) {
+	poll, err := va.polls.getPoll(pollID)
+	if err != nil {
+		return schema.Poll{}, err
+	}
+
+	va.pollMetadata.store(poll)
+
+	return poll, nil
+}
+
+// Count the votes of a poll per option, from the live option counts of
+// the store.
+func (va *VotesAPI) tallyPoll(poll schema.Poll) ([]optionResult, uint, error) {
+	counts, err := va.votesList.GetOptionCounts(poll.PollID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var totalVotes uint
+	for _, count := range counts {
+		totalVotes += count
+	}
+
+	results := make([]optionResult, len(poll.PollOptions))
+	for i, option := range poll.PollOptions {
+		results[i] = optionResult{
+			OptionID:   option.PollOptionID,
+			OptionText: option.PollOptionText,
+			Votes:      counts[option.PollOptionID],
+		}
+	}
+
+	return results, totalVotes, nil
+}
+
+// Implementation of GET /votes/results/:pollId.
+// Returns the per-option results of a poll. Results are public once the
+// poll is certified; before that they are only available to admins or,
+// for closed polls, to holders of an embargo token.
+func (va *VotesAPI) GetPollResults(c *gin.Context) {
+	pollID := c.Param("pollId")
+	pollIDUint, err := strconv.ParseUint(pollID, 10, 32)
+	if err != nil {
+		log.Println("Error converting poll ID to uint: ", err)
+		apierror.AbortInvalidID(c, "Poll ID", err)
+		return
+	}
+
+	meta := map[string]interface{}{
+		"access": "public",
+	}
+
+	poll, err := va.getPollForRead(uint(pollIDUint), meta)
+	if err != nil {
+		log.Println("Error getting poll: ", err)
+		if errors.Is(err, errPollAPIUnavailable) {
+			apierror.AbortWithDetails(c, http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, "Poll API is unavailable", err)
+			return
+		}
+		apierror.AbortWithDetails(c, http.StatusNotFound, apierror.CodeNotFound, "Could not find poll in cache", err)
+		return
+	}
+
+	if !va.authorizeResults(c, poll, meta) {
+		return
+	}
+
+	results, totalVotes, err := va.tallyPoll(poll)
+	if err != nil {
+		log.Println("Error tallying poll: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not tally poll", err)
+		return
+	}
+
+	// Exact counts are reserved for admins; everyone else gets results of
+	// small polls protected according to the privacy configuration.
+	if meta["access"] != "admin" {
+		var protection string
+		results, protection = va.privacy.protect(poll.PollID, results, totalVotes)
+		if protection != "" {
+			meta["privacy"] = protection
+			meta["privacyThreshold"] = va.privacy.threshold
+			totalVotes = 0
+			for _, result := range results {
+				totalVotes += result.Votes
+			}
+		}
+	}
+
+	response := pollResultsResponse{
+		PollID:     poll.PollID,
+		PollTitle:  poll.PollTitle,
+		PollStatus: poll.PollStatus,
+		TotalVotes: totalVotes,
+		Results:    results,
+		Meta:       meta,
+	}
+
+	if poll.AllowWriteIn {
+		// Admins see every write-in, others only the ones that reached
+		// the minimum count.
+		minCount := va.writeInMinCount
+		if meta["access"] == "admin" {
+			minCount = 1
+		}
+
+		writeIns, err := va.tallyWriteIns(poll.PollID, minCount)
+		if err != nil {
+			log.Println("Error tallying write-ins: ", err)
+			apierror.AbortWithError(c, http.StatusInternalServerError, "Could not tally poll", err)
+			return
+		}
+
+		response.WriteIns = &writeIns
+		meta["writeInMinCount"] = minCount
+	}
+
+	negotiate.Respond(c, http.StatusOK, response)
+}
+
+// Check that the caller may read the results of a poll, setting the
+// access of meta: admins always may, everyone once the poll is certified,
+// and holders of an embargo token while it is closed. Otherwise it
+// answers 403 and returns false.
+func (va *VotesAPI) authorizeResults(c *gin.Context, poll schema.Poll, meta map[string]interface{}) bool {
+	if isAdminRequest(c) {
+		meta["access"] = "admin"
+		return true
+	}
+
+	if poll.PollStatus == "certified" {
+		return true
+	}
+
+	embargoToken, ok := va.checkEmbargoToken(c, poll)
+	if !ok {
+		apierror.Abort(c, http.StatusForbidden, apierror.CodeForbidden, "Poll results have not been released")
+		return false
+	}
+
+	meta["access"] = "embargoed"
+	meta["embargoed"] = true
+	meta["issuedTo"] = embargoToken.IssuedTo
+	meta["expiresAt"] = embargoToken.ExpiresAt
+	meta["watermark"] = fmt.Sprintf("EMBARGOED - issued to %s, not for publication before certification", embargoToken.IssuedTo)
+
+	return true
+}
+
+// Validate the embargo token sent with a results request. Tokens only
+// grant access to the poll they were issued for, and only while the poll
+// is closed but not yet certified.
+func (va *VotesAPI) checkEmbargoToken(c *gin.Context, poll schema.Poll) (votes.EmbargoToken, bool) {
+	token := c.GetHeader(EmbargoTokenHeader)
+	if token == "" {
+		token = c.Query("embargoToken")
+	}
+
+	if token == "" || poll.PollStatus != "closed" {
+		return votes.EmbargoToken{}, false
+	}
+
+	embargoToken, err := va.votesCache.GetEmbargoToken(token)
+	if err != nil || embargoToken.PollID != poll.PollID {
+		return votes.EmbargoToken{}, false
+	}
+
+	return embargoToken, true
+}
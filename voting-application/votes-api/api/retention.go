@@ -0,0 +1,188 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	schema "votes-api/Schema"
+
+	"shared/apierror"
+	"shared/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultRetentionInterval = time.Hour
+)
+
+// retentionConfig holds how long votes are kept. A zero duration keeps
+// the records forever.
+type retentionConfig struct {
+	interval     time.Duration
+	votes        time.Duration
+	flaggedVotes time.Duration
+}
+
+// purgeCandidate is a vote the next janitor run would remove.
+type purgeCandidate struct {
+	VoteID uint   `json:"voteId"`
+	PollID uint   `json:"pollId"`
+	Reason string `json:"reason"`
+}
+
+// Read a number of days from the environment as a duration.
+func daysFromEnv(name string) time.Duration {
+	days, err := strconv.ParseUint(os.Getenv(name), 10, 32)
+	if err != nil {
+		return 0
+	}
+
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// Load the retention configuration from the environment.
+// RETENTION_VOTES_DAYS keeps votes for N days after their poll is
+// certified and RETENTION_FLAGGED_VOTES_DAYS keeps flagged votes for K days
+// after they were flagged. RETENTION_INTERVAL sets how often the janitor runs.
+func loadRetentionConfig() retentionConfig {
+	config := retentionConfig{
+		interval:     DefaultRetentionInterval,
+		votes:        daysFromEnv("RETENTION_VOTES_DAYS"),
+		flaggedVotes: daysFromEnv("RETENTION_FLAGGED_VOTES_DAYS"),
+	}
+
+	if interval, err := time.ParseDuration(os.Getenv("RETENTION_INTERVAL")); err == nil && interval > 0 {
+		config.interval = interval
+	}
+
+	return config
+}
+
+// Fetch all polls from the poll API.
+func (va *VotesAPI) getAllPolls() ([]schema.Poll, error) {
+	polls, err := va.polls.listPolls()
+	if err != nil {
+		return nil, err
+	}
+
+	va.pollMetadata.store(polls...)
+
+	return polls, nil
+}
+
+// Work out which votes are past their retention period.
+func (va *VotesAPI) retentionCandidates(now time.Time) ([]purgeCandidate, error) {
+	candidates := make([]purgeCandidate, 0)
+
+	if va.retention.votes == 0 && va.retention.flaggedVotes == 0 {
+		return candidates, nil
+	}
+
+	allVotes, err := va.votesList.GetAllVotes()
+	if err != nil {
+		return nil, err
+	}
+
+	certifiedAt := make(map[uint]time.Time)
+	if va.retention.votes > 0 {
+		polls, err := va.getAllPolls()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, poll := range polls {
+			if poll.CertifiedAt != nil {
+				certifiedAt[poll.PollID] = *poll.CertifiedAt
+			}
+		}
+	}
+
+	for _, vote := range allVotes {
+		if va.retention.flaggedVotes > 0 && vote.FlaggedAt != nil && now.Sub(*vote.FlaggedAt) > va.retention.flaggedVotes {
+			candidates = append(candidates, purgeCandidate{VoteID: vote.VoteID, PollID: vote.PollID, Reason: "flagged vote retention expired"})
+			continue
+		}
+
+		if certified, ok := certifiedAt[vote.PollID]; ok && va.retention.votes > 0 && now.Sub(certified) > va.retention.votes {
+			candidates = append(candidates, purgeCandidate{VoteID: vote.VoteID, PollID: vote.PollID, Reason: "vote retention after certification expired"})
+		}
+	}
+
+	return candidates, nil
+}
+
+// Purge every vote past its retention period.
+func (va *VotesAPI) runRetention(ctx context.Context) error {
+	candidates, err := va.retentionCandidates(time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, candidate := range candidates {
+		if err := va.votesList.DeleteVote(candidate.VoteID); err != nil {
+			log.Println("Error purging vote: ", err)
+			continue
+		}
+	}
+
+	if len(candidates) > 0 {
+		log.Printf("Retention janitor purged %d votes", len(candidates))
+	}
+
+	return nil
+}
+
+// Implementation of GET /admin/retention/report.
+// Dry run of the retention janitor, lists what the next run would purge.
+func (va *VotesAPI) GetRetentionReport(c *gin.Context) {
+	candidates, err := va.retentionCandidates(time.Now())
+	if err != nil {
+		log.Println("Error computing retention candidates: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not compute retention candidates", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"policy": gin.H{
+			"votesDaysAfterCertification": va.retention.votes.Hours() / 24,
+			"flaggedVotesDays":            va.retention.flaggedVotes.Hours() / 24,
+			"interval":                    va.retention.interval.String(),
+		},
+		"votes": candidates,
+	})
+}
+
+// Implementation of POST /admin/votes/:id/flag.
+// Flag a vote for review.
+func (va *VotesAPI) FlagVote(c *gin.Context) {
+	voteID := c.Param("id")
+	voteIDUint, err := strconv.ParseUint(voteID, 10, 32)
+	if err != nil {
+		log.Println("Error converting vote ID to uint: ", err)
+		apierror.AbortInvalidID(c, "Vote ID", err)
+		return
+	}
+
+	var requestBody struct {
+		Reason string `json:"reason"`
+	}
+
+	if err := validation.Bind(c, &requestBody); err != nil {
+		log.Println("Error parsing JSON request body: ", err)
+		return
+	}
+
+	flaggedVote, err := va.votesList.FlagVote(uint(voteIDUint), requestBody.Reason)
+	if err != nil {
+		log.Println("Error flagging vote: ", err)
+		apierror.AbortWithError(c, http.StatusNotFound, "Could not flag vote", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, flaggedVote)
+}
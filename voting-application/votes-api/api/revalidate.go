@@ -0,0 +1,119 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"votes-api/votes"
+
+	"shared/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// invalidVote is a recorded vote that no longer matches its poll.
+type invalidVote struct {
+	Vote   votes.Vote `json:"vote"`
+	Reason string     `json:"reason"`
+	Voided bool       `json:"voided"`
+}
+
+// Implementation of POST /admin/polls/:pollId/revalidate?void=true.
+// Re-validate every vote of a poll against its current options. Votes for
+// options that no longer exist are reported and, with void=true, deleted
+// together with the poll entry in the voter's vote history so the voter
+// can vote again. The votes of a poll whose results are frozen are only
+// reported.
+func (va *VotesAPI) RevalidatePollVotes(c *gin.Context) {
+	pollID := c.Param("pollId")
+	pollIDUint, err := strconv.ParseUint(pollID, 10, 32)
+	if err != nil {
+		log.Println("Error converting poll ID to uint: ", err)
+		apierror.AbortInvalidID(c, "Poll ID", err)
+		return
+	}
+
+	void := c.Query("void") == "true"
+
+	poll, err := va.getPoll(uint(pollIDUint))
+	if err != nil {
+		log.Println("Error getting poll: ", err)
+		apierror.AbortWithDetails(c, http.StatusNotFound, apierror.CodeNotFound, "Could not find poll in cache", err)
+		return
+	}
+
+	if void {
+		if err := va.checkPollNotFrozen(poll.PollID); err != nil {
+			abortWithVoteError(c, err)
+			return
+		}
+	}
+
+	options := make(map[uint]bool, len(poll.PollOptions))
+	for _, option := range poll.PollOptions {
+		options[option.PollOptionID] = true
+	}
+
+	allVotes, err := va.votesList.GetAllVotes()
+	if err != nil {
+		log.Println("Error getting votes: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not get votes", err)
+		return
+	}
+
+	checked := 0
+	invalid := make([]invalidVote, 0)
+	for _, vote := range allVotes {
+		if vote.PollID != poll.PollID {
+			continue
+		}
+
+		checked++
+		valid := true
+		for _, optionID := range vote.Options() {
+			valid = valid && options[optionID]
+		}
+		if valid {
+			continue
+		}
+
+		result := invalidVote{Vote: vote, Reason: "poll option does not exist"}
+		if void {
+			result.Voided = va.voidVote(vote)
+		}
+
+		invalid = append(invalid, result)
+	}
+
+	if len(invalid) > 0 {
+		log.Printf("Admin revalidated poll %d: %d of %d votes invalid, void=%t", poll.PollID, len(invalid), checked, void)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pollId":       poll.PollID,
+		"checkedVotes": checked,
+		"invalidVotes": invalid,
+		"void":         void,
+	})
+}
+
+// Delete an invalid vote and release the voter's vote history entry.
+// It reports whether the vote was voided.
+func (va *VotesAPI) voidVote(vote votes.Vote) bool {
+	if vote.VoterID != 0 {
+		if err := va.voters.deleteVoterPoll(vote.VoterID, vote.PollID); err != nil {
+			log.Println("Error removing voided vote from voter's vote history: ", err)
+			return false
+		}
+	}
+
+	if err := va.votesList.DeleteVote(vote.VoteID); err != nil {
+		log.Println("Error voiding vote: ", err)
+		return false
+	}
+
+	va.publishVoteDeleted(vote)
+
+	return true
+}
@@ -0,0 +1,197 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"votes-api/votes"
+
+	"shared/seed"
+)
+
+const (
+	// How many times the votes of a fixture are tried while the voter or
+	// poll API cannot be reached or has not seeded its part yet, as when
+	// the services start together.
+	seedAttempts  = 5
+	seedRetryWait = 2 * time.Second
+)
+
+// Cast the votes of a fixture, checked against the voter and poll APIs
+// like any other vote. Votes that already exist are skipped; votes that
+// failed because an API could not be reached are tried again. The votes
+// whose voter, poll or option is still missing once the APIs had the time
+// to seed are handled by the import policy of the fixture, and the report
+// lists the outcome of every vote.
+func (va *VotesAPI) Seed(ctx context.Context, fixture *seed.Fixture) seed.Report {
+	report := seed.NewReport()
+
+	policy, err := seed.ParsePolicy(fixture.OnMissing)
+	if err != nil {
+		for _, v := range fixture.Votes {
+			report.Failed("vote", v.VoteID, err)
+			report.Row("vote", v.VoteID, seed.RowFailed, err.Error())
+		}
+		return report
+	}
+	report.Policy = policy
+
+	var pending []seed.Vote
+	for _, v := range fixture.Votes {
+		if _, err := va.votesList.GetVote(v.VoteID); err == nil {
+			report.Skipped("vote")
+			report.Row("vote", v.VoteID, seed.RowExisting, "")
+			continue
+		}
+		pending = append(pending, v)
+	}
+
+	if len(pending) == 0 {
+		return report
+	}
+
+	refs, missing, err := va.resolveReferences(ctx, pending)
+	if err != nil {
+		for _, v := range pending {
+			report.Failed("vote", v.VoteID, err)
+			report.Row("vote", v.VoteID, seed.RowFailed, err.Error())
+		}
+		return report
+	}
+
+	stubbed := make(map[uint]string)
+	if len(missing) > 0 {
+		switch policy {
+		case seed.PolicyRejectAll:
+			for _, v := range pending {
+				reason, ok := missing[v.VoteID]
+				if !ok {
+					reason = "another vote of the fixture references a missing voter, poll or option"
+				}
+				report.Failed("vote", v.VoteID, errors.New(reason))
+				report.Row("vote", v.VoteID, seed.RowRejected, reason)
+			}
+			return report
+
+		case seed.PolicySkipAndReport:
+			var found []seed.Vote
+			for _, v := range pending {
+				if reason, ok := missing[v.VoteID]; ok {
+					report.Skipped("vote")
+					report.Row("vote", v.VoteID, seed.RowSkipped, reason)
+					continue
+				}
+				found = append(found, v)
+			}
+			pending = found
+
+		case seed.PolicyAutoCreateStub:
+			var found []seed.Vote
+			for _, v := range pending {
+				if _, ok := missing[v.VoteID]; ok {
+					reason, err := va.createStubs(refs, v.VoterID, v.PollID, v.Options())
+					if err != nil {
+						report.Failed("vote", v.VoteID, err)
+						report.Row("vote", v.VoteID, seed.RowFailed, err.Error())
+						continue
+					}
+					stubbed[v.VoteID] = reason
+				}
+				found = append(found, v)
+			}
+			pending = found
+		}
+	}
+
+	for attempt := 1; len(pending) > 0; attempt++ {
+		var retry []seed.Vote
+		var lastErr error
+
+		for _, v := range pending {
+			_, err := va.castVote(votes.Vote{
+				VoteID:       v.VoteID,
+				VoterID:      v.VoterID,
+				PollID:       v.PollID,
+				VoteValue:    v.OptionID,
+				OptionIDs:    v.OptionIDs,
+				WriteInValue: v.WriteInValue,
+			}, "")
+			if err == nil {
+				report.Created("vote")
+				if reason, ok := stubbed[v.VoteID]; ok {
+					report.Row("vote", v.VoteID, seed.RowStubbed, reason)
+				} else {
+					report.Row("vote", v.VoteID, seed.RowCreated, "")
+				}
+				continue
+			}
+
+			var voteErr *voteError
+			if errors.As(err, &voteErr) && isRetryableSeed(voteErr.status) && attempt < seedAttempts {
+				retry = append(retry, v)
+				lastErr = err
+				continue
+			}
+
+			report.Failed("vote", v.VoteID, err)
+			report.Row("vote", v.VoteID, seed.RowFailed, err.Error())
+		}
+
+		pending = retry
+		if len(pending) == 0 {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			for _, v := range pending {
+				report.Failed("vote", v.VoteID, lastErr)
+				report.Row("vote", v.VoteID, seed.RowFailed, lastErr.Error())
+			}
+			return report
+		case <-time.After(seedRetryWait):
+		}
+	}
+
+	return report
+}
+
+// Return the voters, polls and options of the voter and poll APIs and why
+// each vote that references a missing one cannot be cast, by vote ID. While
+// some are missing or an API cannot be reached they are loaded again, up to
+// seedAttempts times.
+func (va *VotesAPI) resolveReferences(ctx context.Context, pending []seed.Vote) (references, map[uint]string, error) {
+	for attempt := 1; ; attempt++ {
+		refs, err := va.loadReferences()
+		if err != nil && (unreachableError(err) == nil || attempt >= seedAttempts) {
+			return refs, nil, err
+		}
+
+		missing := make(map[uint]string)
+		if err == nil {
+			for _, v := range pending {
+				if reason := refs.missing(v.VoterID, v.PollID, v.Options()); reason != "" {
+					missing[v.VoteID] = reason
+				}
+			}
+
+			if len(missing) == 0 || attempt >= seedAttempts {
+				return refs, missing, nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return refs, nil, ctx.Err()
+		case <-time.After(seedRetryWait):
+		}
+	}
+}
+
+// Report whether a vote of a fixture can succeed once the other APIs are
+// up and seeded.
+func isRetryableSeed(status int) bool {
+	return status == http.StatusServiceUnavailable || status == http.StatusNotFound
+}
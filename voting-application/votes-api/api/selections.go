@@ -0,0 +1,92 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"unicode/utf8"
+
+	schema "votes-api/Schema"
+	"votes-api/votes"
+)
+
+// Return how many options a vote in the poll can select.
+func maxSelections(poll schema.Poll) int {
+	if poll.MaxSelections > 1 {
+		return int(poll.MaxSelections)
+	}
+
+	return 1
+}
+
+// Keep the options of a vote in one form: VoteValue alone for a single
+// option, OptionIDs starting with VoteValue for several.
+func normalizeSelections(vote votes.Vote) votes.Vote {
+	switch len(vote.OptionIDs) {
+	case 0:
+	case 1:
+		vote.VoteValue = vote.OptionIDs[0]
+		vote.OptionIDs = nil
+	default:
+		vote.VoteValue = vote.OptionIDs[0]
+	}
+
+	return vote
+}
+
+// Check the write-in of a vote against the poll and return the vote with
+// its text normalized. A write-in selects no option. Failures are
+// *voteError.
+func checkWriteIn(poll schema.Poll, vote votes.Vote) (votes.Vote, error) {
+	if !poll.AllowWriteIn {
+		return votes.Vote{}, &voteError{status: http.StatusBadRequest, message: "Poll does not allow write-ins"}
+	}
+
+	if vote.VoteValue != 0 || len(vote.OptionIDs) > 0 {
+		return votes.Vote{}, &voteError{status: http.StatusBadRequest, message: "Send either an option or a writeInValue"}
+	}
+
+	vote.WriteInValue = votes.NormalizeWriteIn(vote.WriteInValue)
+	if vote.WriteInValue == "" {
+		return votes.Vote{}, &voteError{status: http.StatusBadRequest, message: "writeInValue is empty"}
+	}
+	if utf8.RuneCountInString(vote.WriteInValue) > votes.MaxWriteInLength {
+		return votes.Vote{}, &voteError{status: http.StatusBadRequest, message: fmt.Sprintf("writeInValue is longer than %d characters", votes.MaxWriteInLength)}
+	}
+
+	return vote, nil
+}
+
+// Check the selected options of a vote against the poll: no more than it
+// allows, each at most once and each an option of the poll. It returns the
+// caps of the capped options selected. Failures are *voteError.
+func checkSelections(poll schema.Poll, selections []uint) (map[uint]uint, error) {
+	if limit := maxSelections(poll); len(selections) > limit {
+		return nil, &voteError{status: http.StatusBadRequest, message: fmt.Sprintf("Poll allows at most %d options per vote", limit)}
+	}
+
+	options := make(map[uint]schema.PollOption, len(poll.PollOptions))
+	for _, option := range poll.PollOptions {
+		options[option.PollOptionID] = option
+	}
+
+	maxVotes := make(map[uint]uint)
+	seen := make(map[uint]bool, len(selections))
+	for _, optionID := range selections {
+		if seen[optionID] {
+			return nil, &voteError{status: http.StatusBadRequest, message: fmt.Sprintf("Poll option %d is selected more than once", optionID)}
+		}
+		seen[optionID] = true
+
+		option, ok := options[optionID]
+		if !ok {
+			fmt.Printf("Error getting poll option: %d\n", optionID)
+			return nil, &voteError{status: http.StatusNotFound, message: "Could not find poll option in cache"}
+		}
+
+		if option.MaxVotes > 0 {
+			maxVotes[optionID] = option.MaxVotes
+		}
+	}
+
+	return maxVotes, nil
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"log"
+	"net/http"
+
+	"shared/apierror"
+	"shared/keyaudit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Implementation of GET /admin/redis/shards.
+// Returns the redis shards the votes are spread over, with their health.
+func (va *VotesAPI) GetRedisShards(c *gin.Context) {
+	if va.votesCache == nil {
+		apierror.Abort(c, http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, "Redis is not connected")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"shards": va.votesCache.ShardHealth(),
+	})
+}
+
+// Implementation of POST /admin/redis/rebalance?dryRun=true.
+// Move the votes that are on the wrong shard, after shards were added or
+// are being drained. With dryRun=true the votes are only counted.
+func (va *VotesAPI) RebalanceRedisShards(c *gin.Context) {
+	if va.votesCache == nil {
+		apierror.Abort(c, http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, "Redis is not connected")
+		return
+	}
+
+	report, err := va.votesCache.RebalanceShards(c.Query("dryRun") == "true")
+	if err != nil {
+		log.Println("Error rebalancing redis shards: ", err)
+		apierror.AbortWithDetails(c, http.StatusInternalServerError, apierror.CodeInternal, "Could not rebalance redis shards", gin.H{
+			"error":  err.Error(),
+			"report": report,
+		})
+		return
+	}
+
+	if report.Moved > 0 {
+		log.Printf("Admin rebalanced redis shards: moved %d of %d votes", report.Moved, report.Scanned)
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// Implementation of POST /admin/redis/audit?fix=legacy.
+// Audit the keys of the votes and the rest of the redis keyspace: malformed
+// and unreadable keys, votes stored under a legacy key or twice, and keys
+// the application does not write. With fix=legacy the legacy keys are
+// moved and the duplicates deleted, with fix=all the malformed and
+// unreadable keys are deleted too.
+func (va *VotesAPI) AuditRedisKeys(c *gin.Context) {
+	if va.votesCache == nil {
+		apierror.Abort(c, http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, "Redis is not connected")
+		return
+	}
+
+	fix, err := keyaudit.ParseFix(c.Query("fix"))
+	if err != nil {
+		apierror.AbortWithError(c, http.StatusBadRequest, "Invalid fix", err)
+		return
+	}
+
+	report, err := va.votesCache.AuditKeys(fix)
+	if err != nil {
+		log.Println("Error auditing redis keys: ", err)
+		apierror.AbortWithDetails(c, http.StatusInternalServerError, apierror.CodeInternal, "Could not audit redis keys", gin.H{
+			"error":  err.Error(),
+			"report": report,
+		})
+		return
+	}
+
+	if report.Documents.Fixed > 0 {
+		log.Printf("Admin fixed %d redis keys of %d votes", report.Documents.Fixed, report.Documents.Scanned)
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// Implementation of POST /admin/votes/index/rebuild.
+// Rebuild the sets that ?pollId= and ?voterId= of GET /votes read, for
+// votes cast before they existed.
+func (va *VotesAPI) RebuildVoteIndex(c *gin.Context) {
+	if va.votesCache == nil {
+		apierror.Abort(c, http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, "Redis is not connected")
+		return
+	}
+
+	indexed, err := va.votesCache.RebuildVoteIndex()
+	if err != nil {
+		log.Println("Error rebuilding vote index: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not rebuild vote index", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"indexed": indexed,
+	})
+}
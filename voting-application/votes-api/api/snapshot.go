@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"votes-api/votes"
+
+	"shared/snapshot"
+)
+
+var errStopEach = errors.New("stop")
+
+// Add the votes of a snapshot as they are, with their timestamps and
+// flags, into an empty votes API. The voters and polls are restored first,
+// so every vote is checked against the voter and poll APIs before any is
+// added. The votes are not cast again: the vote histories come with the
+// voters of the snapshot.
+func (va *VotesAPI) ImportSnapshot(ctx context.Context, records []json.RawMessage) (int, error) {
+	err := va.votesList.EachVote(func(votes.Vote) error { return errStopEach })
+	if errors.Is(err, errStopEach) {
+		return 0, snapshot.ErrNotEmpty
+	} else if err != nil {
+		return 0, err
+	}
+
+	refs, err := va.loadReferences()
+	if err != nil {
+		return 0, err
+	}
+
+	importedVotes := make([]votes.Vote, len(records))
+	var problems []string
+	for i, record := range records {
+		vote := &importedVotes[i]
+		if err := json.Unmarshal(record, vote); err != nil || vote.VoteID == 0 {
+			problems = append(problems, fmt.Sprintf("vote %d of the snapshot is unreadable or has no voteId", i+1))
+			continue
+		}
+
+		// The votes of anonymous polls have no voter.
+		reason := refs.missingPoll(vote.PollID, vote.Options())
+		if vote.VoterHash == "" {
+			reason = refs.missing(vote.VoterID, vote.PollID, vote.Options())
+		}
+		if reason != "" {
+			problems = append(problems, fmt.Sprintf("vote %d: %s", vote.VoteID, reason))
+		}
+	}
+	if len(problems) > 0 {
+		return 0, &snapshot.InvalidError{Problems: problems}
+	}
+
+	for i, vote := range importedVotes {
+		if ctx.Err() != nil {
+			return i, ctx.Err()
+		}
+
+		if err := va.votesList.AddVote(vote, nil); err != nil {
+			return i, fmt.Errorf("adding vote %d: %w", vote.VoteID, err)
+		}
+	}
+
+	return len(importedVotes), nil
+}
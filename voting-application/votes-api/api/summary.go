@@ -0,0 +1,120 @@
+package api
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	schema "votes-api/Schema"
+	"votes-api/votes"
+
+	"shared/apierror"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/sync/errgroup"
+)
+
+// pollSummary is the number of votes of a poll in the admin summary.
+type pollSummary struct {
+	PollID    uint   `json:"pollId"`
+	PollTitle string `json:"pollTitle"`
+	Votes     int    `json:"votes"`
+}
+
+// busiestHour is the hour in which the most votes were cast.
+type busiestHour struct {
+	Hour  time.Time `json:"hour"`
+	Votes int       `json:"votes"`
+}
+
+// Implementation of GET /admin/summary.
+// Returns the totals an admin dashboard shows: the numbers of voters, polls
+// and votes, the votes of each poll, the share of voters that voted and the
+// busiest hour. The voter and poll APIs and the votes are read at the same
+// time, so the slowest of them sets how long the summary takes.
+func (va *VotesAPI) GetAdminSummary(c *gin.Context) {
+	var voters []schema.Voter
+	var polls []schema.Poll
+	votesPerPoll := make(map[uint]int)
+	votesPerHour := make(map[time.Time]int)
+	totalVotes := 0
+
+	var group errgroup.Group
+
+	group.Go(func() (err error) {
+		voters, err = va.voters.listVoters()
+		return err
+	})
+
+	group.Go(func() (err error) {
+		polls, err = va.polls.listPolls()
+		return err
+	})
+
+	group.Go(func() error {
+		return va.votesList.EachVote(func(vote votes.Vote) error {
+			totalVotes++
+			votesPerPoll[vote.PollID]++
+			// Votes stored before they had timestamps are in no hour.
+			if vote.CreatedAt != nil {
+				votesPerHour[vote.CreatedAt.UTC().Truncate(time.Hour)]++
+			}
+			return nil
+		})
+	})
+
+	if err := group.Wait(); err != nil {
+		log.Println("Error building admin summary: ", err)
+		if voteErr := unreachableError(err); voteErr != nil {
+			abortWithVoteError(c, voteErr)
+			return
+		}
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not build admin summary", err)
+		return
+	}
+
+	// Every poll is listed, with or without votes, then the polls that
+	// only the votes still name.
+	pollSummaries := make([]pollSummary, 0, len(polls))
+	for _, poll := range polls {
+		pollSummaries = append(pollSummaries, pollSummary{PollID: poll.PollID, PollTitle: poll.PollTitle, Votes: votesPerPoll[poll.PollID]})
+		delete(votesPerPoll, poll.PollID)
+	}
+	for pollID, count := range votesPerPoll {
+		pollSummaries = append(pollSummaries, pollSummary{PollID: pollID, Votes: count})
+	}
+	sort.Slice(pollSummaries, func(i, j int) bool { return pollSummaries[i].PollID < pollSummaries[j].PollID })
+
+	// The vote histories also count the voters of anonymous polls, whose
+	// votes have no voter.
+	votersWhoVoted := 0
+	for _, voter := range voters {
+		if len(voter.VoteHistory) > 0 {
+			votersWhoVoted++
+		}
+	}
+
+	participationRate := 0.0
+	if len(voters) > 0 {
+		participationRate = math.Round(float64(votersWhoVoted)/float64(len(voters))*10000) / 100
+	}
+
+	var busiest *busiestHour
+	for hour, count := range votesPerHour {
+		if busiest == nil || count > busiest.Votes || (count == busiest.Votes && hour.Before(busiest.Hour)) {
+			busiest = &busiestHour{Hour: hour, Votes: count}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"voters":            len(voters),
+		"polls":             len(polls),
+		"votes":             totalVotes,
+		"votesPerPoll":      pollSummaries,
+		"votersWhoVoted":    votersWhoVoted,
+		"participationRate": participationRate,
+		"busiestHour":       busiest,
+	})
+}
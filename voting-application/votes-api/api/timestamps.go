@@ -0,0 +1,52 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"votes-api/votes"
+
+	"shared/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Implementation of POST /admin/votes/timestamps/backfill.
+// Give the votes stored before votes had timestamps the vote date of the
+// matching entry of the voter history, from the voter API.
+func (va *VotesAPI) BackfillVoteTimes(c *gin.Context) {
+	voters, err := va.voters.listVoters()
+	if err != nil {
+		log.Println("Error getting voters: ", err)
+		if voteErr := unreachableError(err); voteErr != nil {
+			abortWithVoteError(c, voteErr)
+			return
+		}
+		apierror.AbortWithError(c, http.StatusBadGateway, "Could not get the vote history of the voters", err)
+		return
+	}
+
+	type voterPoll struct{ voterID, pollID uint }
+	voteDates := make(map[voterPoll]time.Time)
+	for _, voter := range voters {
+		for _, entry := range voter.VoteHistory {
+			voteDates[voterPoll{voter.VoterID, entry.PollID}] = entry.VoteDate
+		}
+	}
+
+	backfilled, err := va.votesList.BackfillVoteTimes(func(vote votes.Vote) (time.Time, bool) {
+		voteDate, ok := voteDates[voterPoll{vote.VoterID, vote.PollID}]
+		return voteDate, ok && !voteDate.IsZero()
+	})
+	if err != nil {
+		log.Println("Error backfilling vote times: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not backfill vote times", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Vote times backfilled successfully.",
+		"backfilled": backfilled,
+	})
+}
@@ -0,0 +1,244 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"votes-api/votes"
+
+	"shared/apierror"
+	"shared/export"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The registration cohorts of a turnout report, by month unless ?cohort=
+// asks for another.
+const (
+	CohortMonth   = "month"
+	CohortQuarter = "quarter"
+	CohortYear    = "year"
+
+	// The group of the voters without a status or registration date.
+	unknownGroup = "unknown"
+)
+
+var turnoutExportHeader = []string{"breakdown", "group", "registered", "voted", "turnout"}
+
+// turnoutGroup is the turnout of the voters with a status or of a
+// registration cohort.
+type turnoutGroup struct {
+	Group      string  `json:"group"`
+	Registered int     `json:"registered"`
+	Voted      int     `json:"voted"`
+	Turnout    float64 `json:"turnout"`
+}
+
+// turnoutReport is how many registered voters voted in a poll.
+type turnoutReport struct {
+	PollID     uint    `json:"pollId"`
+	Registered int     `json:"registered"`
+	Voted      int     `json:"voted"`
+	Turnout    float64 `json:"turnout"`
+	// The voters who voted but are not on the roll, deleted since.
+	NotRegistered int `json:"notRegistered"`
+	// The votes of an anonymous poll have no voter, so its voters are
+	// counted without a breakdown.
+	Anonymous   bool           `json:"anonymous"`
+	Cohort      string         `json:"cohort"`
+	ByStatus    []turnoutGroup `json:"byStatus"`
+	ByCohort    []turnoutGroup `json:"byCohort"`
+	GeneratedAt time.Time      `json:"generatedAt"`
+}
+
+// Return the share of the registered voters who voted, 0 when none are.
+func turnoutRatio(voted, registered int) float64 {
+	if registered == 0 {
+		return 0
+	}
+
+	return float64(voted) / float64(registered)
+}
+
+// Return the cohort of a registration date.
+func registrationCohort(registeredAt *time.Time, cohort string) string {
+	if registeredAt == nil {
+		return unknownGroup
+	}
+
+	date := registeredAt.UTC()
+	switch cohort {
+	case CohortYear:
+		return strconv.Itoa(date.Year())
+	case CohortQuarter:
+		return fmt.Sprintf("%d-Q%d", date.Year(), (int(date.Month())+2)/3)
+	default:
+		return date.Format("2006-01")
+	}
+}
+
+// Return the groups of a breakdown in the order of their names, unknown
+// last.
+func turnoutGroups(registered, voted map[string]int) []turnoutGroup {
+	groups := make([]turnoutGroup, 0, len(registered))
+	for group, count := range registered {
+		groups = append(groups, turnoutGroup{
+			Group:      group,
+			Registered: count,
+			Voted:      voted[group],
+			Turnout:    turnoutRatio(voted[group], count),
+		})
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if (groups[i].Group == unknownGroup) != (groups[j].Group == unknownGroup) {
+			return groups[j].Group == unknownGroup
+		}
+		return groups[i].Group < groups[j].Group
+	})
+
+	return groups
+}
+
+// Implementation of GET /reports/turnout/:pollId.
+// Reports how many of the voters registered with the Voter API voted in a
+// poll, broken down by voter status and by registration cohort, by month,
+// ?cohort=quarter or ?cohort=year. The votes are counted from the index of
+// the poll. ?format=csv returns the breakdowns as CSV rows.
+func (va *VotesAPI) GetTurnoutReport(c *gin.Context) {
+	pollIDUint, err := strconv.ParseUint(c.Param("pollId"), 10, 32)
+	if err != nil {
+		log.Println("Error converting poll ID to uint: ", err)
+		apierror.AbortInvalidID(c, "Poll ID", err)
+		return
+	}
+	pollID := uint(pollIDUint)
+
+	cohort := c.DefaultQuery("cohort", CohortMonth)
+	if cohort != CohortMonth && cohort != CohortQuarter && cohort != CohortYear {
+		apierror.AbortWithDetails(c, http.StatusBadRequest, apierror.CodeBadRequest, "Unsupported registration cohort", []string{CohortMonth, CohortQuarter, CohortYear})
+		return
+	}
+
+	format := c.DefaultQuery("format", export.FormatJSON)
+	if format != export.FormatCSV && format != export.FormatJSON {
+		apierror.AbortWithDetails(c, http.StatusBadRequest, apierror.CodeBadRequest, "Unsupported report format", []string{export.FormatCSV, export.FormatJSON})
+		return
+	}
+
+	poll, err := va.getPoll(pollID)
+	if err != nil {
+		log.Println("Error getting poll: ", err)
+		if errors.Is(err, errPollAPIUnavailable) {
+			apierror.AbortWithDetails(c, http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, "Poll API is unavailable", err)
+			return
+		}
+		apierror.AbortWithDetails(c, http.StatusNotFound, apierror.CodeNotFound, "Could not find poll", err)
+		return
+	}
+
+	voters, err := va.voters.listVoters()
+	if err != nil {
+		log.Println("Error getting voters: ", err)
+		apierror.AbortWithError(c, http.StatusServiceUnavailable, "Could not get the voters from the voter API", err)
+		return
+	}
+
+	pollVotes, err := va.votesList.FindVotes(votes.VoteFilter{PollID: pollID})
+	if err != nil {
+		log.Println("Error getting votes of poll: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not get the votes of the poll", err)
+		return
+	}
+
+	report := turnoutReport{
+		PollID:      pollID,
+		Registered:  len(voters),
+		Anonymous:   poll.Anonymous,
+		Cohort:      cohort,
+		GeneratedAt: time.Now().UTC(),
+	}
+
+	// The voters who voted, taken off as they are found on the roll.
+	voted := make(map[uint]bool)
+	anonymousVotes := 0
+	for _, vote := range pollVotes {
+		if vote.VoterID == 0 {
+			anonymousVotes++
+			continue
+		}
+		voted[vote.VoterID] = true
+	}
+
+	registeredByStatus := make(map[string]int)
+	votedByStatus := make(map[string]int)
+	registeredByCohort := make(map[string]int)
+	votedByCohort := make(map[string]int)
+
+	for _, voter := range voters {
+		status := voter.Status
+		if status == "" {
+			status = unknownGroup
+		}
+		group := registrationCohort(voter.RegisteredAt, cohort)
+
+		registeredByStatus[status]++
+		registeredByCohort[group]++
+
+		if voted[voter.VoterID] {
+			report.Voted++
+			votedByStatus[status]++
+			votedByCohort[group]++
+			delete(voted, voter.VoterID)
+		}
+	}
+
+	report.NotRegistered = len(voted)
+
+	if poll.Anonymous {
+		// Who voted is not known, only how many did.
+		report.Voted = anonymousVotes
+		votedByStatus = nil
+		votedByCohort = nil
+	}
+
+	report.Turnout = turnoutRatio(report.Voted, report.Registered)
+	report.ByStatus = turnoutGroups(registeredByStatus, votedByStatus)
+	report.ByCohort = turnoutGroups(registeredByCohort, votedByCohort)
+
+	if format == export.FormatJSON {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	writer := export.NewWriter(c, fmt.Sprintf("turnout-poll-%d", pollID), turnoutExportHeader)
+	if writer == nil {
+		return
+	}
+
+	rows := [][]string{{"total", "all", strconv.Itoa(report.Registered), strconv.Itoa(report.Voted), formatTurnout(report.Turnout)}}
+	for _, group := range report.ByStatus {
+		rows = append(rows, []string{"status", group.Group, strconv.Itoa(group.Registered), strconv.Itoa(group.Voted), formatTurnout(group.Turnout)})
+	}
+	for _, group := range report.ByCohort {
+		rows = append(rows, []string{"cohort", group.Group, strconv.Itoa(group.Registered), strconv.Itoa(group.Voted), formatTurnout(group.Turnout)})
+	}
+
+	var writeErr error
+	for _, row := range rows {
+		if writeErr = writer.Write(nil, row); writeErr != nil {
+			break
+		}
+	}
+	writer.Close(writeErr)
+}
+
+// Format a turnout ratio for a CSV row.
+func formatTurnout(turnout float64) string {
+	return strconv.FormatFloat(turnout, 'f', 4, 64)
+}
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"encoding/xml"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -10,78 +12,164 @@ import (
 	schema "votes-api/Schema"
 	"votes-api/votes"
 
+	"shared/apierror"
+	"shared/apikey"
+	"shared/endpoints"
+	"shared/failover"
+	"shared/healthkit"
+	"shared/listorder"
+	"shared/negotiate"
+	"shared/pagination"
+	"shared/validation"
+	"shared/version"
+	"shared/webhook"
+	"shared/worker"
+
 	"github.com/gin-gonic/gin"
 	"github.com/go-resty/resty/v2"
 )
 
 // The API handler that handles incoming requests.
 type VotesAPI struct {
-	votesList        *votes.VotesCache
+	votesList        votes.Store
+	votesCache       *votes.VotesCache
 	pollAPIURL       string
 	voterAPIURL      string
 	apiClient        *resty.Client
-	totalCalls       uint64
-	errorCalls       uint64
-	bootTime         time.Time
-	totalRequestTime time.Duration
+	voters           voterClient
+	polls            pollClient
+	privacy          privacyConfig
+	writeInMinCount  uint
+	voteHoldTTL      time.Duration
+	retention        retentionConfig
+	scheduler        *worker.Scheduler
+	voteEventsStream string
+	pollMetadata     *pollMetadataCache
+	receiptKey       []byte
+	voterHashKey     []byte
+	compatibility    *version.Checker
+	compatMode       string
+	webhooks         *webhook.Registry
+	apiKeys          *apikey.Store
+	metrics          *healthkit.Metrics
+}
+
+// Return the request metrics of the API, counting the votes cast directly
+// and by confirming a prepared vote as votesCast.
+func newMetrics() *healthkit.Metrics {
+	return healthkit.New(healthkit.WithCounter("votesCast", healthkit.Any(
+		healthkit.Succeeded(http.MethodPost, "/votes/:id"),
+		healthkit.Succeeded(http.MethodPost, "/votes/:id/confirm"),
+	)))
 }
 
-// Create a new instance of VotesAPI with an initialized votes cache.
+// Create a new instance of VotesAPI with an initialized votes cache and
+// the votes store selected by STORAGE_BACKEND.
 func NewVotesHandler(pollAPIURL string, voterAPIURL string) *VotesAPI {
 	votesCache, _ := votes.NewVotesCache()
 	apiClient := resty.New()
 
+	// Downstream calls can be recorded or replayed and can have faults injected.
+	transport := apiClient.GetClient().Transport
+	if cassette := loadCassetteTransport(transport); cassette != nil {
+		transport = cassette
+	}
+	apiClient.SetTransport(withFaultInjection(transport, voterAPIURL, pollAPIURL))
+
+	voters, polls := loadDownstreamClients(apiClient.GetClient().Transport, voterAPIURL, pollAPIURL)
+
+	votesStore, err := votes.NewVotesStore(votesCache)
+	if err != nil {
+		log.Println("Error initializing votes store: ", err)
+	}
+
 	return &VotesAPI{
-		votesList:        votesCache,
+		votesList:        votesStore,
+		votesCache:       votesCache,
+		scheduler:        worker.NewScheduler(votesCache.RedisClient(), "votes-api"),
 		pollAPIURL:       pollAPIURL,
 		voterAPIURL:      voterAPIURL,
 		apiClient:        apiClient,
-		totalCalls:       0,
-		errorCalls:       0,
-		bootTime:         time.Now(),
-		totalRequestTime: 0,
+		voters:           voters,
+		polls:            polls,
+		privacy:          loadPrivacyConfig(votesCache),
+		writeInMinCount:  loadWriteInMinCount(),
+		voteHoldTTL:      loadVoteHoldTTL(),
+		retention:        loadRetentionConfig(),
+		voteEventsStream: voteEventsStream(),
+		pollMetadata:     newPollMetadataCache(),
+		receiptKey:       loadSecret(votesCache, "RECEIPT_SECRET", votes.ReceiptSecretKey),
+		voterHashKey:     loadSecret(votesCache, "VOTER_HASH_SECRET", votes.VoterHashSecretKey),
+		compatibility:    version.NewChecker(compatibilityRequirements(voterAPIURL, pollAPIURL)),
+		compatMode:       compatibilityMode(),
+		webhooks:         webhook.NewRegistry(votesCache.RedisClient(), "votes-api"),
+		apiKeys:          apikey.NewStore(votesCache.RedisClient()),
+		metrics:          newMetrics(),
 	}
 }
 
 // The custom middleware to handle health metadata.
 func HealthMiddleware(va *VotesAPI) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// Update total API calls count.
-		va.totalCalls++
-
-		// Record the start time of the request.
-		start := time.Now()
+	return va.metrics.Middleware()
+}
 
-		// Process the request.
-		c.Next()
+// The fields GET /votes can be sorted by.
+var voteSortFields = listorder.Fields[votes.Vote]{
+	"voteId":    func(a, b votes.Vote) int { return listorder.Compare(a.VoteID, b.VoteID) },
+	"voterId":   func(a, b votes.Vote) int { return listorder.Compare(a.VoterID, b.VoterID) },
+	"pollId":    func(a, b votes.Vote) int { return listorder.Compare(a.PollID, b.PollID) },
+	"voteValue": func(a, b votes.Vote) int { return listorder.Compare(a.VoteValue, b.VoteValue) },
+	"createdAt": func(a, b votes.Vote) int { return listorder.CompareTimes(a.CreatedAt, b.CreatedAt) },
+}
 
-		// Update error API calls count if there's an error.
-		if c.Writer.Status() >= 400 {
-			va.errorCalls++
+// Return the filter of the ?pollId= and ?voterId= of a request. It aborts
+// the request with 400 and returns false when one is not a positive
+// integer.
+func parseVoteFilter(c *gin.Context) (votes.VoteFilter, bool) {
+	var filter votes.VoteFilter
+
+	if query := c.Query("pollId"); query != "" {
+		pollIDUint, err := strconv.ParseUint(query, 10, 32)
+		if err != nil || pollIDUint == 0 {
+			log.Println("Error converting poll ID to uint: ", err)
+			apierror.AbortInvalidID(c, "Poll ID", err)
+			return filter, false
 		}
+		filter.PollID = uint(pollIDUint)
+	}
 
-		// Calculate the request duration.
-		duration := time.Since(start)
-
-		// Update the total request time.
-		va.totalRequestTime += duration
+	if query := c.Query("voterId"); query != "" {
+		voterIDUint, err := strconv.ParseUint(query, 10, 32)
+		if err != nil || voterIDUint == 0 {
+			log.Println("Error converting voter ID to uint: ", err)
+			apierror.AbortInvalidID(c, "Voter ID", err)
+			return filter, false
+		}
+		filter.VoterID = uint(voterIDUint)
 	}
-}
 
-// The root endpoint that welcomes users to the API.
-func (va *VotesAPI) WelcomeToVotesAPI(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Welcome to votes API.",
-	})
+	return filter, true
 }
 
 // Implementation of GET /votes.
-// Returns all Votes with all votes.
+// Returns all Votes with all votes, by vote ID or in the order of ?sort=,
+// or one page of them with ?page= or ?cursor=. ?pollId= and ?voterId=
+// return only the votes of a poll, of a voter, or of a voter in a poll.
 func (va *VotesAPI) ListAllVotes(c *gin.Context) {
-	allVotes, err := va.votesList.GetAllVotes()
+	request, ok := pagination.Parse(c)
+	if !ok {
+		return
+	}
+
+	filter, ok := parseVoteFilter(c)
+	if !ok {
+		return
+	}
+
+	allVotes, err := va.votesList.FindVotes(filter)
 	if err != nil {
 		log.Println("Error getting Votes: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
+		apierror.AbortWithError(c, http.StatusBadRequest, "Could not get votes", err)
 		return
 	}
 
@@ -89,62 +177,76 @@ func (va *VotesAPI) ListAllVotes(c *gin.Context) {
 		allVotes = make([]votes.Vote, 0)
 	}
 
+	if !listorder.Sort(c, allVotes, voteSortFields) {
+		return
+	}
+
+	total := len(allVotes)
+	allVotes = pagination.Slice(request, allVotes)
+
+	response := make([]voteResponse, len(allVotes))
+	for i, vote := range allVotes {
+		response[i] = newVoteResponse(vote)
+	}
+
+	pagination.Negotiate(c, request, total, "votes", response)
+}
+
+// voteResponse is a vote as GET /votes and GET /votes/:id show it, in
+// JSON, XML or msgpack.
+type voteResponse struct {
+	XMLName      xml.Name   `json:"-" xml:"vote" msgpack:"-"`
+	VoteID       uint       `json:"voteId" xml:"voteId" msgpack:"voteId"`
+	VoterID      uint       `json:"voterId" xml:"voterId" msgpack:"voterId"`
+	PollID       uint       `json:"pollId" xml:"pollId" msgpack:"pollId"`
+	VoteValue    uint       `json:"voteValue" xml:"voteValue" msgpack:"voteValue"`
+	OptionIDs    []uint     `json:"optionIds" xml:"optionIds>optionId" msgpack:"optionIds"`
+	WriteInValue string     `json:"writeInValue" xml:"writeInValue,omitempty" msgpack:"writeInValue"`
+	VoteDate     *time.Time `json:"voteDate" xml:"voteDate,omitempty" msgpack:"voteDate"`
+	Links        voteLinks  `json:"links" xml:"links" msgpack:"links"`
+}
+
+// voteLinks are the links of a vote to itself, its poll and its voter.
+type voteLinks struct {
+	Self voteSelfLinks   `json:"self" xml:"self" msgpack:"self"`
+	Poll negotiate.Links `json:"poll" xml:"poll" msgpack:"poll"`
+	// Anonymous votes have no voter to link to.
+	Voter *negotiate.Links `json:"voter,omitempty" xml:"voter,omitempty" msgpack:"voter,omitempty"`
+}
+
+// voteSelfLinks are the links of a vote to itself, which cannot be
+// updated.
+type voteSelfLinks struct {
+	Get    negotiate.Link `json:"get" xml:"get" msgpack:"get"`
+	Delete negotiate.Link `json:"delete" xml:"delete" msgpack:"delete"`
+}
+
+func newVoteResponse(vote votes.Vote) voteResponse {
 	voterAPIURL := "http://localhost:1080"
 	pollAPIURL := "http://localhost:1081"
 
-	response := make([]map[string]interface{}, len(allVotes))
-	for i, vote := range allVotes {
-		link := fmt.Sprintf("/votes/%d", vote.VoteID)
-
-		response[i] = map[string]interface{}{
-			"voteId":    vote.VoteID,
-			"voterId":   vote.VoterID,
-			"pollId":    vote.PollID,
-			"voteValue": vote.VoteValue,
-			"links": map[string]interface{}{
-				"self": map[string]interface{}{
-					"get": map[string]interface{}{
-						"method": "GET",
-						"url":    link,
-					},
-					"delete": map[string]interface{}{
-						"method": "DELETE",
-						"url":    link,
-					},
-				},
-				"voter": map[string]interface{}{
-					"get": map[string]interface{}{
-						"method": "GET",
-						"url":    fmt.Sprintf("%s/voters/%d", voterAPIURL, vote.VoterID),
-					},
-					"update": map[string]interface{}{
-						"method": "PUT",
-						"url":    fmt.Sprintf("%s/voters/%d", voterAPIURL, vote.VoterID),
-					},
-					"delete": map[string]interface{}{
-						"method": "DELETE",
-						"url":    fmt.Sprintf("%s/voters/%d", voterAPIURL, vote.VoterID),
-					},
-				},
-				"poll": map[string]interface{}{
-					"get": map[string]interface{}{
-						"method": "GET",
-						"url":    fmt.Sprintf("%s/polls/%d", pollAPIURL, vote.PollID),
-					},
-					"update": map[string]interface{}{
-						"method": "PUT",
-						"url":    fmt.Sprintf("%s/polls/%d", pollAPIURL, vote.PollID),
-					},
-					"delete": map[string]interface{}{
-						"method": "DELETE",
-						"url":    fmt.Sprintf("%s/polls/%d", pollAPIURL, vote.PollID),
-					},
-				},
-			},
-		}
+	response := voteResponse{
+		VoteID:       vote.VoteID,
+		VoterID:      vote.VoterID,
+		PollID:       vote.PollID,
+		VoteValue:    vote.VoteValue,
+		OptionIDs:    vote.Options(),
+		WriteInValue: vote.WriteInValue,
+		VoteDate:     vote.CastAt(),
 	}
 
-	c.JSON(http.StatusOK, response)
+	link := fmt.Sprintf("/votes/%d", vote.VoteID)
+	response.Links.Self = voteSelfLinks{
+		Get:    negotiate.Link{Method: http.MethodGet, URL: link},
+		Delete: negotiate.Link{Method: http.MethodDelete, URL: link},
+	}
+	response.Links.Poll = negotiate.ResourceLinks(fmt.Sprintf("%s/polls/%d", pollAPIURL, vote.PollID))
+	if vote.VoterID != 0 {
+		voterLinks := negotiate.ResourceLinks(fmt.Sprintf("%s/voters/%d", voterAPIURL, vote.VoterID))
+		response.Links.Voter = &voterLinks
+	}
+
+	return response
 }
 
 // Implementation of GET /votes/:id.
@@ -154,188 +256,465 @@ func (va *VotesAPI) GetVote(c *gin.Context) {
 	voteIDUint, err := strconv.ParseUint(voteID, 10, 32)
 	if err != nil {
 		log.Println("Error converting vote ID to uint: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
+		apierror.AbortInvalidID(c, "Vote ID", err)
 		return
 	}
 
 	vote, err := va.votesList.GetVote(uint(voteIDUint))
 	if err != nil {
 		log.Println("Error getting vote: ", err)
-		c.AbortWithStatus(http.StatusNotFound)
+		apierror.AbortWithError(c, http.StatusNotFound, "Could not get vote", err)
 		return
 	}
 
-	voterAPIURL := "http://localhost:1080"
-	pollAPIURL := "http://localhost:1081"
+	negotiate.Respond(c, http.StatusOK, newVoteResponse(vote))
+}
 
-	response := map[string]interface{}{
-		"voteId":    vote.VoteID,
-		"voterId":   vote.VoterID,
-		"pollId":    vote.PollID,
-		"voteValue": vote.VoteValue,
-		"links": map[string]interface{}{
-			"self": map[string]interface{}{
-				"get": map[string]interface{}{
-					"method": "GET",
-					"url":    fmt.Sprintf("/votes/%d", vote.VoteID),
-				},
-				"delete": map[string]interface{}{
-					"method": "DELETE",
-					"url":    fmt.Sprintf("/votes/%d", vote.VoteID),
-				},
-			},
-			"voter": map[string]interface{}{
-				"get": map[string]interface{}{
-					"method": "GET",
-					"url":    fmt.Sprintf("%s/voters/%d", voterAPIURL, vote.VoterID),
-				},
-				"update": map[string]interface{}{
-					"method": "PUT",
-					"url":    fmt.Sprintf("%s/voters/%d", voterAPIURL, vote.VoterID),
-				},
-				"delete": map[string]interface{}{
-					"method": "DELETE",
-					"url":    fmt.Sprintf("%s/voters/%d", voterAPIURL, vote.VoterID),
-				},
-			},
-			"poll": map[string]interface{}{
-				"get": map[string]interface{}{
-					"method": "GET",
-					"url":    fmt.Sprintf("%s/pools/%d", pollAPIURL, vote.PollID),
-				},
-				"update": map[string]interface{}{
-					"method": "PUT",
-					"url":    fmt.Sprintf("%s/pools/%d", pollAPIURL, vote.PollID),
-				},
-				"delete": map[string]interface{}{
-					"method": "DELETE",
-					"url":    fmt.Sprintf("%s/pools/%d", pollAPIURL, vote.PollID),
-				},
-			},
-		},
-	}
-
-	c.JSON(http.StatusOK, response)
+// voteRequest is the body of a new vote. The chosen option is sent as
+// optionId, the options of a multi-choice poll as optionIds, or the free
+// text of a write-in as writeInValue; voteValue is still accepted but
+// deprecated. The voter is sent as voterId or as the identity.
+type voteRequest struct {
+	votes.Vote
+	OptionID *uint         `json:"optionId"`
+	Identity *voteIdentity `json:"identity"`
 }
 
-// Implementation of POST /votes/:id.
-// Add a new voter with :id.
-func (va *VotesAPI) AddVote(c *gin.Context) {
-	var vote votes.Vote
-	if err := c.ShouldBindJSON(&vote); err != nil {
+// The kinds of identity a vote can be cast with.
+const (
+	IdentityVoter = "voter"
+	IdentityToken = "token"
+)
+
+// voteIdentity is who casts a vote: a registered voter, or the holder of a
+// ballot token of an anonymous poll.
+type voteIdentity struct {
+	Type    string `json:"type" binding:"required,oneof=voter token"`
+	VoterID uint   `json:"voterId"`
+	Token   string `json:"token"`
+}
+
+// Bind the vote of POST /votes/:id and its variants, with the ID of the
+// path, and return it with its ballot token. It answers the request and
+// returns false when the vote is malformed.
+func bindVote(c *gin.Context) (votes.Vote, string, bool) {
+	var request voteRequest
+	if err := validation.Bind(c, &request); err != nil {
 		log.Println("Error binding JSON: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
+		return votes.Vote{}, "", false
+	}
+
+	vote := request.Vote
+	if request.OptionID != nil && len(vote.OptionIDs) > 0 {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeBadRequest, "Send either optionId or optionIds")
+		return votes.Vote{}, "", false
+	}
+	if request.OptionID != nil {
+		vote.VoteValue = *request.OptionID
+	}
+
+	token, ok := request.credentials(c, &vote)
+	if !ok {
+		return votes.Vote{}, "", false
+	}
+
+	voteID := c.Param("id")
+	voteIDUint, err := strconv.ParseUint(voteID, 10, 32)
+	if err != nil {
+		log.Println("Error converting vote ID to uint: ", err)
+		apierror.AbortInvalidID(c, "Vote ID", err)
+		return votes.Vote{}, "", false
+	}
+
+	vote.VoteID = uint(voteIDUint)
+
+	return vote, token, true
+}
+
+// Return the ballot token of a vote request and set its voter from the
+// identity, answering 400 or 422 when the voter is missing or sent twice.
+func (request voteRequest) credentials(c *gin.Context, vote *votes.Vote) (string, bool) {
+	identity := request.Identity
+	if identity == nil {
+		identity = &voteIdentity{Type: IdentityVoter, VoterID: vote.VoterID}
+	} else if vote.VoterID != 0 {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeBadRequest, "Send either voterId or identity")
+		return "", false
+	}
+
+	switch {
+	case identity.Type == IdentityVoter && identity.VoterID == 0:
+		field := "identity.voterId"
+		if request.Identity == nil {
+			field = "voterId"
+		}
+		validation.AbortWithFields(c, []validation.FieldError{{Field: field, Rule: "required", Message: field + " is required"}})
+		return "", false
+	case identity.Type == IdentityToken && identity.Token == "":
+		validation.AbortWithFields(c, []validation.FieldError{{Field: "identity.token", Rule: "required", Message: "identity.token is required"}})
+		return "", false
+	}
+
+	vote.VoterID = identity.VoterID
+	if identity.Type == IdentityToken {
+		vote.VoterID = 0
+		return identity.Token, true
+	}
+
+	return "", true
+}
+
+// voteError is a rejected vote with the HTTP status it is reported with.
+// An empty message reports the status without a body.
+type voteError struct {
+	status  int
+	message string
+	// The details of the error response, if any.
+	details interface{}
+	// The API that could not be reached, if any.
+	upstream string
+}
+
+func (e *voteError) Error() string {
+	if e.message == "" {
+		return http.StatusText(e.status)
+	}
+
+	return e.message
+}
+
+// Report a vote error on the request.
+func abortWithVoteError(c *gin.Context, err error) {
+	var voteErr *voteError
+	if !errors.As(err, &voteErr) {
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not cast vote", err)
 		return
 	}
 
-	vID := vote.VoterID
+	if voteErr.upstream != "" {
+		apierror.Upstream(c, voteErr.upstream)
+	}
 
-	var voters = []schema.Voter{}
-	votersPath := va.voterAPIURL + "/voters"
+	if voteErr.details != nil {
+		apierror.AbortWithDetails(c, voteErr.status, apierror.CodeForStatus(voteErr.status), voteErr.Error(), voteErr.details)
+		return
+	}
+
+	apierror.Abort(c, voteErr.status, apierror.CodeForStatus(voteErr.status), voteErr.Error())
+}
 
-	_, err := va.apiClient.R().SetResult(&voters).Get(votersPath)
+// Check that the voter of a vote exists and is active. Failures are
+// *voteError.
+func (va *VotesAPI) checkVoter(vID uint) error {
+	voters, err := va.voters.listVoters()
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Could not find voter in cache"})
 		fmt.Println("Error getting voters:", err)
-		return
+		if voteErr := unreachableError(err); voteErr != nil {
+			return voteErr
+		}
+		return &voteError{status: http.StatusNotFound, message: "Could not find voter in cache"}
 	}
 
 	var foundVoterID bool = false
-	for _, voter := range voters {
-		if voter.VoterID == vID {
+	var voter schema.Voter
+	for _, v := range voters {
+		if v.VoterID == vID {
 			foundVoterID = true
+			voter = v
 			break
 		}
 	}
 
 	if !foundVoterID {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Could not find voter in cache"})
 		fmt.Println("Error getting voter")
+		return &voteError{status: http.StatusNotFound, message: "Could not find voter in cache"}
+	}
+
+	// A nil *voteError would not be a nil error.
+	if err := checkVoterActive(voter); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Return a 403 voteError when the voter is pending or suspended. A voter
+// without a status, as read over gRPC or from an older voter API, is
+// taken as active.
+func checkVoterActive(voter schema.Voter) *voteError {
+	switch voter.Status {
+	case "", "active":
+		return nil
+	}
+
+	return &voteError{
+		status:  http.StatusForbidden,
+		message: "Voter is not active",
+		details: gin.H{"status": voter.Status},
+	}
+}
+
+// Return a 409 voteError when the poll does not accept votes: a draft
+// that is not open yet, or a closed or certified poll. A poll without a
+// status, as read from an older poll API, is taken as open.
+func checkPollOpen(poll schema.Poll) *voteError {
+	switch poll.PollStatus {
+	case "", "open":
+		return nil
+	case "draft":
+		return &voteError{status: http.StatusConflict, message: "Poll is not open yet"}
+	}
+
+	return &voteError{status: http.StatusConflict, message: "Poll is closed"}
+}
+
+// How far in the future the vote date of a ballot can be, for clients
+// whose clock is ahead.
+const voteDateClockSkew = time.Minute
+
+// Return a 422 voteError when the vote date of a ballot is outside the
+// window in which the poll was open: from its start time, or from its
+// creation when it has none or was opened before it, to its end or
+// closing time, and never after now.
+func checkVoteDate(poll schema.Poll, voteDate, now time.Time) *voteError {
+	var opensAt *time.Time
+	switch {
+	case poll.StartTime != nil && !poll.StartTime.After(now):
+		opensAt = poll.StartTime
+	case !poll.CreatedAt.IsZero():
+		opensAt = &poll.CreatedAt
+	}
+
+	closesAt := now.Add(voteDateClockSkew)
+	for _, end := range []*time.Time{poll.EndTime, poll.ClosedAt} {
+		if end != nil && end.Before(closesAt) {
+			closesAt = *end
+		}
+	}
+
+	if (opensAt == nil || !voteDate.Before(*opensAt)) && !voteDate.After(closesAt) {
+		return nil
+	}
+
+	return &voteError{
+		status:  http.StatusUnprocessableEntity,
+		message: "Vote date is outside the time the poll was open",
+		details: gin.H{"voteDate": voteDate, "opensAt": opensAt, "closesAt": closesAt},
+	}
+}
+
+// Return a 503 voteError when err means a downstream API could not be
+// reached, nil otherwise.
+func unreachableError(err error) *voteError {
+	var unreachable *endpoints.UnreachableError
+	if !errors.As(err, &unreachable) {
+		return nil
+	}
+
+	return &voteError{status: http.StatusServiceUnavailable, message: unreachable.Error(), upstream: unreachable.Upstream()}
+}
+
+// Implementation of POST /votes/:id.
+// Add a new voter with :id.
+func (va *VotesAPI) AddVote(c *gin.Context) {
+	vote, token, ok := bindVote(c)
+	if !ok {
 		return
 	}
 
-	pID := vote.PollID
-	optID := vote.VoteValue
+	vote, err := va.castVote(vote, token)
+	if err != nil {
+		abortWithVoteError(c, err)
+		return
+	}
 
-	var polls = []schema.Poll{}
-	pollsPath := va.pollAPIURL + "/polls"
+	// The receipt lets the voter check later that the vote is unchanged.
+	c.JSON(http.StatusOK, struct {
+		votes.Vote
+		Receipt string `json:"receipt"`
+	}{vote, va.signReceipt(vote)})
+}
 
-	_, err = va.apiClient.R().SetResult(&polls).Get(pollsPath)
+// Check a new vote against the voter and poll APIs, store it and add it
+// to the voter's vote history. A vote cast with the ballot token of an
+// anonymous poll has no voter: the token is used up instead, and the vote
+// keeps only a hash of it. Failures are *voteError.
+func (va *VotesAPI) castVote(vote votes.Vote, token string) (votes.Vote, error) {
+	vote, poll, optionMaxVotes, err := va.validateVote(vote, token)
+	if err != nil {
+		return votes.Vote{}, err
+	}
+
+	vID := vote.VoterID
+	anonymous := poll.Anonymous
+
+	vote.FlaggedAt = nil
+	vote.FlagReason = ""
+	vote.VoterHash = ""
+
+	// The times of a vote are set by the server, never by the client,
+	// except for the date of the ballot, which is now when it has none.
+	now := time.Now().UTC()
+	vote.CreatedAt = &now
+	vote.UpdatedAt = &now
+	if vote.VoteDate == nil {
+		vote.VoteDate = &now
+	}
+
+	// Only redis expires votes, those of test polls.
+	vote.ExpiresAt = nil
+	if va.votesCache != nil && votes.StorageBackend() == votes.StorageBackendRedis {
+		vote.ExpiresAt = va.votesCache.VoteExpiry(poll.Tags, now)
+	}
+
+	// The votes of anonymous polls are stored without their voter, who is
+	// only added to the vote history below, with the rounded vote date.
+	switch {
+	case token != "":
+		vote = va.anonymizeBallot(vote, token)
+
+		redeemed, err := va.votesCache.RedeemBallotToken(vote.PollID, token)
+		if err != nil {
+			log.Println("Error redeeming ballot token: ", err)
+			return votes.Vote{}, &voteError{status: http.StatusInternalServerError}
+		}
+		if !redeemed {
+			return votes.Vote{}, &voteError{status: http.StatusForbidden, message: "Ballot token is not valid for this poll"}
+		}
+	case anonymous:
+		vote = va.anonymize(vote)
+	}
+
+	if err := va.votesList.AddVote(vote, optionMaxVotes); err != nil {
+		fmt.Println("Error adding vote")
+		log.Println("error adding item: ", err)
+		if token != "" {
+			if returnErr := va.votesCache.ReturnBallotToken(vote.PollID, token); returnErr != nil {
+				log.Println("Error returning ballot token: ", returnErr)
+			}
+		}
+		if errors.Is(err, votes.ErrOptionFull) {
+			return votes.Vote{}, &voteError{status: http.StatusConflict, message: "Poll option has reached its maximum number of votes"}
+		}
+		if errors.Is(err, votes.ErrAlreadyVoted) {
+			return votes.Vote{}, &voteError{status: http.StatusConflict, message: "Voter has already voted in this poll"}
+		}
+		if failover.IsFailoverError(err) {
+			return votes.Vote{}, err
+		}
+		return votes.Vote{}, &voteError{status: http.StatusConflict}
+	}
+
+	// After successfully adding the vote, add it to the voter's vote
+	// history. A ballot has no voter whose history could keep it. When the
+	// history cannot be written the vote is deleted again, so it is never
+	// stored without its history entry; it was not announced yet.
+	if token == "" {
+		if err := va.voters.addVoterPoll(vID, vote.PollID, *vote.VoteDate); err != nil {
+			log.Println("Error adding vote to voter's vote history: ", err)
+			if deleteErr := va.votesList.DeleteVote(vote.VoteID); deleteErr != nil {
+				log.Println("Error deleting vote without vote history: ", deleteErr)
+			}
+			if voteErr := unreachableError(err); voteErr != nil {
+				return votes.Vote{}, voteErr
+			}
+			return votes.Vote{}, &voteError{status: http.StatusInternalServerError}
+		}
+	}
+
+	va.publishVoteCast(vote)
+	va.voteCast(vote)
+	va.countVoteReceived(vote)
+
+	return vote, nil
+}
+
+// Check a new vote against the voter and poll APIs without storing it,
+// returning the vote with its selections normalized, its poll and the
+// maximum votes of its options. A vote whose ID or voter is held by a
+// prepared vote is refused. Failures are *voteError.
+func (va *VotesAPI) validateVote(vote votes.Vote, token string) (votes.Vote, schema.Poll, map[uint]uint, error) {
+	vote = normalizeSelections(vote)
+	vID := vote.VoterID
+
+	if err := va.checkNotHeld(vote); err != nil {
+		return votes.Vote{}, schema.Poll{}, nil, err
+	}
+
+	if token == "" {
+		if err := va.checkVoter(vID); err != nil {
+			return votes.Vote{}, schema.Poll{}, nil, err
+		}
+	}
+
+	pID := vote.PollID
+
+	polls, err := va.polls.listPolls()
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Could not find poll in cache"})
 		fmt.Println("Error getting poll")
-		return
+		if voteErr := unreachableError(err); voteErr != nil {
+			return votes.Vote{}, schema.Poll{}, nil, voteErr
+		}
+		return votes.Vote{}, schema.Poll{}, nil, &voteError{status: http.StatusNotFound, message: "Could not find poll in cache"}
 	}
 
+	va.pollMetadata.store(polls...)
+
 	// Check if poll with ID and poll option with ID exist
 	var foundPollID bool = false
-	var foundPollOptID bool = false
-	for _, poll := range polls {
-		if poll.PollID == pID {
+	var poll schema.Poll
+	for _, p := range polls {
+		if p.PollID == pID {
 			foundPollID = true
-			for _, option := range poll.PollOptions {
-				if option.PollOptionID == optID {
-					foundPollOptID = true
-				}
-			}
+			poll = p
 			break
 		}
 	}
 
 	if !foundPollID {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Could not find poll in cache"})
 		fmt.Println("Error getting poll: " + strconv.FormatUint(uint64(pID), 32))
-		return
+		return votes.Vote{}, schema.Poll{}, nil, &voteError{status: http.StatusNotFound, message: "Could not find poll in cache"}
 	}
 
-	if !foundPollOptID {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Could not find poll option in cache"})
-		fmt.Println("Error getting poll option: " + strconv.FormatUint(uint64(optID), 32))
-		return
+	if err := checkPollOpen(poll); err != nil {
+		return votes.Vote{}, schema.Poll{}, nil, err
 	}
 
-	voteID := c.Param("id")
-	voteIDUint, err := strconv.ParseUint(voteID, 10, 32)
-	if err != nil {
-		log.Println("Error converting vote ID to uint: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
-		return
+	if err := va.checkPollNotFrozen(poll.PollID); err != nil {
+		return votes.Vote{}, schema.Poll{}, nil, err
 	}
 
-	vote.VoteID = uint(voteIDUint)
-
-	if err := va.votesList.AddVote(vote); err != nil {
-		fmt.Println("Error adding vote")
-		log.Println("error adding item: ", err)
-		c.AbortWithStatus(http.StatusConflict)
-		return
+	if vote.VoteDate != nil {
+		voteDate := vote.VoteDate.UTC()
+		vote.VoteDate = &voteDate
+		if err := checkVoteDate(poll, voteDate, time.Now()); err != nil {
+			return votes.Vote{}, schema.Poll{}, nil, err
+		}
 	}
 
-	// After successfully adding the vote, add it to the voter's vote history.
-	voterID := vote.VoterID
+	if token != "" && !poll.Anonymous {
+		return votes.Vote{}, schema.Poll{}, nil, &voteError{status: http.StatusUnprocessableEntity, message: "Ballot tokens are only accepted by anonymous polls"}
+	}
 
-	// Construct the URL for adding the vote to the voter's vote history.
-	voterPollURL := fmt.Sprintf("%s/voters/%s/polls/%s", va.voterAPIURL, strconv.Itoa(int(voterID)), strconv.Itoa(int(vote.PollID)))
+	// A ballot token was issued for the poll, whoever holds it can vote.
+	if token == "" {
+		if err := va.checkEligibility(poll, vID); err != nil {
+			return votes.Vote{}, schema.Poll{}, nil, err
+		}
+	}
 
-	// Create a JSON payload for adding the vote to the voter's vote history.
-	// You can modify this payload according to your API's requirements.
-	voterPollPayload := map[string]interface{}{
-		"voteDate": time.Now(),
+	if vote.WriteInValue != "" {
+		if vote, err = checkWriteIn(poll, vote); err != nil {
+			return votes.Vote{}, schema.Poll{}, nil, err
+		}
 	}
 
-	// Make an HTTP POST request to add the vote to the voter's vote history using apiClient.
-	resp, err := va.apiClient.R().
-		SetHeader("Content-Type", "application/json").
-		SetBody(voterPollPayload).
-		Post(voterPollURL)
+	optionMaxVotes, err := checkSelections(poll, vote.Options())
 	if err != nil {
-		log.Println("Error performing POST request to add vote to voter's vote history: ", err)
-		log.Println(resp.StatusCode())
-		c.AbortWithStatus(http.StatusInternalServerError)
-		return
+		return votes.Vote{}, schema.Poll{}, nil, err
 	}
 
-	c.JSON(http.StatusOK, vote)
+	return vote, poll, optionMaxVotes, nil
 }
 
 // Implementation of DELETE /Votes/:id.
@@ -345,59 +724,155 @@ func (va *VotesAPI) DeleteVote(c *gin.Context) {
 	voteIDUint, err := strconv.ParseUint(voteID, 10, 32)
 	if err != nil {
 		log.Println("Error converting vote ID to uint: ", err)
-		c.AbortWithStatus(http.StatusBadRequest)
+		apierror.AbortInvalidID(c, "Vote ID", err)
 		return
 	}
 
-	vote, err := va.votesList.GetVote(uint(voteIDUint))
-	if err != nil {
-		log.Println("Error getting vote: ", err)
-		c.AbortWithStatus(http.StatusNotFound)
+	if err := va.removeVote(uint(voteIDUint)); err != nil {
+		abortWithVoteError(c, err)
 		return
 	}
 
-	// Delete the vote from the voter's vote history using the voter API.
-	voterID := vote.VoterID
-	voterPollURL := fmt.Sprintf("%s/voters/%s/polls/%s", va.voterAPIURL, strconv.Itoa(int(voterID)), strconv.Itoa(int(vote.PollID)))
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Vote deleted successfully.",
+	})
+}
 
-	// Make an HTTP DELETE request to remove the vote from the voter's vote history.
-	resp, err := va.apiClient.R().
-		Delete(voterPollURL)
+// Delete a vote and remove it from the voter's vote history, unless the
+// results of its poll are frozen. Failures are *voteError.
+func (va *VotesAPI) removeVote(voteID uint) error {
+	vote, err := va.votesList.GetVote(voteID)
 	if err != nil {
-		log.Println("Error performing DELETE request to remove vote from voter's vote history: ", err)
-		log.Println(resp.StatusCode())
-		c.AbortWithStatus(http.StatusInternalServerError)
-		return
+		log.Println("Error getting vote: ", err)
+		return &voteError{status: http.StatusNotFound}
+	}
+
+	if err := va.checkPollNotFrozen(vote.PollID); err != nil {
+		return err
+	}
+
+	// Delete the vote from the voter's vote history using the voter API. The
+	// voter of an anonymous vote is not known, their history keeps the poll.
+	if vote.VoterID != 0 {
+		if err := va.voters.deleteVoterPoll(vote.VoterID, vote.PollID); err != nil {
+			log.Println("Error removing vote from voter's vote history: ", err)
+			return &voteError{status: http.StatusInternalServerError}
+		}
 	}
 
 	// Now, delete the vote from your own votes cache.
-	if err := va.votesList.DeleteVote(uint(voteIDUint)); err != nil {
+	if err := va.votesList.DeleteVote(voteID); err != nil {
 		log.Println("Error deleting vote from cache: ", err)
-		c.AbortWithStatus(http.StatusInternalServerError)
-		return
+		if failover.IsFailoverError(err) {
+			return err
+		}
+		return &voteError{status: http.StatusInternalServerError}
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Vote deleted successfully.",
-	})
+	va.publishVoteDeleted(vote)
+
+	return nil
 }
 
 // Implementation of GET Votes/health.
 // Get the health status of the voter API.
 func (va *VotesAPI) HealthCheck(c *gin.Context) {
-	uptime := time.Since(va.bootTime).String()
-	averageRequestTime := time.Duration(0)
-	if va.totalCalls > 0 {
-		averageRequestTime = va.totalRequestTime / time.Duration(va.totalCalls)
+	report := va.metrics.Report()
+	report["schedulerLeader"] = va.scheduler.IsLeader()
+	report["jobs"] = va.scheduler.Stats()
+	report["storageCodec"] = va.votesCache.CodecStats()
+	report["redisShards"] = va.votesCache.ShardHealth()
+	report["redisFailover"] = failover.CurrentStats()
+	report["tallyBatching"] = va.votesCache.TallyBatchStats()
+	report["peerAPIs"] = va.compatibility.Peers()
+
+	c.JSON(http.StatusOK, report)
+}
+
+// Implementation of GET /healthz.
+// Liveness probe, reports that the process is up and serving requests.
+func (va *VotesAPI) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+	})
+}
+
+// Implementation of GET /readyz.
+// Ping the store, treating a store that failed to initialize as down.
+func pingStore(store votes.Store) error {
+	if store == nil {
+		return errors.New("store is not initialized")
+	}
+
+	return store.Ping()
+}
+
+// Return the checks of the stores of the votes API, for the boot report.
+func (va *VotesAPI) DependencyChecks() map[string]func() error {
+	checks := map[string]func() error{
+		"redis": va.votesCache.Ping,
+	}
+	if votes.StorageBackend() == votes.StorageBackendPostgres {
+		checks["postgres"] = func() error { return pingStore(va.votesList) }
+	}
+
+	return checks
+}
+
+// Readiness probe, checks every dependency of the votes API and returns
+// 503 with per-dependency detail when any of them is down.
+func (va *VotesAPI) Readiness(c *gin.Context) {
+	dependencies := map[string]interface{}{}
+	ready := true
+
+	if err := va.votesCache.Ping(); err != nil {
+		dependencies["redis"] = gin.H{"status": "down", "error": err.Error()}
+		ready = false
+	} else {
+		dependencies["redis"] = gin.H{"status": "up"}
+	}
+
+	if votes.StorageBackend() == votes.StorageBackendPostgres {
+		if err := pingStore(va.votesList); err != nil {
+			dependencies["postgres"] = gin.H{"status": "down", "error": err.Error()}
+			ready = false
+		} else {
+			dependencies["postgres"] = gin.H{"status": "up"}
+		}
+	}
+
+	// The peers are asked for their readiness: a peer that is alive but
+	// cannot reach its own storage cannot check the votes either.
+	voterAPIStatus := checkDependency(va.apiClient, va.voterAPIURL+"/readyz")
+	dependencies["voter-api"] = voterAPIStatus
+	ready = ready && voterAPIStatus["status"] == "up"
+
+	pollAPIStatus := checkDependency(va.apiClient, va.pollAPIURL+"/readyz")
+	dependencies["poll-api"] = pollAPIStatus
+	ready = ready && pollAPIStatus["status"] == "up"
+
+	// A degraded votes API is kept out of rotation until the APIs it calls
+	// speak a version it understands.
+	if va.compatMode == CompatibilityDegraded {
+		incompatible := va.compatibility.Incompatible()
+		if len(incompatible) > 0 {
+			dependencies["compatibility"] = gin.H{"status": "incompatible", "peers": incompatible}
+			ready = false
+		} else {
+			dependencies["compatibility"] = gin.H{"status": "up"}
+		}
+	}
+
+	if !ready {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status":       "not ready",
+			"dependencies": dependencies,
+		})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"status":             "ok",
-		"uptime":             uptime,
-		"totalAPICalls":      va.totalCalls,
-		"totalAPICallsError": va.errorCalls,
-		"bootTime":           va.bootTime,
-		"totalRequestTime":   va.totalRequestTime.String(),
-		"averageRequestTime": averageRequestTime.String(),
+		"status":       "ready",
+		"dependencies": dependencies,
 	})
 }
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"votes-api/votes"
+
+	"shared/apierror"
+	"shared/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The window and step of GET /votes/stats/:pollId when they are left out.
+const (
+	DefaultVoteStatsWindow = time.Hour
+	DefaultVoteStatsStep   = time.Minute
+)
+
+// Count a vote the poll received now, for GET /votes/stats/:pollId. A
+// counter that could not be written, or is not kept without redis, only
+// makes the stats short.
+func (va *VotesAPI) countVoteReceived(vote votes.Vote) {
+	if va.votesCache == nil {
+		return
+	}
+
+	if err := va.votesCache.CountVoteReceived(vote.PollID, time.Now()); err != nil {
+		log.Println("Error counting vote in vote stats: ", err)
+	}
+}
+
+// Parse a duration of the stats query, def when it is left out. It must be
+// a whole number of minutes.
+func parseStatsDuration(c *gin.Context, name string, def time.Duration) (time.Duration, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return def, true
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < time.Minute || duration%time.Minute != 0 {
+		validation.AbortWithFields(c, []validation.FieldError{{
+			Field:   name,
+			Rule:    "duration",
+			Message: name + " must be a duration of whole minutes, such as 1m or 1h",
+		}})
+		return 0, false
+	}
+
+	return duration, true
+}
+
+// Implementation of GET /votes/stats/:pollId?window=1h&step=1m.
+// Returns the votes the poll received in every step of the window that
+// ends now, from per-minute counters, so dashboards can graph the ballot
+// flow without reading the votes. The window is at most
+// votes.VoteStatsRetention and a multiple of the step.
+func (va *VotesAPI) GetVoteStats(c *gin.Context) {
+	pollIDUint, err := strconv.ParseUint(c.Param("pollId"), 10, 32)
+	if err != nil {
+		log.Println("Error converting poll ID to uint: ", err)
+		apierror.AbortInvalidID(c, "Poll ID", err)
+		return
+	}
+
+	window, ok := parseStatsDuration(c, "window", DefaultVoteStatsWindow)
+	if !ok {
+		return
+	}
+
+	step, ok := parseStatsDuration(c, "step", DefaultVoteStatsStep)
+	if !ok {
+		return
+	}
+
+	if window > votes.VoteStatsRetention || window%step != 0 {
+		validation.AbortWithFields(c, []validation.FieldError{{
+			Field:   "window",
+			Rule:    "window",
+			Message: "window must be a multiple of step and at most " + votes.VoteStatsRetention.String(),
+		}})
+		return
+	}
+
+	// The window takes in the current minute.
+	to := time.Now().UTC().Truncate(time.Minute).Add(time.Minute)
+	from := to.Add(-window)
+
+	buckets, err := va.votesCache.GetVoteStats(uint(pollIDUint), from, to, step)
+	if err != nil {
+		log.Println("Error getting vote stats: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not get vote stats", err)
+		return
+	}
+
+	var total uint
+	for _, bucket := range buckets {
+		total += bucket.Votes
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pollId":  uint(pollIDUint),
+		"window":  window.String(),
+		"step":    step.String(),
+		"from":    from,
+		"to":      to,
+		"total":   total,
+		"buckets": buckets,
+	})
+}
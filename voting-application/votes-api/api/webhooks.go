@@ -0,0 +1,33 @@
+package api
+
+import (
+	"time"
+
+	"votes-api/votes"
+
+	"shared/webhook"
+)
+
+// voteCastData is the data of a vote.cast delivery. It says which poll got
+// a vote, not who cast it or for what: the hooks are outside of the
+// services, where the secrecy of the ballot and the embargo and privacy
+// rules of the results are not kept.
+type voteCastData struct {
+	VoteID uint       `json:"voteId"`
+	PollID uint       `json:"pollId"`
+	CastAt *time.Time `json:"castAt,omitempty"`
+}
+
+// Return the registry of the webhooks, served on /webhooks.
+func (va *VotesAPI) Webhooks() *webhook.Registry {
+	return va.webhooks
+}
+
+// Deliver vote.cast to the hooks subscribed to it.
+func (va *VotesAPI) voteCast(vote votes.Vote) {
+	va.webhooks.Dispatch(webhook.EventVoteCast, voteCastData{
+		VoteID: vote.VoteID,
+		PollID: vote.PollID,
+		CastAt: vote.CastAt(),
+	})
+}
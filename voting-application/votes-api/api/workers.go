@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"shared/apierror"
+	"shared/keyaudit"
+	"shared/worker"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Register the background jobs of the votes API and start the scheduler.
+// Only the replica holding the leader lease runs them.
+func (va *VotesAPI) StartWorkers(ctx context.Context) {
+	va.scheduler.Register(worker.Job{
+		Name:     "retention",
+		Interval: va.retention.interval,
+		Run:      va.runRetention,
+	})
+
+	va.scheduler.Start(ctx)
+
+	// Every replica migrates the legacy keys it finds; a key moved by
+	// another one is skipped.
+	if va.votesCache != nil {
+		go keyaudit.Migrate(va.votesCache.AuditKeys)
+	}
+
+	go va.watchCompatibility(ctx)
+}
+
+// Implementation of GET /admin/jobs.
+// List the registered background jobs with their run status.
+func (va *VotesAPI) ListJobs(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"leader": va.scheduler.IsLeader(),
+		"jobs":   va.scheduler.Stats(),
+	})
+}
+
+// Implementation of POST /admin/jobs/:name/run.
+// Ask the leader to run a job on its next tick.
+func (va *VotesAPI) RunJob(c *gin.Context) {
+	va.controlJob(c, va.scheduler.Trigger, http.StatusAccepted, "Job run requested successfully.")
+}
+
+// Implementation of POST /admin/jobs/:name/pause.
+// Stop the scheduled runs of a job until it is resumed.
+func (va *VotesAPI) PauseJob(c *gin.Context) {
+	va.controlJob(c, va.scheduler.Pause, http.StatusOK, "Job paused successfully.")
+}
+
+// Implementation of POST /admin/jobs/:name/resume.
+// Restart the scheduled runs of a paused job.
+func (va *VotesAPI) ResumeJob(c *gin.Context) {
+	va.controlJob(c, va.scheduler.Resume, http.StatusOK, "Job resumed successfully.")
+}
+
+// Apply a job control to the job named in the path.
+func (va *VotesAPI) controlJob(c *gin.Context, control func(name string) error, status int, message string) {
+	name := c.Param("name")
+
+	if err := control(name); err != nil {
+		if errors.Is(err, worker.ErrUnknownJob) {
+			apierror.AbortWithDetails(c, http.StatusNotFound, apierror.CodeNotFound, "Unknown job", err)
+			return
+		}
+
+		log.Println("Error controlling job: ", err)
+		apierror.AbortWithError(c, http.StatusInternalServerError, "Could not control job", err)
+		return
+	}
+
+	log.Printf("Admin job control on %s: %s", name, message)
+	c.JSON(status, gin.H{"message": message})
+}
+
+// Write the vote counts that are still batched, so that stopping the
+// service does not lose them.
+func (va *VotesAPI) FlushTallies(ctx context.Context) error {
+	if va.votesCache == nil {
+		return nil
+	}
+
+	return va.votesCache.FlushTallies()
+}
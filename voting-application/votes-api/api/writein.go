@@ -0,0 +1,64 @@
+package api
+
+import (
+	"os"
+	"sort"
+	"strconv"
+)
+
+const (
+	DefaultWriteInMinCount = 3
+)
+
+// writeInResult is the number of votes a write-in text received.
+type writeInResult struct {
+	Text  string `json:"text" xml:"text" msgpack:"text"`
+	Votes uint   `json:"votes" xml:"votes" msgpack:"votes"`
+}
+
+// writeInTally is the write-in part of the results of a poll. Write-ins
+// under the minimum count are only counted in Other.
+type writeInTally struct {
+	TotalVotes uint            `json:"totalVotes" xml:"totalVotes" msgpack:"totalVotes"`
+	Results    []writeInResult `json:"results" xml:"results>writeIn" msgpack:"results"`
+	Other      uint            `json:"other" xml:"other" msgpack:"other"`
+}
+
+// Load how many votes a write-in needs to be listed in the results,
+// WRITE_IN_MIN_COUNT or DefaultWriteInMinCount.
+func loadWriteInMinCount() uint {
+	if count, err := strconv.ParseUint(os.Getenv("WRITE_IN_MIN_COUNT"), 10, 32); err == nil && count > 0 {
+		return uint(count)
+	}
+
+	return DefaultWriteInMinCount
+}
+
+// Group the write-ins of a poll by their normalized text, most votes
+// first. Texts with fewer than minCount votes are summed in Other.
+func (va *VotesAPI) tallyWriteIns(pollID, minCount uint) (writeInTally, error) {
+	counts, err := va.votesList.GetWriteInCounts(pollID)
+	if err != nil {
+		return writeInTally{}, err
+	}
+
+	tally := writeInTally{Results: make([]writeInResult, 0, len(counts))}
+	for text, count := range counts {
+		tally.TotalVotes += count
+		if count < minCount {
+			tally.Other += count
+			continue
+		}
+
+		tally.Results = append(tally.Results, writeInResult{Text: text, Votes: count})
+	}
+
+	sort.Slice(tally.Results, func(i, j int) bool {
+		if tally.Results[i].Votes != tally.Results[j].Votes {
+			return tally.Results[i].Votes > tally.Results[j].Votes
+		}
+		return tally.Results[i].Text < tally.Results[j].Text
+	})
+
+	return tally, nil
+}
@@ -1,51 +1,37 @@
 package main
 
 import (
-	"flag"
-	"fmt"
+	"context"
 
 	"votes-api/api"
+	"votes-api/votes"
 
-	"github.com/gin-contrib/cors"
-	"github.com/gin-gonic/gin"
+	"shared/bootstrap"
+	"shared/endpoints"
 )
 
-var (
-	hostFlag    string
-	portFlag    uint
-	voterAPIURL string
-	pollAPIURL  string
-)
-
-func processCmdLineFlags() {
-	flag.StringVar(&hostFlag, "h", "0.0.0.0", "Listen on all interfaces")
-	flag.StringVar(&voterAPIURL, "v", "http://host.docker.internal:1080", "Default voter API location")
-	flag.StringVar(&pollAPIURL, "papi", "http://host.docker.internal:1081", "Default poll API location")
-	flag.UintVar(&portFlag, "p", 1082, "Default Port")
-
-	flag.Parse()
-}
-
 func main() {
-	processCmdLineFlags()
-	r := gin.Default()
-	r.Use(cors.Default())
-
-	// Create a new instance of the VoterAPI handler.
-	voterHandler := api.NewVotesHandler(pollAPIURL, voterAPIURL)
-
-	// Register the HealthMiddleware, it will be called for every request.
-	r.Use(api.HealthMiddleware(voterHandler))
-
-	// Define the API endpoints and map them to the corresponding handler.
-	r.GET("/", voterHandler.WelcomeToVotesAPI)
-	r.GET("/votes", voterHandler.ListAllVotes)
-	r.GET("/votes/:id", voterHandler.GetVote)
-	r.POST("/votes/:id", voterHandler.AddVote)
-	r.DELETE("/votes/:id", voterHandler.DeleteVote)
-	r.GET("/votes/health", voterHandler.HealthCheck)
-
-	// Start the server.
-	serverPath := fmt.Sprintf("%s:%d", hostFlag, portFlag)
-	r.Run(serverPath)
+	server := bootstrap.New("votes-api", bootstrap.Config{
+		Port:      1082,
+		GRPCPort:  2082,
+		Endpoints: []endpoints.Endpoint{endpoints.VoterAPI, endpoints.PollAPI},
+	})
+
+	// Create a new instance of the VotesAPI handler.
+	votesHandler := api.NewVotesHandler(server.URL(endpoints.PollAPI), server.URL(endpoints.VoterAPI))
+
+	// Refuse to start against a voter or poll API of an incompatible
+	// version.
+	votesHandler.CheckCompatibility(context.Background())
+
+	server.Run(
+		bootstrap.WithMiddleware(api.HealthMiddleware(votesHandler), votesHandler.APIKeys().Middleware()),
+		bootstrap.WithRoutes(routeTable(votesHandler).Register),
+		bootstrap.WithWorkers(votesHandler.StartWorkers),
+		bootstrap.WithGRPC(votesHandler.RegisterGRPC),
+		bootstrap.WithSeeder(votesHandler.Seed),
+		bootstrap.WithShutdownHook(votesHandler.FlushTallies),
+		bootstrap.WithStorageBackend(votes.StorageBackend()),
+		bootstrap.WithDependencyChecks(votesHandler.DependencyChecks()),
+	)
 }
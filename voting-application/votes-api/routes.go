@@ -0,0 +1,107 @@
+package main
+
+import (
+	"net/http"
+
+	"votes-api/api"
+
+	"shared/apierror"
+	"shared/routes"
+	"shared/seed"
+	"shared/snapshot"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	exportLimit    = routes.PerMinute(10)
+	analyticsLimit = routes.PerMinute(30)
+	rebuildLimit   = routes.PerMinute(2)
+	verifyLimit    = routes.PerMinute(60)
+	adminScope     = []string{routes.ScopeAdmin}
+)
+
+// Define the API endpoints and map them to the corresponding handler.
+func routeTable(votesHandler *api.VotesAPI) *routes.Table {
+	return &routes.Table{
+		Service: "votes-api",
+		Welcome: "Welcome to votes API.",
+		Scopes: []routes.Scope{
+			{
+				Name:        routes.ScopeAdmin,
+				Description: "Requires the X-Admin-Token header matching ADMIN_TOKEN",
+				Middleware:  api.AdminMiddleware(),
+				Header:      api.AdminTokenHeader,
+			},
+		},
+		Routes: []routes.Route{
+			{Method: http.MethodGet, Path: "/votes", Handler: votesHandler.ListAllVotes, Summary: "List every vote, or those of ?pollId= and ?voterId="},
+			{Method: http.MethodGet, Path: "/votes/export", Handler: votesHandler.ExportVotes, Summary: "Export every vote as CSV, JSON or Parquet", Limit: exportLimit},
+			{Method: http.MethodGet, Path: "/votes/:id", Handler: votesHandler.GetVote, Summary: "Get a vote"},
+			{Method: http.MethodGet, Path: "/votes/verify/:receipt", Handler: votesHandler.VerifyReceipt, Summary: "Check that the vote of a receipt is recorded unchanged", Limit: verifyLimit},
+			{
+				Method:     http.MethodPost,
+				Path:       "/votes/:id",
+				Middleware: []gin.HandlerFunc{api.IdempotencyMiddleware(votesHandler)},
+				Handler:    votesHandler.AddVote,
+				Summary:    "Cast a vote, safe to repeat with an Idempotency-Key header",
+			},
+			{Method: http.MethodPost, Path: "/votes/:id/prepare", Handler: votesHandler.PrepareVote, Summary: "Check a vote and hold it for VOTE_HOLD_TTL until it is confirmed"},
+			{
+				Method:     http.MethodPost,
+				Path:       "/votes/:id/confirm",
+				Middleware: []gin.HandlerFunc{api.IdempotencyMiddleware(votesHandler)},
+				Handler:    votesHandler.ConfirmVote,
+				Summary:    "Cast a prepared vote with its confirmation token",
+			},
+			{Method: http.MethodDelete, Path: "/votes", Handler: votesHandler.DeleteAllVotes, Summary: "Delete every vote, for cleaning up test environments", Scopes: adminScope},
+			{Method: http.MethodDelete, Path: "/votes/:id", Handler: votesHandler.DeleteVote, Summary: "Delete a vote"},
+			{Method: http.MethodDelete, Path: "/votes/by-poll/:pollId", Handler: votesHandler.DeletePollVotes, Summary: "Delete the votes of a poll and strip it from the vote histories, ?dryRun=true only reports"},
+			{Method: http.MethodDelete, Path: "/votes/by-voter/:voterId", Handler: votesHandler.DeleteVoterVotes, Summary: "Delete the votes of a voter, ?dryRun=true only reports"},
+			{Method: http.MethodGet, Path: "/votes/results/:pollId", Handler: votesHandler.GetPollResults, Summary: "Get the results of a poll"},
+			{Method: http.MethodGet, Path: "/votes/results/:pollId/snapshot", Handler: votesHandler.GetResultSnapshot, Summary: "Get the results of a poll as they were frozen when it closed"},
+			{Method: http.MethodGet, Path: "/votes/analytics/overlap", Handler: votesHandler.GetPollOverlap, Summary: "Count the voters two polls share", Limit: analyticsLimit},
+			{Method: http.MethodGet, Path: "/votes/stats/:pollId", Handler: votesHandler.GetVoteStats, Summary: "Count the votes a poll received per step of a window, ?window=1h&step=1m", Limit: analyticsLimit},
+			{Method: http.MethodGet, Path: "/reports/turnout/:pollId", Handler: votesHandler.GetTurnoutReport, Summary: "Report the turnout of a poll by voter status and registration cohort, as JSON or CSV", Limit: analyticsLimit},
+			{Method: http.MethodGet, Path: "/votes/health", Handler: votesHandler.HealthCheck, Summary: "Request metrics of the API", Access: routes.Internal},
+			{Method: http.MethodGet, Path: "/healthz", Handler: votesHandler.Liveness, Summary: "Liveness probe", Access: routes.Internal},
+			{Method: http.MethodGet, Path: "/readyz", Handler: votesHandler.Readiness, Summary: "Readiness probe", Access: routes.Internal},
+
+			{Method: http.MethodPost, Path: "/admin/ballot-tokens", Handler: votesHandler.AddBallotTokens, Summary: "Issue ballot tokens for an anonymous poll", Scopes: adminScope},
+			{Method: http.MethodGet, Path: "/admin/ballot-tokens/:pollId", Handler: votesHandler.CountBallotTokens, Summary: "Count the unused ballot tokens of a poll", Scopes: adminScope},
+			{Method: http.MethodPost, Path: "/admin/embargo-tokens", Handler: votesHandler.AddEmbargoToken, Summary: "Create an embargo token", Scopes: adminScope},
+			{Method: http.MethodGet, Path: "/admin/embargo-tokens", Handler: votesHandler.ListEmbargoTokens, Summary: "List the embargo tokens", Scopes: adminScope},
+			{Method: http.MethodDelete, Path: "/admin/embargo-tokens/:token", Handler: votesHandler.DeleteEmbargoToken, Summary: "Revoke an embargo token", Scopes: adminScope},
+			{Method: http.MethodPost, Path: "/admin/participation/rebuild", Handler: votesHandler.RebuildParticipation, Summary: "Rebuild the participation sets of the polls", Scopes: adminScope, Limit: rebuildLimit},
+			{Method: http.MethodPost, Path: "/admin/tally/rebuild", Handler: votesHandler.RebuildTallies, Summary: "Rebuild the vote counts of the polls", Scopes: adminScope, Limit: rebuildLimit},
+			{Method: http.MethodPost, Path: "/admin/votes/index/rebuild", Handler: votesHandler.RebuildVoteIndex, Summary: "Rebuild the poll and voter sets of GET /votes?pollId=&voterId=", Scopes: adminScope, Limit: rebuildLimit},
+			{Method: http.MethodPost, Path: "/admin/votes/timestamps/backfill", Handler: votesHandler.BackfillVoteTimes, Summary: "Give the votes without timestamps the vote date of the voter history", Scopes: adminScope, Limit: rebuildLimit},
+			{Method: http.MethodPost, Path: "/admin/votes/:id/flag", Handler: votesHandler.FlagVote, Summary: "Flag a vote for review", Scopes: adminScope},
+			{Method: http.MethodPost, Path: "/admin/polls/:pollId/freeze", Handler: votesHandler.FreezePollResults, Summary: "Freeze the results of a closed poll, which the poll API does when it closes one", Scopes: adminScope},
+			{Method: http.MethodPost, Path: "/admin/polls/:pollId/revalidate", Handler: votesHandler.RevalidatePollVotes, Summary: "Check the votes of a poll against its options, ?void=true deletes invalid ones", Scopes: adminScope},
+			{Method: http.MethodGet, Path: "/admin/summary", Handler: votesHandler.GetAdminSummary, Summary: "Get the totals of voters, polls and votes for the admin dashboard", Scopes: adminScope, Limit: analyticsLimit},
+			{Method: http.MethodGet, Path: "/admin/reconciliation", Handler: votesHandler.ReconcilePoll, Summary: "Compare the votes of ?pollId= with the vote histories of the voters", Scopes: adminScope, Limit: analyticsLimit},
+			{Method: http.MethodPost, Path: "/admin/reconciliation", Handler: votesHandler.ReconcilePoll, Summary: "Fix the vote histories of ?pollId= to match its votes", Scopes: adminScope, Limit: rebuildLimit},
+			{Method: http.MethodGet, Path: "/admin/retention/report", Handler: votesHandler.GetRetentionReport, Summary: "Report the votes past their retention period", Scopes: adminScope},
+			{Method: http.MethodGet, Path: "/admin/expirations", Handler: votesHandler.GetExpirations, Summary: "List the votes that will expire, those of test polls", Scopes: adminScope},
+			{Method: http.MethodGet, Path: "/admin/redis/shards", Handler: votesHandler.GetRedisShards, Summary: "List the Redis shards and their documents", Scopes: adminScope},
+			{Method: http.MethodPost, Path: "/admin/redis/rebalance", Handler: votesHandler.RebalanceRedisShards, Summary: "Move documents to the shard the hash ring assigns them", Scopes: adminScope, Limit: rebuildLimit},
+			{Method: http.MethodPost, Path: "/admin/redis/audit", Handler: votesHandler.AuditRedisKeys, Summary: "Audit the Redis keyspace and fix legacy, duplicated or broken keys", Scopes: adminScope, Limit: rebuildLimit},
+			{Method: http.MethodGet, Path: apierror.RecentErrorsPath, Handler: apierror.RecentErrors.ServeRecentErrors, Summary: "List the latest error responses of the service", Scopes: adminScope},
+			{Method: http.MethodPost, Path: snapshot.ImportPath, Handler: snapshot.Handler("votes", votesHandler.ImportSnapshot), Summary: "Restore the votes of a snapshot into an empty votes API", Scopes: adminScope, Limit: rebuildLimit},
+			{Method: http.MethodPost, Path: "/admin/seed", Handler: seed.Handler(votesHandler.Seed), Summary: "Cast the votes of a JSON or YAML fixture, or of the -seed file", Scopes: adminScope, Limit: rebuildLimit},
+			{Method: http.MethodGet, Path: "/admin/jobs", Handler: votesHandler.ListJobs, Summary: "List the background jobs and their last runs", Scopes: adminScope},
+			{Method: http.MethodPost, Path: "/admin/jobs/:name/run", Handler: votesHandler.RunJob, Summary: "Run a background job now", Scopes: adminScope},
+			{Method: http.MethodPost, Path: "/admin/jobs/:name/pause", Handler: votesHandler.PauseJob, Summary: "Pause a background job", Scopes: adminScope},
+			{Method: http.MethodPost, Path: "/admin/jobs/:name/resume", Handler: votesHandler.ResumeJob, Summary: "Resume a paused background job", Scopes: adminScope},
+			{Method: http.MethodPost, Path: "/webhooks", Handler: votesHandler.Webhooks().ServeAddHook, Summary: "Register a URL for voter.created, poll.closed or vote.cast events", Scopes: adminScope},
+			{Method: http.MethodGet, Path: "/webhooks", Handler: votesHandler.Webhooks().ServeListHooks, Summary: "List the registered webhooks", Scopes: adminScope},
+			{Method: http.MethodGet, Path: "/webhooks/:id", Handler: votesHandler.Webhooks().ServeGetHook, Summary: "Get a webhook", Scopes: adminScope},
+			{Method: http.MethodDelete, Path: "/webhooks/:id", Handler: votesHandler.Webhooks().ServeDeleteHook, Summary: "Delete a webhook and its failed deliveries", Scopes: adminScope},
+			{Method: http.MethodGet, Path: "/webhooks/:id/failures", Handler: votesHandler.Webhooks().ServeHookFailures, Summary: "List the deliveries to a webhook that failed after every retry", Scopes: adminScope},
+			{Method: http.MethodPost, Path: "/admin/apikeys", Handler: votesHandler.APIKeys().ServeCreateKey, Summary: "Create an API key with scopes such as read:polls or write:votes", Scopes: adminScope},
+			{Method: http.MethodGet, Path: "/admin/apikeys", Handler: votesHandler.APIKeys().ServeListKeys, Summary: "List the API keys, without the keys themselves", Scopes: adminScope},
+			{Method: http.MethodDelete, Path: "/admin/apikeys/:id", Handler: votesHandler.APIKeys().ServeRevokeKey, Summary: "Revoke an API key", Scopes: adminScope},
+		},
+	}
+}
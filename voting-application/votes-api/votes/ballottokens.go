@@ -0,0 +1,78 @@
+package votes
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strconv"
+)
+
+const (
+	BallotTokenKeyPrefix = "ballot-tokens:"
+)
+
+// Get a string that can be used as the key of the unused ballot tokens
+// of a poll in redis.
+func ballotTokensKeyFromId(pollID uint) string {
+	return BallotTokenKeyPrefix + strconv.FormatUint(uint64(pollID), 10)
+}
+
+// Only a hash of every token is kept, so the tokens cannot be read back
+// from redis.
+func ballotTokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Issue count new ballot tokens for a poll. Each token casts one vote in
+// the poll; only the returned tokens can be handed out, they are not kept.
+func (vc *VotesCache) IssueBallotTokens(pollID uint, count int) ([]string, error) {
+	if vc == nil {
+		return nil, errors.New("redis is not connected")
+	}
+
+	tokens := make([]string, count)
+	hashes := make([]interface{}, count)
+	for i := range tokens {
+		tokenBytes := make([]byte, 24)
+		if _, err := rand.Read(tokenBytes); err != nil {
+			return nil, err
+		}
+
+		tokens[i] = base64.RawURLEncoding.EncodeToString(tokenBytes)
+		hashes[i] = ballotTokenHash(tokens[i])
+	}
+
+	if err := vc.cacheClient.SAdd(vc.context, ballotTokensKeyFromId(pollID), hashes...).Err(); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// Use up a ballot token of a poll, reporting false when the poll has no
+// such unused token.
+func (vc *VotesCache) RedeemBallotToken(pollID uint, token string) (bool, error) {
+	if vc == nil {
+		return false, errors.New("redis is not connected")
+	}
+
+	removed, err := vc.cacheClient.SRem(vc.context, ballotTokensKeyFromId(pollID), ballotTokenHash(token)).Result()
+	return removed > 0, err
+}
+
+// Give back a redeemed ballot token whose vote could not be stored.
+func (vc *VotesCache) ReturnBallotToken(pollID uint, token string) error {
+	return vc.cacheClient.SAdd(vc.context, ballotTokensKeyFromId(pollID), ballotTokenHash(token)).Err()
+}
+
+// Count the unused ballot tokens of a poll.
+func (vc *VotesCache) CountBallotTokens(pollID uint) (int64, error) {
+	if vc == nil {
+		return 0, errors.New("redis is not connected")
+	}
+
+	return vc.cacheClient.SCard(vc.context, ballotTokensKeyFromId(pollID)).Result()
+}
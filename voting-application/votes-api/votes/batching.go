@@ -0,0 +1,229 @@
+package votes
+
+import (
+	"log"
+	"math/rand"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultTallyFlushInterval = 50 * time.Millisecond
+)
+
+// tallyBatcher coalesces the vote count increments of uncapped options in
+// process and writes the summed deltas to redis in one pipeline per flush,
+// so a burst of votes does not turn into a write per vote. The votes
+// themselves are still written synchronously; counts that were not flushed
+// when a replica dies can be recovered with RebuildTallies.
+type tallyBatcher struct {
+	interval time.Duration
+	lock     sync.Mutex
+	pending  map[uint]map[uint]int64
+	stats    TallyBatchStats
+}
+
+// TallyBatchStats describes the write batching of a VotesCache.
+type TallyBatchStats struct {
+	IntervalMs      int64  `json:"intervalMs"`
+	PendingDeltas   int    `json:"pendingDeltas"`
+	Increments      uint64 `json:"increments"`
+	Flushes         uint64 `json:"flushes"`
+	FlushedWrites   uint64 `json:"flushedWrites"`
+	FailedFlushes   uint64 `json:"failedFlushes"`
+	CoalescedWrites uint64 `json:"coalescedWrites"`
+}
+
+// Load the batching configuration. TALLY_FLUSH_INTERVAL is the time
+// between flushes, such as 50ms; 0 turns batching off and writes every
+// increment directly.
+func loadTallyBatcher() *tallyBatcher {
+	batcher := &tallyBatcher{
+		interval: DefaultTallyFlushInterval,
+		pending:  make(map[uint]map[uint]int64),
+	}
+
+	if value := os.Getenv("TALLY_FLUSH_INTERVAL"); value != "" {
+		interval, err := time.ParseDuration(value)
+		if value == "0" {
+			interval, err = 0, nil
+		}
+
+		if err == nil && interval >= 0 {
+			batcher.interval = interval
+		} else {
+			log.Printf("Invalid TALLY_FLUSH_INTERVAL %q, using %s", value, DefaultTallyFlushInterval)
+		}
+	}
+
+	return batcher
+}
+
+// Report whether increments are batched.
+func (tb *tallyBatcher) enabled() bool {
+	return tb.interval > 0
+}
+
+// Queue an increment of the vote count of an option.
+func (tb *tallyBatcher) add(pollID, optionID uint) {
+	tb.lock.Lock()
+	defer tb.lock.Unlock()
+
+	options, ok := tb.pending[pollID]
+	if !ok {
+		options = make(map[uint]int64)
+		tb.pending[pollID] = options
+	}
+
+	options[optionID]++
+	tb.stats.Increments++
+}
+
+// Take back a queued increment of an option. It reports false when there
+// is none, the count then has to be released in redis.
+func (tb *tallyBatcher) cancel(pollID, optionID uint) bool {
+	tb.lock.Lock()
+	defer tb.lock.Unlock()
+
+	if tb.pending[pollID][optionID] <= 0 {
+		return false
+	}
+
+	tb.pending[pollID][optionID]--
+	return true
+}
+
+// Return the queued deltas of a poll.
+func (tb *tallyBatcher) pendingFor(pollID uint) map[uint]int64 {
+	tb.lock.Lock()
+	defer tb.lock.Unlock()
+
+	deltas := make(map[uint]int64, len(tb.pending[pollID]))
+	for optionID, delta := range tb.pending[pollID] {
+		deltas[optionID] = delta
+	}
+
+	return deltas
+}
+
+// Take every queued delta, leaving none.
+func (tb *tallyBatcher) take() map[uint]map[uint]int64 {
+	tb.lock.Lock()
+	defer tb.lock.Unlock()
+
+	pending := tb.pending
+	tb.pending = make(map[uint]map[uint]int64)
+
+	return pending
+}
+
+// Queue deltas again after a failed flush.
+func (tb *tallyBatcher) restore(pending map[uint]map[uint]int64) {
+	tb.lock.Lock()
+	defer tb.lock.Unlock()
+
+	for pollID, deltas := range pending {
+		options, ok := tb.pending[pollID]
+		if !ok {
+			options = make(map[uint]int64)
+			tb.pending[pollID] = options
+		}
+
+		for optionID, delta := range deltas {
+			options[optionID] += delta
+		}
+	}
+}
+
+// Start the background flusher of the VotesCache when batching is on.
+func (vc *VotesCache) startTallyFlusher() {
+	if !vc.batcher.enabled() {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(vc.batcher.interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := vc.FlushTallies(); err != nil {
+				log.Println("Error flushing tallies: ", err)
+			}
+		}
+	}()
+}
+
+// Write the queued vote count deltas to redis in a single pipeline. Each
+// delta goes to a random shard of its poll. Deltas that could not be
+// written are queued again.
+func (vc *VotesCache) FlushTallies() error {
+	pending := vc.batcher.take()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	pipe := vc.cacheClient.Pipeline()
+	var writes, increments uint64
+	for pollID, deltas := range pending {
+		vc.sharding.lock.Lock()
+		shards := vc.sharding.shards[pollID]
+		vc.sharding.lock.Unlock()
+		if shards == 0 {
+			shards = 1
+		}
+
+		for optionID, delta := range deltas {
+			if delta == 0 {
+				continue
+			}
+
+			shardKey := tallyShardKey(pollID, uint(rand.Intn(int(shards))))
+			pipe.HIncrBy(vc.context, shardKey, strconv.Itoa(int(optionID)), delta)
+			writes++
+			increments += uint64(delta)
+		}
+	}
+
+	if writes == 0 {
+		return nil
+	}
+
+	if _, err := pipe.Exec(vc.context); err != nil {
+		vc.batcher.restore(pending)
+
+		vc.batcher.lock.Lock()
+		vc.batcher.stats.FailedFlushes++
+		vc.batcher.lock.Unlock()
+
+		return err
+	}
+
+	vc.batcher.lock.Lock()
+	vc.batcher.stats.Flushes++
+	vc.batcher.stats.FlushedWrites += writes
+	vc.batcher.stats.CoalescedWrites += increments - writes
+	vc.batcher.lock.Unlock()
+
+	return nil
+}
+
+// Return the write batching metrics of the VotesCache, or nil when it is
+// not connected.
+func (vc *VotesCache) TallyBatchStats() *TallyBatchStats {
+	if vc == nil {
+		return nil
+	}
+
+	vc.batcher.lock.Lock()
+	defer vc.batcher.lock.Unlock()
+
+	stats := vc.batcher.stats
+	stats.IntervalMs = vc.batcher.interval.Milliseconds()
+	for _, deltas := range vc.batcher.pending {
+		stats.PendingDeltas += len(deltas)
+	}
+
+	return &stats
+}
@@ -0,0 +1,115 @@
+package votes
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	EmbargoKeyPrefix = "embargo:"
+)
+
+// EmbargoToken grants early access to the results of a closed but
+// uncertified poll until it expires.
+type EmbargoToken struct {
+	Token     string    `json:"token"`
+	PollID    uint      `json:"pollId"`
+	IssuedTo  string    `json:"issuedTo"`
+	IssuedAt  time.Time `json:"issuedAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Get a string that can be used as an embargo token key in redis.
+func embargoKeyFromToken(token string) string {
+	return EmbargoKeyPrefix + token
+}
+
+// Issue a new embargo token for a poll that is valid for ttl.
+func (vc *VotesCache) CreateEmbargoToken(pollID uint, issuedTo string, ttl time.Duration) (EmbargoToken, error) {
+	tokenBytes := make([]byte, 16)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return EmbargoToken{}, err
+	}
+
+	now := time.Now()
+	token := EmbargoToken{
+		Token:     hex.EncodeToString(tokenBytes),
+		PollID:    pollID,
+		IssuedTo:  issuedTo,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(ttl),
+	}
+
+	data, err := json.Marshal(token)
+	if err != nil {
+		return EmbargoToken{}, err
+	}
+
+	if err := vc.cacheClient.Set(vc.context, embargoKeyFromToken(token.Token), data, ttl).Err(); err != nil {
+		return EmbargoToken{}, err
+	}
+
+	return token, nil
+}
+
+// Retrieve an unexpired embargo token.
+func (vc *VotesCache) GetEmbargoToken(token string) (EmbargoToken, error) {
+	var embargoToken EmbargoToken
+
+	data, err := vc.cacheClient.Get(vc.context, embargoKeyFromToken(token)).Bytes()
+	if err != nil {
+		return EmbargoToken{}, errors.New("embargo token does not exist")
+	}
+
+	if err := json.Unmarshal(data, &embargoToken); err != nil {
+		return EmbargoToken{}, err
+	}
+
+	return embargoToken, nil
+}
+
+// Return all unexpired embargo tokens.
+func (vc *VotesCache) GetAllEmbargoTokens() ([]EmbargoToken, error) {
+	var tokens []EmbargoToken
+
+	pattern := fmt.Sprintf("%s*", EmbargoKeyPrefix)
+	keys, err := vc.cacheClient.Keys(vc.context, pattern).Result()
+	if err != nil {
+		return tokens, err
+	}
+
+	for _, key := range keys {
+		data, err := vc.cacheClient.Get(vc.context, key).Bytes()
+		if err != nil {
+			// The token expired between KEYS and GET.
+			continue
+		}
+
+		var token EmbargoToken
+		if err := json.Unmarshal(data, &token); err != nil {
+			return tokens, err
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	return tokens, nil
+}
+
+// Revoke an embargo token before it expires.
+func (vc *VotesCache) DeleteEmbargoToken(token string) error {
+	deleted, err := vc.cacheClient.Del(vc.context, embargoKeyFromToken(token)).Result()
+	if err != nil {
+		return err
+	}
+
+	if deleted == 0 {
+		return errors.New("embargo token does not exist")
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package votes
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"shared/repository"
+)
+
+const DefaultTestPollTag = "test"
+
+// testVoteTTL is how long the votes of test polls, the polls with the tag,
+// are kept. A zero ttl keeps them forever.
+type testVoteTTL struct {
+	tag string
+	ttl time.Duration
+}
+
+// Load the TTL of the votes of test polls from the environment.
+// TTL_TEST_VOTES_DAYS keeps them for N days after they were cast and
+// TTL_TEST_POLL_TAG sets the tag of test polls, test by default.
+func loadTestVoteTTL() testVoteTTL {
+	config := testVoteTTL{tag: DefaultTestPollTag}
+
+	if days, err := strconv.ParseUint(os.Getenv("TTL_TEST_VOTES_DAYS"), 10, 32); err == nil {
+		config.ttl = time.Duration(days) * 24 * time.Hour
+	}
+
+	if tag := strings.TrimSpace(os.Getenv("TTL_TEST_POLL_TAG")); tag != "" {
+		config.tag = strings.ToLower(tag)
+	}
+
+	return config
+}
+
+// Return when redis deletes a vote, the zero time when it never does.
+func voteExpiry(vote Vote) time.Time {
+	if vote.ExpiresAt == nil {
+		return time.Time{}
+	}
+
+	return *vote.ExpiresAt
+}
+
+// Return when a vote cast at castAt in a poll with the tags expires: the
+// TTL of test votes after it was cast in a test poll, never otherwise.
+func (vc *VotesCache) VoteExpiry(tags []string, castAt time.Time) *time.Time {
+	if vc.testTTL.ttl == 0 {
+		return nil
+	}
+
+	for _, tag := range tags {
+		if strings.EqualFold(tag, vc.testTTL.tag) {
+			expiresAt := castAt.Add(vc.testTTL.ttl)
+			return &expiresAt
+		}
+	}
+
+	return nil
+}
+
+// Return the tag of test polls and how long their votes are kept, forever
+// when zero.
+func (vc *VotesCache) TestVoteTTL() (string, time.Duration) {
+	return vc.testTTL.tag, vc.testTTL.ttl
+}
+
+// Return the votes that will expire, the first to expire first.
+func (vc *VotesCache) GetExpirations() ([]repository.Expiration, error) {
+	return vc.votes.Expirations()
+}
@@ -0,0 +1,184 @@
+package votes
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	VoteHoldKeyPrefix = "vote-hold:"
+
+	// How long a prepared vote waits for its confirmation when
+	// VOTE_HOLD_TTL is not set.
+	DefaultVoteHoldTTL = 2 * time.Minute
+)
+
+var (
+	ErrVoteHeld         = errors.New("vote is prepared and waiting for its confirmation")
+	ErrVoteHoldNotFound = errors.New("prepared vote does not exist or has expired")
+	ErrVoteHoldToken    = errors.New("confirmation token does not match the prepared vote")
+)
+
+// VoteHold is a vote prepared by POST /votes/:id/prepare, kept until it is
+// confirmed or expires.
+type VoteHold struct {
+	Vote              Vote      `json:"vote"`
+	ConfirmationToken string    `json:"confirmationToken"`
+	PreparedAt        time.Time `json:"preparedAt"`
+	ExpiresAt         time.Time `json:"expiresAt"`
+}
+
+// Get the key of the hold of a vote ID, such as vote-hold:1.
+func voteHoldKey(voteID uint) string {
+	return VoteHoldKeyPrefix + strconv.FormatUint(uint64(voteID), 10)
+}
+
+// Get the key of the hold a voter has on their vote in a poll, such as
+// vote-hold:poll:1:voter:2.
+func voterHoldKey(pollID, voterID uint) string {
+	return fmt.Sprintf("%spoll:%d:voter:%d", VoteHoldKeyPrefix, pollID, voterID)
+}
+
+// Hold the ID of a prepared vote, and the vote of its voter in the poll,
+// for ttl, and return the hold with the token that confirms it. Nothing
+// else is changed, so a hold that expires releases the vote without
+// leaving anything behind. It returns ErrVoteHeld when either is held
+// already.
+func (vc *VotesCache) HoldVote(vote Vote, ttl time.Duration) (VoteHold, error) {
+	tokenBytes := make([]byte, 16)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return VoteHold{}, err
+	}
+
+	now := time.Now()
+	hold := VoteHold{
+		Vote:              vote,
+		ConfirmationToken: hex.EncodeToString(tokenBytes),
+		PreparedAt:        now,
+		ExpiresAt:         now.Add(ttl),
+	}
+
+	data, err := json.Marshal(hold)
+	if err != nil {
+		return VoteHold{}, err
+	}
+
+	held, err := vc.cacheClient.SetNX(vc.context, voteHoldKey(vote.VoteID), data, ttl).Result()
+	if err != nil {
+		return VoteHold{}, err
+	}
+	if !held {
+		return VoteHold{}, ErrVoteHeld
+	}
+
+	// A vote without a voter has no voter to hold.
+	if vote.VoterID == 0 {
+		return hold, nil
+	}
+
+	held, err = vc.cacheClient.SetNX(vc.context, voterHoldKey(vote.PollID, vote.VoterID), vote.VoteID, ttl).Result()
+	if err == nil && !held {
+		err = ErrVoteHeld
+	}
+	if err != nil {
+		vc.cacheClient.Del(vc.context, voteHoldKey(vote.VoteID))
+		return VoteHold{}, err
+	}
+
+	return hold, nil
+}
+
+// Report whether the ID of a vote, or the vote of its voter in its poll, is
+// held by a prepared vote.
+func (vc *VotesCache) VoteHeld(vote Vote) (bool, error) {
+	keys := []string{voteHoldKey(vote.VoteID)}
+	if vote.VoterID != 0 {
+		keys = append(keys, voterHoldKey(vote.PollID, vote.VoterID))
+	}
+
+	count, err := vc.cacheClient.Exists(vc.context, keys...).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// Take the hold of a prepared vote when token is its confirmation token,
+// releasing the vote so it can be cast. Only one of concurrent calls gets
+// the hold, the others get ErrVoteHoldNotFound.
+func (vc *VotesCache) TakeVoteHold(voteID uint, token string) (VoteHold, error) {
+	data, err := vc.cacheClient.Get(vc.context, voteHoldKey(voteID)).Result()
+	if err == redis.Nil {
+		return VoteHold{}, ErrVoteHoldNotFound
+	}
+	if err != nil {
+		return VoteHold{}, err
+	}
+
+	var hold VoteHold
+	if err := json.Unmarshal([]byte(data), &hold); err != nil {
+		return VoteHold{}, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hold.ConfirmationToken), []byte(token)) != 1 {
+		return VoteHold{}, ErrVoteHoldToken
+	}
+
+	// The delete that removes the key wins the hold.
+	deleted, err := vc.cacheClient.Del(vc.context, voteHoldKey(voteID)).Result()
+	if err != nil {
+		return VoteHold{}, err
+	}
+	if deleted == 0 {
+		return VoteHold{}, ErrVoteHoldNotFound
+	}
+
+	if hold.Vote.VoterID != 0 {
+		if err := vc.cacheClient.Del(vc.context, voterHoldKey(hold.Vote.PollID, hold.Vote.VoterID)).Err(); err != nil {
+			return VoteHold{}, err
+		}
+	}
+
+	return hold, nil
+}
+
+// Put back the hold of a prepared vote whose confirmation failed, until it
+// expires as it would have, so the confirmation can be retried with the
+// same token. A hold that expired in the meantime is not put back.
+func (vc *VotesCache) RestoreVoteHold(hold VoteHold) error {
+	ttl := time.Until(hold.ExpiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(hold)
+	if err != nil {
+		return err
+	}
+
+	vote := hold.Vote
+	if err := vc.cacheClient.SetNX(vc.context, voteHoldKey(vote.VoteID), data, ttl).Err(); err != nil {
+		return err
+	}
+
+	if vote.VoterID == 0 {
+		return nil
+	}
+
+	return vc.cacheClient.SetNX(vc.context, voterHoldKey(vote.PollID, vote.VoterID), vote.VoteID, ttl).Err()
+}
+
+// Delete the holds of every prepared vote, so none of them can be
+// confirmed.
+func (vc *VotesCache) DeleteVoteHolds() error {
+	return vc.deleteKeys(VoteHoldKeyPrefix)
+}
@@ -0,0 +1,68 @@
+package votes
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	IdempotencyKeyPrefix = "idempotency:"
+	idempotencyPending   = "pending"
+)
+
+// IdempotentResponse is the response recorded for an idempotency key.
+type IdempotentResponse struct {
+	Fingerprint string `json:"fingerprint"`
+	StatusCode  int    `json:"statusCode"`
+	ContentType string `json:"contentType"`
+	Body        []byte `json:"body"`
+}
+
+// Get a string that can be used as an idempotency key in redis.
+func idempotencyKey(key string) string {
+	return IdempotencyKeyPrefix + key
+}
+
+// Reserve an idempotency key before executing a request, for ttl in case
+// the request never records its response or releases the key. It returns
+// false when the key is already reserved or holds a recorded response.
+func (vc *VotesCache) ReserveIdempotencyKey(key string, ttl time.Duration) (bool, error) {
+	return vc.cacheClient.SetNX(vc.context, idempotencyKey(key), idempotencyPending, ttl).Result()
+}
+
+// Release a reserved idempotency key so the request can be retried.
+func (vc *VotesCache) ReleaseIdempotencyKey(key string) error {
+	return vc.cacheClient.Del(vc.context, idempotencyKey(key)).Err()
+}
+
+// Record the response of a request for its idempotency key.
+func (vc *VotesCache) SaveIdempotentResponse(key string, response IdempotentResponse, ttl time.Duration) error {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+
+	return vc.cacheClient.Set(vc.context, idempotencyKey(key), data, ttl).Err()
+}
+
+// Retrieve the response recorded for an idempotency key. The pending flag
+// is set when the original request is still being executed.
+func (vc *VotesCache) GetIdempotentResponse(key string) (IdempotentResponse, bool, error) {
+	var response IdempotentResponse
+
+	data, err := vc.cacheClient.Get(vc.context, idempotencyKey(key)).Result()
+	if err != nil {
+		return IdempotentResponse{}, false, errors.New("idempotency key does not exist")
+	}
+
+	if data == idempotencyPending {
+		return IdempotentResponse{}, true, nil
+	}
+
+	if err := json.Unmarshal([]byte(data), &response); err != nil {
+		return IdempotentResponse{}, false, err
+	}
+
+	return response, false, nil
+}
@@ -0,0 +1,182 @@
+package votes
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"shared/keyring"
+	"shared/repository"
+)
+
+// The sets of the vote IDs of each poll and of each voter, which let the
+// votes of one poll or voter be read without scanning every vote.
+const (
+	PollVotesKeyPrefix  = "vote-poll:"
+	VoterVotesKeyPrefix = "vote-voter:"
+)
+
+// VoteFilter selects votes by their poll and their voter; a zero ID
+// selects any.
+type VoteFilter struct {
+	PollID  uint
+	VoterID uint
+}
+
+func pollVotesKey(pollID uint) string {
+	return fmt.Sprintf("%s%d", PollVotesKeyPrefix, pollID)
+}
+
+func voterVotesKey(voterID uint) string {
+	return fmt.Sprintf("%s%d", VoterVotesKeyPrefix, voterID)
+}
+
+// Return the index sets a vote is in. The votes of anonymous polls have no
+// voter, so they are only in the set of their poll.
+func voteIndexKeys(vote Vote) []string {
+	keys := []string{pollVotesKey(vote.PollID)}
+	if vote.VoterID != 0 {
+		keys = append(keys, voterVotesKey(vote.VoterID))
+	}
+
+	return keys
+}
+
+// Add a vote to the index sets of its poll and voter.
+func (vc *VotesCache) indexVote(vote Vote) error {
+	for _, key := range voteIndexKeys(vote) {
+		if err := vc.cacheClient.SAdd(vc.context, key, vote.VoteID).Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Remove a vote from the index sets of its poll and voter.
+func (vc *VotesCache) unindexVote(vote Vote) error {
+	for _, key := range voteIndexKeys(vote) {
+		if err := vc.cacheClient.SRem(vc.context, key, vote.VoteID).Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Delete every index set, on each master of a cluster.
+func (vc *VotesCache) deleteVoteIndex() error {
+	return vc.deleteKeys(PollVotesKeyPrefix, VoterVotesKeyPrefix)
+}
+
+// Delete every key with one of the prefixes, on each master of a cluster.
+func (vc *VotesCache) deleteKeys(prefixes ...string) error {
+	masters, err := keyring.Masters(vc.cacheClient)
+	if err != nil {
+		return err
+	}
+
+	for _, master := range masters {
+		for _, prefix := range prefixes {
+			iter := master.Scan(vc.context, 0, prefix+"*", repository.ScanBatchSize).Iterator()
+			for iter.Next(vc.context) {
+				if err := master.Del(vc.context, iter.Val()).Err(); err != nil {
+					return err
+				}
+			}
+			if err := iter.Err(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Return the votes the filter selects, in vote ID order, from the index
+// sets. The sets of a poll and a voter are intersected here rather than
+// with SINTER, which a Redis Cluster refuses for keys of different slots.
+// Votes that expired since they were indexed are left out.
+func (vc *VotesCache) FindVotes(filter VoteFilter) ([]Vote, error) {
+	var keys []string
+	if filter.PollID != 0 {
+		keys = append(keys, pollVotesKey(filter.PollID))
+	}
+	if filter.VoterID != 0 {
+		keys = append(keys, voterVotesKey(filter.VoterID))
+	}
+	if len(keys) == 0 {
+		return vc.GetAllVotes()
+	}
+
+	var voteIDs map[uint]bool
+	for _, key := range keys {
+		members, err := vc.cacheClient.SMembers(vc.context, key).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		found := make(map[uint]bool, len(members))
+		for _, member := range members {
+			voteID, err := strconv.ParseUint(member, 10, 32)
+			if err != nil {
+				continue
+			}
+			if voteIDs == nil || voteIDs[uint(voteID)] {
+				found[uint(voteID)] = true
+			}
+		}
+		voteIDs = found
+	}
+
+	ids := make([]uint, 0, len(voteIDs))
+	for voteID := range voteIDs {
+		ids = append(ids, voteID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	// The sets are only a hint; the votes are checked against the filter
+	// in case a set was not updated.
+	votes := make([]Vote, 0, len(ids))
+	for _, voteID := range ids {
+		vote, err := vc.votes.Get(voteID)
+		if errors.Is(err, repository.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if filter.matches(vote) {
+			votes = append(votes, vote)
+		}
+	}
+
+	return votes, nil
+}
+
+// Report whether the filter selects a vote.
+func (filter VoteFilter) matches(vote Vote) bool {
+	return (filter.PollID == 0 || vote.PollID == filter.PollID) &&
+		(filter.VoterID == 0 || vote.VoterID == filter.VoterID)
+}
+
+// Rebuild the index sets from the stored votes, for votes that were stored
+// before the index existed. It returns the number of votes indexed.
+func (vc *VotesCache) RebuildVoteIndex() (int, error) {
+	if err := vc.deleteVoteIndex(); err != nil {
+		return 0, err
+	}
+
+	indexed := 0
+	err := vc.EachVote(func(vote Vote) error {
+		if err := vc.indexVote(vote); err != nil {
+			return err
+		}
+		indexed++
+		return nil
+	})
+
+	return indexed, err
+}
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS votes (
+    vote_id BIGINT PRIMARY KEY,
+    voter_id BIGINT NOT NULL,
+    poll_id BIGINT NOT NULL,
+    vote_value BIGINT NOT NULL,
+    flagged_at TIMESTAMPTZ,
+    flag_reason TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS votes_poll_id_idx ON votes (poll_id, vote_value);
+CREATE INDEX IF NOT EXISTS votes_voter_id_idx ON votes (voter_id);
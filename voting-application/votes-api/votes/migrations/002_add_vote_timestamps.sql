@@ -0,0 +1,18 @@
+ALTER TABLE votes ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ;
+ALTER TABLE votes ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ;
+
+-- Votes cast before the columns existed get the vote date of the voter
+-- history, when the voter API shares the database.
+DO $$
+BEGIN
+    IF to_regclass('voter_polls') IS NOT NULL THEN
+        UPDATE votes
+        SET created_at = voter_polls.vote_date, updated_at = voter_polls.vote_date
+        FROM voter_polls
+        WHERE votes.created_at IS NULL
+            AND voter_polls.voter_id = votes.voter_id
+            AND voter_polls.poll_id = votes.poll_id;
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS votes_created_at_idx ON votes (created_at);
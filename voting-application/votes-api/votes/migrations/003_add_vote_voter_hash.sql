@@ -0,0 +1,5 @@
+-- The votes of anonymous polls keep a hash of their voter instead of the
+-- voter ID, one vote per hash and poll.
+ALTER TABLE votes ADD COLUMN IF NOT EXISTS voter_hash TEXT NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX IF NOT EXISTS votes_voter_hash_idx ON votes (poll_id, voter_hash) WHERE voter_hash <> '';
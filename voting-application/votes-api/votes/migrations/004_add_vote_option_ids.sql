@@ -0,0 +1,3 @@
+-- The options of a vote in a multi-choice poll, vote_value is the first of
+-- them. Single-choice votes keep it empty.
+ALTER TABLE votes ADD COLUMN IF NOT EXISTS option_ids BIGINT[] NOT NULL DEFAULT '{}';
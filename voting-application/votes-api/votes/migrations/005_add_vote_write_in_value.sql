@@ -0,0 +1,2 @@
+-- The normalized free text of a write-in vote, which selects no option.
+ALTER TABLE votes ADD COLUMN IF NOT EXISTS write_in_value TEXT NOT NULL DEFAULT '';
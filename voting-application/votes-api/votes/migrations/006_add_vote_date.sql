@@ -0,0 +1,6 @@
+-- When the ballot was cast, which can be earlier than created_at for
+-- ballots recorded after the fact. Votes cast before the column existed
+-- were cast when they were recorded.
+ALTER TABLE votes ADD COLUMN IF NOT EXISTS vote_date TIMESTAMPTZ;
+
+UPDATE votes SET vote_date = created_at WHERE vote_date IS NULL;
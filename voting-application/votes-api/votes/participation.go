@@ -0,0 +1,116 @@
+package votes
+
+import (
+	"fmt"
+)
+
+const (
+	ParticipationKeyPrefix = "participation:"
+)
+
+// PollOverlap describes how many voters two polls have in common.
+type PollOverlap struct {
+	PollA          uint    `json:"pollA"`
+	PollB          uint    `json:"pollB"`
+	VotersA        int64   `json:"votersA"`
+	VotersB        int64   `json:"votersB"`
+	VotersInBoth   int64   `json:"votersInBoth"`
+	VotersInEither int64   `json:"votersInEither"`
+	JaccardIndex   float64 `json:"jaccardIndex"`
+}
+
+// Get a string that can be used as a participation set key in redis.
+func participationKeyFromId(pollID uint) string {
+	return fmt.Sprintf("%s%d", ParticipationKeyPrefix, pollID)
+}
+
+// Return the member of the participation set of a vote: its voter ID, or
+// the voter hash of an anonymous vote. Voter hashes differ from poll to
+// poll, so anonymous votes never add to the overlap of two polls.
+func participant(vote Vote) interface{} {
+	if vote.VoterHash != "" {
+		return vote.VoterHash
+	}
+
+	return vote.VoterID
+}
+
+// Record that the voter of a vote took part in its poll. It reports
+// whether the voter was not a participant yet.
+func (vc *VotesCache) addParticipation(vote Vote) (bool, error) {
+	added, err := vc.cacheClient.SAdd(vc.context, participationKeyFromId(vote.PollID), participant(vote)).Result()
+	return added > 0, err
+}
+
+// Remove the voter of a vote from the participants of its poll.
+func (vc *VotesCache) removeParticipation(vote Vote) error {
+	return vc.cacheClient.SRem(vc.context, participationKeyFromId(vote.PollID), participant(vote)).Err()
+}
+
+// Compute the voter overlap of two polls from their participation sets.
+func (vc *VotesCache) GetPollOverlap(pollA, pollB uint) (PollOverlap, error) {
+	keyA := participationKeyFromId(pollA)
+	keyB := participationKeyFromId(pollB)
+
+	votersA, err := vc.cacheClient.SCard(vc.context, keyA).Result()
+	if err != nil {
+		return PollOverlap{}, err
+	}
+
+	votersB, err := vc.cacheClient.SCard(vc.context, keyB).Result()
+	if err != nil {
+		return PollOverlap{}, err
+	}
+
+	both, err := vc.cacheClient.SInter(vc.context, keyA, keyB).Result()
+	if err != nil {
+		return PollOverlap{}, err
+	}
+
+	votersInBoth := int64(len(both))
+	votersInEither := votersA + votersB - votersInBoth
+
+	jaccardIndex := 0.0
+	if votersInEither > 0 {
+		jaccardIndex = float64(votersInBoth) / float64(votersInEither)
+	}
+
+	return PollOverlap{
+		PollA:          pollA,
+		PollB:          pollB,
+		VotersA:        votersA,
+		VotersB:        votersB,
+		VotersInBoth:   votersInBoth,
+		VotersInEither: votersInEither,
+		JaccardIndex:   jaccardIndex,
+	}, nil
+}
+
+// Rebuild every participation set from the stored votes, for votes that
+// were recorded before participation tracking existed.
+func (vc *VotesCache) RebuildParticipation() error {
+	pattern := fmt.Sprintf("%s*", ParticipationKeyPrefix)
+	keys, err := vc.cacheClient.Keys(vc.context, pattern).Result()
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if _, deleteErr := vc.cacheClient.Del(vc.context, key).Result(); deleteErr != nil {
+			return deleteErr
+		}
+	}
+
+	allVotes, err := vc.GetAllVotes()
+	if err != nil {
+		return err
+	}
+
+	for _, vote := range allVotes {
+		if _, err := vc.addParticipation(vote); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
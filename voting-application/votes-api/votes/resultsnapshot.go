@@ -0,0 +1,157 @@
+package votes
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	ResultSnapshotKeyPrefix = "result-snapshot:"
+)
+
+// ErrNotFrozen is returned for the result snapshot of a poll that was not
+// frozen.
+var ErrNotFrozen = errors.New("poll results are not frozen")
+
+// OptionCount is the number of votes an option received.
+type OptionCount struct {
+	OptionID   uint   `json:"optionId"`
+	OptionText string `json:"optionText"`
+	Votes      uint   `json:"votes"`
+}
+
+// ResultSnapshot is the result of a poll as it was when the poll closed.
+// It is written once and never changed; the votes of a frozen poll can no
+// longer be cast or deleted.
+type ResultSnapshot struct {
+	PollID    uint          `json:"pollId"`
+	PollTitle string        `json:"pollTitle"`
+	ClosedAt  *time.Time    `json:"closedAt,omitempty"`
+	FrozenAt  time.Time     `json:"frozenAt"`
+	Results   []OptionCount `json:"results"`
+	// The votes of every option; a multi-choice ballot counts once for
+	// each option it selects.
+	TotalVotes uint `json:"totalVotes"`
+	// The number of ballots cast.
+	Turnout uint `json:"turnout"`
+	// The option with the most votes, none on a tie or without votes. The
+	// options tied for the most votes are in TiedOptions.
+	Winner      *uint  `json:"winner"`
+	TiedOptions []uint `json:"tiedOptions,omitempty"`
+	// The SHA-256 of the snapshot without its checksum, "sha256:<hex>".
+	Checksum string `json:"checksum"`
+}
+
+// Get a string that can be used as a result snapshot key in redis.
+func resultSnapshotKey(pollID uint) string {
+	return fmt.Sprintf("%s%d", ResultSnapshotKeyPrefix, pollID)
+}
+
+// Return a snapshot of the results of a poll frozen now, with its winner
+// and checksum.
+func NewResultSnapshot(pollID uint, pollTitle string, closedAt *time.Time, results []OptionCount, turnout uint) ResultSnapshot {
+	snapshot := ResultSnapshot{
+		PollID:    pollID,
+		PollTitle: pollTitle,
+		ClosedAt:  closedAt,
+		FrozenAt:  time.Now().UTC(),
+		Results:   results,
+		Turnout:   turnout,
+	}
+
+	var most uint
+	var leaders []uint
+	for _, result := range results {
+		snapshot.TotalVotes += result.Votes
+		switch {
+		case result.Votes == 0:
+		case result.Votes > most:
+			most = result.Votes
+			leaders = []uint{result.OptionID}
+		case result.Votes == most:
+			leaders = append(leaders, result.OptionID)
+		}
+	}
+
+	switch len(leaders) {
+	case 0:
+	case 1:
+		snapshot.Winner = &leaders[0]
+	default:
+		snapshot.TiedOptions = leaders
+	}
+
+	snapshot.Checksum = snapshot.ComputeChecksum()
+	return snapshot
+}
+
+// Return the checksum of the snapshot, computed over its JSON without the
+// checksum.
+func (s ResultSnapshot) ComputeChecksum() string {
+	s.Checksum = ""
+	data, _ := json.Marshal(s)
+	sum := sha256.Sum256(data)
+
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// Store the result snapshot of a poll unless the poll is already frozen.
+// It returns the stored snapshot, the earlier one when there is one, and
+// whether it was stored now.
+func (vc *VotesCache) FreezeResults(snapshot ResultSnapshot) (ResultSnapshot, bool, error) {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return ResultSnapshot{}, false, err
+	}
+
+	stored, err := vc.cacheClient.SetNX(vc.context, resultSnapshotKey(snapshot.PollID), data, 0).Result()
+	if err != nil {
+		return ResultSnapshot{}, false, err
+	}
+
+	if !stored {
+		snapshot, err = vc.GetResultSnapshot(snapshot.PollID)
+	}
+
+	return snapshot, stored, err
+}
+
+// Retrieve the result snapshot of a poll, ErrNotFrozen when it has none.
+func (vc *VotesCache) GetResultSnapshot(pollID uint) (ResultSnapshot, error) {
+	var snapshot ResultSnapshot
+
+	data, err := vc.cacheClient.Get(vc.context, resultSnapshotKey(pollID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return ResultSnapshot{}, ErrNotFrozen
+	}
+	if err != nil {
+		return ResultSnapshot{}, err
+	}
+
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return ResultSnapshot{}, err
+	}
+
+	return snapshot, nil
+}
+
+// Report whether the results of a poll are frozen.
+func (vc *VotesCache) IsFrozen(pollID uint) (bool, error) {
+	count, err := vc.cacheClient.Exists(vc.context, resultSnapshotKey(pollID)).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// Delete the result snapshot of a poll, when the poll itself is deleted.
+func (vc *VotesCache) DeleteResultSnapshot(pollID uint) error {
+	return vc.cacheClient.Del(vc.context, resultSnapshotKey(pollID)).Err()
+}
@@ -0,0 +1,37 @@
+package votes
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+)
+
+const (
+	ReceiptSecretKey   = "receipt-secret"
+	VoterHashSecretKey = "voter-hash-secret"
+)
+
+// Return the secret kept at key, shared by every replica. The first
+// replica to ask creates it.
+func (vc *VotesCache) Secret(key string) ([]byte, error) {
+	if vc == nil {
+		return nil, errors.New("redis is not connected")
+	}
+
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return nil, err
+	}
+
+	// Keep the secret of a replica that got there first.
+	if err := vc.cacheClient.SetNX(vc.context, key, hex.EncodeToString(secretBytes), 0).Err(); err != nil {
+		return nil, err
+	}
+
+	secret, err := vc.cacheClient.Get(vc.context, key).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	return hex.DecodeString(secret)
+}
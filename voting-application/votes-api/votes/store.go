@@ -0,0 +1,52 @@
+package votes
+
+import (
+	"os"
+	"time"
+)
+
+const (
+	StorageBackendRedis    = "redis"
+	StorageBackendPostgres = "postgres"
+)
+
+// Store is the storage the votes handlers work against. Both the redis
+// VotesCache and the postgres VotesPostgres implement it. Embargo tokens,
+// idempotency keys and the holds of prepared votes are short lived and
+// always kept in redis, as are the result snapshots of the polls.
+type Store interface {
+	GetAllVotes() ([]Vote, error)
+	FindVotes(filter VoteFilter) ([]Vote, error)
+	EachVote(fn func(Vote) error) error
+	GetVote(voteID uint) (Vote, error)
+	AddVote(vote Vote, maxVotes map[uint]uint) error
+	FlagVote(voteID uint, reason string) (Vote, error)
+	BackfillVoteTimes(voteTime func(Vote) (time.Time, bool)) (int, error)
+	DeleteVote(voteID uint) error
+	GetOptionCounts(pollID uint) (map[uint]uint, error)
+	GetWriteInCounts(pollID uint) (map[string]uint, error)
+	RebuildTallies() error
+	GetPollOverlap(pollA, pollB uint) (PollOverlap, error)
+	RebuildParticipation() error
+	Ping() error
+}
+
+// Return the configured storage backend, redis unless STORAGE_BACKEND is set.
+func StorageBackend() string {
+	backend := os.Getenv("STORAGE_BACKEND")
+	if backend == "" {
+		return StorageBackendRedis
+	}
+
+	return backend
+}
+
+// Return the Store selected by STORAGE_BACKEND. The redis backend reuses
+// the provided VotesCache.
+func NewVotesStore(votesCache *VotesCache) (Store, error) {
+	if StorageBackend() == StorageBackendPostgres {
+		return NewVotesPostgres()
+	}
+
+	return votesCache, nil
+}
@@ -0,0 +1,369 @@
+package votes
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	TallyKeyPrefix       = "tally:"
+	TallyShardsKeyPrefix = "tally:shards:"
+
+	DefaultTallyWritesPerShard = 50
+	DefaultTallyMaxShards      = 16
+	TallyRateWindow            = 10 * time.Second
+)
+
+// reserveOptionVoteScript increments the vote count of an option in the
+// first shard and rolls the increment back when the total over all shards
+// would exceed the cap, in one atomic step.
+var reserveOptionVoteScript = redis.NewScript(`
+local count = redis.call('HINCRBY', KEYS[1], ARGV[1], 1)
+for i = 2, #KEYS do
+	count = count + tonumber(redis.call('HGET', KEYS[i], ARGV[1]) or '0')
+end
+local cap = tonumber(ARGV[2])
+if cap > 0 and count > cap then
+	redis.call('HINCRBY', KEYS[1], ARGV[1], -1)
+	return -1
+end
+return count
+`)
+
+// releaseOptionVoteScript decrements the vote count of an option in the
+// first shard that still counts a vote for it.
+var releaseOptionVoteScript = redis.NewScript(`
+for i = 1, #KEYS do
+	if tonumber(redis.call('HGET', KEYS[i], ARGV[1]) or '0') > 0 then
+		redis.call('HINCRBY', KEYS[i], ARGV[1], -1)
+		return 1
+	end
+end
+return 0
+`)
+
+// raiseShardCountScript raises the shard count of a poll, it never lowers
+// it so reads keep summing every shard that was written.
+var raiseShardCountScript = redis.NewScript(`
+local current = tonumber(redis.call('GET', KEYS[1]) or '1')
+local wanted = tonumber(ARGV[1])
+if wanted > current then
+	redis.call('SET', KEYS[1], wanted)
+	return wanted
+end
+return current
+`)
+
+// tallySharding spreads the vote counts of busy polls over several hashes,
+// so a large election does not turn a single key into a hotspot. The shard
+// count of a poll follows the vote rate this replica observes.
+type tallySharding struct {
+	writesPerShard uint
+	maxShards      uint
+	lock           sync.Mutex
+	windowStart    time.Time
+	writes         map[uint]uint
+	shards         map[uint]uint
+}
+
+// Load the sharding configuration. A poll gets one shard for every
+// TALLY_WRITES_PER_SHARD votes per second, rounded up to a power of two
+// and at most TALLY_MAX_SHARDS.
+func loadTallySharding() *tallySharding {
+	sharding := &tallySharding{
+		writesPerShard: DefaultTallyWritesPerShard,
+		maxShards:      DefaultTallyMaxShards,
+		windowStart:    time.Now(),
+		writes:         make(map[uint]uint),
+		shards:         make(map[uint]uint),
+	}
+
+	if value := os.Getenv("TALLY_WRITES_PER_SHARD"); value != "" {
+		if writesPerShard, err := strconv.ParseUint(value, 10, 32); err == nil && writesPerShard > 0 {
+			sharding.writesPerShard = uint(writesPerShard)
+		} else {
+			log.Printf("Invalid TALLY_WRITES_PER_SHARD %q, using %d", value, DefaultTallyWritesPerShard)
+		}
+	}
+
+	if value := os.Getenv("TALLY_MAX_SHARDS"); value != "" {
+		if maxShards, err := strconv.ParseUint(value, 10, 32); err == nil && maxShards > 0 {
+			sharding.maxShards = uint(maxShards)
+		} else {
+			log.Printf("Invalid TALLY_MAX_SHARDS %q, using %d", value, DefaultTallyMaxShards)
+		}
+	}
+
+	return sharding
+}
+
+// Return the number of shards a vote rate calls for.
+func (ts *tallySharding) shardsForRate(rate float64) uint {
+	shards := uint(1)
+	for shards < ts.maxShards && float64(shards*ts.writesPerShard) < rate {
+		shards *= 2
+	}
+
+	if shards > ts.maxShards {
+		shards = ts.maxShards
+	}
+
+	return shards
+}
+
+// Get a string that can be used as a tally key in redis.
+func tallyKeyFromId(pollID uint) string {
+	return fmt.Sprintf("%s%d", TallyKeyPrefix, pollID)
+}
+
+// Get the key of a tally shard. The first shard is the tally key of the
+// poll, so tallies written before sharding are still counted.
+func tallyShardKey(pollID, shard uint) string {
+	if shard == 0 {
+		return tallyKeyFromId(pollID)
+	}
+
+	return fmt.Sprintf("%s%d:%d", TallyKeyPrefix, pollID, shard)
+}
+
+// Return the keys of every tally shard of a poll.
+func (vc *VotesCache) tallyShardKeys(pollID uint) ([]string, error) {
+	shards, err := vc.cacheClient.Get(vc.context, fmt.Sprintf("%s%d", TallyShardsKeyPrefix, pollID)).Uint64()
+	if err == redis.Nil || shards == 0 {
+		shards = 1
+	} else if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, shards)
+	for shard := range keys {
+		keys[shard] = tallyShardKey(pollID, uint(shard))
+	}
+
+	return keys, nil
+}
+
+// Record a vote written to a poll and return the number of shards to
+// spread its counts over. At the end of every TallyRateWindow the shard
+// counts of the polls that got busier are raised.
+func (vc *VotesCache) writeShards(pollID uint) uint {
+	ts := vc.sharding
+
+	ts.lock.Lock()
+	ts.writes[pollID]++
+
+	raise := make(map[uint]uint)
+	if elapsed := time.Since(ts.windowStart); elapsed >= TallyRateWindow {
+		for id, writes := range ts.writes {
+			if wanted := ts.shardsForRate(float64(writes) / elapsed.Seconds()); wanted > ts.shards[id] {
+				raise[id] = wanted
+			}
+		}
+
+		ts.writes = make(map[uint]uint)
+		ts.windowStart = time.Now()
+	}
+
+	shards, known := ts.shards[pollID]
+	ts.lock.Unlock()
+
+	if !known {
+		keys, err := vc.tallyShardKeys(pollID)
+		if err != nil {
+			return 1
+		}
+		shards = uint(len(keys))
+	}
+
+	for id, wanted := range raise {
+		raised, err := raiseShardCountScript.Run(vc.context, vc.cacheClient, []string{fmt.Sprintf("%s%d", TallyShardsKeyPrefix, id)}, wanted).Int64()
+		if err != nil {
+			log.Println("Error raising tally shard count: ", err)
+			continue
+		}
+
+		ts.lock.Lock()
+		if uint(raised) > ts.shards[id] {
+			log.Printf("Spreading the tally of poll %d over %d shards", id, raised)
+			ts.shards[id] = uint(raised)
+		}
+		ts.lock.Unlock()
+
+		if id == pollID {
+			shards = uint(raised)
+		}
+	}
+
+	if !known {
+		ts.lock.Lock()
+		if current, ok := ts.shards[pollID]; !ok || current < shards {
+			ts.shards[pollID] = shards
+		}
+		ts.lock.Unlock()
+	}
+
+	return shards
+}
+
+// Count a vote for an option, unless the option already holds maxVotes
+// votes. A zero maxVotes means the option is not capped. It returns false
+// when the option is full. Uncapped votes are queued for the next flush,
+// or go to a random shard when batching is off; capped ones are checked
+// against the total of every shard.
+func (vc *VotesCache) ReserveOptionVote(pollID, optionID, maxVotes uint) (bool, error) {
+	shards := vc.writeShards(pollID)
+
+	if maxVotes == 0 && vc.batcher.enabled() {
+		vc.batcher.add(pollID, optionID)
+		return true, nil
+	}
+
+	if maxVotes == 0 {
+		shardKey := tallyShardKey(pollID, uint(rand.Intn(int(shards))))
+		if err := vc.cacheClient.HIncrBy(vc.context, shardKey, strconv.Itoa(int(optionID)), 1).Err(); err != nil {
+			return false, err
+		}
+
+		return true, nil
+	}
+
+	// The cap of an option can be set after votes for it were queued.
+	if vc.batcher.pendingFor(pollID)[optionID] > 0 {
+		if err := vc.FlushTallies(); err != nil {
+			return false, err
+		}
+	}
+
+	keys, err := vc.tallyShardKeys(pollID)
+	if err != nil {
+		return false, err
+	}
+
+	count, err := reserveOptionVoteScript.Run(vc.context, vc.cacheClient, keys, optionID, maxVotes).Int64()
+	if err != nil {
+		return false, err
+	}
+
+	return count >= 0, nil
+}
+
+// Give back a vote counted for an option. A queued increment is taken
+// back first; otherwise the shards are tried from a random one on, so
+// releases are spread like the increments.
+func (vc *VotesCache) ReleaseOptionVote(pollID, optionID uint) error {
+	if vc.batcher.cancel(pollID, optionID) {
+		return nil
+	}
+
+	keys, err := vc.tallyShardKeys(pollID)
+	if err != nil {
+		return err
+	}
+
+	start := rand.Intn(len(keys))
+	keys = append(keys[start:], keys[:start]...)
+
+	return releaseOptionVoteScript.Run(vc.context, vc.cacheClient, keys, optionID).Err()
+}
+
+// Return the live vote count of every option of a poll, summed over its
+// shards and the increments this replica has not flushed yet.
+func (vc *VotesCache) GetOptionCounts(pollID uint) (map[uint]uint, error) {
+	counts := make(map[uint]uint)
+
+	keys, err := vc.tallyShardKeys(pollID)
+	if err != nil {
+		return counts, err
+	}
+
+	pipe := vc.cacheClient.Pipeline()
+	shardCmds := make([]*redis.StringStringMapCmd, len(keys))
+	for i, key := range keys {
+		shardCmds[i] = pipe.HGetAll(vc.context, key)
+	}
+
+	if _, err := pipe.Exec(vc.context); err != nil {
+		return counts, err
+	}
+
+	totals := make(map[uint]int64)
+	for _, shardCmd := range shardCmds {
+		for field, value := range shardCmd.Val() {
+			optionID, err := strconv.ParseUint(field, 10, 32)
+			if err != nil {
+				return counts, err
+			}
+
+			count, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return counts, err
+			}
+
+			totals[uint(optionID)] += count
+		}
+	}
+
+	for optionID, delta := range vc.batcher.pendingFor(pollID) {
+		totals[optionID] += delta
+	}
+
+	for optionID, count := range totals {
+		if count > 0 {
+			counts[optionID] = uint(count)
+		}
+	}
+
+	return counts, nil
+}
+
+// Rebuild every tally from the stored votes, for votes that were recorded
+// before the live counts existed. The rebuilt counts go to the first
+// shard; the shard counts are kept, as other replicas keep writing to the
+// shards they know. Queued increments are dropped, their votes are
+// counted by the rebuild.
+func (vc *VotesCache) RebuildTallies() error {
+	vc.batcher.take()
+
+	pattern := fmt.Sprintf("%s*", TallyKeyPrefix)
+	keys, err := vc.cacheClient.Keys(vc.context, pattern).Result()
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if strings.HasPrefix(key, TallyShardsKeyPrefix) {
+			continue
+		}
+
+		if _, deleteErr := vc.cacheClient.Del(vc.context, key).Result(); deleteErr != nil {
+			return deleteErr
+		}
+	}
+
+	allVotes, err := vc.GetAllVotes()
+	if err != nil {
+		return err
+	}
+
+	for _, vote := range allVotes {
+		for _, optionID := range vote.Options() {
+			if err := vc.cacheClient.HIncrBy(vc.context, tallyKeyFromId(vote.PollID), strconv.Itoa(int(optionID)), 1).Err(); err != nil {
+				return err
+			}
+		}
+
+		if err := vc.countWriteIn(vote, 1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
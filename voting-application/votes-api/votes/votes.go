@@ -2,14 +2,18 @@ package votes
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
-	"fmt"
 	"log"
 	"os"
+	"time"
+
+	"shared/codec"
+	"shared/failover"
+	"shared/keyaudit"
+	"shared/keyring"
+	"shared/repository"
 
 	"github.com/go-redis/redis/v8"
-	"github.com/nitishm/go-rejson/v4"
 )
 
 const (
@@ -18,23 +22,79 @@ const (
 	RedisKeyPrefix       = "votes:"
 )
 
+// ErrOptionFull is returned when a vote targets an option that reached its cap.
+var ErrOptionFull = errors.New("poll option is full")
+
+// ErrAlreadyVoted is returned when an anonymous vote has the voter hash of
+// a vote already cast in the poll.
+var ErrAlreadyVoted = errors.New("voter already voted in this poll")
+
 // Vote represents a voter who voted in poll with vote value.
 type Vote struct {
-	VoteID    uint `json:"voteId"`
-	VoterID   uint `json:"voterId"`
-	PollID    uint `json:"pollId"`
-	VoteValue uint `json:"voteValue"`
+	VoteID     uint       `json:"voteId"`
+	VoterID    uint       `json:"voterId"`
+	PollID     uint       `json:"pollId" binding:"required"`
+	VoteValue  uint       `json:"voteValue"`
+	FlaggedAt  *time.Time `json:"flaggedAt,omitempty"`
+	FlagReason string     `json:"flagReason,omitempty"`
+	// The votes of anonymous polls have no VoterID, only a hash of the
+	// voter and poll that no other poll shares, to refuse a second vote.
+	VoterHash string `json:"voterHash,omitempty"`
+	// Set by the server when the vote is cast and when it changes. Votes
+	// stored before they existed have none until BackfillVoteTimes.
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+	// The options of a vote in a multi-choice poll, VoteValue is the first
+	// of them. Single-choice votes leave it empty.
+	OptionIDs []uint `json:"optionIds,omitempty"`
+	// The normalized free text of a write-in vote, which selects no
+	// option and leaves VoteValue 0.
+	WriteInValue string `json:"writeInValue,omitempty"`
+	// When redis deletes the vote, set for the votes of test polls when
+	// TTL_TEST_VOTES_DAYS is.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	// When the ballot was cast, which the client can set to an earlier
+	// time than CreatedAt, such as for a paper ballot recorded later.
+	VoteDate *time.Time `json:"voteDate,omitempty"`
+}
+
+// Return when the vote was cast: its VoteDate, or CreatedAt for the votes
+// stored before they had one. Nil if it has neither.
+func (v Vote) CastAt() *time.Time {
+	if v.VoteDate != nil {
+		return v.VoteDate
+	}
+
+	return v.CreatedAt
+}
+
+// Return the options a vote selects, none for a write-in.
+func (v Vote) Options() []uint {
+	if v.WriteInValue != "" {
+		return nil
+	}
+
+	if len(v.OptionIDs) > 0 {
+		return v.OptionIDs
+	}
+
+	return []uint{v.VoteValue}
 }
 
 type cache struct {
-	cacheClient *redis.Client
-	jsonHelper  *rejson.Handler
+	cacheClient redis.UniversalClient
+	ring        *keyring.Ring
+	documents   *codec.Store
+	votes       *repository.Repository[Vote]
 	context     context.Context
 }
 
 // The reference to a cache object.
 type VotesCache struct {
 	cache
+	sharding *tallySharding
+	batcher  *tallyBatcher
+	testTTL  testVoteTTL
 }
 
 // The constructor function that returns a pointer to a new VotesCache.
@@ -51,111 +111,281 @@ func NewVotesCache() (*VotesCache, error) {
 
 // The constructor function that returns a pointer to a new VotesCache.
 func newVotesCacheInstance(url string) (*VotesCache, error) {
-	client := redis.NewClient(&redis.Options{
-		Addr: url,
-	})
+	client, err := failover.Connect(url)
+	if err != nil {
+		log.Println("Error configuring redis: ", err)
+		return nil, err
+	}
 
 	ctx := context.Background()
 
-	err := client.Ping(ctx).Err()
+	err = client.Ping(ctx).Err()
 	if err != nil {
 		log.Println("Error connecting to redis" + err.Error())
 		return nil, err
 	}
 
-	jsonHelper := rejson.NewReJSONHandler()
-	jsonHelper.SetGoRedisClientWithContext(ctx, client)
+	ring, err := keyring.FromEnv(ctx, client)
+	if err != nil {
+		log.Println("Error connecting to redis shards: ", err)
+		return nil, err
+	}
+	ring.StartHealthChecks(ctx, keyring.DefaultHealthInterval)
 
-	return &VotesCache{
+	votesCache := &VotesCache{
 		cache: cache{
 			cacheClient: client,
-			jsonHelper:  jsonHelper,
+			ring:        ring,
+			documents:   codec.NewStore(codec.FromEnv()),
 			context:     ctx,
 		},
-	}, nil
-}
+		sharding: loadTallySharding(),
+		batcher:  loadTallyBatcher(),
+		testTTL:  loadTestVoteTTL(),
+	}
+	votesCache.votes = repository.New(ctx, ring, votesCache.documents, repository.Config[Vote]{
+		Kind:   "vote",
+		Prefix: RedisKeyPrefix,
+		ID:     func(vote Vote) uint { return vote.VoteID },
+		Hooks: repository.Hooks[Vote]{
+			Index:   votesCache.indexVote,
+			Unindex: votesCache.unindexVote,
+			Cleared: votesCache.deleteVoteIndex,
+		},
+		Expiry: voteExpiry,
+	})
+	votesCache.startTallyFlusher()
 
-// Get a string that can be used as a key in redis.
-func redisKeyFromId(id uint) string {
-	return fmt.Sprintf("%s%d", RedisKeyPrefix, id)
+	return votesCache, nil
 }
 
-// Helper to return a Vote from redis provided a key.
-func (vc *VotesCache) getItemFromRedis(key string, item *Vote) error {
-	itemObject, err := vc.jsonHelper.JSONGet(key, ".")
-	if err != nil {
+// Check that the redis connection of the VotesCache is alive.
+func (vc *VotesCache) Ping() error {
+	if vc == nil {
+		return errors.New("redis is not connected")
+	}
+
+	if err := vc.cacheClient.Ping(vc.context).Err(); err != nil {
 		return err
 	}
 
-	err = json.Unmarshal(itemObject.([]byte), item)
+	return vc.ring.Ping(vc.context)
+}
+
+// Return the health of the redis shards of the VotesCache, or nil when it
+// is not connected.
+func (vc *VotesCache) ShardHealth() []keyring.ShardHealth {
+	if vc == nil {
+		return nil
+	}
+
+	return vc.ring.Health()
+}
+
+// Move the votes that are on the wrong redis shard to the shard they
+// belong to.
+func (vc *VotesCache) RebalanceShards(dryRun bool) (keyring.RebalanceReport, error) {
+	return vc.votes.Rebalance(dryRun)
+}
+
+// Audit the keys of the votes and the keyspace of redis, fixing them as fix
+// says, one of the keyaudit fixes.
+func (vc *VotesCache) AuditKeys(fix string) (keyaudit.Report, error) {
+	report := keyaudit.Report{Fix: fix}
+
+	documents, err := vc.votes.Audit(fix)
+	report.Documents = documents
 	if err != nil {
-		return err
+		return report, err
 	}
 
-	return nil
+	report.Unknown, err = keyaudit.Unknown(vc.context, vc.cacheClient, vc.ring, keyaudit.KnownPrefixes())
+
+	return report, err
+}
+
+// Return the codec metrics of the VotesCache, or nil when it is not connected.
+func (vc *VotesCache) CodecStats() *codec.Stats {
+	if vc == nil {
+		return nil
+	}
+
+	stats := vc.documents.Stats()
+	return &stats
+}
+
+// Return the redis client of the VotesCache, or nil when it is not connected.
+func (vc *VotesCache) RedisClient() redis.UniversalClient {
+	if vc == nil {
+		return nil
+	}
+
+	return vc.cacheClient
 }
 
 // Return a slice of all votes from the VotesCache.
 func (vc *VotesCache) GetAllVotes() ([]Vote, error) {
-	var votes []Vote
+	return vc.votes.All()
+}
 
-	pattern := fmt.Sprintf("%s*", RedisKeyPrefix)
-	keys, err := vc.cacheClient.Keys(vc.context, pattern).Result()
+// Call fn with every vote of the VotesCache. The votes are never all held
+// in memory; a vote changed during the scan may be seen twice.
+func (vc *VotesCache) EachVote(fn func(Vote) error) error {
+	return vc.votes.Each(fn)
+}
 
-	if err != nil {
-		return votes, err
+// Retrieve a single vote from the VotesCache by voteID.
+func (vc *VotesCache) GetVote(voteID uint) (Vote, error) {
+	return vc.votes.Get(voteID)
+}
+
+// Add a new vote to the VotesCache.
+// maxVotes holds the caps of the capped options; ErrOptionFull is returned
+// once a selected option reached its cap.
+func (vc *VotesCache) AddVote(vote Vote, maxVotes map[uint]uint) error {
+	// A taken ID is refused before any option is reserved. The vote is
+	// still created atomically below, for a vote with the ID added in the
+	// meantime.
+	if _, err := vc.GetVote(vote.VoteID); err == nil {
+		return vc.votes.Err(repository.ErrExists)
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return err
 	}
 
-	for _, key := range keys {
-		var vote Vote
+	// An anonymous voter is known by their hash alone, which the
+	// participation set of the poll holds once they voted.
+	if vote.VoterHash != "" {
+		added, err := vc.addParticipation(vote)
+		if err != nil {
+			return err
+		}
+		if !added {
+			return ErrAlreadyVoted
+		}
+	}
 
-		err := vc.getItemFromRedis(key, &vote)
+	var reserved []uint
+	release := func() {
+		for _, optionID := range reserved {
+			if err := vc.ReleaseOptionVote(vote.PollID, optionID); err != nil {
+				log.Println("Error releasing option vote: ", err)
+			}
+		}
+		if vote.VoterHash == "" {
+			return
+		}
+		if err := vc.removeParticipation(vote); err != nil {
+			log.Println("Error releasing voter hash: ", err)
+		}
+	}
+
+	for _, optionID := range vote.Options() {
+		ok, err := vc.ReserveOptionVote(vote.PollID, optionID, maxVotes[optionID])
 		if err != nil {
-			return votes, err
+			release()
+			return err
 		}
 
-		votes = append(votes, vote)
+		if !ok {
+			release()
+			return ErrOptionFull
+		}
+		reserved = append(reserved, optionID)
 	}
 
-	return votes, nil
-}
+	if setErr := vc.votes.Create(vote); setErr != nil {
+		release()
+		return setErr
+	}
 
-// Retrieve a single vote from the VotesCache by voteID.
-func (vc *VotesCache) GetVote(voteID uint) (Vote, error) {
-	var vote Vote
+	// The vote is stored either way; RebuildVoteIndex fixes a missed set.
+	if err := vc.indexVote(vote); err != nil {
+		log.Println("Error indexing vote: ", err)
+	}
 
-	redisKey := redisKeyFromId(voteID)
-	if err := vc.getItemFromRedis(redisKey, &vote); err != nil {
-		return Vote{}, errors.New("vote does not exist")
+	if err := vc.countWriteIn(vote, 1); err != nil {
+		log.Println("Error counting write-in: ", err)
 	}
 
-	return vote, nil
+	if vote.VoterHash != "" {
+		return nil
+	}
+
+	_, err := vc.addParticipation(vote)
+	return err
 }
 
-// Add a new vote to the VotesCache.
-func (vc *VotesCache) AddVote(vote Vote) error {
-	if _, err := vc.GetVote(vote.VoteID); err == nil {
-		return errors.New("vote already exists")
+// Flag a vote for review with the provided reason.
+func (vc *VotesCache) FlagVote(voteID uint, reason string) (Vote, error) {
+	vote, err := vc.GetVote(voteID)
+	if err != nil {
+		return Vote{}, err
 	}
 
-	redisKey := redisKeyFromId(vote.VoteID)
-	if _, setErr := vc.jsonHelper.JSONSet(redisKey, ".", vote); setErr != nil {
-		return setErr
+	now := time.Now().UTC()
+	vote.FlaggedAt = &now
+	vote.FlagReason = reason
+	vote.UpdatedAt = &now
+
+	if setErr := vc.votes.Put(vote); setErr != nil {
+		return Vote{}, setErr
 	}
 
-	return nil
+	return vote, nil
 }
 
 // Delete a single vote from the VotesCache by voteID.
 func (vc *VotesCache) DeleteVote(voteID uint) error {
-	if _, err := vc.GetVote(voteID); err != nil {
-		return errors.New("vote does not exist")
+	vote, err := vc.votes.Delete(voteID)
+	if err != nil {
+		return err
 	}
 
-	redisKey := redisKeyFromId(voteID)
-	if _, deleteErr := vc.jsonHelper.JSONDel(redisKey, "."); deleteErr != nil {
-		return deleteErr
+	for _, optionID := range vote.Options() {
+		if err := vc.ReleaseOptionVote(vote.PollID, optionID); err != nil {
+			return err
+		}
+	}
+
+	if err := vc.countWriteIn(vote, -1); err != nil {
+		return err
 	}
 
-	return nil
+	return vc.removeParticipation(vote)
+}
+
+// Set the creation and update times of the votes that have none to the
+// time voteTime returns for them, when it knows one. It returns the number
+// of votes changed.
+func (vc *VotesCache) BackfillVoteTimes(voteTime func(Vote) (time.Time, bool)) (int, error) {
+	backfilled := 0
+
+	err := vc.EachVote(func(vote Vote) error {
+		if vote.CreatedAt != nil {
+			return nil
+		}
+
+		createdAt, ok := voteTime(vote)
+		if !ok {
+			return nil
+		}
+
+		createdAt = createdAt.UTC()
+		vote.CreatedAt = &createdAt
+		if vote.UpdatedAt == nil {
+			vote.UpdatedAt = &createdAt
+		}
+		if vote.VoteDate == nil {
+			vote.VoteDate = &createdAt
+		}
+
+		if err := vc.votes.Put(vote); err != nil {
+			return err
+		}
+
+		backfilled++
+		return nil
+	})
+
+	return backfilled, err
 }
@@ -0,0 +1,106 @@
+package votes
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	VoteStatsKeyPrefix = "vote-stats:"
+
+	// How long the counter of a minute is kept, and so the longest window
+	// the stats cover.
+	VoteStatsRetention = 7 * 24 * time.Hour
+
+	// How many counters GetVoteStats reads in one MGET; a week of minutes
+	// is 10,080 keys.
+	VoteStatsBatchSize = 500
+)
+
+// VoteStatsBucket is the number of votes a poll received in a step of a
+// window, from Start.
+type VoteStatsBucket struct {
+	Start time.Time `json:"start"`
+	Votes uint      `json:"votes"`
+}
+
+// Get the key of the counter of the votes a poll received in the minute
+// of at, such as vote-stats:{1}:202610141230. The poll ID is the hash tag
+// of the key, so the counters of a poll share a cluster slot and one MGET
+// can read them.
+func voteStatsKey(pollID uint, at time.Time) string {
+	return fmt.Sprintf("%s{%d}:%s", VoteStatsKeyPrefix, pollID, at.UTC().Format("200601021504"))
+}
+
+// Count a vote received by a poll in the minute of at. The counters are
+// kept in redis with either storage backend and expire after
+// VoteStatsRetention. They count the votes as they come in, so deleting a
+// vote leaves them as they are.
+func (vc *VotesCache) CountVoteReceived(pollID uint, at time.Time) error {
+	key := voteStatsKey(pollID, at)
+
+	pipe := vc.cacheClient.TxPipeline()
+	pipe.Incr(vc.context, key)
+	pipe.Expire(vc.context, key, VoteStatsRetention)
+	_, err := pipe.Exec(vc.context)
+
+	return err
+}
+
+// Return the votes a poll received from from to to, in buckets of step
+// from from. The counters are read in MGETs of VoteStatsBatchSize minutes,
+// so the stats cost the same however many votes were cast.
+func (vc *VotesCache) GetVoteStats(pollID uint, from, to time.Time, step time.Duration) ([]VoteStatsBucket, error) {
+	from = from.UTC().Truncate(time.Minute)
+
+	keys := make([]string, 0, int(to.Sub(from)/time.Minute))
+	for minute := from; minute.Before(to); minute = minute.Add(time.Minute) {
+		keys = append(keys, voteStatsKey(pollID, minute))
+	}
+
+	buckets := make([]VoteStatsBucket, 0, int(to.Sub(from)/step)+1)
+	for start := from; start.Before(to); start = start.Add(step) {
+		buckets = append(buckets, VoteStatsBucket{Start: start})
+	}
+
+	if len(keys) == 0 {
+		return buckets, nil
+	}
+
+	minutesPerStep := int(step / time.Minute)
+	for first := 0; first < len(keys); first += VoteStatsBatchSize {
+		last := first + VoteStatsBatchSize
+		if last > len(keys) {
+			last = len(keys)
+		}
+
+		values, err := vc.cacheClient.MGet(vc.context, keys[first:last]...).Result()
+		if err != nil && err != redis.Nil {
+			return nil, err
+		}
+
+		for i, value := range values {
+			text, ok := value.(string)
+			if !ok {
+				continue
+			}
+
+			count, err := strconv.ParseUint(text, 10, 32)
+			if err != nil {
+				continue
+			}
+
+			buckets[(first+i)/minutesPerStep].Votes += uint(count)
+		}
+	}
+
+	return buckets, nil
+}
+
+// Delete the vote stats counters of every poll.
+func (vc *VotesCache) DeleteVoteStats() error {
+	return vc.deleteKeys(VoteStatsKeyPrefix)
+}
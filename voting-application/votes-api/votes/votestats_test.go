@@ -0,0 +1,24 @@
+package votes
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVoteStatsKeyHashTag(t *testing.T) {
+	at := time.Date(2026, 10, 14, 12, 30, 45, 0, time.FixedZone("EDT", -4*60*60))
+
+	if got, want := voteStatsKey(1, at), "vote-stats:{1}:202610141630"; got != want {
+		t.Fatalf("voteStatsKey = %s, want %s", got, want)
+	}
+
+	// Every minute of a poll has the hash tag of the poll, so one MGET
+	// reads them from one cluster slot.
+	for minute := 0; minute < 3; minute++ {
+		key := voteStatsKey(12, at.Add(time.Duration(minute)*24*time.Hour))
+		if !strings.HasPrefix(key, VoteStatsKeyPrefix+"{12}:") {
+			t.Fatalf("key %s does not have the hash tag {12}", key)
+		}
+	}
+}
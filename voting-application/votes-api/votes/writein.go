@@ -0,0 +1,52 @@
+package votes
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	TallyWriteInKeyPrefix = "tally:writein:"
+
+	MaxWriteInLength = 200
+)
+
+// Return the form write-ins are stored and grouped in: trimmed, with runs
+// of whitespace collapsed to one space and case-folded, so "  Jane  Doe"
+// and "jane doe" count together.
+func NormalizeWriteIn(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
+
+// Get the key of the write-in counts of a poll.
+func writeInKeyFromId(pollID uint) string {
+	return fmt.Sprintf("%s%d", TallyWriteInKeyPrefix, pollID)
+}
+
+// Change the count of the write-in of a vote by delta.
+func (vc *VotesCache) countWriteIn(vote Vote, delta int64) error {
+	if vote.WriteInValue == "" {
+		return nil
+	}
+
+	return vc.cacheClient.HIncrBy(vc.context, writeInKeyFromId(vote.PollID), vote.WriteInValue, delta).Err()
+}
+
+// Return the vote count of every write-in of a poll, by normalized text.
+func (vc *VotesCache) GetWriteInCounts(pollID uint) (map[string]uint, error) {
+	counts := make(map[string]uint)
+
+	values, err := vc.cacheClient.HGetAll(vc.context, writeInKeyFromId(pollID)).Result()
+	if err != nil {
+		return counts, err
+	}
+
+	for text, value := range values {
+		var count uint
+		if _, err := fmt.Sscan(value, &count); err == nil && count > 0 {
+			counts[text] = count
+		}
+	}
+
+	return counts, nil
+}